@@ -6,11 +6,17 @@
 package main
 
 import (
+	"errors"
 	"os"
 )
 
 func main() {
 	if err := Execute(); err != nil {
+		// strigoi run exits with the status of the command it launched
+		var exit *exitCodeError
+		if errors.As(err, &exit) {
+			os.Exit(exit.code)
+		}
 		os.Exit(1)
 	}
 }
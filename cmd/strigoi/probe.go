@@ -3,9 +3,11 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	_ "github.com/macawi-ai/strigoi/modules/probe" // Import for init registration
+	"github.com/macawi-ai/strigoi/pkg/engagement"
 	"github.com/macawi-ai/strigoi/pkg/modules"
 	"github.com/macawi-ai/strigoi/pkg/output"
 	"github.com/spf13/cobra"
@@ -120,6 +122,20 @@ func newProbeNorthCommand() *cobra.Command {
 				return
 			}
 
+			harFile, _ := cmd.Flags().GetString("har")
+			if err := module.SetOption("har", harFile); err != nil {
+				errorColor.Printf("[-] Failed to set har: %v\n", err)
+				return
+			}
+
+			// Install the shared engagement scheduler
+			closeEngagement, err := configureEngagement(cmd)
+			if err != nil {
+				errorColor.Printf("[-] Failed to configure engagement: %v\n", err)
+				return
+			}
+			defer closeEngagement()
+
 			// Run the module
 			if verbose {
 				fmt.Println(infoColor.Sprint("[*] Verbose mode enabled"))
@@ -132,7 +148,7 @@ func newProbeNorthCommand() *cobra.Command {
 			}
 
 			// Only print status messages if not JSON output
-			if outputFormat != "json" {
+			if outputFormat != "json" && outputFormat != "html" {
 				fmt.Println(successColor.Sprint("[+] Starting endpoint discovery..."))
 			}
 
@@ -217,6 +233,7 @@ var probeSouthCmd = &cobra.Command{
 		outputFormat, _ := cmd.Flags().GetString("output")
 		scanMCP, _ := cmd.Flags().GetBool("scan-mcp")
 		includeSelf, _ := cmd.Flags().GetBool("include-self")
+		rulePacks, _ := cmd.Flags().GetStringSlice("rules")
 
 		// Load modules if not already loaded
 		_ = modules.LoadBuiltins(nil)
@@ -246,13 +263,19 @@ var probeSouthCmd = &cobra.Command{
 			return
 		}
 
+		// Extra rule packs for MCP scanning
+		if err := module.SetOption("rules", strings.Join(rulePacks, ",")); err != nil {
+			errorColor.Printf("[-] Failed to set rules: %v\n", err)
+			return
+		}
+
 		// Run the module
 		if verbose {
 			fmt.Printf("%s Target: %s\n", infoColor.Sprint("[*]"), target)
 		}
 
 		// Only print status messages if not JSON output
-		if outputFormat != "json" {
+		if outputFormat != "json" && outputFormat != "html" {
 			fmt.Println(successColor.Sprint("[+] Analyzing dependencies..."))
 		}
 
@@ -302,8 +325,19 @@ var probeSouthCmd = &cobra.Command{
 var probeEastCmd = &cobra.Command{
 	Use:   "east [target]",
 	Short: "Probe east direction (data flows)",
-	Long:  `Trace data flows, API integrations, and information leakage.`,
-	Args:  cobra.MaximumNArgs(1),
+	Long: `Trace data flows, API integrations, and information leakage.
+
+Go source is analyzed through its syntax tree and Python/JavaScript through a
+tokenizer. Values are followed from sources (environment variables, request
+bodies, file reads) through assignments to sinks (OpenAI/Anthropic SDK calls,
+outbound HTTP requests, logging), and each source-to-sink path is reported with
+its file:line hops.
+
+String literals that reach an LLM call are classified for personal and
+regulated data (emails, phone numbers, national IDs, IBANs, card numbers,
+health terms, addresses). Findings are tagged GDPR, HIPAA or PCI-DSS and
+counted per destination provider.`,
+	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		target := "./"
 		if len(args) > 0 {
@@ -342,7 +376,7 @@ var probeEastCmd = &cobra.Command{
 		}
 
 		// Only print status messages if not JSON output
-		if outputFormat != "json" {
+		if outputFormat != "json" && outputFormat != "html" {
 			fmt.Println(successColor.Sprint("[+] Tracing data flows..."))
 		}
 
@@ -444,6 +478,46 @@ var probeWestCmd = &cobra.Command{
 			return
 		}
 
+		// Set request pacing
+		rateLimit, _ := cmd.Flags().GetFloat64("rate-limit")
+		if err := module.SetOption("rate_limit", fmt.Sprintf("%v", rateLimit)); err != nil {
+			errorColor.Printf("[-] Failed to set rate_limit: %v\n", err)
+			return
+		}
+
+		maxConcurrent, _ := cmd.Flags().GetInt("max-concurrent")
+		if err := module.SetOption("max_concurrent", fmt.Sprintf("%d", maxConcurrent)); err != nil {
+			errorColor.Printf("[-] Failed to set max_concurrent: %v\n", err)
+			return
+		}
+
+		harFile, _ := cmd.Flags().GetString("har")
+		if err := module.SetOption("har", harFile); err != nil {
+			errorColor.Printf("[-] Failed to set har: %v\n", err)
+			return
+		}
+
+		// Set token and OAuth analysis inputs
+		for flag, option := range map[string]string{
+			"token":        "token",
+			"client-id":    "oauth_client_id",
+			"redirect-uri": "oauth_redirect_uri",
+		} {
+			value, _ := cmd.Flags().GetString(flag)
+			if err := module.SetOption(option, value); err != nil {
+				errorColor.Printf("[-] Failed to set %s: %v\n", option, err)
+				return
+			}
+		}
+
+		// Install the shared engagement scheduler
+		closeEngagement, err := configureEngagement(cmd)
+		if err != nil {
+			errorColor.Printf("[-] Failed to configure engagement: %v\n", err)
+			return
+		}
+		defer closeEngagement()
+
 		// Run the module
 		if verbose {
 			fmt.Printf("%s Target: %s\n", infoColor.Sprint("[*]"), target)
@@ -454,7 +528,7 @@ var probeWestCmd = &cobra.Command{
 		}
 
 		// Only print status messages if not JSON output
-		if outputFormat != "json" {
+		if outputFormat != "json" && outputFormat != "html" {
 			fmt.Println(successColor.Sprint("[+] Testing authentication boundaries..."))
 		}
 
@@ -514,7 +588,7 @@ var probeAllCmd = &cobra.Command{
 		severity, _ := cmd.Flags().GetStringSlice("severity")
 
 		// Only print status messages if not JSON output
-		if outputFormat != "json" && outputFormat != "yaml" {
+		if outputFormat != "json" && outputFormat != "yaml" && outputFormat != "html" {
 			fmt.Println(successColor.Sprint("[+] Probing all directions..."))
 		}
 
@@ -536,27 +610,36 @@ var probeAllCmd = &cobra.Command{
 			}
 		}
 
+		// Pass engagement flags to the network probes
+		for _, probeCmd := range []*cobra.Command{probeNorthCmd, probeWestCmd} {
+			for _, name := range []string{"scope", "audit-log", "global-rps", "per-host-concurrency", "har"} {
+				if flag := cmd.Flags().Lookup(name); flag != nil && flag.Changed {
+					_ = probeCmd.Flags().Set(name, flag.Value.String())
+				}
+			}
+		}
+
 		// Run NORTH with target for AI config discovery
-		if outputFormat != "json" && outputFormat != "yaml" {
+		if outputFormat != "json" && outputFormat != "yaml" && outputFormat != "html" {
 			fmt.Printf("\n%s %s\n", warnColor.Sprint("→"), "NORTH")
 		}
 		probeNorthCmd.Run(probeNorthCmd, []string{target})
 
 		// Run SOUTH with target directory
-		if outputFormat != "json" && outputFormat != "yaml" {
+		if outputFormat != "json" && outputFormat != "yaml" && outputFormat != "html" {
 			fmt.Printf("\n%s %s\n", warnColor.Sprint("→"), "SOUTH")
 		}
 		probeSouthCmd.Run(probeSouthCmd, []string{target})
 
 		// Run EAST with target directory
-		if outputFormat != "json" && outputFormat != "yaml" {
+		if outputFormat != "json" && outputFormat != "yaml" && outputFormat != "html" {
 			fmt.Printf("\n%s %s\n", warnColor.Sprint("→"), "EAST")
 		}
 		probeEastCmd.Run(probeEastCmd, []string{target})
 
 		// Run WEST with special handling
 		// For probe all, always allow private scanning
-		if outputFormat != "json" && outputFormat != "yaml" {
+		if outputFormat != "json" && outputFormat != "yaml" && outputFormat != "html" {
 			fmt.Printf("\n%s %s\n", warnColor.Sprint("→"), "WEST")
 		}
 		// Temporarily set the allow-private flag for West
@@ -583,12 +666,21 @@ func init() {
 
 	// Common flags for all probe commands
 	for _, cmd := range []*cobra.Command{probeNorthCmd, probeSouthCmd, probeEastCmd, probeWestCmd, probeAllCmd} {
-		cmd.Flags().StringP("output", "o", "pretty", "Output format (pretty, json, yaml, markdown)")
+		cmd.Flags().StringP("output", "o", "pretty", "Output format (pretty, json, html, yaml, markdown)")
 		cmd.Flags().StringP("timeout", "t", "30s", "Timeout for probe operations")
 		cmd.Flags().Bool("no-color", false, "Disable colored output")
 		cmd.Flags().StringSlice("severity", nil, "Filter by severity (critical, high, medium, low, info)")
 	}
 
+	// Engagement flags for network probes
+	for _, cmd := range []*cobra.Command{probeNorthCmd, probeWestCmd, probeAllCmd} {
+		cmd.Flags().String("scope", "", "Engagement scope file (JSON) restricting hosts, paths and time windows")
+		cmd.Flags().String("audit-log", "", "Append every outbound request to this JSONL audit log")
+		cmd.Flags().Float64("global-rps", 0, "Global requests-per-second budget shared by all probes (0 = module default)")
+		cmd.Flags().Int("per-host-concurrency", 0, "Maximum in-flight requests per host (0 = module default)")
+		cmd.Flags().String("har", "", "Write redacted request/response evidence to this HAR 1.2 file")
+	}
+
 	// Special flags for north
 	probeNorthCmd.Flags().Bool("follow-redirects", true, "Follow HTTP redirects")
 	probeNorthCmd.Flags().StringSlice("headers", nil, "Custom headers for HTTP requests")
@@ -604,6 +696,7 @@ func init() {
 	// Special flags for south
 	probeSouthCmd.Flags().Bool("scan-mcp", false, "Enable MCP tools scanning")
 	probeSouthCmd.Flags().Bool("include-self", false, "Include Strigoi's own files and processes in scan")
+	probeSouthCmd.Flags().StringSlice("rules", nil, "Additional rule pack files (JSON or YAML) for MCP scanning")
 
 	// Progressive disclosure flags for south
 	probeSouthCmd.Flags().Bool("brief", false, "Show brief one-line help")
@@ -618,4 +711,50 @@ func init() {
 	probeWestCmd.Flags().Float64("rate-limit", 10.0, "Requests per second")
 	probeWestCmd.Flags().Int("max-concurrent", 5, "Maximum concurrent requests")
 	probeWestCmd.Flags().Bool("allow-private", false, "Allow scanning private/local addresses")
+	probeWestCmd.Flags().String("token", "", "JWT to analyze and use as a template for forged-token tests")
+	probeWestCmd.Flags().String("client-id", "", "OAuth client ID for authorization endpoint tests")
+	probeWestCmd.Flags().String("redirect-uri", "", "Registered OAuth redirect URI for authorization endpoint tests")
+}
+
+// configureEngagement installs the shared engagement scheduler from the
+// scope and pacing flags. The returned function closes the audit log.
+func configureEngagement(cmd *cobra.Command) (func(), error) {
+	scopeFile, _ := cmd.Flags().GetString("scope")
+	auditFile, _ := cmd.Flags().GetString("audit-log")
+	rps, _ := cmd.Flags().GetFloat64("global-rps")
+	perHost, _ := cmd.Flags().GetInt("per-host-concurrency")
+
+	// Without engagement flags each module paces itself
+	if scopeFile == "" && auditFile == "" && rps <= 0 && perHost <= 0 {
+		engagement.SetDefault(nil)
+		return func() {}, nil
+	}
+
+	cfg := engagement.SchedulerConfig{
+		RequestsPerSecond:  rps,
+		PerHostConcurrency: perHost,
+	}
+
+	if scopeFile != "" {
+		scope, err := engagement.LoadScope(scopeFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Scope = scope
+	}
+
+	if auditFile != "" {
+		audit, err := engagement.OpenAuditLog(auditFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.AuditLog = audit
+	}
+
+	engagement.SetDefault(engagement.NewScheduler(cfg))
+
+	return func() {
+		engagement.SetDefault(nil)
+		_ = cfg.AuditLog.Close()
+	}, nil
 }
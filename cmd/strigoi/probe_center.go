@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -20,7 +21,15 @@ var (
 	centerBufferSize   int
 	centerPollInterval int
 	centerShowActivity bool
-	centerEnableStrace bool
+	centerEnablePtrace bool
+	centerMCPManifests string
+	centerFollow       bool
+	centerChildPattern string
+	centerReassembly   int
+	centerReassemblyMs int
+	centerStateDirs    []string
+	centerDissectors   string
+	centerDissectorDef []string
 )
 
 var probeCenterCmd = &cobra.Command{
@@ -33,8 +42,19 @@ tokens, and other sensitive data flowing through process streams.
 Key capabilities:
 - Real-time stream monitoring at user privilege level
 - Multi-protocol analysis (JSON, SQL, plaintext)
+- LLM API dissection (OpenAI, Anthropic, Ollama) with streamed responses
+  reassembled: leaked system prompts, plaintext API keys, shell tool calls
 - Credential and secret detection
+- With --enable-ptrace, sockets, pipes and files tracked as separate streams,
+  each attributed with its type, peer and direction
+- PII classification of request bodies sent to LLM APIs, tagged GDPR/HIPAA/PCI-DSS
 - Live terminal display with vulnerability alerts
+- Process-tree following: MCP servers spawned by a host (npx → node,
+  uv → python) are attached to as they start and detached when they exit
+- Container targets: every process of a Docker, containerd, CRI-O or Podman
+  container, or of a Kubernetes pod, resolved from /proc without a runtime API
+- Dissectors selectable by name, plus text and JSON protocols defined in
+  YAML or JSON files loaded at startup (~/.strigoi/dissectors by default)
 - Structured logging for forensic analysis`,
 	Example: `  # Monitor a process by name
   strigoi probe center --target nginx
@@ -42,9 +62,23 @@ Key capabilities:
   # Monitor by PID with custom output
   strigoi probe center --target 12345 --output vulns.jsonl
 
+  # Monitor whatever listens on a port or unix socket
+  strigoi probe center --target port:3000
+  strigoi probe center --target unix:/run/mcp/server.sock
+
+  # Monitor every process in a container or Kubernetes pod
+  strigoi probe center --target container:mcp-gateway
+  strigoi probe center --target pod:ai/model-gateway-7d9f8
+
   # Monitor with filter and duration limit
   strigoi probe center --target mysql --filter "password|token" --duration 1h
 
+  # Follow the servers an MCP host spawns, attaching only to node and python
+  strigoi probe center --target mcp-host --follow-children --child-pattern 'node|python'
+
+  # Skip the SQL and plain-text dissectors, and load in-house protocol definitions
+  strigoi probe center --target rpc-gateway --dissectors -sql,-plaintext --dissector-defs ./dissectors
+
   # Monitor without terminal UI (log only)
   strigoi probe center --target api-server --no-display`,
 	RunE: runProbeCenter,
@@ -52,7 +86,7 @@ Key capabilities:
 
 func init() {
 	// Target specification
-	probeCenterCmd.Flags().StringVarP(&centerTarget, "target", "t", "", "Process name or PID to monitor (required)")
+	probeCenterCmd.Flags().StringVarP(&centerTarget, "target", "t", "", "Process name, PID, port:<n>, unix:<path>, container:<id|name> or pod:<namespace>/<name> to monitor (required)")
 	_ = probeCenterCmd.MarkFlagRequired("target")
 
 	// Monitoring options
@@ -64,12 +98,30 @@ func init() {
 	// Performance tuning
 	probeCenterCmd.Flags().IntVar(&centerBufferSize, "buffer-size", 64, "Buffer size per stream in KB")
 	probeCenterCmd.Flags().IntVar(&centerPollInterval, "poll-interval", 10, "Stream polling interval in milliseconds")
+	probeCenterCmd.Flags().IntVar(&centerReassembly, "reassembly-limit", 1024, "Largest partial message held per stream until it completes, in KB")
+	probeCenterCmd.Flags().IntVar(&centerReassemblyMs, "reassembly-timeout", 1000, "How long a partial message waits to complete before it is analyzed, in milliseconds")
 
 	// Activity display
 	probeCenterCmd.Flags().BoolVar(&centerShowActivity, "show-activity", false, "Show stream activity even when no vulnerabilities detected")
 
-	// Strace fallback
-	probeCenterCmd.Flags().BoolVar(&centerEnableStrace, "enable-strace", false, "Enable strace fallback for PTY capture (requires permissions, impacts performance)")
+	// Native ptrace fallback; --enable-strace is the old name
+	probeCenterCmd.Flags().BoolVar(&centerEnablePtrace, "enable-ptrace", false, "Enable ptrace capture fallback for PTY traffic and per-descriptor sockets, pipes and files (requires permissions, impacts performance)")
+	probeCenterCmd.Flags().BoolVar(&centerEnablePtrace, "enable-strace", false, "Enable ptrace capture fallback")
+	_ = probeCenterCmd.Flags().MarkDeprecated("enable-strace", "use --enable-ptrace; capture no longer needs the strace binary")
+
+	// Process-tree following
+	probeCenterCmd.Flags().BoolVar(&centerFollow, "follow-children", false, "Attach to new descendants of the target as they start and detach when they exit")
+	probeCenterCmd.Flags().StringVar(&centerChildPattern, "child-pattern", "", "Only follow descendants whose name or command line matches this regex")
+
+	// Container targets
+	probeCenterCmd.Flags().StringSliceVar(&centerStateDirs, "runtime-state-dir", nil, "Container runtime state directory to read names and pod labels from (repeatable; default: standard Docker, containerd, CRI-O and Podman locations)")
+
+	// Protocol dissectors
+	probeCenterCmd.Flags().StringVar(&centerDissectors, "dissectors", "all", "Comma-separated dissectors to enable (llm, http, grpc, websocket, mcp, json, sql, plaintext and defined ones), or -name to disable")
+	probeCenterCmd.Flags().StringSliceVar(&centerDissectorDef, "dissector-defs", nil, "File or directory of declarative dissector definitions in YAML or JSON (repeatable; default ~/.strigoi/dissectors)")
+
+	// MCP rug-pull detection
+	probeCenterCmd.Flags().StringVar(&centerMCPManifests, "mcp-manifests", "", "File of approved MCP tool manifests (default ~/.strigoi/mcp_manifests.json)")
 
 	// Note: For real-time visualization, use GoScope (github.com/macawi-ai/GoScope)
 	// A clean Go-native alternative without Qt dependencies
@@ -115,11 +167,40 @@ func runProbeCenter(cmd *cobra.Command, _ []string) error {
 	if err := centerModule.SetOption("poll-interval", fmt.Sprintf("%d", centerPollInterval)); err != nil {
 		return fmt.Errorf("failed to set poll-interval: %w", err)
 	}
+	if err := centerModule.SetOption("reassembly-limit", fmt.Sprintf("%d", centerReassembly)); err != nil {
+		return fmt.Errorf("failed to set reassembly-limit: %w", err)
+	}
+	if err := centerModule.SetOption("reassembly-timeout", fmt.Sprintf("%d", centerReassemblyMs)); err != nil {
+		return fmt.Errorf("failed to set reassembly-timeout: %w", err)
+	}
 	if err := centerModule.SetOption("show-activity", fmt.Sprintf("%t", centerShowActivity)); err != nil {
 		return fmt.Errorf("failed to set show-activity: %w", err)
 	}
-	if err := centerModule.SetOption("enable-strace", fmt.Sprintf("%t", centerEnableStrace)); err != nil {
-		return fmt.Errorf("failed to set enable-strace: %w", err)
+	if err := centerModule.SetOption("enable-ptrace", fmt.Sprintf("%t", centerEnablePtrace)); err != nil {
+		return fmt.Errorf("failed to set enable-ptrace: %w", err)
+	}
+	if cmd.Flags().Changed("runtime-state-dir") {
+		if err := centerModule.SetOption("runtime-state-dirs", strings.Join(centerStateDirs, ",")); err != nil {
+			return fmt.Errorf("failed to set runtime-state-dirs: %w", err)
+		}
+	}
+	if err := centerModule.SetOption("dissectors", centerDissectors); err != nil {
+		return fmt.Errorf("failed to set dissectors: %w", err)
+	}
+	if err := centerModule.SetOption("dissector-defs", strings.Join(centerDissectorDef, ",")); err != nil {
+		return fmt.Errorf("failed to set dissector-defs: %w", err)
+	}
+	if err := centerModule.SetOption("mcp-manifests", centerMCPManifests); err != nil {
+		return fmt.Errorf("failed to set mcp-manifests: %w", err)
+	}
+	if centerChildPattern != "" && !centerFollow {
+		return fmt.Errorf("--child-pattern requires --follow-children")
+	}
+	if err := centerModule.SetOption("follow-children", fmt.Sprintf("%t", centerFollow)); err != nil {
+		return fmt.Errorf("failed to set follow-children: %w", err)
+	}
+	if err := centerModule.SetOption("child-pattern", centerChildPattern); err != nil {
+		return fmt.Errorf("failed to set child-pattern: %w", err)
 	}
 
 	// Check if module can run
@@ -142,8 +223,15 @@ func runProbeCenter(cmd *cobra.Command, _ []string) error {
 	} else {
 		fmt.Printf("  Mode: Interactive terminal UI\n")
 	}
-	if centerEnableStrace {
-		fmt.Printf("  \033[33mStrace: Enabled (performance impact)\033[0m\n")
+	if centerEnablePtrace {
+		fmt.Printf("  \033[33mPtrace: Enabled (performance impact)\033[0m\n")
+	}
+	if centerFollow {
+		if centerChildPattern != "" {
+			fmt.Printf("  Children: following those matching %s\n", centerChildPattern)
+		} else {
+			fmt.Printf("  Children: following all descendants\n")
+		}
 	}
 	fmt.Println()
 
@@ -180,6 +268,17 @@ func runProbeCenter(cmd *cobra.Command, _ []string) error {
 			fmt.Printf("\n✓ Stream monitoring completed - no vulnerabilities detected\n")
 		}
 
+		// State how much of the traffic the findings are based on
+		if stats, ok := result.Data["statistics"].(map[string]interface{}); ok {
+			if seen, _ := stats["bytes_seen"].(int64); seen > 0 {
+				if coverage, ok := stats["coverage_percent"].(*float64); ok && coverage != nil {
+					fmt.Printf("  Traffic observed: %.1f%% of %d bytes seen\n", *coverage, seen)
+				} else {
+					fmt.Printf("  Traffic observed: unknown, %d bytes read (pipes and terminals do not show what was written)\n", seen)
+				}
+			}
+		}
+
 		// Get output format from parent command flags
 		outputFormat, _ := cmd.Parent().Flags().GetString("output")
 
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/macawi-ai/strigoi/modules/probe"
+	"github.com/macawi-ai/strigoi/pkg/output"
+	"github.com/macawi-ai/strigoi/pkg/security"
+)
+
+var probeMCPCmd = &cobra.Command{
+	Use:   "mcp <config-entry|url>",
+	Short: "Interrogate a live MCP server",
+	Long: `Connect to an MCP server and ask it what it offers. A config entry is
+looked up in the mcpServers map of an MCP client configuration and its command
+is launched in a sandboxed child process; a URL is contacted over the
+Streamable HTTP transport.
+
+The probe performs the initialize handshake, lists tools, resources and
+prompts, records the declared capabilities, protocol version and
+authentication requirements, and runs the MCP rule engine over the results.
+
+With --approve, the tools the server lists are recorded as its approved
+manifest, so center and run stop reporting a tool change you have reviewed as
+a rug pull.
+
+This is an active probe: launching a configured server executes its code.`,
+	Example: `  # Interrogate a server from ./.mcp.json or the user's client configs
+  strigoi probe mcp filesystem
+
+  # Use a specific client configuration
+  strigoi probe mcp github --config ~/.cursor/mcp.json
+
+  # Interrogate a remote server with a token
+  strigoi probe mcp https://mcp.example.com/mcp --header "Authorization: Bearer $TOKEN"
+
+  # Accept the server's current tools after reviewing a rug-pull alert
+  strigoi probe mcp filesystem --approve`,
+	Args: cobra.ExactArgs(1),
+	RunE: runProbeMCP,
+}
+
+func init() {
+	probeMCPCmd.Flags().String("config", "", "MCP client configuration containing the server entry (default: search known locations)")
+	probeMCPCmd.Flags().StringSlice("header", nil, "Extra HTTP header for remote servers (\"Name: value\")")
+	probeMCPCmd.Flags().StringP("output", "o", "pretty", "Output format (pretty, json, html, yaml, markdown)")
+	probeMCPCmd.Flags().StringP("timeout", "t", "30s", "Timeout for the interrogation")
+	probeMCPCmd.Flags().Bool("no-color", false, "Disable colored output")
+	probeMCPCmd.Flags().StringSlice("severity", nil, "Filter by severity (critical, high, medium, low, info)")
+	probeMCPCmd.Flags().StringSlice("rules", nil, "Additional rule pack files (JSON or YAML)")
+	probeMCPCmd.Flags().Bool("approve", false, "Record the server's current tools as its approved manifest")
+	probeMCPCmd.Flags().String("mcp-manifests", "", "File of approved MCP tool manifests (default ~/.strigoi/mcp_manifests.json)")
+	probeMCPCmd.Flags().String("scope", "", "Engagement scope file (JSON) restricting hosts, paths and time windows")
+	probeMCPCmd.Flags().String("audit-log", "", "Append every outbound request to this JSONL audit log")
+	probeMCPCmd.Flags().Float64("global-rps", 0, "Global requests-per-second budget for remote servers (0 = unlimited)")
+	probeMCPCmd.Flags().Int("per-host-concurrency", 0, "Maximum in-flight requests per host (0 = unlimited)")
+
+	probeCmd.AddCommand(probeMCPCmd)
+}
+
+func runProbeMCP(cmd *cobra.Command, args []string) error {
+	configPath, _ := cmd.Flags().GetString("config")
+	headers, _ := cmd.Flags().GetStringSlice("header")
+	outputFormat, _ := cmd.Flags().GetString("output")
+	timeoutStr, _ := cmd.Flags().GetString("timeout")
+	noColor, _ := cmd.Flags().GetBool("no-color")
+	severityFilter, _ := cmd.Flags().GetStringSlice("severity")
+	rulePacks, _ := cmd.Flags().GetStringSlice("rules")
+	approve, _ := cmd.Flags().GetBool("approve")
+	manifestPath, _ := cmd.Flags().GetString("mcp-manifests")
+
+	timeout, err := time.ParseDuration(timeoutStr)
+	if err != nil {
+		return fmt.Errorf("invalid timeout: %w", err)
+	}
+
+	target, err := security.ResolveMCPTarget(args[0], configPath)
+	if err != nil {
+		return err
+	}
+	for _, header := range headers {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok {
+			return fmt.Errorf("invalid header %q: expected \"Name: value\"", header)
+		}
+		if target.Headers == nil {
+			target.Headers = make(map[string]string)
+		}
+		target.Headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+
+	if outputFormat != "json" && outputFormat != "html" && outputFormat != "yaml" {
+		if target.URL != "" {
+			fmt.Println(successColor.Sprintf("[+] Interrogating %s...", target.URL))
+		} else {
+			fmt.Println(successColor.Sprintf("[+] Launching %s (%s) in a sandbox...", target.Name, target.Command))
+		}
+	}
+
+	// Remote servers are contacted through the shared engagement scheduler
+	closeEngagement, err := configureEngagement(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to configure engagement: %w", err)
+	}
+	defer closeEngagement()
+
+	scanner := security.NewMCPScanner(security.NewSecureExecutor())
+	if err := scanner.LoadRules(rulePacks); err != nil {
+		return fmt.Errorf("failed to load rules: %w", err)
+	}
+	result, err := scanner.Interrogate(context.Background(), *target, timeout)
+	if err != nil {
+		return fmt.Errorf("MCP interrogation failed: %w", err)
+	}
+
+	if approve {
+		if manifestPath == "" {
+			manifestPath = probe.DefaultMCPManifestPath()
+		}
+		if err := approveMCPManifest(manifestPath, result); err != nil {
+			return err
+		}
+	}
+
+	standardOutput := output.StandardOutput{
+		Module:    "probe/mcp",
+		Target:    result.Location(),
+		Timestamp: result.Timestamp,
+		Duration:  result.Duration,
+		Results:   output.ConvertMCPInterrogation(result),
+	}
+	standardOutput.Summary = output.ExtractSummaryFromResults(standardOutput.Results)
+
+	verbosity := "normal"
+	if verbose {
+		verbosity = "verbose"
+	}
+	formatted, err := output.FormatOutput(standardOutput, outputFormat, verbosity, noColor, severityFilter)
+	if err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+
+	fmt.Print(formatted)
+	return nil
+}
+
+// approveMCPManifest records the interrogated server's tools as its approved
+// manifest in the store at path.
+func approveMCPManifest(path string, result *security.MCPInterrogation) error {
+	target := result.Target
+	key := probe.MCPServerKey(target.Command, target.Args, target.URL)
+	if key == "" {
+		return fmt.Errorf("cannot approve tools: the server has no command or URL")
+	}
+	name := result.ServerName
+	if name == "" {
+		name = target.Name
+	}
+
+	store, err := probe.NewMCPManifestStore(path)
+	if err != nil {
+		return err
+	}
+	tools := make([]probe.MCPTool, 0, len(result.Tools))
+	for _, tool := range result.Tools {
+		tools = append(tools, probe.MCPTool{Name: tool.Name, Description: tool.Description, InputSchema: tool.InputSchema})
+	}
+	if err := store.Approve(key, result.ServerName, tools); err != nil {
+		return fmt.Errorf("failed to approve tools: %w", err)
+	}
+
+	fmt.Fprintln(os.Stderr, successColor.Sprintf("[+] Approved %d tools of %s in %s", len(tools), name, path))
+	return nil
+}
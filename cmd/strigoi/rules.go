@@ -0,0 +1,269 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/macawi-ai/strigoi/pkg/security"
+)
+
+var rulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Inspect, lint and test MCP scanning rules",
+	Long: `Work with the rules used by MCP scanning (probe south --scan-mcp and
+probe mcp). The built-in rules form a versioned rule pack; additional packs are
+JSON or YAML files passed with --rules:
+
+  name: my-org
+  version: 1.2.0
+  rules:
+    - id: ORG-001
+      name: Staging Endpoint
+      category: insecure_config
+      severity: medium
+      pattern: '(?i)staging\.example\.com'
+      file_types: [.json, .yaml]
+      description: Staging endpoint configured
+      remediation: Point production clients at production
+      examples:
+        positive: ["url: https://staging.example.com/mcp"]
+        negative: ["url: https://example.com/mcp"]
+
+A plain JSON array of rules is also accepted. Rules with the ID of a loaded
+rule replace it.`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		_ = cmd.Help()
+	},
+}
+
+var rulesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List loaded rule packs and rules",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		engine, err := loadRuleEngine(cmd)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s Rule packs:\n", infoColor.Sprint("[*]"))
+		for _, pack := range engine.Packs() {
+			fmt.Printf("  %s %s  %s  %s\n",
+				cmdColor.Sprintf("%-20s", pack.Name),
+				fmt.Sprintf("%-12s", pack.Version),
+				grayColor.Sprint(pack.Checksum),
+				grayColor.Sprintf("(%d rules)", pack.Rules))
+		}
+		fmt.Println()
+
+		rules := engine.Rules()
+		fmt.Printf("%s Rules (%d):\n", infoColor.Sprint("[*]"), len(rules))
+		for _, rule := range rules {
+			fmt.Printf("  %s %s %s %s\n",
+				cmdColor.Sprintf("%-18s", rule.ID),
+				severityColor(rule.Severity).Sprintf("%-9s", rule.Severity),
+				grayColor.Sprintf("%-12s", rule.Pack),
+				rule.Name)
+		}
+		return nil
+	},
+}
+
+var rulesShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show a rule in detail",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		engine, err := loadRuleEngine(cmd)
+		if err != nil {
+			return err
+		}
+
+		rule, ok := engine.Rule(args[0])
+		if !ok {
+			return fmt.Errorf("no rule with ID %s", args[0])
+		}
+
+		fmt.Printf("\n%s %s\n", successColor.Sprint("Rule:"), cmdColor.Sprint(rule.ID))
+		fmt.Printf("%s %s\n", successColor.Sprint("Name:"), rule.Name)
+		fmt.Println(strings.Repeat("─", 60))
+		fmt.Printf("%s %s\n", infoColor.Sprint("Severity:"), severityColor(rule.Severity).Sprint(rule.Severity))
+		fmt.Printf("%s %s\n", infoColor.Sprint("Category:"), rule.Category)
+		fmt.Printf("%s %s\n", infoColor.Sprint("Pack:"), rule.Pack)
+		fmt.Printf("%s %s\n", infoColor.Sprint("Pattern:"), rule.Pattern)
+		if len(rule.FileTypes) > 0 {
+			fmt.Printf("%s %s\n", infoColor.Sprint("File types:"), strings.Join(rule.FileTypes, ", "))
+		}
+		if rule.PathPattern != "" {
+			fmt.Printf("%s %s\n", infoColor.Sprint("Path pattern:"), rule.PathPattern)
+		}
+		fmt.Printf("\n%s\n%s\n", infoColor.Sprint("Description:"), rule.Description)
+		fmt.Printf("\n%s\n%s\n", infoColor.Sprint("Remediation:"), rule.Remediation)
+		if len(rule.References) > 0 {
+			fmt.Printf("\n%s\n", infoColor.Sprint("References:"))
+			for _, ref := range rule.References {
+				fmt.Printf("  • %s\n", ref)
+			}
+		}
+		if len(rule.Examples.Positive)+len(rule.Examples.Negative) > 0 {
+			fmt.Printf("\n%s\n", infoColor.Sprint("Examples:"))
+			for _, example := range rule.Examples.Positive {
+				fmt.Printf("  %s %s\n", errorColor.Sprint("match   "), example)
+			}
+			for _, example := range rule.Examples.Negative {
+				fmt.Printf("  %s %s\n", successColor.Sprint("no match"), example)
+			}
+		}
+		fmt.Println()
+		return nil
+	},
+}
+
+var rulesLintCmd = &cobra.Command{
+	Use:   "lint [file...]",
+	Short: "Check rules for invalid patterns, severities and file types",
+	Long: `Lint rule pack files, or the loaded rules when no file is given. Errors
+(rules that cannot load) make the command fail; warnings do not.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sets, err := ruleSets(cmd, args)
+		if err != nil {
+			return err
+		}
+
+		errors, warnings := 0, 0
+		for _, set := range sets {
+			fmt.Printf("%s %s\n", infoColor.Sprint("[*]"), set.name)
+			for _, issue := range security.LintRules(set.rules) {
+				if issue.Level == "error" {
+					errors++
+					fmt.Printf("  %s %s: %s\n", errorColor.Sprint("error  "), issue.RuleID, issue.Message)
+				} else {
+					warnings++
+					fmt.Printf("  %s %s: %s\n", warnColor.Sprint("warning"), issue.RuleID, issue.Message)
+				}
+			}
+		}
+
+		fmt.Printf("\n%d errors, %d warnings\n", errors, warnings)
+		if errors > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("lint found %d errors", errors)
+		}
+		return nil
+	},
+}
+
+var rulesTestCmd = &cobra.Command{
+	Use:   "test [file...]",
+	Short: "Run the positive and negative examples embedded in rules",
+	Long: `Check that every positive example matches its rule's pattern and no
+negative example does, for rule pack files or the loaded rules when no file is
+given.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sets, err := ruleSets(cmd, args)
+		if err != nil {
+			return err
+		}
+
+		passed, failed, untested := 0, 0, 0
+		for _, set := range sets {
+			fmt.Printf("%s %s\n", infoColor.Sprint("[*]"), set.name)
+			for _, result := range security.RunRuleExamples(set.rules) {
+				switch {
+				case result.Examples == 0:
+					untested++
+					fmt.Printf("  %s %s\n", grayColor.Sprint("SKIP"), result.RuleID)
+				case result.Passed():
+					passed++
+					fmt.Printf("  %s %s %s\n", successColor.Sprint("PASS"), result.RuleID, grayColor.Sprintf("(%d examples)", result.Examples))
+				default:
+					failed++
+					fmt.Printf("  %s %s\n", errorColor.Sprint("FAIL"), result.RuleID)
+					for _, failure := range result.Failures {
+						fmt.Printf("       %s\n", failure)
+					}
+				}
+			}
+		}
+
+		fmt.Printf("\n%d passed, %d failed, %d without examples\n", passed, failed, untested)
+		if failed > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%d rules failed their examples", failed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rulesCmd.PersistentFlags().StringSlice("rules", nil, "Additional rule pack files (JSON or YAML) to load")
+
+	rootCmd.AddCommand(rulesCmd)
+	rulesCmd.AddCommand(rulesListCmd)
+	rulesCmd.AddCommand(rulesShowCmd)
+	rulesCmd.AddCommand(rulesLintCmd)
+	rulesCmd.AddCommand(rulesTestCmd)
+}
+
+// loadRuleEngine returns the built-in rules plus any --rules packs.
+func loadRuleEngine(cmd *cobra.Command) (*security.RuleEngine, error) {
+	packs, _ := cmd.Flags().GetStringSlice("rules")
+	engine := security.NewRuleEngine()
+	for _, path := range packs {
+		if err := engine.LoadRulesFromFile(path); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return engine, nil
+}
+
+type ruleSet struct {
+	name  string
+	rules []security.Rule
+}
+
+// ruleSets returns the rules of each file given, or of the loaded engine.
+// Files are read without compiling so lint can report every problem.
+func ruleSets(cmd *cobra.Command, files []string) ([]ruleSet, error) {
+	if len(files) == 0 {
+		engine, err := loadRuleEngine(cmd)
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, pack := range engine.Packs() {
+			names = append(names, pack.Name+" "+pack.Version)
+		}
+		return []ruleSet{{name: strings.Join(names, ", "), rules: engine.Rules()}}, nil
+	}
+
+	var sets []ruleSet
+	for _, file := range files {
+		pack, info, err := security.ReadRulePack(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		sets = append(sets, ruleSet{
+			name:  fmt.Sprintf("%s (%s %s, %s)", file, info.Name, info.Version, info.Checksum),
+			rules: pack.Rules,
+		})
+	}
+	return sets, nil
+}
+
+// severityColor picks the display color for a rule severity.
+func severityColor(severity string) interface {
+	Sprint(a ...interface{}) string
+	Sprintf(format string, a ...interface{}) string
+} {
+	switch severity {
+	case "critical", "high":
+		return errorColor
+	case "medium":
+		return warnColor
+	default:
+		return infoColor
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/macawi-ai/strigoi/modules/probe"
+	"github.com/macawi-ai/strigoi/pkg/security"
+)
+
+var (
+	runPTY          bool
+	runOutput       string
+	runFilter       string
+	runShowActivity bool
+	runLinger       time.Duration
+	runMCPManifests string
+	runQuiet        bool
+	runPolicy       string
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run [flags] -- <command> [args...]",
+	Short: "Launch a command and capture its STDIO",
+	Long: `Launch a command with its stdin, stdout and stderr interposed and analyze
+everything that crosses them with the center probe pipeline. Unlike attaching
+to a running process, nothing is missed and no ptrace permission is needed.
+
+Data is passed through unchanged and strigoi's own messages go to stderr, so
+the command can be wrapped wherever it is launched, such as an MCP server entry
+in a client configuration. Children inherit the interposed streams, so their
+traffic is captured too. strigoi exits with the command's exit code.
+
+Use --pty for interactive tools that need a terminal; stdout and stderr are
+then captured as one stream.
+
+With --policy, strigoi acts as a guard between an MCP client and the server it
+launches. Each JSON-RPC message is checked against the policy and passed,
+modified (secrets redacted from results), rejected with a JSON-RPC error, or
+held until approved at the terminal. Every decision is logged as a
+policy_decision event. Policies are JSON or YAML:
+
+  name: filesystem-guard
+  version: 1.0.0
+  default_action: deny        # allow, deny or approve
+  max_payload: 1048576        # bytes
+  paths: [~/projects]         # roots path arguments must stay under
+  redact_secrets: true
+  tools:
+    - name: read_*
+    - name: write_file
+      action: approve
+      arguments:
+        path: \.(md|txt)$`,
+	Example: `  # Capture an MCP server launched by a client
+  strigoi run -- npx -y @modelcontextprotocol/server-filesystem /tmp
+
+  # In an MCP client configuration
+  "command": "strigoi", "args": ["run", "--", "npx", "server"]
+
+  # Run an interactive tool on a pseudo-terminal
+  strigoi run --pty -o session.jsonl -- python3 agent.py
+
+  # Enforce a policy on an MCP server
+  strigoi run --policy configs/mcp_policy.yaml -- npx -y @modelcontextprotocol/server-filesystem ~/projects`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runRunCommand,
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+
+	// Everything after the command name belongs to the command
+	runCmd.Flags().SetInterspersed(false)
+
+	runCmd.Flags().BoolVar(&runPTY, "pty", false, "Run the command on a pseudo-terminal (interactive tools)")
+	runCmd.Flags().StringVarP(&runOutput, "output", "o", "strigoi-run.jsonl", "Output log file (JSONL format)")
+	runCmd.Flags().StringVarP(&runFilter, "filter", "f", "", "Regex filter for stream data")
+	runCmd.Flags().BoolVar(&runShowActivity, "show-activity", false, "Log stream activity even when no vulnerabilities are detected")
+	runCmd.Flags().DurationVar(&runLinger, "linger", 2*time.Second, "How long to keep reading output held open by children after the command exits")
+	runCmd.Flags().StringVar(&runMCPManifests, "mcp-manifests", "", "File of approved MCP tool manifests (default ~/.strigoi/mcp_manifests.json)")
+	runCmd.Flags().BoolVarP(&runQuiet, "quiet", "q", false, "Do not print a summary to stderr")
+	runCmd.Flags().StringVar(&runPolicy, "policy", "", "Enforce an MCP policy file (JSON or YAML) on the command's stdio")
+}
+
+// exitCodeError carries a launched command's non-zero exit code to main.
+type exitCodeError struct {
+	code int
+}
+
+func (e *exitCodeError) Error() string {
+	return fmt.Sprintf("command exited with status %d", e.code)
+}
+
+// terminalApprover asks at the controlling terminal whether a held message
+// may pass; stdin and stdout carry the protocol. Without a terminal there is
+// no approver and held messages are rejected.
+func terminalApprover() probe.MCPApprover {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil
+	}
+	reader := bufio.NewReader(tty)
+	return func(summary string) bool {
+		warnColor.Fprintf(tty, "strigoi: policy requires approval for %s\n", summary)
+		fmt.Fprint(tty, "Allow? [y/N] ")
+		answer, err := reader.ReadString('\n')
+		if err != nil {
+			return false
+		}
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		return answer == "y" || answer == "yes"
+	}
+}
+
+func runRunCommand(cmd *cobra.Command, args []string) error {
+	center := probe.NewCenterModule().(*probe.CenterModule)
+	options := map[string]string{
+		"target":        args[0],
+		"output":        runOutput,
+		"no-display":    "true",
+		"filter":        runFilter,
+		"show-activity": fmt.Sprintf("%t", runShowActivity),
+		"mcp-manifests": runMCPManifests,
+	}
+	for name, value := range options {
+		if err := center.SetOption(name, value); err != nil {
+			return fmt.Errorf("failed to set %s: %w", name, err)
+		}
+	}
+
+	cmd.SilenceUsage = true
+	opts := probe.LaunchOptions{
+		PTY:    runPTY,
+		Linger: runLinger,
+	}
+	if runPolicy != "" {
+		policy, err := security.ReadMCPPolicy(runPolicy)
+		if err != nil {
+			return err
+		}
+		opts.Policy = policy
+		opts.Approver = terminalApprover()
+	}
+
+	result, exitCode, err := center.Launch(args, opts)
+	if err != nil {
+		return err
+	}
+
+	if !runQuiet {
+		var bytes, vulns int64
+		if stats, ok := result.Data["statistics"].(map[string]interface{}); ok {
+			bytes, _ = stats["total_bytes"].(int64)
+			vulns, _ = stats["total_vulns"].(int64)
+		}
+		summary := fmt.Sprintf("strigoi: captured %d bytes from %s, exit status %d, log %s\n", bytes, args[0], exitCode, runOutput)
+		if vulns > 0 {
+			warnColor.Fprintf(os.Stderr, "strigoi: %d vulnerabilities detected\n", vulns)
+		}
+		if policy, ok := result.Data["policy"].(map[string]interface{}); ok {
+			decisions, _ := policy["decisions"].(map[string]int)
+			if n := decisions["reject"]; n > 0 {
+				warnColor.Fprintf(os.Stderr, "strigoi: policy %s rejected %d messages\n", policy["name"], n)
+			}
+		}
+		grayColor.Fprint(os.Stderr, summary)
+	}
+
+	if exitCode != 0 {
+		cmd.SilenceErrors = true
+		return &exitCodeError{code: exitCode}
+	}
+	return nil
+}
@@ -22,8 +22,8 @@ func main() {
 	// Create capture engine with 256KB buffers and newline delimiter
 	engine := probe.NewCaptureEngineV2(256*1024, []byte("\n"))
 
-	// Optional: Enable strace fallback
-	// engine.EnableStrace()
+	// Optional: Enable ptrace fallback
+	// engine.EnablePtrace()
 
 	// Start a demo process
 	fmt.Println("\n📋 Starting demo process...")
@@ -127,23 +127,14 @@ loop:
 			fmt.Println("────────────────────")
 
 			for stream, stat := range stats {
-				if m, ok := stat.(map[string]interface{}); ok {
-					if stream == "capture_stats" {
-						if cs, ok := m.(*probe.CaptureStats); ok {
-							fmt.Printf("Capture: method=%s, success=%d/%d, bytes=%d\n",
-								cs.Method, cs.Successful, cs.Attempts, cs.BytesCapured)
-						}
-					} else {
-						usage := m["usage_pct"].(float64)
-						written := m["written"].(uint64)
-						dropped := m["dropped"].(uint64)
-						events := m["events_sent"].(uint64)
-						interval := m["scan_interval"].(time.Duration)
-						rate := m["write_rate_bps"].(uint64)
-
-						fmt.Printf("%s: usage=%.1f%%, written=%d, events=%d, dropped=%d, rate=%.1fKB/s, scan=%v\n",
-							stream, usage, written, events, dropped, float64(rate)/1024, interval)
-					}
+				switch st := stat.(type) {
+				case *probe.CaptureStats:
+					fmt.Printf("Capture: method=%s, success=%d/%d, bytes=%d\n",
+						st.Method, st.Successful, st.Attempts, st.BytesCapured)
+				case probe.BufferStats:
+					usage := float64(st.Used) / float64(st.Size) * 100
+					fmt.Printf("%s: usage=%.1f%%, written=%d, events=%d, dropped=%d (%d events)\n",
+						stream, usage, st.BytesWritten, st.EventsRead, st.BytesDropped, st.EventsDropped)
 				}
 			}
 			fmt.Printf("\nEvents captured: stdout=%d, stderr=%d, stdin=%d\n",
@@ -166,11 +157,8 @@ loop:
 
 	finalStats, _ := engine.GetBufferStats(pid)
 	for stream, stat := range finalStats {
-		if m, ok := stat.(map[string]interface{}); ok && stream != "capture_stats" {
-			fmt.Printf("\n%s buffer:\n", stream)
-			for k, v := range m {
-				fmt.Printf("  %s: %v\n", k, v)
-			}
+		if st, ok := stat.(probe.BufferStats); ok {
+			fmt.Printf("\n%s buffer: %+v\n", stream, st)
 		}
 	}
 
@@ -1,7 +1,9 @@
 package probe
 
 import (
+	"net"
 	"regexp"
+	"strings"
 )
 
 // AIProvider represents an AI service provider's characteristics.
@@ -33,6 +35,7 @@ type EndpointResponse struct {
 	Provider   string
 	ModelInfo  string
 	Security   []SecurityFinding
+	HAREntryID string
 }
 
 // SecurityFinding represents a security issue.
@@ -41,6 +44,7 @@ type SecurityFinding struct {
 	Name        string
 	Description string
 	Evidence    string
+	HAREntryID  string
 }
 
 // GetAIProviders returns configured AI service providers.
@@ -301,3 +305,52 @@ func GetLocalModelPorts() []int {
 		8501,  // TensorFlow Serving REST
 	}
 }
+
+// llmAPIHosts maps hosted LLM API domains to provider names.
+var llmAPIHosts = map[string]string{
+	"api.openai.com":                    "openai",
+	"api.anthropic.com":                 "anthropic",
+	"generativelanguage.googleapis.com": "google",
+	"api.mistral.ai":                    "mistral",
+	"api.groq.com":                      "groq",
+	"api.cohere.ai":                     "cohere",
+	"api.cohere.com":                    "cohere",
+	"openrouter.ai":                     "openrouter",
+	"api.together.xyz":                  "together",
+	"api.deepseek.com":                  "deepseek",
+}
+
+// llmProviderForRequest names the LLM provider an HTTP request is sent to,
+// from its host and, for self-hosted or proxied endpoints, its path.
+// It returns "" when the request does not look like an LLM API call.
+func llmProviderForRequest(host, path string) string {
+	hostname, port := host, ""
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		hostname, port = h, p
+	}
+	hostname = strings.ToLower(hostname)
+
+	if provider, ok := llmAPIHosts[hostname]; ok {
+		return provider
+	}
+	if strings.HasSuffix(hostname, ".openai.azure.com") {
+		return "azure_openai"
+	}
+	if port == "11434" {
+		return "ollama"
+	}
+
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	switch {
+	case strings.HasSuffix(path, "/v1/messages"), strings.HasSuffix(path, "/v1/complete"):
+		return "anthropic"
+	case path == "/api/generate", path == "/api/chat":
+		return "ollama"
+	case strings.HasSuffix(path, "/chat/completions"), strings.HasSuffix(path, "/v1/completions"),
+		strings.HasSuffix(path, "/v1/embeddings"), strings.HasSuffix(path, "/v1/responses"):
+		return "openai_compatible"
+	}
+	return ""
+}
@@ -2,16 +2,20 @@ package probe
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/macawi-ai/strigoi/pkg/modules"
+	"github.com/macawi-ai/strigoi/pkg/security"
 )
 
 func init() {
@@ -30,6 +34,10 @@ type CenterModule struct {
 	dissectors   []Dissector
 	vulnDetector *VulnerabilityDetector
 	credHunter   *CredentialHunter
+	pii          *security.PIIClassifier
+
+	// PII seen in LLM request bodies, by provider and type (guarded by mu)
+	piiCounts map[string]map[string]int
 
 	// Output components
 	logger  *EventLogger
@@ -42,12 +50,14 @@ type CenterModule struct {
 	mu     sync.RWMutex
 
 	// Configuration
-	config CenterConfig
+	config       CenterConfig
+	childPattern *regexp.Regexp     // descendants to follow; nil follows all
+	containers   *containerResolver // for container: and pod: targets
 }
 
 // CenterConfig holds configuration for the center probe.
 type CenterConfig struct {
-	CaptureMode    string        `json:"capture_mode"`    // procfs, strace, auto
+	CaptureMode    string        `json:"capture_mode"`    // procfs, ptrace, auto
 	PollInterval   time.Duration `json:"poll_interval"`   // How often to check streams
 	BufferSize     int           `json:"buffer_size"`     // Per-stream buffer size
 	LogFile        string        `json:"log_file"`        // JSONL output file
@@ -55,7 +65,16 @@ type CenterConfig struct {
 	Filters        []string      `json:"filters"`         // Regex filters
 	MaxDuration    time.Duration `json:"max_duration"`    // Maximum monitoring time
 	ShowActivity   bool          `json:"show_activity"`   // Show all stream activity
-	EnableStrace   bool          `json:"enable_strace"`   // Enable strace fallback (opt-in)
+	EnablePtrace   bool          `json:"enable_ptrace"`   // Enable ptrace fallback (opt-in)
+	MCPManifests   string        `json:"mcp_manifests"`   // Approved MCP tool manifests
+	FollowChildren bool          `json:"follow_children"` // Attach to descendants of the targets
+	ChildPattern   string        `json:"child_pattern"`   // Regex a descendant's name or command line must match
+	StateDirs      []string      `json:"state_dirs"`      // Container runtime state directories for names and labels
+	Dissectors     []string      `json:"dissectors"`      // Enabled dissectors, by priority
+	DissectorDefs  []string      `json:"dissector_defs"`  // Files and directories of declarative dissectors
+
+	ReassemblyLimit   int           `json:"reassembly_limit"`   // Largest partial message held per stream
+	ReassemblyTimeout time.Duration `json:"reassembly_timeout"` // How long a partial message waits to complete
 }
 
 // StreamTarget represents a process to monitor.
@@ -64,16 +83,26 @@ type StreamTarget struct {
 	Name        string
 	CommandLine string
 	StartTime   time.Time
+	Listening   []string `json:",omitempty"` // endpoints the process accepts connections on
+	PPID        int      `json:",omitempty"` // parent process
+	Ancestor    int      `json:",omitempty"` // nearest monitored ancestor, for followed descendants
+
+	Container *ContainerInfo `json:",omitempty"` // container the process runs in, for container: and pod: targets
 }
 
 // StreamCapture represents active stream monitoring.
 type StreamCapture struct {
 	Target      StreamTarget
-	Stdin       *StreamBuffer
-	Stdout      *StreamBuffer
-	Stderr      *StreamBuffer
+	Stdin       EventBuffer
+	Stdout      EventBuffer
+	Stderr      EventBuffer
+	FDs         map[string]*FDStreamStats // other descriptors, keyed by stream name
 	CaptureMode string
 	Statistics  StreamStats
+	Exited      bool         // the process exited and monitoring stopped
+	Loss        *CaptureLoss // how much of the traffic was observed, once monitoring stopped
+
+	reassemblers map[string]*streamReassembler // by stream name; used by the analyzing goroutine
 }
 
 // StreamStats tracks capture statistics.
@@ -84,6 +113,14 @@ type StreamStats struct {
 	LastActivity  time.Time
 }
 
+// FDStreamStats tracks the traffic of one descriptor stream.
+type FDStreamStats struct {
+	FDStream
+	Bytes        int64     `json:"bytes"`
+	Events       int64     `json:"events"`
+	LastActivity time.Time `json:"last_activity"`
+}
+
 // StreamVulnerability represents a detected vulnerability.
 type StreamVulnerability struct {
 	ID          string       `json:"id"`
@@ -96,6 +133,9 @@ type StreamVulnerability struct {
 	Context     string       `json:"context"`    // Surrounding data
 	Confidence  float64      `json:"confidence"` // 0.0 to 1.0
 	ProcessInfo StreamTarget `json:"process_info"`
+	Regulations []string     `json:"regulations,omitempty"` // GDPR, HIPAA, PCI-DSS
+	Stream      string       `json:"stream,omitempty"`      // stream the data was seen on
+	FD          *FDStream    `json:"fd,omitempty"`          // descriptor, for streams other than stdio
 }
 
 // NewCenterModule creates a new stream analysis module.
@@ -108,7 +148,7 @@ func NewCenterModule() modules.Module {
 			ModuleOptions: map[string]*modules.ModuleOption{
 				"target": {
 					Name:        "target",
-					Description: "Process name or PID to monitor",
+					Description: "Process name, PID, port:<n>, unix:<path>, container:<id|name> or pod:<namespace>/<name> to monitor",
 					Required:    true,
 					Type:        "string",
 				},
@@ -161,16 +201,74 @@ func NewCenterModule() modules.Module {
 					Type:        "bool",
 					Default:     false,
 				},
-				"enable-strace": {
-					Name:        "enable-strace",
-					Description: "Enable strace fallback for PTY capture (performance impact)",
+				"enable-ptrace": {
+					Name:        "enable-ptrace",
+					Description: "Enable native ptrace capture fallback for PTY traffic (performance impact)",
+					Required:    false,
+					Type:        "bool",
+					Default:     false,
+				},
+				"follow-children": {
+					Name:        "follow-children",
+					Description: "Attach to new descendants of the targets and detach when they exit",
 					Required:    false,
 					Type:        "bool",
 					Default:     false,
 				},
+				"child-pattern": {
+					Name:        "child-pattern",
+					Description: "Regex a descendant's name or command line must match to be followed",
+					Required:    false,
+					Type:        "string",
+					Default:     "",
+				},
+				"reassembly-limit": {
+					Name:        "reassembly-limit",
+					Description: "Largest partial message held per stream until it completes (KB)",
+					Required:    false,
+					Type:        "int",
+					Default:     1024,
+				},
+				"reassembly-timeout": {
+					Name:        "reassembly-timeout",
+					Description: "How long a partial message waits to complete before it is analyzed (ms)",
+					Required:    false,
+					Type:        "int",
+					Default:     1000,
+				},
+				"runtime-state-dirs": {
+					Name:        "runtime-state-dirs",
+					Description: "Comma-separated container runtime state directories to read names and pod labels from",
+					Required:    false,
+					Type:        "string",
+					Default:     strings.Join(defaultRuntimeStateDirs, ","),
+				},
+				"dissectors": {
+					Name:        "dissectors",
+					Description: "Comma-separated dissectors to enable, or -name to disable (default all)",
+					Required:    false,
+					Type:        "string",
+					Default:     "all",
+				},
+				"dissector-defs": {
+					Name:        "dissector-defs",
+					Description: "Comma-separated files or directories of declarative dissector definitions (default ~/.strigoi/dissectors)",
+					Required:    false,
+					Type:        "string",
+					Default:     "",
+				},
+				"mcp-manifests": {
+					Name:        "mcp-manifests",
+					Description: "File of approved MCP tool manifests (default ~/.strigoi/mcp_manifests.json)",
+					Required:    false,
+					Type:        "string",
+					Default:     "",
+				},
 			},
 		},
 		activeStreams: make(map[int]*StreamCapture),
+		pii:           security.NewPIIClassifier(),
+		piiCounts:     make(map[string]map[string]int),
 	}
 }
 
@@ -240,13 +338,94 @@ func (m *CenterModule) Configure() error {
 		}
 	}
 
-	enableStrace := false
-	if es, ok := m.ModuleOptions["enable-strace"]; ok && es.Value != nil {
-		if esBool, ok := es.Value.(bool); ok {
-			enableStrace = esBool
+	enablePtrace := false
+	if ep, ok := m.ModuleOptions["enable-ptrace"]; ok && ep.Value != nil {
+		if epBool, ok := ep.Value.(bool); ok {
+			enablePtrace = epBool
+		}
+	}
+
+	reassemblyLimit := defaultReassemblyLimit / 1024
+	if rl, ok := m.ModuleOptions["reassembly-limit"]; ok && rl.Value != nil {
+		if rlInt, ok := rl.Value.(int); ok && rlInt > 0 {
+			reassemblyLimit = rlInt
 		}
 	}
 
+	reassemblyTimeout := int(defaultReassemblyTimeout / time.Millisecond)
+	if rt, ok := m.ModuleOptions["reassembly-timeout"]; ok && rt.Value != nil {
+		if rtInt, ok := rt.Value.(int); ok && rtInt > 0 {
+			reassemblyTimeout = rtInt
+		}
+	}
+
+	mcpManifests := DefaultMCPManifestPath()
+	if mm, ok := m.ModuleOptions["mcp-manifests"]; ok && mm.Value != nil {
+		if mmStr, ok := mm.Value.(string); ok && mmStr != "" {
+			mcpManifests = mmStr
+		}
+	}
+
+	followChildren := false
+	if fc, ok := m.ModuleOptions["follow-children"]; ok && fc.Value != nil {
+		if fcBool, ok := fc.Value.(bool); ok {
+			followChildren = fcBool
+		}
+	}
+
+	childPattern := ""
+	if cp, ok := m.ModuleOptions["child-pattern"]; ok && cp.Value != nil {
+		if cpStr, ok := cp.Value.(string); ok {
+			childPattern = cpStr
+		}
+	}
+	stateDirs := defaultRuntimeStateDirs
+	if sd, ok := m.ModuleOptions["runtime-state-dirs"]; ok && sd.Value != nil {
+		if sdStr, ok := sd.Value.(string); ok {
+			stateDirs = []string{}
+			for _, dir := range strings.Split(sdStr, ",") {
+				if dir = strings.TrimSpace(dir); dir != "" {
+					stateDirs = append(stateDirs, dir)
+				}
+			}
+		}
+	}
+
+	dissectorSpec := "all"
+	if ds, ok := m.ModuleOptions["dissectors"]; ok && ds.Value != nil {
+		if dsStr, ok := ds.Value.(string); ok {
+			dissectorSpec = dsStr
+		}
+	}
+
+	// Definitions in the default directory are loaded if it exists
+	var dissectorDefs []string
+	if dd, ok := m.ModuleOptions["dissector-defs"]; ok && dd.Value != nil {
+		if ddStr, ok := dd.Value.(string); ok {
+			for _, path := range strings.Split(ddStr, ",") {
+				if path = strings.TrimSpace(path); path != "" {
+					dissectorDefs = append(dissectorDefs, path)
+				}
+			}
+		}
+	}
+	if len(dissectorDefs) == 0 {
+		if dir := DefaultDissectorDir(); dir != "" {
+			if _, err := os.Stat(dir); err == nil {
+				dissectorDefs = []string{dir}
+			}
+		}
+	}
+
+	m.childPattern = nil
+	if childPattern != "" {
+		re, err := regexp.Compile(childPattern)
+		if err != nil {
+			return fmt.Errorf("invalid child pattern: %w", err)
+		}
+		m.childPattern = re
+	}
+
 	m.config = CenterConfig{
 		CaptureMode:    "auto",
 		PollInterval:   time.Duration(pollInterval) * time.Millisecond,
@@ -256,31 +435,55 @@ func (m *CenterModule) Configure() error {
 		Filters:        filters,
 		MaxDuration:    duration,
 		ShowActivity:   showActivity,
-		EnableStrace:   enableStrace,
+		EnablePtrace:   enablePtrace,
+		MCPManifests:   mcpManifests,
+		FollowChildren: followChildren,
+		ChildPattern:   childPattern,
+		StateDirs:      stateDirs,
+		DissectorDefs:  dissectorDefs,
+
+		ReassemblyLimit:   reassemblyLimit * 1024,
+		ReassemblyTimeout: time.Duration(reassemblyTimeout) * time.Millisecond,
 	}
 
 	// Initialize components
 	m.captureEngine = NewCaptureEngine(m.config.CaptureMode)
-	if m.config.EnableStrace {
-		if err := m.captureEngine.EnableStrace(); err != nil {
-			fmt.Printf("Warning: Failed to enable strace: %v\n", err)
+	m.captureEngine.SetPollInterval(m.config.PollInterval)
+	if m.config.EnablePtrace {
+		if err := m.captureEngine.EnablePtrace(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to enable ptrace: %v\n", err)
 		}
 	}
+	m.containers = newContainerResolver("/proc", m.config.StateDirs)
 	m.vulnDetector = NewVulnerabilityDetector()
 	m.credHunter = NewCredentialHunter()
 
-	// Initialize dissectors
-	m.dissectors = []Dissector{
-		NewHTTPDissector(),
-		NewGRPCDissectorV2(), // Use improved version with better performance and security
-		NewWebSocketDissector(),
-		NewJSONDissector(),
-		NewSQLDissector(),
-		NewPlainTextDissector(),
+	// Initialize dissectors: the registered ones and those defined in files
+	available := RegisteredDissectors()
+	definitions, err := LoadDissectorDefinitions(m.config.DissectorDefs)
+	if err != nil {
+		return err
+	}
+	for _, definition := range definitions {
+		for _, registration := range available {
+			if registration.Name == definition.Name() {
+				return fmt.Errorf("dissector %q is already registered", definition.Name())
+			}
+		}
+		available = append(available, definition.registration())
+	}
+	selected, err := selectDissectors(available, dissectorSpec)
+	if err != nil {
+		return err
+	}
+	m.dissectors = make([]Dissector, 0, len(selected))
+	m.config.Dissectors = make([]string, 0, len(selected))
+	for _, registration := range selected {
+		m.dissectors = append(m.dissectors, registration.New(m.config))
+		m.config.Dissectors = append(m.config.Dissectors, registration.Name)
 	}
 
 	// Initialize logger
-	var err error
 	m.logger, err = NewEventLogger(m.config.LogFile)
 	if err != nil {
 		return fmt.Errorf("failed to create logger: %w", err)
@@ -361,7 +564,7 @@ func (m *CenterModule) Run() (*modules.ModuleResult, error) {
 		"config":  m.config,
 	}); err != nil {
 		// Non-critical error, just log it
-		fmt.Printf("Warning: failed to log start event: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Warning: failed to log start event: %v\n", err)
 	}
 
 	// Initialize display
@@ -376,7 +579,7 @@ func (m *CenterModule) Run() (*modules.ModuleResult, error) {
 		}
 		defer func() {
 			if err := m.display.Stop(); err != nil {
-				fmt.Printf("Warning: failed to stop display: %v\n", err)
+				fmt.Fprintf(os.Stderr, "Warning: failed to stop display: %v\n", err)
 			}
 		}()
 	}
@@ -386,6 +589,14 @@ func (m *CenterModule) Run() (*modules.ModuleResult, error) {
 		m.wg.Add(1)
 		go m.monitorTarget(target)
 	}
+	if isContainerTarget(targetStr) {
+		// Descendants are in the container and are found with it
+		m.wg.Add(1)
+		go m.followContainer(targetStr, targets)
+	} else if m.config.FollowChildren {
+		m.wg.Add(1)
+		go m.followChildren(targets)
+	}
 
 	// Wait for completion or interruption
 	m.wg.Wait()
@@ -400,7 +611,7 @@ func (m *CenterModule) Run() (*modules.ModuleResult, error) {
 		"stats":    results["statistics"],
 	}); err != nil {
 		// Non-critical error, just log it
-		fmt.Printf("Warning: failed to log stop event: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Warning: failed to log stop event: %v\n", err)
 	}
 
 	return &modules.ModuleResult{
@@ -412,10 +623,60 @@ func (m *CenterModule) Run() (*modules.ModuleResult, error) {
 	}, nil
 }
 
-// findTargets locates processes matching the target specification.
+// findTargets locates processes matching the target specification: a PID,
+// "port:<n>" or "unix:<path>" for the owner of a listening socket,
+// "container:<id|name>" or "pod:<namespace>/<name>" for every process in a
+// container, or a process name.
 func (m *CenterModule) findTargets(target string) ([]StreamTarget, error) {
 	targets := []StreamTarget{}
 
+	// Container processes are resolved from their cgroups
+	if isContainerTarget(target) {
+		members, err := m.containers.resolve(target)
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			if process, err := m.getProcessInfo(member.PID); err == nil {
+				process.Container = member.Container
+				targets = append(targets, process)
+			}
+		}
+		if inventory, err := security.ReadSocketInventory(); err == nil {
+			for i := range targets {
+				targets[i].Listening = listeningEndpoints(inventory, targets[i].PID)
+			}
+		}
+		return targets, nil
+	}
+
+	// Socket owners are resolved from /proc/net
+	if strings.HasPrefix(target, "port:") || strings.HasPrefix(target, "unix:") {
+		inventory, err := security.ReadSocketInventory()
+		if err != nil {
+			return nil, err
+		}
+
+		var pids []int
+		if portStr, ok := strings.CutPrefix(target, "port:"); ok {
+			port, err := strconv.Atoi(portStr)
+			if err != nil || port <= 0 || port > 65535 {
+				return nil, fmt.Errorf("invalid port in target %q", target)
+			}
+			pids = inventory.PIDsForPort(port)
+		} else {
+			pids = inventory.PIDsForUnixPath(strings.TrimPrefix(target, "unix:"))
+		}
+
+		for _, pid := range pids {
+			if process, err := m.getProcessInfo(pid); err == nil {
+				process.Listening = listeningEndpoints(inventory, pid)
+				targets = append(targets, process)
+			}
+		}
+		return targets, nil
+	}
+
 	// Check if target is a PID
 	if pid, err := strconv.Atoi(target); err == nil {
 		// Direct PID
@@ -460,9 +721,26 @@ func (m *CenterModule) findTargets(target string) ([]StreamTarget, error) {
 		}
 	}
 
+	if inventory, err := security.ReadSocketInventory(); err == nil {
+		for i := range targets {
+			targets[i].Listening = listeningEndpoints(inventory, targets[i].PID)
+		}
+	}
+
 	return targets, nil
 }
 
+// listeningEndpoints lists the sockets a process accepts connections on.
+func listeningEndpoints(inventory *security.SocketInventory, pid int) []string {
+	var endpoints []string
+	for _, socket := range inventory.ForPID(pid) {
+		if socket.State == "LISTEN" || (socket.Listening() && socket.Protocol != "unix") {
+			endpoints = append(endpoints, socket.Protocol+" "+socket.Endpoint())
+		}
+	}
+	return endpoints
+}
+
 // getProcessInfo retrieves information about a process.
 func (m *CenterModule) getProcessInfo(pid int) (StreamTarget, error) {
 	target := StreamTarget{PID: pid}
@@ -481,6 +759,10 @@ func (m *CenterModule) getProcessInfo(pid int) (StreamTarget, error) {
 		target.Name = filepath.Base(parts[0])
 	}
 
+	if ppid, _, err := readProcStat("/proc", pid); err == nil {
+		target.PPID = ppid
+	}
+
 	// Get start time
 	statFile := fmt.Sprintf("/proc/%d/stat", pid)
 	statData, err := os.ReadFile(statFile)
@@ -501,7 +783,25 @@ func (m *CenterModule) getProcessInfo(pid int) (StreamTarget, error) {
 func (m *CenterModule) monitorTarget(target StreamTarget) {
 	defer m.wg.Done()
 
-	// Create stream capture
+	capture := m.addCapture(target)
+
+	// Start capture
+	if err := m.captureEngine.Attach(target.PID); err != nil {
+		m.logAttachError(target.PID, err)
+		return
+	}
+	m.monitorCapture(capture)
+}
+
+// monitorAttached monitors a process the capture engine is already
+// attached to.
+func (m *CenterModule) monitorAttached(target StreamTarget) {
+	defer m.wg.Done()
+	m.monitorCapture(m.addCapture(target))
+}
+
+// addCapture creates the stream capture of a target.
+func (m *CenterModule) addCapture(target StreamTarget) *StreamCapture {
 	capture := &StreamCapture{
 		Target:      target,
 		Stdin:       NewStreamBuffer(m.config.BufferSize),
@@ -513,20 +813,30 @@ func (m *CenterModule) monitorTarget(target StreamTarget) {
 	m.mu.Lock()
 	m.activeStreams[target.PID] = capture
 	m.mu.Unlock()
+	return capture
+}
 
-	// Start capture
-	if err := m.captureEngine.Attach(target.PID); err != nil {
-		if err := m.logger.LogError(fmt.Errorf("failed to attach to PID %d: %w", target.PID, err)); err != nil {
-			fmt.Printf("Warning: failed to log error: %v\n", err)
-		}
-		return
+// logAttachError records that the capture engine could not attach to pid.
+func (m *CenterModule) logAttachError(pid int, err error) {
+	if err := m.logger.LogError(fmt.Errorf("failed to attach to PID %d: %w", pid, err)); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to log error: %v\n", err)
 	}
+}
+
+// monitorCapture reads and analyzes the streams of an attached process
+// until it exits or monitoring stops, then detaches.
+func (m *CenterModule) monitorCapture(capture *StreamCapture) {
+	target := capture.Target
 	defer func() {
+		m.recordLoss(capture, m.captureEngine.Loss(target.PID))
 		if err := m.captureEngine.Detach(target.PID); err != nil {
-			fmt.Printf("Warning: failed to detach from PID %d: %v\n", target.PID, err)
+			fmt.Fprintf(os.Stderr, "Warning: failed to detach from PID %d: %v\n", target.PID, err)
 		}
 	}()
 
+	// Partial messages are analyzed as they are when monitoring stops
+	defer m.flushStreams(capture, time.Time{})
+
 	// Monitoring loop
 	ticker := time.NewTicker(m.config.PollInterval)
 	defer ticker.Stop()
@@ -538,35 +848,203 @@ func (m *CenterModule) monitorTarget(target StreamTarget) {
 		case <-ticker.C:
 			// Read streams
 			data, err := m.captureEngine.ReadStreams(target.PID)
-			if err != nil {
+			empty := data == nil || (len(data.Stdin) == 0 && len(data.Stdout) == 0 && len(data.Stderr) == 0 && len(data.FDs) == 0 && len(data.Snapshots) == 0)
+			if err != nil || empty {
 				// Process might have exited
-				if os.IsNotExist(err) {
+				if errors.Is(err, os.ErrNotExist) || !processRunning("/proc", target.PID) {
+					m.mu.Lock()
+					capture.Exited = true
+					m.mu.Unlock()
 					return
 				}
-				continue
+				if err != nil {
+					continue
+				}
 			}
 
-			// Process data
+			// Process data; snapshots are complete and repeat, so they are
+			// not reassembled
+			names := make([]string, 0, len(data.Snapshots))
+			for name := range data.Snapshots {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				m.processSnapshot(capture, name, nil, data.Snapshots[name])
+			}
 			if len(data.Stdin) > 0 {
-				m.processStreamData(capture, "stdin", data.Stdin)
+				m.processStreamData(capture, "stdin", nil, data.Stdin)
 			}
 			if len(data.Stdout) > 0 {
-				m.processStreamData(capture, "stdout", data.Stdout)
+				m.processStreamData(capture, "stdout", nil, data.Stdout)
 			}
 			if len(data.Stderr) > 0 {
-				m.processStreamData(capture, "stderr", data.Stderr)
+				m.processStreamData(capture, "stderr", nil, data.Stderr)
+			}
+			for i := range data.FDs {
+				fd := &data.FDs[i]
+				m.processStreamData(capture, fd.Name(), fd, fd.Data)
 			}
+			m.flushStreams(capture, time.Now())
 
 			// Update display
 			if m.display != nil {
+				m.mu.RLock()
 				m.display.Update(m.activeStreams)
+				m.mu.RUnlock()
+			}
+		}
+	}
+}
+
+// followChildren attaches to new descendants of the targets that match the
+// child pattern, until every process in their trees has exited. Monitors of
+// exited processes stop by themselves.
+func (m *CenterModule) followChildren(roots []StreamTarget) {
+	defer m.wg.Done()
+
+	follower := newProcessFollower("/proc", roots)
+	failed := make(map[int]bool) // attach errors already logged
+	ticker := time.NewTicker(followInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		candidates, exited, err := follower.scan()
+		if err != nil {
+			continue
+		}
+		for _, pid := range exited {
+			if err := m.logger.LogEvent("child_exited", map[string]interface{}{"pid": pid}); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to log child exit: %v\n", err)
+			}
+		}
+
+		for _, pid := range candidates {
+			target, err := m.getProcessInfo(pid)
+			if err != nil {
+				continue
+			}
+			// Checked again on later scans: a fork matches only once it execs
+			if m.childPattern != nil && !m.childPattern.MatchString(target.Name) && !m.childPattern.MatchString(target.CommandLine) {
+				continue
+			}
+			// Left unmarked on failure, so later scans try again
+			if err := m.captureEngine.Attach(pid); err != nil {
+				if !failed[pid] {
+					failed[pid] = true
+					m.logAttachError(pid, err)
+				}
+				continue
+			}
+			delete(failed, pid)
+			target.Ancestor = follower.ancestor(pid)
+			follower.attach(pid)
+
+			if err := m.logger.LogEvent("child_attached", map[string]interface{}{"target": target}); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to log child attach: %v\n", err)
 			}
+			m.wg.Add(1)
+			go m.monitorAttached(target)
+		}
+
+		if follower.done() {
+			return
 		}
 	}
 }
 
-// processStreamData analyzes stream data for vulnerabilities.
-func (m *CenterModule) processStreamData(capture *StreamCapture, stream string, data []byte) {
+// followContainer attaches to processes that start in the container or
+// pod a target names, such as those run with docker exec, and stops once
+// none of its processes are left.
+func (m *CenterModule) followContainer(target string, initial []StreamTarget) {
+	defer m.wg.Done()
+
+	known := make(map[int]bool, len(initial))
+	for _, process := range initial {
+		known[process.PID] = true
+	}
+	failed := make(map[int]bool) // attach errors already logged
+	ticker := time.NewTicker(followInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		members, err := m.containers.resolve(target)
+		if err != nil {
+			continue
+		}
+		if len(members) == 0 {
+			return
+		}
+		for _, member := range members {
+			if known[member.PID] {
+				continue
+			}
+			process, err := m.getProcessInfo(member.PID)
+			if err != nil {
+				continue
+			}
+			process.Container = member.Container
+			if err := m.captureEngine.Attach(member.PID); err != nil {
+				if !failed[member.PID] {
+					failed[member.PID] = true
+					m.logAttachError(member.PID, err)
+				}
+				continue
+			}
+			delete(failed, member.PID)
+			known[member.PID] = true
+
+			if err := m.logger.LogEvent("container_attached", map[string]interface{}{"target": process}); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to log container attach: %v\n", err)
+			}
+			m.wg.Add(1)
+			go m.monitorAttached(process)
+		}
+	}
+}
+
+// processStreamData records a chunk of stream data and analyzes the
+// messages it completes. fd describes the descriptor for streams other than
+// stdin, stdout and stderr.
+func (m *CenterModule) processStreamData(capture *StreamCapture, stream string, fd *FDStream, data []byte) {
+	m.recordStreamData(capture, stream, fd, data)
+
+	// A descriptor number reused for another pipe, socket or file starts a
+	// new stream; what the old one left unfinished is analyzed as it is
+	if r, ok := capture.reassemblers[stream]; ok && !r.describes(fd) {
+		if message := r.flush(); len(message) > 0 {
+			m.analyzeMessage(capture, stream, r.fd, message)
+		}
+		delete(capture.reassemblers, stream)
+	}
+
+	r := capture.reassembler(stream, fd, m.config.ReassemblyLimit, m.config.ReassemblyTimeout)
+	for _, message := range r.push(data, time.Now()) {
+		m.analyzeMessage(capture, stream, r.fd, message)
+	}
+}
+
+// processSnapshot records and analyzes data read whole, such as a process's
+// environment, as one message.
+func (m *CenterModule) processSnapshot(capture *StreamCapture, stream string, fd *FDStream, data []byte) {
+	m.recordStreamData(capture, stream, fd, data)
+	m.analyzeMessage(capture, stream, fd, data)
+}
+
+// recordStreamData updates the statistics and buffers of a stream.
+func (m *CenterModule) recordStreamData(capture *StreamCapture, stream string, fd *FDStream, data []byte) {
 	// Update statistics
 	capture.Statistics.BytesCaptured += int64(len(data))
 	capture.Statistics.EventsCount++
@@ -580,8 +1058,27 @@ func (m *CenterModule) processStreamData(capture *StreamCapture, stream string,
 		capture.Stdout.Write(data)
 	case "stderr":
 		capture.Stderr.Write(data)
+	default:
+		if fd != nil {
+			capture.recordFD(fd, len(data))
+		}
 	}
+}
 
+// flushStreams analyzes the partial messages that have waited past the
+// reassembly timeout at now, or all of them when now is zero.
+func (m *CenterModule) flushStreams(capture *StreamCapture, now time.Time) {
+	for _, r := range capture.reassemblers {
+		if now.IsZero() || r.expired(now) {
+			if message := r.flush(); len(message) > 0 {
+				m.analyzeMessage(capture, r.stream, r.fd, message)
+			}
+		}
+	}
+}
+
+// analyzeMessage looks for vulnerabilities in one reassembled message.
+func (m *CenterModule) analyzeMessage(capture *StreamCapture, stream string, fd *FDStream, data []byte) {
 	// Apply filters
 	if len(m.config.Filters) > 0 {
 		matched := false
@@ -605,29 +1102,29 @@ func (m *CenterModule) processStreamData(capture *StreamCapture, stream string,
 
 		// Log to JSONL
 		preview := sanitizePreview(data, 50)
-		if err := m.logger.LogActivity(capture.Target, stream, preview, len(data)); err != nil {
-			fmt.Printf("Warning: failed to log activity: %v\n", err)
+		if err := m.logger.LogActivity(capture.Target, stream, fd, preview, len(data)); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to log activity: %v\n", err)
 		}
 	}
 
 	// Detect protocol
-	var dissector Dissector
-	var confidence float64
-	for _, d := range m.dissectors {
-		if match, conf := d.Identify(data); match && conf > confidence {
-			dissector = d
-			confidence = conf
-		}
-	}
+	dissector, _ := m.identify(data)
 
 	// Extract vulnerabilities
 	vulns := []StreamVulnerability{}
 
 	// Use dissector if found
 	if dissector != nil {
-		frame, err := dissector.Dissect(data)
+		var frame *Frame
+		var err error
+		if sd, ok := dissector.(StreamDissector); ok {
+			frame, err = sd.DissectStream(conversationKey(capture.Target.PID, fd), data)
+		} else {
+			frame, err = dissector.Dissect(data)
+		}
 		if err == nil {
 			vulns = append(vulns, dissector.FindVulnerabilities(frame)...)
+			vulns = append(vulns, m.findPromptPII(frame, stream, capture.Target)...)
 		}
 	}
 
@@ -652,10 +1149,12 @@ func (m *CenterModule) processStreamData(capture *StreamCapture, stream string,
 	// Process vulnerabilities
 	for _, vuln := range vulns {
 		capture.Statistics.VulnsFound++
+		vuln.Stream = stream
+		vuln.FD = fd
 
 		// Log vulnerability
 		if err := m.logger.LogVulnerability(vuln); err != nil {
-			fmt.Printf("Warning: failed to log vulnerability: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Warning: failed to log vulnerability: %v\n", err)
 		}
 
 		// Update display
@@ -665,6 +1164,166 @@ func (m *CenterModule) processStreamData(capture *StreamCapture, stream string,
 	}
 }
 
+// identify returns the dissector most confident about data. On equal
+// confidence the one with the higher priority wins.
+func (m *CenterModule) identify(data []byte) (Dissector, float64) {
+	var dissector Dissector
+	var confidence float64
+	for _, d := range m.dissectors {
+		if match, conf := d.Identify(data); match && conf > confidence {
+			dissector = d
+			confidence = conf
+		}
+	}
+	return dissector, confidence
+}
+
+// recordLoss keeps and logs how much of a process's traffic was observed.
+func (m *CenterModule) recordLoss(capture *StreamCapture, loss CaptureLoss) {
+	m.mu.Lock()
+	capture.Loss = &loss
+	m.mu.Unlock()
+
+	if err := m.logger.LogEvent("coverage", map[string]interface{}{
+		"pid":  capture.Target.PID,
+		"loss": loss,
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to log coverage: %v\n", err)
+	}
+}
+
+// recordFD updates the statistics of a descriptor stream.
+func (c *StreamCapture) recordFD(fd *FDStream, n int) {
+	if c.FDs == nil {
+		c.FDs = make(map[string]*FDStreamStats)
+	}
+	stats, ok := c.FDs[fd.Name()]
+	if !ok {
+		stats = &FDStreamStats{}
+		c.FDs[fd.Name()] = stats
+	}
+	// The latest descriptor wins when a number is reused
+	stats.FDStream = *fd
+	stats.FDStream.Data = nil
+	stats.Bytes += int64(n)
+	stats.Events++
+	stats.LastActivity = time.Now()
+}
+
+// fdStreams returns the statistics of the descriptor streams in descriptor
+// order.
+func (c *StreamCapture) fdStreams() []FDStreamStats {
+	streams := make([]FDStreamStats, 0, len(c.FDs))
+	for _, stats := range c.FDs {
+		streams = append(streams, *stats)
+	}
+	sort.Slice(streams, func(i, j int) bool {
+		if streams[i].FD != streams[j].FD {
+			return streams[i].FD < streams[j].FD
+		}
+		return streams[i].Name() < streams[j].Name()
+	})
+	return streams
+}
+
+// conversationKey scopes dissector state to one conversation: the stdio of
+// a process, one of its sockets, or the pipes it shares with another
+// process, which usually come in pairs for requests and responses.
+func conversationKey(pid int, fd *FDStream) string {
+	switch {
+	case fd == nil:
+		return fmt.Sprintf("%d/stdio", pid)
+	case fd.Type == FDTypePipe && fd.Peer != "":
+		return fmt.Sprintf("%d/pipe/%s", pid, fd.Peer)
+	}
+	return fmt.Sprintf("%d/fd%d/%d", pid, fd.FD, fd.Inode)
+}
+
+// findPromptPII classifies the body of an HTTP request sent to an LLM
+// provider and reports one vulnerability per PII type found.
+func (m *CenterModule) findPromptPII(frame *Frame, stream string, target StreamTarget) []StreamVulnerability {
+	if frame.Fields["type"] != "request" {
+		return nil
+	}
+	body, _ := frame.Fields["body"].(string)
+	if body == "" {
+		return nil
+	}
+	host, _ := frame.Fields["host"].(string)
+
+	var path, provider string
+	switch frame.Protocol {
+	case "LLM":
+		path, _ = frame.Fields["path"].(string)
+		provider, _ = frame.Fields["provider"].(string)
+	case "HTTP":
+		rawURL, _ := frame.Fields["url"].(string)
+		path = rawURL
+		if u, err := url.Parse(rawURL); err == nil {
+			path = u.Path
+			if host == "" {
+				host = u.Host
+			}
+		}
+		provider = llmProviderForRequest(host, path)
+	}
+	if provider == "" {
+		return nil
+	}
+
+	matches := m.pii.Classify(body)
+	if len(matches) == 0 {
+		return nil
+	}
+	byType := make(map[string][]security.PIIMatch)
+	var types []string
+	for _, match := range matches {
+		if _, ok := byType[match.Type]; !ok {
+			types = append(types, match.Type)
+		}
+		byType[match.Type] = append(byType[match.Type], match)
+	}
+
+	m.mu.Lock()
+	if m.piiCounts[provider] == nil {
+		m.piiCounts[provider] = make(map[string]int)
+	}
+	for _, piiType := range types {
+		m.piiCounts[provider][piiType] += len(byType[piiType])
+	}
+	m.mu.Unlock()
+
+	method, _ := frame.Fields["method"].(string)
+	vulns := make([]StreamVulnerability, 0, len(types))
+	for _, piiType := range types {
+		found := byType[piiType]
+		confidence := 0.7
+		evidence := make([]string, 0, 3)
+		for i, match := range found {
+			if match.Validated {
+				confidence = 0.9
+			}
+			if i < 3 {
+				evidence = append(evidence, match.String())
+			}
+		}
+		vulns = append(vulns, StreamVulnerability{
+			ID:          fmt.Sprintf("VULN-%d", time.Now().UnixNano()),
+			Timestamp:   time.Now(),
+			Severity:    security.PIISeverity(piiType),
+			Type:        "pii",
+			Subtype:     piiType,
+			Evidence:    strings.Join(evidence, ", "),
+			Location:    stream,
+			Context:     fmt.Sprintf("HTTP %s %s%s → %s", method, host, path, provider),
+			Confidence:  confidence,
+			ProcessInfo: target,
+			Regulations: security.PIIRegulations(found),
+		})
+	}
+	return vulns
+}
+
 // collectResults aggregates monitoring results.
 func (m *CenterModule) collectResults() map[string]interface{} {
 	m.mu.RLock()
@@ -673,32 +1332,72 @@ func (m *CenterModule) collectResults() map[string]interface{} {
 	totalBytes := int64(0)
 	totalEvents := int64(0)
 	totalVulns := int64(0)
+	var observed StreamCoverage
 	processes := []map[string]interface{}{}
 
-	for pid, capture := range m.activeStreams {
+	// Processes are listed in tree order, each before its descendants
+	for _, node := range processTree(m.activeStreams) {
+		capture := node.Capture
 		totalBytes += capture.Statistics.BytesCaptured
 		totalEvents += capture.Statistics.EventsCount
 		totalVulns += capture.Statistics.VulnsFound
 
-		processes = append(processes, map[string]interface{}{
-			"pid":            pid,
+		process := map[string]interface{}{
+			"pid":            capture.Target.PID,
 			"name":           capture.Target.Name,
 			"command_line":   capture.Target.CommandLine,
 			"bytes_captured": capture.Statistics.BytesCaptured,
 			"vulns_found":    capture.Statistics.VulnsFound,
-		})
+		}
+		if len(capture.FDs) > 0 {
+			process["streams"] = capture.fdStreams()
+		}
+		if capture.Target.PPID != 0 {
+			process["ppid"] = capture.Target.PPID
+		}
+		if node.Depth > 0 {
+			process["depth"] = node.Depth
+			process["parent"] = node.Parent
+		}
+		if capture.Exited {
+			process["exited"] = true
+		}
+		if capture.Target.Container != nil {
+			process["container"] = capture.Target.Container
+		}
+		if capture.Loss != nil {
+			process["coverage"] = capture.Loss
+			observed.add(capture.Loss.total())
+		}
+		processes = append(processes, process)
 	}
 
-	return map[string]interface{}{
+	results := map[string]interface{}{
 		"processes": processes,
 		"statistics": map[string]interface{}{
-			"total_bytes":     totalBytes,
-			"total_events":    totalEvents,
-			"total_vulns":     totalVulns,
-			"processes_count": len(m.activeStreams),
+			"total_bytes":      totalBytes,
+			"total_events":     totalEvents,
+			"total_vulns":      totalVulns,
+			"processes_count":  len(m.activeStreams),
+			"bytes_seen":       observed.BytesSeen,
+			"bytes_delivered":  observed.BytesDelivered,
+			"bytes_dropped":    observed.BytesDropped,
+			"truncated_calls":  observed.TruncatedCalls,
+			"coverage_percent": observed.Percent,
 		},
 		"log_file": m.config.LogFile,
 	}
+	if len(m.piiCounts) > 0 {
+		piiByProvider := make(map[string]map[string]int, len(m.piiCounts))
+		for provider, counts := range m.piiCounts {
+			piiByProvider[provider] = make(map[string]int, len(counts))
+			for piiType, n := range counts {
+				piiByProvider[provider][piiType] = n
+			}
+		}
+		results["pii_by_provider"] = piiByProvider
+	}
+	return results
 }
 
 // Info returns module information.
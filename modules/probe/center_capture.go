@@ -12,11 +12,12 @@ import (
 
 // CaptureEngine handles stream capture from processes.
 type CaptureEngine struct {
-	mode          string // procfs, strace, auto
+	mode          string // procfs, ptrace, auto
 	activeProcs   map[int]*ProcessCapture
-	straceEnabled bool // Allow strace fallback
-	straceCaptors map[int]*StraceCapture
+	ptraceEnabled bool // Allow ptrace fallback
+	ptraceCaptors map[int]*PtraceCapture
 	captureStats  map[int]*CaptureStats
+	pollInterval  time.Duration // expected time between reads, for gap accounting
 	mu            sync.RWMutex
 }
 
@@ -34,6 +35,12 @@ type CaptureStats struct {
 	LastStderrBytes int64
 	StaticDataCount int  // How many times we got same data
 	IsUsingPTY      bool // Cached PTY detection result
+
+	// Loss accounting
+	Streams     map[string]*StreamCoverage // procfs reads, by stream name
+	PollingGaps int                        // polls late enough that data may have been missed
+	GapTime     time.Duration              // time beyond the poll interval lost to late polls
+	lastPoll    time.Time
 }
 
 // ProcessCapture tracks capture state for a single process.
@@ -50,22 +57,30 @@ func NewCaptureEngine(mode string) *CaptureEngine {
 	return &CaptureEngine{
 		mode:          mode,
 		activeProcs:   make(map[int]*ProcessCapture),
-		straceEnabled: false, // Opt-in only
-		straceCaptors: make(map[int]*StraceCapture),
+		ptraceEnabled: false, // Opt-in only
+		ptraceCaptors: make(map[int]*PtraceCapture),
 		captureStats:  make(map[int]*CaptureStats),
 	}
 }
 
-// EnableStrace enables strace fallback (opt-in).
-func (e *CaptureEngine) EnableStrace() error {
-	if err := checkStraceAvailable(); err != nil {
-		return fmt.Errorf("cannot enable strace: %w", err)
+// EnablePtrace enables ptrace fallback (opt-in).
+func (e *CaptureEngine) EnablePtrace() error {
+	if err := checkPtraceAvailable(); err != nil {
+		return fmt.Errorf("cannot enable ptrace: %w", err)
 	}
-	e.straceEnabled = true
-	fmt.Println("\033[33mWarning: Strace capture enabled. This may impact performance.\033[0m")
+	e.ptraceEnabled = true
+	fmt.Println("\033[33mWarning: Ptrace capture enabled. This may impact performance.\033[0m")
 	return nil
 }
 
+// SetPollInterval sets how often streams are read. Reads that come much
+// later are counted as polling gaps.
+func (e *CaptureEngine) SetPollInterval(interval time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.pollInterval = interval
+}
+
 // Attach begins monitoring a process.
 func (e *CaptureEngine) Attach(pid int) error {
 	e.mu.Lock()
@@ -89,7 +104,7 @@ func (e *CaptureEngine) Attach(pid int) error {
 	// Try to open file descriptors
 	// Note: We may not have access to stdin (fd/0) as it's often not readable
 	// stdout and stderr are typically symlinks to pts/pipe that we can't read directly
-	// This is why we'll need to use alternative methods like strace in production
+	// This is why we'll need to use alternative methods like ptrace in production
 
 	// For MVP, we'll attempt to read what we can
 	stdinPath := filepath.Join(procPath, "fd", "0")
@@ -121,12 +136,12 @@ func (e *CaptureEngine) Detach(pid int) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	// Stop strace if running
-	if captor, ok := e.straceCaptors[pid]; ok {
+	// Stop ptrace if running
+	if captor, ok := e.ptraceCaptors[pid]; ok {
 		if err := captor.Stop(); err != nil {
-			fmt.Printf("Warning: failed to stop strace for PID %d: %v\n", pid, err)
+			fmt.Printf("Warning: failed to stop ptrace for PID %d: %v\n", pid, err)
 		}
-		delete(e.straceCaptors, pid)
+		delete(e.ptraceCaptors, pid)
 	}
 
 	capture, exists := e.activeProcs[pid]
@@ -150,18 +165,18 @@ func (e *CaptureEngine) Detach(pid int) error {
 	return nil
 }
 
-// startStraceCapture initializes strace capture for a process.
-func (e *CaptureEngine) startStraceCapture(pid int) error {
+// startPtraceCapture initializes ptrace capture for a process.
+func (e *CaptureEngine) startPtraceCapture(pid int) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
 	// Check if already running
-	if _, exists := e.straceCaptors[pid]; exists {
+	if _, exists := e.ptraceCaptors[pid]; exists {
 		return nil
 	}
 
-	// Create strace captor
-	captor, err := NewStraceCapture(pid)
+	// Create ptrace captor
+	captor, err := NewPtraceCapture(pid)
 	if err != nil {
 		return err
 	}
@@ -171,7 +186,7 @@ func (e *CaptureEngine) startStraceCapture(pid int) error {
 		return err
 	}
 
-	e.straceCaptors[pid] = captor
+	e.ptraceCaptors[pid] = captor
 	return nil
 }
 
@@ -180,8 +195,8 @@ func (e *CaptureEngine) GetCaptureMethod(pid int) string {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
 
-	if _, ok := e.straceCaptors[pid]; ok {
-		return "strace"
+	if _, ok := e.ptraceCaptors[pid]; ok {
+		return "ptrace"
 	}
 
 	if stats, ok := e.captureStats[pid]; ok {
@@ -200,11 +215,24 @@ func (e *CaptureEngine) GetCaptureStats() map[int]*CaptureStats {
 	stats := make(map[int]*CaptureStats)
 	for pid, stat := range e.captureStats {
 		statCopy := *stat
+		statCopy.Streams = make(map[string]*StreamCoverage, len(stat.Streams))
+		for name, c := range stat.Streams {
+			cCopy := *c
+			statCopy.Streams[name] = &cCopy
+		}
 		stats[pid] = &statCopy
 	}
 	return stats
 }
 
+// Loss reports how much of a process's traffic the capture observed, from
+// procfs reads and the ptrace captor if there is one.
+func (e *CaptureEngine) Loss(pid int) CaptureLoss {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.captureStats[pid].loss(e.ptraceCaptors[pid])
+}
+
 // isUsingPTY checks if a process is using a pseudo-terminal.
 func isUsingPTY(pid int) (bool, error) {
 	fdDir := fmt.Sprintf("/proc/%d/fd", pid)
@@ -239,36 +267,33 @@ func (e *CaptureEngine) ReadStreams(pid int) (*StreamData, error) {
 		}
 		e.captureStats[pid] = stats
 	}
+	stats.recordPoll(time.Now(), e.pollInterval)
 	e.mu.Unlock()
 
-	// Check if we're already using strace for this PID
+	// Check if we're already using ptrace for this PID
 	e.mu.RLock()
-	if captor, ok := e.straceCaptors[pid]; ok {
+	if captor, ok := e.ptraceCaptors[pid]; ok {
 		e.mu.RUnlock()
 		return captor.Read()
 	}
 	e.mu.RUnlock()
 
 	// Try procfs method
-	data, err := e.readProcFS(pid)
+	data, err := e.readProcFS(pid, stats)
 	stats.Attempts++
 
 	if err == nil {
-		// Calculate new data bytes
+		// Stdio holds only the bytes read since the last poll
 		currentStdinBytes := int64(len(data.Stdin))
 		currentStdoutBytes := int64(len(data.Stdout))
 		currentStderrBytes := int64(len(data.Stderr))
 
-		// Check if we got new stream data (not just static environ)
+		// Check if we got new stream data, not just the static snapshots
 		newStreamData := false
-		if currentStdinBytes > stats.LastStdinBytes ||
-			currentStdoutBytes > stats.LastStdoutBytes ||
-			(currentStderrBytes > stats.LastStderrBytes && currentStderrBytes > 4096) {
-			// Got new data or stderr > 4KB (environ is usually < 4KB)
+		if currentStdinBytes > 0 || currentStdoutBytes > 0 || currentStderrBytes > 0 {
 			newStreamData = true
 			stats.StaticDataCount = 0
-		} else if currentStdinBytes == 0 && currentStdoutBytes == 0 && currentStderrBytes > 0 && currentStderrBytes < 4096 {
-			// Likely just environ data
+		} else if len(data.Snapshots) > 0 {
 			stats.StaticDataCount++
 		}
 
@@ -290,44 +315,44 @@ func (e *CaptureEngine) ReadStreams(pid int) (*StreamData, error) {
 	// No new stream data or error
 	stats.ConsecutiveFails++
 
-	// Check if we should try strace fallback
-	shouldUseStrace := false
-	if e.straceEnabled && e.mode != "procfs" {
-		// Trigger strace if:
+	// Check if we should try ptrace fallback
+	shouldUsePtrace := false
+	if e.ptraceEnabled && e.mode != "procfs" {
+		// Trigger ptrace if:
 		// 1. Process is using PTY
 		// 2. Getting only static data repeatedly
 		// 3. Too many consecutive failures
 		if stats.IsUsingPTY {
-			shouldUseStrace = true
-			fmt.Printf("\033[33mInfo: Process %d is using PTY, switching to strace\033[0m\n", pid)
+			shouldUsePtrace = true
+			fmt.Printf("\033[33mInfo: Process %d is using PTY, switching to ptrace\033[0m\n", pid)
 		} else if stats.StaticDataCount > 5 {
-			shouldUseStrace = true
-			fmt.Printf("\033[33mInfo: Only static data from PID %d, switching to strace\033[0m\n", pid)
+			shouldUsePtrace = true
+			fmt.Printf("\033[33mInfo: Only static data from PID %d, switching to ptrace\033[0m\n", pid)
 		} else if stats.ConsecutiveFails > 10 {
-			shouldUseStrace = true
-			fmt.Printf("\033[33mWarning: Switching to strace for PID %d after %d failed attempts\033[0m\n", pid, stats.ConsecutiveFails)
+			shouldUsePtrace = true
+			fmt.Printf("\033[33mWarning: Switching to ptrace for PID %d after %d failed attempts\033[0m\n", pid, stats.ConsecutiveFails)
 		}
 	}
 
-	if shouldUseStrace {
-		// Try to start strace capture
-		if err := e.startStraceCapture(pid); err != nil {
-			return data, fmt.Errorf("strace fallback failed: %w", err)
+	if shouldUsePtrace {
+		// Try to start ptrace capture
+		if err := e.startPtraceCapture(pid); err != nil {
+			return data, fmt.Errorf("ptrace fallback failed: %w", err)
 		}
 
 		// Update stats
-		stats.Method = "strace"
+		stats.Method = "ptrace"
 		stats.ConsecutiveFails = 0
 		stats.StaticDataCount = 0
 
-		// Try reading from strace
+		// Try reading from ptrace
 		e.mu.RLock()
-		if captor, ok := e.straceCaptors[pid]; ok {
+		if captor, ok := e.ptraceCaptors[pid]; ok {
 			e.mu.RUnlock()
 			return captor.Read()
 		}
 		e.mu.RUnlock()
-	} else if e.straceEnabled && stats.ConsecutiveFails > 0 {
+	} else if e.ptraceEnabled && stats.ConsecutiveFails > 0 {
 		// Debug output for tracking progress
 		if stats.ConsecutiveFails%5 == 0 {
 			fmt.Printf("Debug: PID %d - fails: %d, static: %d, PTY: %v\n",
@@ -339,7 +364,7 @@ func (e *CaptureEngine) ReadStreams(pid int) (*StreamData, error) {
 }
 
 // readProcFS reads streams using the procfs method.
-func (e *CaptureEngine) readProcFS(pid int) (*StreamData, error) {
+func (e *CaptureEngine) readProcFS(pid int, stats *CaptureStats) (*StreamData, error) {
 	e.mu.RLock()
 	capture, exists := e.activeProcs[pid]
 	e.mu.RUnlock()
@@ -348,20 +373,14 @@ func (e *CaptureEngine) readProcFS(pid int) (*StreamData, error) {
 		return nil, fmt.Errorf("not attached to PID %d", pid)
 	}
 
-	data := &StreamData{Timestamp: time.Now()}
-
-	// Read command line arguments (often contains passwords)
-	cmdlineData, err := e.readProcFile(pid, "cmdline")
-	if err == nil && len(cmdlineData) > 0 {
-		// Command line args are null-separated
-		data.Stdout = cmdlineData
-	}
+	data := &StreamData{Timestamp: time.Now(), Snapshots: make(map[string][]byte)}
 
-	// Read environment (often contains API keys)
-	environData, err := e.readProcFile(pid, "environ")
-	if err == nil && len(environData) > 0 {
-		// Environment vars are null-separated
-		data.Stderr = environData
+	// Command line arguments often contain passwords and the environment
+	// API keys; both are null-separated and read whole every time
+	for _, name := range []string{"cmdline", "environ"} {
+		if snapshot, err := e.readProcFile(pid, name); err == nil && len(snapshot) > 0 {
+			data.Snapshots[name] = snapshot
+		}
 	}
 
 	// Try to read from actual file descriptors if open
@@ -369,6 +388,9 @@ func (e *CaptureEngine) readProcFS(pid int) (*StreamData, error) {
 		if buf, err := e.readFromOffset(capture.stdinFile, capture.lastOffsets["stdin"]); err == nil {
 			data.Stdin = append(data.Stdin, buf...)
 			capture.lastOffsets["stdin"] += int64(len(buf))
+			if len(buf) > 0 {
+				stats.recordRead("stdin", capture.stdinFile, len(buf))
+			}
 		}
 	}
 
@@ -376,6 +398,9 @@ func (e *CaptureEngine) readProcFS(pid int) (*StreamData, error) {
 		if buf, err := e.readFromOffset(capture.stdoutFile, capture.lastOffsets["stdout"]); err == nil {
 			data.Stdout = append(data.Stdout, buf...)
 			capture.lastOffsets["stdout"] += int64(len(buf))
+			if len(buf) > 0 {
+				stats.recordRead("stdout", capture.stdoutFile, len(buf))
+			}
 		}
 	}
 
@@ -383,6 +408,9 @@ func (e *CaptureEngine) readProcFS(pid int) (*StreamData, error) {
 		if buf, err := e.readFromOffset(capture.stderrFile, capture.lastOffsets["stderr"]); err == nil {
 			data.Stderr = append(data.Stderr, buf...)
 			capture.lastOffsets["stderr"] += int64(len(buf))
+			if len(buf) > 0 {
+				stats.recordRead("stderr", capture.stderrFile, len(buf))
+			}
 		}
 	}
 
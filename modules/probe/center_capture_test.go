@@ -0,0 +1,57 @@
+package probe
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadProcFSSeparatesSnapshots(t *testing.T) {
+	// The target's stdout is a file already holding output
+	out := filepath.Join(t.TempDir(), "stdout")
+	if err := os.WriteFile(out, []byte("hello\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := os.OpenFile(out, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdout.Close()
+
+	cmd := exec.Command("sleep", "5")
+	cmd.Env = []string{"STRIGOI_TEST_KEY=sk-test"}
+	cmd.Stdout = stdout
+	if err := cmd.Start(); err != nil {
+		t.Skipf("cannot start sleep: %v", err)
+	}
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+
+	engine := NewCaptureEngine("procfs")
+	if err := engine.Attach(cmd.Process.Pid); err != nil {
+		t.Fatal(err)
+	}
+	defer engine.Detach(cmd.Process.Pid)
+
+	data, err := engine.ReadStreams(cmd.Process.Pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data.Stdout) != "hello\n" {
+		t.Errorf("stdout = %q, want only the new output", data.Stdout)
+	}
+	if !strings.HasPrefix(string(data.Snapshots["cmdline"]), "sleep\x005") ||
+		!strings.Contains(string(data.Snapshots["environ"]), "STRIGOI_TEST_KEY=sk-test") {
+		t.Errorf("snapshots = %q", data.Snapshots)
+	}
+
+	// Later polls repeat the snapshots but not the output already read
+	data, _ = engine.ReadStreams(cmd.Process.Pid)
+	if data == nil || len(data.Stdout) != 0 || len(data.Snapshots["environ"]) == 0 {
+		t.Errorf("second poll = %+v", data)
+	}
+}
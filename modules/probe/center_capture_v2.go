@@ -9,22 +9,28 @@ import (
 	"time"
 )
 
-// CaptureEngineV2 handles stream capture with circular buffers.
+// eventPollInterval is how often CaptureEngineV2 collects complete events
+// from its stream buffers.
+const eventPollInterval = 5 * time.Millisecond
+
+// CaptureEngineV2 handles stream capture with lock-free event buffers.
 type CaptureEngineV2 struct {
-	mode          string // procfs, strace, auto
+	mode          string // procfs, ptrace, auto
 	activeProcs   map[int]*ProcessCaptureV2
-	straceEnabled bool
-	straceCaptors map[int]*StraceCapture
+	ptraceEnabled bool
+	ptraceCaptors map[int]*PtraceCapture
 	captureStats  map[int]*CaptureStats
 
 	// Buffer configuration
 	bufferSize     int
 	eventDelimiter []byte
+	overflow       OverflowPolicy
+	pollInterval   time.Duration // expected time between captures, for gap accounting
 
 	mu sync.RWMutex
 }
 
-// ProcessCaptureV2 tracks capture state with circular buffers.
+// ProcessCaptureV2 tracks capture state with event buffers.
 type ProcessCaptureV2 struct {
 	pid         int
 	stdinFile   *os.File
@@ -32,10 +38,10 @@ type ProcessCaptureV2 struct {
 	stderrFile  *os.File
 	lastOffsets map[string]int64
 
-	// Circular buffers for each stream
-	stdinBuffer  *LockFreeCircularBufferV3
-	stdoutBuffer *LockFreeCircularBufferV3
-	stderrBuffer *LockFreeCircularBufferV3
+	// Event buffers for each stream
+	stdinBuffer  EventBuffer
+	stdoutBuffer EventBuffer
+	stderrBuffer EventBuffer
 
 	// Event channels aggregated from all streams
 	events chan StreamEvent
@@ -51,7 +57,9 @@ type StreamEvent struct {
 	Sequence  uint64
 }
 
-// NewCaptureEngineV2 creates a new capture engine with circular buffers.
+// NewCaptureEngineV2 creates a new capture engine with lock-free event
+// buffers. Events end at delimiter; a nil delimiter delivers whatever each
+// poll collected.
 func NewCaptureEngineV2(bufferSize int, delimiter []byte) *CaptureEngineV2 {
 	if bufferSize == 0 {
 		bufferSize = 1024 * 1024 // Default 1MB per stream
@@ -60,25 +68,56 @@ func NewCaptureEngineV2(bufferSize int, delimiter []byte) *CaptureEngineV2 {
 	return &CaptureEngineV2{
 		mode:           "auto",
 		activeProcs:    make(map[int]*ProcessCaptureV2),
-		straceEnabled:  false,
-		straceCaptors:  make(map[int]*StraceCapture),
+		ptraceEnabled:  false,
+		ptraceCaptors:  make(map[int]*PtraceCapture),
 		captureStats:   make(map[int]*CaptureStats),
 		bufferSize:     bufferSize,
 		eventDelimiter: delimiter,
+		overflow:       OverflowDropOldest,
 	}
 }
 
-// EnableStrace enables strace fallback.
-func (e *CaptureEngineV2) EnableStrace() error {
-	if err := checkStraceAvailable(); err != nil {
-		return fmt.Errorf("cannot enable strace: %w", err)
+// SetOverflowPolicy sets what the stream buffers of processes attached
+// afterwards do when a reader falls behind.
+func (e *CaptureEngineV2) SetOverflowPolicy(policy OverflowPolicy) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.overflow = policy
+}
+
+// SetPollInterval sets how often CaptureStreams is called. Calls that come
+// much later are counted as polling gaps.
+func (e *CaptureEngineV2) SetPollInterval(interval time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.pollInterval = interval
+}
+
+// newBuffer creates the event buffer for one stream. It is called with mu
+// held.
+func (e *CaptureEngineV2) newBuffer() (EventBuffer, error) {
+	cfg := EventBufferConfig{
+		Size:     e.bufferSize,
+		Backend:  BufferBackendLockFree,
+		Overflow: e.overflow,
 	}
-	e.straceEnabled = true
-	fmt.Println("\033[33mWarning: Strace capture enabled. This may impact performance.\033[0m")
+	if len(e.eventDelimiter) > 0 {
+		cfg.Detector = NewDelimiterBoundaryDetector(e.eventDelimiter)
+	}
+	return NewEventBuffer(cfg)
+}
+
+// EnablePtrace enables ptrace fallback.
+func (e *CaptureEngineV2) EnablePtrace() error {
+	if err := checkPtraceAvailable(); err != nil {
+		return fmt.Errorf("cannot enable ptrace: %w", err)
+	}
+	e.ptraceEnabled = true
+	fmt.Println("\033[33mWarning: Ptrace capture enabled. This may impact performance.\033[0m")
 	return nil
 }
 
-// Attach begins monitoring a process with circular buffers.
+// Attach begins monitoring a process with event buffers.
 func (e *CaptureEngineV2) Attach(pid int) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
@@ -93,18 +132,18 @@ func (e *CaptureEngineV2) Attach(pid int) error {
 		return fmt.Errorf("process %d not found: %w", pid, err)
 	}
 
-	// Create circular buffers for each stream
-	stdinBuf, err := NewLockFreeCircularBufferV3(e.bufferSize, e.eventDelimiter)
+	// Create event buffers for each stream
+	stdinBuf, err := e.newBuffer()
 	if err != nil {
 		return fmt.Errorf("failed to create stdin buffer: %w", err)
 	}
 
-	stdoutBuf, err := NewLockFreeCircularBufferV3(e.bufferSize, e.eventDelimiter)
+	stdoutBuf, err := e.newBuffer()
 	if err != nil {
 		return fmt.Errorf("failed to create stdout buffer: %w", err)
 	}
 
-	stderrBuf, err := NewLockFreeCircularBufferV3(e.bufferSize, e.eventDelimiter)
+	stderrBuf, err := e.newBuffer()
 	if err != nil {
 		return fmt.Errorf("failed to create stderr buffer: %w", err)
 	}
@@ -154,43 +193,50 @@ func (e *CaptureEngineV2) Attach(pid int) error {
 
 // aggregateEvents combines events from all stream buffers.
 func (pc *ProcessCaptureV2) aggregateEvents() {
+	streams := []struct {
+		name   string
+		buffer EventBuffer
+	}{
+		{"stdin", pc.stdinBuffer},
+		{"stdout", pc.stdoutBuffer},
+		{"stderr", pc.stderrBuffer},
+	}
+
+	ticker := time.NewTicker(eventPollInterval)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case <-pc.done:
 			close(pc.events)
 			return
 
-		case event := <-pc.stdinBuffer.Events():
-			pc.events <- StreamEvent{
-				PID:       pc.pid,
-				Stream:    "stdin",
-				Data:      event.Data,
-				Timestamp: event.Timestamp,
-				Sequence:  event.Sequence,
-			}
-
-		case event := <-pc.stdoutBuffer.Events():
-			pc.events <- StreamEvent{
-				PID:       pc.pid,
-				Stream:    "stdout",
-				Data:      event.Data,
-				Timestamp: event.Timestamp,
-				Sequence:  event.Sequence,
-			}
-
-		case event := <-pc.stderrBuffer.Events():
-			pc.events <- StreamEvent{
-				PID:       pc.pid,
-				Stream:    "stderr",
-				Data:      event.Data,
-				Timestamp: event.Timestamp,
-				Sequence:  event.Sequence,
+		case <-ticker.C:
+			for _, stream := range streams {
+				for {
+					event, ok := stream.buffer.ReadEvent()
+					if !ok {
+						break
+					}
+					select {
+					case pc.events <- StreamEvent{
+						PID:       pc.pid,
+						Stream:    stream.name,
+						Data:      event.Data,
+						Timestamp: event.Timestamp,
+						Sequence:  event.Sequence,
+					}:
+					case <-pc.done:
+						close(pc.events)
+						return
+					}
+				}
 			}
 		}
 	}
 }
 
-// CaptureStreams reads new data from process streams into circular buffers.
+// CaptureStreams reads new data from process streams into event buffers.
 func (e *CaptureEngineV2) CaptureStreams(pid int) error {
 	e.mu.RLock()
 	capture, exists := e.activeProcs[pid]
@@ -200,8 +246,11 @@ func (e *CaptureEngineV2) CaptureStreams(pid int) error {
 		return fmt.Errorf("not attached to PID %d", pid)
 	}
 
+	e.mu.Lock()
 	stats := e.captureStats[pid]
 	stats.Attempts++
+	stats.recordPoll(time.Now(), e.pollInterval)
+	e.mu.Unlock()
 
 	// Try procfs method first
 	bytesRead := int64(0)
@@ -213,6 +262,7 @@ func (e *CaptureEngineV2) CaptureStreams(pid int) error {
 			&offset); err == nil {
 			bytesRead += n
 			capture.lastOffsets["stdin"] = offset
+			e.recordSeen(stats, "stdin", n)
 		}
 	}
 
@@ -223,6 +273,7 @@ func (e *CaptureEngineV2) CaptureStreams(pid int) error {
 			&offset); err == nil {
 			bytesRead += n
 			capture.lastOffsets["stdout"] = offset
+			e.recordSeen(stats, "stdout", n)
 		}
 	}
 
@@ -233,6 +284,7 @@ func (e *CaptureEngineV2) CaptureStreams(pid int) error {
 			&offset); err == nil {
 			bytesRead += n
 			capture.lastOffsets["stderr"] = offset
+			e.recordSeen(stats, "stderr", n)
 		}
 	}
 
@@ -245,17 +297,17 @@ func (e *CaptureEngineV2) CaptureStreams(pid int) error {
 	} else {
 		stats.ConsecutiveFails++
 
-		// Check if we should try strace
-		if e.shouldUseStrace(pid, stats) {
-			return e.captureWithStrace(pid)
+		// Check if we should try ptrace
+		if e.shouldUsePtrace(pid, stats) {
+			return e.captureWithPtrace(pid)
 		}
 	}
 
 	return nil
 }
 
-// readStreamToBuffer reads from a file into a circular buffer.
-func (e *CaptureEngineV2) readStreamToBuffer(file *os.File, buffer *LockFreeCircularBufferV3,
+// readStreamToBuffer reads from a file into an event buffer.
+func (e *CaptureEngineV2) readStreamToBuffer(file *os.File, buffer EventBuffer,
 	lastOffset *int64) (int64, error) {
 
 	// Read in chunks
@@ -265,10 +317,8 @@ func (e *CaptureEngineV2) readStreamToBuffer(file *os.File, buffer *LockFreeCirc
 	for {
 		n, err := file.ReadAt(chunk, *lastOffset)
 		if n > 0 {
-			// Write to circular buffer
+			// Write to the event buffer, which applies its overflow policy
 			if _, writeErr := buffer.Write(chunk[:n]); writeErr != nil {
-				// Buffer full or other error, but we still read the data
-				// The buffer will handle backpressure appropriately
 				log.Printf("Buffer write error (continuing): %v", writeErr)
 			}
 
@@ -284,13 +334,23 @@ func (e *CaptureEngineV2) readStreamToBuffer(file *os.File, buffer *LockFreeCirc
 	return totalRead, nil
 }
 
-// shouldUseStrace determines if we should fallback to strace.
-func (e *CaptureEngineV2) shouldUseStrace(pid int, stats *CaptureStats) bool {
-	if !e.straceEnabled {
+// recordSeen counts n bytes of a stream written to its event buffer.
+func (e *CaptureEngineV2) recordSeen(stats *CaptureStats, stream string, n int64) {
+	if n == 0 {
+		return
+	}
+	e.mu.Lock()
+	stats.stream(stream).BytesSeen += n
+	e.mu.Unlock()
+}
+
+// shouldUsePtrace determines if we should fallback to ptrace.
+func (e *CaptureEngineV2) shouldUsePtrace(pid int, stats *CaptureStats) bool {
+	if !e.ptraceEnabled {
 		return false
 	}
 
-	// Use strace if:
+	// Use ptrace if:
 	// 1. We've failed multiple times
 	// 2. Process is using PTY
 	// 3. No successful reads in the last minute
@@ -299,58 +359,64 @@ func (e *CaptureEngineV2) shouldUseStrace(pid int, stats *CaptureStats) bool {
 		(stats.Successful == 0 && stats.Attempts > 10)
 }
 
-// captureWithStrace uses strace to capture streams.
-func (e *CaptureEngineV2) captureWithStrace(pid int) error {
+// captureWithPtrace uses ptrace to capture streams.
+func (e *CaptureEngineV2) captureWithPtrace(pid int) error {
 	e.mu.RLock()
 	capture := e.activeProcs[pid]
-	strace, straceExists := e.straceCaptors[pid]
+	ptrace, ptraceExists := e.ptraceCaptors[pid]
 	e.mu.RUnlock()
 
-	if !straceExists {
-		if err := e.startStraceCapture(pid); err != nil {
+	if !ptraceExists {
+		if err := e.startPtraceCapture(pid); err != nil {
 			return err
 		}
-		strace = e.straceCaptors[pid]
+		ptrace = e.ptraceCaptors[pid]
 	}
 
-	// Read from strace output
-	if streamData, err := strace.Read(); err == nil && streamData != nil {
+	// Read captured data
+	if streamData, err := ptrace.Read(); err == nil && streamData != nil {
 		// Write captured data to appropriate buffers
+		stats := e.captureStats[pid]
 		if len(streamData.Stdin) > 0 {
 			if _, err := capture.stdinBuffer.Write(streamData.Stdin); err != nil {
 				log.Printf("Failed to write stdin data: %v", err)
 			}
+			e.recordSeen(stats, "stdin", int64(len(streamData.Stdin)))
 		}
 		if len(streamData.Stdout) > 0 {
 			if _, err := capture.stdoutBuffer.Write(streamData.Stdout); err != nil {
 				log.Printf("Failed to write stdout data: %v", err)
 			}
+			e.recordSeen(stats, "stdout", int64(len(streamData.Stdout)))
 		}
 		if len(streamData.Stderr) > 0 {
 			if _, err := capture.stderrBuffer.Write(streamData.Stderr); err != nil {
 				log.Printf("Failed to write stderr data: %v", err)
 			}
+			e.recordSeen(stats, "stderr", int64(len(streamData.Stderr)))
 		}
 
-		stats := e.captureStats[pid]
-		stats.Method = "strace"
+		stats.Method = "ptrace"
 		stats.Successful++
 		stats.BytesCapured += int64(len(streamData.Stdin) + len(streamData.Stdout) + len(streamData.Stderr))
+		for _, fd := range streamData.FDs {
+			stats.BytesCapured += int64(len(fd.Data))
+		}
 	}
 
 	return nil
 }
 
-// startStraceCapture initializes strace capture.
-func (e *CaptureEngineV2) startStraceCapture(pid int) error {
+// startPtraceCapture initializes ptrace capture.
+func (e *CaptureEngineV2) startPtraceCapture(pid int) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	if _, exists := e.straceCaptors[pid]; exists {
+	if _, exists := e.ptraceCaptors[pid]; exists {
 		return nil
 	}
 
-	captor, err := NewStraceCapture(pid)
+	captor, err := NewPtraceCapture(pid)
 	if err != nil {
 		return err
 	}
@@ -359,7 +425,7 @@ func (e *CaptureEngineV2) startStraceCapture(pid int) error {
 		return err
 	}
 
-	e.straceCaptors[pid] = captor
+	e.ptraceCaptors[pid] = captor
 	return nil
 }
 
@@ -395,17 +461,72 @@ func (e *CaptureEngineV2) GetBufferStats(pid int) (map[string]interface{}, error
 	return stats, nil
 }
 
+// Loss reports how much of a process's traffic reached the event channel.
+// Bytes written to a stream's buffer count as seen, and are delivered
+// unless the buffer dropped them or still holds them; what the ptrace
+// captor saw but never handed over is added.
+func (e *CaptureEngineV2) Loss(pid int) (CaptureLoss, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	capture, exists := e.activeProcs[pid]
+	if !exists {
+		return CaptureLoss{}, fmt.Errorf("not attached to PID %d", pid)
+	}
+	stats := e.captureStats[pid]
+	loss := CaptureLoss{
+		Method:      stats.Method,
+		Streams:     make(map[string]StreamCoverage),
+		PollingGaps: stats.PollingGaps,
+		GapTime:     stats.GapTime,
+	}
+
+	var captured map[string]StreamCoverage
+	if captor, ok := e.ptraceCaptors[pid]; ok {
+		captured = captor.Coverage()
+	}
+	for _, stream := range []struct {
+		name   string
+		buffer EventBuffer
+	}{
+		{"stdin", capture.stdinBuffer},
+		{"stdout", capture.stdoutBuffer},
+		{"stderr", capture.stderrBuffer},
+	} {
+		var cov StreamCoverage
+		if seen, ok := stats.Streams[stream.name]; ok {
+			cov.BytesSeen = seen.BytesSeen
+		}
+		buffered := stream.buffer.Stats()
+		cov.BytesDropped = int64(buffered.BytesDropped)
+		cov.BytesDelivered = max(0, cov.BytesSeen-cov.BytesDropped-int64(buffered.Used))
+
+		if c, ok := captured[stream.name]; ok {
+			cov.BytesSeen += c.BytesSeen - c.BytesDelivered
+			cov.BytesDropped += c.BytesDropped
+			cov.TruncatedCalls += c.TruncatedCalls
+		}
+		if cov.BytesSeen == 0 {
+			continue
+		}
+		cov.updatePercent()
+		loss.Streams[stream.name] = cov
+	}
+	loss.Percent = loss.total().Percent
+	return loss, nil
+}
+
 // Detach stops monitoring a process.
 func (e *CaptureEngineV2) Detach(pid int) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	// Stop strace if running
-	if captor, ok := e.straceCaptors[pid]; ok {
+	// Stop ptrace if running
+	if captor, ok := e.ptraceCaptors[pid]; ok {
 		if err := captor.Stop(); err != nil {
-			fmt.Printf("Warning: failed to stop strace for PID %d: %v\n", pid, err)
+			fmt.Printf("Warning: failed to stop ptrace for PID %d: %v\n", pid, err)
 		}
-		delete(e.straceCaptors, pid)
+		delete(e.ptraceCaptors, pid)
 	}
 
 	capture, exists := e.activeProcs[pid]
@@ -416,7 +537,7 @@ func (e *CaptureEngineV2) Detach(pid int) error {
 	// Signal shutdown
 	close(capture.done)
 
-	// Close circular buffers
+	// Close event buffers
 	capture.stdinBuffer.Close()
 	capture.stdoutBuffer.Close()
 	capture.stderrBuffer.Close()
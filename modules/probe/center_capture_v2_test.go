@@ -11,9 +11,9 @@ func TestCaptureEngineV2_BasicCapture(t *testing.T) {
 	// Create test engine with newline delimiter
 	engine := NewCaptureEngineV2(64*1024, []byte("\n")) // 64KB buffers
 
-	// Enable strace for capturing terminal output
-	if err := engine.EnableStrace(); err != nil {
-		t.Skipf("Strace not available: %v", err)
+	// Enable ptrace for capturing terminal output
+	if err := engine.EnablePtrace(); err != nil {
+		t.Skipf("Ptrace not available: %v", err)
 	}
 
 	// Start a test process that outputs data
@@ -101,8 +101,8 @@ loop:
 
 	// Log buffer performance
 	for stream, stat := range stats {
-		if m, ok := stat.(map[string]interface{}); ok {
-			t.Logf("%s buffer: %+v", stream, m)
+		if b, ok := stat.(BufferStats); ok {
+			t.Logf("%s buffer: %+v", stream, b)
 		}
 	}
 }
@@ -189,17 +189,9 @@ finished:
 
 	// Get final stats
 	stats, _ := engine.GetBufferStats(pid)
-	if stdoutStats, ok := stats["stdout"].(map[string]interface{}); ok {
-		t.Logf("Stdout buffer stats: written=%v, dropped=%v, events=%v",
-			stdoutStats["written"], stdoutStats["dropped"], stdoutStats["events_sent"])
-
-		// Check adaptive scan interval
-		if interval, ok := stdoutStats["scan_interval"].(time.Duration); ok {
-			t.Logf("Adaptive scan interval: %v", interval)
-			if interval > time.Millisecond {
-				t.Log("Warning: Scan interval might be too high for real-time capture")
-			}
-		}
+	if stdoutStats, ok := stats["stdout"].(BufferStats); ok {
+		t.Logf("Stdout buffer stats: written=%d, dropped=%d, events=%d",
+			stdoutStats.BytesWritten, stdoutStats.BytesDropped, stdoutStats.EventsRead)
 	}
 }
 
@@ -256,17 +248,26 @@ loop:
 	cmd.Wait()
 
 	stats, _ := engine.GetBufferStats(pid)
-	if stdoutStats, ok := stats["stdout"].(map[string]interface{}); ok {
+	if stdoutStats, ok := stats["stdout"].(BufferStats); ok {
 		t.Logf("Backpressure test - Stdout stats: %+v", stdoutStats)
 
 		// We expect some drops due to backpressure
-		if dropped, ok := stdoutStats["dropped"].(uint64); ok && dropped == 0 {
+		if stdoutStats.BytesDropped == 0 {
 			t.Log("Warning: Expected some dropped data due to backpressure")
 		}
+	}
 
-		// Check if backpressure was triggered
-		if bp, ok := stdoutStats["backpressure"].(bool); ok && bp {
-			t.Log("Backpressure was triggered as expected")
+	// Drops show up as lost coverage
+	loss, err := engine.Loss(pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, cov := range loss.Streams {
+		if cov.BytesDelivered+cov.BytesDropped > cov.BytesSeen {
+			t.Errorf("%s: delivered %d and dropped %d of %d bytes seen", name, cov.BytesDelivered, cov.BytesDropped, cov.BytesSeen)
+		}
+		if cov.BytesDropped > 0 && cov.Percent != nil && *cov.Percent == 100 {
+			t.Errorf("%s: full coverage despite drops: %+v", name, cov)
 		}
 	}
 }
@@ -362,10 +363,9 @@ func BenchmarkCaptureEngineV2(b *testing.B) {
 
 	// Report stats
 	stats, _ := engine.GetBufferStats(pid)
-	if stdoutStats, ok := stats["stdout"].(map[string]interface{}); ok {
-		if written, ok := stdoutStats["written"].(uint64); ok {
-			b.Logf("Total bytes written: %d", written)
-			b.Logf("Throughput: %.2f MB/s", float64(written)/b.Elapsed().Seconds()/1024/1024)
-		}
+	if stdoutStats, ok := stats["stdout"].(BufferStats); ok {
+		written := stdoutStats.BytesWritten
+		b.Logf("Total bytes written: %d", written)
+		b.Logf("Throughput: %.2f MB/s", float64(written)/b.Elapsed().Seconds()/1024/1024)
 	}
 }
@@ -0,0 +1,388 @@
+package probe
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Container runtimes a process can be attributed to.
+const (
+	RuntimeDocker     = "docker"
+	RuntimeContainerd = "containerd"
+	RuntimeCRIO       = "crio"
+	RuntimePodman     = "podman"
+)
+
+// defaultRuntimeStateDirs are searched for container names and pod labels
+// when they exist. Resolution works without them; containers are then
+// known by ID only, and pods by what their processes expose under /proc.
+var defaultRuntimeStateDirs = []string{
+	"/var/lib/docker",
+	"/run/containerd",
+	"/run/containers/storage",
+	"/var/lib/containers/storage",
+}
+
+// ContainerInfo identifies the container a monitored process runs in.
+type ContainerInfo struct {
+	ID           string `json:"id"`
+	Runtime      string `json:"runtime,omitempty"` // docker, containerd, crio or podman; empty if the cgroup does not say
+	Name         string `json:"name,omitempty"`
+	Image        string `json:"image,omitempty"`
+	PodName      string `json:"pod_name,omitempty"`
+	PodNamespace string `json:"pod_namespace,omitempty"`
+	PodUID       string `json:"pod_uid,omitempty"`
+	MountNS      string `json:"mount_ns,omitempty"` // e.g. mnt:[4026532301]
+}
+
+// ShortID is the abbreviated ID container tools display.
+func (c *ContainerInfo) ShortID() string {
+	if len(c.ID) > 12 {
+		return c.ID[:12]
+	}
+	return c.ID
+}
+
+// containerProcess is a host process found in a container.
+type containerProcess struct {
+	PID       int
+	Container *ContainerInfo
+}
+
+var (
+	// A container's cgroup, by runtime and cgroup driver:
+	//   /docker/<id>, /system.slice/docker-<id>.scope
+	//   /kubepods/burstable/pod<uid>/<id>
+	//   /kubepods.slice/.../cri-containerd-<id>.scope, crio-<id>.scope
+	//   /machine.slice/libpod-<id>.scope
+	containerCgroupPattern = regexp.MustCompile(`^(?:(docker|cri-containerd|crio|libpod)-)?([0-9a-f]{64})(?:\.scope)?$`)
+
+	// pod<uid> with cgroupfs, kubepods-<qos>-pod<uid>.slice with systemd,
+	// which writes the dashes of the UID as underscores
+	podCgroupPattern = regexp.MustCompile(`pod([0-9a-f]{8}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{12})(?:\.slice)?$`)
+)
+
+// parseContainerCgroup finds the container ID in the contents of a
+// /proc/<pid>/cgroup file. The ID is empty for processes outside
+// containers.
+func parseContainerCgroup(data []byte) (id, runtime, podUID string) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		// hierarchy-ID:controllers:path
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		components := strings.Split(parts[2], "/")
+		for i := len(components) - 1; i >= 0 && id == ""; i-- {
+			match := containerCgroupPattern.FindStringSubmatch(components[i])
+			if match == nil {
+				continue
+			}
+			id = match[2]
+			switch match[1] {
+			case "docker":
+				runtime = RuntimeDocker
+			case "cri-containerd":
+				runtime = RuntimeContainerd
+			case "crio":
+				runtime = RuntimeCRIO
+			case "libpod":
+				runtime = RuntimePodman
+			default:
+				if i > 0 && components[i-1] == "docker" {
+					runtime = RuntimeDocker
+				}
+			}
+		}
+		if id == "" {
+			continue
+		}
+		for _, component := range components {
+			if match := podCgroupPattern.FindStringSubmatch(component); match != nil {
+				podUID = strings.ReplaceAll(match[1], "_", "-")
+			}
+		}
+		return id, runtime, podUID
+	}
+	return "", "", ""
+}
+
+// containerResolver maps containers and pods to the host processes running
+// in them, from /proc alone. Runtime state directories, when present, add
+// names and labels; no runtime daemon is contacted.
+type containerResolver struct {
+	procRoot  string
+	stateDirs []string
+}
+
+// newContainerResolver creates a resolver reading procRoot. stateDirs
+// defaults to the usual locations of Docker, containerd, CRI-O and Podman.
+func newContainerResolver(procRoot string, stateDirs []string) *containerResolver {
+	if stateDirs == nil {
+		stateDirs = defaultRuntimeStateDirs
+	}
+	return &containerResolver{procRoot: procRoot, stateDirs: stateDirs}
+}
+
+// isContainerTarget reports whether a target names a container or pod.
+func isContainerTarget(target string) bool {
+	return strings.HasPrefix(target, "container:") || strings.HasPrefix(target, "pod:")
+}
+
+// resolve lists the processes of the container or pod a target names:
+// "container:<id|name>" matches a unique ID prefix or a container name,
+// "pod:<namespace>/<name>" every container of the pod. Processes that
+// share a matched container's mount namespace without being in its cgroup,
+// such as those entered with nsenter, are included.
+func (r *containerResolver) resolve(target string) ([]containerProcess, error) {
+	var match func(*ContainerInfo) bool
+	if ref, ok := strings.CutPrefix(target, "container:"); ok {
+		if ref == "" {
+			return nil, fmt.Errorf("invalid target %q: container ID or name required", target)
+		}
+		match = func(c *ContainerInfo) bool {
+			return strings.HasPrefix(c.ID, ref) || c.Name == ref
+		}
+	} else if ref, ok := strings.CutPrefix(target, "pod:"); ok {
+		namespace, name, ok := strings.Cut(ref, "/")
+		if !ok || namespace == "" || name == "" {
+			return nil, fmt.Errorf("invalid target %q: expected pod:<namespace>/<name>", target)
+		}
+		match = func(c *ContainerInfo) bool {
+			return c.PodNamespace == namespace && c.PodName == name
+		}
+	} else {
+		return nil, fmt.Errorf("invalid target %q: not a container or pod", target)
+	}
+
+	entries, err := os.ReadDir(r.procRoot)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", r.procRoot, err)
+	}
+
+	containers := make(map[string]*ContainerInfo)
+	var members []containerProcess
+	outside := make(map[int]string) // processes in no container, by mount namespace
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(r.procRoot, entry.Name(), "cgroup"))
+		if err != nil {
+			continue
+		}
+		id, runtime, podUID := parseContainerCgroup(data)
+		if id == "" {
+			if ns := r.mountNamespace(pid); ns != "" {
+				outside[pid] = ns
+			}
+			continue
+		}
+
+		container, ok := containers[id]
+		if !ok {
+			container = &ContainerInfo{ID: id, Runtime: runtime, PodUID: podUID, MountNS: r.mountNamespace(pid)}
+			r.lookupState(container)
+			if podUID != "" && (container.PodName == "" || container.PodNamespace == "") {
+				r.lookupPod(pid, container)
+			}
+			containers[id] = container
+		}
+		if match(container) {
+			members = append(members, containerProcess{PID: pid, Container: container})
+		}
+	}
+
+	matched := make(map[string]bool)
+	namespaces := make(map[string]*ContainerInfo)
+	for _, member := range members {
+		matched[member.Container.ID] = true
+		if ns := member.Container.MountNS; ns != "" {
+			namespaces[ns] = member.Container
+		}
+	}
+	if len(matched) == 0 {
+		return nil, nil
+	}
+	if strings.HasPrefix(target, "container:") && len(matched) > 1 {
+		ids := make([]string, 0, len(matched))
+		for id := range matched {
+			ids = append(ids, containers[id].ShortID())
+		}
+		sort.Strings(ids)
+		return nil, fmt.Errorf("target %q is ambiguous: matches containers %s", target, strings.Join(ids, ", "))
+	}
+
+	for pid, ns := range outside {
+		if container, ok := namespaces[ns]; ok {
+			members = append(members, containerProcess{PID: pid, Container: container})
+		}
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].PID < members[j].PID })
+	return members, nil
+}
+
+// mountNamespace returns the mount namespace of pid, such as
+// "mnt:[4026532301]".
+func (r *containerResolver) mountNamespace(pid int) string {
+	ns, err := os.Readlink(filepath.Join(r.procRoot, strconv.Itoa(pid), "ns", "mnt"))
+	if err != nil {
+		return ""
+	}
+	return ns
+}
+
+// lookupPod fills in the pod of a container from one of its processes: the
+// kubelet sets HOSTNAME to the pod name, and the service account mount
+// holds the namespace.
+func (r *containerResolver) lookupPod(pid int, container *ContainerInfo) {
+	dir := filepath.Join(r.procRoot, strconv.Itoa(pid))
+	if container.PodName == "" {
+		if environ, err := os.ReadFile(filepath.Join(dir, "environ")); err == nil {
+			for _, variable := range bytes.Split(environ, []byte{0}) {
+				if name, ok := bytes.CutPrefix(variable, []byte("HOSTNAME=")); ok {
+					container.PodName = string(name)
+				}
+			}
+		}
+	}
+	if container.PodNamespace == "" {
+		namespace, err := os.ReadFile(filepath.Join(dir, "root", "var", "run", "secrets", "kubernetes.io", "serviceaccount", "namespace"))
+		if err == nil {
+			container.PodNamespace = strings.TrimSpace(string(namespace))
+		}
+	}
+}
+
+// lookupState adds the name, image and pod labels of a container from the
+// first runtime state directory that knows it.
+func (r *containerResolver) lookupState(container *ContainerInfo) {
+	for _, dir := range r.stateDirs {
+		if lookupDockerState(dir, container) || lookupContainerdState(dir, container) || lookupStorageState(dir, container) {
+			return
+		}
+	}
+}
+
+// lookupDockerState reads <dir>/containers/<id>/config.v2.json.
+func lookupDockerState(dir string, container *ContainerInfo) bool {
+	data, err := os.ReadFile(filepath.Join(dir, "containers", container.ID, "config.v2.json"))
+	if err != nil {
+		return false
+	}
+	var config struct {
+		Name   string
+		Config struct {
+			Image  string
+			Labels map[string]string
+		}
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return false
+	}
+	container.Runtime = RuntimeDocker
+	container.Name = strings.TrimPrefix(config.Name, "/")
+	container.Image = config.Config.Image
+	applyContainerLabels(container, config.Config.Labels)
+	return true
+}
+
+// lookupContainerdState reads the OCI bundle of a containerd task,
+// <dir>/io.containerd.runtime.v2.task/<namespace>/<id>/config.json.
+func lookupContainerdState(dir string, container *ContainerInfo) bool {
+	bundles, _ := filepath.Glob(filepath.Join(dir, "io.containerd.runtime.v2.task", "*", container.ID, "config.json"))
+	for _, bundle := range bundles {
+		if annotations, ok := readOCIAnnotations(bundle); ok {
+			container.Runtime = RuntimeContainerd
+			applyContainerLabels(container, annotations)
+			return true
+		}
+	}
+	return false
+}
+
+// lookupStorageState reads the containers/storage layout shared by CRI-O
+// and Podman: names from <dir>/overlay-containers/containers.json and
+// annotations from the container's userdata/config.json.
+func lookupStorageState(dir string, container *ContainerInfo) bool {
+	containersDir := filepath.Join(dir, "overlay-containers")
+	found := false
+	if data, err := os.ReadFile(filepath.Join(containersDir, "containers.json")); err == nil {
+		var entries []struct {
+			ID    string   `json:"id"`
+			Names []string `json:"names"`
+		}
+		if json.Unmarshal(data, &entries) == nil {
+			for _, entry := range entries {
+				if entry.ID == container.ID && len(entry.Names) > 0 {
+					container.Name = entry.Names[0]
+					found = true
+				}
+			}
+		}
+	}
+	if annotations, ok := readOCIAnnotations(filepath.Join(containersDir, container.ID, "userdata", "config.json")); ok {
+		applyContainerLabels(container, annotations)
+		found = true
+	}
+	if found && container.Runtime == "" {
+		container.Runtime = RuntimePodman
+		if container.PodUID != "" {
+			container.Runtime = RuntimeCRIO
+		}
+	}
+	return found
+}
+
+// readOCIAnnotations returns the annotations of an OCI runtime spec.
+func readOCIAnnotations(path string) (map[string]string, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var spec struct {
+		Annotations map[string]string `json:"annotations"`
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, false
+	}
+	return spec.Annotations, true
+}
+
+// applyContainerLabels takes names from the labels and annotations that
+// Kubernetes runtimes and nerdctl set on containers.
+func applyContainerLabels(container *ContainerInfo, labels map[string]string) {
+	first := func(keys ...string) string {
+		for _, key := range keys {
+			if value := labels[key]; value != "" {
+				return value
+			}
+		}
+		return ""
+	}
+	if name := first("io.kubernetes.container.name", "io.kubernetes.cri.container-name", "nerdctl/name"); name != "" {
+		container.Name = name
+	}
+	if pod := first("io.kubernetes.pod.name", "io.kubernetes.cri.sandbox-name"); pod != "" {
+		container.PodName = pod
+	}
+	if namespace := first("io.kubernetes.pod.namespace", "io.kubernetes.cri.sandbox-namespace"); namespace != "" {
+		container.PodNamespace = namespace
+	}
+	if uid := first("io.kubernetes.pod.uid", "io.kubernetes.cri.sandbox-uid"); uid != "" {
+		container.PodUID = uid
+	}
+	if image := first("io.kubernetes.cri.image-name"); image != "" {
+		container.Image = image
+	}
+}
@@ -0,0 +1,201 @@
+package probe
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const (
+	testContainerA = "3f4e8c1b2a7d9e6f5c4b3a2918273645fedcba9876543210aabbccddeeff0011"
+	testContainerB = "9a8b7c6d5e4f3a2b1c0d9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4b3c2d1e0f9a8b"
+	testPodUID     = "6b1c2d3e-4f50-4a6b-8c7d-9e0f1a2b3c4d"
+)
+
+// writeContainerProc adds a process with the given cgroup file and mount
+// namespace to a fake /proc.
+func writeContainerProc(t *testing.T, root string, pid int, cgroup, mountNS string) string {
+	t.Helper()
+	dir := filepath.Join(root, strconv.Itoa(pid))
+	if err := os.MkdirAll(filepath.Join(dir, "ns"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cgroup"), []byte(cgroup), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(mountNS, filepath.Join(dir, "ns", "mnt")); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestParseContainerCgroup(t *testing.T) {
+	podSlice := "kubepods-burstable-pod" + strings.ReplaceAll(testPodUID, "-", "_") + ".slice"
+	for _, tt := range []struct {
+		name, cgroup        string
+		id, runtime, podUID string
+	}{
+		{"host", "0::/user.slice/user-1000.slice/session-2.scope\n", "", "", ""},
+		{"docker cgroupfs", "12:pids:/docker/" + testContainerA + "\n0::/\n", testContainerA, RuntimeDocker, ""},
+		{"docker systemd", "0::/system.slice/docker-" + testContainerA + ".scope\n", testContainerA, RuntimeDocker, ""},
+		{"podman", "0::/machine.slice/libpod-" + testContainerA + ".scope/container\n", testContainerA, RuntimePodman, ""},
+		{"kubernetes cgroupfs", "4:memory:/kubepods/burstable/pod" + testPodUID + "/" + testContainerA + "\n", testContainerA, "", testPodUID},
+		{
+			"containerd systemd",
+			"0::/kubepods.slice/kubepods-burstable.slice/" + podSlice + "/cri-containerd-" + testContainerA + ".scope\n",
+			testContainerA, RuntimeContainerd, testPodUID,
+		},
+		{"crio conmon", "0::/kubepods.slice/" + podSlice + "/crio-conmon-" + testContainerA + ".scope\n", "", "", ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			id, runtime, podUID := parseContainerCgroup([]byte(tt.cgroup))
+			if id != tt.id || runtime != tt.runtime || podUID != tt.podUID {
+				t.Errorf("parseContainerCgroup() = %q, %q, %q", id, runtime, podUID)
+			}
+		})
+	}
+}
+
+func TestContainerResolverDocker(t *testing.T) {
+	root, state := t.TempDir(), t.TempDir()
+	writeContainerProc(t, root, 1, "0::/init.scope\n", "mnt:[4026531841]")
+	writeContainerProc(t, root, 300, "0::/system.slice/docker-"+testContainerA+".scope\n", "mnt:[4026532301]")
+	writeContainerProc(t, root, 301, "0::/system.slice/docker-"+testContainerA+".scope\n", "mnt:[4026532301]")
+	writeContainerProc(t, root, 400, "0::/system.slice/docker-"+testContainerB+".scope\n", "mnt:[4026532402]")
+	// Entered with nsenter: host cgroup, container mount namespace
+	writeContainerProc(t, root, 500, "0::/user.slice/session-2.scope\n", "mnt:[4026532301]")
+
+	config := filepath.Join(state, "containers", testContainerA, "config.v2.json")
+	if err := os.MkdirAll(filepath.Dir(config), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(config, []byte(`{"Name":"/mcp-gateway","Config":{"Image":"ghcr.io/acme/gateway:1.4"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	resolver := newContainerResolver(root, []string{state})
+	for _, target := range []string{"container:mcp-gateway", "container:" + testContainerA[:12]} {
+		members, err := resolver.resolve(target)
+		if err != nil {
+			t.Fatalf("resolve(%q): %v", target, err)
+		}
+		var pids []int
+		for _, member := range members {
+			pids = append(pids, member.PID)
+		}
+		if !reflect.DeepEqual(pids, []int{300, 301, 500}) {
+			t.Fatalf("resolve(%q) = %v", target, pids)
+		}
+		container := members[0].Container
+		if container.Name != "mcp-gateway" || container.Runtime != RuntimeDocker ||
+			container.Image != "ghcr.io/acme/gateway:1.4" || container.MountNS != "mnt:[4026532301]" {
+			t.Errorf("container = %+v", container)
+		}
+	}
+
+	// Without state, the container is known by ID only
+	bare := newContainerResolver(root, []string{})
+	if members, err := bare.resolve("container:mcp-gateway"); err != nil || len(members) != 0 {
+		t.Errorf("resolve by name without state = %v, %v", members, err)
+	}
+	if members, err := bare.resolve("container:" + testContainerB); err != nil || len(members) != 1 || members[0].PID != 400 {
+		t.Errorf("resolve by full ID = %v, %v", members, err)
+	}
+
+	// An empty reference would prefix every ID
+	if _, err := resolver.resolve("container:"); err == nil {
+		t.Error("expected an error for an empty container reference")
+	}
+}
+
+func TestContainerResolverAmbiguousPrefix(t *testing.T) {
+	root := t.TempDir()
+	other := testContainerA[:4] + testContainerB[4:]
+	writeContainerProc(t, root, 300, "0::/docker/"+testContainerA+"\n", "mnt:[4026532301]")
+	writeContainerProc(t, root, 400, "0::/docker/"+other+"\n", "mnt:[4026532402]")
+
+	resolver := newContainerResolver(root, []string{})
+	if _, err := resolver.resolve("container:" + testContainerA[:4]); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("expected an ambiguity error, got %v", err)
+	}
+}
+
+func TestContainerResolverPod(t *testing.T) {
+	root, state := t.TempDir(), t.TempDir()
+	podSlice := "/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod" + strings.ReplaceAll(testPodUID, "-", "_") + ".slice/"
+
+	// The application container is labelled in containerd's state; the
+	// sidecar is found through what its process exposes
+	writeContainerProc(t, root, 700, "0::"+podSlice+"cri-containerd-"+testContainerA+".scope\n", "mnt:[4026532501]")
+	sidecar := writeContainerProc(t, root, 710, "0::"+podSlice+"cri-containerd-"+testContainerB+".scope\n", "mnt:[4026532502]")
+	writeContainerProc(t, root, 800, "0::/kubepods.slice/kubepods-podaaaaaaaa_bbbb_cccc_dddd_eeeeeeeeeeee.slice/cri-containerd-"+strings.Repeat("e", 64)+".scope\n", "mnt:[4026532601]")
+
+	bundle := filepath.Join(state, "io.containerd.runtime.v2.task", "k8s.io", testContainerA, "config.json")
+	if err := os.MkdirAll(filepath.Dir(bundle), 0755); err != nil {
+		t.Fatal(err)
+	}
+	annotations := `{"annotations":{
+		"io.kubernetes.cri.container-name":"gateway",
+		"io.kubernetes.cri.sandbox-name":"model-gateway-7d9f8",
+		"io.kubernetes.cri.sandbox-namespace":"ai",
+		"io.kubernetes.cri.image-name":"ghcr.io/acme/gateway:1.4"}}`
+	if err := os.WriteFile(bundle, []byte(annotations), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(sidecar, "environ"), []byte("PATH=/usr/bin\x00HOSTNAME=model-gateway-7d9f8\x00"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	account := filepath.Join(sidecar, "root", "var", "run", "secrets", "kubernetes.io", "serviceaccount")
+	if err := os.MkdirAll(account, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(account, "namespace"), []byte("ai\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	resolver := newContainerResolver(root, []string{state})
+	members, err := resolver.resolve("pod:ai/model-gateway-7d9f8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 2 || members[0].PID != 700 || members[1].PID != 710 {
+		t.Fatalf("resolve() = %+v", members)
+	}
+	gateway := members[0].Container
+	if gateway.Name != "gateway" || gateway.Runtime != RuntimeContainerd || gateway.PodUID != testPodUID {
+		t.Errorf("gateway = %+v", gateway)
+	}
+	if sidecar := members[1].Container; sidecar.PodName != "model-gateway-7d9f8" || sidecar.PodNamespace != "ai" {
+		t.Errorf("sidecar = %+v", sidecar)
+	}
+
+	if members, err := resolver.resolve("pod:default/model-gateway-7d9f8"); err != nil || len(members) != 0 {
+		t.Errorf("resolve() in another namespace = %v, %v", members, err)
+	}
+	if _, err := resolver.resolve("pod:model-gateway-7d9f8"); err == nil {
+		t.Error("expected an error for a pod without a namespace")
+	}
+}
+
+func TestLookupStorageState(t *testing.T) {
+	state := t.TempDir()
+	dir := filepath.Join(state, "overlay-containers")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "containers.json"), []byte(`[{"id":"`+testContainerA+`","names":["ollama"]}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	container := &ContainerInfo{ID: testContainerA}
+	if !lookupStorageState(state, container) || container.Name != "ollama" || container.Runtime != RuntimePodman {
+		t.Errorf("container = %+v", container)
+	}
+	if lookupStorageState(state, &ContainerInfo{ID: testContainerB}) {
+		t.Error("unknown container found")
+	}
+}
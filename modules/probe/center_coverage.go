@@ -0,0 +1,138 @@
+package probe
+
+import (
+	"os"
+	"time"
+)
+
+// StreamCoverage accounts for how much of one stream's traffic a capture
+// observed. BytesSeen is what the target moved as far as the backend can
+// tell; what was not delivered was truncated, dropped or is still buffered.
+// When the backend cannot tell, BytesSeen is only what it read and the
+// coverage is unknown.
+type StreamCoverage struct {
+	BytesSeen      int64    `json:"bytes_seen"`
+	BytesDelivered int64    `json:"bytes_delivered"`
+	BytesDropped   int64    `json:"bytes_dropped,omitempty"`   // overwritten in buffers or cut by limits
+	TruncatedCalls int64    `json:"truncated_calls,omitempty"` // system calls whose data was cut short
+	Unmeasured     bool     `json:"unmeasured,omitempty"`      // what the target moved is not known
+	Percent        *float64 `json:"coverage_percent"`          // nil when unmeasured
+}
+
+// add accumulates the counters of other.
+func (c *StreamCoverage) add(other StreamCoverage) {
+	c.BytesSeen += other.BytesSeen
+	c.BytesDelivered += other.BytesDelivered
+	c.BytesDropped += other.BytesDropped
+	c.TruncatedCalls += other.TruncatedCalls
+	c.Unmeasured = c.Unmeasured || other.Unmeasured
+	c.updatePercent()
+}
+
+// updatePercent recomputes the coverage from the counters.
+func (c *StreamCoverage) updatePercent() {
+	if c.Unmeasured {
+		c.Percent = nil
+		return
+	}
+	percent := coveragePercent(c.BytesSeen, c.BytesDelivered)
+	c.Percent = &percent
+}
+
+// coveragePercent is the share of seen bytes that were delivered. A stream
+// with no traffic is fully covered.
+func coveragePercent(seen, delivered int64) float64 {
+	if seen <= 0 || delivered >= seen {
+		return 100
+	}
+	return float64(delivered) / float64(seen) * 100
+}
+
+// CaptureLoss summarizes what the capture of one process missed.
+type CaptureLoss struct {
+	Method      string                    `json:"method"`
+	Streams     map[string]StreamCoverage `json:"streams,omitempty"`
+	PollingGaps int                       `json:"polling_gaps,omitempty"` // polls late enough that data may have been missed
+	GapTime     time.Duration             `json:"gap_time,omitempty"`     // time beyond the poll interval lost to late polls
+	Percent     *float64                  `json:"coverage_percent"`       // over all streams; nil if any is unmeasured
+}
+
+// total sums the coverage of every stream.
+func (l *CaptureLoss) total() StreamCoverage {
+	var total StreamCoverage
+	for _, c := range l.Streams {
+		total.add(c)
+	}
+	total.updatePercent()
+	return total
+}
+
+// stream returns the coverage counters of a stream, creating them on first
+// use.
+func (s *CaptureStats) stream(name string) *StreamCoverage {
+	if s.Streams == nil {
+		s.Streams = make(map[string]*StreamCoverage)
+	}
+	c, ok := s.Streams[name]
+	if !ok {
+		c = &StreamCoverage{}
+		s.Streams[name] = c
+	}
+	return c
+}
+
+// recordPoll counts a polling gap when now is more than twice interval after
+// the previous poll.
+func (s *CaptureStats) recordPoll(now time.Time, interval time.Duration) {
+	if !s.lastPoll.IsZero() && interval > 0 {
+		if late := now.Sub(s.lastPoll); late > 2*interval {
+			s.PollingGaps++
+			s.GapTime += late - interval
+		}
+	}
+	s.lastPoll = now
+}
+
+// recordRead accounts for n bytes read from a standard stream's
+// descriptor. A regular file shows how much the target wrote in all; for
+// pipes and terminals only what was read is known, so their coverage is
+// unmeasured.
+func (s *CaptureStats) recordRead(stream string, file *os.File, n int) {
+	c := s.stream(stream)
+	c.BytesDelivered += int64(n)
+	if info, err := file.Stat(); err == nil && info.Mode().IsRegular() {
+		// Includes what was written but not read yet
+		c.BytesSeen = max(c.BytesDelivered, info.Size())
+	} else {
+		c.BytesSeen += int64(n)
+		c.Unmeasured = true
+	}
+	c.updatePercent()
+}
+
+// loss summarizes the counters, merged with those of a ptrace captor.
+func (s *CaptureStats) loss(captor *PtraceCapture) CaptureLoss {
+	loss := CaptureLoss{Method: "procfs", Streams: make(map[string]StreamCoverage)}
+	if s != nil {
+		loss.Method = s.Method
+		loss.PollingGaps = s.PollingGaps
+		loss.GapTime = s.GapTime
+		for name, c := range s.Streams {
+			loss.Streams[name] = *c
+		}
+	}
+	if captor != nil {
+		loss.Method = "ptrace"
+		for name, c := range captor.Coverage() {
+			merged := loss.Streams[name]
+			merged.add(c)
+			loss.Streams[name] = merged
+		}
+	}
+	for name, c := range loss.Streams {
+		c.updatePercent()
+		loss.Streams[name] = c
+	}
+	loss.Percent = loss.total().Percent
+	return loss
+}
@@ -0,0 +1,172 @@
+package probe
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCoveragePercent(t *testing.T) {
+	for _, tt := range []struct {
+		seen, delivered int64
+		want            float64
+	}{
+		{0, 0, 100},
+		{200, 200, 100},
+		{200, 150, 75},
+		{200, 0, 0},
+	} {
+		if got := coveragePercent(tt.seen, tt.delivered); got != tt.want {
+			t.Errorf("coveragePercent(%d, %d) = %v, want %v", tt.seen, tt.delivered, got, tt.want)
+		}
+	}
+
+	loss := CaptureLoss{Streams: map[string]StreamCoverage{
+		"stdout": {BytesSeen: 300, BytesDelivered: 300},
+		"stderr": {BytesSeen: 100, BytesDelivered: 0, BytesDropped: 100},
+	}}
+	if total := loss.total(); percentOf(total.Percent) != 75 || total.BytesDropped != 100 {
+		t.Errorf("total() = %+v", total)
+	}
+}
+
+func TestCaptureStatsPollingGaps(t *testing.T) {
+	var stats CaptureStats
+	start := time.Now()
+	for _, offset := range []time.Duration{0, 10, 20, 80, 90} {
+		stats.recordPoll(start.Add(offset*time.Millisecond), 10*time.Millisecond)
+	}
+	if stats.PollingGaps != 1 || stats.GapTime != 50*time.Millisecond {
+		t.Errorf("gaps = %d (%s), want 1 (50ms)", stats.PollingGaps, stats.GapTime)
+	}
+}
+
+func TestCaptureStatsRecordRead(t *testing.T) {
+	// The target has written more to a file than one poll reads
+	path := filepath.Join(t.TempDir(), "out.log")
+	if err := os.WriteFile(path, make([]byte, 8192), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var stats CaptureStats
+	stats.recordRead("stdout", file, 4096)
+	loss := stats.loss(nil)
+	if c := loss.Streams["stdout"]; c.BytesSeen != 8192 || c.BytesDelivered != 4096 || percentOf(c.Percent) != 50 {
+		t.Errorf("coverage = %+v", c)
+	}
+
+	stats.recordRead("stdout", file, 4096)
+	if loss := stats.loss(nil); percentOf(loss.Percent) != 100 {
+		t.Errorf("coverage after catching up = %v", percentOf(loss.Percent))
+	}
+}
+
+func TestCaptureStatsRecordReadPipe(t *testing.T) {
+	// A pipe does not show how much the target wrote to it
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	var stats CaptureStats
+	stats.recordRead("stdout", r, 4096)
+	loss := stats.loss(nil)
+	if c := loss.Streams["stdout"]; !c.Unmeasured || c.Percent != nil || c.BytesSeen != 4096 {
+		t.Errorf("coverage = %+v, want unmeasured", c)
+	}
+	if loss.Percent != nil {
+		t.Errorf("total coverage = %v, want unknown", *loss.Percent)
+	}
+
+	data, err := json.Marshal(loss.Streams["stdout"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"coverage_percent":null`) {
+		t.Errorf("JSON = %s, want a null coverage", data)
+	}
+}
+
+// percentOf returns the coverage, or -1 if it is unknown.
+func percentOf(percent *float64) float64 {
+	if percent == nil {
+		return -1
+	}
+	return *percent
+}
+
+func TestPtraceCaptureCoverage(t *testing.T) {
+	c := &PtraceCapture{
+		config:       PtraceConfig{MaxBufferSize: 64, MaxTotalBytes: 1 << 20},
+		stdinBuffer:  NewStreamBuffer(64),
+		stdoutBuffer: NewStreamBuffer(64),
+		stderrBuffer: NewStreamBuffer(64),
+		fdStreams:    make(map[ptraceStreamKey]*ptraceStream),
+		coverage:     make(map[string]*StreamCoverage),
+		active:       true,
+	}
+	stdout := FDStream{FD: 1, Direction: FDWrite}
+	line := []byte("0123456789abcdef0123456789abcdef\n") // 33 bytes
+
+	// A call larger than the copy limit, then more than the buffer holds
+	c.record(stdout, line, 100)
+	c.record(stdout, line, 33)
+	c.record(stdout, line, 33)
+	data, _, err := c.drain()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cov := c.Coverage()["stdout"]
+	if cov.BytesSeen != 166 || cov.TruncatedCalls != 1 || cov.BytesDelivered != int64(len(data.Stdout)) {
+		t.Errorf("coverage = %+v, delivered %d", cov, len(data.Stdout))
+	}
+	if cov.BytesDropped != 99-int64(len(data.Stdout)) {
+		t.Errorf("dropped %d bytes, want the %d overwritten", cov.BytesDropped, 99-len(data.Stdout))
+	}
+	if percentOf(cov.Percent) >= 100 {
+		t.Errorf("coverage %v%% despite losses", percentOf(cov.Percent))
+	}
+	if _, ok := c.Coverage()["stdin"]; ok {
+		t.Error("stream without traffic reported")
+	}
+}
+
+func TestPtraceCaptureFileBudget(t *testing.T) {
+	c := &PtraceCapture{
+		config:       PtraceConfig{MaxBufferSize: 64, MaxTotalBytes: 64, MaxFileBytes: 32},
+		stdinBuffer:  NewStreamBuffer(64),
+		stdoutBuffer: NewStreamBuffer(64),
+		stderrBuffer: NewStreamBuffer(64),
+		fdStreams:    make(map[ptraceStreamKey]*ptraceStream),
+		coverage:     make(map[string]*StreamCoverage),
+		stop:         make(chan struct{}),
+		active:       true,
+	}
+	file := FDStream{FD: 5, Type: FDTypeFile, Path: "/app/node_modules/lib.js", Direction: FDRead}
+	chunk := []byte("0123456789abcdef0123456789abcdef") // 32 bytes
+
+	// Files past their budget are dropped without ending the capture
+	c.record(file, chunk, 32)
+	c.record(file, chunk, 32)
+	c.record(FDStream{FD: 1, Direction: FDWrite}, chunk, 32)
+	if c.lastError != nil {
+		t.Fatalf("file reads ended the capture: %v", c.lastError)
+	}
+	if c.fileBytes != 32 || c.bytesTotal != 32 {
+		t.Errorf("file bytes %d, total %d; want 32 each", c.fileBytes, c.bytesTotal)
+	}
+	if cov := c.Coverage()[ptraceStreamName(file)]; cov.BytesDropped != 32 {
+		t.Errorf("file coverage = %+v", cov)
+	}
+}
@@ -11,6 +11,7 @@ import (
 type TerminalDisplay struct {
 	vulns        []StreamVulnerability
 	activities   []ActivityEvent
+	processes    []DisplayProcess
 	stats        DisplayStats
 	running      bool
 	mu           sync.RWMutex
@@ -28,6 +29,16 @@ type DisplayStats struct {
 	LastActivity  time.Time
 }
 
+// DisplayProcess is a monitored process as shown in the process tree.
+type DisplayProcess struct {
+	PID    int
+	Name   string
+	Depth  int // 0 for targets, 1 for their followed children, ...
+	Bytes  int64
+	Vulns  int64
+	Exited bool
+}
+
 // ActivityEvent represents stream activity for display.
 type ActivityEvent struct {
 	Timestamp time.Time
@@ -88,6 +99,18 @@ func (d *TerminalDisplay) Update(streams map[int]*StreamCapture) {
 	d.stats.EventsCount = 0
 	d.stats.VulnsCount = 0
 
+	d.processes = d.processes[:0]
+	for _, node := range processTree(streams) {
+		d.processes = append(d.processes, DisplayProcess{
+			PID:    node.Capture.Target.PID,
+			Name:   node.Capture.Target.Name,
+			Depth:  node.Depth,
+			Bytes:  node.Capture.Statistics.BytesCaptured,
+			Vulns:  node.Capture.Statistics.VulnsFound,
+			Exited: node.Capture.Exited,
+		})
+	}
+
 	for _, capture := range streams {
 		d.stats.BytesCaptured += capture.Statistics.BytesCaptured
 		d.stats.EventsCount += capture.Statistics.EventsCount
@@ -204,6 +227,9 @@ func (d *TerminalDisplay) render() {
 	// Header
 	d.renderHeader(&sb)
 
+	// Process tree, once children are followed
+	d.renderProcessTree(&sb)
+
 	// Activity table (if enabled)
 	if d.ShowActivity {
 		d.renderActivities(&sb)
@@ -242,6 +268,39 @@ func (d *TerminalDisplay) renderHeader(sb *strings.Builder) {
 	sb.WriteString("\n")
 }
 
+// renderProcessTree draws the monitored processes with their followed
+// descendants indented below them. It is omitted for a single process.
+func (d *TerminalDisplay) renderProcessTree(sb *strings.Builder) {
+	if len(d.processes) < 2 {
+		return
+	}
+	sb.WriteString("\033[1;36m▼ Process Tree\033[0m\n")
+
+	for _, p := range d.processes {
+		branch := ""
+		if p.Depth > 0 {
+			branch = strings.Repeat("   ", p.Depth-1) + "└─ "
+		}
+		status := ""
+		if p.Exited {
+			status = " \033[2m(exited)\033[0m"
+		}
+		vulns := ""
+		if p.Vulns > 0 {
+			vulns = fmt.Sprintf(" \033[1;31m%d vulns\033[0m", p.Vulns)
+		}
+		sb.WriteString(fmt.Sprintf("  %s%s [%d] %s%s%s\n",
+			branch,
+			p.Name,
+			p.PID,
+			formatBytes(p.Bytes),
+			vulns,
+			status,
+		))
+	}
+	sb.WriteString("\n")
+}
+
 // renderActivities draws the activity table.
 func (d *TerminalDisplay) renderActivities(sb *strings.Builder) {
 	sb.WriteString("\033[1;36m▼ Stream Activity\033[0m\n")
@@ -252,16 +311,16 @@ func (d *TerminalDisplay) renderActivities(sb *strings.Builder) {
 	}
 
 	// Table header
-	sb.WriteString("╭────────────────┬────────┬───────────┬──────────────────────────╮\n")
-	sb.WriteString("│ Time           │ Stream │ Bytes     │ Preview                  │\n")
-	sb.WriteString("├────────────────┼────────┼───────────┼──────────────────────────┤\n")
+	sb.WriteString("╭────────────────┬──────────────────┬───────────┬──────────────────────────╮\n")
+	sb.WriteString("│ Time           │ Stream           │ Bytes     │ Preview                  │\n")
+	sb.WriteString("├────────────────┼──────────────────┼───────────┼──────────────────────────┤\n")
 
 	// Show activities
 	for _, activity := range d.activities {
 		d.renderActivityRow(sb, &activity)
 	}
 
-	sb.WriteString("╰────────────────┴────────┴───────────┴──────────────────────────╯\n")
+	sb.WriteString("╰────────────────┴──────────────────┴───────────┴──────────────────────────╯\n")
 	sb.WriteString("\n")
 }
 
@@ -269,15 +328,25 @@ func (d *TerminalDisplay) renderActivities(sb *strings.Builder) {
 func (d *TerminalDisplay) renderActivityRow(sb *strings.Builder, activity *ActivityEvent) {
 	timeStr := activity.Timestamp.Format("15:04:05.000")
 
-	// Format stream name
+	// Format stream name; descriptor streams are named fd<N>:<type>:<direction>
 	streamStr := activity.Stream
+	if len(streamStr) > 16 {
+		streamStr = streamStr[:15] + "…"
+	}
+	streamStr = fmt.Sprintf("%-16s", streamStr)
 	switch activity.Stream {
 	case "stdin":
-		streamStr = "\033[32mstdin \033[0m" // Green
+		streamStr = "\033[32m" + streamStr + "\033[0m" // Green
 	case "stdout":
-		streamStr = "\033[34mstdout\033[0m" // Blue
+		streamStr = "\033[34m" + streamStr + "\033[0m" // Blue
 	case "stderr":
-		streamStr = "\033[33mstderr\033[0m" // Yellow
+		streamStr = "\033[33m" + streamStr + "\033[0m" // Yellow
+	default:
+		if strings.HasSuffix(activity.Stream, ":"+FDWrite) {
+			streamStr = "\033[35m" + streamStr + "\033[0m" // Magenta
+		} else {
+			streamStr = "\033[36m" + streamStr + "\033[0m" // Cyan
+		}
 	}
 
 	// Format bytes
@@ -310,9 +379,9 @@ func (d *TerminalDisplay) renderVulnerabilities(sb *strings.Builder) {
 	}
 
 	// Table header
-	sb.WriteString("╭────────────────┬──────────┬────────────┬─────────────────────╮\n")
-	sb.WriteString("│ Time           │ Severity │ Type       │ Evidence            │\n")
-	sb.WriteString("├────────────────┼──────────┼────────────┼─────────────────────┤\n")
+	sb.WriteString("╭────────────────┬──────────┬────────────┬──────────────────┬─────────────────────╮\n")
+	sb.WriteString("│ Time           │ Severity │ Type       │ Stream           │ Evidence            │\n")
+	sb.WriteString("├────────────────┼──────────┼────────────┼──────────────────┼─────────────────────┤\n")
 
 	// Show last 10 vulnerabilities
 	start := 0
@@ -325,7 +394,7 @@ func (d *TerminalDisplay) renderVulnerabilities(sb *strings.Builder) {
 		d.renderVulnRow(sb, &vuln)
 	}
 
-	sb.WriteString("╰────────────────┴──────────┴────────────┴─────────────────────╯\n")
+	sb.WriteString("╰────────────────┴──────────┴────────────┴──────────────────┴─────────────────────╯\n")
 	sb.WriteString("\n")
 }
 
@@ -358,10 +427,16 @@ func (d *TerminalDisplay) renderVulnRow(sb *strings.Builder, vuln *StreamVulnera
 		typeStr = typeStr[:10]
 	}
 
-	sb.WriteString(fmt.Sprintf("│ %s │ %s │ %-10s │ %-19s │\n",
+	streamStr := vuln.Stream
+	if len(streamStr) > 16 {
+		streamStr = streamStr[:15] + "…"
+	}
+
+	sb.WriteString(fmt.Sprintf("│ %s │ %s │ %-10s │ %-16s │ %-19s │\n",
 		timeStr,
 		severityStr,
 		typeStr,
+		streamStr,
 		evidence,
 	))
 }
@@ -444,7 +519,15 @@ func (d *SimpleDisplay) LogVulnerability(vuln StreamVulnerability) {
 		color = "\033[32m" // Green
 	}
 
-	fmt.Printf("%s[%s] %sSEVERITY: %s\033[0m | TYPE: %s | EVIDENCE: %s | PID: %d\n",
+	stream := ""
+	if vuln.Stream != "" {
+		stream = " | STREAM: " + vuln.Stream
+		if vuln.FD != nil && vuln.FD.Peer != "" {
+			stream += " (" + vuln.FD.Peer + ")"
+		}
+	}
+
+	fmt.Printf("%s[%s] %sSEVERITY: %s\033[0m | TYPE: %s | EVIDENCE: %s | PID: %d%s\n",
 		timestamp,
 		vuln.ID,
 		color,
@@ -452,5 +535,6 @@ func (d *SimpleDisplay) LogVulnerability(vuln StreamVulnerability) {
 		vuln.Subtype,
 		vuln.Evidence,
 		vuln.ProcessInfo.PID,
+		stream,
 	)
 }
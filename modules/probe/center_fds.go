@@ -0,0 +1,172 @@
+package probe
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/macawi-ai/strigoi/pkg/security"
+)
+
+// fdPeersMax bounds the peer cache of a long-running target.
+const fdPeersMax = 4096
+
+// describeFD reports what descriptor fd of pid refers to. Descriptors that
+// carry no stream data, such as eventfds, epoll instances and files under
+// /proc, are not captured; the standard streams always are.
+func describeFD(procRoot string, pid, fd int) (FDStream, bool) {
+	stream := FDStream{FD: fd}
+	target, err := os.Readlink(filepath.Join(procRoot, strconv.Itoa(pid), "fd", strconv.Itoa(fd)))
+	if err == nil {
+		stream.Type, stream.Inode, stream.Path = classifyFDTarget(target)
+	}
+	if stream.Type == "" {
+		if fd > 2 {
+			return stream, false
+		}
+		stream.Type = FDTypeFile
+		stream.Path = target
+	}
+	return stream, true
+}
+
+// classifyFDTarget interprets the target of a /proc/<pid>/fd link, such as
+// "socket:[41893]", "pipe:[41894]", "/dev/pts/3" or a file path. The type is
+// empty for descriptors that are not captured.
+func classifyFDTarget(target string) (fdType string, inode uint64, path string) {
+	for prefix, t := range map[string]string{"socket:[": FDTypeSocket, "pipe:[": FDTypePipe} {
+		if rest, ok := strings.CutPrefix(target, prefix); ok {
+			n, err := strconv.ParseUint(strings.TrimSuffix(rest, "]"), 10, 64)
+			if err != nil {
+				return "", 0, ""
+			}
+			return t, n, ""
+		}
+	}
+
+	switch {
+	case target == "/dev/tty" || target == "/dev/console" ||
+		strings.HasPrefix(target, "/dev/pts/") || strings.HasPrefix(target, "/dev/tty"):
+		return FDTypeTTY, 0, target
+	case strings.HasPrefix(target, "/proc/") || strings.HasPrefix(target, "/sys/") || strings.HasPrefix(target, "/dev/"):
+		return "", 0, ""
+	case strings.HasPrefix(target, "/"):
+		return FDTypeFile, 0, target
+	}
+	return "", 0, "" // anon_inode:[eventfd] and the like
+}
+
+// fdPeers resolves what is at the other end of a process's sockets and
+// pipes. Peers are cached by inode, as they do not change over the lifetime
+// of a socket or pipe, so /proc is scanned once per descriptor rather than
+// on every read.
+type fdPeers struct {
+	procRoot      string
+	pid           int
+	cache         map[uint64]string
+	readInventory func() (*security.SocketInventory, error)
+}
+
+// newFDPeers creates a peer resolver for pid.
+func newFDPeers(procRoot string, pid int) *fdPeers {
+	return &fdPeers{
+		procRoot:      procRoot,
+		pid:           pid,
+		cache:         make(map[uint64]string),
+		readInventory: security.ReadSocketInventory,
+	}
+}
+
+// resolve fills in the peer of stream.
+func (p *fdPeers) resolve(stream *FDStream) {
+	if stream.Inode == 0 || (stream.Type != FDTypeSocket && stream.Type != FDTypePipe) {
+		return
+	}
+	peer, ok := p.cache[stream.Inode]
+	if !ok {
+		if stream.Type == FDTypeSocket {
+			peer = p.socketPeer(stream.Inode)
+		} else {
+			peer = p.pipePeer(stream.Inode)
+		}
+		if len(p.cache) >= fdPeersMax {
+			p.cache = make(map[uint64]string)
+		}
+		p.cache[stream.Inode] = peer
+	}
+	stream.Peer = peer
+}
+
+// socketPeer describes the remote end of a TCP or UDP socket, or the path
+// of a unix socket, from /proc/net.
+func (p *fdPeers) socketPeer(inode uint64) string {
+	inventory, err := p.readInventory()
+	if err != nil {
+		return ""
+	}
+	s, ok := inventory.ByInode(inode)
+	switch {
+	case !ok:
+		return ""
+	case s.Protocol == "unix":
+		if s.Path != "" {
+			return "unix " + s.Path
+		}
+		return "unix"
+	case s.Listening():
+		return s.Protocol + " listening on " + s.Endpoint()
+	}
+	return s.Protocol + " " + net.JoinHostPort(s.RemoteAddress, strconv.Itoa(s.RemotePort))
+}
+
+// pipePeer names the other processes holding the pipe, such as the child a
+// server talks to over its stdio.
+func (p *fdPeers) pipePeer(inode uint64) string {
+	entries, err := os.ReadDir(p.procRoot)
+	if err != nil {
+		return ""
+	}
+	want := fmt.Sprintf("pipe:[%d]", inode)
+
+	var peers []string
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == p.pid {
+			continue
+		}
+		fdDir := filepath.Join(p.procRoot, entry.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue // exited, or not ours to inspect
+		}
+		for _, fd := range fds {
+			if target, err := os.Readlink(filepath.Join(fdDir, fd.Name())); err == nil && target == want {
+				peers = append(peers, fmt.Sprintf("pid %d (%s)", pid, processName(p.procRoot, pid)))
+				break
+			}
+		}
+	}
+	return strings.Join(peers, ", ")
+}
+
+// processName returns the command name of pid.
+func processName(procRoot string, pid int) string {
+	comm, err := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "comm"))
+	if err != nil {
+		return "unknown"
+	}
+	return strings.TrimSpace(string(comm))
+}
+
+// processCommandLine returns the command line of pid with its arguments
+// separated by spaces, or "" if it cannot be read.
+func processCommandLine(procRoot string, pid int) string {
+	cmdline, err := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "cmdline"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.ReplaceAll(string(cmdline), "\x00", " "))
+}
@@ -0,0 +1,125 @@
+package probe
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/macawi-ai/strigoi/pkg/security"
+)
+
+func TestClassifyFDTarget(t *testing.T) {
+	tests := []struct {
+		target string
+		fdType string
+		inode  uint64
+		path   string
+	}{
+		{"socket:[41893]", FDTypeSocket, 41893, ""},
+		{"pipe:[41894]", FDTypePipe, 41894, ""},
+		{"/dev/pts/3", FDTypeTTY, 0, "/dev/pts/3"},
+		{"/dev/tty", FDTypeTTY, 0, "/dev/tty"},
+		{"/home/user/notes.md", FDTypeFile, 0, "/home/user/notes.md"},
+		{"/dev/null", "", 0, ""},
+		{"/proc/1/status", "", 0, ""},
+		{"anon_inode:[eventfd]", "", 0, ""},
+		{"socket:[bad]", "", 0, ""},
+	}
+	for _, tt := range tests {
+		fdType, inode, path := classifyFDTarget(tt.target)
+		if fdType != tt.fdType || inode != tt.inode || path != tt.path {
+			t.Errorf("classifyFDTarget(%q) = %q, %d, %q", tt.target, fdType, inode, path)
+		}
+	}
+}
+
+// fakeFDProc builds a /proc with a target (pid 100) holding a socket and
+// one end of a pipe whose other end belongs to pid 200.
+func fakeFDProc(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	link := func(pid, fd, target string) {
+		dir := filepath.Join(root, pid, "fd")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(target, filepath.Join(dir, fd)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link("100", "0", "/dev/null")
+	link("100", "3", "socket:[111]")
+	link("100", "4", "pipe:[222]")
+	link("100", "5", "anon_inode:[eventfd]")
+	link("100", "6", "/srv/data.db")
+	link("200", "1", "pipe:[222]")
+	link("300", "1", "pipe:[333]")
+	if err := os.WriteFile(filepath.Join(root, "200", "comm"), []byte("mcp-tool\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestDescribeFD(t *testing.T) {
+	root := fakeFDProc(t)
+
+	tests := []struct {
+		fd   int
+		ok   bool
+		want FDStream
+	}{
+		{0, true, FDStream{FD: 0, Type: FDTypeFile, Path: "/dev/null"}},
+		{3, true, FDStream{FD: 3, Type: FDTypeSocket, Inode: 111}},
+		{4, true, FDStream{FD: 4, Type: FDTypePipe, Inode: 222}},
+		{5, false, FDStream{}},
+		{6, true, FDStream{FD: 6, Type: FDTypeFile, Path: "/srv/data.db"}},
+		{9, false, FDStream{}},
+	}
+	for _, tt := range tests {
+		got, ok := describeFD(root, 100, tt.fd)
+		if ok != tt.ok || (ok && (got.FD != tt.want.FD || got.Type != tt.want.Type || got.Inode != tt.want.Inode || got.Path != tt.want.Path)) {
+			t.Errorf("describeFD(%d) = %+v, %v", tt.fd, got, ok)
+		}
+	}
+}
+
+func TestFDPeers(t *testing.T) {
+	reads := 0
+	peers := newFDPeers(fakeFDProc(t), 100)
+	peers.readInventory = func() (*security.SocketInventory, error) {
+		reads++
+		return &security.SocketInventory{Sockets: []security.Socket{
+			{Protocol: "tcp", LocalAddress: "10.0.0.2", LocalPort: 50000, RemoteAddress: "203.0.113.5", RemotePort: 443, State: "ESTABLISHED", Inode: 111},
+			{Protocol: "tcp6", LocalAddress: "::", LocalPort: 3000, RemoteAddress: "::", State: "LISTEN", Inode: 112},
+			{Protocol: "unix", State: "ESTABLISHED", Path: "/run/mcp.sock", Inode: 113},
+		}}, nil
+	}
+
+	tests := []struct {
+		stream FDStream
+		peer   string
+	}{
+		{FDStream{Type: FDTypeSocket, Inode: 111}, "tcp 203.0.113.5:443"},
+		{FDStream{Type: FDTypeSocket, Inode: 112}, "tcp6 listening on [::]:3000"},
+		{FDStream{Type: FDTypeSocket, Inode: 113}, "unix /run/mcp.sock"},
+		{FDStream{Type: FDTypeSocket, Inode: 114}, ""},
+		{FDStream{Type: FDTypePipe, Inode: 222}, "pid 200 (mcp-tool)"},
+		{FDStream{Type: FDTypePipe, Inode: 444}, ""},
+		{FDStream{Type: FDTypeFile, Path: "/srv/data.db"}, ""},
+	}
+	for _, tt := range tests {
+		stream := tt.stream
+		peers.resolve(&stream)
+		if stream.Peer != tt.peer {
+			t.Errorf("peer of %+v = %q, want %q", tt.stream, stream.Peer, tt.peer)
+		}
+	}
+
+	// Peers are cached by inode
+	before := reads
+	stream := FDStream{Type: FDTypeSocket, Inode: 111}
+	peers.resolve(&stream)
+	if reads != before || stream.Peer != "tcp 203.0.113.5:443" {
+		t.Errorf("cached peer re-read: %d reads, peer %q", reads-before, stream.Peer)
+	}
+}
@@ -0,0 +1,221 @@
+package probe
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// followInterval is how often the process table is scanned for new
+// descendants of the targets.
+const followInterval = 250 * time.Millisecond
+
+// processFollower tracks the process trees below the monitored targets.
+// Every descendant is tracked, attached or not, so that a process stays in
+// the tree when its parent exits and it is re-parented: npx may exit while
+// the node server it started keeps running.
+type processFollower struct {
+	procRoot string
+	members  map[int]int  // descendants and roots: pid -> parent pid
+	attached map[int]bool // members being monitored
+}
+
+// newProcessFollower starts a follower at the given roots, which are
+// already monitored.
+func newProcessFollower(procRoot string, roots []StreamTarget) *processFollower {
+	f := &processFollower{
+		procRoot: procRoot,
+		members:  make(map[int]int),
+		attached: make(map[int]bool),
+	}
+	for _, root := range roots {
+		f.members[root.PID] = root.PPID
+		f.attached[root.PID] = true
+	}
+	return f
+}
+
+// scan reads the process table. It returns the descendants that are not
+// yet monitored and the monitored members that have exited.
+func (f *processFollower) scan() (candidates, exited []int, err error) {
+	parents, err := readProcessParents(f.procRoot)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for pid := range f.members {
+		if _, ok := parents[pid]; !ok {
+			delete(f.members, pid)
+			if f.attached[pid] {
+				delete(f.attached, pid)
+				exited = append(exited, pid)
+			}
+		}
+	}
+
+	// Grandchildren may be listed before their parents
+	for grown := true; grown; {
+		grown = false
+		for pid, ppid := range parents {
+			if _, ok := f.members[pid]; ok {
+				continue
+			}
+			if _, ok := f.members[ppid]; ok {
+				f.members[pid] = ppid
+				grown = true
+			}
+		}
+	}
+
+	for pid := range f.members {
+		if !f.attached[pid] {
+			candidates = append(candidates, pid)
+		}
+	}
+	sort.Ints(candidates)
+	sort.Ints(exited)
+	return candidates, exited, nil
+}
+
+// attach marks a descendant as monitored.
+func (f *processFollower) attach(pid int) {
+	f.attached[pid] = true
+}
+
+// ancestor returns the nearest monitored ancestor of a member, skipping
+// intermediate processes that were not followed, such as a shell between
+// npx and node.
+func (f *processFollower) ancestor(pid int) int {
+	seen := map[int]bool{pid: true}
+	for parent, ok := f.members[pid]; ok && !seen[parent]; parent, ok = f.members[parent] {
+		if f.attached[parent] {
+			return parent
+		}
+		seen[parent] = true
+	}
+	return 0
+}
+
+// done reports whether every process in the trees has exited.
+func (f *processFollower) done() bool {
+	return len(f.members) == 0
+}
+
+// readProcessParents maps every running process to its parent. Zombies
+// have exited and are left out.
+func readProcessParents(procRoot string) (map[int]int, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", procRoot, err)
+	}
+
+	parents := make(map[int]int, len(entries))
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		ppid, state, err := readProcStat(procRoot, pid)
+		if err != nil || state == 'Z' || state == 'X' {
+			continue
+		}
+		parents[pid] = ppid
+	}
+	return parents, nil
+}
+
+// readProcStat returns the parent and state of pid from /proc/<pid>/stat:
+//
+//	1234 (node server) S 1200 ...
+//
+// The command name may contain spaces and parentheses, so fields are read
+// after its last closing parenthesis.
+func readProcStat(procRoot string, pid int) (ppid int, state byte, err error) {
+	data, err := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return 0, 0, err
+	}
+	end := bytes.LastIndexByte(data, ')')
+	if end < 0 {
+		return 0, 0, fmt.Errorf("malformed stat for PID %d", pid)
+	}
+	fields := bytes.Fields(data[end+1:])
+	if len(fields) < 2 || len(fields[0]) != 1 {
+		return 0, 0, fmt.Errorf("malformed stat for PID %d", pid)
+	}
+	ppid, err = strconv.Atoi(string(fields[1]))
+	if err != nil {
+		return 0, 0, fmt.Errorf("malformed stat for PID %d: %w", pid, err)
+	}
+	return ppid, fields[0][0], nil
+}
+
+// processRunning reports whether pid exists and has not exited.
+func processRunning(procRoot string, pid int) bool {
+	_, state, err := readProcStat(procRoot, pid)
+	return err == nil && state != 'Z' && state != 'X'
+}
+
+// processNode is a monitored process placed in its tree.
+type processNode struct {
+	Capture *StreamCapture
+	Depth   int
+	Parent  int // monitored parent or ancestor; 0 for roots
+}
+
+// processTree orders the monitored processes depth-first, each followed by
+// its monitored descendants. A process without a monitored parent or
+// ancestor is a root.
+func processTree(streams map[int]*StreamCapture) []processNode {
+	children := make(map[int][]int)
+	var roots []int
+	for pid, capture := range streams {
+		ppid := capture.Target.PPID
+		if capture.Target.Ancestor != 0 {
+			ppid = capture.Target.Ancestor
+		}
+		if _, ok := streams[ppid]; ok && ppid != pid {
+			children[ppid] = append(children[ppid], pid)
+		} else {
+			roots = append(roots, pid)
+		}
+	}
+	sort.Ints(roots)
+
+	nodes := make([]processNode, 0, len(streams))
+	visited := make(map[int]bool, len(streams))
+	var walk func(pid, parent, depth int)
+	walk = func(pid, parent, depth int) {
+		if visited[pid] {
+			return
+		}
+		visited[pid] = true
+		nodes = append(nodes, processNode{Capture: streams[pid], Depth: depth, Parent: parent})
+		sort.Ints(children[pid])
+		for _, child := range children[pid] {
+			walk(child, pid, depth+1)
+		}
+	}
+	for _, pid := range roots {
+		walk(pid, 0, 0)
+	}
+
+	// Parent links that loop, after PID reuse, leave no root to start from
+	if len(nodes) < len(streams) {
+		var rest []int
+		for pid := range streams {
+			if !visited[pid] {
+				rest = append(rest, pid)
+			}
+		}
+		sort.Ints(rest)
+		for _, pid := range rest {
+			walk(pid, 0, 0)
+		}
+	}
+	return nodes
+}
@@ -0,0 +1,157 @@
+package probe
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// writeStat adds a process to a fake /proc.
+func writeStat(t *testing.T, root string, pid int, comm string, state byte, ppid int) {
+	t.Helper()
+	dir := filepath.Join(root, strconv.Itoa(pid))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	stat := fmt.Sprintf("%d (%s) %c %d 1 1 0 -1 4194560\n", pid, comm, state, ppid)
+	if err := os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadProcStat(t *testing.T) {
+	root := t.TempDir()
+	writeStat(t, root, 42, "node (mcp) server", 'S', 7)
+
+	ppid, state, err := readProcStat(root, 42)
+	if err != nil || ppid != 7 || state != 'S' {
+		t.Errorf("readProcStat() = %d, %c, %v", ppid, state, err)
+	}
+	if _, _, err := readProcStat(root, 43); err == nil {
+		t.Error("expected an error for a missing process")
+	}
+}
+
+func TestProcessFollower(t *testing.T) {
+	root := t.TempDir()
+	writeStat(t, root, 100, "npx", 'S', 1)
+	writeStat(t, root, 101, "sh", 'S', 100)
+	writeStat(t, root, 102, "node", 'S', 101)
+	writeStat(t, root, 103, "defunct", 'Z', 100)
+	writeStat(t, root, 200, "unrelated", 'S', 1)
+
+	follower := newProcessFollower(root, []StreamTarget{{PID: 100, PPID: 1}})
+	candidates, exited, err := follower.scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(candidates, []int{101, 102}) || len(exited) != 0 {
+		t.Fatalf("scan() = %v, %v", candidates, exited)
+	}
+
+	// Only node is followed; the shell between them is skipped
+	follower.attach(102)
+	if ancestor := follower.ancestor(102); ancestor != 100 {
+		t.Errorf("ancestor(102) = %d, want 100", ancestor)
+	}
+
+	// npx exits and the shell is re-parented; its subtree is still followed
+	if err := os.RemoveAll(filepath.Join(root, "100")); err != nil {
+		t.Fatal(err)
+	}
+	writeStat(t, root, 101, "sh", 'S', 1)
+	writeStat(t, root, 104, "python", 'S', 102)
+	candidates, exited, _ = follower.scan()
+	if !reflect.DeepEqual(candidates, []int{101, 104}) || !reflect.DeepEqual(exited, []int{100}) {
+		t.Errorf("scan() after exit = %v, %v", candidates, exited)
+	}
+
+	for _, pid := range []string{"101", "102", "104"} {
+		if err := os.RemoveAll(filepath.Join(root, pid)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, exited, _ = follower.scan(); !reflect.DeepEqual(exited, []int{102}) || !follower.done() {
+		t.Errorf("expected node to exit and the tree to be done, got %v", exited)
+	}
+}
+
+func TestProcessTree(t *testing.T) {
+	streams := map[int]*StreamCapture{
+		300: {Target: StreamTarget{PID: 300, PPID: 1}},
+		310: {Target: StreamTarget{PID: 310, PPID: 305, Ancestor: 300}},
+		320: {Target: StreamTarget{PID: 320, PPID: 310}},
+		100: {Target: StreamTarget{PID: 100, PPID: 1}},
+	}
+	var order []string
+	for _, node := range processTree(streams) {
+		order = append(order, fmt.Sprintf("%d:%d:%d", node.Capture.Target.PID, node.Depth, node.Parent))
+	}
+	want := []string{"100:0:0", "300:0:0", "310:1:300", "320:2:310"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("processTree() = %v, want %v", order, want)
+	}
+}
+
+func TestFollowChildren(t *testing.T) {
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("no /proc")
+	}
+	m := newLaunchModule(t)
+
+	// The shell forks one matching child, then execs into another command
+	cmd := exec.Command("sh", "-c", "sleep 0.6; exec sleep 0.8")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	unrelated := exec.Command("sleep", "0.1")
+	if err := unrelated.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = unrelated.Wait() }()
+	go func() { _ = cmd.Wait() }()
+
+	for name, value := range map[string]string{
+		"target":          strconv.Itoa(cmd.Process.Pid),
+		"duration":        "10s",
+		"follow-children": "true",
+		"child-pattern":   `sleep 0\.6`,
+	} {
+		if err := m.SetOption(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	start := time.Now()
+	result, err := m.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 8*time.Second {
+		t.Errorf("monitoring did not stop when the tree exited (%s)", elapsed)
+	}
+
+	processes := result.Data["processes"].([]map[string]interface{})
+	if len(processes) != 2 {
+		t.Fatalf("expected the target and one child, got %v", processes)
+	}
+	if processes[0]["pid"] != cmd.Process.Pid || processes[1]["parent"] != cmd.Process.Pid || processes[1]["depth"] != 1 {
+		t.Errorf("unexpected tree %v", processes)
+	}
+	for _, p := range processes {
+		if p["exited"] != true {
+			t.Errorf("process not marked exited: %v", p)
+		}
+	}
+	if events := readLogEvents(t, m.config.LogFile, "child_attached"); len(events) != 1 {
+		t.Errorf("logged %d attaches, want 1", len(events))
+	}
+	if events := readLogEvents(t, m.config.LogFile, "child_exited"); len(events) < 1 {
+		t.Error("child exit not logged")
+	}
+}
@@ -0,0 +1,436 @@
+package probe
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/macawi-ai/strigoi/pkg/modules"
+	"github.com/macawi-ai/strigoi/pkg/security"
+	"golang.org/x/term"
+)
+
+// launchReadSize is the largest chunk read from a stream at once.
+const launchReadSize = 32 * 1024
+
+// LaunchOptions configures launch-and-capture mode.
+type LaunchOptions struct {
+	// PTY runs the command on a pseudo-terminal instead of pipes, for
+	// interactive tools. stdout and stderr are then a single stream.
+	PTY bool
+	// Linger bounds how long output is still read after the command exits,
+	// while children that inherited its stdio hold the streams open.
+	Linger time.Duration
+
+	// The streams interposed between; default os.Stdin, os.Stdout, os.Stderr.
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Policy, if set, makes the interposition an MCP proxy: stdin and
+	// stdout carry MCP stdio traffic and every message is subject to the
+	// policy. It requires pipes.
+	Policy *security.MCPPolicy
+	// Approver decides messages the policy holds for approval; without
+	// one they are rejected.
+	Approver MCPApprover
+}
+
+// launchChunk is data read from one of the command's streams.
+type launchChunk struct {
+	stream string
+	data   []byte
+}
+
+// Launch runs argv with its stdio interposed: everything read from Stdin is
+// passed to the command and everything it writes is passed on to Stdout and
+// Stderr, and each chunk is also fed to the analysis pipeline. Children
+// inherit the interposed streams, so their traffic is captured too. Launch
+// returns once the command has exited and its streams are drained, with the
+// command's exit code (128+n if it was killed by signal n).
+func (m *CenterModule) Launch(argv []string, opts LaunchOptions) (*modules.ModuleResult, int, error) {
+	if len(argv) == 0 {
+		return nil, -1, fmt.Errorf("no command to run")
+	}
+	if opts.Stdin == nil {
+		opts.Stdin = os.Stdin
+	}
+	if opts.Stdout == nil {
+		opts.Stdout = os.Stdout
+	}
+	if opts.Stderr == nil {
+		opts.Stderr = os.Stderr
+	}
+
+	if opts.Policy != nil && opts.PTY {
+		return nil, -1, fmt.Errorf("policy enforcement needs pipes and cannot be used with a PTY")
+	}
+
+	if err := m.Configure(); err != nil {
+		return nil, -1, err
+	}
+	// The command owns the terminal; results go to the log only
+	m.display = nil
+
+	cmd := exec.Command(argv[0], argv[1:]...)
+	var session *launchSession
+	var err error
+	if opts.PTY {
+		session, err = startPTYSession(cmd, opts)
+	} else {
+		session, err = startPipeSession(cmd, opts)
+	}
+	if err != nil {
+		return nil, -1, fmt.Errorf("failed to start %s: %w", argv[0], err)
+	}
+
+	startTime := time.Now()
+	mode := "launch"
+	if opts.PTY {
+		mode = "launch-pty"
+	}
+	capture := &StreamCapture{
+		Target: StreamTarget{
+			PID:         cmd.Process.Pid,
+			Name:        filepath.Base(argv[0]),
+			CommandLine: strings.Join(argv, " "),
+			StartTime:   startTime,
+		},
+		Stdin:       NewStreamBuffer(m.config.BufferSize),
+		Stdout:      NewStreamBuffer(m.config.BufferSize),
+		Stderr:      NewStreamBuffer(m.config.BufferSize),
+		CaptureMode: mode,
+	}
+	m.mu.Lock()
+	m.activeStreams[capture.Target.PID] = capture
+	m.mu.Unlock()
+
+	start := map[string]interface{}{
+		"targets": []StreamTarget{capture.Target},
+		"config":  m.config,
+		"mode":    mode,
+	}
+	var proxy *MCPProxy
+	if opts.Policy != nil {
+		proxy = NewMCPProxy(opts.Policy, m.logger, opts.Stdout, session.input)
+		proxy.SetApprover(opts.Approver)
+		start["policy"] = map[string]string{"name": opts.Policy.Name, "version": opts.Policy.Version}
+	}
+	if err := m.logger.LogEvent("start", start); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to log start event: %v\n", err)
+	}
+
+	// A single consumer keeps analysis in arrival order; the channel blocks
+	// readers rather than dropping data when analysis falls behind.
+	chunks := make(chan launchChunk, 64)
+	analyzed := make(chan struct{})
+	go func() {
+		defer close(analyzed)
+		ticker := time.NewTicker(m.config.PollInterval)
+		defer ticker.Stop()
+
+		// Interposed pipes see every byte the command moves
+		loss := CaptureLoss{Method: mode, Streams: make(map[string]StreamCoverage)}
+		for {
+			select {
+			case chunk, ok := <-chunks:
+				if !ok {
+					m.flushStreams(capture, time.Time{})
+					loss.Percent = loss.total().Percent
+					m.recordLoss(capture, loss)
+					return
+				}
+				cov := loss.Streams[chunk.stream]
+				cov.add(StreamCoverage{BytesSeen: int64(len(chunk.data)), BytesDelivered: int64(len(chunk.data))})
+				loss.Streams[chunk.stream] = cov
+				m.processStreamData(capture, chunk.stream, nil, chunk.data)
+			case now := <-ticker.C:
+				m.flushStreams(capture, now)
+			}
+		}
+	}()
+
+	// stdin is not waited for: the command may exit without reading it all
+	go func() {
+		if proxy != nil {
+			relayStream(proxy, opts.Stdin, MCPClientToServer, "stdin", chunks, session)
+		} else {
+			teeStream(opts.Stdin, session.input, "stdin", chunks, session)
+		}
+		session.closeInput()
+	}()
+
+	var readers sync.WaitGroup
+	for i, out := range session.outputs {
+		readers.Add(1)
+		go func(out *os.File, stream string, dst io.Writer) {
+			defer readers.Done()
+			if proxy != nil && stream == "stdout" {
+				relayStream(proxy, out, MCPServerToClient, stream, chunks, nil)
+				return
+			}
+			teeStream(out, dst, stream, chunks, nil)
+		}(out, session.names[i], session.writers[i])
+	}
+
+	// Signals for strigoi are meant for the command
+	signals := make(chan os.Signal, 4)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	exited := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				_ = cmd.Process.Signal(sig)
+			case <-exited:
+				return
+			}
+		}
+	}()
+
+	waitErr := cmd.Wait()
+	signal.Stop(signals)
+	close(exited)
+	exitCode := exitStatus(cmd.ProcessState, waitErr)
+
+	// Drain output still buffered or written by children, within Linger
+	drained := make(chan struct{})
+	go func() {
+		readers.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(opts.Linger):
+		for _, out := range session.outputs {
+			out.Close()
+		}
+		<-drained
+	}
+	for _, out := range session.outputs {
+		out.Close()
+	}
+	session.restore()
+
+	// Late stdin is dropped; the consumer exits once readers are done
+	session.stopInput(chunks)
+	<-analyzed
+
+	results := m.collectResults()
+	results["command"] = argv
+	results["exit_code"] = exitCode
+	if proxy != nil {
+		results["policy"] = proxy.Summary()
+	}
+	if err := m.logger.LogEvent("stop", map[string]interface{}{
+		"reason":    "exited",
+		"exit_code": exitCode,
+		"duration":  time.Since(startTime),
+		"stats":     results["statistics"],
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to log stop event: %v\n", err)
+	}
+
+	return &modules.ModuleResult{
+		Module:    m.Name(),
+		Status:    "completed",
+		StartTime: startTime,
+		EndTime:   time.Now(),
+		Data:      results,
+	}, exitCode, nil
+}
+
+// launchSession holds the interposed ends of a started command's stdio.
+type launchSession struct {
+	input   io.WriteCloser // the command's stdin
+	outputs []*os.File     // the command's output streams
+	names   []string       // stream names for outputs
+	writers []io.Writer    // where each output is passed on
+
+	mu       sync.Mutex
+	stopped  bool
+	restorer func()
+}
+
+// closeInput closes the command's stdin unless the session already ended.
+func (s *launchSession) closeInput() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.stopped {
+		s.input.Close()
+	}
+}
+
+// stopInput ends stdin forwarding and closes the chunk channel; the stdin
+// goroutine may still be blocked reading and must not send afterwards.
+func (s *launchSession) stopInput(chunks chan launchChunk) {
+	s.mu.Lock()
+	s.stopped = true
+	s.mu.Unlock()
+	s.input.Close()
+	close(chunks)
+}
+
+// send forwards a stdin chunk unless the session has ended.
+func (s *launchSession) send(chunks chan launchChunk, chunk launchChunk) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.stopped {
+		chunks <- chunk
+	}
+}
+
+// restore undoes terminal changes made for the session.
+func (s *launchSession) restore() {
+	if s.restorer != nil {
+		s.restorer()
+	}
+}
+
+// startPipeSession starts cmd with its stdin, stdout and stderr on pipes.
+// The command gets real pipe fds, which its children inherit.
+func startPipeSession(cmd *exec.Cmd, opts LaunchOptions) (*launchSession, error) {
+	stdinR, stdinW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	stdoutR, stdoutW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	stderrR, stderrW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = stdinR, stdoutW, stderrW
+	cmd.SysProcAttr = pipeProcAttr()
+	err = cmd.Start()
+	// The command holds its own copies now
+	stdinR.Close()
+	stdoutW.Close()
+	stderrW.Close()
+	if err != nil {
+		stdinW.Close()
+		stdoutR.Close()
+		stderrR.Close()
+		return nil, err
+	}
+
+	return &launchSession{
+		input:   stdinW,
+		outputs: []*os.File{stdoutR, stderrR},
+		names:   []string{"stdout", "stderr"},
+		writers: []io.Writer{opts.Stdout, opts.Stderr},
+	}, nil
+}
+
+// startPTYSession starts cmd as a session leader on a new pseudo-terminal.
+// If Stdin is a terminal it is switched to raw mode so keystrokes, including
+// Ctrl+C, reach the command unchanged.
+func startPTYSession(cmd *exec.Cmd, opts LaunchOptions) (*launchSession, error) {
+	master, slave, err := openPTY()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &launchSession{
+		input:   master,
+		outputs: []*os.File{master},
+		names:   []string{"stdout"},
+		writers: []io.Writer{opts.Stdout},
+	}
+	if in, ok := opts.Stdin.(*os.File); ok && term.IsTerminal(int(in.Fd())) {
+		stopResize := followWindowSize(in, master)
+		state, err := term.MakeRaw(int(in.Fd()))
+		session.restorer = func() {
+			stopResize()
+			if err == nil {
+				_ = term.Restore(int(in.Fd()), state)
+			}
+		}
+	}
+
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = slave, slave, slave
+	cmd.SysProcAttr = ptyProcAttr()
+	err = cmd.Start()
+	slave.Close()
+	if err != nil {
+		session.restore()
+		master.Close()
+		return nil, err
+	}
+	// Closing the master would hang up the command; stdin EOF is not an end
+	session.input = nopWriteCloser{master}
+	return session, nil
+}
+
+// nopWriteCloser ignores Close.
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// teeStream copies src to dst and sends a copy of every chunk for analysis.
+// dst is written first so the interposition adds no latency.
+func teeStream(src io.Reader, dst io.Writer, stream string, chunks chan launchChunk, session *launchSession) {
+	buf := make([]byte, launchReadSize)
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			if _, werr := dst.Write(buf[:n]); werr != nil && stream == "stdin" {
+				return
+			}
+			chunk := launchChunk{stream: stream, data: append([]byte(nil), buf[:n]...)}
+			if session != nil {
+				session.send(chunks, chunk)
+			} else {
+				chunks <- chunk
+			}
+		}
+		if err != nil {
+			// EIO is how a PTY master reports that the terminal closed
+			if !errors.Is(err, io.EOF) && !errors.Is(err, syscall.EIO) && !errors.Is(err, os.ErrClosed) {
+				fmt.Fprintf(os.Stderr, "Warning: reading %s: %v\n", stream, err)
+			}
+			return
+		}
+	}
+}
+
+// relayStream runs src through proxy and sends a copy of every message as
+// read for analysis.
+func relayStream(proxy *MCPProxy, src io.Reader, direction, stream string, chunks chan launchChunk, session *launchSession) {
+	err := proxy.Relay(src, direction, func(data []byte) {
+		chunk := launchChunk{stream: stream, data: data}
+		if session != nil {
+			session.send(chunks, chunk)
+		} else {
+			chunks <- chunk
+		}
+	})
+	if err != nil && !errors.Is(err, syscall.EIO) && !errors.Is(err, syscall.EPIPE) && !errors.Is(err, os.ErrClosed) {
+		fmt.Fprintf(os.Stderr, "Warning: relaying %s: %v\n", stream, err)
+	}
+}
+
+// exitStatus converts how a command ended into a shell-style exit code.
+func exitStatus(state *os.ProcessState, err error) int {
+	if state == nil {
+		if err != nil {
+			return 1
+		}
+		return 0
+	}
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return state.ExitCode()
+}
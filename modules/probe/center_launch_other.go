@@ -0,0 +1,26 @@
+//go:build !linux
+
+package probe
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// openPTY is only implemented for Linux.
+func openPTY() (master, slave *os.File, err error) {
+	return nil, nil, fmt.Errorf("PTY mode is only supported on Linux")
+}
+
+func followWindowSize(_, _ *os.File) func() {
+	return func() {}
+}
+
+func pipeProcAttr() *syscall.SysProcAttr {
+	return nil
+}
+
+func ptyProcAttr() *syscall.SysProcAttr {
+	return nil
+}
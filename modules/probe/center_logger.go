@@ -80,6 +80,15 @@ func (l *EventLogger) LogVulnerability(vuln StreamVulnerability) error {
 			},
 		},
 	}
+	if len(vuln.Regulations) > 0 {
+		event["vuln"].(map[string]interface{})["regulations"] = vuln.Regulations
+	}
+	if vuln.Stream != "" {
+		event["vuln"].(map[string]interface{})["stream"] = vuln.Stream
+	}
+	if vuln.FD != nil {
+		event["vuln"].(map[string]interface{})["fd"] = vuln.FD
+	}
 
 	return l.encoder.Encode(event)
 }
@@ -97,9 +106,10 @@ func (l *EventLogger) LogStatistics(stats map[string]interface{}) error {
 	return l.LogEvent("statistics", stats)
 }
 
-// LogActivity logs stream activity (when show-activity is enabled).
-func (l *EventLogger) LogActivity(target StreamTarget, stream string, preview string, bytes int) error {
-	return l.LogEvent("activity", map[string]interface{}{
+// LogActivity logs stream activity (when show-activity is enabled). fd
+// describes the descriptor for streams other than stdin, stdout and stderr.
+func (l *EventLogger) LogActivity(target StreamTarget, stream string, fd *FDStream, preview string, bytes int) error {
+	data := map[string]interface{}{
 		"process": map[string]interface{}{
 			"pid":  target.PID,
 			"name": target.Name,
@@ -107,7 +117,11 @@ func (l *EventLogger) LogActivity(target StreamTarget, stream string, preview st
 		"stream":  stream,
 		"preview": preview,
 		"bytes":   bytes,
-	})
+	}
+	if fd != nil {
+		data["fd"] = fd
+	}
+	return l.LogEvent("activity", data)
 }
 
 // VulnerabilityDetector manages vulnerability detection logic.
@@ -0,0 +1,397 @@
+package probe
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PtraceCapture captures a process's I/O by tracing its system calls with
+// ptrace. It attaches to every thread of the process and intercepts read,
+// write, send and receive calls on stdin, stdout, stderr, sockets, pipes and
+// terminals, copying the full buffers out of the tracee's memory. Unlike
+// procfs polling it sees PTY traffic, and it needs no external tools.
+type PtraceCapture struct {
+	pid    int
+	config PtraceConfig
+
+	mu         sync.RWMutex
+	active     bool
+	lastError  error
+	startTime  time.Time
+	syscalls   int64
+	bytesTotal int64
+	fileBytes  int64 // regular file data, kept out of bytesTotal
+	truncated  int64 // calls whose data exceeded MaxBufferSize or could not be read
+	threads    int
+
+	// Loss accounting by stream name; buffer drops are added when reported
+	coverage map[string]*StreamCoverage
+
+	// Accumulated stream data
+	stdinBuffer  EventBuffer
+	stdoutBuffer EventBuffer
+	stderrBuffer EventBuffer
+	fdStreams    map[ptraceStreamKey]*ptraceStream
+
+	peersMu sync.Mutex
+	peers   *fdPeers
+
+	lastRateLimitCheck time.Time
+	bytesInWindow      int64
+
+	stop      chan struct{}
+	stopOnce  sync.Once
+	done      chan struct{}
+	woken     bool // Stop signalled the tracee to wake the tracer
+	detaching bool // the tracer no longer expects to be woken
+}
+
+// PtraceConfig holds configuration for ptrace capture.
+type PtraceConfig struct {
+	MaxBufferSize int   // Max size per stream buffer and per call (default: 1MB)
+	MaxTotalBytes int64 // Max total bytes before stopping (default: 100MB)
+	MaxFileBytes  int64 // Max bytes of regular file data; later file reads are dropped (default: 10MB)
+	RateLimit     int   // Max bytes per second (0 = unlimited)
+}
+
+// DefaultPtraceConfig returns default ptrace configuration.
+func DefaultPtraceConfig() *PtraceConfig {
+	return &PtraceConfig{
+		MaxBufferSize: 1024 * 1024,       // 1MB per buffer
+		MaxTotalBytes: 100 * 1024 * 1024, // 100MB total
+		MaxFileBytes:  10 * 1024 * 1024,  // 10MB of files
+		RateLimit:     0,                 // Unlimited by default
+	}
+}
+
+// NewPtraceCapture creates a new ptrace-based capture engine.
+func NewPtraceCapture(pid int) (*PtraceCapture, error) {
+	return NewPtraceCaptureWithConfig(pid, DefaultPtraceConfig())
+}
+
+// NewPtraceCaptureWithConfig creates a new ptrace-based capture engine with
+// custom config.
+func NewPtraceCaptureWithConfig(pid int, config *PtraceConfig) (*PtraceCapture, error) {
+	if err := checkPtraceAvailable(); err != nil {
+		return nil, err
+	}
+	if pid <= 0 {
+		return nil, fmt.Errorf("invalid PID %d", pid)
+	}
+
+	cfg := *config
+	if cfg.MaxBufferSize <= 0 {
+		cfg.MaxBufferSize = 1024 * 1024
+	}
+	if cfg.MaxBufferSize > 10*1024*1024 { // Cap at 10MB per buffer
+		cfg.MaxBufferSize = 10 * 1024 * 1024
+	}
+	if cfg.MaxTotalBytes <= 0 {
+		cfg.MaxTotalBytes = 100 * 1024 * 1024
+	}
+	if cfg.MaxFileBytes <= 0 {
+		cfg.MaxFileBytes = 10 * 1024 * 1024
+	}
+
+	c := &PtraceCapture{
+		pid:          pid,
+		config:       cfg,
+		stdinBuffer:  NewStreamBuffer(cfg.MaxBufferSize),
+		stdoutBuffer: NewStreamBuffer(cfg.MaxBufferSize),
+		stderrBuffer: NewStreamBuffer(cfg.MaxBufferSize),
+		fdStreams:    make(map[ptraceStreamKey]*ptraceStream),
+		coverage:     make(map[string]*StreamCoverage),
+		peers:        newFDPeers("/proc", pid),
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+
+	return c, nil
+}
+
+// Stop detaches from all threads, leaving the process running as before.
+func (c *PtraceCapture) Stop() error {
+	c.mu.RLock()
+	started := !c.startTime.IsZero()
+	c.mu.RUnlock()
+	if !started {
+		return nil
+	}
+
+	c.stopOnce.Do(func() { close(c.stop) })
+	c.wake()
+	select {
+	case <-c.done:
+		return nil
+	case <-time.After(5 * time.Second):
+		return fmt.Errorf("timed out detaching from PID %d", c.pid)
+	}
+}
+
+// ptraceStreamKey tells descriptor streams apart. A reused descriptor that
+// refers to something else starts a new stream.
+type ptraceStreamKey struct {
+	fd        int
+	direction string
+	inode     uint64
+	path      string
+}
+
+// ptraceStream accumulates the data of one descriptor stream.
+type ptraceStream struct {
+	info   FDStream
+	buffer EventBuffer
+}
+
+// ptraceStreamName names the stream a call's data is kept in.
+func ptraceStreamName(info FDStream) string {
+	switch {
+	case info.FD == 0 && info.Direction == FDRead:
+		return "stdin"
+	case info.FD == 1 && info.Direction == FDWrite:
+		return "stdout"
+	case info.FD == 2 && info.Direction == FDWrite:
+		return "stderr"
+	}
+	return info.Name()
+}
+
+// streamCoverage returns the loss counters of a stream. It is called with mu
+// held.
+func (c *PtraceCapture) streamCoverage(name string) *StreamCoverage {
+	cov, ok := c.coverage[name]
+	if !ok {
+		cov = &StreamCoverage{}
+		c.coverage[name] = cov
+	}
+	return cov
+}
+
+// record stores data transferred by one call of n bytes on the descriptor
+// info describes; data holds as much of it as was copied. Reads of stdin
+// and writes to stdout and stderr go to the standard streams; everything
+// else is kept per descriptor and direction. Regular files have a budget of
+// their own, so a server loading its dependencies does not use up the one
+// for streams and end the capture.
+func (c *PtraceCapture) record(info FDStream, data []byte, n int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cov := c.streamCoverage(ptraceStreamName(info))
+	cov.BytesSeen += n
+	if int64(len(data)) < n {
+		cov.TruncatedCalls++
+		c.truncated++
+	}
+	if len(data) == 0 {
+		return
+	}
+
+	file := info.Type == FDTypeFile && info.FD > 2
+	if file && c.fileBytes >= c.config.MaxFileBytes {
+		cov.BytesDropped += int64(len(data))
+		return
+	}
+	if !file && c.bytesTotal >= c.config.MaxTotalBytes {
+		cov.BytesDropped += int64(len(data))
+		if c.lastError == nil {
+			c.lastError = fmt.Errorf("max total bytes limit reached (%d bytes)", c.config.MaxTotalBytes)
+			c.stopOnce.Do(func() { close(c.stop) })
+		}
+		return
+	}
+
+	if c.config.RateLimit > 0 {
+		now := time.Now()
+		if now.Sub(c.lastRateLimitCheck) >= time.Second {
+			c.bytesInWindow = 0
+			c.lastRateLimitCheck = now
+		}
+		// Drop data to maintain the rate limit
+		if c.bytesInWindow+int64(len(data)) > int64(c.config.RateLimit) {
+			cov.BytesDropped += int64(len(data))
+			return
+		}
+		c.bytesInWindow += int64(len(data))
+	}
+
+	c.syscalls++
+	if file {
+		c.fileBytes += int64(len(data))
+	} else {
+		c.bytesTotal += int64(len(data))
+	}
+
+	switch {
+	case info.FD == 0 && info.Direction == FDRead:
+		c.stdinBuffer.Write(data)
+	case info.FD == 1 && info.Direction == FDWrite:
+		c.stdoutBuffer.Write(data)
+	case info.FD == 2 && info.Direction == FDWrite:
+		c.stderrBuffer.Write(data)
+	default:
+		key := ptraceStreamKey{fd: info.FD, direction: info.Direction, inode: info.Inode, path: info.Path}
+		stream, ok := c.fdStreams[key]
+		if !ok {
+			stream = &ptraceStream{info: info, buffer: NewStreamBuffer(c.config.MaxBufferSize)}
+			c.fdStreams[key] = stream
+		}
+		stream.buffer.Write(data)
+	}
+}
+
+// Read retrieves and clears the data captured since the last call.
+func (c *PtraceCapture) Read() (*StreamData, error) {
+	data, idle, err := c.drain()
+
+	// /proc is read outside the lock so that it does not hold up the tracer
+	c.forgetClosed(idle)
+	if err != nil {
+		return nil, err
+	}
+
+	c.peersMu.Lock()
+	for i := range data.FDs {
+		c.peers.resolve(&data.FDs[i])
+	}
+	c.peersMu.Unlock()
+	return data, nil
+}
+
+// drain takes the buffered data of every stream, and returns the streams
+// that had none so the caller can check whether they are closed.
+func (c *PtraceCapture) drain() (*StreamData, map[ptraceStreamKey]FDStream, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data := &StreamData{
+		Timestamp: time.Now(),
+		Stdin:     c.stdinBuffer.ReadAll(),
+		Stdout:    c.stdoutBuffer.ReadAll(),
+		Stderr:    c.stderrBuffer.ReadAll(),
+	}
+	c.streamCoverage("stdin").BytesDelivered += int64(len(data.Stdin))
+	c.streamCoverage("stdout").BytesDelivered += int64(len(data.Stdout))
+	c.streamCoverage("stderr").BytesDelivered += int64(len(data.Stderr))
+	idle := make(map[ptraceStreamKey]FDStream)
+	for key, stream := range c.fdStreams {
+		chunk := stream.buffer.ReadAll()
+		c.streamCoverage(stream.info.Name()).BytesDelivered += int64(len(chunk))
+		if len(chunk) == 0 {
+			idle[key] = stream.info
+			continue
+		}
+		fd := stream.info
+		fd.Data = chunk
+		data.FDs = append(data.FDs, fd)
+	}
+	sort.Slice(data.FDs, func(i, j int) bool {
+		if data.FDs[i].FD != data.FDs[j].FD {
+			return data.FDs[i].FD < data.FDs[j].FD
+		}
+		return data.FDs[i].Direction < data.FDs[j].Direction
+	})
+	hasData := len(data.Stdin) > 0 || len(data.Stdout) > 0 || len(data.Stderr) > 0 || len(data.FDs) > 0
+
+	// Buffered data is returned before any error
+	if c.lastError != nil && !hasData {
+		return nil, idle, c.lastError
+	}
+	if !c.active && !hasData && c.bytesTotal == 0 {
+		return nil, idle, fmt.Errorf("ptrace capture not active or no data captured")
+	}
+	return data, idle, nil
+}
+
+// forgetClosed stops tracking the drained streams whose descriptors are
+// closed.
+func (c *PtraceCapture) forgetClosed(idle map[ptraceStreamKey]FDStream) {
+	var closed []ptraceStreamKey
+	for key, info := range idle {
+		if !c.fdOpen(info) {
+			closed = append(closed, key)
+		}
+	}
+	if len(closed) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range closed {
+		// Data written meanwhile is kept for the next drain
+		stream, ok := c.fdStreams[key]
+		if !ok || stream.buffer.Len() > 0 {
+			continue
+		}
+		c.streamCoverage(stream.info.Name()).BytesDropped += int64(stream.buffer.Stats().BytesDropped)
+		delete(c.fdStreams, key)
+	}
+}
+
+// GetStats returns capture statistics.
+func (c *PtraceCapture) GetStats() map[string]interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	stats := map[string]interface{}{
+		"method":          "ptrace",
+		"pid":             c.pid,
+		"active":          c.active,
+		"threads":         c.threads,
+		"duration":        time.Since(c.startTime),
+		"syscalls":        c.syscalls,
+		"bytes_total":     c.bytesTotal,
+		"file_bytes":      c.fileBytes,
+		"truncated":       c.truncated,
+		"fds":             len(c.fdStreams),
+		"max_buffer_size": c.config.MaxBufferSize,
+		"max_total_bytes": c.config.MaxTotalBytes,
+		"max_file_bytes":  c.config.MaxFileBytes,
+		"rate_limit":      c.config.RateLimit,
+		"last_error":      c.lastError,
+	}
+	if c.config.MaxTotalBytes > 0 {
+		stats["bytes_limit_percent"] = float64(c.bytesTotal) / float64(c.config.MaxTotalBytes) * 100
+	}
+	return stats
+}
+
+// Coverage reports how much of each stream's traffic was delivered by Read.
+// Calls the tracer could not copy in full count as truncated, and data
+// overwritten before it was read as dropped.
+func (c *PtraceCapture) Coverage() map[string]StreamCoverage {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	buffers := map[string]EventBuffer{
+		"stdin":  c.stdinBuffer,
+		"stdout": c.stdoutBuffer,
+		"stderr": c.stderrBuffer,
+	}
+	for _, stream := range c.fdStreams {
+		buffers[stream.info.Name()] = stream.buffer
+	}
+
+	coverage := make(map[string]StreamCoverage, len(c.coverage))
+	for name, cov := range c.coverage {
+		if cov.BytesSeen == 0 {
+			continue
+		}
+		report := *cov
+		if buffer, ok := buffers[name]; ok {
+			report.BytesDropped += int64(buffer.Stats().BytesDropped)
+		}
+		report.updatePercent()
+		coverage[name] = report
+	}
+	return coverage
+}
+
+// fdOpen reports whether the descriptor still refers to what info describes.
+func (c *PtraceCapture) fdOpen(info FDStream) bool {
+	current, ok := describeFD("/proc", c.pid, info.FD)
+	return ok && current.Inode == info.Inode && current.Path == info.Path
+}
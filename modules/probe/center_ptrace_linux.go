@@ -0,0 +1,533 @@
+//go:build linux && (amd64 || arm64)
+
+package probe
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// nativeAuditArch identifies system calls made with the native ABI; calls
+// from 32-bit compat code use other numbers and are ignored.
+var nativeAuditArch = map[string]uint32{
+	"amd64": unix.AUDIT_ARCH_X86_64,
+	"arm64": unix.AUDIT_ARCH_AARCH64,
+}[runtime.GOARCH]
+
+// ptraceCall describes how a traced system call carries its data.
+type ptraceCall struct {
+	name   string
+	write  bool // data flows out of the process
+	vector bool // buffer argument is an iovec array
+	msghdr bool // buffer argument is a struct msghdr
+}
+
+// ptraceCalls are the system calls whose data is captured.
+var ptraceCalls = map[uint64]ptraceCall{
+	unix.SYS_READ:     {name: "read"},
+	unix.SYS_WRITE:    {name: "write", write: true},
+	unix.SYS_READV:    {name: "readv", vector: true},
+	unix.SYS_WRITEV:   {name: "writev", write: true, vector: true},
+	unix.SYS_RECVFROM: {name: "recvfrom"},
+	unix.SYS_SENDTO:   {name: "sendto", write: true},
+	unix.SYS_RECVMSG:  {name: "recvmsg", msghdr: true},
+	unix.SYS_SENDMSG:  {name: "sendmsg", write: true, msghdr: true},
+}
+
+// ptraceSyscallInfo mirrors struct ptrace_syscall_info (Linux 5.3+).
+type ptraceSyscallInfo struct {
+	Op                 uint8
+	_                  [3]byte
+	Arch               uint32
+	InstructionPointer uint64
+	StackPointer       uint64
+	Data               [8]uint64 // entry: nr, args[6]; exit: rval, is_error
+}
+
+const (
+	ptraceSyscallInfoEntry = 1
+	ptraceSyscallInfoExit  = 2
+	ptraceMaxIovecs        = 1024 // IOV_MAX
+)
+
+// ptraceWakeSignal is sent to the tracee by Stop to end the tracer's
+// blocking wait. It is ignored by default, and the tracer suppresses it.
+const ptraceWakeSignal = unix.SIGURG
+
+// ptraceTask is the tracer's view of one traced thread.
+type ptraceTask struct {
+	nr   uint64 // traced call in progress, from its entry stop
+	args [6]uint64
+	busy bool
+}
+
+// ptracer owns the tracing state. All ptrace requests must come from the
+// OS thread that attached, so it only runs on a locked goroutine.
+type ptracer struct {
+	capture *PtraceCapture
+	tasks   map[int]*ptraceTask
+	woken   bool // the wake signal from Stop was seen and suppressed
+}
+
+// checkPtraceAvailable reports whether the kernel allows ptrace at all.
+func checkPtraceAvailable() error {
+	if data, err := os.ReadFile("/proc/sys/kernel/yama/ptrace_scope"); err == nil && strings.TrimSpace(string(data)) == "3" {
+		return fmt.Errorf("ptrace is disabled (kernel.yama.ptrace_scope=3)")
+	}
+	return nil
+}
+
+// checkPtracePermission verifies the target process exists.
+func checkPtracePermission(pid int) error {
+	if err := unix.Kill(pid, 0); err != nil && !errors.Is(err, unix.EPERM) {
+		return fmt.Errorf("process %d not found or not accessible", pid)
+	}
+	return nil
+}
+
+// Start attaches to every thread of the process and begins capture.
+func (c *PtraceCapture) Start() error {
+	c.mu.Lock()
+	if !c.startTime.IsZero() {
+		c.mu.Unlock()
+		return fmt.Errorf("ptrace capture already started")
+	}
+	c.startTime = time.Now()
+	c.lastRateLimitCheck = c.startTime
+	c.mu.Unlock()
+
+	if err := checkPtracePermission(c.pid); err != nil {
+		close(c.done)
+		return err
+	}
+
+	ready := make(chan error, 1)
+	go c.trace(ready)
+	return <-ready
+}
+
+// trace runs the tracer until the process exits or Stop is called.
+func (c *PtraceCapture) trace(ready chan<- error) {
+	// The thread is never unlocked, so it exits with the goroutine and no
+	// other goroutine inherits the tracer relationship
+	runtime.LockOSThread()
+	defer close(c.done)
+
+	t := &ptracer{capture: c, tasks: make(map[int]*ptraceTask)}
+	if err := t.attach(); err != nil {
+		ready <- err
+		return
+	}
+	c.mu.Lock()
+	c.active = true
+	c.threads = len(t.tasks)
+	c.mu.Unlock()
+	ready <- nil
+
+	err := t.run()
+
+	c.mu.Lock()
+	c.active = false
+	if err != nil && c.lastError == nil {
+		c.lastError = err
+	}
+	c.mu.Unlock()
+}
+
+// attach seizes every thread of the process. Threads created meanwhile are
+// found by listing again until no new ones appear; later ones are reported
+// through clone events.
+func (t *ptracer) attach() error {
+	pid := t.capture.pid
+	options := unix.PTRACE_O_TRACESYSGOOD | unix.PTRACE_O_TRACECLONE
+	for {
+		entries, err := os.ReadDir(fmt.Sprintf("/proc/%d/task", pid))
+		if err != nil {
+			t.detachAll()
+			return fmt.Errorf("process %d not found: %w", pid, err)
+		}
+
+		added := 0
+		for _, entry := range entries {
+			tid, err := strconv.Atoi(entry.Name())
+			if err != nil || t.tasks[tid] != nil {
+				continue
+			}
+			if err := ptraceRequest(unix.PTRACE_SEIZE, tid, 0, uintptr(options)); err != nil {
+				if errors.Is(err, unix.ESRCH) {
+					continue // thread exited
+				}
+				t.detachAll()
+				if errors.Is(err, unix.EPERM) {
+					return fmt.Errorf("insufficient permissions for ptrace on PID %d (needs CAP_SYS_PTRACE or kernel.yama.ptrace_scope=0): %w", pid, err)
+				}
+				return fmt.Errorf("failed to attach to thread %d: %w", tid, err)
+			}
+			t.tasks[tid] = &ptraceTask{}
+			added++
+			// Stop it so syscall tracing can be switched on
+			_ = unix.PtraceInterrupt(tid)
+		}
+		if added == 0 {
+			break
+		}
+	}
+	if len(t.tasks) == 0 {
+		return fmt.Errorf("process %d has no threads to trace", pid)
+	}
+
+	// Syscalls are only traced once a thread is resumed from its interrupt
+	// stop; until then a call in progress completes unseen
+	probed := false
+	for tid := range t.tasks {
+		for t.tasks[tid] != nil {
+			var status unix.WaitStatus
+			if _, err := unix.Wait4(tid, &status, unix.WALL, nil); err != nil {
+				delete(t.tasks, tid)
+				break
+			}
+			stopped := status.Stopped() && ptraceEvent(status) == unix.PTRACE_EVENT_STOP
+			if stopped && !probed {
+				if err := probeSyscallInfo(tid); err != nil {
+					t.handle(tid, status)
+					t.detachAll()
+					return err
+				}
+				probed = true
+			}
+			t.handle(tid, status)
+			if stopped {
+				break
+			}
+		}
+	}
+	if len(t.tasks) == 0 {
+		return fmt.Errorf("process %d exited while attaching", pid)
+	}
+	return nil
+}
+
+// run handles stops until every thread has exited or capture is stopped.
+// It blocks waiting for any tracee; __WNOTHREAD keeps the wait to those
+// this thread attached, so children of the rest of this process are never
+// reaped. Every call of every thread stops, since a seccomp filter to stop
+// only at traced calls can only be installed by the process itself.
+func (t *ptracer) run() error {
+	for len(t.tasks) > 0 {
+		select {
+		case <-t.capture.stop:
+			// Wait for the wake signal Stop sent so it is not delivered
+			// once detached
+			if woken := t.capture.startDetach(); !woken || t.woken {
+				t.detachAll()
+				return nil
+			}
+		default:
+		}
+
+		var status unix.WaitStatus
+		tid, err := unix.Wait4(-1, &status, unix.WALL|unix.WNOTHREAD, nil)
+		if err != nil {
+			if errors.Is(err, unix.EINTR) {
+				continue
+			}
+			break // ECHILD: no tracee is left
+		}
+
+		if t.tasks[tid] == nil && status.Stopped() {
+			// A new thread may stop before its clone event is reported
+			t.tasks[tid] = &ptraceTask{}
+			t.capture.setThreads(len(t.tasks))
+		}
+		if status.Stopped() && ptraceEvent(status) == 0 && status.StopSignal() == ptraceWakeSignal && t.capture.wokenBy() {
+			t.woken = true
+			_ = unix.PtraceSyscall(tid, 0)
+		} else {
+			t.handle(tid, status)
+		}
+	}
+	return fmt.Errorf("traced process %d exited: %w", t.capture.pid, os.ErrNotExist)
+}
+
+// wake makes the tracer return from its blocking wait: the signal stops a
+// thread of the tracee, and the tracer suppresses it. Nothing is sent once
+// the tracer has begun detaching.
+func (c *PtraceCapture) wake() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.active && !c.detaching && !c.woken {
+		c.woken = unix.Kill(c.pid, ptraceWakeSignal) == nil
+	}
+}
+
+// wokenBy reports whether Stop sent the wake signal.
+func (c *PtraceCapture) wokenBy() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.woken
+}
+
+// startDetach stops wake signals from being sent and reports whether one
+// was.
+func (c *PtraceCapture) startDetach() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detaching = true
+	return c.woken
+}
+
+// probeSyscallInfo checks that the kernel supports PTRACE_GET_SYSCALL_INFO
+// (Linux 5.3+), without which no call can be decoded. tid must be in a
+// ptrace stop.
+func probeSyscallInfo(tid int) error {
+	var info ptraceSyscallInfo
+	if err := ptraceRequest(unix.PTRACE_GET_SYSCALL_INFO, tid, unsafe.Sizeof(info), uintptr(unsafe.Pointer(&info))); err != nil && !errors.Is(err, unix.ESRCH) {
+		return fmt.Errorf("ptrace capture needs PTRACE_GET_SYSCALL_INFO (Linux 5.3 or later): %w", err)
+	}
+	return nil
+}
+
+// handle processes one stop and resumes the thread.
+func (t *ptracer) handle(tid int, status unix.WaitStatus) {
+	if status.Exited() || status.Signaled() {
+		delete(t.tasks, tid)
+		t.capture.setThreads(len(t.tasks))
+		return
+	}
+	if !status.Stopped() {
+		return
+	}
+
+	sig := status.StopSignal()
+	event := ptraceEvent(status)
+	switch {
+	case sig == unix.SIGTRAP|0x80:
+		t.syscallStop(tid)
+		_ = unix.PtraceSyscall(tid, 0)
+
+	case event == unix.PTRACE_EVENT_CLONE:
+		if msg, err := unix.PtraceGetEventMsg(tid); err == nil && t.tasks[int(msg)] == nil {
+			// The new thread is traced already and reports its own stop
+			t.tasks[int(msg)] = &ptraceTask{}
+			t.capture.setThreads(len(t.tasks))
+		}
+		_ = unix.PtraceSyscall(tid, 0)
+
+	case event == unix.PTRACE_EVENT_STOP:
+		if sig == unix.SIGSTOP || sig == unix.SIGTSTP || sig == unix.SIGTTIN || sig == unix.SIGTTOU {
+			// Group-stop: stay stopped without blocking the tracer
+			_ = ptraceRequest(unix.PTRACE_LISTEN, tid, 0, 0)
+		} else {
+			_ = unix.PtraceSyscall(tid, 0)
+		}
+
+	case event != 0:
+		_ = unix.PtraceSyscall(tid, 0)
+
+	default:
+		// Signal-delivery-stop: deliver the signal
+		_ = unix.PtraceSyscall(tid, int(sig))
+	}
+}
+
+// ptraceEvent returns the PTRACE_EVENT_* of an event stop, or 0. Group-stops
+// of seized threads are event stops that carry the stopping signal, so
+// WaitStatus.TrapCause does not report them.
+func ptraceEvent(status unix.WaitStatus) int {
+	return int(uint32(status) >> 16)
+}
+
+// syscallStop records a traced call at entry and captures its data at exit,
+// once the number of bytes transferred is known.
+func (t *ptracer) syscallStop(tid int) {
+	task := t.tasks[tid]
+	if task == nil {
+		return
+	}
+
+	var info ptraceSyscallInfo
+	if err := ptraceRequest(unix.PTRACE_GET_SYSCALL_INFO, tid, unsafe.Sizeof(info), uintptr(unsafe.Pointer(&info))); err != nil {
+		task.busy = false
+		if !errors.Is(err, unix.ESRCH) {
+			t.capture.setError(fmt.Errorf("failed to decode a system call of thread %d: %w", tid, err))
+		}
+		return
+	}
+
+	switch info.Op {
+	case ptraceSyscallInfoEntry:
+		_, traced := ptraceCalls[info.Data[0]]
+		task.busy = traced && info.Arch == nativeAuditArch
+		task.nr = info.Data[0]
+		copy(task.args[:], info.Data[1:7])
+
+	case ptraceSyscallInfoExit:
+		if !task.busy {
+			return
+		}
+		task.busy = false
+		n := int64(info.Data[0])
+		if info.Data[1]&0xff != 0 || n <= 0 {
+			return
+		}
+		t.capture.captureCall(tid, ptraceCalls[task.nr], task.args, n)
+	}
+}
+
+// detachAll stops every thread and detaches, re-injecting any signal that
+// was about to be delivered.
+func (t *ptracer) detachAll() {
+	for tid := range t.tasks {
+		_ = unix.PtraceInterrupt(tid)
+	}
+	for tid := range t.tasks {
+		for {
+			var status unix.WaitStatus
+			if _, err := unix.Wait4(tid, &status, unix.WALL, nil); err != nil {
+				if errors.Is(err, unix.EINTR) {
+					continue
+				}
+				break
+			}
+			if status.Exited() || status.Signaled() {
+				break
+			}
+			if !status.Stopped() {
+				continue
+			}
+			sig := 0
+			if ptraceEvent(status) == 0 && status.StopSignal() != unix.SIGTRAP|0x80 {
+				sig = int(status.StopSignal())
+			}
+			_ = ptraceRequest(unix.PTRACE_DETACH, tid, 0, uintptr(sig))
+			break
+		}
+		delete(t.tasks, tid)
+	}
+	t.capture.setThreads(0)
+}
+
+// captureCall copies the data of a completed call on a captured descriptor.
+func (c *PtraceCapture) captureCall(tid int, call ptraceCall, args [6]uint64, n int64) {
+	// Descriptors are looked up on every call because they are reused
+	info, ok := describeFD("/proc", c.pid, int(args[0]))
+	if !ok {
+		return
+	}
+	info.Direction = FDRead
+	if call.write {
+		info.Direction = FDWrite
+	}
+
+	total := n
+	if n > int64(c.config.MaxBufferSize) {
+		n = int64(c.config.MaxBufferSize)
+	}
+
+	var segments []unix.RemoteIovec
+	switch {
+	case call.msghdr:
+		// struct msghdr: name, namelen, iov, iovlen, ...
+		hdr := readTraceeMemory(tid, []unix.RemoteIovec{{Base: uintptr(args[1]), Len: 32}}, 32)
+		if len(hdr) < 32 {
+			c.record(info, nil, total)
+			return
+		}
+		segments = readIovecs(tid, binary.LittleEndian.Uint64(hdr[16:]), binary.LittleEndian.Uint64(hdr[24:]), n)
+	case call.vector:
+		segments = readIovecs(tid, args[1], args[2], n)
+	default:
+		segments = []unix.RemoteIovec{{Base: uintptr(args[1]), Len: int(n)}}
+	}
+
+	c.record(info, readTraceeMemory(tid, segments, int(n)), total)
+}
+
+// setError records the first error of the capture.
+func (c *PtraceCapture) setError(err error) {
+	c.mu.Lock()
+	if c.lastError == nil {
+		c.lastError = err
+	}
+	c.mu.Unlock()
+}
+
+// setThreads updates the traced thread count.
+func (c *PtraceCapture) setThreads(n int) {
+	c.mu.Lock()
+	c.threads = n
+	c.mu.Unlock()
+}
+
+// readIovecs reads an iovec array from the tracee and returns the segments
+// covering its first n bytes.
+func readIovecs(tid int, addr, count uint64, n int64) []unix.RemoteIovec {
+	if count > ptraceMaxIovecs {
+		count = ptraceMaxIovecs
+	}
+	raw := readTraceeMemory(tid, []unix.RemoteIovec{{Base: uintptr(addr), Len: int(count * 16)}}, int(count*16))
+
+	var segments []unix.RemoteIovec
+	for i := 0; i+16 <= len(raw) && n > 0; i += 16 {
+		base := binary.LittleEndian.Uint64(raw[i:])
+		length := int64(binary.LittleEndian.Uint64(raw[i+8:]))
+		if length > n {
+			length = n
+		}
+		if length > 0 {
+			segments = append(segments, unix.RemoteIovec{Base: uintptr(base), Len: int(length)})
+			n -= length
+		}
+	}
+	return segments
+}
+
+// readTraceeMemory copies up to size bytes from the tracee's segments with
+// process_vm_readv, falling back to PTRACE_PEEKDATA where that is not
+// permitted.
+func readTraceeMemory(tid int, segments []unix.RemoteIovec, size int) []byte {
+	if size <= 0 || len(segments) == 0 {
+		return nil
+	}
+	buf := make([]byte, size)
+	n, err := unix.ProcessVMReadv(tid, []unix.Iovec{{Base: &buf[0], Len: uint64(size)}}, segments, 0)
+	if err == nil {
+		return buf[:n]
+	}
+	if !errors.Is(err, unix.ENOSYS) && !errors.Is(err, unix.EPERM) {
+		return nil
+	}
+
+	n = 0
+	for _, segment := range segments {
+		end := n + segment.Len
+		if end > size {
+			end = size
+		}
+		count, err := unix.PtracePeekData(tid, segment.Base, buf[n:end])
+		n += count
+		if err != nil || n >= size {
+			break
+		}
+	}
+	return buf[:n]
+}
+
+// ptraceRequest issues a ptrace request not wrapped by x/sys with its data
+// argument.
+func ptraceRequest(request, tid int, addr, data uintptr) error {
+	_, _, errno := unix.Syscall6(unix.SYS_PTRACE, uintptr(request), uintptr(tid), addr, data, 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
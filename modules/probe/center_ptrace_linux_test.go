@@ -0,0 +1,187 @@
+//go:build linux && (amd64 || arm64)
+
+package probe
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// startTraced starts cmd with piped stdio and attaches a capture to it.
+func startTraced(t *testing.T, cmd *exec.Cmd) (*PtraceCapture, io.WriteCloser, *bufio.Reader) {
+	t.Helper()
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Skipf("cannot start %s: %v", cmd.Path, err)
+	}
+	t.Cleanup(func() {
+		stdin.Close()
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+
+	capture, err := NewPtraceCapture(cmd.Process.Pid)
+	if err != nil {
+		t.Skipf("ptrace unavailable: %v", err)
+	}
+	if err := capture.Start(); err != nil {
+		t.Skipf("ptrace not permitted: %v", err)
+	}
+	t.Cleanup(func() { _ = capture.Stop() })
+	return capture, stdin, bufio.NewReader(stdout)
+}
+
+// collect reads from capture until done reports true or time runs out.
+func collect(capture *PtraceCapture, done func(*StreamData) bool) *StreamData {
+	all := &StreamData{}
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if data, err := capture.Read(); err == nil {
+			all.Stdin = append(all.Stdin, data.Stdin...)
+			all.Stdout = append(all.Stdout, data.Stdout...)
+			all.FDs = append(all.FDs, data.FDs...)
+		}
+		if done(all) {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return all
+}
+
+func TestPtraceCaptureStdio(t *testing.T) {
+	capture, stdin, stdout := startTraced(t, exec.Command("cat"))
+
+	// Far beyond what strace -s 1024 kept, and split over several reads
+	payload := strings.Repeat("0123456789", 20000) + "\n"
+	go io.WriteString(stdin, "hello ptrace\n"+payload)
+	for i := 0; i < 2; i++ {
+		if _, err := stdout.ReadString('\n'); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := "hello ptrace\n" + payload
+	data := collect(capture, func(d *StreamData) bool { return len(d.Stdin) >= len(want) && len(d.Stdout) >= len(want) })
+	if string(data.Stdin) != want {
+		t.Errorf("captured %d stdin bytes, want %d", len(data.Stdin), len(want))
+	}
+	if string(data.Stdout) != want {
+		t.Errorf("captured %d stdout bytes, want %d", len(data.Stdout), len(want))
+	}
+
+	stats := capture.GetStats()
+	if stats["method"] != "ptrace" || stats["active"] != true || stats["truncated"] != int64(0) {
+		t.Errorf("unexpected stats %v", stats)
+	}
+
+	// After detaching the process carries on untraced
+	if err := capture.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(stdin, "after\n"); err != nil {
+		t.Fatal(err)
+	}
+	if line, err := stdout.ReadString('\n'); err != nil || line != "after\n" {
+		t.Fatalf("process broken after detach: %q, %v", line, err)
+	}
+	if data, err := capture.Read(); err == nil && len(data.Stdin) > 0 {
+		t.Errorf("captured %q after detach", data.Stdin)
+	}
+}
+
+func TestPtraceStopSuppressesWakeSignal(t *testing.T) {
+	// The shell reports the signal Stop uses to wake the tracer if it
+	// is ever delivered
+	cmd := exec.Command("sh", "-c", `trap 'echo urg' URG; read x; echo done`)
+	capture, stdin, stdout := startTraced(t, cmd)
+
+	// Blocked in read, the process makes no system call to stop at
+	time.Sleep(100 * time.Millisecond)
+	start := time.Now()
+	if err := capture.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Stop took %s", elapsed)
+	}
+
+	if _, err := io.WriteString(stdin, "x\n"); err != nil {
+		t.Fatal(err)
+	}
+	if line, err := stdout.ReadString('\n'); err != nil || line != "done\n" {
+		t.Errorf("process printed %q, %v; want done", line, err)
+	}
+}
+
+func TestPtraceCaptureOtherFDs(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	cmd := exec.Command(os.Args[0], "-test.run=TestPtraceHelperProcess")
+	cmd.Env = append(os.Environ(), "STRIGOI_PTRACE_HELPER=1")
+	cmd.ExtraFiles = []*os.File{w} // fd 3
+	capture, stdin, _ := startTraced(t, cmd)
+	w.Close()
+
+	// The helper writes only once it is traced
+	if _, err := io.WriteString(stdin, "go\n"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil || got != "alpha beta\n" {
+		t.Fatalf("helper wrote %q, %v", got, err)
+	}
+
+	var written []byte
+	var stream FDStream
+	collect(capture, func(d *StreamData) bool {
+		for _, fd := range d.FDs {
+			if fd.FD == 3 {
+				stream = fd
+				written = append(written, fd.Data...)
+			}
+		}
+		d.FDs = nil
+		return bytes.Contains(written, []byte("\n"))
+	})
+	if string(written) != "alpha beta\n" {
+		t.Errorf("captured %q on fd 3, want the gathered writev data", written)
+	}
+	if stream.Name() != "fd3:pipe:write" || stream.Inode == 0 {
+		t.Errorf("unexpected stream %+v", stream)
+	}
+	// The test process holds the read end
+	if want := fmt.Sprintf("pid %d ", os.Getpid()); !strings.Contains(stream.Peer, want) {
+		t.Errorf("peer %q does not name %s", stream.Peer, want)
+	}
+}
+
+// TestPtraceHelperProcess is the traced process of TestPtraceCaptureOtherFDs.
+func TestPtraceHelperProcess(t *testing.T) {
+	if os.Getenv("STRIGOI_PTRACE_HELPER") != "1" {
+		return
+	}
+	_, _ = bufio.NewReader(os.Stdin).ReadString('\n')
+	_, _ = unix.Writev(3, [][]byte{[]byte("alpha "), []byte("beta\n")})
+	os.Exit(0)
+}
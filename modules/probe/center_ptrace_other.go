@@ -0,0 +1,21 @@
+//go:build !linux || !(amd64 || arm64)
+
+package probe
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// checkPtraceAvailable reports that native capture is unsupported here.
+func checkPtraceAvailable() error {
+	return fmt.Errorf("ptrace capture is only supported on Linux amd64 and arm64, not %s/%s", runtime.GOOS, runtime.GOARCH)
+}
+
+// Start is only implemented for Linux.
+func (c *PtraceCapture) Start() error {
+	return checkPtraceAvailable()
+}
+
+// wake has no tracer to wake.
+func (c *PtraceCapture) wake() {}
@@ -0,0 +1,117 @@
+package probe
+
+import (
+	"bytes"
+	"time"
+)
+
+// Reassembly defaults, used when the module options leave them unset.
+const (
+	defaultReassemblyLimit   = 1024 * 1024
+	defaultReassemblyTimeout = time.Second
+)
+
+// streamReassembler joins the chunks one stream delivers across polls into
+// complete protocol messages, so that dissectors see an HTTP body, a gRPC
+// frame or a JSON object whole. Boundaries come from a
+// ProtocolBoundaryDetector of its own, as some detectors keep state.
+//
+// A partial message is held until it completes, grows past the limit or
+// waits longer than the timeout; then it is delivered as it is. A
+// reassembler is used by one goroutine.
+type streamReassembler struct {
+	detector *ProtocolBoundaryDetector
+	limit    int
+	timeout  time.Duration
+
+	stream  string
+	fd      *FDStream // descriptor, without data; nil for stdio
+	pending []byte
+	since   time.Time // when the partial message started
+}
+
+func newStreamReassembler(stream string, fd *FDStream, limit int, timeout time.Duration) *streamReassembler {
+	if limit <= 0 {
+		limit = defaultReassemblyLimit
+	}
+	if timeout <= 0 {
+		timeout = defaultReassemblyTimeout
+	}
+	if fd != nil {
+		desc := *fd
+		desc.Data = nil
+		fd = &desc
+	}
+	return &streamReassembler{
+		detector: NewProtocolBoundaryDetector(),
+		limit:    limit,
+		timeout:  timeout,
+		stream:   stream,
+		fd:       fd,
+	}
+}
+
+// push adds a chunk and returns the messages it completes. A partial
+// message past the limit is returned too.
+func (r *streamReassembler) push(data []byte, now time.Time) [][]byte {
+	if len(r.pending) == 0 {
+		r.since = now
+	}
+	r.pending = append(r.pending, data...)
+
+	var messages [][]byte
+	for {
+		// Whitespace between messages is not analyzed
+		r.pending = bytes.TrimLeft(r.pending, " \t\r\n")
+		if len(r.pending) == 0 {
+			r.pending = nil
+			break
+		}
+		end, _, found := r.detector.detectAccumulated(r.pending, 0)
+		if !found || end <= 0 || end > len(r.pending) {
+			break
+		}
+		messages = append(messages, bytes.Clone(r.pending[:end]))
+		r.pending = r.pending[end:]
+		r.since = now
+	}
+
+	if len(r.pending) > r.limit {
+		messages = append(messages, r.flush())
+	}
+	return messages
+}
+
+// expired reports whether a partial message has waited past the timeout.
+func (r *streamReassembler) expired(now time.Time) bool {
+	return len(r.pending) > 0 && now.Sub(r.since) >= r.timeout
+}
+
+// flush removes and returns the partial message.
+func (r *streamReassembler) flush() []byte {
+	data := r.pending
+	r.pending = nil
+	return data
+}
+
+// describes reports whether fd is the descriptor the reassembler was created
+// for, rather than another file opened under the same number.
+func (r *streamReassembler) describes(fd *FDStream) bool {
+	if r.fd == nil || fd == nil {
+		return r.fd == nil && fd == nil
+	}
+	return r.fd.Inode == fd.Inode && r.fd.Path == fd.Path
+}
+
+// reassembler returns the reassembler of a stream, creating it on first use.
+func (c *StreamCapture) reassembler(stream string, fd *FDStream, limit int, timeout time.Duration) *streamReassembler {
+	if c.reassemblers == nil {
+		c.reassemblers = make(map[string]*streamReassembler)
+	}
+	r, ok := c.reassemblers[stream]
+	if !ok {
+		r = newStreamReassembler(stream, fd, limit, timeout)
+		c.reassemblers[stream] = r
+	}
+	return r
+}
@@ -1,287 +1,24 @@
 package probe
 
 import (
-	"strings"
-	"sync"
+	"fmt"
 	"time"
 )
 
-// StreamBuffer provides a thread-safe circular buffer for stream data.
-type StreamBuffer struct {
-	data     []byte
-	size     int
-	writePos int
-	readPos  int
-	mu       sync.Mutex
+// defaultStreamBufferSize is used when no buffer size is configured.
+const defaultStreamBufferSize = 64 * 1024
 
-	// Event boundary preservation
-	eventDelimiter []byte
-	eventMarks     []int // Positions of event boundaries
-	maxEvents      int   // Maximum number of events to track
-}
-
-// NewStreamBuffer creates a new stream buffer with the specified size.
-func NewStreamBuffer(size int) *StreamBuffer {
-	return &StreamBuffer{
-		data:       make([]byte, size),
-		size:       size,
-		eventMarks: make([]int, 0, 100),
-		maxEvents:  100,
-	}
-}
-
-// SetEventDelimiter sets the delimiter for event boundary detection.
-func (b *StreamBuffer) SetEventDelimiter(delimiter []byte) {
-	b.mu.Lock()
-	defer b.mu.Unlock()
-	b.eventDelimiter = delimiter
-}
-
-// Write adds data to the buffer, preserving event boundaries.
-func (b *StreamBuffer) Write(data []byte) int {
-	b.mu.Lock()
-	defer b.mu.Unlock()
-
-	if len(data) == 0 {
-		return 0
-	}
-
-	// Split data into complete events if delimiter is set
-	if b.eventDelimiter != nil {
-		return b.writeEvents(data)
-	}
-
-	// No delimiter - write as single unit
-	return b.writeData(data)
-}
-
-// writeEvents splits data by delimiter and writes complete events.
-func (b *StreamBuffer) writeEvents(data []byte) int {
-	delimiter := string(b.eventDelimiter)
-	dataStr := string(data)
-
-	// Split by delimiter but keep delimiter with each event
-	var events []string
-	start := 0
-	for {
-		idx := strings.Index(dataStr[start:], delimiter)
-		if idx == -1 {
-			// No more delimiters - add remaining data if any
-			if start < len(dataStr) {
-				events = append(events, dataStr[start:])
-			}
-			break
-		}
-		// Include delimiter in the event
-		end := start + idx + len(delimiter)
-		events = append(events, dataStr[start:end])
-		start = end
-	}
-
-	// Write each complete event
-	totalWritten := 0
-	for _, event := range events {
-		eventBytes := []byte(event)
-		written := b.writeData(eventBytes)
-		totalWritten += written
-
-		// Mark event boundary if this event ends with delimiter
-		if strings.HasSuffix(event, delimiter) {
-			b.addEventMark(b.writePos)
-		}
-	}
-
-	return totalWritten
-}
-
-// writeData writes raw data to buffer, making room if needed.
-func (b *StreamBuffer) writeData(data []byte) int {
-	if len(data) == 0 {
-		return 0
-	}
-
-	// If data is larger than total buffer, truncate to most recent part
-	if len(data) >= b.size {
-		data = data[len(data)-(b.size-1):]
-	}
-
-	// Make room if needed - apply 2π regulation with minimal intervention
-	available := b.getAvailableSpace()
-	if len(data) > available {
-		if len(data) >= b.size-1 {
-			// Data is too large for buffer - clear completely (extreme case)
-			b.readPos = 0
-			b.writePos = 0
-			b.eventMarks = nil
-		} else {
-			// Apply minimal 2π intervention - remove just enough oldest data
-			needed := len(data) - available
-			b.removeOldestData(needed)
-		}
-	}
-
-	// Write the data
-	written := 0
-	for _, byte := range data {
-		b.data[b.writePos] = byte
-		b.writePos = (b.writePos + 1) % b.size
-		written++
-	}
-	return written
-}
-
-// addEventMark records the position of an event boundary.
-func (b *StreamBuffer) addEventMark(position int) {
-	// Remove old marks that are behind readPos
-	validMarks := b.eventMarks[:0]
-	for _, mark := range b.eventMarks {
-		if b.isPositionAhead(mark, b.readPos) {
-			validMarks = append(validMarks, mark)
-		}
-	}
-	b.eventMarks = validMarks
-
-	// Add new mark
-	if len(b.eventMarks) < b.maxEvents {
-		b.eventMarks = append(b.eventMarks, position)
-	}
-}
-
-// getAvailableSpace calculates available space in the buffer.
-func (b *StreamBuffer) getAvailableSpace() int {
-	if b.writePos >= b.readPos {
-		return b.size - (b.writePos - b.readPos) - 1
-	}
-	return b.readPos - b.writePos - 1
-}
-
-// removeOldestData removes oldest data to free up at least bytesToRemove space.
-func (b *StreamBuffer) removeOldestData(bytesToRemove int) {
-	if bytesToRemove <= 0 {
-		return
-	}
-
-	// If we have event marks, try to remove complete events first
-	if len(b.eventMarks) > 0 {
-		for bytesToRemove > 0 && len(b.eventMarks) > 0 {
-			// Find the oldest event mark
-			oldestMark := b.eventMarks[0]
-			for _, mark := range b.eventMarks {
-				if !b.isPositionAhead(mark, oldestMark) {
-					oldestMark = mark
-				}
-			}
-
-			// Calculate how many bytes removing this event would free
-			if b.isPositionAhead(oldestMark, b.readPos) {
-				// Move readPos to after this event mark
-				bytesFreed := b.calculateDistance(b.readPos, (oldestMark+1)%b.size)
-				b.readPos = (oldestMark + 1) % b.size
-				bytesToRemove -= bytesFreed
-			} else {
-				// This event is already past readPos, move byte by byte
-				break
-			}
-
-			// Clean up event marks
-			b.cleanupEventMarks()
-		}
-	}
-
-	// If still need more space, remove byte by byte
-	removed := 0
-	for removed < bytesToRemove && b.readPos != b.writePos {
-		b.readPos = (b.readPos + 1) % b.size
-		removed++
-	}
-
-	// Final cleanup
-	b.cleanupEventMarks()
-}
-
-// calculateDistance calculates distance between two positions in circular buffer.
-func (b *StreamBuffer) calculateDistance(from, to int) int {
-	if to >= from {
-		return to - from
-	}
-	return b.size - from + to
-}
-
-// cleanupEventMarks removes event marks that are now behind readPos.
-func (b *StreamBuffer) cleanupEventMarks() {
-	validMarks := b.eventMarks[:0]
-	for _, mark := range b.eventMarks {
-		if b.isPositionAhead(mark, b.readPos) || mark == b.readPos {
-			validMarks = append(validMarks, mark)
-		}
-	}
-	b.eventMarks = validMarks
-}
-
-// isPositionAhead checks if pos1 is ahead of pos2 in circular buffer.
-func (b *StreamBuffer) isPositionAhead(pos1, pos2 int) bool {
-	if pos1 == pos2 {
-		return false
-	}
-
-	// Calculate distance considering circular nature
-	if pos1 > pos2 {
-		return (pos1 - pos2) <= (b.size / 2)
-	}
-	return (pos2 - pos1) > (b.size / 2)
-}
-
-// Read retrieves data from the buffer.
-func (b *StreamBuffer) Read(p []byte) (int, error) {
-	b.mu.Lock()
-	defer b.mu.Unlock()
-
-	if b.readPos == b.writePos {
-		return 0, nil // Empty buffer
-	}
-
-	n := 0
-	for n < len(p) && b.readPos != b.writePos {
-		p[n] = b.data[b.readPos]
-		b.readPos = (b.readPos + 1) % b.size
-		n++
+// NewStreamBuffer creates the buffer kept for each captured stream: events
+// end at newlines and the oldest are dropped when the buffer is full.
+func NewStreamBuffer(size int) EventBuffer {
+	if size <= 0 {
+		size = defaultStreamBufferSize
 	}
-	return n, nil
-}
-
-// Available returns the number of bytes available to read.
-func (b *StreamBuffer) Available() int {
-	b.mu.Lock()
-	defer b.mu.Unlock()
-
-	if b.writePos >= b.readPos {
-		return b.writePos - b.readPos
-	}
-	return b.size - b.readPos + b.writePos
-}
-
-// ReadAll reads all available data and resets the buffer.
-func (b *StreamBuffer) ReadAll() []byte {
-	b.mu.Lock()
-	defer b.mu.Unlock()
-
-	if b.readPos == b.writePos {
-		return nil
-	}
-
-	var result []byte
-	if b.writePos > b.readPos {
-		result = make([]byte, b.writePos-b.readPos)
-		copy(result, b.data[b.readPos:b.writePos])
-	} else {
-		// Wrapped around
-		result = make([]byte, b.size-b.readPos+b.writePos)
-		copy(result, b.data[b.readPos:])
-		copy(result[b.size-b.readPos:], b.data[:b.writePos])
-	}
-
-	b.readPos = b.writePos
-	return result
+	return newLockedEventBuffer(EventBufferConfig{
+		Size:     size,
+		Detector: NewLineBoundaryDetector(),
+		Overflow: OverflowDropOldest,
+	})
 }
 
 // StreamData holds captured stream data.
@@ -290,6 +27,40 @@ type StreamData struct {
 	Stdin     []byte
 	Stdout    []byte
 	Stderr    []byte
+	FDs       []FDStream        // other descriptors, such as sockets and pipes
+	Snapshots map[string][]byte // whole files read again every poll, such as /proc cmdline and environ, by name
+}
+
+// Directions of the data on a descriptor, from the target's side.
+const (
+	FDRead  = "read"
+	FDWrite = "write"
+)
+
+// Descriptor types.
+const (
+	FDTypePipe   = "pipe"
+	FDTypeSocket = "socket"
+	FDTypeFile   = "file"
+	FDTypeTTY    = "tty"
+)
+
+// FDStream is the data a process moved through one descriptor in one
+// direction, with what the descriptor refers to.
+type FDStream struct {
+	FD        int    `json:"fd"`
+	Direction string `json:"direction"`       // read or write
+	Type      string `json:"type"`            // pipe, socket, file or tty
+	Inode     uint64 `json:"inode,omitempty"` // pipes and sockets
+	Path      string `json:"path,omitempty"`  // files and terminals
+	Peer      string `json:"peer,omitempty"`  // remote address, unix path or pipe partner
+	Data      []byte `json:"-"`
+}
+
+// Name identifies the stream in logs and the display, such as
+// "fd5:socket:write".
+func (s *FDStream) Name() string {
+	return fmt.Sprintf("fd%d:%s:%s", s.FD, s.Type, s.Direction)
 }
 
 // Credential represents a detected credential.
@@ -314,6 +85,13 @@ type Dissector interface {
 	GetSessionID(frame *Frame) (string, error)
 }
 
+// StreamDissector is implemented by dissectors that keep state across
+// chunks, such as request/response correlation. DissectStream scopes that
+// state to one conversation: a socket, or a process's stdio.
+type StreamDissector interface {
+	DissectStream(conversation string, data []byte) (*Frame, error)
+}
+
 // Frame represents parsed protocol data.
 type Frame struct {
 	Protocol string                 `json:"protocol"`
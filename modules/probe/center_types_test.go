@@ -1,7 +1,6 @@
 package probe
 
 import (
-	"bytes"
 	"fmt"
 	"strings"
 	"testing"
@@ -10,7 +9,6 @@ import (
 func TestStreamBuffer_EventBoundaryPreservation(t *testing.T) {
 	// Create buffer with small size to test overflow
 	buffer := NewStreamBuffer(50)
-	buffer.SetEventDelimiter([]byte("\n"))
 
 	// Write multiple events
 	events := []string{
@@ -21,9 +19,9 @@ func TestStreamBuffer_EventBoundaryPreservation(t *testing.T) {
 	}
 
 	for _, event := range events {
-		n := buffer.Write([]byte(event))
-		if n != len(event) {
-			t.Errorf("Expected to write %d bytes, wrote %d", len(event), n)
+		n, err := buffer.Write([]byte(event))
+		if err != nil || n != len(event) {
+			t.Errorf("Expected to write %d bytes, wrote %d (%v)", len(event), n, err)
 		}
 	}
 
@@ -45,54 +43,66 @@ func TestStreamBuffer_EventBoundaryPreservation(t *testing.T) {
 	}
 }
 
-func TestStreamBuffer_NoDelimiter(t *testing.T) {
+func TestStreamBuffer_OversizedWrite(t *testing.T) {
 	buffer := NewStreamBuffer(20)
-	// No delimiter set - should use simple FIFO
 
-	// Write more than buffer size
+	// Write more than buffer size without a newline
 	data := []byte("0123456789ABCDEFGHIJKLMNOP")
-	buffer.Write(data)
+	if n, err := buffer.Write(data); err != nil || n != len(data) {
+		t.Fatalf("Write() = %d, %v", n, err)
+	}
 
 	// Should contain last 20 bytes
 	result := buffer.ReadAll()
-	expected := "GHIJKLMNOP"
-	if !bytes.Contains(result, []byte(expected)) {
-		t.Errorf("Expected buffer to contain %q, got %q", expected, result)
+	if string(result) != "6789ABCDEFGHIJKLMNOP" {
+		t.Errorf("Expected the last 20 bytes, got %q", result)
+	}
+	if stats := buffer.Stats(); stats.BytesDropped != 6 {
+		t.Errorf("Expected 6 dropped bytes, got %d", stats.BytesDropped)
 	}
 }
 
 func TestStreamBuffer_MultiByteDelimiter(t *testing.T) {
 	buffer := NewStreamBuffer(100)
-	buffer.SetEventDelimiter([]byte("\r\n"))
 
-	// Write events with Windows-style line endings
-	events := []string{
-		"Line 1\r\n",
-		"Line 2 with more content\r\n",
-		"Line 3\r\n",
+	// Windows-style line endings, one split between its \r and \n
+	for _, chunk := range []string{"Line 1\r\n", "Line 2 with more content\r", "\nLine 3\r\n"} {
+		if _, err := buffer.Write([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
 	}
 
-	for _, event := range events {
-		buffer.Write([]byte(event))
+	for _, want := range []string{"Line 1\r\n", "Line 2 with more content\r\n"} {
+		event, ok := buffer.ReadEvent()
+		if !ok || string(event.Data) != want {
+			t.Fatalf("ReadEvent() = %q, %v, want %q", event.Data, ok, want)
+		}
+	}
+	if data := string(buffer.ReadAll()); data != "Line 3\r\n" {
+		t.Errorf("ReadAll() = %q, want the last complete line", data)
 	}
+}
 
-	data := string(buffer.ReadAll())
+func TestStreamBuffer_PartialRead(t *testing.T) {
+	buffer := NewStreamBuffer(100)
+	buffer.Write([]byte("Line 1\nLine 2\nLine 3\nLine"))
 
-	// Should have all complete lines
-	if !strings.Contains(data, "Line 1") {
-		t.Error("Missing Line 1")
+	// Take one event, then everything else including the incomplete line
+	event, ok := buffer.ReadEvent()
+	if !ok || string(event.Data) != "Line 1\n" {
+		t.Fatalf("ReadEvent() = %q, %v", event.Data, ok)
 	}
-	if !strings.Contains(data, "Line 2") {
-		t.Error("Missing Line 2")
+	remaining := buffer.ReadAll()
+	if full := string(event.Data) + string(remaining); full != "Line 1\nLine 2\nLine 3\nLine" {
+		t.Errorf("Data was corrupted during partial read: %q", full)
 	}
-	if !strings.Contains(data, "Line 3") {
-		t.Error("Missing Line 3")
+	if buffer.Len() != 0 {
+		t.Errorf("Expected an empty buffer, %d bytes left", buffer.Len())
 	}
 }
 
 func TestStreamBuffer_OverflowWithEvents(t *testing.T) {
 	buffer := NewStreamBuffer(30)
-	buffer.SetEventDelimiter([]byte("\n"))
 
 	// Write events that will cause overflow
 	buffer.Write([]byte("First event\n"))      // 12 bytes
@@ -113,51 +123,55 @@ func TestStreamBuffer_OverflowWithEvents(t *testing.T) {
 	}
 }
 
-func TestStreamBuffer_PartialRead(t *testing.T) {
+func TestStreamBuffer_ReadEvents(t *testing.T) {
 	buffer := NewStreamBuffer(100)
-	buffer.SetEventDelimiter([]byte("\n"))
 
-	// Write some data
-	buffer.Write([]byte("Line 1\nLine 2\nLine 3\n"))
+	// One write holding several events and the start of another
+	buffer.Write([]byte("Line 1\nLine 2\nLine 3\nLine"))
 
-	// Read partial data
-	p := make([]byte, 10)
-	n, err := buffer.Read(p)
-	if err != nil {
-		t.Fatalf("Read error: %v", err)
+	for _, want := range []string{"Line 1\n", "Line 2\n", "Line 3\n"} {
+		event, ok := buffer.ReadEvent()
+		if !ok || string(event.Data) != want || event.Partial {
+			t.Fatalf("ReadEvent() = %q, %v, want %q", event.Data, ok, want)
+		}
 	}
-	if n != 10 {
-		t.Errorf("Expected to read 10 bytes, got %d", n)
+	if event, ok := buffer.ReadEvent(); ok {
+		t.Fatalf("Incomplete line delivered as an event: %q", event.Data)
 	}
 
-	// Read remaining
-	remaining := buffer.ReadAll()
-	fullData := string(p[:n]) + string(remaining)
-
-	if !strings.Contains(fullData, "Line 1") || !strings.Contains(fullData, "Line 3") {
-		t.Error("Data was corrupted during partial read")
+	// The line completes in the next write
+	buffer.Write([]byte(" 4\n"))
+	if event, ok := buffer.ReadEvent(); !ok || string(event.Data) != "Line 4\n" || event.Sequence != 3 {
+		t.Errorf("ReadEvent() = %+v, %v", event, ok)
 	}
 }
 
-func TestStreamBuffer_EventMarkManagement(t *testing.T) {
+func TestStreamBuffer_DropsWholeEvents(t *testing.T) {
 	buffer := NewStreamBuffer(1000)
-	buffer.SetEventDelimiter([]byte("\n"))
 
-	// Write many events to test event mark management
+	// Write many events to overflow the buffer many times over
 	for i := 0; i < 150; i++ {
-		event := fmt.Sprintf("Event %d\n", i)
-		buffer.Write([]byte(event))
+		buffer.Write([]byte(fmt.Sprintf("Event %d\n", i)))
 	}
 
-	// Event marks should be limited to maxEvents (100)
-	if len(buffer.eventMarks) > buffer.maxEvents {
-		t.Errorf("Event marks exceeded limit: %d > %d", len(buffer.eventMarks), buffer.maxEvents)
+	stats := buffer.Stats()
+	if stats.EventsDropped == 0 || stats.Used > 1000 {
+		t.Errorf("Unexpected stats %+v", stats)
 	}
 
-	// All event marks should be valid positions
-	for _, mark := range buffer.eventMarks {
-		if mark < 0 || mark >= buffer.size {
-			t.Errorf("Invalid event mark position: %d", mark)
+	// What remains are the most recent events, each complete
+	var last string
+	for {
+		event, ok := buffer.ReadEvent()
+		if !ok {
+			break
 		}
+		if event.Partial || !strings.HasPrefix(string(event.Data), "Event ") {
+			t.Errorf("Event cut by overflow: %q", event.Data)
+		}
+		last = string(event.Data)
+	}
+	if last != "Event 149\n" {
+		t.Errorf("Last event %q, want the most recent", last)
 	}
 }
@@ -2,6 +2,7 @@ package probe
 
 import (
 	"errors"
+	"runtime"
 	"sync/atomic"
 	"time"
 	"unsafe"
@@ -20,6 +21,11 @@ type LockFreeCircularBufferV3 struct {
 	writeIndex atomic.Uint64 // Current write position with version
 	readIndex  atomic.Uint64 // Current read position with version
 
+	// Writers reserve space by advancing writeIndex, copy their data, then
+	// publish it in reservation order by advancing commitIndex. Readers only
+	// look below commitIndex, so they never see a reserved but unwritten span.
+	commitIndex atomic.Uint32
+
 	// Event tracking
 	eventDelimiter []byte
 	maxEventSize   int
@@ -144,6 +150,7 @@ func (cb *LockFreeCircularBufferV3) Write(p []byte) (int, error) {
 		if cb.writeIndex.CompareAndSwap(packedWrite, newPacked) {
 			// Successfully reserved space, now copy data
 			cb.copyToBuffer(p, uint64(writeIdx))
+			cb.commit(writeIdx, newIdx)
 			cb.written.Add(uint64(n))
 			cb.updateWriteRate()
 			return int(n), nil
@@ -152,6 +159,19 @@ func (cb *LockFreeCircularBufferV3) Write(p []byte) (int, error) {
 	}
 }
 
+// commit publishes the span [from, to) once every earlier reservation has
+// been published.
+func (cb *LockFreeCircularBufferV3) commit(from, to uint32) {
+	for !cb.commitIndex.CompareAndSwap(from, to) {
+		runtime.Gosched()
+	}
+}
+
+// committed returns the end of the data readers may consume.
+func (cb *LockFreeCircularBufferV3) committed() uint32 {
+	return cb.commitIndex.Load()
+}
+
 // shouldBackpressure checks if we should apply backpressure.
 func (cb *LockFreeCircularBufferV3) shouldBackpressure() bool {
 	packedWrite := cb.writeIndex.Load()
@@ -223,6 +243,20 @@ func (cb *LockFreeCircularBufferV3) copyToBuffer(data []byte, pos uint64) {
 	}
 }
 
+// copyFromBuffer copies data out of the buffer handling wraparound.
+func (cb *LockFreeCircularBufferV3) copyFromBuffer(dst []byte, pos uint64) {
+	n := uint64(len(dst))
+	start := pos & cb.mask
+
+	if start+n <= cb.size {
+		copy(dst, cb.data[start:start+n])
+	} else {
+		firstPart := cb.size - start
+		copy(dst[:firstPart], cb.data[start:])
+		copy(dst[firstPart:], cb.data[:n-firstPart])
+	}
+}
+
 // processEvents continuously scans for complete events with adaptive timing.
 func (cb *LockFreeCircularBufferV3) processEvents() {
 	var eventStart uint64
@@ -254,8 +288,7 @@ func (cb *LockFreeCircularBufferV3) scanForEvents(scanBuf []byte, eventStart *ui
 	packedRead := cb.readIndex.Load()
 	readVer, readIdx := unpackIndex(packedRead)
 
-	packedWrite := cb.writeIndex.Load()
-	_, writeIdx := unpackIndex(packedWrite)
+	writeIdx := cb.committed()
 
 	if readIdx >= writeIdx {
 		return // No new data
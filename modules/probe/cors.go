@@ -0,0 +1,245 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// corsAttackerDomain is the attacker-controlled site used in Origin headers.
+const corsAttackerDomain = "strigoi-attacker.example"
+
+// CORSFinding is a cross-origin exposure observed on one endpoint.
+type CORSFinding struct {
+	Name        string
+	Severity    string
+	Description string
+	Origin      string
+	Request     string
+	Response    string
+	HAREntryID  string
+}
+
+// CORSChecker sends preflight and simple requests with attacker-controlled
+// Origin values and reports permissive cross-origin policies.
+type CORSChecker struct {
+	Do        func(*http.Request) (*http.Response, error)
+	HAR       *HARRecorder // optional; used to reference evidence
+	UserAgent string
+}
+
+// corsOrigin is a crafted Origin value and how it relates to the target.
+type corsOrigin struct {
+	value string
+	kind  string // arbitrary, null, prefix, suffix, subdomain, insecure
+}
+
+// corsResponse is the cross-origin policy returned for one request.
+type corsResponse struct {
+	method        string
+	status        int
+	allowOrigin   string
+	credentials   bool
+	exposeHeaders string
+	allowHeaders  string
+	harEntryID    string
+}
+
+// corsOrigins builds the Origin values tried against targetURL.
+func corsOrigins(targetURL string) []corsOrigin {
+	origins := []corsOrigin{
+		{value: "https://" + corsAttackerDomain, kind: "arbitrary"},
+		{value: "null", kind: "null"},
+	}
+
+	u, err := url.Parse(targetURL)
+	if err != nil || u.Hostname() == "" {
+		return origins
+	}
+	host := u.Hostname()
+
+	origins = append(origins,
+		// Origin validated with a prefix match
+		corsOrigin{value: "https://" + host + "." + corsAttackerDomain, kind: "prefix"},
+		// Origin validated with a suffix match
+		corsOrigin{value: "https://" + strings.ReplaceAll(corsAttackerDomain, ".", "-") + host, kind: "suffix"},
+		// Any subdomain trusted, so one XSS or takeover exposes the API
+		corsOrigin{value: "https://strigoi." + host, kind: "subdomain"},
+	)
+	if u.Scheme == "https" {
+		origins = append(origins, corsOrigin{value: "http://" + u.Host, kind: "insecure"})
+	}
+	return origins
+}
+
+// Check probes targetURL and returns its cross-origin findings. authenticated
+// marks endpoints known to require credentials.
+func (c *CORSChecker) Check(ctx context.Context, targetURL string, authenticated bool) []CORSFinding {
+	var findings []CORSFinding
+	seen := make(map[string]bool)
+	add := func(f CORSFinding) {
+		if !seen[f.Name] {
+			seen[f.Name] = true
+			findings = append(findings, f)
+		}
+	}
+
+	for _, origin := range corsOrigins(targetURL) {
+		for _, preflight := range []bool{false, true} {
+			resp, ok := c.send(ctx, targetURL, origin.value, preflight)
+			if !ok {
+				continue
+			}
+
+			// Endpoints that challenge anonymous callers carry user data
+			if resp.status == http.StatusUnauthorized || resp.status == http.StatusForbidden {
+				authenticated = true
+			}
+
+			for _, f := range evaluateCORS(origin, resp, authenticated) {
+				add(f)
+			}
+		}
+	}
+
+	return findings
+}
+
+// send issues one simple or preflight request with the given Origin.
+func (c *CORSChecker) send(ctx context.Context, targetURL, origin string, preflight bool) (*corsResponse, bool) {
+	method := "GET"
+	if preflight {
+		method = "OPTIONS"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, targetURL, nil)
+	if err != nil {
+		return nil, false
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	req.Header.Set("Origin", origin)
+	if preflight {
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		req.Header.Set("Access-Control-Request-Headers", "authorization, content-type")
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, false
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	return &corsResponse{
+		method:        method,
+		status:        resp.StatusCode,
+		allowOrigin:   resp.Header.Get("Access-Control-Allow-Origin"),
+		credentials:   strings.EqualFold(resp.Header.Get("Access-Control-Allow-Credentials"), "true"),
+		exposeHeaders: resp.Header.Get("Access-Control-Expose-Headers"),
+		allowHeaders:  resp.Header.Get("Access-Control-Allow-Headers"),
+		harEntryID:    c.HAR.EntryID(resp),
+	}, true
+}
+
+// evaluateCORS applies the exposure rules to one response.
+func evaluateCORS(origin corsOrigin, resp *corsResponse, authenticated bool) []CORSFinding {
+	var findings []CORSFinding
+
+	newFinding := func(name, severity, description string) CORSFinding {
+		response := fmt.Sprintf("Status: %d; Access-Control-Allow-Origin: %s", resp.status, resp.allowOrigin)
+		if resp.credentials {
+			response += "; Access-Control-Allow-Credentials: true"
+		}
+		if resp.exposeHeaders != "" {
+			response += "; Access-Control-Expose-Headers: " + resp.exposeHeaders
+		}
+		if resp.allowHeaders != "" {
+			response += "; Access-Control-Allow-Headers: " + resp.allowHeaders
+		}
+		return CORSFinding{
+			Name:        name,
+			Severity:    severity,
+			Description: description,
+			Origin:      origin.value,
+			Request:     fmt.Sprintf("%s with Origin: %s", resp.method, origin.value),
+			Response:    response,
+			HAREntryID:  resp.harEntryID,
+		}
+	}
+
+	reflected := resp.allowOrigin != "" && resp.allowOrigin == origin.value
+	if reflected {
+		switch {
+		case resp.credentials && (origin.kind == "arbitrary" || origin.kind == "null"):
+			findings = append(findings, newFinding(
+				"CORS Origin Reflection With Credentials", "Critical",
+				fmt.Sprintf("Any website can make credentialed requests and read responses (Origin %s was trusted)", origin.value),
+			))
+		case resp.credentials && (origin.kind == "prefix" || origin.kind == "suffix"):
+			findings = append(findings, newFinding(
+				"CORS Origin Validation Bypass", "High",
+				fmt.Sprintf("Origin matching is anchored incorrectly; the attacker domain %s was trusted with credentials", origin.value),
+			))
+		case resp.credentials && origin.kind == "insecure":
+			findings = append(findings, newFinding(
+				"CORS Trusts Insecure Origin", "Medium",
+				fmt.Sprintf("Credentialed responses are shared with the plaintext origin %s", origin.value),
+			))
+		case resp.credentials && origin.kind == "subdomain":
+			findings = append(findings, newFinding(
+				"CORS Trusts All Subdomains", "Low",
+				"Credentialed responses are shared with any subdomain, so one compromised subdomain exposes the API",
+			))
+		case !resp.credentials && (origin.kind == "arbitrary" || origin.kind == "null"):
+			severity := "Low"
+			if authenticated {
+				severity = "Medium"
+			}
+			findings = append(findings, newFinding(
+				"CORS Origin Reflection", severity,
+				fmt.Sprintf("The endpoint reflects arbitrary Origin values (%s)", origin.value),
+			))
+		}
+	}
+
+	if resp.allowOrigin == "*" && authenticated {
+		findings = append(findings, newFinding(
+			"Wildcard CORS Origin on Authenticated Endpoint", "High",
+			"An endpoint that requires credentials allows every origin, so any website can call it with a key the browser holds",
+		))
+	}
+
+	if reflected || resp.allowOrigin == "*" {
+		if exposed := sensitiveExposedHeaders(resp.exposeHeaders); len(exposed) > 0 {
+			findings = append(findings, newFinding(
+				"Sensitive Headers Exposed via CORS", "Medium",
+				fmt.Sprintf("Cross-origin callers can read sensitive response headers: %s", strings.Join(exposed, ", ")),
+			))
+		}
+	}
+
+	return findings
+}
+
+// sensitiveExposedHeaders returns the sensitive names in an
+// Access-Control-Expose-Headers value.
+func sensitiveExposedHeaders(value string) []string {
+	var exposed []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		lower := strings.ToLower(name)
+		if name == "*" || isSensitiveParam(name) || strings.Contains(lower, "cookie") ||
+			strings.Contains(lower, "session") || strings.Contains(lower, "organization") {
+			exposed = append(exposed, name)
+		}
+	}
+	return exposed
+}
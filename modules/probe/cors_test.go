@@ -0,0 +1,82 @@
+package probe
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func corsFindingNames(findings []CORSFinding) map[string]CORSFinding {
+	names := make(map[string]CORSFinding)
+	for _, f := range findings {
+		names[f.Name] = f
+	}
+	return names
+}
+
+func TestCORSCheckerReflectionWithCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+			w.Header().Set("Access-Control-Expose-Headers", "X-Request-Id, OpenAI-Organization, Set-Cookie")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	checker := &CORSChecker{Do: http.DefaultClient.Do}
+	found := corsFindingNames(checker.Check(context.Background(), server.URL+"/v1/chat/completions", false))
+
+	reflection, ok := found["CORS Origin Reflection With Credentials"]
+	if !ok || reflection.Severity != "Critical" {
+		t.Fatalf("expected critical reflection finding, got %v", found)
+	}
+	if !strings.Contains(reflection.Response, "Access-Control-Allow-Credentials: true") {
+		t.Errorf("evidence missing credentials header: %q", reflection.Response)
+	}
+	for _, expected := range []string{"CORS Origin Validation Bypass", "CORS Trusts All Subdomains", "Sensitive Headers Exposed via CORS"} {
+		if _, ok := found[expected]; !ok {
+			t.Errorf("expected finding %q, got %v", expected, found)
+		}
+	}
+	if exposed := found["Sensitive Headers Exposed via CORS"].Description; strings.Contains(exposed, "X-Request-Id") {
+		t.Errorf("X-Request-Id is not sensitive: %q", exposed)
+	}
+}
+
+func TestCORSCheckerWildcardOnAuthenticatedEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	checker := &CORSChecker{Do: http.DefaultClient.Do}
+	found := corsFindingNames(checker.Check(context.Background(), server.URL+"/v1/models", false))
+
+	if f, ok := found["Wildcard CORS Origin on Authenticated Endpoint"]; !ok || f.Severity != "High" {
+		t.Errorf("expected wildcard finding, got %v", found)
+	}
+	if _, ok := found["CORS Origin Reflection With Credentials"]; ok {
+		t.Error("wildcard must not be reported as reflection")
+	}
+}
+
+func TestCORSCheckerStrictPolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Origin") == "https://app.example.com" {
+			w.Header().Set("Access-Control-Allow-Origin", "https://app.example.com")
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	checker := &CORSChecker{Do: http.DefaultClient.Do}
+	if findings := checker.Check(context.Background(), server.URL, true); len(findings) != 0 {
+		t.Errorf("expected no findings for an exact allowlist, got %+v", findings)
+	}
+}
@@ -0,0 +1,545 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/macawi-ai/strigoi/pkg/security"
+)
+
+// Defaults of declarative dissector definitions. The confidence ties the
+// generic JSON dissector's, which the higher priority wins, and stays below
+// every match of the LLM and MCP dissectors.
+const (
+	defaultDeclarativePriority   = 45
+	defaultDeclarativeConfidence = 0.95
+)
+
+// Formats of declarative dissectors.
+const (
+	DissectorFormatJSON = "json"
+	DissectorFormatText = "text"
+)
+
+// DissectorDefinition declares a dissector for a simple text or JSON
+// protocol, loaded from a JSON or YAML file:
+//
+//	name: acme-rpc
+//	format: json
+//	identify:
+//	  patterns: ['"acme_rpc":\s*"1\.']
+//	fields:
+//	  method: $.method
+//	  session: $.meta.session_id
+//	  token: $.params.auth.token
+//	session_field: session
+//	rules:
+//	  - id: acme-live-token
+//	    field: token
+//	    pattern: '^live_'
+//	    severity: high
+//
+// Fields of JSON messages are extracted by JSONPath ($.a.b, $['a'], [n] and
+// [*]); fields of text messages by a regex whose first group is the value.
+type DissectorDefinition struct {
+	Name         string            `json:"name"`
+	Version      string            `json:"version,omitempty"`
+	Description  string            `json:"description,omitempty"`
+	Priority     int               `json:"priority,omitempty"` // 0 for the default
+	Format       string            `json:"format"`             // json or text
+	Identify     DissectorIdentify `json:"identify"`
+	Fields       map[string]string `json:"fields,omitempty"`
+	SessionField string            `json:"session_field,omitempty"`
+	Rules        []DissectorRule   `json:"rules,omitempty"`
+}
+
+// DissectorIdentify decides whether a message belongs to the protocol.
+type DissectorIdentify struct {
+	Patterns   []string `json:"patterns"`             // regexes; any match identifies the protocol
+	Confidence float64  `json:"confidence,omitempty"` // reported on a match; 0 for the default
+}
+
+// DissectorRule reports a vulnerability when a field, or the whole message
+// if no field is named, matches a pattern. Matches are masked in evidence.
+type DissectorRule struct {
+	ID          string `json:"id"`
+	Field       string `json:"field,omitempty"`
+	Pattern     string `json:"pattern"`
+	Severity    string `json:"severity"`
+	Type        string `json:"type,omitempty"`    // default "protocol_rule"
+	Subtype     string `json:"subtype,omitempty"` // default the rule ID
+	Description string `json:"description,omitempty"`
+}
+
+var (
+	dissectorNamePattern     = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+	validDissectorSeverities = map[string]bool{"critical": true, "high": true, "medium": true, "low": true, "info": true}
+)
+
+// DeclarativeDissector is a dissector compiled from a definition.
+type DeclarativeDissector struct {
+	def        DissectorDefinition
+	source     string
+	identify   []*regexp.Regexp
+	jsonFields map[string]jsonPath
+	textFields map[string]*regexp.Regexp
+	rules      []compiledDissectorRule
+}
+
+type compiledDissectorRule struct {
+	DissectorRule
+	pattern *regexp.Regexp
+}
+
+// NewDeclarativeDissector compiles a definition read from source.
+func NewDeclarativeDissector(def DissectorDefinition, source string) (*DeclarativeDissector, error) {
+	fail := func(format string, args ...interface{}) (*DeclarativeDissector, error) {
+		return nil, fmt.Errorf("dissector %q in %s: %s", def.Name, source, fmt.Sprintf(format, args...))
+	}
+
+	if !dissectorNamePattern.MatchString(def.Name) {
+		return fail("name must be lowercase letters, digits, - and _")
+	}
+	if def.Priority == 0 {
+		def.Priority = defaultDeclarativePriority
+	}
+	if def.Identify.Confidence == 0 {
+		def.Identify.Confidence = defaultDeclarativeConfidence
+	}
+	if def.Identify.Confidence < 0 || def.Identify.Confidence > 1 {
+		return fail("confidence %v is not between 0 and 1", def.Identify.Confidence)
+	}
+
+	d := &DeclarativeDissector{def: def, source: source}
+	if len(def.Identify.Patterns) == 0 {
+		return fail("identify needs at least one pattern")
+	}
+	for _, pattern := range def.Identify.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fail("identify pattern: %v", err)
+		}
+		d.identify = append(d.identify, re)
+	}
+
+	switch def.Format {
+	case DissectorFormatJSON:
+		d.jsonFields = make(map[string]jsonPath, len(def.Fields))
+		for name, expr := range def.Fields {
+			path, err := compileJSONPath(expr)
+			if err != nil {
+				return fail("field %s: %v", name, err)
+			}
+			d.jsonFields[name] = path
+		}
+	case DissectorFormatText:
+		d.textFields = make(map[string]*regexp.Regexp, len(def.Fields))
+		for name, expr := range def.Fields {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return fail("field %s: %v", name, err)
+			}
+			d.textFields[name] = re
+		}
+	default:
+		return fail("format must be %q or %q", DissectorFormatJSON, DissectorFormatText)
+	}
+
+	if _, ok := def.Fields[def.SessionField]; def.SessionField != "" && !ok {
+		return fail("session field %q is not a declared field", def.SessionField)
+	}
+
+	seen := make(map[string]bool)
+	for i, rule := range def.Rules {
+		switch {
+		case rule.ID == "":
+			return fail("rule #%d has no ID", i+1)
+		case seen[rule.ID]:
+			return fail("duplicate rule ID %q", rule.ID)
+		case !validDissectorSeverities[rule.Severity]:
+			return fail("rule %s: invalid severity %q", rule.ID, rule.Severity)
+		}
+		if _, ok := def.Fields[rule.Field]; rule.Field != "" && !ok {
+			return fail("rule %s: field %q is not declared", rule.ID, rule.Field)
+		}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return fail("rule %s: %v", rule.ID, err)
+		}
+		seen[rule.ID] = true
+		d.rules = append(d.rules, compiledDissectorRule{DissectorRule: rule, pattern: re})
+	}
+	return d, nil
+}
+
+// Name returns the name the dissector is selected by.
+func (d *DeclarativeDissector) Name() string {
+	return d.def.Name
+}
+
+// registration makes the dissector selectable alongside the registered
+// ones. Its state is read-only, so sessions share it.
+func (d *DeclarativeDissector) registration() DissectorRegistration {
+	return DissectorRegistration{
+		Name:     d.def.Name,
+		Priority: d.def.Priority,
+		New:      func(CenterConfig) Dissector { return d },
+	}
+}
+
+// Identify matches the definition's patterns, and for JSON protocols
+// requires the message to parse.
+func (d *DeclarativeDissector) Identify(data []byte) (bool, float64) {
+	matched := false
+	for _, re := range d.identify {
+		if re.Match(data) {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return false, 0
+	}
+	if d.def.Format == DissectorFormatJSON && !json.Valid(bytes.TrimSpace(data)) {
+		return false, 0
+	}
+	return true, d.def.Identify.Confidence
+}
+
+// Dissect extracts the declared fields. Fields that are absent from the
+// message are left out.
+func (d *DeclarativeDissector) Dissect(data []byte) (*Frame, error) {
+	frame := &Frame{
+		Protocol: d.def.Name,
+		Fields:   make(map[string]interface{}),
+		Raw:      data,
+	}
+
+	if d.def.Format == DissectorFormatJSON {
+		var doc interface{}
+		if err := json.Unmarshal(bytes.TrimSpace(data), &doc); err != nil {
+			return nil, fmt.Errorf("invalid %s message: %w", d.def.Name, err)
+		}
+		for name, path := range d.jsonFields {
+			switch values := path.find(doc); len(values) {
+			case 0:
+			case 1:
+				frame.Fields[name] = values[0]
+			default:
+				frame.Fields[name] = values
+			}
+		}
+		return frame, nil
+	}
+
+	for name, re := range d.textFields {
+		if match := re.FindSubmatch(data); match != nil {
+			value := match[0]
+			if len(match) > 1 {
+				value = match[1]
+			}
+			frame.Fields[name] = string(value)
+		}
+	}
+	return frame, nil
+}
+
+// FindVulnerabilities applies the definition's rules.
+func (d *DeclarativeDissector) FindVulnerabilities(frame *Frame) []StreamVulnerability {
+	vulns := []StreamVulnerability{}
+	for _, rule := range d.rules {
+		var value string
+		if rule.Field == "" {
+			value = string(frame.Raw)
+		} else if field, ok := frame.Fields[rule.Field]; ok {
+			value = fieldString(field)
+		} else {
+			continue
+		}
+
+		loc := rule.pattern.FindStringIndex(value)
+		if loc == nil {
+			continue
+		}
+		match := value[loc[0]:loc[1]]
+		vuln := StreamVulnerability{
+			ID:         fmt.Sprintf("%s-%s", strings.ToUpper(d.def.Name), rule.ID),
+			Type:       rule.Type,
+			Subtype:    rule.Subtype,
+			Severity:   rule.Severity,
+			Evidence:   maskValue(match),
+			Context:    rule.Description,
+			Confidence: d.def.Identify.Confidence,
+		}
+		if vuln.Type == "" {
+			vuln.Type = "protocol_rule"
+		}
+		if vuln.Subtype == "" {
+			vuln.Subtype = rule.ID
+		}
+		if vuln.Context == "" && rule.Field != "" {
+			vuln.Context = fmt.Sprintf("Field: %s", rule.Field)
+		}
+		vulns = append(vulns, vuln)
+	}
+	return vulns
+}
+
+// GetSessionID returns the value of the session field.
+func (d *DeclarativeDissector) GetSessionID(frame *Frame) (string, error) {
+	if d.def.SessionField == "" {
+		return "", fmt.Errorf("%s declares no session field", d.def.Name)
+	}
+	value, ok := frame.Fields[d.def.SessionField]
+	if !ok {
+		return "", fmt.Errorf("no %s in %s message", d.def.SessionField, d.def.Name)
+	}
+	return fieldString(value), nil
+}
+
+// fieldString renders an extracted field as text: strings as they are,
+// anything else as JSON.
+func fieldString(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
+
+// DefaultDissectorDir is where dissector definitions are loaded from when
+// none are configured.
+func DefaultDissectorDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".strigoi", "dissectors")
+}
+
+// LoadDissectorDefinitions reads and compiles the definitions in paths,
+// which are files or directories of .json, .yaml and .yml files. A file
+// holds one definition or a list of them.
+func LoadDissectorDefinitions(paths []string) ([]*DeclarativeDissector, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read dissector definitions: %w", err)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read dissector definitions: %w", err)
+		}
+		for _, entry := range entries {
+			switch strings.ToLower(filepath.Ext(entry.Name())) {
+			case ".json", ".yaml", ".yml":
+				if !entry.IsDir() {
+					files = append(files, filepath.Join(path, entry.Name()))
+				}
+			}
+		}
+	}
+	sort.Strings(files)
+
+	var dissectors []*DeclarativeDissector
+	names := make(map[string]string)
+	for _, file := range files {
+		defs, err := readDissectorDefinitions(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, def := range defs {
+			if other, ok := names[def.Name]; ok {
+				return nil, fmt.Errorf("dissector %q is defined in both %s and %s", def.Name, other, file)
+			}
+			names[def.Name] = file
+			dissector, err := NewDeclarativeDissector(def, file)
+			if err != nil {
+				return nil, err
+			}
+			dissectors = append(dissectors, dissector)
+		}
+	}
+	return dissectors, nil
+}
+
+// readDissectorDefinitions decodes the definitions in one file.
+func readDissectorDefinitions(file string) ([]DissectorDefinition, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dissector definitions: %w", err)
+	}
+
+	content := bytes.TrimSpace(data)
+	if ext := strings.ToLower(filepath.Ext(file)); ext == ".yaml" || ext == ".yml" {
+		parsed, err := security.ParseYAML(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		// The YAML subset only yields strings
+		if err := typeYAMLDissectors(parsed); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		if content, err = json.Marshal(parsed); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+	}
+
+	var defs []DissectorDefinition
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if bytes.HasPrefix(content, []byte("[")) {
+		err = decoder.Decode(&defs)
+	} else {
+		var def DissectorDefinition
+		err = decoder.Decode(&def)
+		defs = append(defs, def)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+	return defs, nil
+}
+
+// typeYAMLDissectors converts the numeric fields of parsed YAML
+// definitions.
+func typeYAMLDissectors(parsed interface{}) error {
+	docs, ok := parsed.([]interface{})
+	if !ok {
+		docs = []interface{}{parsed}
+	}
+	for _, doc := range docs {
+		def, ok := doc.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if value, ok := def["priority"].(string); ok {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("priority: %w", err)
+			}
+			def["priority"] = n
+		}
+		if identify, ok := def["identify"].(map[string]interface{}); ok {
+			if value, ok := identify["confidence"].(string); ok {
+				f, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					return fmt.Errorf("confidence: %w", err)
+				}
+				identify["confidence"] = f
+			}
+		}
+	}
+	return nil
+}
+
+// jsonPath is a compiled path in the JSONPath subset of definitions.
+type jsonPath []jsonPathStep
+
+type jsonPathStep struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// compileJSONPath parses paths such as $.params.arguments[0].name,
+// $['x-api-key'] and $.items[*].id.
+func compileJSONPath(expr string) (jsonPath, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(expr), "$")
+	if !ok {
+		return nil, fmt.Errorf("JSONPath %q must start with $", expr)
+	}
+
+	var path jsonPath
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			key := rest[:end]
+			if key == "" {
+				return nil, fmt.Errorf("JSONPath %q has an empty name", expr)
+			}
+			if key == "*" {
+				path = append(path, jsonPathStep{wildcard: true})
+			} else {
+				path = append(path, jsonPathStep{key: key})
+			}
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("JSONPath %q has an unclosed [", expr)
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+			switch {
+			case inner == "*":
+				path = append(path, jsonPathStep{wildcard: true})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				path = append(path, jsonPathStep{key: inner[1 : len(inner)-1]})
+			default:
+				n, err := strconv.Atoi(inner)
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("JSONPath %q has an invalid index %q", expr, inner)
+				}
+				path = append(path, jsonPathStep{index: n, isIndex: true})
+			}
+		default:
+			return nil, fmt.Errorf("JSONPath %q: unexpected %q", expr, rest[0])
+		}
+	}
+	return path, nil
+}
+
+// find returns the values the path selects in a decoded JSON document.
+func (p jsonPath) find(doc interface{}) []interface{} {
+	current := []interface{}{doc}
+	for _, step := range p {
+		var next []interface{}
+		for _, value := range current {
+			switch v := value.(type) {
+			case map[string]interface{}:
+				if step.wildcard {
+					keys := make([]string, 0, len(v))
+					for key := range v {
+						keys = append(keys, key)
+					}
+					sort.Strings(keys)
+					for _, key := range keys {
+						next = append(next, v[key])
+					}
+				} else if child, ok := v[step.key]; ok && !step.isIndex {
+					next = append(next, child)
+				}
+			case []interface{}:
+				if step.wildcard {
+					next = append(next, v...)
+				} else if step.isIndex && step.index < len(v) {
+					next = append(next, v[step.index])
+				}
+			}
+		}
+		current = next
+	}
+	return current
+}
@@ -0,0 +1,215 @@
+package probe
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadDissectorDefinitionsExample(t *testing.T) {
+	dissectors, err := LoadDissectorDefinitions([]string{filepath.Join("..", "..", "configs", "dissector_example.yaml")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dissectors) != 1 {
+		t.Fatalf("loaded %d dissectors, want 1", len(dissectors))
+	}
+	d := dissectors[0]
+	if d.Name() != "acme-rpc" || d.def.Priority != defaultDeclarativePriority || d.def.Identify.Confidence != 0.97 {
+		t.Errorf("definition = %+v", d.def)
+	}
+
+	message := []byte(`{"acme_rpc": "1.2", "method": "admin.reset", "meta": {"session_id": "s-42"},
+		"params": {"auth": {"token": "live_0123456789abcdefXYZ"},
+		"targets": [{"host": "db1.corp.acme.internal"}, {"host": "cache"}]}}`)
+	if ok, confidence := d.Identify(message); !ok || confidence != 0.97 {
+		t.Errorf("Identify() = %v, %v", ok, confidence)
+	}
+	if ok, _ := d.Identify([]byte(`{"acme_rpc": "1.2"`)); ok {
+		t.Error("identified a message that does not parse")
+	}
+	if ok, _ := d.Identify([]byte(`{"jsonrpc": "2.0"}`)); ok {
+		t.Error("identified another protocol")
+	}
+
+	frame, err := d.Dissect(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if frame.Protocol != "acme-rpc" || frame.Fields["method"] != "admin.reset" {
+		t.Errorf("fields = %v", frame.Fields)
+	}
+	if targets := frame.Fields["targets"]; !reflect.DeepEqual(targets, []interface{}{"db1.corp.acme.internal", "cache"}) {
+		t.Errorf("targets = %v", targets)
+	}
+	if session, err := d.GetSessionID(frame); err != nil || session != "s-42" {
+		t.Errorf("GetSessionID() = %q, %v", session, err)
+	}
+
+	vulns := d.FindVulnerabilities(frame)
+	var subtypes []string
+	for _, vuln := range vulns {
+		subtypes = append(subtypes, vuln.Subtype)
+		if strings.Contains(vuln.Evidence, "0123456789abcdef") {
+			t.Errorf("unmasked evidence %q", vuln.Evidence)
+		}
+	}
+	if !reflect.DeepEqual(subtypes, []string{"acme_token", "admin-method", "internal-host"}) {
+		t.Errorf("vulnerabilities = %+v", vulns)
+	}
+	if vulns[0].Type != "credential" || vulns[0].Severity != "critical" || vulns[1].Type != "protocol_rule" {
+		t.Errorf("vulnerabilities = %+v", vulns)
+	}
+}
+
+func TestDeclarativeDissectorText(t *testing.T) {
+	d, err := NewDeclarativeDissector(DissectorDefinition{
+		Name:     "kvline",
+		Format:   DissectorFormatText,
+		Identify: DissectorIdentify{Patterns: []string{`^KV/1 `}},
+		Fields: map[string]string{
+			"user": `user=(\S+)`,
+			"sid":  `sid=(\S+)`,
+		},
+		SessionField: "sid",
+		Rules: []DissectorRule{
+			{ID: "root-user", Field: "user", Pattern: `^root$`, Severity: "high"},
+			{ID: "debug-flag", Pattern: `debug=1`, Severity: "low"},
+		},
+	}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frame, err := d.Dissect([]byte("KV/1 user=root sid=abc debug=0\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if frame.Fields["user"] != "root" || frame.Fields["sid"] != "abc" {
+		t.Errorf("fields = %v", frame.Fields)
+	}
+	if vulns := d.FindVulnerabilities(frame); len(vulns) != 1 || vulns[0].ID != "KVLINE-root-user" {
+		t.Errorf("vulnerabilities = %+v", vulns)
+	}
+
+	frame, _ = d.Dissect([]byte("KV/1 debug=1\n"))
+	if vulns := d.FindVulnerabilities(frame); len(vulns) != 1 || vulns[0].Subtype != "debug-flag" {
+		t.Errorf("vulnerabilities = %+v", vulns)
+	}
+	if _, err := d.GetSessionID(frame); err == nil {
+		t.Error("expected an error for a message without a session")
+	}
+}
+
+func TestDeclarativeDissectorValidation(t *testing.T) {
+	valid := func() DissectorDefinition {
+		return DissectorDefinition{
+			Name:     "proto",
+			Format:   DissectorFormatJSON,
+			Identify: DissectorIdentify{Patterns: []string{`"proto"`}},
+			Fields:   map[string]string{"id": "$.id"},
+		}
+	}
+	if _, err := NewDeclarativeDissector(valid(), "test"); err != nil {
+		t.Fatalf("valid definition rejected: %v", err)
+	}
+
+	for name, mutate := range map[string]func(*DissectorDefinition){
+		"name":          func(d *DissectorDefinition) { d.Name = "My Proto" },
+		"format":        func(d *DissectorDefinition) { d.Format = "xml" },
+		"no patterns":   func(d *DissectorDefinition) { d.Identify.Patterns = nil },
+		"bad pattern":   func(d *DissectorDefinition) { d.Identify.Patterns = []string{"("} },
+		"confidence":    func(d *DissectorDefinition) { d.Identify.Confidence = 1.5 },
+		"jsonpath":      func(d *DissectorDefinition) { d.Fields["id"] = "id" },
+		"session field": func(d *DissectorDefinition) { d.SessionField = "session" },
+		"rule field": func(d *DissectorDefinition) {
+			d.Rules = []DissectorRule{{ID: "r", Field: "missing", Pattern: "x", Severity: "low"}}
+		},
+		"severity": func(d *DissectorDefinition) {
+			d.Rules = []DissectorRule{{ID: "r", Pattern: "x", Severity: "severe"}}
+		},
+		"duplicate rule": func(d *DissectorDefinition) {
+			d.Rules = []DissectorRule{{ID: "r", Pattern: "x", Severity: "low"}, {ID: "r", Pattern: "y", Severity: "low"}}
+		},
+	} {
+		def := valid()
+		mutate(&def)
+		if _, err := NewDeclarativeDissector(def, "test"); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestLoadDissectorDefinitionsDirectory(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.json":    `[{"name": "one", "format": "text", "identify": {"patterns": ["^ONE"]}}, {"name": "two", "format": "text", "identify": {"patterns": ["^TWO"]}, "priority": 5}]`,
+		"b.yml":     "name: three\nformat: text\npriority: 90\nidentify:\n  patterns: ['^THREE']\n",
+		"notes.txt": "ignored",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dissectors, err := LoadDissectorDefinitions([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, d := range dissectors {
+		names = append(names, d.Name())
+	}
+	if !reflect.DeepEqual(names, []string{"one", "two", "three"}) || dissectors[1].def.Priority != 5 || dissectors[2].def.Priority != 90 {
+		t.Errorf("loaded %v", names)
+	}
+
+	// Unknown keys are mistakes, not extensions
+	if err := os.WriteFile(filepath.Join(dir, "c.yaml"), []byte("name: four\nformat: text\nidentfy:\n  patterns: ['x']\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadDissectorDefinitions([]string{dir}); err == nil {
+		t.Error("expected an error for an unknown key")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "c.yaml"), []byte("name: one\nformat: text\nidentify:\n  patterns: ['x']\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadDissectorDefinitions([]string{dir}); err == nil || !strings.Contains(err.Error(), "defined in both") {
+		t.Errorf("expected a duplicate definition error, got %v", err)
+	}
+}
+
+func TestJSONPath(t *testing.T) {
+	doc := map[string]interface{}{
+		"params": map[string]interface{}{
+			"x-api-key": "k",
+			"items":     []interface{}{map[string]interface{}{"id": "a"}, map[string]interface{}{"id": "b"}},
+		},
+	}
+	for expr, want := range map[string][]interface{}{
+		"$.params['x-api-key']": {"k"},
+		"$.params.items[1].id":  {"b"},
+		"$.params.items[*].id":  {"a", "b"},
+		"$.params.items[5].id":  nil,
+		"$.missing.id":          nil,
+	} {
+		path, err := compileJSONPath(expr)
+		if err != nil {
+			t.Errorf("compileJSONPath(%q): %v", expr, err)
+			continue
+		}
+		if got := path.find(doc); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s = %v, want %v", expr, got, want)
+		}
+	}
+
+	for _, expr := range []string{"params.id", "$.params[", "$..id", "$.items[-1]"} {
+		if _, err := compileJSONPath(expr); err == nil {
+			t.Errorf("compileJSONPath(%q): expected an error", expr)
+		}
+	}
+}
@@ -0,0 +1,1000 @@
+package probe
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// llmMaxSystemPrompts bounds the system prompts remembered for leak detection.
+const llmMaxSystemPrompts = 64
+
+// llmLeakFragment is the shortest system prompt line or sentence whose
+// appearance in a completion is reported as a leak.
+const llmLeakFragment = 24
+
+// LLM API payloads recognized by LLMDissector.
+const (
+	llmOpenAIChat        = "openai_chat"
+	llmOpenAICompletions = "openai_completions"
+	llmOpenAIEmbeddings  = "openai_embeddings"
+	llmAnthropicMessages = "anthropic_messages"
+	llmOllamaGenerate    = "ollama_generate"
+	llmOllamaChat        = "ollama_chat"
+	llmOllamaEmbeddings  = "ollama_embeddings"
+)
+
+// Headers that carry LLM API keys, in the order they are checked.
+var llmKeyHeaders = []string{"Authorization", "X-Api-Key", "Api-Key", "X-Goog-Api-Key"}
+
+var (
+	// Tool names that suggest the tool runs commands
+	llmShellTool = regexp.MustCompile(`(?i)(shell|bash|exec|command|terminal|powershell|run_code|run_script|subprocess)`)
+	// Commands that fetch-and-run, delete broadly, open reverse shells or escalate
+	llmDangerousCommand = regexp.MustCompile(`(?i)((curl|wget)[^|;]*\|\s*(ba|z)?sh|rm\s+-rf\s+[/~]|base64\s+-d[^|]*\|\s*(ba)?sh|\bnc\b.*\s-e\s|/dev/tcp/|chmod\s+[+u]s|>\s*/etc/|\bsudo\b)`)
+)
+
+// LLMDissector parses LLM API traffic: OpenAI-compatible chat completions,
+// completions and embeddings, Anthropic messages and Ollama generate, chat
+// and embeddings. Streamed responses (SSE, or Ollama's newline-delimited
+// JSON) are reassembled into the complete response before fields are
+// extracted.
+type LLMDissector struct {
+	// Recent system prompts, to spot them echoed back in completions
+	mu            sync.Mutex
+	systemPrompts []string
+}
+
+// NewLLMDissector creates a new LLM API dissector.
+func NewLLMDissector() *LLMDissector {
+	return &LLMDissector{}
+}
+
+// llmMessage is an LLM API body with its HTTP envelope, if any.
+type llmMessage struct {
+	kind    string // request or response; empty for a bare body
+	method  string
+	url     string
+	host    string
+	path    string
+	status  int
+	headers http.Header
+	body    []byte
+}
+
+// llmBody is a decoded body. Streamed bodies are reassembled into obj.
+type llmBody struct {
+	obj    map[string]interface{}
+	stream string // "sse" or "ndjson" when reassembled
+	events int
+}
+
+// Identify checks if the data is an LLM API request or response.
+func (d *LLMDissector) Identify(data []byte) (bool, float64) {
+	msg, ok := splitLLMMessage(data)
+	if !ok {
+		return false, 0
+	}
+	if msg.kind == "request" && msg.method == http.MethodPost && llmPathAPI(msg.path) != "" {
+		return true, 0.97
+	}
+	if body := decodeLLMBody(msg.body); body.obj != nil {
+		if api, _ := llmBodyAPI(body.obj); api != "" {
+			return true, 0.97
+		}
+	}
+	return false, 0
+}
+
+// Dissect parses an LLM API message into a frame with the model, system
+// prompt, messages, tool calls, token usage and API key header.
+func (d *LLMDissector) Dissect(data []byte) (*Frame, error) {
+	msg, ok := splitLLMMessage(data)
+	if !ok {
+		return nil, fmt.Errorf("not an HTTP message or JSON body")
+	}
+	body := decodeLLMBody(msg.body)
+
+	api, kind := llmPathAPI(msg.path), msg.kind
+	if body.obj != nil {
+		bodyAPI, bodyKind := llmBodyAPI(body.obj)
+		if api == "" {
+			api = bodyAPI
+		}
+		if kind == "" {
+			kind = bodyKind
+		}
+	}
+	if api == "" || kind == "" {
+		return nil, fmt.Errorf("no LLM API payload found")
+	}
+
+	frame := &Frame{
+		Protocol: "LLM",
+		Fields:   make(map[string]interface{}),
+		Raw:      data,
+	}
+	fields := frame.Fields
+	fields["type"] = kind
+	fields["api"] = api
+	fields["body"] = string(msg.body)
+	fields["body_size"] = len(msg.body)
+
+	provider := ""
+	switch kind {
+	case "request":
+		if msg.method != "" {
+			fields["method"] = msg.method
+			fields["url"] = msg.url
+			fields["host"] = msg.host
+			fields["path"] = msg.path
+			provider = llmProviderForRequest(msg.host, msg.path)
+		}
+		if header, key := llmAPIKey(msg.headers); key != "" {
+			fields["api_key_header"] = header
+			fields["api_key"] = redactLLMKey(key)
+		}
+	case "response":
+		if msg.status != 0 {
+			fields["status_code"] = msg.status
+		}
+	}
+	if provider == "" {
+		provider, _, _ = strings.Cut(api, "_")
+	}
+	fields["provider"] = provider
+
+	if body.obj == nil {
+		return frame, nil
+	}
+	if body.stream != "" {
+		fields["stream"] = true
+		fields["stream_format"] = body.stream
+		fields["stream_events"] = body.events
+	}
+	if kind == "request" {
+		d.describeRequest(api, body.obj, fields)
+	} else {
+		describeLLMResponse(api, body.obj, fields)
+	}
+
+	return frame, nil
+}
+
+// describeRequest extracts the prompt side of a request and remembers its
+// system prompt.
+func (d *LLMDissector) describeRequest(api string, obj map[string]interface{}, fields map[string]interface{}) {
+	fields["model"] = llmString(obj["model"])
+	if stream, ok := obj["stream"].(bool); ok {
+		fields["stream"] = stream
+	}
+
+	var system []string
+	switch api {
+	case llmAnthropicMessages:
+		if text := llmText(obj["system"]); text != "" {
+			system = append(system, text)
+		}
+	case llmOllamaGenerate:
+		if text := llmString(obj["system"]); text != "" {
+			system = append(system, text)
+		}
+	}
+
+	if raw := llmArray(obj["messages"]); raw != nil {
+		messages := make([]map[string]interface{}, 0, len(raw))
+		for _, m := range raw {
+			message := llmObject(m)
+			role := llmString(message["role"])
+			content := llmText(message["content"])
+			if role == "system" || role == "developer" {
+				system = append(system, content)
+			}
+			messages = append(messages, map[string]interface{}{"role": role, "content": content})
+		}
+		fields["messages"] = messages
+		fields["message_count"] = len(messages)
+	}
+
+	switch prompt := obj["prompt"].(type) {
+	case string:
+		fields["prompt"] = prompt
+	case []interface{}:
+		fields["prompt"] = llmText(prompt)
+	}
+	switch input := obj["input"].(type) {
+	case string:
+		fields["input_count"] = 1
+	case []interface{}:
+		fields["input_count"] = len(input)
+	}
+
+	if raw := llmArray(obj["tools"]); raw != nil {
+		tools := make([]string, 0, len(raw))
+		for _, t := range raw {
+			tool := llmObject(t)
+			name := llmString(tool["name"])
+			if fn := llmObject(tool["function"]); fn != nil {
+				name = llmString(fn["name"])
+			}
+			if name != "" {
+				tools = append(tools, name)
+			}
+		}
+		fields["tools"] = tools
+	}
+
+	if len(system) > 0 {
+		prompt := strings.Join(system, "\n")
+		fields["system_prompt"] = prompt
+		d.remember(prompt)
+	}
+}
+
+// describeLLMResponse extracts the completion, tool calls and token usage.
+func describeLLMResponse(api string, obj map[string]interface{}, fields map[string]interface{}) {
+	fields["model"] = llmString(obj["model"])
+	if id := llmString(obj["id"]); id != "" {
+		fields["id"] = id
+	}
+
+	var completion string
+	var calls []map[string]interface{}
+	stopReason := ""
+
+	switch api {
+	case llmOpenAIChat, llmOpenAICompletions:
+		for _, c := range llmArray(obj["choices"]) {
+			choice := llmObject(c)
+			message := llmObject(choice["message"])
+			completion += llmText(message["content"]) + llmString(choice["text"])
+			for _, tc := range llmArray(message["tool_calls"]) {
+				call := llmObject(tc)
+				fn := llmObject(call["function"])
+				calls = append(calls, llmToolCall(llmString(call["id"]), llmString(fn["name"]), fn["arguments"]))
+			}
+			if fn := llmObject(message["function_call"]); fn != nil {
+				calls = append(calls, llmToolCall("", llmString(fn["name"]), fn["arguments"]))
+			}
+			if reason := llmString(choice["finish_reason"]); reason != "" {
+				stopReason = reason
+			}
+		}
+	case llmAnthropicMessages:
+		for _, b := range llmArray(obj["content"]) {
+			block := llmObject(b)
+			switch llmString(block["type"]) {
+			case "text":
+				completion += llmString(block["text"])
+			case "tool_use":
+				calls = append(calls, llmToolCall(llmString(block["id"]), llmString(block["name"]), block["input"]))
+			}
+		}
+		stopReason = llmString(obj["stop_reason"])
+	case llmOllamaChat, llmOllamaGenerate:
+		message := llmObject(obj["message"])
+		completion = llmString(message["content"]) + llmString(obj["response"])
+		for _, tc := range llmArray(message["tool_calls"]) {
+			fn := llmObject(llmObject(tc)["function"])
+			calls = append(calls, llmToolCall("", llmString(fn["name"]), fn["arguments"]))
+		}
+		stopReason = llmString(obj["done_reason"])
+	case llmOpenAIEmbeddings:
+		fields["embedding_count"] = len(llmArray(obj["data"]))
+	case llmOllamaEmbeddings:
+		fields["embedding_count"] = len(llmArray(obj["embeddings"]))
+		if _, ok := obj["embedding"]; ok {
+			fields["embedding_count"] = 1
+		}
+	}
+
+	if completion != "" {
+		fields["completion"] = completion
+	}
+	if len(calls) > 0 {
+		fields["tool_calls"] = calls
+	}
+	if stopReason != "" {
+		fields["stop_reason"] = stopReason
+	}
+	if usage := llmUsage(obj); usage != nil {
+		fields["usage"] = usage
+	}
+}
+
+// llmToolCall normalizes a tool call; arguments are kept as JSON text.
+func llmToolCall(id, name string, arguments interface{}) map[string]interface{} {
+	args, ok := arguments.(string)
+	if !ok && arguments != nil {
+		encoded, _ := json.Marshal(arguments)
+		args = string(encoded)
+	}
+	call := map[string]interface{}{"name": name, "arguments": args}
+	if id != "" {
+		call["id"] = id
+	}
+	return call
+}
+
+// llmUsage normalizes OpenAI, Anthropic and Ollama token counts.
+func llmUsage(obj map[string]interface{}) map[string]int {
+	input, output, total := -1, -1, -1
+	if usage := llmObject(obj["usage"]); usage != nil {
+		for key, target := range map[string]*int{
+			"prompt_tokens":     &input,
+			"input_tokens":      &input,
+			"completion_tokens": &output,
+			"output_tokens":     &output,
+			"total_tokens":      &total,
+		} {
+			if v, ok := usage[key].(float64); ok {
+				*target = int(v)
+			}
+		}
+	}
+	if v, ok := obj["prompt_eval_count"].(float64); ok {
+		input = int(v)
+	}
+	if v, ok := obj["eval_count"].(float64); ok {
+		output = int(v)
+	}
+	if input < 0 && output < 0 && total < 0 {
+		return nil
+	}
+
+	usage := make(map[string]int)
+	if input >= 0 {
+		usage["input_tokens"] = input
+	}
+	if output >= 0 {
+		usage["output_tokens"] = output
+	}
+	if total < 0 {
+		total = max(input, 0) + max(output, 0)
+	}
+	usage["total_tokens"] = total
+	return usage
+}
+
+// remember records a system prompt for leak detection.
+func (d *LLMDissector) remember(prompt string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, p := range d.systemPrompts {
+		if p == prompt {
+			return
+		}
+	}
+	d.systemPrompts = append(d.systemPrompts, prompt)
+	if len(d.systemPrompts) > llmMaxSystemPrompts {
+		d.systemPrompts = d.systemPrompts[len(d.systemPrompts)-llmMaxSystemPrompts:]
+	}
+}
+
+// leakedSystemPrompt returns a line or sentence of a recently seen system
+// prompt that appears in completion.
+func (d *LLMDissector) leakedSystemPrompt(completion string) string {
+	d.mu.Lock()
+	prompts := append([]string(nil), d.systemPrompts...)
+	d.mu.Unlock()
+
+	normalized := normalizeLLMText(completion)
+	for _, prompt := range prompts {
+		for _, fragment := range llmFragments.Split(prompt, -1) {
+			fragment = normalizeLLMText(fragment)
+			if len(fragment) >= llmLeakFragment && strings.Contains(normalized, fragment) {
+				return fragment
+			}
+		}
+	}
+	return ""
+}
+
+// llmFragments splits a system prompt into lines and sentences.
+var llmFragments = regexp.MustCompile(`\n+|[.!?]\s+`)
+
+// normalizeLLMText lowercases text and collapses whitespace.
+func normalizeLLMText(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
+
+// FindVulnerabilities reports API keys sent over plaintext HTTP, system
+// prompts echoed back in completions and tool calls that run shell commands.
+func (d *LLMDissector) FindVulnerabilities(frame *Frame) []StreamVulnerability {
+	var vulns []StreamVulnerability
+	api, _ := frame.Fields["api"].(string)
+	kind, _ := frame.Fields["type"].(string)
+	location := fmt.Sprintf("LLM %s %s", api, kind)
+
+	// Center only sees TLS traffic as ciphertext, so a key readable here
+	// crossed the connection in the clear unless the server is local.
+	if header, ok := frame.Fields["api_key_header"].(string); ok {
+		host, _ := frame.Fields["host"].(string)
+		if !isLoopbackHost(host) {
+			key, _ := frame.Fields["api_key"].(string)
+			vulns = append(vulns, StreamVulnerability{
+				ID:         fmt.Sprintf("LLM-%d", len(vulns)),
+				Timestamp:  time.Now(),
+				Severity:   "critical",
+				Type:       "api_key_exposure",
+				Subtype:    "plaintext_http",
+				Evidence:   fmt.Sprintf("%s: %s", header, key),
+				Location:   location,
+				Context:    fmt.Sprintf("LLM API key sent over plaintext HTTP to %s", host),
+				Confidence: 0.9,
+			})
+		}
+	}
+
+	if completion, ok := frame.Fields["completion"].(string); ok {
+		if fragment := d.leakedSystemPrompt(completion); fragment != "" {
+			vulns = append(vulns, StreamVulnerability{
+				ID:         fmt.Sprintf("LLM-%d", len(vulns)),
+				Timestamp:  time.Now(),
+				Severity:   "high",
+				Type:       "system_prompt_leak",
+				Subtype:    api,
+				Evidence:   truncateLLMText(fragment, 120),
+				Location:   location,
+				Context:    "Model response repeats the system prompt of an earlier request",
+				Confidence: 0.85,
+			})
+		}
+	}
+
+	calls, _ := frame.Fields["tool_calls"].([]map[string]interface{})
+	for _, call := range calls {
+		name, _ := call["name"].(string)
+		arguments, _ := call["arguments"].(string)
+		command, ok := llmShellCommand(name, arguments)
+		if !ok {
+			continue
+		}
+		severity := "high"
+		if llmDangerousCommand.MatchString(command) {
+			severity = "critical"
+		}
+		vulns = append(vulns, StreamVulnerability{
+			ID:         fmt.Sprintf("LLM-%d", len(vulns)),
+			Timestamp:  time.Now(),
+			Severity:   severity,
+			Type:       "llm_tool_call",
+			Subtype:    "shell_execution",
+			Evidence:   truncateLLMText(name+": "+command, 200),
+			Location:   location,
+			Context:    "Model requested a tool call that executes a shell command",
+			Confidence: 0.8,
+		})
+	}
+
+	return vulns
+}
+
+// llmShellCommand returns the command a tool call runs, if it runs one: the
+// tool is named like a shell, or its arguments carry a command.
+func llmShellCommand(name, arguments string) (string, bool) {
+	var args map[string]interface{}
+	_ = json.Unmarshal([]byte(arguments), &args)
+
+	shellTool := llmShellTool.MatchString(name)
+	for _, key := range []string{"command", "cmd", "script", "code"} {
+		command := llmText(args[key])
+		if command == "" {
+			continue
+		}
+		if shellTool || key == "command" || key == "cmd" {
+			return command, true
+		}
+	}
+	if shellTool {
+		return arguments, true
+	}
+	return "", false
+}
+
+// GetSessionID returns the completion or message ID of a response.
+func (d *LLMDissector) GetSessionID(frame *Frame) (string, error) {
+	if id, ok := frame.Fields["id"].(string); ok && id != "" {
+		return fmt.Sprintf("llm_%s", id), nil
+	}
+	return "", fmt.Errorf("no LLM response identifier in frame")
+}
+
+// splitLLMMessage separates an HTTP request or response into its envelope
+// and body. Data that is not HTTP is treated as a bare body.
+func splitLLMMessage(data []byte) (*llmMessage, bool) {
+	data = bytes.TrimLeft(data, " \t\r\n")
+	if len(data) == 0 {
+		return nil, false
+	}
+	if !isHTTPStart(data) {
+		if data[0] != '{' && !bytes.HasPrefix(data, []byte("data:")) && !bytes.HasPrefix(data, []byte("event:")) {
+			return nil, false
+		}
+		return &llmMessage{body: bytes.TrimSpace(data)}, true
+	}
+
+	// Bodies may be cut short by the capture; keep whatever arrived
+	reader := bufio.NewReader(bytes.NewReader(data))
+	if bytes.HasPrefix(data, []byte("HTTP/")) {
+		resp, err := http.ReadResponse(reader, nil)
+		if err != nil {
+			return nil, false
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return &llmMessage{kind: "response", status: resp.StatusCode, headers: resp.Header, body: body}, true
+	}
+
+	req, err := http.ReadRequest(reader)
+	if err != nil {
+		return nil, false
+	}
+	defer req.Body.Close()
+	body, _ := io.ReadAll(req.Body)
+	return &llmMessage{
+		kind:    "request",
+		method:  req.Method,
+		url:     req.URL.String(),
+		host:    req.Host,
+		path:    req.URL.Path,
+		headers: req.Header,
+		body:    body,
+	}, true
+}
+
+// llmPathAPI names the API an endpoint path serves.
+func llmPathAPI(path string) string {
+	switch {
+	case path == "":
+		return ""
+	case strings.HasSuffix(path, "/chat/completions"):
+		return llmOpenAIChat
+	case strings.HasSuffix(path, "/v1/messages"):
+		return llmAnthropicMessages
+	case path == "/api/generate":
+		return llmOllamaGenerate
+	case path == "/api/chat":
+		return llmOllamaChat
+	case path == "/api/embed", path == "/api/embeddings":
+		return llmOllamaEmbeddings
+	case strings.HasSuffix(path, "/completions"):
+		return llmOpenAICompletions
+	case strings.HasSuffix(path, "/embeddings"):
+		return llmOpenAIEmbeddings
+	}
+	return ""
+}
+
+// llmBodyAPI recognizes an LLM API payload by its shape and says whether it
+// is a request or a response.
+func llmBodyAPI(obj map[string]interface{}) (api, kind string) {
+	// Responses
+	switch llmString(obj["object"]) {
+	case "chat.completion":
+		return llmOpenAIChat, "response"
+	case "text_completion":
+		return llmOpenAICompletions, "response"
+	case "list":
+		if data := llmArray(obj["data"]); len(data) > 0 {
+			if _, ok := llmObject(data[0])["embedding"]; ok {
+				return llmOpenAIEmbeddings, "response"
+			}
+		}
+	}
+	if llmString(obj["type"]) == "message" && llmString(obj["role"]) == "assistant" {
+		return llmAnthropicMessages, "response"
+	}
+	if _, ok := obj["done"].(bool); ok {
+		if _, ok := obj["message"]; ok {
+			return llmOllamaChat, "response"
+		}
+		if _, ok := obj["response"]; ok {
+			return llmOllamaGenerate, "response"
+		}
+	}
+
+	// Requests name a model
+	if llmString(obj["model"]) == "" {
+		return "", ""
+	}
+	if _, ok := obj["embeddings"]; ok {
+		return llmOllamaEmbeddings, "response"
+	}
+	_, ollama := obj["options"]
+	if _, ok := obj["keep_alive"]; ok {
+		ollama = true
+	}
+	switch {
+	case llmArray(obj["messages"]) != nil:
+		if _, ok := obj["system"]; ok {
+			return llmAnthropicMessages, "request"
+		}
+		if ollama {
+			return llmOllamaChat, "request"
+		}
+		return llmOpenAIChat, "request"
+	case obj["prompt"] != nil:
+		for _, key := range []string{"system", "raw", "context"} {
+			if _, ok := obj[key]; ok {
+				ollama = true
+			}
+		}
+		if ollama {
+			return llmOllamaGenerate, "request"
+		}
+		return llmOpenAICompletions, "request"
+	case obj["input"] != nil:
+		if ollama {
+			return llmOllamaEmbeddings, "request"
+		}
+		return llmOpenAIEmbeddings, "request"
+	}
+	return "", ""
+}
+
+// decodeLLMBody decodes a JSON body, reassembling SSE and newline-delimited
+// JSON streams into a single response object.
+func decodeLLMBody(body []byte) llmBody {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return llmBody{}
+	}
+
+	if body[0] == '{' {
+		var obj map[string]interface{}
+		if err := json.Unmarshal(body, &obj); err == nil {
+			return llmBody{obj: obj}
+		}
+		// Ollama streams one JSON object per line
+		var lines []map[string]interface{}
+		for _, line := range bytes.Split(body, []byte("\n")) {
+			var part map[string]interface{}
+			if err := json.Unmarshal(bytes.TrimSpace(line), &part); err == nil {
+				lines = append(lines, part)
+			}
+		}
+		if len(lines) == 0 {
+			return llmBody{}
+		}
+		return llmBody{obj: reassembleNDJSON(lines), stream: "ndjson", events: len(lines)}
+	}
+
+	events := parseLLMEvents(body)
+	if len(events) == 0 {
+		return llmBody{}
+	}
+	for _, event := range events {
+		switch llmString(event["type"]) {
+		case "message_start", "content_block_start", "content_block_delta":
+			return llmBody{obj: reassembleAnthropic(events), stream: "sse", events: len(events)}
+		}
+	}
+	return llmBody{obj: reassembleOpenAI(events), stream: "sse", events: len(events)}
+}
+
+// parseLLMEvents decodes the JSON data of each server-sent event.
+func parseLLMEvents(body []byte) []map[string]interface{} {
+	var events []map[string]interface{}
+	var data bytes.Buffer
+	flush := func() {
+		if data.Len() > 0 {
+			var obj map[string]interface{}
+			if err := json.Unmarshal(data.Bytes(), &obj); err == nil {
+				events = append(events, obj)
+			}
+			data.Reset()
+		}
+	}
+
+	for _, line := range bytes.Split(body, []byte("\n")) {
+		line = bytes.TrimRight(line, "\r")
+		switch {
+		case len(line) == 0:
+			flush()
+		case bytes.HasPrefix(line, []byte("data:")):
+			payload := bytes.TrimSpace(line[len("data:"):])
+			if string(payload) == "[DONE]" {
+				continue
+			}
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.Write(payload)
+		}
+	}
+	flush()
+	return events
+}
+
+// reassembleOpenAI joins chat.completion.chunk (or streamed text_completion)
+// events into a complete response.
+func reassembleOpenAI(events []map[string]interface{}) map[string]interface{} {
+	type partialCall struct {
+		id, name string
+		args     strings.Builder
+	}
+	resp := map[string]interface{}{"object": "chat.completion"}
+	var text strings.Builder
+	calls := make(map[int]*partialCall)
+	finish := ""
+
+	for _, event := range events {
+		for _, key := range []string{"id", "model", "usage"} {
+			if v, ok := event[key]; ok && v != nil {
+				resp[key] = v
+			}
+		}
+		if llmString(event["object"]) == "text_completion" {
+			resp["object"] = "text_completion"
+		}
+		for _, c := range llmArray(event["choices"]) {
+			choice := llmObject(c)
+			delta := llmObject(choice["delta"])
+			text.WriteString(llmString(delta["content"]))
+			text.WriteString(llmString(choice["text"]))
+			for _, tc := range llmArray(delta["tool_calls"]) {
+				chunk := llmObject(tc)
+				index := int(llmNumber(chunk["index"]))
+				call, ok := calls[index]
+				if !ok {
+					call = &partialCall{}
+					calls[index] = call
+				}
+				if id := llmString(chunk["id"]); id != "" {
+					call.id = id
+				}
+				fn := llmObject(chunk["function"])
+				if name := llmString(fn["name"]); name != "" {
+					call.name = name
+				}
+				call.args.WriteString(llmString(fn["arguments"]))
+			}
+			if reason := llmString(choice["finish_reason"]); reason != "" {
+				finish = reason
+			}
+		}
+	}
+
+	if resp["object"] == "text_completion" {
+		resp["choices"] = []interface{}{map[string]interface{}{"text": text.String(), "finish_reason": finish}}
+		return resp
+	}
+
+	indexes := make([]int, 0, len(calls))
+	for index := range calls {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	toolCalls := make([]interface{}, 0, len(indexes))
+	for _, index := range indexes {
+		call := calls[index]
+		toolCalls = append(toolCalls, map[string]interface{}{
+			"id":       call.id,
+			"type":     "function",
+			"function": map[string]interface{}{"name": call.name, "arguments": call.args.String()},
+		})
+	}
+	message := map[string]interface{}{"role": "assistant", "content": text.String()}
+	if len(toolCalls) > 0 {
+		message["tool_calls"] = toolCalls
+	}
+	resp["choices"] = []interface{}{map[string]interface{}{"message": message, "finish_reason": finish}}
+	return resp
+}
+
+// reassembleAnthropic joins message_start, content_block_* and
+// message_delta events into a complete message.
+func reassembleAnthropic(events []map[string]interface{}) map[string]interface{} {
+	resp := map[string]interface{}{"type": "message", "role": "assistant"}
+	usage := make(map[string]interface{})
+	blocks := make(map[int]map[string]interface{})
+	inputs := make(map[int]*strings.Builder)
+	var order []int
+
+	block := func(index int) map[string]interface{} {
+		b, ok := blocks[index]
+		if !ok {
+			b = map[string]interface{}{"type": "text"}
+			blocks[index] = b
+			order = append(order, index)
+		}
+		return b
+	}
+
+	for _, event := range events {
+		switch llmString(event["type"]) {
+		case "message_start":
+			message := llmObject(event["message"])
+			for _, key := range []string{"id", "model"} {
+				if v, ok := message[key]; ok {
+					resp[key] = v
+				}
+			}
+			for k, v := range llmObject(message["usage"]) {
+				usage[k] = v
+			}
+		case "content_block_start":
+			b := block(int(llmNumber(event["index"])))
+			for k, v := range llmObject(event["content_block"]) {
+				b[k] = v
+			}
+		case "content_block_delta":
+			index := int(llmNumber(event["index"]))
+			b := block(index)
+			delta := llmObject(event["delta"])
+			switch llmString(delta["type"]) {
+			case "text_delta":
+				b["text"] = llmString(b["text"]) + llmString(delta["text"])
+			case "input_json_delta":
+				if inputs[index] == nil {
+					inputs[index] = &strings.Builder{}
+				}
+				inputs[index].WriteString(llmString(delta["partial_json"]))
+			}
+		case "message_delta":
+			if reason := llmString(llmObject(event["delta"])["stop_reason"]); reason != "" {
+				resp["stop_reason"] = reason
+			}
+			for k, v := range llmObject(event["usage"]) {
+				usage[k] = v
+			}
+		}
+	}
+
+	content := make([]interface{}, 0, len(order))
+	sort.Ints(order)
+	for _, index := range order {
+		b := blocks[index]
+		if partial, ok := inputs[index]; ok {
+			var input interface{}
+			if err := json.Unmarshal([]byte(partial.String()), &input); err == nil {
+				b["input"] = input
+			} else {
+				b["input"] = partial.String()
+			}
+		}
+		content = append(content, b)
+	}
+	resp["content"] = content
+	if len(usage) > 0 {
+		resp["usage"] = usage
+	}
+	return resp
+}
+
+// reassembleNDJSON joins Ollama's streamed generate or chat objects.
+func reassembleNDJSON(lines []map[string]interface{}) map[string]interface{} {
+	resp := make(map[string]interface{})
+	var text, response strings.Builder
+	var calls []interface{}
+	chat := false
+
+	for _, line := range lines {
+		for _, key := range []string{"model", "done", "done_reason", "prompt_eval_count", "eval_count"} {
+			if v, ok := line[key]; ok {
+				resp[key] = v
+			}
+		}
+		if message := llmObject(line["message"]); message != nil {
+			chat = true
+			text.WriteString(llmString(message["content"]))
+			calls = append(calls, llmArray(message["tool_calls"])...)
+		}
+		response.WriteString(llmString(line["response"]))
+	}
+
+	if chat {
+		message := map[string]interface{}{"role": "assistant", "content": text.String()}
+		if len(calls) > 0 {
+			message["tool_calls"] = calls
+		}
+		resp["message"] = message
+	} else {
+		resp["response"] = response.String()
+	}
+	return resp
+}
+
+// llmAPIKey returns the header carrying an API key and the key.
+func llmAPIKey(headers http.Header) (string, string) {
+	for _, name := range llmKeyHeaders {
+		value := strings.TrimSpace(headers.Get(name))
+		if value == "" {
+			continue
+		}
+		if name == "Authorization" {
+			scheme, token, ok := strings.Cut(value, " ")
+			if !ok || !strings.EqualFold(scheme, "Bearer") {
+				continue
+			}
+			value = strings.TrimSpace(token)
+		}
+		return name, value
+	}
+	return "", ""
+}
+
+// redactLLMKey keeps only enough of a key to tell keys apart.
+func redactLLMKey(key string) string {
+	if len(key) <= 12 {
+		return "****"
+	}
+	return key[:4] + "****" + key[len(key)-4:]
+}
+
+// isLoopbackHost reports whether a Host header names this machine.
+func isLoopbackHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// truncateLLMText shortens text for evidence fields.
+func truncateLLMText(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	return text[:limit] + "..."
+}
+
+// llmText flattens message content: a string, or a list of text parts or
+// content blocks.
+func llmText(v interface{}) string {
+	switch content := v.(type) {
+	case string:
+		return content
+	case []interface{}:
+		parts := make([]string, 0, len(content))
+		for _, item := range content {
+			if s, ok := item.(string); ok {
+				parts = append(parts, s)
+				continue
+			}
+			part := llmObject(item)
+			if text := llmString(part["text"]); text != "" {
+				parts = append(parts, text)
+			} else if text := llmText(part["content"]); text != "" {
+				parts = append(parts, text) // tool_result blocks
+			}
+		}
+		return strings.Join(parts, "\n")
+	}
+	return ""
+}
+
+func llmString(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+func llmNumber(v interface{}) float64 {
+	n, _ := v.(float64)
+	return n
+}
+
+func llmObject(v interface{}) map[string]interface{} {
+	obj, _ := v.(map[string]interface{})
+	return obj
+}
+
+func llmArray(v interface{}) []interface{} {
+	arr, _ := v.([]interface{})
+	return arr
+}
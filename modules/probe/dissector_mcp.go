@@ -0,0 +1,643 @@
+package probe
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// mcpMaxPending bounds the request table used to correlate responses.
+const mcpMaxPending = 1024
+
+// mcpUnknownServer names a server whose initialize response was not seen.
+const mcpUnknownServer = "unknown"
+
+// mcpLabels maps the Model Context Protocol methods we label to their
+// message type.
+var mcpLabels = map[string]string{
+	"initialize":     "initialize",
+	"tools/list":     "tool_list",
+	"tools/call":     "tool_call",
+	"resources/list": "resource_list",
+	"resources/read": "resource_read",
+	"prompts/list":   "prompt_list",
+	"prompts/get":    "prompt_get",
+}
+
+// MCPDissector parses Model Context Protocol JSON-RPC 2.0 traffic carried
+// over stdio (Content-Length or newline framing) or HTTP (JSON or SSE bodies).
+type MCPDissector struct {
+	credHunter   *CredentialHunter
+	toolAnalyzer *ToolPoisoningAnalyzer
+	manifests    *MCPManifestStore                // optional; detects tool definition changes
+	serverKey    func(conversation string) string // MCPServerKey of a conversation's server
+
+	// Requests awaiting a response, keyed by conversation and JSON-RPC id
+	mu      sync.Mutex
+	pending map[string]mcpPending
+	servers map[string]string // serverInfo name from each conversation's last initialize result
+}
+
+// mcpPending is an outstanding request.
+type mcpPending struct {
+	method   string
+	toolName string
+	sent     time.Time
+}
+
+// mcpMessage is a single JSON-RPC 2.0 message.
+type mcpMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data,omitempty"`
+	} `json:"error,omitempty"`
+}
+
+// NewMCPDissector creates a new Model Context Protocol dissector.
+func NewMCPDissector() *MCPDissector {
+	return &MCPDissector{
+		credHunter:   NewCredentialHunter(),
+		toolAnalyzer: NewToolPoisoningAnalyzer(),
+		pending:      make(map[string]mcpPending),
+		servers:      make(map[string]string),
+	}
+}
+
+// SetManifestStore enables rug-pull detection against store.
+func (d *MCPDissector) SetManifestStore(store *MCPManifestStore) {
+	d.manifests = store
+}
+
+// SetServerKey sets how a conversation's server is identified in the
+// manifest store. Without it manifests are not checked.
+func (d *MCPDissector) SetServerKey(serverKey func(conversation string) string) {
+	d.serverKey = serverKey
+}
+
+// Identify checks if the data carries JSON-RPC 2.0 / MCP messages.
+func (d *MCPDissector) Identify(data []byte) (bool, float64) {
+	messages, _, _ := splitMCPMessages(data)
+	if len(messages) == 0 {
+		return false, 0
+	}
+
+	for _, msg := range messages {
+		if _, ok := mcpLabels[msg.Method]; ok || strings.HasPrefix(msg.Method, "notifications/") {
+			return true, 0.98
+		}
+		if msg.Method == "" && len(msg.ID) > 0 && d.isPending(string(msg.ID)) {
+			return true, 0.98
+		}
+	}
+
+	// Generic JSON-RPC 2.0 still outranks plain JSON
+	return true, 0.96
+}
+
+// Dissect parses MCP data into a frame. The first message populates the
+// top-level fields; every message is listed under "messages".
+func (d *MCPDissector) Dissect(data []byte) (*Frame, error) {
+	return d.DissectStream("", data)
+}
+
+// DissectStream is Dissect within a conversation: responses are only
+// correlated with requests seen in the same conversation, so that a server's
+// stdio and the connections it makes upstream do not answer each other.
+func (d *MCPDissector) DissectStream(conversation string, data []byte) (*Frame, error) {
+	messages, framing, headers := splitMCPMessages(data)
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("no JSON-RPC 2.0 messages found")
+	}
+
+	frame := &Frame{
+		Protocol: "MCP",
+		Fields:   make(map[string]interface{}),
+		Raw:      data,
+	}
+	frame.Fields["framing"] = framing
+	frame.Fields["message_count"] = len(messages)
+	if sessionID := headers.Get("Mcp-Session-Id"); sessionID != "" {
+		frame.Fields["mcp_session_id"] = sessionID
+	}
+
+	list := make([]map[string]interface{}, 0, len(messages))
+	for _, msg := range messages {
+		list = append(list, d.describe(conversation, msg))
+	}
+	for k, v := range list[0] {
+		frame.Fields[k] = v
+	}
+	frame.Fields["messages"] = list
+
+	return frame, nil
+}
+
+// describe turns a message into frame fields and tracks request/response
+// correlation.
+func (d *MCPDissector) describe(conversation string, msg mcpMessage) map[string]interface{} {
+	fields := map[string]interface{}{
+		"jsonrpc": msg.JSONRPC,
+	}
+
+	id := ""
+	if len(msg.ID) > 0 && string(msg.ID) != "null" {
+		id = string(msg.ID)
+		fields["id"] = strings.Trim(id, `"`)
+	}
+
+	var params map[string]interface{}
+	if len(msg.Params) > 0 {
+		_ = json.Unmarshal(msg.Params, &params)
+	}
+
+	switch {
+	case msg.Method != "" && id != "":
+		fields["kind"] = "request"
+	case msg.Method != "":
+		fields["kind"] = "notification"
+	case msg.Error != nil:
+		fields["kind"] = "error"
+	default:
+		fields["kind"] = "response"
+	}
+
+	method := msg.Method
+	toolName := ""
+	if method != "" {
+		fields["method"] = method
+		if params != nil {
+			fields["params"] = params
+		}
+
+		switch method {
+		case "tools/call":
+			toolName, _ = params["name"].(string)
+			fields["tool_name"] = toolName
+			if args, ok := params["arguments"]; ok {
+				fields["arguments"] = args
+			}
+		case "resources/read":
+			fields["resource_uri"], _ = params["uri"].(string)
+		case "prompts/get":
+			fields["prompt_name"], _ = params["name"].(string)
+			if args, ok := params["arguments"]; ok {
+				fields["arguments"] = args
+			}
+		case "initialize":
+			fields["protocol_version"], _ = params["protocolVersion"].(string)
+			if info, ok := params["clientInfo"]; ok {
+				fields["client_info"] = info
+			}
+			if caps, ok := params["capabilities"]; ok {
+				fields["capabilities"] = caps
+			}
+		}
+
+		if id != "" {
+			d.track(mcpPendingKey(conversation, id), mcpPending{method: method, toolName: toolName, sent: time.Now()})
+		}
+	} else if id != "" {
+		// Response: recover the request it answers
+		if req, ok := d.resolve(mcpPendingKey(conversation, id)); ok {
+			method = req.method
+			toolName = req.toolName
+			fields["method"] = method
+			fields["correlated"] = true
+			fields["latency_ms"] = time.Since(req.sent).Milliseconds()
+			if toolName != "" {
+				fields["tool_name"] = toolName
+			}
+		} else {
+			fields["correlated"] = false
+		}
+	}
+
+	if label, ok := mcpLabels[method]; ok {
+		fields["mcp_type"] = label
+	} else if strings.HasPrefix(method, "notifications/") {
+		fields["mcp_type"] = "notification"
+	} else {
+		fields["mcp_type"] = "other"
+	}
+
+	if len(msg.Result) > 0 {
+		var result interface{}
+		if err := json.Unmarshal(msg.Result, &result); err == nil {
+			fields["result"] = result
+			d.describeResult(conversation, method, result, fields)
+		}
+	}
+	if msg.Error != nil {
+		fields["error"] = map[string]interface{}{
+			"code":    msg.Error.Code,
+			"message": msg.Error.Message,
+		}
+	}
+
+	return fields
+}
+
+// describeResult lifts the interesting parts of well-known results.
+func (d *MCPDissector) describeResult(conversation, method string, result interface{}, fields map[string]interface{}) {
+	obj, ok := result.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	switch method {
+	case "initialize":
+		fields["protocol_version"], _ = obj["protocolVersion"].(string)
+		if info, ok := obj["serverInfo"].(map[string]interface{}); ok {
+			fields["server_info"] = info
+			if name, ok := info["name"].(string); ok && name != "" {
+				d.mu.Lock()
+				if len(d.servers) >= mcpMaxPending {
+					d.servers = make(map[string]string)
+				}
+				d.servers[conversation] = name
+				d.mu.Unlock()
+			}
+		}
+		if caps, ok := obj["capabilities"]; ok {
+			fields["capabilities"] = caps
+		}
+	case "tools/list":
+		var listed struct {
+			Tools []MCPTool `json:"tools"`
+		}
+		if encoded, err := json.Marshal(obj); err == nil {
+			_ = json.Unmarshal(encoded, &listed)
+		}
+		names := make([]string, 0, len(listed.Tools))
+		for _, tool := range listed.Tools {
+			names = append(names, tool.Name)
+		}
+		fields["tools"] = names
+		fields["tool_definitions"] = listed.Tools
+
+		d.mu.Lock()
+		server := d.servers[conversation]
+		d.mu.Unlock()
+		if server == "" {
+			server = mcpUnknownServer
+		}
+		fields["server"] = server
+		if d.serverKey != nil {
+			if key := d.serverKey(conversation); key != "" {
+				fields["server_key"] = key
+			}
+		}
+	case "tools/call":
+		if isError, ok := obj["isError"].(bool); ok {
+			fields["tool_error"] = isError
+		}
+	}
+}
+
+// track records an outstanding request.
+func (d *MCPDissector) track(key string, req mcpPending) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.pending) >= mcpMaxPending {
+		// Unanswered requests are dropped once they are a minute old
+		for k, p := range d.pending {
+			if time.Since(p.sent) > time.Minute {
+				delete(d.pending, k)
+			}
+		}
+		if len(d.pending) >= mcpMaxPending {
+			d.pending = make(map[string]mcpPending)
+		}
+	}
+	d.pending[key] = req
+}
+
+// resolve removes and returns the request a response answers.
+func (d *MCPDissector) resolve(key string) (mcpPending, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	req, ok := d.pending[key]
+	if ok {
+		delete(d.pending, key)
+	}
+	return req, ok
+}
+
+// isPending reports whether id belongs to an outstanding request in any
+// conversation.
+func (d *MCPDissector) isPending(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	suffix := "\x00" + id
+	for key := range d.pending {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// mcpPendingKey keys an outstanding request by its conversation and id.
+func mcpPendingKey(conversation, id string) string {
+	return conversation + "\x00" + id
+}
+
+// FindVulnerabilities looks for credentials in tool arguments and results,
+// reads of sensitive resources, poisoned tool definitions and tool
+// definitions that changed since they were first approved.
+func (d *MCPDissector) FindVulnerabilities(frame *Frame) []StreamVulnerability {
+	var vulns []StreamVulnerability
+
+	messages, _ := frame.Fields["messages"].([]map[string]interface{})
+	for _, msg := range messages {
+		method, _ := msg["method"].(string)
+		location := "MCP " + method
+
+		for _, part := range []string{"arguments", "result"} {
+			value, ok := msg[part]
+			if !ok {
+				continue
+			}
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				continue
+			}
+			for _, cred := range d.credHunter.Hunt(encoded) {
+				vulns = append(vulns, StreamVulnerability{
+					ID:         fmt.Sprintf("MCP-%d", len(vulns)),
+					Timestamp:  time.Now(),
+					Severity:   cred.Severity,
+					Type:       "credential_exposure",
+					Subtype:    cred.Type,
+					Evidence:   cred.Redacted,
+					Location:   location + " " + part,
+					Context:    fmt.Sprintf("%s found in MCP %s", cred.Type, part),
+					Confidence: cred.Confidence,
+				})
+			}
+		}
+
+		if tools, ok := msg["tool_definitions"].([]MCPTool); ok {
+			server, _ := msg["server"].(string)
+			vulns = append(vulns, d.toolAnalyzer.Analyze(server, tools)...)
+			// Only a server we know how to launch or reach has a manifest;
+			// the name it reports can be claimed by any server
+			if key, _ := msg["server_key"].(string); key != "" {
+				if vuln := d.checkManifest(key, server, tools); vuln != nil {
+					vulns = append(vulns, *vuln)
+				}
+			}
+		}
+
+		if uri, ok := msg["resource_uri"].(string); ok && isSensitiveResource(uri) {
+			vulns = append(vulns, StreamVulnerability{
+				ID:         fmt.Sprintf("MCP-%d", len(vulns)),
+				Timestamp:  time.Now(),
+				Severity:   "high",
+				Type:       "sensitive_resource_access",
+				Subtype:    "resources/read",
+				Evidence:   uri,
+				Location:   location,
+				Context:    "MCP client read a resource that commonly holds credentials",
+				Confidence: 0.8,
+			})
+		}
+	}
+
+	return vulns
+}
+
+// checkManifest reports a server whose tools changed after first approval.
+func (d *MCPDissector) checkManifest(key, server string, tools []MCPTool) *StreamVulnerability {
+	if d.manifests == nil {
+		return nil
+	}
+
+	change, err := d.manifests.Observe(key, server, tools)
+	if err != nil || change == nil {
+		return nil
+	}
+
+	var parts []string
+	for _, group := range []struct {
+		label string
+		names []string
+	}{{"added", change.Added}, {"removed", change.Removed}, {"modified", change.Modified}} {
+		if len(group.names) > 0 {
+			parts = append(parts, fmt.Sprintf("%s: %s", group.label, strings.Join(group.names, ", ")))
+		}
+	}
+
+	severity := "high"
+	if len(change.Modified) > 0 {
+		severity = "critical"
+	}
+
+	return &StreamVulnerability{
+		ID:         "MCP-RUGPULL",
+		Timestamp:  time.Now(),
+		Severity:   severity,
+		Type:       "tool_rug_pull",
+		Subtype:    "manifest_changed",
+		Evidence:   strings.Join(parts, "; "),
+		Location:   fmt.Sprintf("MCP tools/list %s (%s)", server, key),
+		Context:    "Server changed its tool definitions after they were first approved",
+		Confidence: 0.95,
+	}
+}
+
+// isSensitiveResource reports whether a resource URI points at credential
+// material.
+func isSensitiveResource(uri string) bool {
+	lower := strings.ToLower(uri)
+	for _, hint := range []string{"/.ssh/", "/.aws/", "/.env", ".pem", ".key", "/etc/shadow", "/.netrc", "/.docker/config.json", "/.kube/config"} {
+		if strings.Contains(lower, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetSessionID extracts the MCP session identifier.
+func (d *MCPDissector) GetSessionID(frame *Frame) (string, error) {
+	if sessionID, ok := frame.Fields["mcp_session_id"].(string); ok && sessionID != "" {
+		return fmt.Sprintf("mcp_%s", sessionID), nil
+	}
+	return "", fmt.Errorf("no MCP session identifier in frame")
+}
+
+// splitMCPMessages extracts JSON-RPC 2.0 messages from Content-Length framed,
+// newline-delimited, HTTP or SSE data. It returns the messages, the framing
+// detected and any HTTP headers.
+func splitMCPMessages(data []byte) ([]mcpMessage, string, textproto.MIMEHeader) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, "", nil
+	}
+
+	// HTTP transport: peel off the start line and headers
+	if isHTTPStart(trimmed) {
+		head, body, found := bytes.Cut(data, []byte("\r\n\r\n"))
+		if !found {
+			return nil, "", nil
+		}
+		reader := textproto.NewReader(bufio.NewReader(bytes.NewReader(append(head, '\r', '\n', '\r', '\n'))))
+		if _, err := reader.ReadLine(); err != nil {
+			return nil, "", nil
+		}
+		headers, _ := reader.ReadMIMEHeader()
+
+		if strings.Contains(headers.Get("Content-Type"), "text/event-stream") || bytes.HasPrefix(bytes.TrimSpace(body), []byte("event:")) || bytes.HasPrefix(bytes.TrimSpace(body), []byte("data:")) {
+			return parseSSEMessages(body), "sse", headers
+		}
+		messages, _ := parseNewlineMessages(body)
+		return messages, "http", headers
+	}
+
+	// stdio with LSP-style Content-Length headers
+	if bytes.HasPrefix(trimmed, []byte("Content-Length:")) || bytes.HasPrefix(trimmed, []byte("Content-Type:")) {
+		return parseContentLengthMessages(trimmed), "content-length", nil
+	}
+
+	messages, framing := parseNewlineMessages(trimmed)
+	return messages, framing, nil
+}
+
+// isHTTPStart reports whether data begins with an HTTP request or status line.
+func isHTTPStart(data []byte) bool {
+	if bytes.HasPrefix(data, []byte("HTTP/1.")) {
+		return true
+	}
+	for _, method := range []string{"POST ", "GET ", "DELETE "} {
+		if bytes.HasPrefix(data, []byte(method)) {
+			line, _, _ := bytes.Cut(data, []byte("\n"))
+			return bytes.Contains(line, []byte(" HTTP/"))
+		}
+	}
+	return false
+}
+
+// parseContentLengthMessages reads a sequence of header-framed messages.
+func parseContentLengthMessages(data []byte) []mcpMessage {
+	var messages []mcpMessage
+	for len(data) > 0 {
+		head, rest, found := bytes.Cut(data, []byte("\r\n\r\n"))
+		if !found {
+			head, rest, found = bytes.Cut(data, []byte("\n\n"))
+			if !found {
+				break
+			}
+		}
+
+		length := -1
+		for _, line := range strings.Split(string(head), "\n") {
+			name, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+			if ok && strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+				length, _ = strconv.Atoi(strings.TrimSpace(value))
+			}
+		}
+		if length < 0 || length > len(rest) {
+			break
+		}
+
+		if msg, ok := decodeMCPMessage(rest[:length]); ok {
+			messages = append(messages, msg...)
+		}
+		data = bytes.TrimLeft(rest[length:], "\r\n")
+	}
+	return messages
+}
+
+// parseNewlineMessages reads newline-delimited JSON, falling back to a single
+// document spanning several lines.
+func parseNewlineMessages(data []byte) ([]mcpMessage, string) {
+	var messages []mcpMessage
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if msg, ok := decodeMCPMessage(line); ok {
+			messages = append(messages, msg...)
+		}
+	}
+	if len(messages) > 0 {
+		return messages, "newline"
+	}
+
+	if msg, ok := decodeMCPMessage(bytes.TrimSpace(data)); ok {
+		return msg, "raw"
+	}
+	return nil, ""
+}
+
+// parseSSEMessages reads the data lines of a server-sent event stream.
+func parseSSEMessages(body []byte) []mcpMessage {
+	var messages []mcpMessage
+	var event bytes.Buffer
+	flush := func() {
+		if event.Len() > 0 {
+			if msg, ok := decodeMCPMessage(event.Bytes()); ok {
+				messages = append(messages, msg...)
+			}
+			event.Reset()
+		}
+	}
+
+	for _, line := range bytes.Split(body, []byte("\n")) {
+		line = bytes.TrimRight(line, "\r")
+		switch {
+		case len(line) == 0:
+			flush()
+		case bytes.HasPrefix(line, []byte("data:")):
+			event.Write(bytes.TrimSpace(line[len("data:"):]))
+		}
+	}
+	flush()
+	return messages
+}
+
+// decodeMCPMessage decodes a JSON-RPC 2.0 message or batch.
+func decodeMCPMessage(data []byte) ([]mcpMessage, bool) {
+	if len(data) == 0 {
+		return nil, false
+	}
+
+	if data[0] == '[' {
+		var batch []mcpMessage
+		if err := json.Unmarshal(data, &batch); err != nil {
+			return nil, false
+		}
+		var valid []mcpMessage
+		for _, msg := range batch {
+			if msg.JSONRPC == "2.0" {
+				valid = append(valid, msg)
+			}
+		}
+		return valid, len(valid) > 0
+	}
+
+	var msg mcpMessage
+	if err := json.Unmarshal(data, &msg); err != nil || msg.JSONRPC != "2.0" {
+		return nil, false
+	}
+	if msg.Method == "" && len(msg.Result) == 0 && msg.Error == nil {
+		return nil, false
+	}
+	return []mcpMessage{msg}, true
+}
@@ -0,0 +1,163 @@
+package probe
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DissectorFactory creates a dissector for one center session.
+type DissectorFactory func(config CenterConfig) Dissector
+
+// DissectorRegistration is a dissector center can be configured with.
+type DissectorRegistration struct {
+	Name string
+	// Priority orders the dissectors: the message goes to the one that
+	// identifies it with the highest confidence, and on a tie to the one
+	// with the higher priority.
+	Priority int
+	New      DissectorFactory
+}
+
+var (
+	dissectorRegistryMu sync.RWMutex
+	dissectorRegistry   = make(map[string]DissectorRegistration)
+)
+
+// RegisterDissector makes a dissector available to center by name. A later
+// registration under the same name replaces the earlier one.
+func RegisterDissector(name string, priority int, factory DissectorFactory) {
+	dissectorRegistryMu.Lock()
+	defer dissectorRegistryMu.Unlock()
+	dissectorRegistry[name] = DissectorRegistration{Name: name, Priority: priority, New: factory}
+}
+
+// RegisteredDissectors lists the registered dissectors by priority.
+func RegisteredDissectors() []DissectorRegistration {
+	dissectorRegistryMu.RLock()
+	defer dissectorRegistryMu.RUnlock()
+
+	registrations := make([]DissectorRegistration, 0, len(dissectorRegistry))
+	for _, registration := range dissectorRegistry {
+		registrations = append(registrations, registration)
+	}
+	sortDissectors(registrations)
+	return registrations
+}
+
+// sortDissectors orders registrations by descending priority, then name.
+func sortDissectors(registrations []DissectorRegistration) {
+	sort.Slice(registrations, func(i, j int) bool {
+		if registrations[i].Priority != registrations[j].Priority {
+			return registrations[i].Priority > registrations[j].Priority
+		}
+		return registrations[i].Name < registrations[j].Name
+	})
+}
+
+func init() {
+	// Specific protocols come before the generic JSON, SQL and text ones
+	RegisterDissector("llm", 80, func(CenterConfig) Dissector { return NewLLMDissector() })
+	RegisterDissector("http", 70, func(CenterConfig) Dissector { return NewHTTPDissector() })
+	RegisterDissector("grpc", 60, func(CenterConfig) Dissector { return NewGRPCDissectorV2() })
+	RegisterDissector("websocket", 50, func(CenterConfig) Dissector { return NewWebSocketDissector() })
+	RegisterDissector("mcp", 40, newCenterMCPDissector)
+	RegisterDissector("json", 30, func(CenterConfig) Dissector { return NewJSONDissector() })
+	RegisterDissector("sql", 20, func(CenterConfig) Dissector { return NewSQLDissector() })
+	RegisterDissector("plaintext", 10, func(CenterConfig) Dissector { return NewPlainTextDissector() })
+}
+
+// newCenterMCPDissector creates the MCP dissector with the manifest store of
+// the configuration, which persists across sessions for rug-pull detection.
+func newCenterMCPDissector(config CenterConfig) Dissector {
+	dissector := NewMCPDissector()
+	manifests, err := NewMCPManifestStore(config.MCPManifests)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v; tool changes are only tracked for this session\n", err)
+		manifests, _ = NewMCPManifestStore("")
+	}
+	dissector.SetManifestStore(manifests)
+	dissector.SetServerKey(func(conversation string) string {
+		return centerMCPServerKey("/proc", conversation)
+	})
+	return dissector
+}
+
+// centerMCPServerKey identifies the server of a conversation by its command
+// line: the monitored process for its stdio, or the single process at the
+// other end of a pipe. Socket conversations are not identified, since the
+// key of a conversation does not name the remote end.
+func centerMCPServerKey(procRoot, conversation string) string {
+	pidText, rest, _ := strings.Cut(conversation, "/")
+	pid, err := strconv.Atoi(pidText)
+	if err != nil {
+		return ""
+	}
+	if peer, ok := strings.CutPrefix(rest, "pipe/"); ok {
+		if strings.Contains(peer, ", ") {
+			return ""
+		}
+		if _, err := fmt.Sscanf(peer, "pid %d ", &pid); err != nil {
+			return ""
+		}
+	} else if rest != "stdio" {
+		return ""
+	}
+	return processCommandLine(procRoot, pid)
+}
+
+// selectDissectors applies a --dissectors specification to the available
+// dissectors. It is a comma-separated list of names to enable; names
+// prefixed with "-" are disabled instead, from all dissectors if nothing is
+// enabled. Empty and "all" enable everything.
+func selectDissectors(available []DissectorRegistration, spec string) ([]DissectorRegistration, error) {
+	known := make(map[string]bool, len(available))
+	for _, registration := range available {
+		known[registration.Name] = true
+	}
+
+	enabled := make(map[string]bool)
+	disabled := make(map[string]bool)
+	all := true
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		negate := strings.HasPrefix(name, "-")
+		name = strings.TrimPrefix(name, "-")
+		switch {
+		case name == "":
+			continue
+		case name == "all" && !negate:
+			enabled["all"] = true
+			continue
+		case !known[name]:
+			names := make([]string, 0, len(available))
+			for _, registration := range available {
+				names = append(names, registration.Name)
+			}
+			return nil, fmt.Errorf("unknown dissector %q (available: %s)", name, strings.Join(names, ", "))
+		case negate:
+			disabled[name] = true
+		default:
+			enabled[name] = true
+			all = false
+		}
+	}
+	if enabled["all"] {
+		all = true
+	}
+
+	var selected []DissectorRegistration
+	for _, registration := range available {
+		if (all || enabled[registration.Name]) && !disabled[registration.Name] {
+			selected = append(selected, registration)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("dissector selection %q leaves no dissectors enabled", spec)
+	}
+	sortDissectors(selected)
+	return selected, nil
+}
@@ -0,0 +1,134 @@
+package probe
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func registrationNames(registrations []DissectorRegistration) []string {
+	names := make([]string, 0, len(registrations))
+	for _, registration := range registrations {
+		names = append(names, registration.Name)
+	}
+	return names
+}
+
+func TestRegisteredDissectorsByPriority(t *testing.T) {
+	want := []string{"llm", "http", "grpc", "websocket", "mcp", "json", "sql", "plaintext"}
+	if got := registrationNames(RegisteredDissectors()); !reflect.DeepEqual(got, want) {
+		t.Errorf("RegisteredDissectors() = %v, want %v", got, want)
+	}
+}
+
+func TestSelectDissectors(t *testing.T) {
+	available := RegisteredDissectors()
+	for _, tt := range []struct {
+		spec string
+		want []string
+	}{
+		{"", []string{"llm", "http", "grpc", "websocket", "mcp", "json", "sql", "plaintext"}},
+		{"all,-grpc", []string{"llm", "http", "websocket", "mcp", "json", "sql", "plaintext"}},
+		{"-sql, -plaintext", []string{"llm", "http", "grpc", "websocket", "mcp", "json"}},
+		{"plaintext,mcp", []string{"mcp", "plaintext"}},
+		{"mcp,json,-json", []string{"mcp"}},
+	} {
+		selected, err := selectDissectors(available, tt.spec)
+		if err != nil {
+			t.Errorf("selectDissectors(%q): %v", tt.spec, err)
+			continue
+		}
+		if got := registrationNames(selected); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("selectDissectors(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+
+	for _, spec := range []string{"xml", "-xml", "mcp,-mcp"} {
+		if _, err := selectDissectors(available, spec); err == nil {
+			t.Errorf("selectDissectors(%q): expected an error", spec)
+		}
+	}
+}
+
+func TestCenterConfigureDissectors(t *testing.T) {
+	dir := t.TempDir()
+	defs := filepath.Join(dir, "dissectors")
+	if err := os.Mkdir(defs, 0755); err != nil {
+		t.Fatal(err)
+	}
+	definition := "name: ping\nformat: text\nidentify:\n  patterns: ['^PING ']\n"
+	if err := os.WriteFile(filepath.Join(defs, "ping.yaml"), []byte(definition), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	configure := func(options map[string]string) (*CenterModule, error) {
+		m := NewCenterModule().(*CenterModule)
+		options["output"] = filepath.Join(dir, "center.jsonl")
+		options["no-display"] = "true"
+		options["mcp-manifests"] = filepath.Join(dir, "manifests.json")
+		for name, value := range options {
+			if err := m.SetOption(name, value); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return m, m.Configure()
+	}
+
+	m, err := configure(map[string]string{"dissectors": "-grpc,-websocket,-sql", "dissector-defs": defs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"llm", "http", "ping", "mcp", "json", "plaintext"}
+	if !reflect.DeepEqual(m.config.Dissectors, want) || len(m.dissectors) != len(want) {
+		t.Errorf("dissectors = %v, want %v", m.config.Dissectors, want)
+	}
+	if _, ok := m.dissectors[2].(*DeclarativeDissector); !ok {
+		t.Errorf("dissector 2 is %T, want the declarative one", m.dissectors[2])
+	}
+
+	// A definition matching any JSON-RPC message defers to the MCP dissector
+	definition = "name: rpc\nformat: json\nidentify:\n  patterns: ['\"jsonrpc\"']\n"
+	if err := os.WriteFile(filepath.Join(defs, "rpc.yaml"), []byte(definition), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err = configure(map[string]string{"dissector-defs": defs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rpc Dissector
+	for _, d := range m.dissectors {
+		if declarative, ok := d.(*DeclarativeDissector); ok && declarative.Name() == "rpc" {
+			rpc = d
+		}
+	}
+	if rpc == nil {
+		t.Fatal("definition not configured")
+	}
+	for _, message := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"read_file"}}`,
+		`{"jsonrpc":"2.0","id":7,"method":"acme/custom"}`,
+	} {
+		if ok, _ := rpc.Identify([]byte(message)); !ok {
+			t.Errorf("definition did not match %s", message)
+		}
+		if d, _ := m.identify([]byte(message)); reflect.TypeOf(d) != reflect.TypeOf(&MCPDissector{}) {
+			t.Errorf("%s identified by %T, want the MCP dissector", message, d)
+		}
+	}
+	if err := os.Remove(filepath.Join(defs, "rpc.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := configure(map[string]string{"dissectors": "ping"}); err == nil {
+		t.Error("expected an error selecting a definition that was not loaded")
+	}
+
+	// Definitions cannot shadow registered dissectors
+	if err := os.WriteFile(filepath.Join(defs, "json.yaml"), []byte("name: json\nformat: json\nidentify:\n  patterns: ['{']\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := configure(map[string]string{"dissector-defs": defs}); err == nil {
+		t.Error("expected an error for a definition named like a registered dissector")
+	}
+}
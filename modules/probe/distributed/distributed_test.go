@@ -306,6 +306,30 @@ func TestWorker(t *testing.T) {
 			t.Errorf("Result task ID mismatch: %s != %s", result.TaskID, task.ID)
 		}
 	})
+
+	t.Run("CaptureBuffering", func(t *testing.T) {
+		// A JSON-RPC line split across frames is taken as one message
+		frames := make(chan []byte, 3)
+		frames <- []byte(`{"jsonrpc":"2.0",`)
+		frames <- []byte(`"method":"tools/list"}` + "\n" + `{"jsonrpc"`)
+		frames <- []byte(`:"2.0","id":1}` + "\n")
+		close(frames)
+
+		stats, err := worker.bufferCapture(frames, "line")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.EventsRead != 2 || stats.BytesDropped != 0 {
+			t.Errorf("unexpected buffer stats %+v", stats)
+		}
+		if events := worker.metrics.GetSnapshot()["capture_events"]; events != uint64(2) {
+			t.Errorf("recorded %v capture events, want 2", events)
+		}
+
+		if _, err := worker.bufferCapture(frames, "gopher"); err == nil {
+			t.Error("expected an error for an unknown protocol")
+		}
+	})
 }
 
 func TestDistributedProcessing(t *testing.T) {
@@ -11,9 +11,13 @@ import (
 
 	// "github.com/macawi-ai/strigoi/modules/probe/capture"
 	// "github.com/macawi-ai/strigoi/modules/probe/dissect"
+	"github.com/macawi-ai/strigoi/modules/probe"
 	"github.com/macawi-ai/strigoi/modules/probe/ml"
 )
 
+// captureEventPoll is how often captured frames are split into events
+const captureEventPoll = 5 * time.Millisecond
+
 // Temporary mock types until capture and dissect packages are available
 type captureEngine struct{}
 
@@ -62,6 +66,8 @@ type WorkerConfig struct {
 	ProcessTimeout time.Duration
 	EnableML       bool
 	MLConfig       ml.DetectorConfig
+	BufferSize     int                  // Per-capture event buffer size (default 1MB)
+	BufferOverflow probe.OverflowPolicy // What captures do when events back up (default drop-oldest)
 	// CaptureConfig   capture.EngineConfig
 	// DissectorConfig dissect.EngineConfig
 }
@@ -80,6 +86,13 @@ type WorkerHealthStatus struct {
 
 // NewWorker creates a new worker node
 func NewWorker(config WorkerConfig) (*Worker, error) {
+	if config.BufferSize <= 0 {
+		config.BufferSize = 1024 * 1024
+	}
+	if config.BufferOverflow == "" {
+		config.BufferOverflow = probe.OverflowDropOldest
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 
 	worker := &Worker{
@@ -353,6 +366,7 @@ func (w *Worker) processCaptureTask(ctx context.Context, task *ProcessingTask) e
 		Interface string        `json:"interface"`
 		Filter    string        `json:"filter"`
 		Duration  time.Duration `json:"duration"`
+		Protocol  string        `json:"protocol"` // boundary detector; empty detects per message
 	}
 
 	if err := json.Unmarshal(task.Data, &params); err != nil {
@@ -368,13 +382,88 @@ func (w *Worker) processCaptureTask(ctx context.Context, task *ProcessingTask) e
 		return fmt.Errorf("capture failed: %w", err)
 	}
 
-	// Process captured frames
+	_, err = w.bufferCapture(frames, params.Protocol)
+	return err
+}
+
+// bufferCapture splits captured frames into protocol messages through an
+// event buffer, so that messages spanning frames arrive whole
+func (w *Worker) bufferCapture(frames <-chan []byte, protocol string) (probe.BufferStats, error) {
+	detectors := probe.NewProtocolBoundaryDetector()
+	var detector probe.BoundaryDetector = detectors
+	if protocol != "" {
+		d, ok := detectors.Detector(protocol)
+		if !ok {
+			return probe.BufferStats{}, fmt.Errorf("unknown capture protocol: %s", protocol)
+		}
+		detector = d
+	}
+
+	buffer, err := probe.NewEventBuffer(probe.EventBufferConfig{
+		Size:     w.config.BufferSize,
+		Backend:  probe.BufferBackendLockFree,
+		Detector: detector,
+		Overflow: w.config.BufferOverflow,
+	})
+	if err != nil {
+		return probe.BufferStats{}, fmt.Errorf("failed to create capture buffer: %w", err)
+	}
+	defer buffer.Close()
+
+	stop := make(chan struct{})
+	consumed := make(chan struct{})
+	go func() {
+		defer close(consumed)
+		w.consumeCaptureEvents(buffer, stop)
+	}()
+
 	for frame := range frames {
-		// Send to dissector or storage
-		_ = frame
+		if _, err := buffer.Write(frame); err != nil {
+			close(stop)
+			<-consumed
+			return buffer.Stats(), fmt.Errorf("failed to buffer frame: %w", err)
+		}
 	}
+	close(stop)
+	<-consumed
 
-	return nil
+	stats := buffer.Stats()
+	w.metrics.RecordCaptureDropped(stats.BytesDropped)
+	return stats, nil
+}
+
+// consumeCaptureEvents takes events from buffer until stop is closed, then
+// flushes what remains
+func (w *Worker) consumeCaptureEvents(buffer probe.EventBuffer, stop <-chan struct{}) {
+	ticker := time.NewTicker(captureEventPoll)
+	defer ticker.Stop()
+
+	for {
+		for {
+			event, ok := buffer.ReadEvent()
+			if !ok {
+				break
+			}
+			// Send to dissector or storage
+			w.metrics.RecordCaptureEvent(len(event.Data))
+		}
+
+		select {
+		case <-stop:
+			for {
+				event, ok := buffer.ReadEvent()
+				if !ok {
+					break
+				}
+				w.metrics.RecordCaptureEvent(len(event.Data))
+			}
+			if rest := buffer.ReadAll(); len(rest) > 0 {
+				w.metrics.RecordCaptureEvent(len(rest))
+			}
+			return
+		case <-ticker.C:
+		}
+	}
 }
 
 // processDissectTask processes protocol dissection tasks
@@ -560,6 +649,9 @@ type WorkerMetrics struct {
 	tasksFailed    uint64
 	queueFullCount uint64
 	totalTime      uint64
+	captureEvents  uint64
+	captureBytes   uint64
+	captureDropped uint64
 	mu             sync.RWMutex
 }
 
@@ -591,6 +683,17 @@ func (m *WorkerMetrics) RecordQueueFull() {
 	atomic.AddUint64(&m.queueFullCount, 1)
 }
 
+// RecordCaptureEvent records a message taken from a capture
+func (m *WorkerMetrics) RecordCaptureEvent(size int) {
+	atomic.AddUint64(&m.captureEvents, 1)
+	atomic.AddUint64(&m.captureBytes, uint64(size))
+}
+
+// RecordCaptureDropped records captured bytes lost to buffer overflow
+func (m *WorkerMetrics) RecordCaptureDropped(bytes uint64) {
+	atomic.AddUint64(&m.captureDropped, bytes)
+}
+
 // GetTotalProcessed returns total processed
 func (m *WorkerMetrics) GetTotalProcessed() uint64 {
 	return atomic.LoadUint64(&m.tasksProcessed)
@@ -616,5 +719,8 @@ func (m *WorkerMetrics) GetSnapshot() map[string]interface{} {
 		"tasks_failed":     atomic.LoadUint64(&m.tasksFailed),
 		"queue_full_count": atomic.LoadUint64(&m.queueFullCount),
 		"avg_process_time": avgTime,
+		"capture_events":   atomic.LoadUint64(&m.captureEvents),
+		"capture_bytes":    atomic.LoadUint64(&m.captureBytes),
+		"capture_dropped":  atomic.LoadUint64(&m.captureDropped),
 	}
 }
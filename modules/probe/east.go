@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/macawi-ai/strigoi/pkg/modules"
+	"github.com/macawi-ai/strigoi/pkg/security"
 )
 
 func init() {
@@ -23,6 +24,7 @@ type EastModule struct {
 	modules.BaseModule
 	executor         *SecureExecutor
 	compiledPatterns map[string]*regexp.Regexp
+	pii              *security.PIIClassifier
 }
 
 // NewEastModule creates a new East probe module.
@@ -30,7 +32,7 @@ func NewEastModule() modules.Module {
 	return &EastModule{
 		BaseModule: modules.BaseModule{
 			ModuleName:        "probe/east",
-			ModuleDescription: "Trace data flows, API integrations, and information leakage",
+			ModuleDescription: "Trace data flows from sources to LLM, HTTP and logging sinks, API integrations, and information leakage",
 			ModuleType:        modules.ProbeModule,
 			ModuleOptions: map[string]*modules.ModuleOption{
 				"target": {
@@ -79,6 +81,7 @@ func NewEastModule() modules.Module {
 		},
 		executor:         NewSecureExecutor(),
 		compiledPatterns: make(map[string]*regexp.Regexp),
+		pii:              security.NewPIIClassifier(),
 	}
 }
 
@@ -109,7 +112,7 @@ func (m *EastModule) Info() *modules.ModuleInfo {
 	return &modules.ModuleInfo{
 		Author:  "Strigoi Team",
 		Version: "1.0.0",
-		Tags:    []string{"data-flow", "secrets", "api", "leakage"},
+		Tags:    []string{"data-flow", "taint-tracking", "secrets", "api", "leakage", "pii"},
 		References: []string{
 			"https://owasp.org/www-project-top-ten/",
 			"https://cwe.mitre.org/data/definitions/200.html",
@@ -142,7 +145,8 @@ func (m *EastModule) Run() (*modules.ModuleResult, error) {
 	excludeDirs := m.getExcludeDirs()
 	maxFileSize := m.getMaxFileSize()
 
-	// Scan files
+	// Scan files, collecting literal prompt text on the way
+	var prompts []promptTemplate
 	err := filepath.Walk(target, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil // Skip files we can't access
@@ -182,6 +186,13 @@ func (m *EastModule) Run() (*modules.ModuleResult, error) {
 		findings := m.analyzeFile(path)
 		result.Findings = append(result.Findings, findings...)
 
+		// Trace source-to-sink flows in languages we can parse
+		if src, err := os.ReadFile(path); err == nil {
+			flows, templates := traceDataFlows(path, src)
+			result.DataFlows = append(result.DataFlows, flows...)
+			prompts = append(prompts, templates...)
+		}
+
 		return nil
 	})
 
@@ -189,8 +200,9 @@ func (m *EastModule) Run() (*modules.ModuleResult, error) {
 		return nil, fmt.Errorf("scan failed: %w", err)
 	}
 
-	// Extract data flows and services from findings
+	// Report traced data flows and extract services from findings
 	m.extractDataFlows(result)
+	m.extractPromptPII(result, prompts)
 	m.extractServices(result)
 
 	// Calculate summary
@@ -422,6 +434,22 @@ func (m *EastModule) getReferences(category string) []string {
 		"private_key": {
 			"https://owasp.org/www-project-web-security-testing-guide/latest/4-Web_Application_Security_Testing/09-Testing_for_Weak_Cryptography/04-Testing_for_Weak_Encryption",
 		},
+		"credential_to_log": {
+			"https://cwe.mitre.org/data/definitions/532.html",
+		},
+		"user_input_to_log": {
+			"https://cwe.mitre.org/data/definitions/532.html",
+		},
+		"pii_exposure": {
+			"https://cwe.mitre.org/data/definitions/359.html",
+			"https://owasp.org/www-project-top-10-for-large-language-model-applications/",
+		},
+	}
+	if strings.HasSuffix(category, "_to_llm") {
+		return []string{
+			"https://owasp.org/www-project-top-10-for-large-language-model-applications/",
+			"https://cwe.mitre.org/data/definitions/200.html",
+		}
 	}
 
 	if refs, exists := references[category]; exists {
@@ -433,55 +461,126 @@ func (m *EastModule) getReferences(category string) []string {
 	}
 }
 
-// extractDataFlows analyzes findings to identify data flows.
+// extractDataFlows numbers the traced flows and reports each one as a
+// finding located at its sink.
 func (m *EastModule) extractDataFlows(result *DataFlowResult) {
-	// Group findings by file to identify flows
-	fileFindings := make(map[string][]Finding)
-	for _, finding := range result.Findings {
-		parts := strings.Split(finding.Location, ":")
-		if len(parts) > 0 {
-			fileName := parts[0]
-			fileFindings[fileName] = append(fileFindings[fileName], finding)
-		}
+	for i := range result.DataFlows {
+		flow := &result.DataFlows[i]
+		flow.ID = fmt.Sprintf("flow_%d", i+1)
+		result.Findings = append(result.Findings, m.dataFlowFinding(*flow))
 	}
+}
 
-	// Create basic data flows based on findings
-	flowID := 0
-	for _, findings := range fileFindings {
-		var hasInput, hasOutput, hasSecret bool
-		var sensitiveData []string
-
-		for _, finding := range findings {
-			switch finding.Type {
-			case "hardcoded_secret":
-				hasSecret = true
-				sensitiveData = append(sensitiveData, finding.Category)
-			case "api_endpoint":
-				hasOutput = true
-			case "information_disclosure":
-				hasInput = true
-			}
+// dataFlowFinding describes a traced flow as a finding. Flows through a
+// redaction or hashing call are reported at low severity.
+func (m *EastModule) dataFlowFinding(flow DataFlow) Finding {
+	sinkKind, _, _ := strings.Cut(flow.Destination, ":")
+	category := flow.SensitiveData[0] + "_to_" + sinkKind
+	sink := flow.Hops[len(flow.Hops)-1]
+
+	// Go is traced through its syntax tree, scripts through tokens
+	confidence := "medium"
+	if filepath.Ext(sink.File) == ".go" {
+		confidence = "high"
+	}
+	severity := m.determineSeverity("data_flow", category)
+	if len(flow.Protection) > 0 && flow.Protection[0] == "redacted" {
+		severity = "low"
+	}
+
+	steps := make([]string, len(flow.Hops))
+	for i, hop := range flow.Hops {
+		steps[i] = fmt.Sprintf("%s:%d %s %s", hop.File, hop.Line, hop.Kind, hop.Detail)
+	}
+
+	impacts := map[string]string{
+		flowSinkLLM:  "Data is sent to a third-party model provider and may be retained or echoed back",
+		flowSinkHTTP: "Data leaves the process in an outbound HTTP request",
+		flowSinkLog:  "Data is written to logs readable by anyone with log access",
+	}
+	remediations := map[string]string{
+		flowSinkLLM:  "Redact secrets and personal data before building prompts; treat request input as untrusted",
+		flowSinkHTTP: "Do not forward sensitive values in request bodies or URLs; send credentials only in auth headers",
+		flowSinkLog:  "Do not log sensitive values; redact them or log a fingerprint instead",
+	}
+
+	return Finding{
+		Type:        "data_flow",
+		Category:    category,
+		Location:    fmt.Sprintf("%s:%d", sink.File, sink.Line),
+		Confidence:  confidence,
+		Severity:    severity,
+		Evidence:    fmt.Sprintf("%s → %s", flow.Source, flow.Destination),
+		Impact:      impacts[sinkKind],
+		Remediation: remediations[sinkKind],
+		DataFlow:    steps,
+		References:  m.getReferences(category),
+	}
+}
+
+// extractPromptPII classifies the literal text of prompts sent to LLM
+// providers and reports one finding per PII type and template.
+func (m *EastModule) extractPromptPII(result *DataFlowResult, prompts []promptTemplate) {
+	for _, prompt := range prompts {
+		matches := m.pii.Classify(prompt.Text)
+		if len(matches) == 0 {
+			continue
 		}
 
-		// If we have both input and output, create a flow
-		if hasInput && hasOutput {
-			flowID++
-			flow := DataFlow{
-				ID:            fmt.Sprintf("flow_%d", flowID),
-				Source:        "user_input",
-				Destination:   "external_api",
-				SensitiveData: sensitiveData,
+		byType := make(map[string][]security.PIIMatch)
+		var types []string
+		for _, match := range matches {
+			if _, ok := byType[match.Type]; !ok {
+				types = append(types, match.Type)
 			}
+			byType[match.Type] = append(byType[match.Type], match)
+		}
 
-			if hasSecret {
-				flow.Protection = []string{"none"}
-			} else {
-				flow.Protection = []string{"unknown"}
-			}
+		if result.PIIByProvider == nil {
+			result.PIIByProvider = make(map[string]map[string]int)
+		}
+		if result.PIIByProvider[prompt.Provider] == nil {
+			result.PIIByProvider[prompt.Provider] = make(map[string]int)
+		}
+		for _, piiType := range types {
+			result.PIIByProvider[prompt.Provider][piiType] += len(byType[piiType])
+			result.Findings = append(result.Findings, m.piiFinding(prompt, byType[piiType]))
+		}
+	}
+}
 
-			result.DataFlows = append(result.DataFlows, flow)
+// piiFinding describes PII of one type in a prompt template.
+func (m *EastModule) piiFinding(prompt promptTemplate, matches []security.PIIMatch) Finding {
+	// Checksummed identifiers are rarely coincidences
+	confidence := "medium"
+	for _, match := range matches {
+		if match.Validated {
+			confidence = "high"
+			break
 		}
 	}
+
+	evidence := make([]string, 0, len(matches))
+	for i, match := range matches {
+		if i == 3 {
+			evidence = append(evidence, fmt.Sprintf("(+%d more)", len(matches)-i))
+			break
+		}
+		evidence = append(evidence, match.String())
+	}
+
+	return Finding{
+		Type:        "pii_exposure",
+		Category:    matches[0].Type,
+		Location:    fmt.Sprintf("%s:%d", prompt.File, prompt.Line),
+		Confidence:  confidence,
+		Severity:    m.determineSeverity("pii_exposure", matches[0].Type),
+		Evidence:    fmt.Sprintf("%s → llm:%s", strings.Join(evidence, ", "), prompt.Provider),
+		Impact:      "Personal or regulated data in the prompt is disclosed to the model provider on every call",
+		Remediation: "Remove personal data from prompt templates; pseudonymize or tokenize values before sending them to the provider",
+		References:  m.getReferences("pii_exposure"),
+		Regulations: security.PIIRegulations(matches),
+	}
 }
 
 // extractServices identifies external services from findings.
@@ -523,7 +622,7 @@ func (m *EastModule) calculateDataFlowSummary(result *DataFlowResult) DataFlowSu
 		switch finding.Type {
 		case "hardcoded_secret":
 			summary.PotentialSecrets++
-		case "information_disclosure", "misconfiguration":
+		case "information_disclosure", "misconfiguration", "data_flow", "pii_exposure":
 			summary.LeakPoints++
 		}
 	}
@@ -573,6 +672,28 @@ func (m *EastModule) determineSeverity(findingType, category string) string {
 		"auth_token":   true,
 	}
 
+	if findingType == "pii_exposure" {
+		return security.PIISeverity(category)
+	}
+
+	// Data flows are rated by what reaches which sink
+	if findingType == "data_flow" {
+		dataFlowSeverities := map[string]string{
+			"credential_to_llm":     "high",
+			"credential_to_http":    "high",
+			"credential_to_log":     "high",
+			"user_input_to_llm":     "medium",
+			"user_input_to_http":    "medium",
+			"user_input_to_log":     "medium",
+			"file_contents_to_llm":  "medium",
+			"file_contents_to_http": "medium",
+		}
+		if severity, ok := dataFlowSeverities[category]; ok {
+			return severity
+		}
+		return "low"
+	}
+
 	// Check for critical
 	if criticalPatterns[category] {
 		return "critical"
@@ -0,0 +1,811 @@
+package probe
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Data flow source and sink kinds.
+const (
+	flowSourceEnv         = "env"
+	flowSourceRequestBody = "request_body"
+	flowSourceFileRead    = "file_read"
+
+	flowSinkLLM  = "llm"
+	flowSinkHTTP = "http"
+	flowSinkLog  = "log"
+)
+
+// maxTaintsPerValue bounds how many distinct sources one variable tracks.
+const maxTaintsPerValue = 8
+
+var (
+	sensitiveEnvName = regexp.MustCompile(`(?i)(key|secret|token|passw|credential|auth|dsn|private)`)
+	protectingCall   = regexp.MustCompile(`(?i)(redact|mask|sanitiz|scrub|anonymi|hash|sha256|hmac)`)
+)
+
+// taint records where a value came from and the hops it has taken since.
+type taint struct {
+	kind       string // env, request_body, file_read
+	name       string // env var name or source expression
+	hops       []FlowHop
+	transforms []string
+}
+
+func newTaint(kind, name string, hop FlowHop) *taint {
+	hop.Kind = "source"
+	return &taint{kind: kind, name: name, hops: []FlowHop{hop}}
+}
+
+// key identifies the origin of a taint for de-duplication.
+func (t *taint) key() string {
+	return fmt.Sprintf("%s:%s@%s:%d", t.kind, t.name, t.hops[0].File, t.hops[0].Line)
+}
+
+// through returns a copy of the taint that has passed through transform
+// (a function call) and/or an assignment hop.
+func (t *taint) through(transform string, hop *FlowHop) *taint {
+	next := &taint{kind: t.kind, name: t.name}
+	next.hops = append([]FlowHop{}, t.hops...)
+	next.transforms = append([]string{}, t.transforms...)
+	if transform != "" && !containsString(next.transforms, transform) {
+		next.transforms = append(next.transforms, transform)
+	}
+	if hop != nil {
+		last := next.hops[len(next.hops)-1]
+		if last.Line != hop.Line || last.Detail != hop.Detail {
+			next.hops = append(next.hops, *hop)
+		}
+	}
+	return next
+}
+
+// sensitiveData classifies what kind of data the taint carries.
+func (t *taint) sensitiveData() string {
+	switch t.kind {
+	case flowSourceEnv:
+		if sensitiveEnvName.MatchString(t.name) {
+			return "credential"
+		}
+		return "configuration"
+	case flowSourceRequestBody:
+		return "user_input"
+	default:
+		return "file_contents"
+	}
+}
+
+// taintSet is every source a value may carry.
+type taintSet []*taint
+
+// union merges sets, keeping the first path seen for each origin.
+func (s taintSet) union(other taintSet) taintSet {
+	for _, t := range other {
+		if len(s) >= maxTaintsPerValue {
+			break
+		}
+		dup := false
+		for _, existing := range s {
+			if existing.key() == t.key() {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			s = append(s, t)
+		}
+	}
+	return s
+}
+
+// through applies taint.through to every member of the set.
+func (s taintSet) through(transform string, hop *FlowHop) taintSet {
+	out := make(taintSet, 0, len(s))
+	for _, t := range s {
+		out = append(out, t.through(transform, hop))
+	}
+	return out
+}
+
+// maxTextsPerValue bounds how many string literals one variable tracks.
+const maxTextsPerValue = 32
+
+// promptText is a string literal that may become part of a prompt.
+type promptText struct {
+	line int
+	text string
+}
+
+// promptTemplate is literal text that reaches an LLM call.
+type promptTemplate struct {
+	File     string
+	Line     int // of the literal
+	Provider string
+	Text     string
+}
+
+// mergeTexts appends texts not already present, up to maxTextsPerValue.
+func mergeTexts(texts []promptText, more []promptText) []promptText {
+	for _, t := range more {
+		if len(texts) >= maxTextsPerValue {
+			break
+		}
+		dup := false
+		for _, existing := range texts {
+			if existing == t {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			texts = append(texts, t)
+		}
+	}
+	return texts
+}
+
+// flowCollector turns tainted values reaching sinks into DataFlow entries
+// and records the literal text reaching LLM calls.
+type flowCollector struct {
+	file    string
+	flows   []DataFlow
+	prompts []promptTemplate
+	seen    map[string]bool
+}
+
+func newFlowCollector(file string) *flowCollector {
+	return &flowCollector{file: file, seen: make(map[string]bool)}
+}
+
+// prompt records literal text sent to an LLM provider.
+func (c *flowCollector) prompt(provider string, texts []promptText) {
+	for _, t := range texts {
+		key := fmt.Sprintf("prompt@%d:%s", t.line, provider)
+		if c.seen[key] || strings.TrimSpace(t.text) == "" {
+			continue
+		}
+		c.seen[key] = true
+		c.prompts = append(c.prompts, promptTemplate{File: c.file, Line: t.line, Provider: provider, Text: t.text})
+	}
+}
+
+// sink records a flow for each taint reaching a sink call.
+func (c *flowCollector) sink(taints taintSet, kind, label string, line int, detail string) {
+	for _, t := range taints {
+		destination := kind + ":" + label
+		key := fmt.Sprintf("%s->%s@%d", t.key(), destination, line)
+		if c.seen[key] {
+			continue
+		}
+		c.seen[key] = true
+
+		protection := []string{"none"}
+		for _, transform := range t.transforms {
+			if protectingCall.MatchString(transform) {
+				protection = []string{"redacted"}
+				break
+			}
+		}
+
+		hops := append([]FlowHop{}, t.hops...)
+		hops = append(hops, FlowHop{File: c.file, Line: line, Kind: "sink", Detail: detail})
+		c.flows = append(c.flows, DataFlow{
+			Source:          t.kind + ":" + t.name,
+			Transformations: append([]string{}, t.transforms...),
+			Destination:     destination,
+			SensitiveData:   []string{t.sensitiveData()},
+			Protection:      protection,
+			Hops:            hops,
+		})
+	}
+}
+
+// urlHost returns the host of a literal URL argument, if it is one.
+func urlHost(literal string) string {
+	u, err := url.Parse(literal)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	return u.Host
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// traceDataFlows dispatches a file to the tracer for its language. It
+// returns the source-to-sink flows and the prompt text sent to LLM calls.
+func traceDataFlows(path string, src []byte) ([]DataFlow, []promptTemplate) {
+	var c *flowCollector
+	switch filepath.Ext(path) {
+	case ".go":
+		c = traceGoFlows(path, src)
+	case ".py":
+		c = traceScriptFlows(path, src, langPython)
+	case ".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs":
+		c = traceScriptFlows(path, src, langJavaScript)
+	}
+	if c == nil {
+		return nil, nil
+	}
+	return c.flows, c.prompts
+}
+
+// Go import paths of LLM SDKs, by provider.
+var goLLMPackages = map[string]string{
+	"github.com/sashabaranov/go-openai":      "openai",
+	"github.com/openai/openai-go":            "openai",
+	"github.com/anthropics/anthropic-sdk-go": "anthropic",
+	"github.com/liushuangls/go-anthropic":    "anthropic",
+	"github.com/tmc/langchaingo":             "langchain",
+}
+
+// Go SDK methods that send a prompt to the provider.
+var goLLMMethods = map[string]bool{
+	"CreateChatCompletion":       true,
+	"CreateChatCompletionStream": true,
+	"CreateCompletion":           true,
+	"CreateCompletionStream":     true,
+	"CreateMessages":             true,
+	"CreateMessagesStream":       true,
+	"GenerateContent":            true,
+	"GenerateFromSinglePrompt":   true,
+}
+
+// Go SDK services whose New/NewStreaming methods send a prompt.
+var goLLMServices = map[string]bool{
+	"Completions": true,
+	"Messages":    true,
+	"Responses":   true,
+}
+
+var (
+	goLoggerReceiver = regexp.MustCompile(`(?i)^(log|logger|logging|slog|logrus|zap|sugar|zlog)$`)
+	goLogLevel       = regexp.MustCompile(`^(Print|Fatal|Panic|Debug|Info|Warn|Error|Trace|Log)`)
+	// Constructors that consume a credential to configure a client rather
+	// than passing it along.
+	goClientConfig = regexp.MustCompile(`^(New\w*Client|DefaultConfig|DefaultAzureConfig|With\w*(Key|Token))$`)
+)
+
+// goFlowTracer follows values through the statements of a Go file.
+type goFlowTracer struct {
+	fset      *token.FileSet
+	file      string
+	imports   map[string]string // local name -> import path
+	providers []string          // LLM providers imported by the file
+	requests  map[string]string // request parameter -> framework (http, gin)
+	texts     map[string][]promptText
+	flows     *flowCollector
+}
+
+// traceGoFlows parses a Go file and follows values from sources to sinks
+// within each function. Package-level variables are visible to all of them.
+func traceGoFlows(path string, src []byte) *flowCollector {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+
+	g := &goFlowTracer{
+		fset:    fset,
+		file:    path,
+		imports: make(map[string]string),
+		texts:   make(map[string][]promptText),
+		flows:   newFlowCollector(path),
+	}
+	for _, spec := range file.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		name := goPackageName(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		g.imports[name] = importPath
+		for prefix, provider := range goLLMPackages {
+			if strings.HasPrefix(importPath, prefix) && !containsString(g.providers, provider) {
+				g.providers = append(g.providers, provider)
+			}
+		}
+	}
+
+	globals := make(map[string]taintSet)
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && (gen.Tok == token.VAR || gen.Tok == token.CONST) {
+			g.walk(gen, globals)
+		}
+	}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		scope := make(map[string]taintSet, len(globals))
+		for name, taints := range globals {
+			scope[name] = taints
+		}
+		g.requests = g.requestParams(fn)
+		g.walk(fn.Body, scope)
+	}
+	return g.flows
+}
+
+// goPackageName guesses the package name of an import path, skipping major
+// version suffixes and go- prefixes.
+func goPackageName(importPath string) string {
+	name := path.Base(importPath)
+	if len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = path.Base(path.Dir(importPath))
+	}
+	name = strings.TrimPrefix(name, "go-")
+	name = strings.TrimSuffix(name, "-go")
+	name = strings.TrimSuffix(name, "-sdk")
+	return strings.ReplaceAll(name, "-", "")
+}
+
+// requestParams finds the request parameters of a function and the
+// closures inside it.
+func (g *goFlowTracer) requestParams(fn *ast.FuncDecl) map[string]string {
+	params := make(map[string]string)
+	collect := func(fields *ast.FieldList) {
+		if fields == nil {
+			return
+		}
+		for _, field := range fields.List {
+			framework := g.requestType(field.Type)
+			if framework == "" {
+				continue
+			}
+			for _, name := range field.Names {
+				params[name.Name] = framework
+			}
+		}
+	}
+
+	collect(fn.Type.Params)
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.FuncLit); ok {
+			collect(lit.Type.Params)
+		}
+		return true
+	})
+	return params
+}
+
+// requestType reports whether a parameter type is an incoming request.
+func (g *goFlowTracer) requestType(expr ast.Expr) string {
+	star, ok := expr.(*ast.StarExpr)
+	if !ok {
+		return ""
+	}
+	sel, ok := star.X.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return ""
+	}
+	switch {
+	case g.imports[pkg.Name] == "net/http" && sel.Sel.Name == "Request":
+		return "http"
+	case g.imports[pkg.Name] == "github.com/gin-gonic/gin" && sel.Sel.Name == "Context":
+		return "gin"
+	}
+	return ""
+}
+
+// walk visits statements in source order, propagating taint through
+// assignments and reporting tainted arguments of sink calls.
+func (g *goFlowTracer) walk(root ast.Node, scope map[string]taintSet) {
+	ast.Inspect(root, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.AssignStmt:
+			g.assign(node.Lhs, node.Rhs, scope, node.Tok == token.DEFINE || node.Tok == token.ASSIGN)
+		case *ast.ValueSpec:
+			lhs := make([]ast.Expr, len(node.Names))
+			for i, name := range node.Names {
+				lhs[i] = name
+			}
+			g.assign(lhs, node.Values, scope, true)
+		case *ast.RangeStmt:
+			if taints := g.taintOf(node.X, scope); len(taints) > 0 {
+				g.assign([]ast.Expr{node.Key, node.Value}, nil, scope, false, taints...)
+			}
+		case *ast.CallExpr:
+			g.call(node, scope)
+		}
+		return true
+	})
+}
+
+// assign taints the left-hand side of an assignment. With one value per
+// name each name gets its own value's taint; a multi-value call taints
+// every name. Compound assignments (+=) keep the existing taint.
+func (g *goFlowTracer) assign(lhs, rhs []ast.Expr, scope map[string]taintSet, replace bool, extra ...*taint) {
+	for i, target := range lhs {
+		name := goRootName(target)
+		if name == "" || name == "_" || name == "err" {
+			continue
+		}
+
+		var taints taintSet
+		var value ast.Expr
+		switch {
+		case len(extra) > 0:
+			taints = extra
+		case len(rhs) == len(lhs):
+			value = rhs[i]
+		case len(rhs) == 1:
+			value = rhs[0]
+		}
+		if value != nil {
+			taints = g.taintOf(value, scope)
+			if texts := g.textsOf(value); len(texts) > 0 {
+				if replace {
+					g.texts[name] = nil
+				}
+				g.texts[name] = mergeTexts(g.texts[name], texts)
+			}
+		}
+
+		if !replace {
+			taints = scope[name].union(taints)
+		} else if _, isIdent := target.(*ast.Ident); !isIdent {
+			// Writing a field or element taints the whole value
+			taints = scope[name].union(taints)
+		}
+		if len(taints) == 0 {
+			if replace {
+				delete(scope, name)
+			}
+			continue
+		}
+		hop := FlowHop{File: g.file, Line: g.line(target), Kind: "assign", Detail: name}
+		scope[name] = taints.through("", &hop)
+	}
+}
+
+// taintOf returns the sources that may flow into an expression's value.
+func (g *goFlowTracer) taintOf(expr ast.Expr, scope map[string]taintSet) taintSet {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return scope[e.Name]
+	case *ast.SelectorExpr:
+		if t := g.source(e); t != nil {
+			return taintSet{t}
+		}
+		return g.taintOf(e.X, scope)
+	case *ast.CallExpr:
+		if t := g.source(e); t != nil {
+			return taintSet{t}
+		}
+		callee := goExprName(e.Fun)
+		if goClientConfig.MatchString(goLastName(callee)) {
+			return nil
+		}
+		var taints taintSet
+		if sel, ok := e.Fun.(*ast.SelectorExpr); ok && !g.isPackage(sel.X) {
+			taints = taints.union(g.taintOf(sel.X, scope))
+		}
+		for _, arg := range e.Args {
+			taints = taints.union(g.taintOf(arg, scope))
+		}
+		return taints.through(callee, nil)
+	case *ast.BinaryExpr:
+		return g.taintOf(e.X, scope).union(g.taintOf(e.Y, scope))
+	case *ast.CompositeLit:
+		var taints taintSet
+		for _, elt := range e.Elts {
+			taints = taints.union(g.taintOf(elt, scope))
+		}
+		return taints
+	case *ast.KeyValueExpr:
+		return g.taintOf(e.Value, scope)
+	case *ast.UnaryExpr:
+		return g.taintOf(e.X, scope)
+	case *ast.StarExpr:
+		return g.taintOf(e.X, scope)
+	case *ast.ParenExpr:
+		return g.taintOf(e.X, scope)
+	case *ast.IndexExpr:
+		return g.taintOf(e.X, scope)
+	case *ast.SliceExpr:
+		return g.taintOf(e.X, scope)
+	case *ast.TypeAssertExpr:
+		return g.taintOf(e.X, scope)
+	}
+	return nil
+}
+
+// source recognizes expressions that introduce sensitive data.
+func (g *goFlowTracer) source(expr ast.Expr) *taint {
+	hop := FlowHop{File: g.file, Line: g.line(expr), Detail: goExprName(expr)}
+
+	switch e := expr.(type) {
+	case *ast.SelectorExpr:
+		if recv, ok := e.X.(*ast.Ident); ok && g.requests[recv.Name] == "http" {
+			switch e.Sel.Name {
+			case "Body", "Form", "PostForm", "MultipartForm":
+				return newTaint(flowSourceRequestBody, hop.Detail, hop)
+			}
+		}
+	case *ast.CallExpr:
+		sel, ok := e.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return nil
+		}
+		recv, ok := sel.X.(*ast.Ident)
+		if !ok {
+			return nil
+		}
+		method := sel.Sel.Name
+		switch g.requests[recv.Name] {
+		case "http":
+			if method == "FormValue" || method == "PostFormValue" || method == "FormFile" {
+				return newTaint(flowSourceRequestBody, recv.Name+"."+method, hop)
+			}
+		case "gin":
+			if strings.HasPrefix(method, "Bind") || strings.HasPrefix(method, "ShouldBind") ||
+				method == "GetRawData" || method == "PostForm" || method == "FormFile" {
+				return newTaint(flowSourceRequestBody, recv.Name+"."+method, hop)
+			}
+		}
+
+		switch g.imports[recv.Name] {
+		case "os":
+			switch method {
+			case "Getenv", "LookupEnv":
+				name := "?"
+				if len(e.Args) == 1 {
+					if lit, ok := e.Args[0].(*ast.BasicLit); ok {
+						name, _ = strconv.Unquote(lit.Value)
+					}
+				}
+				hop.Detail = fmt.Sprintf("os.%s(%q)", method, name)
+				return newTaint(flowSourceEnv, name, hop)
+			case "ReadFile", "Open", "OpenFile":
+				return newTaint(flowSourceFileRead, "os."+method, hop)
+			}
+		case "io/ioutil":
+			if method == "ReadFile" {
+				return newTaint(flowSourceFileRead, "ioutil.ReadFile", hop)
+			}
+		}
+	}
+	return nil
+}
+
+// call handles a call expression: out-parameters (&v) of a call receiving
+// tainted data become tainted, and tainted arguments of sinks are reported.
+func (g *goFlowTracer) call(call *ast.CallExpr, scope map[string]taintSet) {
+	var inputs taintSet
+	if t := g.source(call); t != nil {
+		inputs = taintSet{t}
+	} else if sel, ok := call.Fun.(*ast.SelectorExpr); ok && !g.isPackage(sel.X) {
+		inputs = g.taintOf(sel.X, scope)
+	}
+	for _, arg := range call.Args {
+		if unary, ok := arg.(*ast.UnaryExpr); !ok || unary.Op != token.AND {
+			inputs = inputs.union(g.taintOf(arg, scope))
+		}
+	}
+	if len(inputs) > 0 {
+		callee := goExprName(call.Fun)
+		for _, arg := range call.Args {
+			unary, ok := arg.(*ast.UnaryExpr)
+			if !ok || unary.Op != token.AND {
+				continue
+			}
+			if name := goRootName(unary.X); name != "" && name != "_" {
+				hop := FlowHop{File: g.file, Line: g.line(arg), Kind: "assign", Detail: name}
+				scope[name] = scope[name].union(inputs.through(callee, &hop))
+			}
+		}
+	}
+
+	kind, label, args := g.sink(call)
+	if kind == "" {
+		return
+	}
+	var taints taintSet
+	for _, arg := range args {
+		taints = taints.union(g.taintOf(arg, scope))
+		if kind == flowSinkLLM {
+			g.flows.prompt(label, g.textsOf(arg))
+		}
+	}
+	g.flows.sink(taints, kind, label, g.line(call), goExprName(call.Fun))
+}
+
+// textsOf returns the string literals that may make up an expression's
+// value: literals in it and those previously assigned to names it uses.
+func (g *goFlowTracer) textsOf(expr ast.Expr) []promptText {
+	var texts []promptText
+	ast.Inspect(expr, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.BasicLit:
+			if node.Kind == token.STRING {
+				if text, err := strconv.Unquote(node.Value); err == nil {
+					texts = mergeTexts(texts, []promptText{{line: g.line(node), text: text}})
+				}
+			}
+		case *ast.Ident:
+			texts = mergeTexts(texts, g.texts[node.Name])
+		}
+		return true
+	})
+	return texts
+}
+
+// sink classifies a call as an LLM, HTTP or logging sink and returns the
+// arguments that reach it.
+func (g *goFlowTracer) sink(call *ast.CallExpr) (kind, label string, args []ast.Expr) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return "", "", nil
+	}
+	method := sel.Sel.Name
+	callee := goExprName(call.Fun)
+
+	if recv, ok := sel.X.(*ast.Ident); ok && g.isPackage(recv) {
+		switch g.imports[recv.Name] {
+		case "net/http":
+			switch method {
+			case "Post", "PostForm", "Get", "Head", "NewRequest", "NewRequestWithContext":
+				return flowSinkHTTP, g.httpLabel(call, callee), call.Args
+			}
+		case "log":
+			if goLogLevel.MatchString(method) {
+				return flowSinkLog, callee, call.Args
+			}
+		case "log/slog", "github.com/sirupsen/logrus":
+			if goLogLevel.MatchString(method) {
+				return flowSinkLog, callee, call.Args
+			}
+		case "fmt":
+			switch method {
+			case "Print", "Printf", "Println":
+				return flowSinkLog, callee, call.Args
+			case "Fprint", "Fprintf", "Fprintln":
+				if len(call.Args) > 0 {
+					if dest := goExprName(call.Args[0]); dest == "os.Stdout" || dest == "os.Stderr" {
+						return flowSinkLog, callee, call.Args[1:]
+					}
+				}
+			}
+		}
+		return "", "", nil
+	}
+
+	if len(g.providers) > 0 {
+		service := ""
+		if inner, ok := sel.X.(*ast.SelectorExpr); ok {
+			service = inner.Sel.Name
+		}
+		if goLLMMethods[method] || (goLLMServices[service] && (method == "New" || method == "NewStreaming")) {
+			return flowSinkLLM, g.llmProvider(method, service), call.Args
+		}
+	}
+
+	if g.importsHTTP() && (method == "Post" || method == "PostForm") {
+		return flowSinkHTTP, g.httpLabel(call, callee), call.Args
+	}
+
+	if recv := goRootName(sel.X); goLoggerReceiver.MatchString(recv) && goLogLevel.MatchString(method) {
+		return flowSinkLog, callee, call.Args
+	}
+	return "", "", nil
+}
+
+// llmProvider picks the provider an SDK call goes to when a file imports
+// more than one SDK.
+func (g *goFlowTracer) llmProvider(method, service string) string {
+	if len(g.providers) == 1 {
+		return g.providers[0]
+	}
+	if strings.Contains(method, "Messages") || service == "Messages" {
+		if containsString(g.providers, "anthropic") {
+			return "anthropic"
+		}
+	}
+	if containsString(g.providers, "openai") {
+		return "openai"
+	}
+	return g.providers[0]
+}
+
+// httpLabel names an HTTP sink by the host of a literal URL argument,
+// falling back to the callee.
+func (g *goFlowTracer) httpLabel(call *ast.CallExpr, callee string) string {
+	for _, arg := range call.Args {
+		if lit, ok := arg.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+			value, _ := strconv.Unquote(lit.Value)
+			if host := urlHost(value); host != "" {
+				return host
+			}
+		}
+	}
+	return callee
+}
+
+func (g *goFlowTracer) importsHTTP() bool {
+	for _, importPath := range g.imports {
+		if importPath == "net/http" {
+			return true
+		}
+	}
+	return false
+}
+
+// isPackage reports whether an expression names an imported package.
+func (g *goFlowTracer) isPackage(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	_, imported := g.imports[ident.Name]
+	return imported
+}
+
+func (g *goFlowTracer) line(node ast.Node) int {
+	return g.fset.Position(node.Pos()).Line
+}
+
+// goRootName returns the variable an lvalue expression writes to.
+func goRootName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.SelectorExpr:
+		return goRootName(e.X)
+	case *ast.IndexExpr:
+		return goRootName(e.X)
+	case *ast.StarExpr:
+		return goRootName(e.X)
+	case *ast.ParenExpr:
+		return goRootName(e.X)
+	}
+	return ""
+}
+
+// goExprName renders a callee or operand as dotted text, e.g.
+// "client.Chat.Completions.New" or "json.NewDecoder().Decode".
+func goExprName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.SelectorExpr:
+		return goExprName(e.X) + "." + e.Sel.Name
+	case *ast.CallExpr:
+		return goExprName(e.Fun) + "()"
+	case *ast.IndexExpr:
+		return goExprName(e.X) + "[]"
+	case *ast.StarExpr:
+		return goExprName(e.X)
+	case *ast.ParenExpr:
+		return goExprName(e.X)
+	}
+	return "?"
+}
+
+func goLastName(dotted string) string {
+	if i := strings.LastIndex(dotted, "."); i >= 0 {
+		return dotted[i+1:]
+	}
+	return dotted
+}
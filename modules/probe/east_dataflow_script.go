@@ -0,0 +1,621 @@
+package probe
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Script languages handled by the tokenizer.
+const (
+	langPython     = "python"
+	langJavaScript = "javascript"
+)
+
+type scriptTokenKind int
+
+const (
+	tokIdent scriptTokenKind = iota
+	tokString
+	tokNumber
+	tokPunct
+	tokNewline
+)
+
+// scriptToken is a lexical token of Python or JavaScript source.
+type scriptToken struct {
+	kind scriptTokenKind
+	text string
+	line int
+	refs []string // names interpolated into f-strings and template literals
+}
+
+// Operators recognized as single tokens, longest first.
+var scriptOperators = []string{
+	"===", "!==", "**=", "...", "?.", "??", "==", "!=", "<=", ">=", "=>", "->",
+	"+=", "-=", "*=", "/=", "%=", "|=", "&=", ":=", "**", "&&", "||", "++", "--",
+}
+
+var interpolatedName = regexp.MustCompile(`[A-Za-z_$][\w$]*(?:\.[A-Za-z_$][\w$]*)*`)
+
+// tokenizeScript splits Python or JavaScript source into tokens. Comments
+// are dropped; strings become single tokens that remember the names they
+// interpolate. It is deliberately forgiving: anything unrecognized becomes
+// a one-character punctuation token.
+func tokenizeScript(src []byte, lang string) []scriptToken {
+	s := string(src)
+	var tokens []scriptToken
+	line := 1
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\n':
+			tokens = append(tokens, scriptToken{kind: tokNewline, text: "\n", line: line})
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '\\' && i+1 < len(s) && s[i+1] == '\n':
+			// Explicit line continuation
+			line++
+			i += 2
+		case c == '#' && lang == langPython:
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case c == '/' && lang == langJavaScript && strings.HasPrefix(s[i:], "//"):
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case c == '/' && lang == langJavaScript && strings.HasPrefix(s[i:], "/*"):
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				end = len(s) - i - 2
+			}
+			line += strings.Count(s[i:i+2+end], "\n")
+			i += end + 4
+		case c == '"' || c == '\'' || (c == '`' && lang == langJavaScript):
+			tok, next := scanScriptString(s, i, "", lang)
+			tok.line = line
+			line += strings.Count(s[i:next], "\n")
+			tokens = append(tokens, tok)
+			i = next
+		case isScriptIdentStart(c):
+			start := i
+			for i < len(s) && isScriptIdentPart(s[i]) {
+				i++
+			}
+			word := s[start:i]
+			if lang == langPython && i < len(s) && (s[i] == '"' || s[i] == '\'') && isPythonStringPrefix(word) {
+				tok, next := scanScriptString(s, i, strings.ToLower(word), lang)
+				tok.line = line
+				line += strings.Count(s[i:next], "\n")
+				tokens = append(tokens, tok)
+				i = next
+				continue
+			}
+			tokens = append(tokens, scriptToken{kind: tokIdent, text: word, line: line})
+		case c >= '0' && c <= '9':
+			start := i
+			for i < len(s) && (isScriptIdentPart(s[i]) || s[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, scriptToken{kind: tokNumber, text: s[start:i], line: line})
+		default:
+			op := string(c)
+			for _, candidate := range scriptOperators {
+				if strings.HasPrefix(s[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			tokens = append(tokens, scriptToken{kind: tokPunct, text: op, line: line})
+			i += len(op)
+		}
+	}
+	return tokens
+}
+
+// scanScriptString reads a string literal starting at the quote s[i] and
+// returns its token and the index after it. Python f-strings and JS template
+// literals record the names used in their interpolations.
+func scanScriptString(s string, i int, prefix, lang string) (scriptToken, int) {
+	quote := s[i : i+1]
+	if lang == langPython && (strings.HasPrefix(s[i:], `"""`) || strings.HasPrefix(s[i:], `'''`)) {
+		quote = s[i : i+3]
+	}
+	start := i + len(quote)
+	raw := strings.Contains(prefix, "r")
+
+	j := start
+	for j < len(s) {
+		if s[j] == '\\' && !raw {
+			j += 2
+			continue
+		}
+		if strings.HasPrefix(s[j:], quote) {
+			break
+		}
+		if s[j] == '\n' && len(quote) == 1 && quote != "`" {
+			break // unterminated single-line string
+		}
+		j++
+	}
+	if j > len(s) {
+		j = len(s)
+	}
+	body := s[start:j]
+	next := j + len(quote)
+	if next > len(s) {
+		next = len(s)
+	}
+
+	tok := scriptToken{kind: tokString, text: body}
+	switch {
+	case quote == "`":
+		tok.refs = interpolatedNames(body, "${")
+	case strings.Contains(prefix, "f"):
+		tok.refs = interpolatedNames(body, "{")
+	}
+	return tok, next
+}
+
+// interpolatedNames returns the dotted names used inside interpolations
+// opened by open and closed by "}".
+func interpolatedNames(body, open string) []string {
+	var names []string
+	for {
+		start := strings.Index(body, open)
+		if start < 0 {
+			return names
+		}
+		body = body[start+len(open):]
+		end := strings.Index(body, "}")
+		if end < 0 {
+			end = len(body)
+		}
+		names = append(names, interpolatedName.FindAllString(body[:end], -1)...)
+		body = body[end:]
+	}
+}
+
+func isScriptIdentStart(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func isScriptIdentPart(c byte) bool {
+	return isScriptIdentStart(c) || (c >= '0' && c <= '9')
+}
+
+func isPythonStringPrefix(word string) bool {
+	switch strings.ToLower(word) {
+	case "f", "r", "b", "u", "rf", "fr", "rb", "br":
+		return true
+	}
+	return false
+}
+
+// scriptChain is a dotted name such as "client.chat.completions.create".
+type scriptChain struct {
+	name string
+	end  int // index after the last token
+	line int
+}
+
+var (
+	// Request objects whose body fields carry user input.
+	scriptRequestRoots  = map[string]bool{"request": true, "req": true, "ctx.request": true}
+	scriptRequestFields = map[string]bool{
+		"body": true, "json": true, "get_json": true, "form": true, "data": true,
+		"values": true, "files": true, "stream": true, "formData": true, "text": true,
+	}
+	scriptLLMSinks  = regexp.MustCompile(`(^|\.)(chat\.completions\.(create|parse|stream)|completions\.create|messages\.(create|stream)|responses\.create|ChatCompletion\.create|Completion\.create)$`)
+	scriptHTTPSinks = regexp.MustCompile(`^((requests|httpx|session|aiohttp|axios|got|superagent)\.(post|put|patch|get|request|delete)|urllib\.request\.urlopen|urlopen|fetch|axios|https?\.request)$`)
+	scriptLogSinks  = regexp.MustCompile(`^(print|console\.(log|info|warn|error|debug|trace)|((self\.)?(logging|logger|log|_logger|_log))\.(debug|info|warning|warn|error|exception|critical|fatal|log|trace))$`)
+	// Client constructors that consume a credential rather than passing it along.
+	scriptClientConfig = regexp.MustCompile(`(^|\.)(OpenAI|AsyncOpenAI|AzureOpenAI|AsyncAzureOpenAI|Anthropic|AsyncAnthropic|ChatOpenAI|ChatAnthropic)$`)
+	scriptKeywords     = map[string]bool{
+		"const": true, "let": true, "var": true, "await": true, "new": true, "return": true,
+		"global": true, "nonlocal": true, "export": true, "yield": true, "async": true,
+	}
+)
+
+// scriptFlowTracer follows values through a Python or JavaScript file. The
+// analysis is flow-ordered but not scope-aware: one name table covers the
+// whole file.
+type scriptFlowTracer struct {
+	file   string
+	lang   string
+	tokens []scriptToken
+	scope  map[string]taintSet
+	texts  map[string][]promptText
+	flows  *flowCollector
+}
+
+// traceScriptFlows tokenizes a Python or JavaScript file and follows values
+// from sources to sinks.
+func traceScriptFlows(path string, src []byte, lang string) *flowCollector {
+	t := &scriptFlowTracer{
+		file:   path,
+		lang:   lang,
+		tokens: tokenizeScript(src, lang),
+		scope:  make(map[string]taintSet),
+		texts:  make(map[string][]promptText),
+		flows:  newFlowCollector(path),
+	}
+
+	// Bracket depth per brace frame: a line break inside call arguments
+	// does not start a statement, but one inside a callback body does.
+	depth := []int{0}
+	for i, tok := range t.tokens {
+		if depth[len(depth)-1] == 0 && t.statementStart(i) {
+			t.statement(i)
+		}
+		if chain, ok := t.chainAt(i); ok && t.isCall(chain.end) {
+			t.call(chain)
+		}
+
+		if tok.kind != tokPunct {
+			continue
+		}
+		switch tok.text {
+		case "(", "[":
+			depth[len(depth)-1]++
+		case ")", "]":
+			if depth[len(depth)-1] > 0 {
+				depth[len(depth)-1]--
+			}
+		case "{":
+			depth = append(depth, 0)
+		case "}":
+			if len(depth) > 1 {
+				depth = depth[:len(depth)-1]
+			}
+		}
+	}
+	return t.flows
+}
+
+// statementStart reports whether token i begins a statement.
+func (t *scriptFlowTracer) statementStart(i int) bool {
+	if i == 0 {
+		return true
+	}
+	switch prev := t.tokens[i-1]; {
+	case prev.kind == tokNewline:
+		return true
+	case prev.kind == tokPunct && (prev.text == ";" || prev.text == "{" || prev.text == "}" || prev.text == ":"):
+		return true
+	}
+	return false
+}
+
+// statementEnd returns the index of the newline or ";" ending the statement
+// that starts at i, ignoring line breaks inside brackets.
+func (t *scriptFlowTracer) statementEnd(i int) int {
+	depth := 0
+	for j := i; j < len(t.tokens); j++ {
+		tok := t.tokens[j]
+		switch {
+		case tok.kind == tokPunct && (tok.text == "(" || tok.text == "[" || tok.text == "{"):
+			depth++
+		case tok.kind == tokPunct && (tok.text == ")" || tok.text == "]" || tok.text == "}"):
+			depth--
+			if depth < 0 {
+				return j
+			}
+		case depth == 0 && (tok.kind == tokNewline || tok.text == ";"):
+			return j
+		}
+	}
+	return len(t.tokens)
+}
+
+// statement handles assignments, Python with/for and JS for-of headers.
+func (t *scriptFlowTracer) statement(i int) {
+	end := t.statementEnd(i)
+	if i >= end {
+		return
+	}
+
+	first := t.tokens[i].text
+	switch {
+	case first == "with" && t.lang == langPython:
+		// with open(path) as f:
+		if as := t.find(i, end, "as"); as > 0 {
+			t.bind(t.names(as+1, end), t.taintOf(i+1, as), t.tokens[as].line)
+		}
+		return
+	case first == "for":
+		// for x in xs: / for (const x of xs)
+		keyword := "in"
+		if t.lang == langJavaScript && t.find(i, end, "of") > 0 {
+			keyword = "of"
+		}
+		if in := t.find(i, end, keyword); in > 0 {
+			t.bind(t.names(i+1, in), t.taintOf(in+1, end), t.tokens[in].line)
+		}
+		return
+	}
+
+	// Find "=" (or a compound assignment) at bracket depth zero
+	depth := 0
+	for j := i; j < end && j < i+64; j++ {
+		tok := t.tokens[j]
+		if tok.kind != tokPunct {
+			continue
+		}
+		switch tok.text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+		case "=", ":=", "+=", "-=", "|=":
+			if depth != 0 {
+				continue
+			}
+			names := t.names(i, j)
+			taints := t.taintOf(j+1, end)
+			replace := tok.text == "=" || tok.text == ":="
+			if !replace {
+				for _, name := range names {
+					taints = taints.union(t.scope[name])
+				}
+			}
+			t.bind(names, taints, tok.line)
+			if texts := t.textsOf(j+1, end); len(texts) > 0 {
+				for _, name := range names {
+					if replace {
+						t.texts[name] = nil
+					}
+					t.texts[name] = mergeTexts(t.texts[name], texts)
+				}
+			}
+			return
+		case "==", "===", "!=", "!==", "=>":
+			return
+		}
+	}
+}
+
+// bind assigns taint to names, clearing names assigned untainted values.
+func (t *scriptFlowTracer) bind(names []string, taints taintSet, line int) {
+	for _, name := range names {
+		if len(taints) == 0 {
+			delete(t.scope, name)
+			continue
+		}
+		hop := FlowHop{File: t.file, Line: line, Kind: "assign", Detail: name}
+		t.scope[name] = taints.through("", &hop)
+	}
+}
+
+// names returns the assignable names in tokens [i, end): plain and dotted
+// names, destructuring patterns and tuples, skipping keywords.
+func (t *scriptFlowTracer) names(i, end int) []string {
+	var names []string
+	for j := i; j < end; j++ {
+		if chain, ok := t.chainAt(j); ok {
+			if !scriptKeywords[chain.name] && chain.name != "self" && chain.name != "this" {
+				names = append(names, chain.name)
+			}
+			j = chain.end - 1
+		}
+	}
+	return names
+}
+
+// find returns the index of the identifier word in [i, end), or -1.
+func (t *scriptFlowTracer) find(i, end int, word string) int {
+	for j := i; j < end; j++ {
+		if t.tokens[j].kind == tokIdent && t.tokens[j].text == word {
+			return j
+		}
+	}
+	return -1
+}
+
+// chainAt parses a dotted name starting at token i. It only starts a chain
+// at a name not itself preceded by a dot.
+func (t *scriptFlowTracer) chainAt(i int) (scriptChain, bool) {
+	if t.tokens[i].kind != tokIdent {
+		return scriptChain{}, false
+	}
+	if i > 0 && t.tokens[i-1].kind == tokPunct && (t.tokens[i-1].text == "." || t.tokens[i-1].text == "?.") {
+		return scriptChain{}, false
+	}
+
+	parts := []string{t.tokens[i].text}
+	j := i + 1
+	for j+1 < len(t.tokens) && t.tokens[j].kind == tokPunct && (t.tokens[j].text == "." || t.tokens[j].text == "?.") &&
+		t.tokens[j+1].kind == tokIdent {
+		parts = append(parts, t.tokens[j+1].text)
+		j += 2
+	}
+	return scriptChain{name: strings.Join(parts, "."), end: j, line: t.tokens[i].line}, true
+}
+
+func (t *scriptFlowTracer) isCall(i int) bool {
+	return i < len(t.tokens) && t.tokens[i].kind == tokPunct && t.tokens[i].text == "("
+}
+
+// closing returns the index of the bracket closing the one at i.
+func (t *scriptFlowTracer) closing(i int) int {
+	depth := 0
+	for j := i; j < len(t.tokens); j++ {
+		if t.tokens[j].kind != tokPunct {
+			continue
+		}
+		switch t.tokens[j].text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return len(t.tokens)
+}
+
+// taintOf returns the sources that may flow into the expression in tokens
+// [i, end). A credential handed to a client constructor is not propagated.
+func (t *scriptFlowTracer) taintOf(i, end int) taintSet {
+	var taints taintSet
+	transform := ""
+	for j := i; j < end; j++ {
+		tok := t.tokens[j]
+		if tok.kind == tokString {
+			for _, ref := range tok.refs {
+				taints = taints.union(t.lookup(ref))
+			}
+			continue
+		}
+		chain, ok := t.chainAt(j)
+		if !ok {
+			continue
+		}
+		call := t.isCall(chain.end)
+		if call && scriptClientConfig.MatchString(chain.name) {
+			j = t.closing(chain.end)
+			continue
+		}
+		if src := t.source(chain); src != nil {
+			taints = taints.union(taintSet{src})
+		} else {
+			taints = taints.union(t.lookup(chain.name))
+			if call && transform == "" && !scriptKeywords[chain.name] {
+				transform = chain.name
+			}
+		}
+		j = chain.end - 1
+	}
+	if transform != "" {
+		taints = taints.through(transform, nil)
+	}
+	return taints
+}
+
+// lookup returns the taint of a name or of any object it is a member of.
+func (t *scriptFlowTracer) lookup(name string) taintSet {
+	for {
+		if taints, ok := t.scope[name]; ok {
+			return taints
+		}
+		i := strings.LastIndex(name, ".")
+		if i < 0 {
+			return nil
+		}
+		name = name[:i]
+	}
+}
+
+// source recognizes environment, request body and file reads.
+func (t *scriptFlowTracer) source(chain scriptChain) *taint {
+	hop := FlowHop{File: t.file, Line: chain.line, Detail: chain.name}
+	name := chain.name
+
+	// Environment variables
+	for _, prefix := range []string{"process.env", "os.environ", "os.getenv", "os.environ.get"} {
+		if name != prefix && !strings.HasPrefix(name, prefix+".") {
+			continue
+		}
+		envName := strings.SplitN(strings.TrimPrefix(strings.TrimPrefix(name, prefix), "."), ".", 2)[0]
+		if envName == "get" {
+			envName = ""
+		}
+		if envName == "" {
+			envName = t.firstString(chain.end)
+		}
+		if envName == "" {
+			envName = "?"
+		}
+		hop.Detail = prefix + " " + envName
+		return newTaint(flowSourceEnv, envName, hop)
+	}
+
+	// Request bodies
+	for root := range scriptRequestRoots {
+		if field, ok := strings.CutPrefix(name, root+"."); ok {
+			if scriptRequestFields[strings.SplitN(field, ".", 2)[0]] {
+				return newTaint(flowSourceRequestBody, name, hop)
+			}
+		}
+	}
+
+	// File reads
+	if t.isCall(chain.end) {
+		switch last := goLastName(name); {
+		case t.lang == langPython && (name == "open" || name == "io.open" || name == "codecs.open"):
+			return newTaint(flowSourceFileRead, name, hop)
+		case last == "read_text" || last == "read_bytes":
+			return newTaint(flowSourceFileRead, name, hop)
+		case t.lang == langJavaScript && (last == "readFileSync" || last == "readFile"):
+			return newTaint(flowSourceFileRead, name, hop)
+		}
+	}
+	return nil
+}
+
+// firstString returns the first string literal at or just inside token i,
+// such as the key in os.environ["KEY"] or os.getenv("KEY").
+func (t *scriptFlowTracer) firstString(i int) string {
+	if i+1 < len(t.tokens) && t.tokens[i].kind == tokPunct && (t.tokens[i].text == "(" || t.tokens[i].text == "[") &&
+		t.tokens[i+1].kind == tokString {
+		return t.tokens[i+1].text
+	}
+	return ""
+}
+
+// call reports tainted arguments of LLM, HTTP and logging calls.
+func (t *scriptFlowTracer) call(chain scriptChain) {
+	kind, label := "", chain.name
+	switch {
+	case scriptLLMSinks.MatchString(chain.name):
+		kind, label = flowSinkLLM, "openai"
+		if strings.Contains(chain.name, "messages.") {
+			label = "anthropic"
+		}
+	case scriptHTTPSinks.MatchString(chain.name):
+		kind = flowSinkHTTP
+	case scriptLogSinks.MatchString(chain.name):
+		kind = flowSinkLog
+	default:
+		return
+	}
+
+	args := t.closing(chain.end)
+	if kind == flowSinkHTTP {
+		if arg := chain.end + 1; arg < args && t.tokens[arg].kind == tokString {
+			if host := urlHost(t.tokens[arg].text); host != "" {
+				label = host
+			}
+		}
+	}
+	if kind == flowSinkLLM {
+		t.flows.prompt(label, t.textsOf(chain.end+1, args))
+	}
+	t.flows.sink(t.taintOf(chain.end+1, args), kind, label, chain.line, chain.name)
+}
+
+// textsOf returns the string literals that may make up the expression in
+// tokens [i, end): literals in it and those assigned to names it uses.
+func (t *scriptFlowTracer) textsOf(i, end int) []promptText {
+	var texts []promptText
+	for j := i; j < end; j++ {
+		tok := t.tokens[j]
+		if tok.kind == tokString {
+			texts = mergeTexts(texts, []promptText{{line: tok.line, text: tok.text}})
+			for _, ref := range tok.refs {
+				texts = mergeTexts(texts, t.texts[ref])
+			}
+			continue
+		}
+		if chain, ok := t.chainAt(j); ok {
+			texts = mergeTexts(texts, t.texts[chain.name])
+			j = chain.end - 1
+		}
+	}
+	return texts
+}
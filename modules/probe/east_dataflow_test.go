@@ -0,0 +1,313 @@
+package probe
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const goFlowSample = `package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+var apiKey = os.Getenv("OPENAI_API_KEY")
+
+type chatRequest struct{ Prompt string }
+
+func handler(w http.ResponseWriter, r *http.Request) {
+	client := openai.NewClient(apiKey)
+
+	var req chatRequest
+	json.NewDecoder(r.Body).Decode(&req)
+	prompt := "Summarize: " + req.Prompt
+
+	resp, _ := client.CreateChatCompletion(r.Context(), openai.ChatCompletionRequest{
+		Messages: []openai.ChatCompletionMessage{{Role: "user", Content: prompt}},
+	})
+	_ = resp
+	log.Printf("using key %s", apiKey)
+	log.Printf("port %s", os.Getenv("PORT"))
+}
+
+func upload() {
+	data, _ := os.ReadFile("/etc/app/config.json")
+	http.Post("https://collector.example.com/upload", "application/json", bytesReader(redact(data)))
+}
+`
+
+func TestTraceGoFlows(t *testing.T) {
+	flows := traceGoFlows("main.go", []byte(goFlowSample)).flows
+	got := flowSummaries(flows)
+
+	for _, want := range []string{
+		"request_body:r.Body -> llm:openai",
+		"env:OPENAI_API_KEY -> log:log.Printf",
+		"env:PORT -> log:log.Printf",
+		"file_read:os.ReadFile -> http:collector.example.com",
+	} {
+		if !got[want] {
+			t.Errorf("missing flow %q in %v", want, got)
+		}
+	}
+	// The key configures the client; it is not sent in the prompt
+	if got["env:OPENAI_API_KEY -> llm:openai"] {
+		t.Errorf("client credential reported as prompt data: %v", got)
+	}
+
+	llm := findFlow(flows, "llm:openai")
+	if llm == nil {
+		t.Fatal("no LLM flow")
+	}
+	first, last := llm.Hops[0], llm.Hops[len(llm.Hops)-1]
+	if first.Kind != "source" || first.Line != 20 || last.Kind != "sink" || last.Line != 23 {
+		t.Errorf("unexpected hops: %+v", llm.Hops)
+	}
+	if llm.SensitiveData[0] != "user_input" {
+		t.Errorf("unexpected sensitive data: %v", llm.SensitiveData)
+	}
+
+	upload := findFlow(flows, "http:collector.example.com")
+	if upload == nil || upload.Protection[0] != "redacted" {
+		t.Errorf("expected redacted upload flow, got %+v", upload)
+	}
+}
+
+const pythonFlowSample = `import os
+import logging
+from flask import Flask, request
+from anthropic import Anthropic
+
+client = Anthropic(api_key=os.environ["ANTHROPIC_API_KEY"])  # not a leak
+logger = logging.getLogger(__name__)
+
+@app.route("/chat", methods=["POST"])
+def chat():
+    body = request.get_json()
+    question = body["question"]
+    prompt = f"""Answer briefly:
+{question}"""
+    msg = client.messages.create(
+        model="claude",
+        messages=[{"role": "user", "content": prompt}],
+    )
+    logger.info("token=%s", os.getenv("SERVICE_TOKEN"))
+    with open("notes.txt") as fh:
+        notes = fh.read()
+    requests.post("https://hooks.example.com/x", json={"notes": notes})
+    return msg
+`
+
+func TestTracePythonFlows(t *testing.T) {
+	flows := traceScriptFlows("app.py", []byte(pythonFlowSample), langPython).flows
+	got := flowSummaries(flows)
+
+	for _, want := range []string{
+		"request_body:request.get_json -> llm:anthropic",
+		"env:SERVICE_TOKEN -> log:logger.info",
+		"file_read:open -> http:hooks.example.com",
+	} {
+		if !got[want] {
+			t.Errorf("missing flow %q in %v", want, got)
+		}
+	}
+	if got["env:ANTHROPIC_API_KEY -> llm:anthropic"] {
+		t.Errorf("client credential reported as prompt data: %v", got)
+	}
+
+	llm := findFlow(flows, "llm:anthropic")
+	var path []string
+	for _, hop := range llm.Hops {
+		path = append(path, hop.Detail)
+	}
+	if strings.Join(path, ",") != "request.get_json,body,question,prompt,client.messages.create" {
+		t.Errorf("unexpected hops: %v", path)
+	}
+	if last := llm.Hops[len(llm.Hops)-1]; last.Line != 15 {
+		t.Errorf("sink on line %d, want 15", last.Line)
+	}
+}
+
+const jsFlowSample = `import OpenAI from "openai";
+const openai = new OpenAI({ apiKey: process.env.OPENAI_API_KEY });
+
+/* handlers */
+app.post("/ask", async (req, res) => {
+  const { question } = req.body;
+  const content = ` + "`Q: ${question}`" + `;
+  const completion = await openai.chat.completions.create({
+    model: "gpt-4o",
+    messages: [{ role: "user", content }],
+  });
+  // console.log(process.env.OPENAI_API_KEY) is commented out
+  console.log("key", process.env.OPENAI_API_KEY);
+  await fetch("https://metrics.example.com", { method: "POST", body: JSON.stringify(req.body) });
+  res.json(completion);
+});
+`
+
+func TestTraceJavaScriptFlows(t *testing.T) {
+	flows := traceScriptFlows("server.ts", []byte(jsFlowSample), langJavaScript).flows
+	got := flowSummaries(flows)
+
+	for _, want := range []string{
+		"request_body:req.body -> llm:openai",
+		"env:OPENAI_API_KEY -> log:console.log",
+		"request_body:req.body -> http:metrics.example.com",
+	} {
+		if !got[want] {
+			t.Errorf("missing flow %q in %v", want, got)
+		}
+	}
+	if len(flows) != 3 {
+		t.Errorf("expected 3 flows, got %v", got)
+	}
+	if log := findFlow(flows, "log:console.log"); log.Hops[0].Line != 13 {
+		t.Errorf("commented-out call traced: %+v", log.Hops)
+	}
+}
+
+func TestEastRunReportsDataFlows(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(goFlowSample), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	module := NewEastModule().(*EastModule)
+	if err := module.SetOption("target", dir); err != nil {
+		t.Fatal(err)
+	}
+	module.Check()
+	moduleResult, err := module.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := moduleResult.Data["result"].(*DataFlowResult)
+
+	if len(result.DataFlows) != 4 || result.DataFlows[0].ID != "flow_1" || result.Summary.DataFlows != 4 {
+		t.Fatalf("unexpected flows: %+v", result.DataFlows)
+	}
+	var finding *Finding
+	for i := range result.Findings {
+		if result.Findings[i].Category == "credential_to_log" {
+			finding = &result.Findings[i]
+		}
+	}
+	if finding == nil {
+		t.Fatalf("no credential_to_log finding in %+v", result.Findings)
+	}
+	if finding.Severity != "high" || finding.Confidence != "high" || len(finding.DataFlow) < 2 ||
+		!strings.HasSuffix(finding.Location, "main.go:27") {
+		t.Errorf("unexpected finding: %+v", finding)
+	}
+}
+
+func flowSummaries(flows []DataFlow) map[string]bool {
+	summaries := make(map[string]bool)
+	for _, flow := range flows {
+		summaries[flow.Source+" -> "+flow.Destination] = true
+	}
+	return summaries
+}
+
+func findFlow(flows []DataFlow, destination string) *DataFlow {
+	for i := range flows {
+		if flows[i].Destination == destination {
+			return &flows[i]
+		}
+	}
+	return nil
+}
+
+const piiPromptSample = `package main
+
+import (
+	"context"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+const systemPrompt = "You are a billing bot. Escalate to jane.doe@acme.io."
+
+func ask(client *openai.Client, question string) {
+	example := "Example: card 4111 1111 1111 1111 was declined"
+	client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: systemPrompt + "\n" + example},
+			{Role: "user", Content: question},
+		},
+	})
+}
+`
+
+func TestTracePromptTemplates(t *testing.T) {
+	prompts := traceGoFlows("bot.go", []byte(piiPromptSample)).prompts
+	lines := make(map[int]string)
+	for _, p := range prompts {
+		if p.Provider != "openai" {
+			t.Errorf("unexpected provider %q", p.Provider)
+		}
+		lines[p.Line] = p.Text
+	}
+	if !strings.Contains(lines[9], "jane.doe@acme.io") || !strings.Contains(lines[12], "4111") {
+		t.Errorf("prompt literals not traced: %+v", prompts)
+	}
+
+	src := "from openai import OpenAI\nclient = OpenAI()\n" +
+		"note = \"Patient SSN 123-45-6789\"\n" +
+		"client.chat.completions.create(messages=[{\"role\": \"user\", \"content\": note}])\n"
+	prompts = traceScriptFlows("bot.py", []byte(src), langPython).prompts
+	found := false
+	for _, p := range prompts {
+		found = found || (p.Line == 3 && strings.Contains(p.Text, "123-45-6789"))
+	}
+	if !found {
+		t.Errorf("python prompt literal not traced: %+v", prompts)
+	}
+}
+
+func TestEastRunReportsPromptPII(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "bot.go"), []byte(piiPromptSample), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	module := NewEastModule().(*EastModule)
+	if err := module.SetOption("target", dir); err != nil {
+		t.Fatal(err)
+	}
+	module.Check()
+	moduleResult, err := module.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := moduleResult.Data["result"].(*DataFlowResult)
+
+	findings := make(map[string]Finding)
+	for _, f := range result.Findings {
+		if f.Type == "pii_exposure" {
+			findings[f.Category] = f
+		}
+	}
+	card, ok := findings["credit_card"]
+	if !ok || card.Severity != "high" || card.Confidence != "high" ||
+		!strings.HasSuffix(card.Location, "bot.go:12") || strings.Contains(card.Evidence, "4111 1111") {
+		t.Errorf("unexpected card finding: %+v", card)
+	}
+	if strings.Join(card.Regulations, ",") != "GDPR,PCI-DSS" {
+		t.Errorf("unexpected regulations %v", card.Regulations)
+	}
+	if email, ok := findings["email"]; !ok || email.Severity != "medium" {
+		t.Errorf("unexpected email finding: %+v", email)
+	}
+	if got := result.PIIByProvider["openai"]; got["email"] != 1 || got["credit_card"] != 1 {
+		t.Errorf("unexpected per-provider counts %v", result.PIIByProvider)
+	}
+}
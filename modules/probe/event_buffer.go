@@ -0,0 +1,424 @@
+package probe
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// EventBuffer buffers a captured byte stream and splits it into events at
+// the boundaries its BoundaryDetector finds. When a write does not fit, the
+// buffer's OverflowPolicy decides what gives.
+type EventBuffer interface {
+	// Write appends stream data. A single write larger than the buffer
+	// keeps only its most recent bytes.
+	Write(p []byte) (int, error)
+
+	// ReadEvent removes and returns the oldest complete event.
+	ReadEvent() (BufferEvent, bool)
+
+	// ReadAll removes and returns everything buffered, including an
+	// incomplete trailing event.
+	ReadAll() []byte
+
+	// Len returns the number of bytes buffered.
+	Len() int
+
+	// Stats returns buffer statistics for monitoring.
+	Stats() BufferStats
+
+	// Close releases the buffer and wakes blocked writers.
+	Close() error
+}
+
+// OverflowPolicy decides what an EventBuffer does with a write that does
+// not fit.
+type OverflowPolicy string
+
+const (
+	// OverflowDropOldest discards the oldest complete events, and then the
+	// oldest bytes, until the write fits.
+	OverflowDropOldest OverflowPolicy = "drop-oldest"
+
+	// OverflowBlock makes the writer wait for a reader to make room.
+	OverflowBlock OverflowPolicy = "block"
+
+	// OverflowSample keeps one write in SampleRate while the buffer is
+	// above its high-water mark, and drops the oldest data when a kept
+	// write still does not fit.
+	OverflowSample OverflowPolicy = "sample"
+)
+
+// Event buffer backends.
+const (
+	BufferBackendLocked   = "locked"   // mutex-guarded, for modest rates
+	BufferBackendLockFree = "lockfree" // lock-free writers over LockFreeCircularBufferV3
+)
+
+var (
+	// ErrBufferClosed is returned by writes to a closed buffer.
+	ErrBufferClosed = errors.New("buffer closed")
+
+	// ErrBufferFull is returned by a blocking write that timed out.
+	ErrBufferFull = errors.New("buffer full")
+)
+
+// bufferHighWaterMark is the fill ratio above which sampling starts.
+const bufferHighWaterMark = 0.9
+
+// EventBufferConfig configures an EventBuffer.
+type EventBufferConfig struct {
+	Size         int              // capacity in bytes
+	Backend      string           // BufferBackendLocked (default) or BufferBackendLockFree
+	Detector     BoundaryDetector // nil treats everything buffered as one event
+	Overflow     OverflowPolicy   // default OverflowDropOldest
+	SampleRate   int              // with OverflowSample, keep one write in SampleRate (default 10)
+	BlockTimeout time.Duration    // with OverflowBlock, how long a write may wait (0 = until room or Close)
+}
+
+// BufferEvent is a complete event taken from an EventBuffer.
+type BufferEvent struct {
+	Data      []byte
+	Timestamp time.Time
+	Sequence  uint64 // monotonic per buffer
+	Partial   bool   // cut short by an overflow, or too large to find its end
+}
+
+// BufferStats reports the state of an EventBuffer.
+type BufferStats struct {
+	Backend       string         `json:"backend"`
+	Policy        OverflowPolicy `json:"policy"`
+	Size          int            `json:"size"`
+	Used          int            `json:"used"`
+	BytesWritten  uint64         `json:"bytes_written"`  // bytes accepted
+	BytesDropped  uint64         `json:"bytes_dropped"`  // bytes discarded by the overflow policy
+	EventsRead    uint64         `json:"events_read"`    // events taken with ReadEvent
+	EventsDropped uint64         `json:"events_dropped"` // complete events discarded by the overflow policy
+	WritesSampled uint64         `json:"writes_sampled"` // writes skipped by OverflowSample
+	WritesBlocked uint64         `json:"writes_blocked"` // writes that waited for room
+}
+
+// NewEventBuffer creates an event buffer with the configured backend.
+func NewEventBuffer(cfg EventBufferConfig) (EventBuffer, error) {
+	if cfg.Size <= 0 {
+		return nil, fmt.Errorf("invalid buffer size %d", cfg.Size)
+	}
+	switch cfg.Overflow {
+	case "":
+		cfg.Overflow = OverflowDropOldest
+	case OverflowDropOldest, OverflowBlock, OverflowSample:
+	default:
+		return nil, fmt.Errorf("unknown overflow policy %q", cfg.Overflow)
+	}
+	if cfg.SampleRate <= 0 {
+		cfg.SampleRate = 10
+	}
+
+	switch cfg.Backend {
+	case "", BufferBackendLocked:
+		return newLockedEventBuffer(cfg), nil
+	case BufferBackendLockFree:
+		return newLockFreeEventBuffer(cfg)
+	}
+	return nil, fmt.Errorf("unknown buffer backend %q", cfg.Backend)
+}
+
+// eventSplitter holds buffered stream bytes and the complete events found in
+// them. It is not safe for concurrent use.
+type eventSplitter struct {
+	detector BoundaryDetector
+	maxEvent int // bytes without a boundary after which they are delivered as a partial event
+
+	data    []byte // oldest first
+	ends    []int  // ends of the complete events in data
+	scanned int    // where detection resumes: the end of the last complete event
+	resync  bool   // data starts part way into an event after a drop
+
+	sequence      uint64
+	eventsRead    uint64
+	eventsDropped uint64
+}
+
+// newEventSplitter creates a splitter for a buffer of size bytes.
+func newEventSplitter(detector BoundaryDetector, size int) *eventSplitter {
+	maxEvent := size / 4
+	if detector != nil && detector.MaxMessageSize() < maxEvent {
+		maxEvent = detector.MaxMessageSize()
+	}
+	if maxEvent < 1 {
+		maxEvent = 1
+	}
+	return &eventSplitter{detector: detector, maxEvent: maxEvent}
+}
+
+// append adds stream data and finds the events it completes.
+func (s *eventSplitter) append(p []byte) {
+	s.data = append(s.data, p...)
+	s.detect()
+}
+
+// detect finds complete events after the last one found. Detection resumes
+// at the start of the incomplete event, so boundaries split across writes
+// are found once the rest arrives.
+func (s *eventSplitter) detect() {
+	if s.detector == nil {
+		return
+	}
+	for s.scanned < len(s.data) {
+		pos, _, found := detectAccumulated(s.detector, s.data, s.scanned)
+		if !found || pos <= s.scanned || pos > len(s.data) {
+			return
+		}
+		s.ends = append(s.ends, pos)
+		s.scanned = pos
+	}
+}
+
+// next removes the oldest complete event. Without a detector everything
+// buffered is one event; bytes that grow past maxEvent without a boundary
+// are delivered as a partial event rather than held forever.
+func (s *eventSplitter) next() (BufferEvent, bool) {
+	switch {
+	case len(s.data) == 0:
+		return BufferEvent{}, false
+	case s.detector == nil:
+		return s.take(len(s.data), false), true
+	case len(s.ends) > 0:
+		return s.take(s.ends[0], false), true
+	case len(s.data) >= s.maxEvent:
+		return s.take(len(s.data), true), true
+	}
+	return BufferEvent{}, false
+}
+
+// take removes the first n bytes as an event.
+func (s *eventSplitter) take(n int, partial bool) BufferEvent {
+	event := BufferEvent{
+		Data:      append([]byte(nil), s.data[:n]...),
+		Timestamp: time.Now(),
+		Sequence:  s.sequence,
+		Partial:   partial || s.resync,
+	}
+	s.sequence++
+	s.eventsRead++
+	s.discard(n)
+	s.resync = false
+	return event
+}
+
+// flush removes and returns everything buffered.
+func (s *eventSplitter) flush() []byte {
+	if len(s.data) == 0 {
+		return nil
+	}
+	data := append([]byte(nil), s.data...)
+	s.discard(len(s.data))
+	s.resync = false
+	return data
+}
+
+// drop frees at least need bytes, oldest complete events first, and returns
+// the number of bytes dropped.
+func (s *eventSplitter) drop(need int) int {
+	dropped := 0
+	for dropped < need && len(s.ends) > 0 {
+		n := s.ends[0]
+		s.discard(n)
+		s.eventsDropped++
+		dropped += n
+		s.resync = false
+	}
+	if dropped < need && len(s.data) > 0 {
+		// Not enough whole events; what remains starts mid-event
+		n := need - dropped
+		if n > len(s.data) {
+			n = len(s.data)
+		}
+		s.discard(n)
+		dropped += n
+		s.resync = true
+		s.detect()
+	}
+	return dropped
+}
+
+// discard removes the first n bytes, which must not split a found event.
+func (s *eventSplitter) discard(n int) {
+	s.data = s.data[n:]
+	if len(s.data) == 0 {
+		s.data = nil // let a large backing array go
+	}
+	ends := s.ends[:0]
+	for _, end := range s.ends {
+		if end > n {
+			ends = append(ends, end-n)
+		}
+	}
+	s.ends = ends
+	s.scanned -= n
+	if s.scanned < 0 {
+		s.scanned = 0
+	}
+}
+
+// lockedEventBuffer is the mutex-guarded EventBuffer backend.
+type lockedEventBuffer struct {
+	cfg   EventBufferConfig
+	mu    sync.Mutex
+	room  *sync.Cond // signalled when a read makes room or the buffer closes
+	split *eventSplitter
+
+	closed        bool
+	sampleCount   int
+	bytesWritten  uint64
+	bytesDropped  uint64
+	writesSampled uint64
+	writesBlocked uint64
+}
+
+func newLockedEventBuffer(cfg EventBufferConfig) *lockedEventBuffer {
+	b := &lockedEventBuffer{
+		cfg:   cfg,
+		split: newEventSplitter(cfg.Detector, cfg.Size),
+	}
+	b.room = sync.NewCond(&b.mu)
+	return b
+}
+
+// Write adds data to the buffer, applying the overflow policy.
+func (b *lockedEventBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return 0, ErrBufferClosed
+	}
+	n := len(p)
+	if n == 0 {
+		return 0, nil
+	}
+	if len(p) > b.cfg.Size {
+		b.bytesDropped += uint64(len(p) - b.cfg.Size)
+		p = p[len(p)-b.cfg.Size:]
+	}
+
+	switch b.cfg.Overflow {
+	case OverflowSample:
+		if float64(len(b.split.data)) >= float64(b.cfg.Size)*bufferHighWaterMark {
+			b.sampleCount++
+			if b.sampleCount%b.cfg.SampleRate != 0 {
+				b.writesSampled++
+				b.bytesDropped += uint64(len(p))
+				return n, nil
+			}
+		}
+	case OverflowBlock:
+		if err := b.waitForRoom(len(p)); err != nil {
+			b.bytesDropped += uint64(len(p))
+			return 0, err
+		}
+	}
+
+	if free := b.cfg.Size - len(b.split.data); len(p) > free {
+		b.bytesDropped += uint64(b.split.drop(len(p) - free))
+	}
+	b.split.append(p)
+	b.bytesWritten += uint64(len(p))
+	return n, nil
+}
+
+// waitForRoom blocks until n bytes fit, the buffer closes or the block
+// timeout passes. It is called with mu held.
+func (b *lockedEventBuffer) waitForRoom(n int) error {
+	if b.cfg.Size-len(b.split.data) >= n {
+		return nil
+	}
+	b.writesBlocked++
+
+	var deadline time.Time
+	if b.cfg.BlockTimeout > 0 {
+		deadline = time.Now().Add(b.cfg.BlockTimeout)
+		timer := time.AfterFunc(b.cfg.BlockTimeout, func() {
+			b.mu.Lock()
+			b.room.Broadcast()
+			b.mu.Unlock()
+		})
+		defer timer.Stop()
+	}
+
+	for b.cfg.Size-len(b.split.data) < n {
+		if b.closed {
+			return ErrBufferClosed
+		}
+		if !deadline.IsZero() && !time.Now().Before(deadline) {
+			return ErrBufferFull
+		}
+		b.room.Wait()
+	}
+	return nil
+}
+
+// ReadEvent removes and returns the oldest complete event.
+func (b *lockedEventBuffer) ReadEvent() (BufferEvent, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	event, ok := b.split.next()
+	if ok {
+		b.room.Broadcast()
+	}
+	return event, ok
+}
+
+// ReadAll removes and returns everything buffered.
+func (b *lockedEventBuffer) ReadAll() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	data := b.split.flush()
+	if data != nil {
+		b.room.Broadcast()
+	}
+	return data
+}
+
+// Len returns the number of bytes buffered.
+func (b *lockedEventBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.split.data)
+}
+
+// Stats returns buffer statistics.
+func (b *lockedEventBuffer) Stats() BufferStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return BufferStats{
+		Backend:       BufferBackendLocked,
+		Policy:        b.cfg.Overflow,
+		Size:          b.cfg.Size,
+		Used:          len(b.split.data),
+		BytesWritten:  b.bytesWritten,
+		BytesDropped:  b.bytesDropped,
+		EventsRead:    b.split.eventsRead,
+		EventsDropped: b.split.eventsDropped,
+		WritesSampled: b.writesSampled,
+		WritesBlocked: b.writesBlocked,
+	}
+}
+
+// Close wakes blocked writers and rejects further writes. Buffered data can
+// still be read.
+func (b *lockedEventBuffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrBufferClosed
+	}
+	b.closed = true
+	b.room.Broadcast()
+	return nil
+}
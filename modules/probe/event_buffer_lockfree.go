@@ -0,0 +1,196 @@
+package probe
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// lockFreeBlockPoll is how often a blocked writer retries on the lock-free
+// backend.
+const lockFreeBlockPoll = time.Millisecond
+
+// lockFreeEventBuffer is the high-throughput EventBuffer backend. Writers
+// append to a LockFreeCircularBufferV3 without locking. The read side moves
+// committed data into an event splitter under a mutex, which writers only
+// take when the ring is full and the overflow policy must make room.
+//
+// The size is rounded up to a power of two of at least 64KB. The ring and
+// the read side each hold up to that many bytes.
+type lockFreeEventBuffer struct {
+	cfg  EventBufferConfig
+	ring *LockFreeCircularBufferV3
+
+	mu    sync.Mutex // guards split; only the holder advances ring.readIndex
+	split *eventSplitter
+
+	closed        atomic.Bool
+	sampleCount   atomic.Uint64
+	bytesWritten  atomic.Uint64
+	bytesDropped  atomic.Uint64
+	writesSampled atomic.Uint64
+	writesBlocked atomic.Uint64
+}
+
+func newLockFreeEventBuffer(cfg EventBufferConfig) (*lockFreeEventBuffer, error) {
+	ring, err := NewLockFreeCircularBufferV3NoProcessor(cfg.Size, nil)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Size = int(ring.size)
+
+	return &lockFreeEventBuffer{
+		cfg:   cfg,
+		ring:  ring,
+		split: newEventSplitter(cfg.Detector, cfg.Size),
+	}, nil
+}
+
+// Write adds data to the ring, applying the overflow policy when it is full.
+func (b *lockFreeEventBuffer) Write(p []byte) (int, error) {
+	if b.closed.Load() {
+		return 0, ErrBufferClosed
+	}
+	n := len(p)
+	if n == 0 {
+		return 0, nil
+	}
+	if len(p) > b.cfg.Size {
+		b.bytesDropped.Add(uint64(len(p) - b.cfg.Size))
+		p = p[len(p)-b.cfg.Size:]
+	}
+
+	if b.cfg.Overflow == OverflowSample && b.ring.shouldBackpressure() {
+		if b.sampleCount.Add(1)%uint64(b.cfg.SampleRate) != 0 {
+			b.writesSampled.Add(1)
+			b.bytesDropped.Add(uint64(len(p)))
+			return n, nil
+		}
+	}
+
+	var deadline time.Time
+	blocked := false
+	for {
+		if _, err := b.ring.Write(p); err == nil {
+			b.bytesWritten.Add(uint64(len(p)))
+			return n, nil
+		}
+		if b.closed.Load() {
+			return 0, ErrBufferClosed
+		}
+
+		// Move the ring's data to the read side, which drops its oldest
+		// events to make room unless writers are to wait
+		if b.drain(b.cfg.Overflow != OverflowBlock) {
+			continue
+		}
+
+		if !blocked {
+			blocked = true
+			b.writesBlocked.Add(1)
+			if b.cfg.BlockTimeout > 0 {
+				deadline = time.Now().Add(b.cfg.BlockTimeout)
+			}
+		}
+		if !deadline.IsZero() && !time.Now().Before(deadline) {
+			b.bytesDropped.Add(uint64(len(p)))
+			return 0, ErrBufferFull
+		}
+		time.Sleep(lockFreeBlockPoll)
+	}
+}
+
+// drain moves committed data out of the ring.
+func (b *lockFreeEventBuffer) drain(makeRoom bool) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.drainLocked(makeRoom)
+}
+
+// drainLocked moves committed data out of the ring into the splitter. With
+// makeRoom the splitter drops its oldest events to take all of it;
+// otherwise only what fits is moved. It reports whether anything moved and
+// is called with mu held.
+func (b *lockFreeEventBuffer) drainLocked(makeRoom bool) bool {
+	version, readIdx := unpackIndex(b.ring.readIndex.Load())
+	n := int(b.ring.committed() - readIdx)
+	if n == 0 {
+		return false
+	}
+	if free := b.cfg.Size - len(b.split.data); n > free {
+		if makeRoom {
+			b.bytesDropped.Add(uint64(b.split.drop(n - free)))
+		} else if n = free; n == 0 {
+			return false
+		}
+	}
+
+	chunk := make([]byte, n)
+	b.ring.copyFromBuffer(chunk, uint64(readIdx))
+	b.ring.readIndex.Store(packIndex(version+1, readIdx+uint32(n)))
+	b.split.append(chunk)
+	return true
+}
+
+// ReadEvent removes and returns the oldest complete event.
+func (b *lockFreeEventBuffer) ReadEvent() (BufferEvent, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.drainLocked(false)
+	return b.split.next()
+}
+
+// ReadAll removes and returns everything buffered.
+func (b *lockFreeEventBuffer) ReadAll() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var data []byte
+	for {
+		data = append(data, b.split.flush()...)
+		if !b.drainLocked(false) {
+			return data
+		}
+	}
+}
+
+// Len returns the number of bytes buffered.
+func (b *lockFreeEventBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.lenLocked()
+}
+
+func (b *lockFreeEventBuffer) lenLocked() int {
+	_, readIdx := unpackIndex(b.ring.readIndex.Load())
+	return int(b.ring.committed()-readIdx) + len(b.split.data)
+}
+
+// Stats returns buffer statistics.
+func (b *lockFreeEventBuffer) Stats() BufferStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return BufferStats{
+		Backend:       BufferBackendLockFree,
+		Policy:        b.cfg.Overflow,
+		Size:          b.cfg.Size,
+		Used:          b.lenLocked(),
+		BytesWritten:  b.bytesWritten.Load(),
+		BytesDropped:  b.bytesDropped.Load(),
+		EventsRead:    b.split.eventsRead,
+		EventsDropped: b.split.eventsDropped,
+		WritesSampled: b.writesSampled.Load(),
+		WritesBlocked: b.writesBlocked.Load(),
+	}
+}
+
+// Close wakes blocked writers and rejects further writes. Buffered data can
+// still be read.
+func (b *lockFreeEventBuffer) Close() error {
+	if !b.closed.CompareAndSwap(false, true) {
+		return ErrBufferClosed
+	}
+	return b.ring.Close()
+}
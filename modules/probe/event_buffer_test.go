@@ -0,0 +1,314 @@
+package probe
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var eventBufferBackends = []string{BufferBackendLocked, BufferBackendLockFree}
+
+func newTestEventBuffer(t *testing.T, cfg EventBufferConfig) EventBuffer {
+	t.Helper()
+	buffer, err := NewEventBuffer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = buffer.Close() })
+	return buffer
+}
+
+// readEvents takes every complete event from buffer.
+func readEvents(buffer EventBuffer) []string {
+	var events []string
+	for {
+		event, ok := buffer.ReadEvent()
+		if !ok {
+			return events
+		}
+		events = append(events, string(event.Data))
+	}
+}
+
+func TestNewEventBuffer_InvalidConfig(t *testing.T) {
+	for _, cfg := range []EventBufferConfig{
+		{Size: 0},
+		{Size: 1024, Backend: "ring"},
+		{Size: 1024, Overflow: "drop-newest"},
+	} {
+		if _, err := NewEventBuffer(cfg); err == nil {
+			t.Errorf("NewEventBuffer(%+v) succeeded", cfg)
+		}
+	}
+}
+
+func TestEventBuffer_SplitsEvents(t *testing.T) {
+	for _, backend := range eventBufferBackends {
+		t.Run(backend, func(t *testing.T) {
+			buffer := newTestEventBuffer(t, EventBufferConfig{
+				Size:     4096,
+				Backend:  backend,
+				Detector: NewDelimiterBoundaryDetector([]byte("\r\n")),
+			})
+
+			// Boundaries split across writes
+			for _, chunk := range []string{"Line 1\r", "\nLine 2 with more", " content\r\nLine 3\r\nLine"} {
+				buffer.Write([]byte(chunk))
+			}
+
+			want := []string{"Line 1\r\n", "Line 2 with more content\r\n", "Line 3\r\n"}
+			if got := readEvents(buffer); fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("events = %q, want %q", got, want)
+			}
+			if rest := buffer.ReadAll(); string(rest) != "Line" {
+				t.Errorf("ReadAll() = %q, want the incomplete event", rest)
+			}
+			if stats := buffer.Stats(); stats.Backend != backend || stats.EventsRead != 3 || stats.Used != 0 {
+				t.Errorf("unexpected stats %+v", stats)
+			}
+		})
+	}
+}
+
+func TestEventBuffer_ProtocolDetectors(t *testing.T) {
+	detectors := NewProtocolBoundaryDetector()
+	http, _ := detectors.Detector("http")
+	buffer := newTestEventBuffer(t, EventBufferConfig{Size: 4096, Detector: http})
+
+	// The body completes the request several writes after the headers
+	request := "POST /mcp HTTP/1.1\r\nContent-Length: 13\r\n\r\n{\"id\": \"abc\"}"
+	for i := 0; i < len(request); i += 7 {
+		buffer.Write([]byte(request[i:min(i+7, len(request))]))
+	}
+	if got := readEvents(buffer); len(got) != 1 || got[0] != request {
+		t.Errorf("events = %q", got)
+	}
+
+	// The set as a whole recognizes each message's protocol
+	buffer = newTestEventBuffer(t, EventBufferConfig{Size: 4096, Detector: detectors})
+	object := `{"jsonrpc":"2.0","method":"tools/list"}`
+	buffer.Write([]byte(request))
+	buffer.Write([]byte(object[:9]))
+	buffer.Write([]byte(object[9:] + "\n"))
+	if got := readEvents(buffer); len(got) != 2 || got[0] != request || got[1] != object+"\n" {
+		t.Errorf("events = %q", got)
+	}
+}
+
+func TestEventBuffer_NoDetector(t *testing.T) {
+	buffer := newTestEventBuffer(t, EventBufferConfig{Size: 64})
+	buffer.Write([]byte("abc"))
+	buffer.Write([]byte("def"))
+
+	if event, ok := buffer.ReadEvent(); !ok || string(event.Data) != "abcdef" {
+		t.Errorf("ReadEvent() = %q, %v", event.Data, ok)
+	}
+}
+
+func TestEventBuffer_FlushesOversizedEvents(t *testing.T) {
+	// Without a boundary within a quarter of the buffer the bytes are
+	// delivered rather than held until they are dropped
+	buffer := newTestEventBuffer(t, EventBufferConfig{Size: 100, Detector: NewLineBoundaryDetector()})
+	buffer.Write([]byte(strings.Repeat("x", 30)))
+
+	event, ok := buffer.ReadEvent()
+	if !ok || len(event.Data) != 30 || !event.Partial {
+		t.Errorf("ReadEvent() = %d bytes, %v, partial %v", len(event.Data), ok, event.Partial)
+	}
+}
+
+func TestEventBuffer_DropOldest(t *testing.T) {
+	for _, backend := range eventBufferBackends {
+		t.Run(backend, func(t *testing.T) {
+			buffer := newTestEventBuffer(t, EventBufferConfig{
+				Size:     64 * 1024,
+				Backend:  backend,
+				Detector: NewLineBoundaryDetector(),
+			})
+
+			// Several times the capacity, with nothing read
+			for i := 0; i < 20000; i++ {
+				if _, err := buffer.Write([]byte(fmt.Sprintf("event %05d\n", i))); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			events := readEvents(buffer)
+			if len(events) == 0 || events[len(events)-1] != "event 19999\n" {
+				t.Fatalf("most recent event lost: %d events", len(events))
+			}
+			for _, event := range events {
+				if len(event) != 12 {
+					t.Fatalf("event cut by overflow: %q", event)
+				}
+			}
+
+			stats := buffer.Stats()
+			if stats.BytesDropped == 0 || stats.EventsDropped == 0 || stats.BytesWritten != 20000*12 {
+				t.Errorf("unexpected stats %+v", stats)
+			}
+			if stats.BytesDropped != uint64(20000-len(events))*12 {
+				t.Errorf("dropped %d bytes, but %d events are missing", stats.BytesDropped, 20000-len(events))
+			}
+		})
+	}
+}
+
+func TestEventBuffer_Block(t *testing.T) {
+	buffer := newTestEventBuffer(t, EventBufferConfig{Size: 16, Detector: NewLineBoundaryDetector(), Overflow: OverflowBlock})
+	buffer.Write([]byte("first 01\n"))
+
+	written := make(chan error, 1)
+	go func() {
+		_, err := buffer.Write([]byte("second 2\n"))
+		written <- err
+	}()
+
+	select {
+	case err := <-written:
+		t.Fatalf("write did not block: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if event, ok := buffer.ReadEvent(); !ok || string(event.Data) != "first 01\n" {
+		t.Fatalf("ReadEvent() = %q, %v", event.Data, ok)
+	}
+	if err := <-written; err != nil {
+		t.Fatal(err)
+	}
+	if got := readEvents(buffer); len(got) != 1 || got[0] != "second 2\n" {
+		t.Errorf("events = %q", got)
+	}
+	if stats := buffer.Stats(); stats.WritesBlocked != 1 || stats.BytesDropped != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	// Closing wakes a blocked writer
+	buffer.Write([]byte("third 03\n"))
+	go func() {
+		_, err := buffer.Write([]byte("fourth 4\n"))
+		written <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	buffer.Close()
+	if err := <-written; !errors.Is(err, ErrBufferClosed) {
+		t.Errorf("blocked write returned %v after Close", err)
+	}
+}
+
+func TestEventBuffer_BlockTimeout(t *testing.T) {
+	for _, backend := range eventBufferBackends {
+		t.Run(backend, func(t *testing.T) {
+			buffer := newTestEventBuffer(t, EventBufferConfig{
+				Size:         64 * 1024,
+				Backend:      backend,
+				Overflow:     OverflowBlock,
+				BlockTimeout: 50 * time.Millisecond,
+			})
+
+			chunk := bytes.Repeat([]byte("x"), 16*1024)
+			var err error
+			for i := 0; i < 16 && err == nil; i++ {
+				_, err = buffer.Write(chunk)
+			}
+			if !errors.Is(err, ErrBufferFull) {
+				t.Fatalf("expected the buffer to fill, got %v", err)
+			}
+
+			stats := buffer.Stats()
+			if stats.WritesBlocked != 1 || stats.BytesDropped != uint64(len(chunk)) || stats.EventsDropped != 0 {
+				t.Errorf("unexpected stats %+v", stats)
+			}
+			if n := len(buffer.ReadAll()); uint64(n) != stats.BytesWritten {
+				t.Errorf("read %d bytes, %d written", n, stats.BytesWritten)
+			}
+		})
+	}
+}
+
+func TestEventBuffer_Sample(t *testing.T) {
+	buffer := newTestEventBuffer(t, EventBufferConfig{
+		Size:       100,
+		Detector:   NewLineBoundaryDetector(),
+		Overflow:   OverflowSample,
+		SampleRate: 3,
+	})
+
+	for i := 0; i < 9; i++ {
+		buffer.Write([]byte(fmt.Sprintf("sample %d\n", i))) // 9 bytes
+	}
+	// Above the high-water mark one write in three is kept
+	for i := 9; i < 16; i++ {
+		buffer.Write([]byte(fmt.Sprintf("sample %d\n", i)))
+	}
+
+	stats := buffer.Stats()
+	if stats.WritesSampled != 4 {
+		t.Errorf("sampled out %d writes, want 4", stats.WritesSampled)
+	}
+	events := readEvents(buffer)
+	if last := events[len(events)-1]; last != "sample 15\n" {
+		t.Errorf("last event %q", last)
+	}
+}
+
+func TestEventBuffer_ConcurrentWriters(t *testing.T) {
+	const writers, perWriter = 4, 2000
+
+	buffer := newTestEventBuffer(t, EventBufferConfig{
+		Size:     64 * 1024,
+		Backend:  BufferBackendLockFree,
+		Detector: NewLineBoundaryDetector(),
+		Overflow: OverflowBlock,
+	})
+
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				if _, err := buffer.Write([]byte(fmt.Sprintf("w%d %06d\n", w, i))); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(w)
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	// Each writer's events arrive whole and in order
+	next := make([]int, writers)
+	received := 0
+	for received < writers*perWriter {
+		event, ok := buffer.ReadEvent()
+		if !ok {
+			select {
+			case <-done:
+				if buffer.Len() == 0 {
+					t.Fatalf("received %d of %d events", received, writers*perWriter)
+				}
+			default:
+			}
+			time.Sleep(time.Millisecond)
+			continue
+		}
+		var w, i int
+		if _, err := fmt.Sscanf(string(event.Data), "w%d %d\n", &w, &i); err != nil || len(event.Data) != 10 {
+			t.Fatalf("corrupt event %q", event.Data)
+		}
+		if i != next[w] {
+			t.Fatalf("writer %d: event %d after %d", w, i, next[w]-1)
+		}
+		next[w]++
+		received++
+	}
+}
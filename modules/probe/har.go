@@ -0,0 +1,463 @@
+package probe
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// harMaxBodySize limits how much of each body is archived.
+const harMaxBodySize = 64 * 1024
+
+// HARLog is the top-level HAR 1.2 document.
+type HARLog struct {
+	Log HARContent `json:"log"`
+}
+
+// HARContent holds the archive metadata and entries.
+type HARContent struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+// HARCreator identifies the tool that produced the archive.
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HAREntry is a single recorded HTTP exchange. ID is a Strigoi extension
+// referenced by findings.
+type HAREntry struct {
+	ID              string      `json:"_id"`
+	Module          string      `json:"_module,omitempty"`
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+}
+
+// HARRequest describes the recorded request.
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HARResponse describes the recorded response.
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARBody        `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HARNameValue is a header, cookie or query parameter.
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARPostData holds a request body.
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// HARBody holds a response body.
+type HARBody struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+}
+
+// HARTimings breaks down the exchange duration in milliseconds.
+type HARTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// HARRecorder archives HTTP exchanges as HAR entries with secrets redacted.
+type HARRecorder struct {
+	mu      sync.Mutex
+	entries []HAREntry
+	ids     map[*http.Response]string // responses whose body is still open
+	hunter  *CredentialHunter
+}
+
+// NewHARRecorder creates an empty recorder.
+func NewHARRecorder() *HARRecorder {
+	return &HARRecorder{
+		ids:    make(map[*http.Response]string),
+		hunter: NewCredentialHunter(),
+	}
+}
+
+var (
+	harRecordersMu sync.Mutex
+	harRecorders   = make(map[string]*HARRecorder)
+)
+
+// harRecorderFor returns the recorder shared by all modules writing to the
+// same archive, so "probe all" produces one HAR file.
+func harRecorderFor(filename string) *HARRecorder {
+	harRecordersMu.Lock()
+	defer harRecordersMu.Unlock()
+
+	if r, ok := harRecorders[filename]; ok {
+		return r
+	}
+	r := NewHARRecorder()
+	harRecorders[filename] = r
+	return r
+}
+
+// Transport wraps base so every exchange it carries is recorded for module.
+func (r *HARRecorder) Transport(module string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &harTransport{recorder: r, module: module, base: base}
+}
+
+// EntryID returns the HAR entry ID recorded for resp, or "" if none. It is
+// only known until the response body is closed.
+func (r *HARRecorder) EntryID(resp *http.Response) string {
+	if r == nil || resp == nil {
+		return ""
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ids[resp]
+}
+
+// Entries returns a copy of the recorded entries.
+func (r *HARRecorder) Entries() []HAREntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := make([]HAREntry, len(r.entries))
+	copy(entries, r.entries)
+	return entries
+}
+
+// WriteFile writes the archive to filename.
+func (r *HARRecorder) WriteFile(filename string) error {
+	if r == nil {
+		return nil
+	}
+
+	doc := HARLog{
+		Log: HARContent{
+			Version: "1.2",
+			Creator: HARCreator{Name: "Strigoi", Version: "0.5.2"},
+			Entries: r.Entries(),
+		},
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode HAR: %w", err)
+	}
+	if err := os.WriteFile(filename, data, 0600); err != nil {
+		return fmt.Errorf("failed to write HAR file: %w", err)
+	}
+	return nil
+}
+
+// record stores an exchange whose response body is yet to be read and
+// returns the index of its entry; finish completes it.
+func (r *HARRecorder) record(module string, req *http.Request, reqBody []byte, resp *http.Response, start time.Time, wait time.Duration) int {
+	entry := HAREntry{
+		Module:          module,
+		StartedDateTime: start.UTC().Format(time.RFC3339Nano),
+		Time:            durationMillis(wait),
+		Request: HARRequest{
+			Method:      req.Method,
+			URL:         r.redactURL(req),
+			HTTPVersion: req.Proto,
+			Cookies:     r.redactCookies(req.Cookies()),
+			Headers:     r.redactHeaders(req.Header),
+			QueryString: r.redactQuery(req),
+			HeadersSize: -1,
+			BodySize:    len(reqBody),
+		},
+		Response: HARResponse{
+			Status:      resp.StatusCode,
+			StatusText:  http.StatusText(resp.StatusCode),
+			HTTPVersion: resp.Proto,
+			Cookies:     r.redactCookies(resp.Cookies()),
+			Headers:     r.redactHeaders(resp.Header),
+			Content: HARBody{
+				MimeType: resp.Header.Get("Content-Type"),
+			},
+			RedirectURL: resp.Header.Get("Location"),
+			HeadersSize: -1,
+		},
+		Timings: HARTimings{
+			Send: 0,
+			Wait: durationMillis(wait),
+		},
+	}
+
+	if entry.Request.HTTPVersion == "" {
+		entry.Request.HTTPVersion = "HTTP/1.1"
+	}
+	if len(reqBody) > 0 {
+		entry.Request.PostData = &HARPostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     r.redactBody(reqBody),
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry.ID = fmt.Sprintf("entry-%d", len(r.entries)+1)
+	r.entries = append(r.entries, entry)
+	r.ids[resp] = entry.ID
+	return len(r.entries) - 1
+}
+
+// finish completes the entry at index with the size bytes of response body
+// read, of which body is the archived prefix, total after the request
+// started.
+func (r *HARRecorder) finish(index int, body []byte, size int, total time.Duration) {
+	text := r.redactBody(body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry := &r.entries[index]
+	entry.Response.Content.Size = size
+	entry.Response.Content.Text = text
+	entry.Response.BodySize = size
+	entry.Time = durationMillis(total)
+	entry.Timings.Receive = entry.Time - entry.Timings.Wait
+}
+
+// harSensitiveHeaders lists headers whose values are always masked.
+var harSensitiveHeaders = map[string]bool{
+	"authorization":       true,
+	"proxy-authorization": true,
+	"cookie":              true,
+	"set-cookie":          true,
+	"x-api-key":           true,
+	"api-key":             true,
+	"x-auth-token":        true,
+	"x-csrf-token":        true,
+	"x-xsrf-token":        true,
+}
+
+// redactHeaders converts headers to HAR pairs, masking credentials.
+func (r *HARRecorder) redactHeaders(headers http.Header) []HARNameValue {
+	pairs := []HARNameValue{}
+	for name, values := range headers {
+		lower := strings.ToLower(name)
+		for _, value := range values {
+			switch {
+			case lower == "authorization" || lower == "proxy-authorization":
+				value = maskAuthValue(value)
+			case harSensitiveHeaders[lower]:
+				value = maskValue(value)
+			default:
+				value = r.redactText(value)
+			}
+			pairs = append(pairs, HARNameValue{Name: name, Value: value})
+		}
+	}
+	return pairs
+}
+
+// redactCookies masks cookie values.
+func (r *HARRecorder) redactCookies(cookies []*http.Cookie) []HARNameValue {
+	pairs := []HARNameValue{}
+	for _, c := range cookies {
+		pairs = append(pairs, HARNameValue{Name: c.Name, Value: maskValue(c.Value)})
+	}
+	return pairs
+}
+
+// redactQuery masks query parameters that look like credentials.
+func (r *HARRecorder) redactQuery(req *http.Request) []HARNameValue {
+	pairs := []HARNameValue{}
+	for name, values := range req.URL.Query() {
+		for _, value := range values {
+			if isSensitiveParam(name) {
+				value = maskValue(value)
+			}
+			pairs = append(pairs, HARNameValue{Name: name, Value: value})
+		}
+	}
+	return pairs
+}
+
+// redactURL returns the request URL with sensitive query values masked.
+func (r *HARRecorder) redactURL(req *http.Request) string {
+	u := *req.URL
+	query := u.Query()
+	changed := false
+	for name, values := range query {
+		if isSensitiveParam(name) {
+			for i := range values {
+				values[i] = maskValue(values[i])
+			}
+			changed = true
+		}
+	}
+	if changed {
+		u.RawQuery = query.Encode()
+	}
+	u.User = nil
+	return u.String()
+}
+
+// redactBody masks credentials in a body and truncates it for the archive.
+func (r *HARRecorder) redactBody(body []byte) string {
+	if len(body) > harMaxBodySize {
+		body = body[:harMaxBodySize]
+	}
+	return r.redactText(string(body))
+}
+
+// harJSONField matches string-valued JSON fields in bodies.
+var harJSONField = regexp.MustCompile(`"([A-Za-z0-9_\-]+)"\s*:\s*"([^"\\]*)"`)
+
+// redactText replaces detected credentials with their redacted form and
+// masks JSON fields whose names suggest a secret.
+func (r *HARRecorder) redactText(text string) string {
+	for _, cred := range r.hunter.Hunt([]byte(text)) {
+		text = strings.ReplaceAll(text, cred.Value, cred.Redacted)
+	}
+	return harJSONField.ReplaceAllStringFunc(text, func(field string) string {
+		m := harJSONField.FindStringSubmatch(field)
+		if !isSensitiveParam(m[1]) || m[2] == "" {
+			return field
+		}
+		return field[:len(field)-len(m[2])-1] + maskValue(m[2]) + `"`
+	})
+}
+
+// isSensitiveParam reports whether a query parameter likely carries a secret.
+func isSensitiveParam(name string) bool {
+	lower := strings.ToLower(name)
+	for _, hint := range []string{"key", "token", "secret", "password", "auth", "sig"} {
+		if strings.Contains(lower, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// durationMillis converts a duration to fractional milliseconds.
+func durationMillis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// harTransport records exchanges passing through an underlying transport.
+type harTransport struct {
+	recorder *HARRecorder
+	module   string
+	base     http.RoundTripper
+}
+
+// RoundTrip performs the request and archives it.
+func (t *harTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			reqBody, _ = io.ReadAll(io.LimitReader(body, harMaxBodySize))
+			body.Close()
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// The body is archived as the caller reads it, so streaming responses
+	// are handed over at once
+	index := t.recorder.record(t.module, req, reqBody, resp, start, time.Since(start))
+	resp.Body = &harBody{
+		body:     resp.Body,
+		recorder: t.recorder,
+		resp:     resp,
+		index:    index,
+		start:    start,
+	}
+	return resp, nil
+}
+
+// harBody archives the prefix of a response body as it is read. The entry
+// is completed at the end of the body or on close, whichever comes first,
+// and the response's entry ID is forgotten once closed.
+type harBody struct {
+	body     io.ReadCloser
+	recorder *HARRecorder
+	resp     *http.Response
+	index    int
+	start    time.Time
+
+	mu       sync.Mutex // Close may be called while a Read blocks
+	prefix   []byte
+	size     int
+	finished bool
+}
+
+// Read reads from the underlying body and keeps what fits in the archive.
+func (b *harBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	b.mu.Lock()
+	b.size += n
+	if room := harMaxBodySize - len(b.prefix); room > 0 {
+		b.prefix = append(b.prefix, p[:min(n, room)]...)
+	}
+	b.mu.Unlock()
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+// Close completes the entry and closes the underlying body.
+func (b *harBody) Close() error {
+	b.finish()
+	b.recorder.mu.Lock()
+	delete(b.recorder.ids, b.resp)
+	b.recorder.mu.Unlock()
+	return b.body.Close()
+}
+
+// finish completes the entry with what was read so far, once.
+func (b *harBody) finish() {
+	b.mu.Lock()
+	if b.finished {
+		b.mu.Unlock()
+		return
+	}
+	b.finished = true
+	prefix, size := b.prefix, b.size
+	b.mu.Unlock()
+	b.recorder.finish(b.index, prefix, size, time.Since(b.start))
+}
@@ -0,0 +1,155 @@
+package probe
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHARRecorderRedactsSecrets(t *testing.T) {
+	const apiKey = "sk-proj-abcdefghijklmnopqrstuvwxyz0123456789ABCD"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s3cr3t-session-value"})
+		_, _ = w.Write([]byte(`{"api_key": "` + apiKey + `"}`))
+	}))
+	defer server.Close()
+
+	recorder := NewHARRecorder()
+	client := &http.Client{Transport: recorder.Transport("probe/test", nil)}
+
+	req, _ := http.NewRequest("GET", server.URL+"/v1/models?api_key=supersecretvalue&limit=5", nil)
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+
+	// The caller still sees the full, unredacted body
+	if !strings.Contains(string(body), apiKey) {
+		t.Errorf("response body was altered for the caller: %s", body)
+	}
+
+	if id := recorder.EntryID(resp); id != "entry-1" {
+		t.Errorf("expected entry-1, got %q", id)
+	}
+
+	// Closing the body releases the response
+	resp.Body.Close()
+	if id := recorder.EntryID(resp); id != "" || len(recorder.ids) != 0 {
+		t.Errorf("entry ID kept after close: %q, %d responses tracked", id, len(recorder.ids))
+	}
+
+	entries := recorder.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+
+	archived, _ := json.Marshal(entries[0])
+	for _, secret := range []string{apiKey, "supersecretvalue", "s3cr3t-session-value"} {
+		if strings.Contains(string(archived), secret) {
+			t.Errorf("secret %q leaked into HAR entry: %s", secret, archived)
+		}
+	}
+	if !strings.Contains(string(archived), "limit") || entries[0].Module != "probe/test" {
+		t.Errorf("unexpected HAR entry: %s", archived)
+	}
+}
+
+func TestHARRecorderWriteFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	recorder := NewHARRecorder()
+	client := &http.Client{Transport: recorder.Transport("probe/test", nil)}
+	for i := 0; i < 2; i++ {
+		resp, err := client.Post(server.URL+"/login", "application/json", strings.NewReader(`{"user":"a"}`))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	path := filepath.Join(t.TempDir(), "out.har")
+	if err := recorder.WriteFile(path); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read HAR: %v", err)
+	}
+
+	var doc HARLog
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("HAR is not valid JSON: %v", err)
+	}
+	if doc.Log.Version != "1.2" || len(doc.Log.Entries) != 2 {
+		t.Fatalf("unexpected HAR document: %+v", doc.Log)
+	}
+
+	second := doc.Log.Entries[1]
+	if second.ID != "entry-2" || second.Response.Status != http.StatusUnauthorized {
+		t.Errorf("unexpected entry: %+v", second)
+	}
+	if second.Request.PostData == nil || second.Request.PostData.Text != `{"user":"a"}` {
+		t.Errorf("request body not archived: %+v", second.Request.PostData)
+	}
+}
+
+func TestHARRecorderStreamingResponse(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: first\n\n"))
+		w.(http.Flusher).Flush()
+		<-release
+		_, _ = w.Write([]byte("data: second\n\n"))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	recorder := NewHARRecorder()
+	client := &http.Client{Transport: recorder.Transport("probe/test", nil)}
+
+	// The response arrives while the stream is still open
+	done := make(chan *http.Response, 1)
+	go func() {
+		resp, err := client.Get(server.URL + "/sse")
+		if err != nil {
+			t.Error(err)
+		}
+		done <- resp
+	}()
+	var resp *http.Response
+	select {
+	case resp = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("RoundTrip blocked on a streaming body")
+	}
+	if resp == nil {
+		return
+	}
+
+	event := make([]byte, len("data: first\n\n"))
+	if _, err := io.ReadFull(resp.Body, event); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	entries := recorder.Entries()
+	if len(entries) != 1 || entries[0].Response.Content.Text != "data: first\n\n" || entries[0].Response.Content.Size != len(event) {
+		t.Errorf("unexpected entries %+v", entries)
+	}
+}
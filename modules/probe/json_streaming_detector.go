@@ -201,6 +201,19 @@ func (e *EnhancedJSONBoundaryDetector) DetectBoundary(data []byte, offset int) (
 	return pos, size, found
 }
 
+// detectAccumulated detects on data that already holds every partial
+// message, without buffering it again.
+func (e *EnhancedJSONBoundaryDetector) detectAccumulated(data []byte, offset int) (int, int, bool) {
+	e.buffer = e.buffer[:0]
+	e.detector.Reset()
+	return e.detector.DetectBoundary(data, offset)
+}
+
+// recognizes reports whether data starts a JSON object or array.
+func (e *EnhancedJSONBoundaryDetector) recognizes(data []byte) bool {
+	return startsJSON(data)
+}
+
 // MinMessageSize returns minimum valid JSON size
 func (e *EnhancedJSONBoundaryDetector) MinMessageSize() int {
 	return e.detector.MinMessageSize()
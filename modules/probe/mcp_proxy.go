@@ -0,0 +1,591 @@
+package probe
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/macawi-ai/strigoi/pkg/security"
+)
+
+// Directions of relayed MCP traffic.
+const (
+	MCPClientToServer = "client_to_server"
+	MCPServerToClient = "server_to_client"
+)
+
+// Policy decisions recorded for each relayed message.
+const (
+	mcpDecisionPass   = "pass"
+	mcpDecisionModify = "modify"
+	mcpDecisionReject = "reject"
+	mcpDecisionHold   = "hold"
+)
+
+// mcpPolicyErrorCode is the JSON-RPC error returned for rejected requests,
+// from the range reserved for implementation-defined server errors.
+const mcpPolicyErrorCode = -32001
+
+// mcpMaxFrameSize bounds a message when the policy sets no max_payload, so
+// a peer cannot make the proxy allocate without limit.
+const mcpMaxFrameSize = 64 << 20
+
+// MCPApprover asks whether a held message may pass; summary describes it.
+type MCPApprover func(summary string) bool
+
+// MCPProxy relays MCP stdio traffic between a client and a server and
+// enforces a policy on each JSON-RPC message: it passes it, modifies it,
+// rejects it with a JSON-RPC error or holds it for approval. Every decision
+// is logged.
+type MCPProxy struct {
+	policy     *security.MCPPolicy
+	logger     *EventLogger
+	credHunter *CredentialHunter
+	approver   MCPApprover
+	approveMu  sync.Mutex
+
+	// Writes to either side come from both directions
+	client *syncWriter
+	server *syncWriter
+
+	mu        sync.Mutex
+	pending   map[string]string // request id -> method, for responses
+	decisions map[string]int
+}
+
+// mcpFrame is one message read from a stdio stream, with its framing.
+type mcpFrame struct {
+	raw           []byte // as read
+	body          []byte
+	contentLength bool
+	oversize      int // size of a message too large to read, which was discarded
+}
+
+// mcpOutcome is what happens to one message: what is forwarded and what is
+// replied to the sender in its place.
+type mcpOutcome struct {
+	forward json.RawMessage
+	reply   json.RawMessage
+}
+
+// NewMCPProxy creates a proxy enforcing policy between client and server.
+func NewMCPProxy(policy *security.MCPPolicy, logger *EventLogger, client, server io.Writer) *MCPProxy {
+	return &MCPProxy{
+		policy:     policy,
+		logger:     logger,
+		credHunter: NewCredentialHunter(),
+		client:     &syncWriter{w: client},
+		server:     &syncWriter{w: server},
+		pending:    make(map[string]string),
+		decisions:  make(map[string]int),
+	}
+}
+
+// SetApprover sets how held messages are approved. Without one they are
+// rejected.
+func (p *MCPProxy) SetApprover(approver MCPApprover) {
+	p.approver = approver
+}
+
+// Relay reads messages from src, which is the client for MCPClientToServer
+// and the server otherwise, and applies the policy to each. observe, if set,
+// receives every message as read. Relay returns when src ends or, for
+// client traffic, when the server can no longer be written to.
+func (p *MCPProxy) Relay(src io.Reader, direction string, observe func([]byte)) error {
+	dst, sender := p.server, p.client
+	if direction == MCPServerToClient {
+		dst, sender = p.client, p.server
+	}
+
+	limit := mcpMaxFrameSize
+	if p.policy.MaxPayload > 0 {
+		limit = p.policy.MaxPayload
+	}
+
+	reader := bufio.NewReaderSize(src, launchReadSize)
+	for {
+		frame, err := readMCPFrame(reader, limit)
+		if len(frame.raw) > 0 || frame.oversize > 0 {
+			if observe != nil {
+				observe(frame.raw)
+			}
+			forward, reply := p.process(frame, direction)
+			if forward != nil {
+				if _, werr := dst.Write(forward); werr != nil && direction == MCPClientToServer {
+					return werr
+				}
+			}
+			if reply != nil {
+				_, _ = sender.Write(reply)
+			}
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, os.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// Summary reports the policy in force and how many messages each decision
+// applied to.
+func (p *MCPProxy) Summary() map[string]interface{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	decisions := make(map[string]int, len(p.decisions))
+	for k, v := range p.decisions {
+		decisions[k] = v
+	}
+	return map[string]interface{}{
+		"name":      p.policy.Name,
+		"version":   p.policy.Version,
+		"decisions": decisions,
+	}
+}
+
+// process decides a frame and returns the bytes to forward and to reply
+// with; either may be nil. Data from the server that is not JSON-RPC passes
+// unchanged.
+func (p *MCPProxy) process(frame mcpFrame, direction string) ([]byte, []byte) {
+	if frame.oversize > 0 {
+		// Dropped unread, so there is no request id to answer
+		verdict := p.policy.CheckPayload(frame.oversize)
+		if verdict.Action != security.MCPPolicyDeny {
+			verdict.Action = security.MCPPolicyDeny
+			verdict.Reason = fmt.Sprintf("message of %d bytes exceeds the %d byte frame limit", frame.oversize, mcpMaxFrameSize)
+		}
+		p.record(direction, nil, "", "", mcpDecisionReject, verdict, frame.oversize, nil)
+		return nil, nil
+	}
+
+	body := bytes.TrimSpace(frame.body)
+	if len(body) == 0 {
+		return frame.raw, nil
+	}
+
+	var batch []json.RawMessage
+	isBatch := body[0] == '['
+	if isBatch {
+		if err := json.Unmarshal(body, &batch); err != nil {
+			isBatch = false
+		}
+	}
+	if !isBatch {
+		outcome, changed := p.decide(body, direction, len(body))
+		if !changed {
+			return frame.raw, nil
+		}
+		return frame.encode(outcome.forward), frame.encode(outcome.reply)
+	}
+
+	// Batch members are decided one by one and regrouped
+	var forward, reply []json.RawMessage
+	changed := false
+	for _, msg := range batch {
+		outcome, c := p.decide(msg, direction, len(body))
+		changed = changed || c
+		if outcome.forward != nil {
+			forward = append(forward, outcome.forward)
+		}
+		if outcome.reply != nil {
+			reply = append(reply, outcome.reply)
+		}
+	}
+	if !changed {
+		return frame.raw, nil
+	}
+	var fwd, rep []byte
+	if len(forward) > 0 {
+		fwd, _ = json.Marshal(forward)
+	}
+	if len(reply) > 0 {
+		rep, _ = json.Marshal(reply)
+	}
+	return frame.encode(fwd), frame.encode(rep)
+}
+
+// decide applies the policy to one JSON-RPC message and logs the decision.
+// size is that of the frame carrying it. changed is false when the message
+// passes untouched.
+func (p *MCPProxy) decide(data []byte, direction string, size int) (mcpOutcome, bool) {
+	pass := mcpOutcome{forward: json.RawMessage(data)}
+
+	var msg mcpMessage
+	if err := json.Unmarshal(data, &msg); err != nil || msg.JSONRPC != "2.0" {
+		verdict := security.MCPPolicyVerdict{Rule: "json-rpc", Reason: "not a JSON-RPC 2.0 message"}
+		if direction == MCPClientToServer {
+			// The checks below cannot see into it, so the server must not
+			// either
+			verdict.Action = security.MCPPolicyDeny
+			p.record(direction, nil, "", "", mcpDecisionReject, verdict, size, nil)
+			return mcpOutcome{}, true
+		}
+		p.record(direction, nil, "", "", mcpDecisionPass, verdict, size, nil)
+		return pass, false
+	}
+	id := string(msg.ID)
+	method := msg.Method
+	if method == "" && id != "" {
+		// Responses are attributed to the request they answer
+		p.mu.Lock()
+		method = p.pending[id]
+		delete(p.pending, id)
+		p.mu.Unlock()
+	}
+
+	if verdict := p.policy.CheckPayload(size); verdict.Action == security.MCPPolicyDeny {
+		p.record(direction, msg.ID, method, "", mcpDecisionReject, verdict, size, nil)
+		return p.reject(msg, verdict), true
+	}
+
+	// encoding/json keeps the last of duplicate keys and matches keys case
+	// insensitively, so the server could act on a method, tool or argument
+	// other than the one checked
+	if direction == MCPClientToServer {
+		if key, ok := ambiguousJSONKey(data); ok {
+			verdict := security.MCPPolicyVerdict{
+				Action: security.MCPPolicyDeny,
+				Rule:   "ambiguous-keys",
+				Reason: fmt.Sprintf("duplicate or case-variant key %q", key),
+			}
+			p.record(direction, msg.ID, method, "", mcpDecisionReject, verdict, size, nil)
+			return p.reject(msg, verdict), true
+		}
+	}
+
+	// Requests sent to the server are checked against the policy
+	tool := ""
+	if direction == MCPClientToServer && msg.Method != "" {
+		var verdict security.MCPPolicyVerdict
+		switch msg.Method {
+		case "tools/call":
+			var params struct {
+				Name      string                 `json:"name"`
+				Arguments map[string]interface{} `json:"arguments"`
+			}
+			_ = json.Unmarshal(msg.Params, &params)
+			tool = params.Name
+			verdict = p.policy.CheckToolCall(params.Name, params.Arguments)
+		case "resources/read":
+			var params struct {
+				URI string `json:"uri"`
+			}
+			_ = json.Unmarshal(msg.Params, &params)
+			verdict = p.policy.CheckResource(params.URI)
+		default:
+			verdict = security.MCPPolicyVerdict{Action: security.MCPPolicyAllow}
+		}
+
+		if verdict.Action == security.MCPPolicyApprove {
+			p.record(direction, msg.ID, msg.Method, tool, mcpDecisionHold, verdict, size, nil)
+			verdict = p.approve(msg, tool, verdict)
+		}
+		if verdict.Action == security.MCPPolicyDeny {
+			p.record(direction, msg.ID, msg.Method, tool, mcpDecisionReject, verdict, size, nil)
+			return p.reject(msg, verdict), true
+		}
+
+		if id != "" {
+			p.mu.Lock()
+			p.pending[id] = msg.Method
+			p.mu.Unlock()
+		}
+		p.record(direction, msg.ID, msg.Method, tool, mcpDecisionPass, verdict, size, nil)
+		return pass, false
+	}
+
+	// Results returned to the client are redacted
+	if direction == MCPServerToClient && len(msg.Result) > 0 {
+		var result interface{}
+		if err := json.Unmarshal(msg.Result, &result); err == nil {
+			redacted, kinds := p.redact(result)
+			if len(kinds) > 0 {
+				modified := map[string]interface{}{"jsonrpc": "2.0", "id": msg.ID, "result": redacted}
+				if data, err := json.Marshal(modified); err == nil {
+					verdict := security.MCPPolicyVerdict{Action: security.MCPPolicyAllow, Rule: "redact", Reason: "secrets redacted from result"}
+					p.record(direction, msg.ID, method, "", mcpDecisionModify, verdict, size, kinds)
+					return mcpOutcome{forward: data}, true
+				}
+			}
+		}
+	}
+
+	p.record(direction, msg.ID, method, "", mcpDecisionPass, security.MCPPolicyVerdict{Action: security.MCPPolicyAllow}, size, nil)
+	return pass, false
+}
+
+// ambiguousJSONKey returns a key that appears in one object more than once,
+// exactly or differing only in case.
+func ambiguousJSONKey(data []byte) (string, bool) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var walk func() (string, bool)
+	walk = func() (string, bool) {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", false
+		}
+		switch tok {
+		case json.Delim('{'):
+			var seen []string
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return "", false
+				}
+				key, _ := keyTok.(string)
+				// EqualFold folds case the way encoding/json matches keys
+				for _, other := range seen {
+					if strings.EqualFold(key, other) {
+						return key, true
+					}
+				}
+				seen = append(seen, key)
+				if key, ok := walk(); ok {
+					return key, true
+				}
+			}
+			_, _ = dec.Token()
+		case json.Delim('['):
+			for dec.More() {
+				if key, ok := walk(); ok {
+					return key, true
+				}
+			}
+			_, _ = dec.Token()
+		}
+		return "", false
+	}
+	return walk()
+}
+
+// approve holds a message until the approver decides. Approvals are asked
+// one at a time.
+func (p *MCPProxy) approve(msg mcpMessage, tool string, verdict security.MCPPolicyVerdict) security.MCPPolicyVerdict {
+	if p.approver == nil {
+		verdict.Action = security.MCPPolicyDeny
+		verdict.Reason = "approval required but no approver is available"
+		return verdict
+	}
+
+	summary := msg.Method
+	if tool != "" {
+		summary += " " + tool
+	}
+	if len(msg.Params) > 0 {
+		summary += " " + truncateLLMText(string(msg.Params), 200)
+	}
+
+	p.approveMu.Lock()
+	approved := p.approver(summary)
+	p.approveMu.Unlock()
+	if approved {
+		verdict.Action = security.MCPPolicyAllow
+		verdict.Reason = "approved"
+	} else {
+		verdict.Action = security.MCPPolicyDeny
+		verdict.Reason = "approval denied"
+	}
+	return verdict
+}
+
+// reject replaces msg: a request is answered with a JSON-RPC error, while a
+// notification or response is dropped. A dropped response is replaced by an
+// error so the client is not left waiting.
+func (p *MCPProxy) reject(msg mcpMessage, verdict security.MCPPolicyVerdict) mcpOutcome {
+	if len(msg.ID) == 0 {
+		return mcpOutcome{}
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      msg.ID,
+		"error": map[string]interface{}{
+			"code":    mcpPolicyErrorCode,
+			"message": "Blocked by strigoi policy: " + verdict.Reason,
+			"data": map[string]interface{}{
+				"policy": p.policy.Name,
+				"rule":   verdict.Rule,
+			},
+		},
+	})
+	if msg.Method == "" {
+		return mcpOutcome{forward: data}
+	}
+	return mcpOutcome{reply: data}
+}
+
+// redact removes secrets from every string in a decoded JSON value and
+// returns it with the kinds of secret removed.
+func (p *MCPProxy) redact(value interface{}) (interface{}, []string) {
+	var kinds []string
+	var walk func(v interface{}) interface{}
+	walk = func(v interface{}) interface{} {
+		switch t := v.(type) {
+		case string:
+			if p.policy.RedactSecrets {
+				for _, cred := range p.credHunter.Hunt([]byte(t)) {
+					if strings.Contains(t, cred.Value) {
+						t = strings.ReplaceAll(t, cred.Value, cred.Redacted)
+						kinds = append(kinds, cred.Type)
+					}
+				}
+			}
+			if redacted, n := p.policy.RedactText(t); n > 0 {
+				t = redacted
+				kinds = append(kinds, "pattern")
+			}
+			return t
+		case []interface{}:
+			for i := range t {
+				t[i] = walk(t[i])
+			}
+		case map[string]interface{}:
+			for k := range t {
+				t[k] = walk(t[k])
+			}
+		}
+		return v
+	}
+	return walk(value), kinds
+}
+
+// record logs a decision and counts it.
+func (p *MCPProxy) record(direction string, id json.RawMessage, method, tool, decision string, verdict security.MCPPolicyVerdict, size int, redacted []string) {
+	p.mu.Lock()
+	p.decisions[decision]++
+	p.mu.Unlock()
+
+	if p.logger == nil {
+		return
+	}
+	event := map[string]interface{}{
+		"policy":    p.policy.Name,
+		"direction": direction,
+		"decision":  decision,
+		"size":      size,
+	}
+	if len(id) > 0 {
+		event["id"] = string(id)
+	}
+	if method != "" {
+		event["method"] = method
+	}
+	if tool != "" {
+		event["tool"] = tool
+	}
+	if verdict.Rule != "" {
+		event["rule"] = verdict.Rule
+	}
+	if verdict.Reason != "" {
+		event["reason"] = verdict.Reason
+	}
+	if len(redacted) > 0 {
+		event["redacted"] = redacted
+	}
+	if err := p.logger.LogEvent("policy_decision", event); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to log policy decision: %v\n", err)
+	}
+}
+
+// readMCPFrame reads one stdio message: a Content-Length framed message or
+// a line of newline-delimited JSON. A body longer than limit, or a line
+// longer than mcpMaxFrameSize, is discarded unread and only its size is
+// returned.
+func readMCPFrame(r *bufio.Reader, limit int) (mcpFrame, error) {
+	line, size, err := readMCPLine(r)
+	if line == nil && size > 0 {
+		return mcpFrame{oversize: size}, err
+	}
+	lower := bytes.ToLower(line)
+	if !bytes.HasPrefix(lower, []byte("content-length:")) && !bytes.HasPrefix(lower, []byte("content-type:")) {
+		return mcpFrame{raw: line, body: line}, err
+	}
+
+	// Headers end at a blank line
+	raw := line
+	length := -1
+	for {
+		if name, value, ok := bytes.Cut(line, []byte(":")); ok && strings.EqualFold(string(bytes.TrimSpace(name)), "Content-Length") {
+			if n, convErr := strconv.Atoi(string(bytes.TrimSpace(value))); convErr == nil {
+				length = n
+			}
+		}
+		if err != nil {
+			return mcpFrame{raw: raw}, err
+		}
+		line, size, err = readMCPLine(r)
+		if line == nil && size > 0 {
+			return mcpFrame{oversize: size}, err
+		}
+		raw = append(raw, line...)
+		if len(bytes.TrimSpace(line)) == 0 {
+			if err != nil {
+				return mcpFrame{raw: raw}, err
+			}
+			break
+		}
+	}
+	if length < 0 {
+		return mcpFrame{raw: raw}, nil
+	}
+	if length > limit {
+		_, err := io.CopyN(io.Discard, r, int64(length))
+		return mcpFrame{oversize: length}, err
+	}
+
+	body := make([]byte, length)
+	n, err := io.ReadFull(r, body)
+	raw = append(raw, body[:n]...)
+	if err != nil {
+		return mcpFrame{raw: raw}, err
+	}
+	return mcpFrame{raw: raw, body: body, contentLength: true}, nil
+}
+
+// readMCPLine reads up to and including the next newline. A line longer
+// than mcpMaxFrameSize is discarded: line is nil and size is its length.
+func readMCPLine(r *bufio.Reader) (line []byte, size int, err error) {
+	for {
+		chunk, err := r.ReadSlice('\n')
+		size += len(chunk)
+		if size <= mcpMaxFrameSize {
+			line = append(line, chunk...)
+		} else {
+			line = nil
+		}
+		if err != bufio.ErrBufferFull {
+			return line, size, err
+		}
+	}
+}
+
+// encode frames data the way the frame it replaces was framed.
+func (f mcpFrame) encode(data []byte) []byte {
+	if data == nil {
+		return nil
+	}
+	if f.contentLength {
+		return append([]byte(fmt.Sprintf("Content-Length: %d\r\n\r\n", len(data))), data...)
+	}
+	return append(data, '\n')
+}
+
+// syncWriter serializes writes to w.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(data []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(data)
+}
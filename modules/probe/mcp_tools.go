@@ -0,0 +1,478 @@
+package probe
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// MCPTool is a tool definition advertised in a tools/list result.
+type MCPTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"inputSchema,omitempty"`
+}
+
+var (
+	// Directives that try to override the host's instructions
+	overridePattern = regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override)\s+(all\s+|any\s+|the\s+)?(previous|prior|above|earlier|system|other)\s+(instructions?|prompts?|rules|messages|directions)|\byou are now\b|\bnew instructions\b`)
+
+	// Markup and phrasing used to hide instructions from the user
+	hiddenInstructionPattern = regexp.MustCompile(`(?i)<\s*/?\s*(important|system|instructions?|secret|hidden|admin)\s*>|\b(do not|don't|never)\s+(tell|mention|inform|reveal|show|notify)\b[^.]{0,40}\buser\b|\bwithout\s+(telling|informing|notifying)\b[^.]{0,20}\buser\b|\bbefore using this tool\b`)
+
+	// References to credential material
+	sensitiveFilePattern = regexp.MustCompile(`(?i)(~|\$home)?/?\.(ssh|aws|kube|docker|gnupg|netrc|env)\b|id_rsa|id_ed25519|mcp\.json|claude_desktop_config|\.cursor/|credentials\.json`)
+
+	// URLs in descriptions, which rarely belong there
+	urlPattern = regexp.MustCompile(`(?i)\b(?:https?|ftp|wss?)://[^\s"'<>)]+`)
+)
+
+// exfiltrationHosts are request catchers and tunnels commonly used to
+// receive stolen data.
+var exfiltrationHosts = []string{
+	"webhook.site", "requestbin", "pipedream.net", "ngrok", "burpcollaborator",
+	"interact.sh", "oast.", "beeceptor", "hookbin", "requestcatcher", "trycloudflare.com",
+}
+
+// ToolPoisoningAnalyzer inspects advertised tool definitions for content
+// aimed at the model rather than the user.
+type ToolPoisoningAnalyzer struct {
+	mu    sync.Mutex
+	tools map[string]map[string]bool // server -> tool names
+}
+
+// NewToolPoisoningAnalyzer creates a new tool definition analyzer.
+func NewToolPoisoningAnalyzer() *ToolPoisoningAnalyzer {
+	return &ToolPoisoningAnalyzer{
+		tools: make(map[string]map[string]bool),
+	}
+}
+
+// Analyze checks the tools advertised by server.
+func (a *ToolPoisoningAnalyzer) Analyze(server string, tools []MCPTool) []StreamVulnerability {
+	a.mu.Lock()
+	names := make(map[string]bool, len(tools))
+	for _, tool := range tools {
+		names[tool.Name] = true
+	}
+	a.tools[server] = names
+
+	// Tools owned by other servers, for shadowing checks
+	foreign := make(map[string]string)
+	for other, otherTools := range a.tools {
+		if other == server {
+			continue
+		}
+		for name := range otherTools {
+			foreign[name] = other
+		}
+	}
+	a.mu.Unlock()
+
+	var vulns []StreamVulnerability
+	for _, tool := range tools {
+		text := toolText(tool)
+		location := fmt.Sprintf("MCP tools/list %s/%s", server, tool.Name)
+
+		add := func(subtype, severity, evidence, context string, confidence float64) {
+			vulns = append(vulns, StreamVulnerability{
+				ID:         fmt.Sprintf("MCP-TOOL-%d", len(vulns)),
+				Timestamp:  time.Now(),
+				Severity:   severity,
+				Type:       "tool_poisoning",
+				Subtype:    subtype,
+				Evidence:   evidence,
+				Location:   location,
+				Context:    context,
+				Confidence: confidence,
+			})
+		}
+
+		if hidden := invisibleRunes(text); len(hidden) > 0 {
+			add("invisible_unicode", "high", strings.Join(hidden, " "),
+				"Tool definition contains invisible or bidirectional Unicode that can hide instructions from the user", 0.9)
+		}
+		if match := overridePattern.FindString(text); match != "" {
+			add("instruction_override", "critical", match,
+				"Tool definition tells the model to disregard its instructions", 0.9)
+		}
+		if match := hiddenInstructionPattern.FindString(text); match != "" {
+			add("hidden_instructions", "high", match,
+				"Tool definition carries instructions intended for the model and concealed from the user", 0.8)
+		}
+		for _, u := range urlPattern.FindAllString(text, -1) {
+			severity := "medium"
+			if isExfiltrationURL(u) {
+				severity = "critical"
+			}
+			add("exfiltration_url", severity, u,
+				"Tool definition embeds a URL the model may be induced to send data to", 0.7)
+		}
+		if match := sensitiveFilePattern.FindString(text); match != "" {
+			add("sensitive_file_reference", "high", match,
+				"Tool definition references credential files the tool has no reason to read", 0.75)
+		}
+
+		lower := strings.ToLower(tool.Description)
+		for name, owner := range foreign {
+			lowerName := strings.ToLower(name)
+			if len(name) < 3 || genericToolNames[lowerName] || !containsWord(lower, lowerName) {
+				continue
+			}
+			// A single word may be mentioned in passing; a compound name
+			// only refers to the tool
+			severity, confidence := "medium", 0.5
+			if strings.ContainsAny(name, "_-.") {
+				severity, confidence = "high", 0.8
+			}
+			add("tool_shadowing", severity, fmt.Sprintf("%s references %s from %s", tool.Name, name, owner),
+				"Tool description tries to change how another server's tool is used", confidence)
+		}
+	}
+
+	return vulns
+}
+
+// genericToolNames are tool names common enough that a description using
+// the word says nothing about another server's tool.
+var genericToolNames = map[string]bool{
+	"fetch": true, "search": true, "read": true, "write": true, "get": true,
+	"set": true, "list": true, "query": true, "run": true, "open": true,
+	"find": true, "create": true, "update": true, "delete": true, "send": true,
+	"call": true, "execute": true, "exec": true, "echo": true, "add": true,
+	"remove": true, "save": true, "load": true, "upload": true, "download": true,
+	"help": true, "status": true, "info": true, "ping": true, "time": true,
+}
+
+// toolText flattens the description and every string in the input schema.
+func toolText(tool MCPTool) string {
+	parts := []string{tool.Description}
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch val := v.(type) {
+		case string:
+			parts = append(parts, val)
+		case map[string]interface{}:
+			for k, child := range val {
+				parts = append(parts, k)
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range val {
+				walk(child)
+			}
+		}
+	}
+	walk(tool.InputSchema)
+	return strings.Join(parts, "\n")
+}
+
+// invisibleRunes lists the invisible and bidirectional control characters in s.
+func invisibleRunes(s string) []string {
+	var found []string
+	seen := make(map[rune]bool)
+	for _, r := range s {
+		// Format characters cover zero-width spaces and joiners, bidi
+		// overrides and isolates, the BOM, soft hyphens and Unicode tags
+		invisible := unicode.Is(unicode.Cf, r)
+		if invisible && !seen[r] {
+			seen[r] = true
+			found = append(found, fmt.Sprintf("U+%04X", r))
+		}
+	}
+	return found
+}
+
+// isExfiltrationURL reports whether u points at a known request catcher.
+func isExfiltrationURL(u string) bool {
+	lower := strings.ToLower(u)
+	for _, host := range exfiltrationHosts {
+		if strings.Contains(lower, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsWord reports whether word appears in s delimited by non-identifier
+// characters.
+func containsWord(s, word string) bool {
+	for i := 0; ; {
+		idx := strings.Index(s[i:], word)
+		if idx < 0 {
+			return false
+		}
+		start, end := i+idx, i+idx+len(word)
+		before := start == 0 || !isIdentByte(s[start-1])
+		after := end == len(s) || !isIdentByte(s[end])
+		if before && after {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+func isIdentByte(b byte) bool {
+	return b == '_' || b == '-' || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9')
+}
+
+// MCPManifest is the approved tool manifest of one server.
+type MCPManifest struct {
+	Key       string            `json:"key"`    // how the server is launched or reached; see MCPServerKey
+	Server    string            `json:"server"` // name the server reported, for display only
+	Hash      string            `json:"hash"`
+	Tools     map[string]string `json:"tools"` // tool name -> definition hash
+	FirstSeen time.Time         `json:"first_seen"`
+	LastSeen  time.Time         `json:"last_seen"`
+}
+
+// MCPManifestChange describes how a server's tools differ from the approved
+// manifest.
+type MCPManifestChange struct {
+	Server   string   `json:"server"`
+	Added    []string `json:"added,omitempty"`
+	Removed  []string `json:"removed,omitempty"`
+	Modified []string `json:"modified,omitempty"`
+}
+
+// MCPServerKey identifies a server for its manifest: the URL it is reached
+// at, or the command line it is launched with. The name a server reports
+// is not used, since any server can claim any name.
+func MCPServerKey(command string, args []string, url string) string {
+	if url != "" {
+		return url
+	}
+	return strings.TrimSpace(strings.Join(append([]string{command}, args...), " "))
+}
+
+// MCPManifestStore remembers each server's tool manifest across sessions,
+// keyed by MCPServerKey. The first manifest seen is treated as approved
+// (trust on first use). Sessions sharing the file lock it while they update
+// it.
+type MCPManifestStore struct {
+	path      string
+	mu        sync.Mutex
+	manifests map[string]*MCPManifest
+}
+
+// NewMCPManifestStore loads the store at path. An empty path keeps the
+// store in memory only.
+func NewMCPManifestStore(path string) (*MCPManifestStore, error) {
+	store := &MCPManifestStore{
+		path:      path,
+		manifests: make(map[string]*MCPManifest),
+	}
+	if path == "" {
+		return store, nil
+	}
+
+	manifests, err := readMCPManifests(path)
+	if err != nil {
+		return nil, err
+	}
+	store.manifests = manifests
+	return store, nil
+}
+
+// readMCPManifests reads the manifests saved at path, if any.
+func readMCPManifests(path string) (map[string]*MCPManifest, error) {
+	manifests := make(map[string]*MCPManifest)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return manifests, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read MCP manifest store: %w", err)
+	}
+	if err := json.Unmarshal(data, &manifests); err != nil {
+		return nil, fmt.Errorf("failed to parse MCP manifest store: %w", err)
+	}
+	return manifests, nil
+}
+
+// DefaultMCPManifestPath returns ~/.strigoi/mcp_manifests.json.
+func DefaultMCPManifestPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".strigoi", "mcp_manifests.json")
+}
+
+// Observe compares tools with the approved manifest of the server
+// identified by key; server is the name it reported. It returns nil for a
+// first sighting or an unchanged manifest. The store is only written when
+// a first sighting adds a manifest.
+func (s *MCPManifestStore) Observe(key, server string, tools []MCPTool) (*MCPManifestChange, error) {
+	current := make(map[string]string, len(tools))
+	for _, tool := range tools {
+		current[tool.Name] = hashMCPTool(tool)
+	}
+	hash := hashMCPManifest(current)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	approved, ok := s.manifests[key]
+	if !ok {
+		now := time.Now()
+		err := s.update(func(manifests map[string]*MCPManifest) {
+			// Another session may have approved one meanwhile
+			if _, ok := manifests[key]; !ok {
+				manifests[key] = &MCPManifest{
+					Key:       key,
+					Server:    server,
+					Hash:      hash,
+					Tools:     current,
+					FirstSeen: now,
+					LastSeen:  now,
+				}
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		approved = s.manifests[key]
+	}
+
+	approved.LastSeen = time.Now()
+	if approved.Hash == hash {
+		return nil, nil
+	}
+
+	change := &MCPManifestChange{Server: server}
+	if server == "" {
+		change.Server = key
+	}
+	for name, h := range current {
+		old, existed := approved.Tools[name]
+		switch {
+		case !existed:
+			change.Added = append(change.Added, name)
+		case old != h:
+			change.Modified = append(change.Modified, name)
+		}
+	}
+	for name := range approved.Tools {
+		if _, ok := current[name]; !ok {
+			change.Removed = append(change.Removed, name)
+		}
+	}
+	sort.Strings(change.Added)
+	sort.Strings(change.Removed)
+	sort.Strings(change.Modified)
+
+	// The change stays unapproved until Approve accepts it
+	return change, nil
+}
+
+// Approve accepts the given tools as the new manifest of the server
+// identified by key; server is the name it reported.
+func (s *MCPManifestStore) Approve(key, server string, tools []MCPTool) error {
+	current := make(map[string]string, len(tools))
+	for _, tool := range tools {
+		current[tool.Name] = hashMCPTool(tool)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	return s.update(func(manifests map[string]*MCPManifest) {
+		manifest, ok := manifests[key]
+		if !ok {
+			manifest = &MCPManifest{Key: key, FirstSeen: now}
+			manifests[key] = manifest
+		}
+		if server != "" {
+			manifest.Server = server
+		}
+		manifest.Tools = current
+		manifest.Hash = hashMCPManifest(current)
+		manifest.LastSeen = now
+	})
+}
+
+// update applies change to the manifests and saves them. The file is
+// locked and read again first, so changes made by other sessions since it
+// was loaded are kept, and it is replaced by a rename so readers never see
+// it half written. Callers hold s.mu.
+func (s *MCPManifestStore) update(change func(map[string]*MCPManifest)) error {
+	if s.path == "" {
+		change(s.manifests)
+		return nil
+	}
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create manifest directory: %w", err)
+	}
+
+	unlock, err := lockFile(s.path + ".lock")
+	if err != nil {
+		return fmt.Errorf("failed to lock MCP manifest store: %w", err)
+	}
+	defer unlock()
+
+	manifests, err := readMCPManifests(s.path)
+	if err != nil {
+		return err
+	}
+	change(manifests)
+	data, err := json.MarshalIndent(manifests, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save MCP manifest store: %w", err)
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to save MCP manifest store: %w", err)
+	}
+	s.manifests = manifests
+	return nil
+}
+
+// hashMCPTool hashes a canonical encoding of one tool definition.
+func hashMCPTool(tool MCPTool) string {
+	// encoding/json sorts map keys, which makes the schema canonical
+	data, _ := json.Marshal(tool)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hashMCPManifest hashes the sorted tool hashes of a manifest.
+func hashMCPManifest(tools map[string]string) string {
+	names := make([]string, 0, len(tools))
+	for name := range tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		h.Write([]byte(name))
+		h.Write([]byte{0})
+		h.Write([]byte(tools[name]))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -1,6 +1,7 @@
 package probe
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -8,10 +9,14 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/macawi-ai/strigoi/pkg/engagement"
 	"github.com/macawi-ai/strigoi/pkg/modules"
+	"golang.org/x/sync/errgroup"
 )
 
 func init() {
@@ -28,8 +33,12 @@ type NorthModule struct {
 	aiPreset     string
 	includeLocal bool
 	delay        int // milliseconds between requests
+	concurrency  int // concurrent requests per host
 	discovered   []EndpointResponse
 	providers    map[string]*AIProvider
+	scheduler    *engagement.Scheduler
+	harFile      string
+	har          *HARRecorder
 }
 
 // NewNorthModule creates a new probe north module instance.
@@ -82,13 +91,28 @@ func NewNorthModule() modules.Module {
 					Type:        "int",
 					Default:     "100",
 				},
+				"concurrency": {
+					Name:        "concurrency",
+					Description: "Concurrent requests per host",
+					Required:    false,
+					Type:        "int",
+					Default:     "4",
+				},
+				"har": {
+					Name:        "har",
+					Description: "Write HTTP exchanges to this HAR file",
+					Required:    false,
+					Type:        "string",
+					Default:     "",
+				},
 			},
 		},
-		timeout:   10,
-		userAgent: "Strigoi/0.5.0",
-		aiPreset:  "basic",
-		delay:     100,
-		providers: GetAIProviders(),
+		timeout:     10,
+		userAgent:   "Strigoi/0.5.0",
+		aiPreset:    "basic",
+		delay:       100,
+		concurrency: 4,
+		providers:   GetAIProviders(),
 	}
 	return m
 }
@@ -123,6 +147,17 @@ func (m *NorthModule) SetOption(name, value string) error {
 			return fmt.Errorf("invalid delay value: %v", err)
 		}
 		m.delay = delay
+	case "concurrency":
+		concurrency, err := modules.ParseInt(value)
+		if err != nil {
+			return fmt.Errorf("invalid concurrency value: %v", err)
+		}
+		if concurrency < 1 {
+			return fmt.Errorf("concurrency must be at least 1")
+		}
+		m.concurrency = concurrency
+	case "har":
+		m.harFile = value
 	default:
 		return m.BaseModule.SetOption(name, value)
 	}
@@ -169,6 +204,9 @@ func (m *NorthModule) Run() (*modules.ModuleResult, error) {
 		Data:      make(map[string]interface{}),
 	}
 
+	// All outbound requests go through the engagement scheduler
+	m.scheduler = m.requestScheduler()
+
 	// Check if target is a directory for local AI config scanning
 	if m.target == "." || strings.HasPrefix(m.target, "/") || strings.HasPrefix(m.target, "./") {
 		return m.scanDirectoryForAIConfigs(result)
@@ -183,6 +221,13 @@ func (m *NorthModule) Run() (*modules.ModuleResult, error) {
 		},
 	}
 
+	// Archive every exchange when a HAR file is requested
+	m.har = nil
+	if m.harFile != "" {
+		m.har = harRecorderFor(m.harFile)
+		client.Transport = m.har.Transport(m.Name(), client.Transport)
+	}
+
 	// Try ethical discovery first
 	if provider, confidence := m.ethicalDiscoveryProbe(client); provider != "" {
 		// Store the discovered provider with high confidence
@@ -198,32 +243,49 @@ func (m *NorthModule) Run() (*modules.ModuleResult, error) {
 
 	m.discovered = []EndpointResponse{}
 
-	// Probe main target with AI endpoints
+	// Probe main target with AI endpoints; the scheduler paces and bounds
+	// the fan-out so we only need to cap outstanding goroutines here
+	var mu sync.Mutex
+	var g errgroup.Group
+	g.SetLimit(m.concurrency)
+
 	for _, endpoint := range endpoints {
 		targetURL := strings.TrimRight(m.target, "/") + endpoint
 
 		// Try GET and POST methods (most AI endpoints use these)
-		methods := []string{"GET", "POST"}
-
-		for _, method := range methods {
-			resp, err := m.probeAIEndpoint(client, targetURL, method)
-			if err != nil {
-				continue
-			}
-
-			// Only save meaningful responses
-			if resp.StatusCode < 500 && resp.StatusCode != 0 {
-				m.discovered = append(m.discovered, *resp)
-			}
+		for _, method := range []string{"GET", "POST"} {
+			g.Go(func() error {
+				resp, err := m.probeAIEndpoint(client, targetURL, method)
+				if err != nil {
+					return nil
+				}
 
-			// Add delay between requests
-			time.Sleep(time.Duration(m.delay) * time.Millisecond)
+				// Only save meaningful responses
+				if resp.StatusCode < 500 && resp.StatusCode != 0 {
+					mu.Lock()
+					m.discovered = append(m.discovered, *resp)
+					mu.Unlock()
+				}
+				return nil
+			})
 		}
 	}
+	_ = g.Wait()
+
+	// Keep output stable regardless of completion order
+	sort.SliceStable(m.discovered, func(i, j int) bool {
+		if m.discovered[i].URL != m.discovered[j].URL {
+			return m.discovered[i].URL < m.discovered[j].URL
+		}
+		return m.discovered[i].Method < m.discovered[j].Method
+	})
 
 	// Check local ports if requested
 	m.probeLocalPorts(client)
 
+	// Check browser-origin exposure of what was found
+	m.checkCORS(client)
+
 	// Analyze findings
 	aiServicesFound := m.analyzeFindings()
 
@@ -242,6 +304,7 @@ func (m *NorthModule) Run() (*modules.ModuleResult, error) {
 			ContentType: contentType,
 			Headers:     make(map[string]string),
 			Timestamp:   time.Now(),
+			HAREntryID:  resp.HAREntryID,
 		}
 
 		// Add important headers
@@ -271,9 +334,70 @@ func (m *NorthModule) Run() (*modules.ModuleResult, error) {
 	result.Data["ai_services"] = aiServicesFound
 	result.Data["target"] = m.target
 
+	// Report the TLS posture of HTTPS targets
+	if assessment := m.assessTLS(); assessment != nil {
+		result.Data["tls"] = assessment
+	}
+
+	result.Data["engagement"] = m.scheduler.Stats()
+
+	if m.har != nil {
+		if err := m.har.WriteFile(m.harFile); err != nil {
+			return nil, err
+		}
+		result.Data["har_file"] = m.harFile
+	}
+
 	return result, nil
 }
 
+// assessTLS runs the TLS assessor against an HTTPS target.
+func (m *NorthModule) assessTLS() *TLSAssessment {
+	u, err := url.Parse(m.target)
+	if err != nil || u.Scheme != "https" {
+		return nil
+	}
+	port := u.Port()
+	if port == "" {
+		port = "443"
+	}
+
+	assessor := NewTLSAssessor(m.Name(), time.Duration(m.timeout)*time.Second)
+	assessor.Scheduler = m.scheduler
+
+	assessment, err := assessor.Assess(context.Background(), u.Hostname(), port)
+	if err != nil {
+		return nil
+	}
+	return assessment
+}
+
+// requestScheduler returns the shared engagement scheduler, paced by the
+// module's delay and concurrency options where the engagement sets no
+// limits, or a scheduler of its own when none is installed.
+func (m *NorthModule) requestScheduler() *engagement.Scheduler {
+	rps := 0.0
+	if m.delay > 0 {
+		rps = 1000.0 / float64(m.delay)
+	}
+	if s := engagement.Default(); s != nil {
+		return s.Derive(rps, 1, m.concurrency)
+	}
+	return engagement.NewScheduler(engagement.SchedulerConfig{
+		RequestsPerSecond:  rps,
+		Burst:              1,
+		PerHostConcurrency: m.concurrency,
+	})
+}
+
+// send dispatches a request through the engagement scheduler.
+func (m *NorthModule) send(client *http.Client, req *http.Request) (*http.Response, error) {
+	if m.scheduler == nil {
+		m.scheduler = m.requestScheduler()
+	}
+	return m.scheduler.Do(context.Background(), m.Name(), client, req)
+}
+
 // scanDirectoryForAIConfigs scans a directory for AI service configurations
 func (m *NorthModule) scanDirectoryForAIConfigs(result *modules.ModuleResult) (*modules.ModuleResult, error) {
 	discoveredConfigs := []map[string]interface{}{}
@@ -394,7 +518,11 @@ func (m *NorthModule) scanDirectoryForAIConfigs(result *modules.ModuleResult) (*
 		for port, service := range localPorts {
 			url := fmt.Sprintf("http://localhost:%d/health", port)
 			client := &http.Client{Timeout: 2 * time.Second}
-			if resp, err := client.Get(url); err == nil {
+			req, err := http.NewRequest("GET", url, nil)
+			if err != nil {
+				continue
+			}
+			if resp, err := m.send(client, req); err == nil {
 				resp.Body.Close()
 				if resp.StatusCode < 500 {
 					discoveredConfigs = append(discoveredConfigs, map[string]interface{}{
@@ -438,7 +566,7 @@ func (m *NorthModule) probeEndpoint(client *http.Client, targetURL, method strin
 
 	req.Header.Set("User-Agent", m.userAgent)
 
-	resp, err := client.Do(req)
+	resp, err := m.send(client, req)
 	if err != nil {
 		return modules.EndpointInfo{}, err
 	}
@@ -526,7 +654,10 @@ func (m *NorthModule) probeAIEndpoint(client *http.Client, targetURL, method str
 	req.Header.Set("User-Agent", m.userAgent)
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := client.Do(req)
+	resp, err := m.send(client, req)
+	if engagement.IsOutOfScope(err) {
+		return nil, err
+	}
 	if err != nil {
 		return &EndpointResponse{
 			URL:    targetURL,
@@ -547,6 +678,7 @@ func (m *NorthModule) probeAIEndpoint(client *http.Client, targetURL, method str
 		Headers:    resp.Header,
 		Body:       body,
 		Security:   []SecurityFinding{},
+		HAREntryID: m.har.EntryID(resp),
 	}
 
 	// Analyze response for AI provider fingerprints
@@ -597,6 +729,7 @@ func (m *NorthModule) analyzeAIResponse(resp *EndpointResponse) {
 					Name:        check.Name,
 					Description: check.Description,
 					Evidence:    resp.URL,
+					HAREntryID:  resp.HAREntryID,
 				})
 			}
 		}
@@ -633,14 +766,44 @@ func (m *NorthModule) probeLocalPorts(client *http.Client) {
 			resp, err := m.probeAIEndpoint(client, targetURL, "GET")
 			if err == nil && resp.StatusCode > 0 && resp.StatusCode < 500 {
 				m.discovered = append(m.discovered, *resp)
-
-				// Add delay between requests
-				time.Sleep(time.Duration(m.delay) * time.Millisecond)
 			}
 		}
 	}
 }
 
+// checkCORS sends attacker-controlled Origin values to each discovered AI or
+// access-controlled endpoint and records permissive cross-origin policies.
+func (m *NorthModule) checkCORS(client *http.Client) {
+	checker := &CORSChecker{
+		Do:        func(req *http.Request) (*http.Response, error) { return m.send(client, req) },
+		HAR:       m.har,
+		UserAgent: m.userAgent,
+	}
+
+	checked := make(map[string]bool)
+	for i := range m.discovered {
+		resp := &m.discovered[i]
+		if checked[resp.URL] || resp.Error != nil {
+			continue
+		}
+		authenticated := resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden
+		if resp.Provider == "" && resp.StatusCode != http.StatusOK && !authenticated {
+			continue
+		}
+		checked[resp.URL] = true
+
+		for _, finding := range checker.Check(context.Background(), resp.URL, authenticated) {
+			resp.Security = append(resp.Security, SecurityFinding{
+				Severity:    strings.ToLower(finding.Severity),
+				Name:        finding.Name,
+				Description: finding.Description,
+				Evidence:    fmt.Sprintf("%s %s -> %s", finding.Request, resp.URL, finding.Response),
+				HAREntryID:  finding.HAREntryID,
+			})
+		}
+	}
+}
+
 // analyzeFindings processes discovered endpoints to identify AI services.
 func (m *NorthModule) analyzeFindings() map[string]interface{} {
 	aiServices := make(map[string]interface{})
@@ -718,6 +881,7 @@ func (m *NorthModule) GetDiscoveredEndpoints() []modules.EndpointInfo {
 			ContentType: contentType,
 			Headers:     make(map[string]string),
 			Timestamp:   time.Now(),
+			HAREntryID:  resp.HAREntryID,
 		}
 
 		// Add important headers
@@ -771,14 +935,14 @@ func (m *NorthModule) ethicalDiscoveryProbe(client *http.Client) (string, float6
 		req.Header.Set("X-API-Key", "ETHICAL_DISCOVERY_PROBE")
 		req.Header.Set("Accept", "application/json")
 
-		resp, err := client.Do(req)
+		resp, err := m.send(client, req)
 		if err != nil {
 			continue
 		}
-		defer resp.Body.Close()
 
-		// Read the error response
+		// Read the error response and release the connection before the next probe
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, 8192))
+		resp.Body.Close()
 
 		// Analyze error response for provider fingerprints
 		if provider, confidence := m.analyzeErrorResponse(resp.StatusCode, resp.Header, body); provider != "" {
@@ -96,8 +96,7 @@ func (pab *ProtocolAwareBuffer) scanForProtocolEvents(scanBuf []byte,
 	packedRead := pab.readIndex.Load()
 	readVer, readIdx := unpackIndex(packedRead)
 
-	packedWrite := pab.writeIndex.Load()
-	_, writeIdx := unpackIndex(packedWrite)
+	writeIdx := pab.committed()
 
 	if readIdx >= writeIdx {
 		return // No new data
@@ -29,6 +29,30 @@ type BoundaryDetector interface {
 	Name() string
 }
 
+// accumulatingDetector is implemented by detectors that keep partial
+// messages between calls, for callers that pass one chunk at a time.
+type accumulatingDetector interface {
+	// detectAccumulated detects on data that already holds every partial
+	// message.
+	detectAccumulated(data []byte, offset int) (int, int, bool)
+}
+
+// messageRecognizer is implemented by detectors that can tell the start of
+// a message of their protocol before it is complete. Auto-detection waits
+// for such a message rather than cutting it with a more generic detector.
+type messageRecognizer interface {
+	recognizes(data []byte) bool
+}
+
+// detectAccumulated finds the next boundary in data that holds everything
+// buffered so far, as EventBuffer passes it.
+func detectAccumulated(detector BoundaryDetector, data []byte, offset int) (int, int, bool) {
+	if a, ok := detector.(accumulatingDetector); ok {
+		return a.detectAccumulated(data, offset)
+	}
+	return detector.DetectBoundary(data, offset)
+}
+
 // NewProtocolBoundaryDetector creates a detector with common protocols.
 func NewProtocolBoundaryDetector() *ProtocolBoundaryDetector {
 	detector := &ProtocolBoundaryDetector{
@@ -71,6 +95,79 @@ func (p *ProtocolBoundaryDetector) DetectProtocol(data []byte) (protocol string,
 	return "", -1, 0
 }
 
+// Detector returns the detector registered under name.
+func (p *ProtocolBoundaryDetector) Detector(name string) (BoundaryDetector, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	detector, ok := p.detectors[name]
+	return detector, ok
+}
+
+// DetectBoundary finds the next boundary with the first registered detector
+// that recognizes the data, so that the set can serve as one
+// auto-detecting BoundaryDetector. A message whose start a detector
+// recognizes is not found until it is complete.
+func (p *ProtocolBoundaryDetector) DetectBoundary(data []byte, offset int) (int, int, bool) {
+	return p.detect(data, offset, BoundaryDetector.DetectBoundary)
+}
+
+// detectAccumulated is DetectBoundary for data that holds everything
+// buffered so far.
+func (p *ProtocolBoundaryDetector) detectAccumulated(data []byte, offset int) (int, int, bool) {
+	return p.detect(data, offset, detectAccumulated)
+}
+
+func (p *ProtocolBoundaryDetector) detect(data []byte, offset int,
+	detect func(BoundaryDetector, []byte, int) (int, int, bool)) (int, int, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, name := range p.order {
+		detector := p.detectors[name]
+		if r, ok := detector.(messageRecognizer); ok {
+			if !r.recognizes(data[offset:]) {
+				continue
+			}
+			return detect(detector, data, offset)
+		}
+		if pos, size, found := detect(detector, data, offset); found {
+			return pos, size, true
+		}
+	}
+	return -1, 0, false
+}
+
+// MinMessageSize returns the smallest minimum of the registered detectors.
+func (p *ProtocolBoundaryDetector) MinMessageSize() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	size := 0
+	for i, name := range p.order {
+		if n := p.detectors[name].MinMessageSize(); i == 0 || n < size {
+			size = n
+		}
+	}
+	return size
+}
+
+// MaxMessageSize returns the largest maximum of the registered detectors.
+func (p *ProtocolBoundaryDetector) MaxMessageSize() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	size := 0
+	for _, name := range p.order {
+		if n := p.detectors[name].MaxMessageSize(); n > size {
+			size = n
+		}
+	}
+	return size
+}
+
+// Name returns "auto".
+func (p *ProtocolBoundaryDetector) Name() string { return "auto" }
+
 // HTTPBoundaryDetector detects HTTP message boundaries.
 type HTTPBoundaryDetector struct {
 	requestPattern  []byte
@@ -132,6 +229,9 @@ func (h *HTTPBoundaryDetector) DetectBoundary(data []byte, offset int) (int, int
 
 	if contentLength >= 0 {
 		// Fixed content length
+		if len(data) < headerEndPos+contentLength {
+			return -1, 0, false // Incomplete body
+		}
 		totalSize := (headerEndPos - start) + contentLength
 		return headerEndPos + contentLength, totalSize, true
 	}
@@ -150,6 +250,26 @@ func (h *HTTPBoundaryDetector) DetectBoundary(data []byte, offset int) (int, int
 	return headerEndPos, headerEndPos - start, true
 }
 
+// recognizes reports whether data starts with a request method or a
+// status line, or with the first bytes of one.
+func (h *HTTPBoundaryDetector) recognizes(data []byte) bool {
+	for _, start := range httpMessageStarts {
+		n := min(len(data), len(start))
+		if n > 0 && bytes.Equal(data[:n], start[:n]) {
+			return true
+		}
+	}
+	return false
+}
+
+// httpMessageStarts are the prefixes of HTTP requests and responses.
+var httpMessageStarts = [][]byte{
+	[]byte("GET "), []byte("POST "), []byte("PUT "),
+	[]byte("DELETE "), []byte("HEAD "), []byte("OPTIONS "),
+	[]byte("PATCH "), []byte("CONNECT "), []byte("TRACE "),
+	[]byte("HTTP/"),
+}
+
 func (h *HTTPBoundaryDetector) parseContentLength(headers []byte) int {
 	pattern := []byte("Content-Length: ")
 	idx := bytes.Index(headers, pattern)
@@ -244,6 +364,15 @@ func (g *GRPCBoundaryDetector) DetectBoundary(data []byte, offset int) (int, int
 	return endPos, totalSize, true
 }
 
+// recognizes reports whether data starts with a plausible message header:
+// a compression flag of 0 or 1 and a length within the maximum.
+func (g *GRPCBoundaryDetector) recognizes(data []byte) bool {
+	if len(data) == 0 || data[0] > 1 {
+		return false
+	}
+	return len(data) < 5 || binary.BigEndian.Uint32(data[1:5]) <= uint32(g.MaxMessageSize())
+}
+
 func (g *GRPCBoundaryDetector) MinMessageSize() int { return 5 }
 func (g *GRPCBoundaryDetector) MaxMessageSize() int { return 4 * 1024 * 1024 }
 func (g *GRPCBoundaryDetector) Name() string        { return "gRPC" }
@@ -311,6 +440,17 @@ func (w *WebSocketBoundaryDetector) DetectBoundary(data []byte, offset int) (int
 	return endPos, totalSize, true
 }
 
+// recognizes reports whether data starts with a final frame header: FIN
+// set, no reserved bits but the compression bit, and a known opcode. Text
+// never starts this way, and fragments are left to complete detection.
+func (w *WebSocketBoundaryDetector) recognizes(data []byte) bool {
+	if len(data) == 0 || data[0]&0x80 == 0 || data[0]&0x30 != 0 {
+		return false
+	}
+	opcode := data[0] & 0x0F
+	return opcode <= 2 || (opcode >= 8 && opcode <= 10)
+}
+
 func (w *WebSocketBoundaryDetector) MinMessageSize() int { return 2 }
 func (w *WebSocketBoundaryDetector) MaxMessageSize() int { return 64 * 1024 * 1024 }
 func (w *WebSocketBoundaryDetector) Name() string        { return "WebSocket" }
@@ -388,6 +528,26 @@ func (j *JSONBoundaryDetector) DetectBoundary(data []byte, offset int) (int, int
 	return -1, 0, false // Incomplete JSON
 }
 
+// recognizes reports whether data starts a JSON object or array.
+func (j *JSONBoundaryDetector) recognizes(data []byte) bool {
+	return startsJSON(data)
+}
+
+// startsJSON reports whether data, after whitespace, opens a JSON object or
+// an array whose first element is a value. Bracketed log prefixes such as
+// "[INFO]" are not arrays.
+func startsJSON(data []byte) bool {
+	data = bytes.TrimLeft(data, " \t\r\n")
+	if len(data) == 0 || (data[0] != '{' && data[0] != '[') {
+		return false
+	}
+	if data[0] == '{' {
+		return true
+	}
+	data = bytes.TrimLeft(data[1:], " \t\r\n")
+	return len(data) == 0 || bytes.IndexByte([]byte(`{["-0123456789tfn]`), data[0]) >= 0
+}
+
 func (j *JSONBoundaryDetector) MinMessageSize() int { return 2 } // "{}"
 func (j *JSONBoundaryDetector) MaxMessageSize() int { return 10 * 1024 * 1024 }
 func (j *JSONBoundaryDetector) Name() string        { return "JSON" }
@@ -398,8 +558,14 @@ type LineBoundaryDetector struct {
 }
 
 func NewLineBoundaryDetector() *LineBoundaryDetector {
+	return NewDelimiterBoundaryDetector([]byte("\n"))
+}
+
+// NewDelimiterBoundaryDetector detects messages ending in delimiter, such
+// as "\r\n".
+func NewDelimiterBoundaryDetector(delimiter []byte) *LineBoundaryDetector {
 	return &LineBoundaryDetector{
-		delimiter: []byte("\n"),
+		delimiter: delimiter,
 	}
 }
 
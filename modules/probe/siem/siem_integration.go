@@ -3,6 +3,8 @@ package siem
 import (
 	"context"
 	"fmt"
+	"net"
+	"strconv"
 	"strings"
 	"time"
 
@@ -186,6 +188,76 @@ func (c *EventConverter) ConvertFrame(frame *probe.Frame, sessionID string, vuln
 	return events
 }
 
+// ConvertTLSAssessment converts a TLS posture assessment into one event per
+// finding, or a single observation event when nothing was found
+func (c *EventConverter) ConvertTLSAssessment(assessment *probe.TLSAssessment, sessionID string) []*SecurityEvent {
+	if assessment == nil {
+		return nil
+	}
+
+	baseEvent := &SecurityEvent{
+		Timestamp:       time.Now(),
+		SessionID:       sessionID,
+		Protocol:        "TLS",
+		StrigoiVersion:  "1.0.0",
+		EventCategory:   []string{"network", "configuration"},
+		NetworkProtocol: "TLS",
+		TLSVersion:      assessment.NegotiatedVersion,
+		TLSCipher:       assessment.NegotiatedCipher,
+		Tags:            []string{"strigoi", "TLS"},
+		Labels: map[string]string{
+			"tls.host":      assessment.Host,
+			"tls.protocols": strings.Join(assessment.Protocols, ","),
+		},
+	}
+	if ip := net.ParseIP(assessment.Host); ip != nil {
+		baseEvent.DestinationIP = ip.String()
+	}
+	if port, err := strconv.Atoi(assessment.Port); err == nil {
+		baseEvent.DestinationPort = port
+	}
+	if assessment.Certificate != nil {
+		baseEvent.TLSSubject = assessment.Certificate.Subject
+	}
+
+	var events []*SecurityEvent
+	for _, finding := range assessment.Findings {
+		event := *baseEvent // Copy base event
+
+		event.EventType = "vulnerability_detected"
+		event.EventCategory = append(append([]string{}, baseEvent.EventCategory...), "vulnerability")
+		event.EventSeverity = c.severityToECS(strings.ToLower(finding.Severity))
+		event.EventRisk = fmt.Sprintf("%d", event.EventSeverity*20) // Simple risk score
+
+		event.Message = fmt.Sprintf("TLS weakness detected: %s - %s", finding.Name, finding.Description)
+
+		event.VulnerabilityType = finding.Category
+		event.VulnerabilityName = finding.Name
+
+		event.Evidence = map[string]string{
+			"details": finding.Evidence.Details,
+		}
+		event.Remediation = finding.Remediation
+
+		event.Tags = append(append([]string{}, baseEvent.Tags...),
+			fmt.Sprintf("severity:%s", c.severityToString(strings.ToLower(finding.Severity))),
+		)
+
+		events = append(events, &event)
+	}
+
+	if len(events) == 0 {
+		event := *baseEvent
+		event.EventType = "protocol_observation"
+		event.EventSeverity = 0 // Info level
+		event.Message = fmt.Sprintf("TLS configuration assessed: %s %s",
+			assessment.NegotiatedVersion, assessment.NegotiatedCipher)
+		events = append(events, &event)
+	}
+
+	return events
+}
+
 // createVulnerabilityEvent creates an event for a detected vulnerability
 func (c *EventConverter) createVulnerabilityEvent(base *SecurityEvent, vuln *probe.Vulnerability) *SecurityEvent {
 	event := *base // Copy base event
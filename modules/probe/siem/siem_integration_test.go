@@ -77,6 +77,50 @@ func TestEventConverter_ConvertFrame(t *testing.T) {
 	}
 }
 
+func TestEventConverter_ConvertTLSAssessment(t *testing.T) {
+	converter := NewEventConverter(ConverterConfig{})
+
+	assessment := &probe.TLSAssessment{
+		Host:              "127.0.0.1",
+		Port:              "8443",
+		Protocols:         []string{"TLS 1.0", "TLS 1.2"},
+		NegotiatedVersion: "TLS 1.2",
+		NegotiatedCipher:  "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+		Certificate:       &probe.TLSCertificateInfo{Subject: "CN=example.com"},
+		Findings: []probe.AuthVulnerability{
+			{Name: "Deprecated TLS Protocol Enabled", Severity: "Medium", Category: "Transport Security"},
+			{Name: "Self-Signed Certificate", Severity: "High", Category: "Transport Security"},
+		},
+	}
+
+	events := converter.ConvertTLSAssessment(assessment, "session-tls")
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+
+	for _, event := range events {
+		if event.TLSVersion != "TLS 1.2" || event.TLSCipher != "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256" {
+			t.Errorf("TLS fields not set: %s / %s", event.TLSVersion, event.TLSCipher)
+		}
+		if event.TLSSubject != "CN=example.com" {
+			t.Errorf("TLSSubject = %s, want CN=example.com", event.TLSSubject)
+		}
+		if event.DestinationIP != "127.0.0.1" || event.DestinationPort != 8443 {
+			t.Errorf("Destination = %s:%d", event.DestinationIP, event.DestinationPort)
+		}
+	}
+
+	if events[1].EventSeverity != 73 || events[1].VulnerabilityName != "Self-Signed Certificate" {
+		t.Errorf("Unexpected event: severity %d, name %s", events[1].EventSeverity, events[1].VulnerabilityName)
+	}
+
+	assessment.Findings = nil
+	events = converter.ConvertTLSAssessment(assessment, "session-tls")
+	if len(events) != 1 || events[0].EventType != "protocol_observation" {
+		t.Errorf("Expected a single observation event, got %+v", events)
+	}
+}
+
 func TestDataMaskingEngine(t *testing.T) {
 	engine := NewDataMaskingEngine()
 
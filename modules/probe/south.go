@@ -75,6 +75,13 @@ func NewSouthModule() modules.Module {
 					Required:    false,
 					Default:     false,
 				},
+				"rules": {
+					Name:        "rules",
+					Description: "Comma-separated rule pack files (JSON or YAML) added to MCP scanning",
+					Type:        "string",
+					Required:    false,
+					Default:     "",
+				},
 				"include_self": {
 					Name:        "include_self",
 					Description: "Include Strigoi's own files and processes in scan",
@@ -176,6 +183,14 @@ func (m *SouthModule) Run() (*modules.ModuleResult, error) {
 		includeSelf := includeSelfOpt.(bool)
 		m.mcpScanner.SetIncludeSelf(includeSelf)
 
+		if rulesOpt, _ := m.GetOption("rules"); rulesOpt != nil {
+			if rules, ok := rulesOpt.(string); ok && rules != "" {
+				if err := m.mcpScanner.LoadRules(strings.Split(rules, ",")); err != nil {
+					return nil, fmt.Errorf("failed to load rules: %w", err)
+				}
+			}
+		}
+
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 		mcpTools, mcpErr := m.mcpScanner.DiscoverMCPTools(ctx)
@@ -185,6 +200,7 @@ func (m *SouthModule) Run() (*modules.ModuleResult, error) {
 		} else {
 			result.MCPTools = mcpTools
 		}
+		result.RulePacks = m.mcpScanner.RulePacks()
 	}
 
 	// Calculate summary
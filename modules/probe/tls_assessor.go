@@ -0,0 +1,555 @@
+package probe
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/macawi-ai/strigoi/pkg/engagement"
+)
+
+const (
+	// certExpiryWarning is how far ahead an expiring certificate is reported.
+	certExpiryWarning = 30 * 24 * time.Hour
+
+	// hstsPreloadMaxAge is the minimum max-age accepted by the HSTS preload list.
+	hstsPreloadMaxAge = 31536000
+)
+
+// TLSAssessment describes the TLS posture of a single host.
+type TLSAssessment struct {
+	Host              string              `json:"host"`
+	Port              string              `json:"port"`
+	Protocols         []string            `json:"protocols"`
+	CipherSuites      map[string][]string `json:"cipher_suites"`
+	NegotiatedVersion string              `json:"negotiated_version"`
+	NegotiatedCipher  string              `json:"negotiated_cipher"`
+	Certificate       *TLSCertificateInfo `json:"certificate,omitempty"`
+	OCSPStapled       bool                `json:"ocsp_stapled"`
+	HSTS              string              `json:"hsts,omitempty"`
+	HSTSPreloadable   bool                `json:"hsts_preloadable"`
+	Findings          []AuthVulnerability `json:"findings,omitempty"`
+}
+
+// TLSCertificateInfo summarizes the leaf certificate and chain validation.
+type TLSCertificateInfo struct {
+	Subject            string    `json:"subject"`
+	Issuer             string    `json:"issuer"`
+	DNSNames           []string  `json:"dns_names,omitempty"`
+	NotBefore          time.Time `json:"not_before"`
+	NotAfter           time.Time `json:"not_after"`
+	KeyType            string    `json:"key_type"`
+	KeyBits            int       `json:"key_bits"`
+	SignatureAlgorithm string    `json:"signature_algorithm"`
+	SelfSigned         bool      `json:"self_signed"`
+	NameMismatch       string    `json:"name_mismatch,omitempty"`
+	ChainValid         bool      `json:"chain_valid"`
+	ChainError         string    `json:"chain_error,omitempty"`
+}
+
+// TLSAssessor enumerates the protocol versions and cipher suites a server
+// accepts and inspects its certificate chain, OCSP stapling and HSTS policy.
+type TLSAssessor struct {
+	Timeout   time.Duration
+	Scheduler *engagement.Scheduler // optional; gates every handshake and request
+	Module    string
+	Client    *http.Client   // used for the HSTS check
+	Roots     *x509.CertPool // nil uses the system roots
+	Now       func() time.Time
+}
+
+// NewTLSAssessor creates an assessor that reports as module.
+func NewTLSAssessor(module string, timeout time.Duration) *TLSAssessor {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &TLSAssessor{
+		Timeout: timeout,
+		Module:  module,
+		Client: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				// The chain is validated separately; this client only reads headers
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+			CheckRedirect: func(_ *http.Request, _ []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		Now: time.Now,
+	}
+}
+
+// tlsVersions lists the protocol versions probed, oldest first.
+var tlsVersions = []uint16{tls.VersionTLS10, tls.VersionTLS11, tls.VersionTLS12, tls.VersionTLS13}
+
+// Assess handshakes with host:port and returns its TLS posture.
+func (a *TLSAssessor) Assess(ctx context.Context, host, port string) (*TLSAssessment, error) {
+	if port == "" {
+		port = "443"
+	}
+
+	assessment := &TLSAssessment{
+		Host:         host,
+		Port:         port,
+		CipherSuites: make(map[string][]string),
+	}
+
+	// Protocol versions
+	var supported []uint16
+	for _, version := range tlsVersions {
+		state, err := a.handshake(ctx, host, port, &tls.Config{
+			MinVersion: version,
+			MaxVersion: version,
+		})
+		if err != nil {
+			if engagement.IsOutOfScope(err) || ctx.Err() != nil {
+				return nil, err
+			}
+			continue
+		}
+		supported = append(supported, version)
+		assessment.Protocols = append(assessment.Protocols, tls.VersionName(version))
+		if version == tls.VersionTLS13 {
+			assessment.CipherSuites[tls.VersionName(version)] = []string{tls.CipherSuiteName(state.CipherSuite)}
+		}
+	}
+	if len(supported) == 0 {
+		return nil, fmt.Errorf("no TLS handshake with %s succeeded", net.JoinHostPort(host, port))
+	}
+
+	// Cipher suites for versions where the client controls the offer
+	for _, version := range supported {
+		if version == tls.VersionTLS13 {
+			continue
+		}
+		assessment.CipherSuites[tls.VersionName(version)] = a.enumerateCipherSuites(ctx, host, port, version)
+	}
+
+	// Default handshake for the negotiated parameters and certificate chain
+	state, err := a.handshake(ctx, host, port, &tls.Config{MinVersion: tls.VersionTLS10})
+	if err != nil {
+		return nil, err
+	}
+	assessment.NegotiatedVersion = tls.VersionName(state.Version)
+	assessment.NegotiatedCipher = tls.CipherSuiteName(state.CipherSuite)
+	assessment.OCSPStapled = len(state.OCSPResponse) > 0
+	if len(state.PeerCertificates) > 0 {
+		assessment.Certificate = a.inspectChain(host, state.PeerCertificates)
+	}
+
+	// HSTS policy
+	assessment.HSTS = a.fetchHSTS(ctx, host, port)
+	preloadIssues := hstsPreloadIssues(assessment.HSTS)
+	assessment.HSTSPreloadable = len(preloadIssues) == 0
+
+	assessment.Findings = a.findings(assessment, supported, preloadIssues)
+	return assessment, nil
+}
+
+// handshake performs one TLS handshake with cfg and returns its state.
+func (a *TLSAssessor) handshake(ctx context.Context, host, port string, cfg *tls.Config) (tls.ConnectionState, error) {
+	addr := net.JoinHostPort(host, port)
+
+	if a.Scheduler != nil {
+		release, err := a.Scheduler.Acquire(ctx, a.Module, "TLS", &url.URL{Scheme: "https", Host: addr})
+		if err != nil {
+			return tls.ConnectionState{}, err
+		}
+		defer release()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, a.Timeout)
+	defer cancel()
+
+	dialer := &net.Dialer{}
+	rawConn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return tls.ConnectionState{}, err
+	}
+	defer rawConn.Close()
+
+	cfg = cfg.Clone()
+	cfg.ServerName = host
+	cfg.InsecureSkipVerify = true // chain is verified in inspectChain
+
+	conn := tls.Client(rawConn, cfg)
+	if err := conn.HandshakeContext(ctx); err != nil {
+		return tls.ConnectionState{}, err
+	}
+	return conn.ConnectionState(), nil
+}
+
+// enumerateCipherSuites repeatedly offers the remaining suites and removes
+// whichever the server picks, until the server refuses all that are left.
+func (a *TLSAssessor) enumerateCipherSuites(ctx context.Context, host, port string, version uint16) []string {
+	var remaining []uint16
+	for _, list := range [][]*tls.CipherSuite{tls.CipherSuites(), tls.InsecureCipherSuites()} {
+		for _, suite := range list {
+			for _, v := range suite.SupportedVersions {
+				if v == version {
+					remaining = append(remaining, suite.ID)
+					break
+				}
+			}
+		}
+	}
+
+	var accepted []string
+	for len(remaining) > 0 {
+		state, err := a.handshake(ctx, host, port, &tls.Config{
+			MinVersion:   version,
+			MaxVersion:   version,
+			CipherSuites: remaining,
+		})
+		if err != nil {
+			break
+		}
+		accepted = append(accepted, tls.CipherSuiteName(state.CipherSuite))
+
+		next := remaining[:0]
+		for _, id := range remaining {
+			if id != state.CipherSuite {
+				next = append(next, id)
+			}
+		}
+		if len(next) == len(remaining) {
+			break
+		}
+		remaining = next
+	}
+
+	return accepted
+}
+
+// inspectChain validates the presented chain against host.
+func (a *TLSAssessor) inspectChain(host string, chain []*x509.Certificate) *TLSCertificateInfo {
+	leaf := chain[0]
+	info := &TLSCertificateInfo{
+		Subject:            leaf.Subject.String(),
+		Issuer:             leaf.Issuer.String(),
+		DNSNames:           leaf.DNSNames,
+		NotBefore:          leaf.NotBefore,
+		NotAfter:           leaf.NotAfter,
+		SignatureAlgorithm: leaf.SignatureAlgorithm.String(),
+		SelfSigned:         isSelfSigned(leaf),
+	}
+	info.KeyType, info.KeyBits = publicKeyInfo(leaf.PublicKey)
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	if err := leaf.VerifyHostname(host); err != nil {
+		info.NameMismatch = err.Error()
+	}
+
+	_, err := leaf.Verify(x509.VerifyOptions{
+		Intermediates: intermediates,
+		Roots:         a.Roots,
+		CurrentTime:   a.Now(),
+	})
+	info.ChainValid = err == nil
+	if err != nil {
+		info.ChainError = err.Error()
+	}
+
+	return info
+}
+
+// fetchHSTS returns the Strict-Transport-Security header served at the root.
+func (a *TLSAssessor) fetchHSTS(ctx context.Context, host, port string) string {
+	target := url.URL{Scheme: "https", Host: net.JoinHostPort(host, port), Path: "/"}
+	if port == "443" {
+		target.Host = host
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", target.String(), nil)
+	if err != nil {
+		return ""
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; Strigoi/1.0)")
+
+	var resp *http.Response
+	if a.Scheduler != nil {
+		resp, err = a.Scheduler.Do(ctx, a.Module, a.Client, req)
+	} else {
+		resp, err = a.Client.Do(req)
+	}
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+
+	return resp.Header.Get("Strict-Transport-Security")
+}
+
+// hstsPreloadIssues lists why an HSTS header is not eligible for preloading.
+func hstsPreloadIssues(header string) []string {
+	if header == "" {
+		return []string{"Strict-Transport-Security header not served"}
+	}
+
+	var issues []string
+	maxAge := -1
+	var includeSubDomains, preload bool
+	for _, directive := range strings.Split(header, ";") {
+		directive = strings.TrimSpace(directive)
+		name, value, _ := strings.Cut(directive, "=")
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "max-age":
+			if age, err := strconv.Atoi(strings.Trim(strings.TrimSpace(value), `"`)); err == nil {
+				maxAge = age
+			}
+		case "includesubdomains":
+			includeSubDomains = true
+		case "preload":
+			preload = true
+		}
+	}
+
+	if maxAge < hstsPreloadMaxAge {
+		issues = append(issues, fmt.Sprintf("max-age below %d seconds", hstsPreloadMaxAge))
+	}
+	if !includeSubDomains {
+		issues = append(issues, "includeSubDomains directive missing")
+	}
+	if !preload {
+		issues = append(issues, "preload directive missing")
+	}
+	return issues
+}
+
+// findings turns an assessment into vulnerabilities.
+func (a *TLSAssessor) findings(assessment *TLSAssessment, supported []uint16, preloadIssues []string) []AuthVulnerability {
+	target := net.JoinHostPort(assessment.Host, assessment.Port)
+	var vulns []AuthVulnerability
+
+	var deprecated []string
+	hasTLS13 := false
+	for _, version := range supported {
+		switch version {
+		case tls.VersionTLS10, tls.VersionTLS11:
+			deprecated = append(deprecated, tls.VersionName(version))
+		case tls.VersionTLS13:
+			hasTLS13 = true
+		}
+	}
+	if len(deprecated) > 0 {
+		vulns = append(vulns, newTLSVulnerability(
+			"Deprecated TLS Protocol Enabled", "Medium",
+			fmt.Sprintf("%s accepts %s", target, strings.Join(deprecated, ", ")),
+			"Protocols: "+strings.Join(assessment.Protocols, ", "),
+			"Disable TLS 1.0 and TLS 1.1 and require TLS 1.2 or later",
+			[]string{"RFC8996", "CWE-327"},
+		))
+	}
+	if !hasTLS13 {
+		vulns = append(vulns, newTLSVulnerability(
+			"TLS 1.3 Not Supported", "Low",
+			fmt.Sprintf("%s does not negotiate TLS 1.3", target),
+			"Protocols: "+strings.Join(assessment.Protocols, ", "),
+			"Enable TLS 1.3 for stronger defaults and forward secrecy",
+			[]string{"RFC8446"},
+		))
+	}
+
+	insecure := make(map[string]bool)
+	for _, suite := range tls.InsecureCipherSuites() {
+		insecure[suite.Name] = true
+	}
+	var weak, noForwardSecrecy []string
+	seen := make(map[string]bool)
+	for _, suites := range assessment.CipherSuites {
+		for _, name := range suites {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			if insecure[name] {
+				weak = append(weak, name)
+			}
+			if strings.HasPrefix(name, "TLS_RSA_") {
+				noForwardSecrecy = append(noForwardSecrecy, name)
+			}
+		}
+	}
+	if len(weak) > 0 {
+		vulns = append(vulns, newTLSVulnerability(
+			"Weak Cipher Suites Enabled", "Medium",
+			fmt.Sprintf("%s accepts cipher suites with known weaknesses", target),
+			"Cipher suites: "+strings.Join(weak, ", "),
+			"Remove RC4, 3DES and CBC-SHA1 suites from the server configuration",
+			[]string{"CWE-327"},
+		))
+	}
+	if len(noForwardSecrecy) > 0 {
+		vulns = append(vulns, newTLSVulnerability(
+			"Cipher Suites Without Forward Secrecy", "Low",
+			fmt.Sprintf("%s accepts static RSA key exchange", target),
+			"Cipher suites: "+strings.Join(noForwardSecrecy, ", "),
+			"Prefer ECDHE key exchange and disable TLS_RSA_* suites",
+			[]string{"CWE-326"},
+		))
+	}
+
+	if cert := assessment.Certificate; cert != nil {
+		vulns = append(vulns, a.certificateFindings(target, cert)...)
+	}
+
+	if !assessment.OCSPStapled {
+		vulns = append(vulns, newTLSVulnerability(
+			"OCSP Stapling Not Enabled", "Low",
+			fmt.Sprintf("%s does not staple an OCSP response", target),
+			"No OCSP response in the TLS handshake",
+			"Enable OCSP stapling so clients can check revocation without contacting the CA",
+			[]string{"RFC6066"},
+		))
+	}
+
+	if len(preloadIssues) > 0 {
+		vulns = append(vulns, newTLSVulnerability(
+			"HSTS Not Preload Eligible", "Low",
+			fmt.Sprintf("%s cannot be added to the HSTS preload list", target),
+			strings.Join(preloadIssues, "; "),
+			fmt.Sprintf("Serve Strict-Transport-Security: max-age=%d; includeSubDomains; preload", hstsPreloadMaxAge),
+			[]string{"RFC6797"},
+		))
+	}
+
+	return vulns
+}
+
+// certificateFindings reports problems with the leaf certificate and chain.
+func (a *TLSAssessor) certificateFindings(target string, cert *TLSCertificateInfo) []AuthVulnerability {
+	var vulns []AuthVulnerability
+	now := a.Now()
+
+	switch {
+	case now.After(cert.NotAfter):
+		vulns = append(vulns, newTLSVulnerability(
+			"Expired Certificate", "Critical",
+			fmt.Sprintf("The certificate for %s expired on %s", target, cert.NotAfter.Format("2006-01-02")),
+			"Subject: "+cert.Subject,
+			"Renew the certificate and automate renewal",
+			[]string{"CWE-298"},
+		))
+	case cert.NotAfter.Sub(now) < certExpiryWarning:
+		vulns = append(vulns, newTLSVulnerability(
+			"Certificate Expiring Soon", "Medium",
+			fmt.Sprintf("The certificate for %s expires on %s", target, cert.NotAfter.Format("2006-01-02")),
+			"Subject: "+cert.Subject,
+			"Renew the certificate before it expires and automate renewal",
+			[]string{"CWE-298"},
+		))
+	}
+
+	if cert.SelfSigned {
+		vulns = append(vulns, newTLSVulnerability(
+			"Self-Signed Certificate", "High",
+			fmt.Sprintf("%s presents a self-signed certificate", target),
+			"Subject: "+cert.Subject,
+			"Use a certificate issued by a trusted certificate authority",
+			[]string{"CWE-295"},
+		))
+	}
+
+	if cert.NameMismatch != "" {
+		vulns = append(vulns, newTLSVulnerability(
+			"Certificate Name Mismatch", "High",
+			fmt.Sprintf("The certificate for %s does not cover the requested host", target),
+			cert.NameMismatch,
+			"Issue a certificate whose subject alternative names include the host",
+			[]string{"CWE-297"},
+		))
+	}
+
+	if !cert.ChainValid && !cert.SelfSigned && !now.After(cert.NotAfter) {
+		vulns = append(vulns, newTLSVulnerability(
+			"Untrusted Certificate Chain", "High",
+			fmt.Sprintf("The certificate chain for %s does not validate", target),
+			cert.ChainError,
+			"Serve the full intermediate chain from a trusted certificate authority",
+			[]string{"CWE-295"},
+		))
+	}
+
+	weakKey := false
+	switch cert.KeyType {
+	case "RSA":
+		weakKey = cert.KeyBits < 2048
+	case "ECDSA":
+		weakKey = cert.KeyBits < 256
+	}
+	if weakKey {
+		vulns = append(vulns, newTLSVulnerability(
+			"Weak Certificate Key", "High",
+			fmt.Sprintf("The certificate for %s uses a %d-bit %s key", target, cert.KeyBits, cert.KeyType),
+			"Subject: "+cert.Subject,
+			"Reissue the certificate with an RSA key of at least 2048 bits or an ECDSA P-256 key",
+			[]string{"CWE-326"},
+		))
+	}
+
+	if strings.Contains(cert.SignatureAlgorithm, "SHA1") || strings.Contains(cert.SignatureAlgorithm, "MD5") {
+		vulns = append(vulns, newTLSVulnerability(
+			"Weak Certificate Signature", "Medium",
+			fmt.Sprintf("The certificate for %s is signed with %s", target, cert.SignatureAlgorithm),
+			"Subject: "+cert.Subject,
+			"Reissue the certificate with a SHA-256 or stronger signature",
+			[]string{"CWE-328"},
+		))
+	}
+
+	return vulns
+}
+
+// isSelfSigned reports whether cert is signed by its own key.
+func isSelfSigned(cert *x509.Certificate) bool {
+	if cert.Issuer.String() != cert.Subject.String() {
+		return false
+	}
+	err := cert.CheckSignatureFrom(cert)
+	return err == nil || errors.Is(err, x509.ErrUnsupportedAlgorithm)
+}
+
+// publicKeyInfo returns the key algorithm and size.
+func publicKeyInfo(key interface{}) (string, int) {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return "RSA", k.N.BitLen()
+	case *ecdsa.PublicKey:
+		return "ECDSA", k.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return "Ed25519", 256
+	}
+	return "unknown", 0
+}
+
+// newTLSVulnerability builds a transport security finding.
+func newTLSVulnerability(name, severity, description, details, remediation string, references []string) AuthVulnerability {
+	return AuthVulnerability{
+		ID:          fmt.Sprintf("WEST-%d", time.Now().Unix()),
+		Name:        name,
+		Severity:    severity,
+		Category:    "Transport Security",
+		Description: description,
+		Evidence:    Evidence{Details: details},
+		Remediation: remediation,
+		References:  references,
+		Confidence:  1.0,
+		Timestamp:   time.Now(),
+	}
+}
@@ -0,0 +1,130 @@
+package probe
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// newTLSStandIn starts an HTTPS server with the self-signed httptest
+// certificate, TLS 1.0 enabled and a short-lived HSTS policy.
+func newTLSStandIn(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Strict-Transport-Security", "max-age=300")
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{MinVersion: tls.VersionTLS10}
+	server.Config.ErrorLog = log.New(io.Discard, "", 0) // refused handshakes are expected
+	server.StartTLS()
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("invalid server URL: %v", err)
+	}
+	return server, u.Port()
+}
+
+func TestTLSAssessorReportsPosture(t *testing.T) {
+	server, port := newTLSStandIn(t)
+	defer server.Close()
+
+	assessor := NewTLSAssessor("probe/test", 5*time.Second)
+	assessment, err := assessor.Assess(context.Background(), "127.0.0.1", port)
+	if err != nil {
+		t.Fatalf("Assess failed: %v", err)
+	}
+
+	protocols := make(map[string]bool)
+	for _, p := range assessment.Protocols {
+		protocols[p] = true
+	}
+	if !protocols["TLS 1.0"] || !protocols["TLS 1.3"] {
+		t.Errorf("unexpected protocols: %v", assessment.Protocols)
+	}
+	if len(assessment.CipherSuites["TLS 1.2"]) < 2 {
+		t.Errorf("expected several TLS 1.2 suites, got %v", assessment.CipherSuites["TLS 1.2"])
+	}
+	if assessment.NegotiatedVersion != "TLS 1.3" {
+		t.Errorf("negotiated %s, want TLS 1.3", assessment.NegotiatedVersion)
+	}
+	if assessment.HSTS != "max-age=300" || assessment.HSTSPreloadable {
+		t.Errorf("unexpected HSTS result: %q preloadable=%v", assessment.HSTS, assessment.HSTSPreloadable)
+	}
+	if cert := assessment.Certificate; cert == nil || !cert.SelfSigned || cert.ChainValid {
+		t.Errorf("expected an untrusted self-signed certificate, got %+v", cert)
+	}
+
+	found := make(map[string]bool)
+	for _, vuln := range assessment.Findings {
+		found[vuln.Name] = true
+		if vuln.Category != "Transport Security" {
+			t.Errorf("unexpected category %q for %s", vuln.Category, vuln.Name)
+		}
+	}
+	for _, expected := range []string{
+		"Deprecated TLS Protocol Enabled",
+		"Self-Signed Certificate",
+		"OCSP Stapling Not Enabled",
+		"HSTS Not Preload Eligible",
+	} {
+		if !found[expected] {
+			t.Errorf("expected finding %q, got %v", expected, found)
+		}
+	}
+	if found["TLS 1.3 Not Supported"] || found["Certificate Name Mismatch"] {
+		t.Errorf("unexpected findings: %v", found)
+	}
+}
+
+func TestTLSAssessorCertificateChecks(t *testing.T) {
+	server, port := newTLSStandIn(t)
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	assessor := NewTLSAssessor("probe/test", 5*time.Second)
+	assessor.Roots = roots
+	assessor.Now = func() time.Time { return server.Certificate().NotAfter.Add(time.Hour) }
+
+	// The httptest certificate only covers example.com and loopback IPs
+	if _, err := net.LookupHost("localhost"); err != nil {
+		t.Skip("localhost does not resolve")
+	}
+	assessment, err := assessor.Assess(context.Background(), "localhost", port)
+	if err != nil {
+		t.Fatalf("Assess failed: %v", err)
+	}
+
+	found := make(map[string]bool)
+	for _, vuln := range assessment.Findings {
+		found[vuln.Name] = true
+	}
+	for _, expected := range []string{"Expired Certificate", "Certificate Name Mismatch"} {
+		if !found[expected] {
+			t.Errorf("expected finding %q, got %v", expected, found)
+		}
+	}
+}
+
+func TestHSTSPreloadIssues(t *testing.T) {
+	if issues := hstsPreloadIssues("max-age=63072000; includeSubDomains; preload"); len(issues) != 0 {
+		t.Errorf("expected header to be preload eligible, got %v", issues)
+	}
+	if issues := hstsPreloadIssues(`max-age="31536000"; preload`); len(issues) != 1 {
+		t.Errorf("expected only includeSubDomains to be missing, got %v", issues)
+	}
+	if issues := hstsPreloadIssues(""); len(issues) != 1 {
+		t.Errorf("expected missing header to be reported, got %v", issues)
+	}
+}
@@ -46,6 +46,8 @@ type SupplyChainResult struct {
 	Licenses        map[string]int     `json:"licenses"`
 	DependencyGraph *DependencyGraph   `json:"dependency_graph,omitempty"`
 	MCPTools        []security.MCPTool `json:"mcp_tools,omitempty"`
+	// Rule packs used for MCP scanning, with versions and checksums
+	RulePacks []security.RulePackInfo `json:"rule_packs,omitempty"`
 }
 
 // Summary provides high-level statistics.
@@ -101,6 +103,9 @@ type DataFlowResult struct {
 	Findings         []Finding         `json:"findings"`
 	DataFlows        []DataFlow        `json:"data_flows"`
 	ExternalServices []ExternalService `json:"external_services"`
+	// PIIByProvider counts personal data found in prompt templates,
+	// by LLM provider and PII type.
+	PIIByProvider map[string]map[string]int `json:"pii_by_provider,omitempty"`
 }
 
 // DataFlowSummary provides high-level statistics.
@@ -123,16 +128,27 @@ type Finding struct {
 	Remediation string   `json:"remediation"`
 	DataFlow    []string `json:"data_flow,omitempty"`
 	References  []string `json:"references,omitempty"`
+	Regulations []string `json:"regulations,omitempty"`
 }
 
 // DataFlow represents how data moves through the system.
 type DataFlow struct {
-	ID              string   `json:"id"`
-	Source          string   `json:"source"`
-	Transformations []string `json:"transformations"`
-	Destination     string   `json:"destination"`
-	SensitiveData   []string `json:"sensitive_data,omitempty"`
-	Protection      []string `json:"protection,omitempty"`
+	ID              string    `json:"id"`
+	Source          string    `json:"source"`
+	Transformations []string  `json:"transformations"`
+	Destination     string    `json:"destination"`
+	SensitiveData   []string  `json:"sensitive_data,omitempty"`
+	Protection      []string  `json:"protection,omitempty"`
+	Hops            []FlowHop `json:"hops,omitempty"`
+}
+
+// FlowHop is one step of a traced data flow: where the value originates,
+// each variable it passes through, and the call that receives it.
+type FlowHop struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Kind   string `json:"kind"` // source, assign, sink
+	Detail string `json:"detail"`
 }
 
 // ExternalService represents an external API or service.
@@ -13,9 +13,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/macawi-ai/strigoi/pkg/engagement"
 	"github.com/macawi-ai/strigoi/pkg/modules"
 	"golang.org/x/sync/errgroup"
-	"golang.org/x/time/rate"
 )
 
 func init() {
@@ -32,28 +32,41 @@ type WestModule struct {
 	authzMatrix     map[string]AccessRequirement
 	vulnerabilities []AuthVulnerability
 
+	// Token and OpenID Connect analysis
+	tokens    []observedToken
+	tokenSeen map[string]bool
+	oidc      *OIDCConfiguration
+	jwks      *JSONWebKeySet
+
+	// TLS posture of the target
+	tlsAssessment *TLSAssessment
+
 	// Security and operational components
 	config         WestConfig
-	rateLimiter    *rate.Limiter
+	scheduler      *engagement.Scheduler
 	circuitBreaker *CircuitBreaker
 	tlsClient      *http.Client
+	harFile        string
+	har            *HARRecorder
 
 	// Execution control
-	ctx       context.Context
-	cancel    context.CancelFunc
-	semaphore chan struct{}
-	mu        sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+	mu     sync.Mutex
 }
 
 // WestConfig holds configuration for the west probe.
 type WestConfig struct {
-	RateLimit       float64       `json:"rate_limit"`
-	RequestTimeout  time.Duration `json:"request_timeout"`
-	MaxConcurrency  int           `json:"max_concurrency"`
-	DryRun          bool          `json:"dry_run"`
-	FollowRedirects bool          `json:"follow_redirects"`
-	MaxRedirects    int           `json:"max_redirects"`
-	AllowPrivate    bool          `json:"allow_private"`
+	RateLimit        float64       `json:"rate_limit"`
+	RequestTimeout   time.Duration `json:"request_timeout"`
+	MaxConcurrency   int           `json:"max_concurrency"`
+	DryRun           bool          `json:"dry_run"`
+	FollowRedirects  bool          `json:"follow_redirects"`
+	MaxRedirects     int           `json:"max_redirects"`
+	AllowPrivate     bool          `json:"allow_private"`
+	Token            string        `json:"-"`
+	OAuthClientID    string        `json:"oauth_client_id,omitempty"`
+	OAuthRedirectURI string        `json:"oauth_redirect_uri,omitempty"`
 }
 
 // AuthEndpoint represents an authentication endpoint.
@@ -65,6 +78,7 @@ type AuthEndpoint struct {
 	MFAEnabled      bool                `json:"mfa_enabled"`
 	Headers         map[string]string   `json:"headers,omitempty"`
 	Vulnerabilities []AuthVulnerability `json:"vulnerabilities,omitempty"`
+	HAREntryID      string              `json:"har_entry_id,omitempty"`
 }
 
 // SessionPattern represents a session management pattern.
@@ -101,9 +115,10 @@ type AuthVulnerability struct {
 
 // Evidence contains proof of a vulnerability.
 type Evidence struct {
-	Request  string `json:"request,omitempty"`
-	Response string `json:"response,omitempty"`
-	Details  string `json:"details,omitempty"`
+	Request    string `json:"request,omitempty"`
+	Response   string `json:"response,omitempty"`
+	Details    string `json:"details,omitempty"`
+	HAREntryID string `json:"har_entry_id,omitempty"`
 }
 
 // CircuitBreaker implements a simple circuit breaker pattern.
@@ -165,12 +180,41 @@ func NewWestModule() modules.Module {
 					Type:        "bool",
 					Default:     false,
 				},
+				"har": {
+					Name:        "har",
+					Description: "Write HTTP exchanges to this HAR file",
+					Required:    false,
+					Type:        "string",
+					Default:     "",
+				},
+				"token": {
+					Name:        "token",
+					Description: "JWT to analyze and use as a template for forged tokens",
+					Required:    false,
+					Type:        "string",
+					Default:     "",
+				},
+				"oauth_client_id": {
+					Name:        "oauth_client_id",
+					Description: "OAuth client ID for authorization endpoint tests",
+					Required:    false,
+					Type:        "string",
+					Default:     "",
+				},
+				"oauth_redirect_uri": {
+					Name:        "oauth_redirect_uri",
+					Description: "Registered redirect URI for authorization endpoint tests",
+					Required:    false,
+					Type:        "string",
+					Default:     "",
+				},
 			},
 		},
 		authEndpoints:   []AuthEndpoint{},
 		sessionPatterns: []SessionPattern{},
 		authzMatrix:     make(map[string]AccessRequirement),
 		vulnerabilities: []AuthVulnerability{},
+		tokenSeen:       make(map[string]bool),
 	}
 }
 
@@ -196,8 +240,13 @@ func (m *WestModule) Configure() error {
 
 	rateLimit := 10.0
 	if r, ok := m.ModuleOptions["rate_limit"]; ok && r.Value != nil {
-		if rFloat, ok := r.Value.(float64); ok {
-			rateLimit = rFloat
+		switch v := r.Value.(type) {
+		case float64:
+			rateLimit = v
+		case string:
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				rateLimit = parsed
+			}
 		}
 	} else if r, ok := m.ModuleOptions["rate_limit"]; ok && r.Default != nil {
 		if rFloat, ok := r.Default.(float64); ok {
@@ -239,18 +288,39 @@ func (m *WestModule) Configure() error {
 		}
 	}
 
-	m.config = WestConfig{
-		RateLimit:       rateLimit,
-		RequestTimeout:  time.Duration(timeout) * time.Second,
-		MaxConcurrency:  maxConcurrent,
-		DryRun:          dryRun,
-		FollowRedirects: false, // Don't follow redirects during auth testing
-		MaxRedirects:    5,
-		AllowPrivate:    allowPrivate,
+	stringOption := func(name string) string {
+		if opt, ok := m.ModuleOptions[name]; ok && opt.Value != nil {
+			if v, ok := opt.Value.(string); ok {
+				return v
+			}
+		}
+		return ""
 	}
 
-	// Initialize rate limiter
-	m.rateLimiter = rate.NewLimiter(rate.Limit(rateLimit), int(rateLimit))
+	m.config = WestConfig{
+		RateLimit:        rateLimit,
+		RequestTimeout:   time.Duration(timeout) * time.Second,
+		MaxConcurrency:   maxConcurrent,
+		DryRun:           dryRun,
+		FollowRedirects:  false, // Don't follow redirects during auth testing
+		MaxRedirects:     5,
+		AllowPrivate:     allowPrivate,
+		Token:            strings.TrimPrefix(stringOption("token"), "Bearer "),
+		OAuthClientID:    stringOption("oauth_client_id"),
+		OAuthRedirectURI: stringOption("oauth_redirect_uri"),
+	}
+
+	// Share the engagement scheduler when one is installed; this module's
+	// rate limit and concurrency options apply where it sets no limits
+	if s := engagement.Default(); s != nil {
+		m.scheduler = s.Derive(rateLimit, int(rateLimit), maxConcurrent)
+	} else {
+		m.scheduler = engagement.NewScheduler(engagement.SchedulerConfig{
+			RequestsPerSecond:  rateLimit,
+			Burst:              int(rateLimit),
+			PerHostConcurrency: maxConcurrent,
+		})
+	}
 
 	// Initialize circuit breaker
 	m.circuitBreaker = &CircuitBreaker{
@@ -259,12 +329,20 @@ func (m *WestModule) Configure() error {
 		state:     "closed",
 	}
 
-	// Initialize semaphore for concurrency control
-	m.semaphore = make(chan struct{}, maxConcurrent)
-
 	// Configure secure TLS client
 	m.configureTLSClient()
 
+	// Archive every exchange when a HAR file is requested
+	m.harFile = ""
+	m.har = nil
+	if h, ok := m.ModuleOptions["har"]; ok && h.Value != nil {
+		if harFile, ok := h.Value.(string); ok && harFile != "" {
+			m.harFile = harFile
+			m.har = harRecorderFor(harFile)
+			m.tlsClient.Transport = m.har.Transport(m.Name(), m.tlsClient.Transport)
+		}
+	}
+
 	return nil
 }
 
@@ -383,26 +461,91 @@ func (m *WestModule) Run() (*modules.ModuleResult, error) {
 		}, err
 	}
 
+	// Phase 4: Token and OpenID Connect analysis of what the earlier phases saw
+	m.tokenAnalysis(m.ctx, targetStr)
+
+	// Phase 5: TLS configuration assessment of HTTPS targets
+	m.assessTLS(m.ctx, targetStr)
+
 	// Aggregate results
 	results := m.getResults()
 
+	data := map[string]interface{}{
+		"target":          targetStr,
+		"auth_endpoints":  results["auth_endpoints"],
+		"session_info":    results["session_info"],
+		"access_control":  results["access_control"],
+		"vulnerabilities": results["vulnerabilities"],
+		"statistics":      results["statistics"],
+		"recommendations": m.generateRecommendations(),
+		"engagement":      m.scheduler.Stats(),
+		"tokens":          m.getTokenInfo(),
+	}
+
+	if m.oidc != nil {
+		data["oidc"] = m.oidc
+	}
+
+	if m.tlsAssessment != nil {
+		data["tls"] = m.tlsAssessment
+	}
+
+	if m.har != nil {
+		if err := m.har.WriteFile(m.harFile); err != nil {
+			return &modules.ModuleResult{
+				Module:    m.Name(),
+				Status:    "failed",
+				StartTime: startTime,
+				EndTime:   time.Now(),
+				Error:     err.Error(),
+				Data:      data,
+			}, err
+		}
+		data["har_file"] = m.harFile
+	}
+
 	return &modules.ModuleResult{
 		Module:    m.Name(),
 		Status:    "completed",
 		StartTime: startTime,
 		EndTime:   time.Now(),
-		Data: map[string]interface{}{
-			"target":          targetStr,
-			"auth_endpoints":  results["auth_endpoints"],
-			"session_info":    results["session_info"],
-			"access_control":  results["access_control"],
-			"vulnerabilities": results["vulnerabilities"],
-			"statistics":      results["statistics"],
-			"recommendations": m.generateRecommendations(),
-		},
+		Data:      data,
 	}, nil
 }
 
+// assessTLS reports the TLS posture of an HTTPS target and records its
+// findings alongside the authentication vulnerabilities.
+func (m *WestModule) assessTLS(ctx context.Context, target string) {
+	m.tlsAssessment = nil
+
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme != "https" {
+		return
+	}
+	port := u.Port()
+	if port == "" {
+		port = "443"
+	}
+
+	assessor := NewTLSAssessor(m.Name(), m.config.RequestTimeout)
+	assessor.Scheduler = m.scheduler
+
+	assessment, err := assessor.Assess(ctx, u.Hostname(), port)
+	if err != nil {
+		return
+	}
+
+	m.mu.Lock()
+	m.tlsAssessment = assessment
+	m.vulnerabilities = append(m.vulnerabilities, assessment.Findings...)
+	m.mu.Unlock()
+}
+
+// do sends a request through the engagement scheduler.
+func (m *WestModule) do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	return m.scheduler.Do(ctx, m.Name(), m.tlsClient, req)
+}
+
 // validateTarget ensures the target URL is valid and safe.
 func (m *WestModule) validateTarget(target string) error {
 	// Ensure target has a scheme
@@ -503,11 +646,6 @@ func (m *WestModule) passiveDetection(ctx context.Context, target string) error
 
 // checkAuthEndpoint checks if an endpoint exists and its auth type.
 func (m *WestModule) checkAuthEndpoint(ctx context.Context, fullURL string) *AuthEndpoint {
-	// Rate limiting
-	if err := m.rateLimiter.Wait(ctx); err != nil {
-		return nil
-	}
-
 	// Circuit breaker check
 	if !m.circuitBreaker.Allow() {
 		return nil
@@ -520,7 +658,10 @@ func (m *WestModule) checkAuthEndpoint(ctx context.Context, fullURL string) *Aut
 
 	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; Strigoi/1.0)")
 
-	resp, err := m.tlsClient.Do(req)
+	resp, err := m.do(ctx, req)
+	if engagement.IsOutOfScope(err) {
+		return nil
+	}
 	if err != nil {
 		m.circuitBreaker.RecordFailure()
 		return nil
@@ -535,14 +676,18 @@ func (m *WestModule) checkAuthEndpoint(ctx context.Context, fullURL string) *Aut
 	}
 
 	endpoint := &AuthEndpoint{
-		URL:     fullURL,
-		Method:  "GET",
-		Headers: make(map[string]string),
+		URL:        fullURL,
+		Method:     "GET",
+		Headers:    make(map[string]string),
+		HAREntryID: m.har.EntryID(resp),
 	}
 
 	// Analyze response headers and body to determine auth type
 	m.analyzeAuthType(endpoint, resp)
 
+	// Collect any JWTs the endpoint hands out
+	m.observeTokens(fullURL, resp)
+
 	return endpoint
 }
 
@@ -637,6 +782,10 @@ func (m *WestModule) testEndpointSecurity(ctx context.Context, endpoint *AuthEnd
 			Severity:    "Medium",
 			Category:    "Transport Security",
 			Description: fmt.Sprintf("Endpoint %s lacks HSTS header", endpoint.URL),
+			Evidence: Evidence{
+				Request:    fmt.Sprintf("%s %s", endpoint.Method, endpoint.URL),
+				HAREntryID: endpoint.HAREntryID,
+			},
 			Remediation: "Add Strict-Transport-Security header with appropriate max-age",
 			References:  []string{"OWASP-TRANSPORT"},
 			Confidence:  1.0,
@@ -644,6 +793,9 @@ func (m *WestModule) testEndpointSecurity(ctx context.Context, endpoint *AuthEnd
 		})
 	}
 
+	// Test cross-origin exposure
+	vulns = append(vulns, m.testCORS(ctx, endpoint)...)
+
 	// Test for authentication bypass attempts (carefully)
 	if endpoint.RequiresAuth {
 		// Test common bypass techniques
@@ -701,11 +853,6 @@ func (m *WestModule) testEndpointSecurity(ctx context.Context, endpoint *AuthEnd
 
 // testAuthBypass tests for authentication bypass vulnerabilities.
 func (m *WestModule) testAuthBypass(ctx context.Context, endpoint *AuthEndpoint, testName string, modifier func(*http.Request)) *AuthVulnerability {
-	// Rate limiting
-	if err := m.rateLimiter.Wait(ctx); err != nil {
-		return nil
-	}
-
 	req, err := http.NewRequestWithContext(ctx, endpoint.Method, endpoint.URL, nil)
 	if err != nil {
 		return nil
@@ -714,7 +861,7 @@ func (m *WestModule) testAuthBypass(ctx context.Context, endpoint *AuthEndpoint,
 	// Apply bypass technique
 	modifier(req)
 
-	resp, err := m.tlsClient.Do(req)
+	resp, err := m.do(ctx, req)
 	if err != nil {
 		return nil
 	}
@@ -729,8 +876,9 @@ func (m *WestModule) testAuthBypass(ctx context.Context, endpoint *AuthEndpoint,
 			Category:    "Authentication",
 			Description: fmt.Sprintf("Authentication can be bypassed using %s technique", testName),
 			Evidence: Evidence{
-				Request:  fmt.Sprintf("%s %s", req.Method, req.URL.String()),
-				Response: fmt.Sprintf("Status: %d", resp.StatusCode),
+				Request:    fmt.Sprintf("%s %s", req.Method, req.URL.String()),
+				Response:   fmt.Sprintf("Status: %d", resp.StatusCode),
+				HAREntryID: m.har.EntryID(resp),
 			},
 			Remediation: "Implement proper authentication checks that cannot be bypassed with header manipulation",
 			References:  []string{"CWE-290", "OWASP-AUTH"},
@@ -742,13 +890,38 @@ func (m *WestModule) testAuthBypass(ctx context.Context, endpoint *AuthEndpoint,
 	return nil
 }
 
-// testCSRFProtection tests for CSRF vulnerabilities.
-func (m *WestModule) testCSRFProtection(ctx context.Context, endpoint *AuthEndpoint) *AuthVulnerability {
-	// Rate limiting
-	if err := m.rateLimiter.Wait(ctx); err != nil {
-		return nil
+// testCORS tests whether other websites can call the endpoint from a browser.
+func (m *WestModule) testCORS(ctx context.Context, endpoint *AuthEndpoint) []AuthVulnerability {
+	checker := &CORSChecker{
+		Do:  func(req *http.Request) (*http.Response, error) { return m.do(ctx, req) },
+		HAR: m.har,
+	}
+
+	var vulns []AuthVulnerability
+	for _, finding := range checker.Check(ctx, endpoint.URL, endpoint.RequiresAuth) {
+		vulns = append(vulns, AuthVulnerability{
+			ID:          fmt.Sprintf("WEST-%d", time.Now().Unix()),
+			Name:        finding.Name,
+			Severity:    finding.Severity,
+			Category:    "Cross-Origin Resource Sharing",
+			Description: fmt.Sprintf("%s: %s", endpoint.URL, finding.Description),
+			Evidence: Evidence{
+				Request:    finding.Request,
+				Response:   finding.Response,
+				HAREntryID: finding.HAREntryID,
+			},
+			Remediation: "Validate Origin against an exact allowlist, never reflect it, and only allow credentials for trusted origins",
+			References:  []string{"CWE-942", "OWASP-CORS"},
+			Confidence:  0.9,
+			Timestamp:   time.Now(),
+		})
 	}
 
+	return vulns
+}
+
+// testCSRFProtection tests for CSRF vulnerabilities.
+func (m *WestModule) testCSRFProtection(ctx context.Context, endpoint *AuthEndpoint) *AuthVulnerability {
 	// Test without CSRF token
 	req, err := http.NewRequestWithContext(ctx, endpoint.Method, endpoint.URL, nil)
 	if err != nil {
@@ -761,7 +934,7 @@ func (m *WestModule) testCSRFProtection(ctx context.Context, endpoint *AuthEndpo
 	req.Header.Set("Origin", "https://evil.com")
 	req.Header.Set("Referer", "https://evil.com")
 
-	resp, err := m.tlsClient.Do(req)
+	resp, err := m.do(ctx, req)
 	if err != nil {
 		return nil
 	}
@@ -776,8 +949,9 @@ func (m *WestModule) testCSRFProtection(ctx context.Context, endpoint *AuthEndpo
 			Category:    "Session Management",
 			Description: fmt.Sprintf("Endpoint %s accepts state-changing requests without CSRF protection", endpoint.URL),
 			Evidence: Evidence{
-				Request:  fmt.Sprintf("%s request from evil.com origin", endpoint.Method),
-				Response: fmt.Sprintf("Status: %d (request accepted)", resp.StatusCode),
+				Request:    fmt.Sprintf("%s request from evil.com origin", endpoint.Method),
+				Response:   fmt.Sprintf("Status: %d (request accepted)", resp.StatusCode),
+				HAREntryID: m.har.EntryID(resp),
 			},
 			Remediation: "Implement CSRF tokens for all state-changing operations",
 			References:  []string{"CWE-352", "OWASP-CSRF"},
@@ -791,22 +965,18 @@ func (m *WestModule) testCSRFProtection(ctx context.Context, endpoint *AuthEndpo
 
 // testSessionFixation tests for session fixation vulnerabilities.
 func (m *WestModule) testSessionFixation(ctx context.Context, endpoint *AuthEndpoint) *AuthVulnerability {
-	// Rate limiting
-	if err := m.rateLimiter.Wait(ctx); err != nil {
-		return nil
-	}
-
 	// First request to get initial session
 	req1, err := http.NewRequestWithContext(ctx, "GET", endpoint.URL, nil)
 	if err != nil {
 		return nil
 	}
 
-	resp1, err := m.tlsClient.Do(req1)
+	resp1, err := m.do(ctx, req1)
 	if err != nil {
 		return nil
 	}
-	defer resp1.Body.Close()
+	// Release the host slot before the follow-up request
+	resp1.Body.Close()
 
 	// Check if session cookie was set
 	var sessionCookie *http.Cookie
@@ -829,7 +999,7 @@ func (m *WestModule) testSessionFixation(ctx context.Context, endpoint *AuthEndp
 	}
 	req2.AddCookie(sessionCookie)
 
-	resp2, err := m.tlsClient.Do(req2)
+	resp2, err := m.do(ctx, req2)
 	if err != nil {
 		return nil
 	}
@@ -845,7 +1015,8 @@ func (m *WestModule) testSessionFixation(ctx context.Context, endpoint *AuthEndp
 				Category:    "Session Management",
 				Description: "Session ID remains unchanged after authentication, allowing session fixation attacks",
 				Evidence: Evidence{
-					Details: fmt.Sprintf("Session cookie '%s' not regenerated after authentication", sessionCookie.Name),
+					Details:    fmt.Sprintf("Session cookie '%s' not regenerated after authentication", sessionCookie.Name),
+					HAREntryID: m.har.EntryID(resp2),
 				},
 				Remediation: "Regenerate session IDs after successful authentication",
 				References:  []string{"CWE-384", "OWASP-SESSION"},
@@ -882,12 +1053,15 @@ func (m *WestModule) analyzeSessionManagement(ctx context.Context, target string
 		return
 	}
 
-	resp, err := m.tlsClient.Do(req)
+	resp, err := m.do(ctx, req)
 	if err != nil {
 		return
 	}
 	defer resp.Body.Close()
 
+	// Collect any JWTs issued with the session
+	m.observeTokens(target, resp)
+
 	// Analyze cookies
 	for _, cookie := range resp.Cookies() {
 		sameSiteStr := ""
@@ -933,6 +1107,10 @@ func (m *WestModule) analyzeSessionManagement(ctx context.Context, target string
 				Severity:    "Medium",
 				Category:    "Session Management",
 				Description: fmt.Sprintf("Cookie '%s' has security weaknesses: %s", cookie.Name, strings.Join(pattern.Weaknesses, ", ")),
+				Evidence: Evidence{
+					Details:    fmt.Sprintf("Set-Cookie: %s", cookie.Name),
+					HAREntryID: m.har.EntryID(resp),
+				},
 				Remediation: "Set Secure and HttpOnly flags on all session cookies",
 				References:  []string{"CWE-614", "CWE-1004"},
 				Confidence:  1.0,
@@ -988,6 +1166,7 @@ func (m *WestModule) getResults() map[string]interface{} {
 		"high_vulns":            countBySeverity(m.vulnerabilities, "High"),
 		"medium_vulns":          countBySeverity(m.vulnerabilities, "Medium"),
 		"low_vulns":             countBySeverity(m.vulnerabilities, "Low"),
+		"tokens_analyzed":       len(m.tokens),
 	}
 
 	return map[string]interface{}{
@@ -0,0 +1,243 @@
+package probe
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// jwtPattern finds compact-serialized JWTs in headers and bodies.
+var jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+
+// weakJWTSecrets is the bundled wordlist of HMAC secrets commonly left in
+// place from tutorials, framework defaults and sample configurations.
+var weakJWTSecrets = []string{
+	"",
+	"secret",
+	"secretkey",
+	"secret_key",
+	"secret-key",
+	"secret123",
+	"mysecret",
+	"my_secret",
+	"my_secret_key",
+	"mysecretkey",
+	"supersecret",
+	"super-secret",
+	"topsecret",
+	"s3cr3t",
+	"jwt",
+	"jwtsecret",
+	"jwt_secret",
+	"jwt-secret",
+	"jwtkey",
+	"jwt_key",
+	"your-256-bit-secret",
+	"your-secret-key",
+	"your_jwt_secret",
+	"your-jwt-secret",
+	"shhhhh",
+	"shhhhhhared-secret",
+	"keyboard cat",
+	"gottacatchemall",
+	"changeme",
+	"changeit",
+	"password",
+	"passw0rd",
+	"P@ssw0rd",
+	"123456",
+	"12345678",
+	"1234567890",
+	"qwerty",
+	"letmein",
+	"admin",
+	"test",
+	"testing",
+	"default",
+	"key",
+	"private",
+	"privatekey",
+	"token",
+	"auth",
+	"hmac",
+	"HS256",
+	"development",
+	"dev",
+	"production",
+	"prod",
+	"example",
+	"sample",
+	"hello",
+	"welcome",
+	"app_secret",
+	"appsecret",
+	"api_secret",
+	"apisecret",
+	"access_secret",
+	"refresh_secret",
+	"session_secret",
+	"node",
+	"express",
+	"laravel",
+	"django",
+	"flask",
+	"rails",
+	"spring",
+}
+
+// jwtToken is a decoded compact JWT.
+type jwtToken struct {
+	raw          string
+	header       map[string]interface{}
+	claims       map[string]interface{}
+	signingInput string
+	signature    []byte
+}
+
+// parseJWT decodes a compact JWT without verifying its signature.
+func parseJWT(raw string) (*jwtToken, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("token has %d segments, expected 3", len(parts))
+	}
+
+	token := &jwtToken{
+		raw:          raw,
+		signingInput: parts[0] + "." + parts[1],
+	}
+
+	if err := decodeJWTSegment(parts[0], &token.header); err != nil {
+		return nil, fmt.Errorf("invalid JWT header: %w", err)
+	}
+	if err := decodeJWTSegment(parts[1], &token.claims); err != nil {
+		return nil, fmt.Errorf("invalid JWT claims: %w", err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT signature: %w", err)
+	}
+	token.signature = signature
+
+	return token, nil
+}
+
+// decodeJWTSegment decodes a base64url JSON segment into v.
+func decodeJWTSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// alg returns the signing algorithm named in the header.
+func (t *jwtToken) alg() string {
+	alg, _ := t.header["alg"].(string)
+	return alg
+}
+
+// stringClaim returns a string claim or "".
+func (t *jwtToken) stringClaim(name string) string {
+	value, _ := t.claims[name].(string)
+	return value
+}
+
+// audience returns the aud claim, which may be a string or a list.
+func (t *jwtToken) audience() []string {
+	switch v := t.claims["aud"].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var aud []string
+		for _, a := range v {
+			if s, ok := a.(string); ok {
+				aud = append(aud, s)
+			}
+		}
+		return aud
+	}
+	return nil
+}
+
+// timeClaim returns a NumericDate claim.
+func (t *jwtToken) timeClaim(name string) (time.Time, bool) {
+	value, ok := t.claims[name].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(value), 0), true
+}
+
+// verifyHMAC reports whether the token's HMAC signature matches secret.
+func (t *jwtToken) verifyHMAC(secret []byte) bool {
+	newHash := jwtHMACHash(t.alg())
+	if newHash == nil {
+		return false
+	}
+	mac := hmac.New(newHash, secret)
+	mac.Write([]byte(t.signingInput))
+	return hmac.Equal(mac.Sum(nil), t.signature)
+}
+
+// crackJWTSecret tries each candidate as the HMAC secret of an HS* token.
+func crackJWTSecret(t *jwtToken, candidates []string) (string, bool) {
+	if jwtHMACHash(t.alg()) == nil {
+		return "", false
+	}
+	for _, candidate := range candidates {
+		if t.verifyHMAC([]byte(candidate)) {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// signJWT builds a compact JWT. alg "none" produces an unsigned token and
+// HS256/384/512 are signed with key.
+func signJWT(header, claims map[string]interface{}, key []byte) (string, error) {
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." +
+		base64.RawURLEncoding.EncodeToString(claimsJSON)
+
+	alg, _ := header["alg"].(string)
+	if strings.EqualFold(alg, "none") {
+		return signingInput + ".", nil
+	}
+
+	newHash := jwtHMACHash(alg)
+	if newHash == nil {
+		return "", fmt.Errorf("unsupported signing algorithm: %s", alg)
+	}
+	mac := hmac.New(newHash, key)
+	mac.Write([]byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// jwtHMACHash returns the hash constructor for an HMAC JWT algorithm.
+func jwtHMACHash(alg string) func() hash.Hash {
+	switch alg {
+	case "HS256":
+		return sha256.New
+	case "HS384":
+		return sha512.New384
+	case "HS512":
+		return sha512.New
+	}
+	return nil
+}
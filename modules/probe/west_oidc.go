@@ -0,0 +1,798 @@
+package probe
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// maxJWTLifetime is the longest token lifetime accepted without a finding.
+	maxJWTLifetime = 24 * time.Hour
+
+	// maxTokenScanSize limits how much of a response body is scanned for JWTs.
+	maxTokenScanSize = 64 * 1024
+
+	// maxForgeryTargets limits how many protected endpoints receive forged tokens.
+	maxForgeryTargets = 3
+)
+
+// OIDCConfiguration is the subset of an OpenID Provider discovery document
+// analyzed by the west probe.
+type OIDCConfiguration struct {
+	Issuer                           string   `json:"issuer"`
+	AuthorizationEndpoint            string   `json:"authorization_endpoint,omitempty"`
+	TokenEndpoint                    string   `json:"token_endpoint,omitempty"`
+	UserinfoEndpoint                 string   `json:"userinfo_endpoint,omitempty"`
+	JWKSURI                          string   `json:"jwks_uri,omitempty"`
+	ResponseTypesSupported           []string `json:"response_types_supported,omitempty"`
+	GrantTypesSupported              []string `json:"grant_types_supported,omitempty"`
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported,omitempty"`
+	CodeChallengeMethodsSupported    []string `json:"code_challenge_methods_supported,omitempty"`
+}
+
+// JSONWebKey is a public key published in a JWKS document.
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// JSONWebKeySet is a JWKS document.
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// TokenInfo summarizes a JWT observed during analysis.
+type TokenInfo struct {
+	Source     string     `json:"source"`
+	Algorithm  string     `json:"alg"`
+	KeyID      string     `json:"kid,omitempty"`
+	Issuer     string     `json:"iss,omitempty"`
+	Subject    string     `json:"sub,omitempty"`
+	Audience   []string   `json:"aud,omitempty"`
+	IssuedAt   *time.Time `json:"iat,omitempty"`
+	ExpiresAt  *time.Time `json:"exp,omitempty"`
+	Token      string     `json:"token"`
+	HAREntryID string     `json:"har_entry_id,omitempty"`
+}
+
+// observedToken is a JWT seen in a response or supplied by the operator.
+type observedToken struct {
+	token      *jwtToken
+	source     string
+	harEntryID string
+}
+
+// observeTokens records JWTs found in the headers and body of resp. The
+// body is consumed up to maxTokenScanSize.
+func (m *WestModule) observeTokens(source string, resp *http.Response) {
+	var sb strings.Builder
+	for _, values := range resp.Header {
+		for _, value := range values {
+			sb.WriteString(value)
+			sb.WriteString("\n")
+		}
+	}
+	if body, err := io.ReadAll(io.LimitReader(resp.Body, maxTokenScanSize)); err == nil {
+		sb.Write(body)
+	}
+
+	harEntryID := m.har.EntryID(resp)
+	for _, raw := range jwtPattern.FindAllString(sb.String(), -1) {
+		m.addToken(raw, source, harEntryID)
+	}
+}
+
+// addToken decodes and records a JWT once.
+func (m *WestModule) addToken(raw, source, harEntryID string) {
+	token, err := parseJWT(raw)
+	if err != nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.tokenSeen[raw] {
+		return
+	}
+	m.tokenSeen[raw] = true
+	m.tokens = append(m.tokens, observedToken{token: token, source: source, harEntryID: harEntryID})
+}
+
+// tokenAnalysis discovers the OpenID Provider configuration and analyzes
+// every JWT observed by the earlier phases.
+func (m *WestModule) tokenAnalysis(ctx context.Context, target string) {
+	// Ensure target has scheme
+	if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+		target = "https://" + target
+	}
+
+	baseURL, err := url.Parse(target)
+	if err != nil {
+		return
+	}
+
+	if m.config.Token != "" {
+		m.addToken(m.config.Token, "operator supplied", "")
+	}
+
+	var vulns []AuthVulnerability
+	vulns = append(vulns, m.discoverOIDC(ctx, baseURL)...)
+
+	m.mu.Lock()
+	tokens := make([]observedToken, len(m.tokens))
+	copy(tokens, m.tokens)
+	m.mu.Unlock()
+
+	for _, observed := range tokens {
+		vulns = append(vulns, m.analyzeJWT(observed)...)
+	}
+
+	if !m.config.DryRun {
+		vulns = append(vulns, m.testTokenForgery(ctx, tokens)...)
+		vulns = append(vulns, m.testAuthorizationRequest(ctx, baseURL)...)
+	}
+
+	if len(vulns) > 0 {
+		m.mu.Lock()
+		m.vulnerabilities = append(m.vulnerabilities, vulns...)
+		m.mu.Unlock()
+	}
+}
+
+// discoverOIDC fetches the discovery document and JWKS and checks the
+// advertised provider capabilities.
+func (m *WestModule) discoverOIDC(ctx context.Context, baseURL *url.URL) []AuthVulnerability {
+	discoveryURL := *baseURL
+	discoveryURL.Path = "/.well-known/openid-configuration"
+	discoveryURL.RawQuery = ""
+
+	var config OIDCConfiguration
+	harEntryID, ok := m.fetchJSON(ctx, discoveryURL.String(), &config)
+	if !ok || (config.Issuer == "" && config.AuthorizationEndpoint == "") {
+		return nil
+	}
+
+	m.mu.Lock()
+	m.oidc = &config
+	m.mu.Unlock()
+
+	var vulns []AuthVulnerability
+	evidence := Evidence{
+		Request:    fmt.Sprintf("GET %s", discoveryURL.String()),
+		HAREntryID: harEntryID,
+	}
+
+	var hasAsymmetric, hasSymmetric bool
+	for _, alg := range config.IDTokenSigningAlgValuesSupported {
+		switch {
+		case strings.EqualFold(alg, "none"):
+			vulns = append(vulns, newTokenVulnerability(
+				"OIDC Provider Advertises alg=none",
+				"Critical",
+				"The OpenID Provider lists 'none' in id_token_signing_alg_values_supported, allowing unsigned ID tokens",
+				withDetails(evidence, "id_token_signing_alg_values_supported: "+strings.Join(config.IDTokenSigningAlgValuesSupported, ", ")),
+				"Remove 'none' from the supported ID token signing algorithms and reject unsigned tokens",
+				[]string{"CWE-347", "RFC8725"},
+				1.0,
+			))
+		case strings.HasPrefix(alg, "HS"):
+			hasSymmetric = true
+		case strings.HasPrefix(alg, "RS"), strings.HasPrefix(alg, "PS"), strings.HasPrefix(alg, "ES"):
+			hasAsymmetric = true
+		}
+	}
+	if hasSymmetric && hasAsymmetric {
+		vulns = append(vulns, newTokenVulnerability(
+			"Mixed Symmetric and Asymmetric Token Algorithms",
+			"Medium",
+			"The OpenID Provider advertises both HMAC and public-key ID token algorithms, which enables algorithm confusion if verifiers trust the token header",
+			withDetails(evidence, "id_token_signing_alg_values_supported: "+strings.Join(config.IDTokenSigningAlgValuesSupported, ", ")),
+			"Pin the expected algorithm per key in token verifiers and avoid advertising HMAC algorithms for public clients",
+			[]string{"CWE-327", "RFC8725"},
+			0.7,
+		))
+	}
+
+	if !containsFold(config.CodeChallengeMethodsSupported, "S256") {
+		detail := "code_challenge_methods_supported is not advertised"
+		if len(config.CodeChallengeMethodsSupported) > 0 {
+			detail = "code_challenge_methods_supported: " + strings.Join(config.CodeChallengeMethodsSupported, ", ")
+		}
+		vulns = append(vulns, newTokenVulnerability(
+			"PKCE S256 Not Supported",
+			"Medium",
+			"The OpenID Provider does not advertise PKCE with the S256 method, leaving authorization codes open to interception",
+			withDetails(evidence, detail),
+			"Support PKCE with S256 and require it for all authorization code flows",
+			[]string{"RFC7636", "OAUTH-BCP"},
+			0.8,
+		))
+	}
+
+	for _, responseType := range config.ResponseTypesSupported {
+		if containsFold(strings.Fields(responseType), "token") {
+			vulns = append(vulns, newTokenVulnerability(
+				"OAuth Implicit Flow Enabled",
+				"Low",
+				fmt.Sprintf("The OpenID Provider supports response_type '%s', which returns access tokens in the browser", responseType),
+				withDetails(evidence, "response_types_supported: "+strings.Join(config.ResponseTypesSupported, ", ")),
+				"Disable the implicit flow and use the authorization code flow with PKCE",
+				[]string{"OAUTH-BCP"},
+				0.9,
+			))
+			break
+		}
+	}
+
+	if config.JWKSURI != "" {
+		vulns = append(vulns, m.fetchJWKS(ctx, config.JWKSURI)...)
+	}
+
+	return vulns
+}
+
+// fetchJWKS retrieves the provider's signing keys and flags weak ones.
+func (m *WestModule) fetchJWKS(ctx context.Context, jwksURI string) []AuthVulnerability {
+	var jwks JSONWebKeySet
+	harEntryID, ok := m.fetchJSON(ctx, jwksURI, &jwks)
+	if !ok {
+		return nil
+	}
+
+	m.mu.Lock()
+	m.jwks = &jwks
+	m.mu.Unlock()
+
+	var vulns []AuthVulnerability
+	for _, key := range jwks.Keys {
+		pub, err := key.rsaPublicKey()
+		if err != nil {
+			continue
+		}
+		if bits := pub.N.BitLen(); bits < 2048 {
+			vulns = append(vulns, newTokenVulnerability(
+				"Weak RSA Token Signing Key",
+				"High",
+				fmt.Sprintf("JWKS key '%s' is a %d-bit RSA key", key.Kid, bits),
+				Evidence{Request: fmt.Sprintf("GET %s", jwksURI), HAREntryID: harEntryID},
+				"Rotate to RSA keys of at least 2048 bits or to an elliptic curve algorithm",
+				[]string{"CWE-326"},
+				1.0,
+			))
+		}
+	}
+
+	return vulns
+}
+
+// fetchJSON GETs rawURL and decodes a JSON body into v.
+func (m *WestModule) fetchJSON(ctx context.Context, rawURL string, v interface{}) (string, bool) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return "", false
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; Strigoi/1.0)")
+
+	resp, err := m.do(ctx, req)
+	if err != nil {
+		return "", false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", false
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxTokenScanSize)).Decode(v); err != nil {
+		return "", false
+	}
+	return m.har.EntryID(resp), true
+}
+
+// analyzeJWT checks the algorithm, claims and signing secret of a token.
+func (m *WestModule) analyzeJWT(observed observedToken) []AuthVulnerability {
+	token := observed.token
+	evidence := Evidence{
+		Details:    fmt.Sprintf("Token %s from %s", maskJWT(token.raw), observed.source),
+		HAREntryID: observed.harEntryID,
+	}
+
+	var vulns []AuthVulnerability
+
+	alg := token.alg()
+	if alg == "" || strings.EqualFold(alg, "none") {
+		vulns = append(vulns, newTokenVulnerability(
+			"Unsigned JWT Issued",
+			"Critical",
+			"A JWT with alg=none was issued, so its claims can be altered by anyone",
+			evidence,
+			"Sign every token with a strong algorithm and reject unsigned tokens",
+			[]string{"CWE-347", "RFC8725"},
+			1.0,
+		))
+	}
+
+	exp, hasExp := token.timeClaim("exp")
+	if !hasExp {
+		vulns = append(vulns, newTokenVulnerability(
+			"JWT Without Expiration",
+			"Medium",
+			"The JWT has no 'exp' claim and remains valid indefinitely",
+			evidence,
+			"Issue short-lived tokens with an 'exp' claim and validate it",
+			[]string{"CWE-613", "RFC7519"},
+			1.0,
+		))
+	} else {
+		issued, hasIat := token.timeClaim("iat")
+		if !hasIat {
+			issued = time.Now()
+		}
+		if lifetime := exp.Sub(issued); lifetime > maxJWTLifetime {
+			vulns = append(vulns, newTokenVulnerability(
+				"Long-Lived JWT",
+				"Medium",
+				fmt.Sprintf("The JWT is valid for %s, longer than the recommended %s", lifetime.Round(time.Minute), maxJWTLifetime),
+				evidence,
+				"Shorten access token lifetimes and use refresh tokens for long sessions",
+				[]string{"CWE-613"},
+				0.9,
+			))
+		}
+	}
+
+	for _, claim := range []string{"aud", "iss"} {
+		if _, ok := token.claims[claim]; !ok {
+			vulns = append(vulns, newTokenVulnerability(
+				fmt.Sprintf("JWT Missing '%s' Claim", claim),
+				"Low",
+				fmt.Sprintf("The JWT has no '%s' claim, so verifiers cannot reject tokens minted for another service", claim),
+				evidence,
+				fmt.Sprintf("Include the '%s' claim and validate it on every request", claim),
+				[]string{"RFC8725"},
+				1.0,
+			))
+		}
+	}
+
+	if secret, ok := crackJWTSecret(token, weakJWTSecrets); ok {
+		vulns = append(vulns, newTokenVulnerability(
+			"JWT Signed With Weak HMAC Secret",
+			"Critical",
+			fmt.Sprintf("The %s signing secret was found in the bundled wordlist, so arbitrary tokens can be forged", alg),
+			withDetails(evidence, fmt.Sprintf("Secret: %s", maskValue(secret))),
+			"Replace the signing secret with at least 256 bits of random data and rotate all issued tokens",
+			[]string{"CWE-521", "CWE-798"},
+			1.0,
+		))
+	}
+
+	return vulns
+}
+
+// testTokenForgery replays forged tokens against endpoints that require
+// authentication: alg=none, and HS256 signed with the provider's RSA public key.
+func (m *WestModule) testTokenForgery(ctx context.Context, tokens []observedToken) []AuthVulnerability {
+	targets := m.forgeryTargets()
+	if len(targets) == 0 {
+		return nil
+	}
+
+	claims := m.forgeryClaims(tokens)
+
+	m.mu.Lock()
+	jwks := m.jwks
+	m.mu.Unlock()
+
+	var vulns []AuthVulnerability
+	for _, target := range targets {
+		// Skip endpoints that accept any bearer token; the bypass tests cover them
+		invalid, _ := signJWT(map[string]interface{}{"alg": "HS256", "typ": "JWT"}, claims, []byte(randomHex(16)))
+		if accepted, _ := m.bearerAccepted(ctx, target, invalid); accepted {
+			continue
+		}
+
+		for _, alg := range []string{"none", "None"} {
+			forged, err := signJWT(map[string]interface{}{"alg": alg, "typ": "JWT"}, claims, nil)
+			if err != nil {
+				continue
+			}
+			if accepted, harEntryID := m.bearerAccepted(ctx, target, forged); accepted {
+				vulns = append(vulns, newTokenVulnerability(
+					"JWT alg=none Accepted",
+					"Critical",
+					fmt.Sprintf("Endpoint %s accepted an unsigned JWT with alg=%s", target, alg),
+					Evidence{
+						Request:    fmt.Sprintf("GET %s with Authorization: Bearer %s", target, maskJWT(forged)),
+						Response:   "Status: 2xx (token accepted)",
+						HAREntryID: harEntryID,
+					},
+					"Reject tokens whose algorithm is not on an explicit allow-list",
+					[]string{"CWE-347", "RFC8725"},
+					0.95,
+				))
+				break
+			}
+		}
+
+		if jwks == nil {
+			continue
+		}
+		for _, key := range jwks.Keys {
+			pub, err := key.rsaPublicKey()
+			if err != nil {
+				continue
+			}
+			if vuln := m.testAlgorithmConfusion(ctx, target, key, pub, claims); vuln != nil {
+				vulns = append(vulns, *vuln)
+				break
+			}
+		}
+	}
+
+	return vulns
+}
+
+// testAlgorithmConfusion signs an HS256 token with the RSA public key as
+// the HMAC secret and reports whether target accepts it.
+func (m *WestModule) testAlgorithmConfusion(ctx context.Context, target string, key JSONWebKey, pub *rsa.PublicKey, claims map[string]interface{}) *AuthVulnerability {
+	header := map[string]interface{}{"alg": "HS256", "typ": "JWT"}
+	if key.Kid != "" {
+		header["kid"] = key.Kid
+	}
+
+	for _, secret := range rsaPublicKeyEncodings(pub) {
+		forged, err := signJWT(header, claims, secret)
+		if err != nil {
+			continue
+		}
+		if accepted, harEntryID := m.bearerAccepted(ctx, target, forged); accepted {
+			vuln := newTokenVulnerability(
+				"JWT Algorithm Confusion (RS256 to HS256)",
+				"Critical",
+				fmt.Sprintf("Endpoint %s accepted an HS256 token signed with the public RSA key '%s' as the HMAC secret", target, key.Kid),
+				Evidence{
+					Request:    fmt.Sprintf("GET %s with Authorization: Bearer %s", target, maskJWT(forged)),
+					Response:   "Status: 2xx (token accepted)",
+					HAREntryID: harEntryID,
+				},
+				"Bind each verification key to its algorithm and never take the algorithm from the token header",
+				[]string{"CWE-327", "CWE-347", "RFC8725"},
+				0.95,
+			)
+			return &vuln
+		}
+	}
+
+	return nil
+}
+
+// forgeryTargets returns endpoints that should reject unauthenticated requests.
+func (m *WestModule) forgeryTargets() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var targets []string
+	seen := make(map[string]bool)
+	add := func(target string) {
+		if target != "" && !seen[target] && len(targets) < maxForgeryTargets {
+			seen[target] = true
+			targets = append(targets, target)
+		}
+	}
+
+	if m.oidc != nil {
+		add(m.oidc.UserinfoEndpoint)
+	}
+	for _, endpoint := range m.authEndpoints {
+		if endpoint.RequiresAuth && endpoint.Method == "GET" {
+			add(endpoint.URL)
+		}
+	}
+
+	return targets
+}
+
+// forgeryClaims returns the claims used in forged tokens, copied from an
+// observed token when one exists.
+func (m *WestModule) forgeryClaims(tokens []observedToken) map[string]interface{} {
+	now := time.Now()
+	claims := map[string]interface{}{
+		"sub": "strigoi-probe",
+		"iat": now.Unix(),
+		"exp": now.Add(5 * time.Minute).Unix(),
+	}
+
+	if len(tokens) > 0 {
+		for k, v := range tokens[0].token.claims {
+			claims[k] = v
+		}
+		claims["iat"] = now.Unix()
+		claims["exp"] = now.Add(5 * time.Minute).Unix()
+	}
+
+	m.mu.Lock()
+	if m.oidc != nil && m.oidc.Issuer != "" {
+		if _, ok := claims["iss"]; !ok {
+			claims["iss"] = m.oidc.Issuer
+		}
+	}
+	m.mu.Unlock()
+
+	return claims
+}
+
+// bearerAccepted sends token to target and reports whether it was accepted.
+func (m *WestModule) bearerAccepted(ctx context.Context, target, token string) (bool, string) {
+	req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
+	if err != nil {
+		return false, ""
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; Strigoi/1.0)")
+
+	resp, err := m.do(ctx, req)
+	if err != nil {
+		return false, ""
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode >= 200 && resp.StatusCode < 300, m.har.EntryID(resp)
+}
+
+// testAuthorizationRequest sends authorization code requests without PKCE
+// and checks how the endpoint handles the state parameter.
+func (m *WestModule) testAuthorizationRequest(ctx context.Context, baseURL *url.URL) []AuthVulnerability {
+	endpoint := m.authorizationEndpoint()
+	if endpoint == "" {
+		return nil
+	}
+
+	redirectURI := m.config.OAuthRedirectURI
+	if redirectURI == "" {
+		callback := *baseURL
+		callback.Path = "/callback"
+		callback.RawQuery = ""
+		redirectURI = callback.String()
+	}
+	clientID := m.config.OAuthClientID
+	if clientID == "" {
+		clientID = "strigoi-probe"
+	}
+
+	var vulns []AuthVulnerability
+
+	// Authorization code request with state but without a code challenge
+	state := randomHex(16)
+	location, harEntryID, ok := m.authorize(ctx, endpoint, clientID, redirectURI, state)
+	if !ok {
+		return nil
+	}
+
+	if location.Query().Get("code") != "" {
+		vulns = append(vulns, newTokenVulnerability(
+			"Authorization Code Issued Without PKCE",
+			"Medium",
+			fmt.Sprintf("Authorization endpoint %s issued a code to a request without code_challenge", endpoint),
+			Evidence{
+				Request:    fmt.Sprintf("GET %s?response_type=code&client_id=%s (no code_challenge)", endpoint, clientID),
+				Response:   fmt.Sprintf("Redirect to %s", location.Redacted()),
+				HAREntryID: harEntryID,
+			},
+			"Require PKCE (S256) for every authorization code request",
+			[]string{"RFC7636", "OAUTH-BCP"},
+			0.9,
+		))
+	}
+
+	if location.Query().Get("state") != state {
+		vulns = append(vulns, newTokenVulnerability(
+			"OAuth State Parameter Not Preserved",
+			"Medium",
+			fmt.Sprintf("Authorization endpoint %s did not return the state value unchanged, breaking client CSRF protection", endpoint),
+			Evidence{
+				Request:    fmt.Sprintf("GET %s?response_type=code&client_id=%s&state=%s", endpoint, clientID, state),
+				Response:   fmt.Sprintf("Redirect to %s", location.Redacted()),
+				HAREntryID: harEntryID,
+			},
+			"Return the state parameter exactly as received on every authorization response",
+			[]string{"RFC6749-10.12", "CWE-352"},
+			0.8,
+		))
+	}
+
+	// Authorization code request without state
+	location, harEntryID, ok = m.authorize(ctx, endpoint, clientID, redirectURI, "")
+	if ok && location.Query().Get("code") != "" {
+		vulns = append(vulns, newTokenVulnerability(
+			"Authorization Request Accepted Without State",
+			"Low",
+			fmt.Sprintf("Authorization endpoint %s issued a code to a request without a state parameter", endpoint),
+			Evidence{
+				Request:    fmt.Sprintf("GET %s?response_type=code&client_id=%s (no state)", endpoint, clientID),
+				Response:   fmt.Sprintf("Redirect to %s", location.Redacted()),
+				HAREntryID: harEntryID,
+			},
+			"Require clients to send state or PKCE so authorization responses cannot be injected",
+			[]string{"RFC6749-10.12", "OAUTH-BCP"},
+			0.7,
+		))
+	}
+
+	return vulns
+}
+
+// authorizationEndpoint returns the discovered OAuth authorization endpoint.
+func (m *WestModule) authorizationEndpoint() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.oidc != nil && m.oidc.AuthorizationEndpoint != "" {
+		return m.oidc.AuthorizationEndpoint
+	}
+	for _, endpoint := range m.authEndpoints {
+		if strings.Contains(endpoint.URL, "authorize") {
+			return endpoint.URL
+		}
+	}
+	return ""
+}
+
+// authorize sends an authorization code request and returns the redirect
+// location when the endpoint redirects.
+func (m *WestModule) authorize(ctx context.Context, endpoint, clientID, redirectURI, state string) (*url.URL, string, bool) {
+	authURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, "", false
+	}
+
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", clientID)
+	query.Set("redirect_uri", redirectURI)
+	query.Set("scope", "openid")
+	if state != "" {
+		query.Set("state", state)
+	}
+	authURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", authURL.String(), nil)
+	if err != nil {
+		return nil, "", false
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; Strigoi/1.0)")
+
+	resp, err := m.do(ctx, req)
+	if err != nil {
+		return nil, "", false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 300 || resp.StatusCode >= 400 {
+		return nil, "", false
+	}
+
+	location, err := resp.Location()
+	if err != nil {
+		return nil, "", false
+	}
+	return location, m.har.EntryID(resp), true
+}
+
+// getTokenInfo summarizes observed tokens with their values masked.
+func (m *WestModule) getTokenInfo() []TokenInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	infos := make([]TokenInfo, 0, len(m.tokens))
+	for _, observed := range m.tokens {
+		token := observed.token
+		info := TokenInfo{
+			Source:     observed.source,
+			Algorithm:  token.alg(),
+			Issuer:     token.stringClaim("iss"),
+			Subject:    token.stringClaim("sub"),
+			Audience:   token.audience(),
+			Token:      maskJWT(token.raw),
+			HAREntryID: observed.harEntryID,
+		}
+		info.KeyID, _ = token.header["kid"].(string)
+		if iat, ok := token.timeClaim("iat"); ok {
+			info.IssuedAt = &iat
+		}
+		if exp, ok := token.timeClaim("exp"); ok {
+			info.ExpiresAt = &exp
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// rsaPublicKey decodes an RSA JWK.
+func (k JSONWebKey) rsaPublicKey() (*rsa.PublicKey, error) {
+	if k.Kty != "RSA" {
+		return nil, fmt.Errorf("key type %s is not RSA", k.Kty)
+	}
+	n, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(k.N, "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(k.E, "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
+
+// rsaPublicKeyEncodings returns the PEM encodings a confused verifier may
+// use as the HMAC secret.
+func rsaPublicKeyEncodings(pub *rsa.PublicKey) [][]byte {
+	var encodings [][]byte
+	if der, err := x509.MarshalPKIXPublicKey(pub); err == nil {
+		encodings = append(encodings, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	}
+	encodings = append(encodings, pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(pub)}))
+	return encodings
+}
+
+// newTokenVulnerability builds a token or OAuth finding.
+func newTokenVulnerability(name, severity, description string, evidence Evidence, remediation string, references []string, confidence float64) AuthVulnerability {
+	return AuthVulnerability{
+		ID:          fmt.Sprintf("WEST-%d", time.Now().Unix()),
+		Name:        name,
+		Severity:    severity,
+		Category:    "Token Security",
+		Description: description,
+		Evidence:    evidence,
+		Remediation: remediation,
+		References:  references,
+		Confidence:  confidence,
+		Timestamp:   time.Now(),
+	}
+}
+
+// withDetails returns a copy of evidence with details set.
+func withDetails(evidence Evidence, details string) Evidence {
+	evidence.Details = details
+	return evidence
+}
+
+// containsFold reports whether list contains value, ignoring case.
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// randomHex returns n random bytes hex encoded.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,219 @@
+package probe
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseJWTAndCrackSecret(t *testing.T) {
+	raw, err := signJWT(
+		map[string]interface{}{"alg": "HS256", "typ": "JWT"},
+		map[string]interface{}{"sub": "alice", "aud": []string{"api", "web"}, "exp": 1700000000},
+		[]byte("keyboard cat"),
+	)
+	if err != nil {
+		t.Fatalf("signJWT failed: %v", err)
+	}
+
+	token, err := parseJWT(raw)
+	if err != nil {
+		t.Fatalf("parseJWT failed: %v", err)
+	}
+	if token.alg() != "HS256" || token.stringClaim("sub") != "alice" {
+		t.Errorf("unexpected token: %+v", token)
+	}
+	if aud := token.audience(); len(aud) != 2 || aud[0] != "api" {
+		t.Errorf("unexpected audience: %v", aud)
+	}
+	if exp, ok := token.timeClaim("exp"); !ok || exp.Unix() != 1700000000 {
+		t.Errorf("unexpected exp: %v", exp)
+	}
+
+	secret, ok := crackJWTSecret(token, weakJWTSecrets)
+	if !ok || secret != "keyboard cat" {
+		t.Errorf("expected weak secret to be cracked, got %q, %v", secret, ok)
+	}
+
+	strong, _ := signJWT(map[string]interface{}{"alg": "HS256"}, map[string]interface{}{"sub": "bob"}, []byte(randomHex(32)))
+	strongToken, _ := parseJWT(strong)
+	if _, ok := crackJWTSecret(strongToken, weakJWTSecrets); ok {
+		t.Error("random secret should not be cracked")
+	}
+
+	if _, err := parseJWT("not.a.jwt"); err == nil {
+		t.Error("expected invalid token to be rejected")
+	}
+}
+
+// newOIDCStandIn starts a deliberately weak OpenID Provider: it advertises
+// alg=none, hands out a weakly signed token without exp, accepts unsigned
+// tokens at userinfo and drops the state parameter on authorization.
+func newOIDCStandIn(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	issued, _ := signJWT(
+		map[string]interface{}{"alg": "HS256", "typ": "JWT"},
+		map[string]interface{}{"sub": "alice", "iat": time.Now().Unix()},
+		[]byte("secret"),
+	)
+
+	mux := http.NewServeMux()
+	var server *httptest.Server
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                server.URL,
+			"authorization_endpoint":                server.URL + "/authorize",
+			"token_endpoint":                        server.URL + "/token",
+			"userinfo_endpoint":                     server.URL + "/userinfo",
+			"jwks_uri":                              server.URL + "/jwks",
+			"response_types_supported":              []string{"code", "id_token token"},
+			"id_token_signing_alg_values_supported": []string{"RS256", "HS256", "none"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test-key",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, _ *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "access_token", Value: issued, Secure: true, HttpOnly: true})
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		token, err := parseJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		if err != nil || !strings.EqualFold(token.alg(), "none") {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, r.URL.Query().Get("redirect_uri")+"?code=abc123", http.StatusFound)
+	})
+
+	server = httptest.NewServer(mux)
+	return server
+}
+
+func TestWestTokenAnalysis(t *testing.T) {
+	server := newOIDCStandIn(t)
+	defer server.Close()
+
+	module := NewWestModule().(*WestModule)
+	for name, value := range map[string]string{
+		"target":        server.URL,
+		"allow_private": "true",
+		"rate_limit":    "200",
+		"timeout":       "5",
+	} {
+		if err := module.SetOption(name, value); err != nil {
+			t.Fatalf("SetOption(%s) failed: %v", name, err)
+		}
+	}
+
+	result, err := module.Run()
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	found := make(map[string]bool)
+	for _, vuln := range result.Data["vulnerabilities"].([]AuthVulnerability) {
+		found[vuln.Name] = true
+	}
+
+	for _, expected := range []string{
+		"OIDC Provider Advertises alg=none",
+		"Mixed Symmetric and Asymmetric Token Algorithms",
+		"PKCE S256 Not Supported",
+		"OAuth Implicit Flow Enabled",
+		"Weak RSA Token Signing Key",
+		"JWT Without Expiration",
+		"JWT Missing 'aud' Claim",
+		"JWT Signed With Weak HMAC Secret",
+		"JWT alg=none Accepted",
+		"Authorization Code Issued Without PKCE",
+		"OAuth State Parameter Not Preserved",
+	} {
+		if !found[expected] {
+			t.Errorf("expected finding %q, got %v", expected, found)
+		}
+	}
+
+	if found["JWT Algorithm Confusion (RS256 to HS256)"] {
+		t.Error("stand-in rejects HS256 tokens, confusion should not be reported")
+	}
+
+	tokens := result.Data["tokens"].([]TokenInfo)
+	if len(tokens) != 1 || tokens[0].Algorithm != "HS256" || tokens[0].Subject != "alice" {
+		t.Errorf("unexpected tokens: %+v", tokens)
+	}
+	if !strings.HasSuffix(tokens[0].Token, ".****.**") {
+		t.Errorf("token should be masked: %q", tokens[0].Token)
+	}
+}
+
+func TestWestAlgorithmConfusion(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	secret := rsaPublicKeyEncodings(&key.PublicKey)[0]
+
+	// Verifier that trusts the header and uses the public key PEM as HMAC secret
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, err := parseJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		if err != nil || !token.verifyHMAC(secret) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	module := NewWestModule().(*WestModule)
+	if err := module.SetOption("target", server.URL); err != nil {
+		t.Fatalf("SetOption failed: %v", err)
+	}
+	if err := module.Configure(); err != nil {
+		t.Fatalf("Configure failed: %v", err)
+	}
+
+	jwk := JSONWebKey{
+		Kty: "RSA",
+		Kid: "k1",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+	pub, err := jwk.rsaPublicKey()
+	if err != nil {
+		t.Fatalf("rsaPublicKey failed: %v", err)
+	}
+
+	claims := map[string]interface{}{"sub": "strigoi-probe"}
+	if vuln := module.testAlgorithmConfusion(context.Background(), server.URL, jwk, pub, claims); vuln == nil {
+		t.Error("expected algorithm confusion to be detected")
+	}
+}
@@ -0,0 +1,74 @@
+package engagement
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Audit decisions recorded for each request.
+const (
+	DecisionSent    = "sent"
+	DecisionBlocked = "blocked"
+	DecisionFailed  = "failed"
+)
+
+// AuditEntry records a single outbound request for rules-of-engagement evidence.
+type AuditEntry struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Module     string    `json:"module"`
+	Method     string    `json:"method"`
+	URL        string    `json:"url"`
+	Host       string    `json:"host"`
+	Decision   string    `json:"decision"`
+	Reason     string    `json:"reason,omitempty"`
+	StatusCode int       `json:"status_code,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// AuditLog writes audit entries as JSON lines.
+type AuditLog struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	closer  io.Closer
+}
+
+// NewAuditLog creates an audit log writing to w.
+func NewAuditLog(w io.Writer) *AuditLog {
+	log := &AuditLog{encoder: json.NewEncoder(w)}
+	if c, ok := w.(io.Closer); ok {
+		log.closer = c
+	}
+	return log
+}
+
+// OpenAuditLog opens filename for appending audit entries.
+func OpenAuditLog(filename string) (*AuditLog, error) {
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return NewAuditLog(file), nil
+}
+
+// Record appends an entry to the log.
+func (a *AuditLog) Record(entry AuditEntry) error {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.encoder.Encode(entry)
+}
+
+// Close closes the underlying writer if it is closable.
+func (a *AuditLog) Close() error {
+	if a == nil || a.closer == nil {
+		return nil
+	}
+	return a.closer.Close()
+}
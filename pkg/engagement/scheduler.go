@@ -0,0 +1,305 @@
+package engagement
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// SchedulerConfig configures a request scheduler.
+type SchedulerConfig struct {
+	RequestsPerSecond  float64 // global budget; <= 0 means unlimited
+	Burst              int
+	PerHostConcurrency int // maximum in-flight requests per host; <= 0 means unlimited
+	Scope              *Scope
+	AuditLog           *AuditLog
+}
+
+// SchedulerStats summarizes scheduler activity.
+type SchedulerStats struct {
+	Sent    int64  `json:"sent"`
+	Blocked int64  `json:"blocked"`
+	Failed  int64  `json:"failed"`
+	Scope   string `json:"scope,omitempty"`
+}
+
+// Scheduler gates every outbound probe request through the engagement
+// scope, a global requests-per-second budget and per-host concurrency.
+type Scheduler struct {
+	limiter *rate.Limiter // nil when unlimited
+	hosts   *hostSlots    // nil when unlimited
+	scope   *Scope
+	audit   *AuditLog
+	stats   *schedulerCounters
+}
+
+// hostSlots holds the per-host concurrency semaphores.
+type hostSlots struct {
+	limit int
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+// schedulerCounters are the counters of a scheduler and those derived
+// from it.
+type schedulerCounters struct {
+	mu    sync.Mutex
+	stats SchedulerStats
+}
+
+// NewScheduler creates a scheduler from cfg.
+func NewScheduler(cfg SchedulerConfig) *Scheduler {
+	s := &Scheduler{
+		limiter: newRateLimiter(cfg.RequestsPerSecond, cfg.Burst),
+		hosts:   newHostSlots(cfg.PerHostConcurrency),
+		scope:   cfg.Scope,
+		audit:   cfg.AuditLog,
+		stats:   &schedulerCounters{},
+	}
+	if cfg.Scope != nil {
+		s.stats.stats.Scope = cfg.Scope.Name
+	}
+	return s
+}
+
+// Derive returns a scheduler for a module that paces itself: the module's
+// rate and per-host limits apply where this scheduler sets none. The scope,
+// audit log, counters and any limits this scheduler does set are shared.
+func (s *Scheduler) Derive(rps float64, burst, perHost int) *Scheduler {
+	derived := &Scheduler{
+		limiter: s.limiter,
+		hosts:   s.hosts,
+		scope:   s.scope,
+		audit:   s.audit,
+		stats:   s.stats,
+	}
+	if derived.limiter == nil {
+		derived.limiter = newRateLimiter(rps, burst)
+	}
+	if derived.hosts == nil {
+		derived.hosts = newHostSlots(perHost)
+	}
+	return derived
+}
+
+// newRateLimiter creates the limiter of a requests-per-second budget, or
+// returns nil for an unlimited one.
+func newRateLimiter(rps float64, burst int) *rate.Limiter {
+	if rps <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = int(rps)
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return rate.NewLimiter(rate.Limit(rps), burst)
+}
+
+// newHostSlots creates per-host semaphores of limit slots, or returns nil
+// for unlimited concurrency.
+func newHostSlots(limit int) *hostSlots {
+	if limit <= 0 {
+		return nil
+	}
+	return &hostSlots{limit: limit, slots: make(map[string]chan struct{})}
+}
+
+var (
+	defaultMu        sync.RWMutex
+	defaultScheduler *Scheduler
+)
+
+// SetDefault installs the process-wide scheduler shared by all network modules.
+func SetDefault(s *Scheduler) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultScheduler = s
+}
+
+// Default returns the process-wide scheduler, or nil if none is installed.
+func Default() *Scheduler {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultScheduler
+}
+
+// Acquire checks scope, waits for budget and reserves a per-host slot for a
+// connection to u. Callers must invoke the returned release function when done.
+// Use it for non-HTTP connections such as raw TLS handshakes.
+func (s *Scheduler) Acquire(ctx context.Context, module, method string, u *url.URL) (func(), error) {
+	if err := s.scope.Check(ctx, u, time.Now()); err != nil {
+		s.count(DecisionBlocked)
+		s.record(AuditEntry{
+			Timestamp: time.Now(),
+			Module:    module,
+			Method:    method,
+			URL:       u.String(),
+			Host:      u.Host,
+			Decision:  DecisionBlocked,
+			Reason:    err.Error(),
+		})
+		return nil, err
+	}
+
+	if s.limiter != nil {
+		if err := s.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	slot := s.hostSlot(u.Host)
+	if slot == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case slot <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	var once sync.Once
+	return func() { once.Do(func() { <-slot }) }, nil
+}
+
+// Do sends req with client once the scheduler admits it, and records the
+// exchange in the audit log. The per-host slot is held until the response
+// body is closed.
+func (s *Scheduler) Do(ctx context.Context, module string, client *http.Client, req *http.Request) (*http.Response, error) {
+	return s.exchange(ctx, module, req, func() (*http.Response, error) {
+		return client.Do(req.WithContext(ctx))
+	})
+}
+
+// Transport wraps base so every request it carries is admitted by the
+// scheduler and audited for module, for clients that cannot call Do.
+func (s *Scheduler) Transport(module string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &schedulerTransport{scheduler: s, module: module, base: base}
+}
+
+// schedulerTransport sends requests through a scheduler.
+type schedulerTransport struct {
+	scheduler *Scheduler
+	module    string
+	base      http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *schedulerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.scheduler.exchange(req.Context(), t.module, req, func() (*http.Response, error) {
+		return t.base.RoundTrip(req)
+	})
+}
+
+// exchange admits req, sends it and records the outcome.
+func (s *Scheduler) exchange(ctx context.Context, module string, req *http.Request, send func() (*http.Response, error)) (*http.Response, error) {
+	release, err := s.Acquire(ctx, module, req.Method, req.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := send()
+
+	entry := AuditEntry{
+		Timestamp:  start,
+		Module:     module,
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		Host:       req.URL.Host,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+
+	if err != nil {
+		release()
+		entry.Decision = DecisionFailed
+		entry.Error = err.Error()
+		s.count(DecisionFailed)
+		s.record(entry)
+		return nil, err
+	}
+
+	entry.Decision = DecisionSent
+	entry.StatusCode = resp.StatusCode
+	s.count(DecisionSent)
+	s.record(entry)
+
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// Stats returns a snapshot of scheduler counters.
+func (s *Scheduler) Stats() SchedulerStats {
+	s.stats.mu.Lock()
+	defer s.stats.mu.Unlock()
+	return s.stats.stats
+}
+
+// hostSlot returns the concurrency semaphore for host.
+func (s *Scheduler) hostSlot(host string) chan struct{} {
+	if s.hosts == nil {
+		return nil
+	}
+
+	host = strings.ToLower(host)
+
+	s.hosts.mu.Lock()
+	defer s.hosts.mu.Unlock()
+
+	slot, ok := s.hosts.slots[host]
+	if !ok {
+		slot = make(chan struct{}, s.hosts.limit)
+		s.hosts.slots[host] = slot
+	}
+	return slot
+}
+
+// count updates the counter for a decision.
+func (s *Scheduler) count(decision string) {
+	s.stats.mu.Lock()
+	defer s.stats.mu.Unlock()
+
+	switch decision {
+	case DecisionSent:
+		s.stats.stats.Sent++
+	case DecisionBlocked:
+		s.stats.stats.Blocked++
+	case DecisionFailed:
+		s.stats.stats.Failed++
+	}
+}
+
+// record writes an audit entry, ignoring write errors so probes keep running.
+func (s *Scheduler) record(entry AuditEntry) {
+	_ = s.audit.Record(entry)
+}
+
+// IsOutOfScope reports whether err was caused by a scope violation.
+func IsOutOfScope(err error) bool {
+	return errors.Is(err, ErrOutOfScope)
+}
+
+// releasingBody frees the per-host slot when the response body is closed.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+// Close closes the body and releases the host slot.
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
@@ -0,0 +1,210 @@
+package engagement
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSchedulerDoRecordsAudit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	scheduler := NewScheduler(SchedulerConfig{AuditLog: NewAuditLog(&buf)})
+
+	req, _ := http.NewRequest("GET", server.URL+"/v1/models", nil)
+	resp, err := scheduler.Do(context.Background(), "probe/test", server.Client(), req)
+	if err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	resp.Body.Close()
+
+	var entry AuditEntry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("audit log is not JSON: %v (%q)", err, buf.String())
+	}
+	if entry.Module != "probe/test" || entry.Decision != DecisionSent || entry.StatusCode != http.StatusTeapot {
+		t.Errorf("unexpected audit entry: %+v", entry)
+	}
+
+	if stats := scheduler.Stats(); stats.Sent != 1 {
+		t.Errorf("expected 1 sent request, got %+v", stats)
+	}
+}
+
+func TestSchedulerBlocksOutOfScope(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&hits, 1)
+	}))
+	defer server.Close()
+
+	scope := &Scope{Name: "test", ExcludedPaths: []string{"/admin"}}
+	if err := scope.Compile(); err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	scheduler := NewScheduler(SchedulerConfig{Scope: scope, AuditLog: NewAuditLog(&buf)})
+
+	req, _ := http.NewRequest("GET", server.URL+"/admin", nil)
+	if _, err := scheduler.Do(context.Background(), "probe/test", server.Client(), req); !IsOutOfScope(err) {
+		t.Fatalf("expected out of scope error, got %v", err)
+	}
+
+	if atomic.LoadInt32(&hits) != 0 {
+		t.Error("out of scope request reached the server")
+	}
+	if !strings.Contains(buf.String(), `"decision":"blocked"`) {
+		t.Errorf("blocked request not audited: %s", buf.String())
+	}
+	if stats := scheduler.Stats(); stats.Blocked != 1 || stats.Scope != "test" {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestSchedulerPerHostConcurrency(t *testing.T) {
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		for {
+			seen := atomic.LoadInt32(&maxInFlight)
+			if current <= seen || atomic.CompareAndSwapInt32(&maxInFlight, seen, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	scheduler := NewScheduler(SchedulerConfig{PerHostConcurrency: 2})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest("GET", server.URL, nil)
+			resp, err := scheduler.Do(context.Background(), "probe/test", server.Client(), req)
+			if err != nil {
+				t.Errorf("Do failed: %v", err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&maxInFlight); got > 2 {
+		t.Errorf("expected at most 2 concurrent requests, saw %d", got)
+	}
+}
+
+func TestSchedulerRateLimit(t *testing.T) {
+	scheduler := NewScheduler(SchedulerConfig{RequestsPerSecond: 20, Burst: 1})
+	u, _ := url.Parse("https://api.example.com/")
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		release, err := scheduler.Acquire(context.Background(), "probe/test", "CONNECT", u)
+		if err != nil {
+			t.Fatalf("Acquire failed: %v", err)
+		}
+		release()
+	}
+
+	// 5 requests at 20 rps with burst 1 need at least 4 intervals of 50ms
+	if elapsed := time.Since(start); elapsed < 180*time.Millisecond {
+		t.Errorf("rate limit not enforced, 5 requests took %v", elapsed)
+	}
+}
+
+func TestSchedulerDeriveKeepsModuleLimits(t *testing.T) {
+	scope := &Scope{Name: "test", ExcludedPaths: []string{"/admin"}}
+	if err := scope.Compile(); err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	root := NewScheduler(SchedulerConfig{Scope: scope, AuditLog: NewAuditLog(&buf)})
+	derived := root.Derive(20, 1, 2)
+	u, _ := url.Parse("https://api.example.com/")
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		release, err := derived.Acquire(context.Background(), "probe/test", "CONNECT", u)
+		if err != nil {
+			t.Fatalf("Acquire failed: %v", err)
+		}
+		release()
+	}
+	if elapsed := time.Since(start); elapsed < 180*time.Millisecond {
+		t.Errorf("module rate limit not enforced, 5 requests took %v", elapsed)
+	}
+	if slot := derived.hostSlot("api.example.com"); cap(slot) != 2 {
+		t.Errorf("expected 2 per-host slots, got %d", cap(slot))
+	}
+
+	blocked, _ := url.Parse("https://api.example.com/admin")
+	if _, err := derived.Acquire(context.Background(), "probe/test", "GET", blocked); !IsOutOfScope(err) {
+		t.Fatalf("expected out of scope error, got %v", err)
+	}
+	if stats := root.Stats(); stats.Blocked != 1 || stats.Scope != "test" {
+		t.Errorf("derived requests not counted by the engagement: %+v", stats)
+	}
+	if !strings.Contains(buf.String(), `"decision":"blocked"`) {
+		t.Errorf("derived request not audited: %s", buf.String())
+	}
+}
+
+func TestSchedulerDeriveSharesEngagementLimits(t *testing.T) {
+	root := NewScheduler(SchedulerConfig{RequestsPerSecond: 5, PerHostConcurrency: 1})
+	derived := root.Derive(1000, 1000, 50)
+
+	if derived.limiter != root.limiter {
+		t.Error("derived scheduler does not share the engagement rate limit")
+	}
+	if derived.hostSlot("api.example.com") != root.hostSlot("API.example.com") {
+		t.Error("derived scheduler does not share the engagement host slots")
+	}
+}
+
+func TestSchedulerTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	scheduler := NewScheduler(SchedulerConfig{AuditLog: NewAuditLog(&buf), PerHostConcurrency: 1})
+	client := &http.Client{Transport: scheduler.Transport("probe/test", nil)}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL + "/mcp")
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		// The host slot is held until the body is closed
+		resp.Body.Close()
+	}
+
+	if stats := scheduler.Stats(); stats.Sent != 2 {
+		t.Errorf("expected 2 sent requests, got %+v", stats)
+	}
+	if strings.Count(buf.String(), `"module":"probe/test"`) != 2 {
+		t.Errorf("transport requests not audited: %s", buf.String())
+	}
+}
@@ -0,0 +1,315 @@
+// Package engagement enforces the rules of engagement for active network
+// probes: an engagement scope, a shared request budget and an audit trail.
+package engagement
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// ErrOutOfScope is returned when a request falls outside the engagement scope.
+var ErrOutOfScope = errors.New("target is out of engagement scope")
+
+// Scope describes which targets may be contacted and when.
+type Scope struct {
+	Name          string       `json:"name,omitempty"`
+	AllowedHosts  []string     `json:"allowed_hosts,omitempty"`  // exact hostnames or "*.example.com"
+	AllowedCIDRs  []string     `json:"allowed_cidrs,omitempty"`  // e.g. "10.0.0.0/8"
+	AllowedPaths  []string     `json:"allowed_paths,omitempty"`  // path prefixes or globs; empty allows all
+	ExcludedPaths []string     `json:"excluded_paths,omitempty"` // path prefixes or globs
+	TimeWindows   []TimeWindow `json:"time_windows,omitempty"`   // empty allows any time
+
+	networks []*net.IPNet
+	lookup   func(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// TimeWindow is either an absolute Start/End range or a recurring daily
+// window (Days, From, To) evaluated in Timezone.
+type TimeWindow struct {
+	Start    time.Time `json:"start,omitempty"`
+	End      time.Time `json:"end,omitempty"`
+	Days     []string  `json:"days,omitempty"` // mon, tue, ... sun
+	From     string    `json:"from,omitempty"` // HH:MM
+	To       string    `json:"to,omitempty"`   // HH:MM
+	Timezone string    `json:"timezone,omitempty"`
+}
+
+// LoadScope reads and validates a JSON scope file.
+func LoadScope(filename string) (*Scope, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scope file: %w", err)
+	}
+
+	var scope Scope
+	if err := json.Unmarshal(data, &scope); err != nil {
+		return nil, fmt.Errorf("failed to parse scope file: %w", err)
+	}
+
+	if err := scope.Compile(); err != nil {
+		return nil, err
+	}
+
+	return &scope, nil
+}
+
+// Compile validates the scope and prepares it for matching.
+func (s *Scope) Compile() error {
+	s.networks = nil
+	for _, cidr := range s.AllowedCIDRs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("invalid CIDR %q: %w", cidr, err)
+		}
+		s.networks = append(s.networks, network)
+	}
+
+	for i, w := range s.TimeWindows {
+		if err := w.validate(); err != nil {
+			return fmt.Errorf("invalid time window %d: %w", i, err)
+		}
+	}
+
+	for _, p := range append(append([]string{}, s.AllowedPaths...), s.ExcludedPaths...) {
+		if _, err := path.Match(p, "/"); err != nil {
+			return fmt.Errorf("invalid path pattern %q: %w", p, err)
+		}
+	}
+
+	return nil
+}
+
+// Check reports whether a request to u at the given time is within scope.
+// The returned error wraps ErrOutOfScope and explains the violated rule.
+func (s *Scope) Check(ctx context.Context, u *url.URL, now time.Time) error {
+	if s == nil {
+		return nil
+	}
+
+	if !s.inWindow(now) {
+		return fmt.Errorf("%w: outside permitted time windows", ErrOutOfScope)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if !s.hostAllowed(ctx, host) {
+		return fmt.Errorf("%w: host %s not allowed", ErrOutOfScope, host)
+	}
+
+	reqPath, err := scopePath(u)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrOutOfScope, err)
+	}
+
+	for _, excluded := range s.ExcludedPaths {
+		if matchPath(excluded, reqPath) {
+			return fmt.Errorf("%w: path %s is excluded", ErrOutOfScope, reqPath)
+		}
+	}
+
+	if len(s.AllowedPaths) > 0 {
+		for _, allowed := range s.AllowedPaths {
+			if matchPath(allowed, reqPath) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: path %s not allowed", ErrOutOfScope, reqPath)
+	}
+
+	return nil
+}
+
+// hostAllowed matches a host against allowed names and networks.
+func (s *Scope) hostAllowed(ctx context.Context, host string) bool {
+	if len(s.AllowedHosts) == 0 && len(s.AllowedCIDRs) == 0 {
+		return true
+	}
+
+	for _, pattern := range s.AllowedHosts {
+		pattern = strings.ToLower(pattern)
+		if pattern == host {
+			return true
+		}
+		if strings.HasPrefix(pattern, "*.") && strings.HasSuffix(host, pattern[1:]) {
+			return true
+		}
+	}
+
+	if len(s.AllowedCIDRs) == 0 {
+		return false
+	}
+
+	if s.networks == nil {
+		if err := s.Compile(); err != nil {
+			return false
+		}
+	}
+
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		lookup := s.lookup
+		if lookup == nil {
+			lookup = net.DefaultResolver.LookupIPAddr
+		}
+		addrs, err := lookup(ctx, host)
+		if err != nil || len(addrs) == 0 {
+			return false
+		}
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+
+	// Every resolved address must be in scope so DNS cannot steer us out
+	for _, ip := range ips {
+		if !s.ipAllowed(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// ipAllowed checks an address against the allowed networks.
+func (s *Scope) ipAllowed(ip net.IP) bool {
+	for _, network := range s.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// inWindow checks whether now falls in any configured time window.
+func (s *Scope) inWindow(now time.Time) bool {
+	if len(s.TimeWindows) == 0 {
+		return true
+	}
+	for _, w := range s.TimeWindows {
+		if w.contains(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// validate checks the window definition.
+func (w TimeWindow) validate() error {
+	if !w.Start.IsZero() || !w.End.IsZero() {
+		if !w.Start.IsZero() && !w.End.IsZero() && !w.End.After(w.Start) {
+			return fmt.Errorf("end must be after start")
+		}
+		return nil
+	}
+
+	if w.From == "" || w.To == "" {
+		return fmt.Errorf("either start/end or from/to is required")
+	}
+	if _, err := time.Parse("15:04", w.From); err != nil {
+		return fmt.Errorf("invalid from time %q", w.From)
+	}
+	if _, err := time.Parse("15:04", w.To); err != nil {
+		return fmt.Errorf("invalid to time %q", w.To)
+	}
+	if w.Timezone != "" {
+		if _, err := time.LoadLocation(w.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone %q", w.Timezone)
+		}
+	}
+	for _, day := range w.Days {
+		if _, ok := weekdays[strings.ToLower(day)]; !ok {
+			return fmt.Errorf("invalid day %q", day)
+		}
+	}
+	return nil
+}
+
+// contains reports whether t falls inside the window.
+func (w TimeWindow) contains(t time.Time) bool {
+	if !w.Start.IsZero() || !w.End.IsZero() {
+		if !w.Start.IsZero() && t.Before(w.Start) {
+			return false
+		}
+		if !w.End.IsZero() && !t.Before(w.End) {
+			return false
+		}
+		return true
+	}
+
+	if w.Timezone != "" {
+		if loc, err := time.LoadLocation(w.Timezone); err == nil {
+			t = t.In(loc)
+		}
+	}
+
+	if len(w.Days) > 0 {
+		dayAllowed := false
+		for _, day := range w.Days {
+			if weekdays[strings.ToLower(day)] == t.Weekday() {
+				dayAllowed = true
+				break
+			}
+		}
+		if !dayAllowed {
+			return false
+		}
+	}
+
+	from, _ := time.Parse("15:04", w.From)
+	to, _ := time.Parse("15:04", w.To)
+	minute := t.Hour()*60 + t.Minute()
+	start := from.Hour()*60 + from.Minute()
+	end := to.Hour()*60 + to.Minute()
+
+	if start <= end {
+		return minute >= start && minute < end
+	}
+	// Window wraps past midnight
+	return minute >= start || minute < end
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// scopePath returns the path of u as the server resolves it: unescaped,
+// with dot segments and repeated slashes removed, so that /public/../admin,
+// //admin and %2e%2e/admin are all checked as /admin. A trailing slash is
+// kept.
+func scopePath(u *url.URL) (string, error) {
+	unescaped, err := url.PathUnescape(u.EscapedPath())
+	if err != nil {
+		return "", fmt.Errorf("invalid path %q: %w", u.EscapedPath(), err)
+	}
+	cleaned := path.Clean("/" + unescaped)
+	if strings.HasSuffix(unescaped, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned, nil
+}
+
+// matchPath matches a request path against a glob pattern, or a prefix
+// that ends at a path segment boundary: /api matches /api and /api/v1 but
+// not /api-admin.
+func matchPath(pattern, reqPath string) bool {
+	if strings.ContainsAny(pattern, "*?[") {
+		matched, _ := path.Match(pattern, reqPath)
+		return matched
+	}
+	rest, ok := strings.CutPrefix(reqPath, pattern)
+	return ok && (rest == "" || strings.HasSuffix(pattern, "/") || strings.HasPrefix(rest, "/"))
+}
@@ -0,0 +1,171 @@
+package engagement
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestScopeCheck(t *testing.T) {
+	scope := &Scope{
+		AllowedHosts:  []string{"api.example.com", "*.corp.example"},
+		AllowedCIDRs:  []string{"10.0.0.0/8"},
+		ExcludedPaths: []string{"/admin", "/v1/*/delete"},
+	}
+	if err := scope.Compile(); err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	scope.lookup = func(_ context.Context, host string) ([]net.IPAddr, error) {
+		switch host {
+		case "internal.test":
+			return []net.IPAddr{{IP: net.ParseIP("10.1.2.3")}}, nil
+		case "mixed.test":
+			return []net.IPAddr{{IP: net.ParseIP("10.1.2.3")}, {IP: net.ParseIP("8.8.8.8")}}, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: host}
+	}
+
+	tests := []struct {
+		name    string
+		rawURL  string
+		inScope bool
+	}{
+		{"exact host", "https://api.example.com/v1/models", true},
+		{"wildcard host", "https://llm.corp.example/v1/chat", true},
+		{"host not listed", "https://evil.example.org/", false},
+		{"ip in cidr", "http://10.20.30.40:8080/health", true},
+		{"ip outside cidr", "http://192.168.1.1/", false},
+		{"resolved into cidr", "http://internal.test/", true},
+		{"partially resolved outside cidr", "http://mixed.test/", false},
+		{"excluded prefix", "https://api.example.com/admin/users", false},
+		{"excluded prefix exact", "https://api.example.com/admin", false},
+		{"prefix not at segment boundary", "https://api.example.com/administrator", true},
+		{"excluded glob", "https://api.example.com/v1/models/delete", false},
+		{"dot segments", "https://api.example.com/public/../admin", false},
+		{"repeated slash", "https://api.example.com//admin", false},
+		{"encoded dot segments", "https://api.example.com/public/%2e%2e/admin/users", false},
+		{"uppercase encoded dot segments", "https://api.example.com/public/%2E%2E/admin", false},
+		{"encoded letters", "https://api.example.com/%61dmin", false},
+		{"encoded slash", "https://api.example.com/public%2F..%2Fadmin", false},
+		{"dot segment into glob", "https://api.example.com/v1/x/./delete", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, _ := url.Parse(tt.rawURL)
+			err := scope.Check(context.Background(), u, time.Now())
+			if tt.inScope && err != nil {
+				t.Errorf("expected in scope, got %v", err)
+			}
+			if !tt.inScope && !IsOutOfScope(err) {
+				t.Errorf("expected out of scope, got %v", err)
+			}
+		})
+	}
+}
+
+func TestScopeAllowedPaths(t *testing.T) {
+	scope := &Scope{AllowedPaths: []string{"/v1/"}}
+	if err := scope.Compile(); err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	allowed, _ := url.Parse("https://any.example/v1/models")
+	if err := scope.Check(context.Background(), allowed, time.Now()); err != nil {
+		t.Errorf("expected /v1/models to be allowed, got %v", err)
+	}
+
+	denied, _ := url.Parse("https://any.example/api/tags")
+	if err := scope.Check(context.Background(), denied, time.Now()); !IsOutOfScope(err) {
+		t.Errorf("expected /api/tags to be out of scope, got %v", err)
+	}
+
+	for _, raw := range []string{"https://any.example/v1/../api/tags", "https://any.example/v1/%2e%2e/api/tags", "https://any.example/v1"} {
+		escaped, _ := url.Parse(raw)
+		if err := scope.Check(context.Background(), escaped, time.Now()); !IsOutOfScope(err) {
+			t.Errorf("expected %s to be out of scope, got %v", raw, err)
+		}
+	}
+	dir, _ := url.Parse("https://any.example/v1//models/")
+	if err := scope.Check(context.Background(), dir, time.Now()); err != nil {
+		t.Errorf("expected /v1//models/ to be allowed, got %v", err)
+	}
+}
+
+func TestScopeTimeWindows(t *testing.T) {
+	scope := &Scope{
+		TimeWindows: []TimeWindow{
+			{Days: []string{"mon", "tue", "wed", "thu", "fri"}, From: "09:00", To: "17:00", Timezone: "UTC"},
+			{From: "22:00", To: "02:00", Timezone: "UTC"},
+		},
+	}
+	if err := scope.Compile(); err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	u, _ := url.Parse("https://api.example.com/")
+	tests := []struct {
+		name    string
+		at      time.Time
+		inScope bool
+	}{
+		{"weekday business hours", time.Date(2025, 3, 5, 10, 30, 0, 0, time.UTC), true},
+		{"weekday evening", time.Date(2025, 3, 5, 18, 0, 0, 0, time.UTC), false},
+		{"weekend business hours", time.Date(2025, 3, 8, 10, 30, 0, 0, time.UTC), false},
+		{"overnight window before midnight", time.Date(2025, 3, 8, 23, 0, 0, 0, time.UTC), true},
+		{"overnight window after midnight", time.Date(2025, 3, 9, 1, 0, 0, 0, time.UTC), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := scope.Check(context.Background(), u, tt.at)
+			if tt.inScope != (err == nil) {
+				t.Errorf("expected inScope=%v, got %v", tt.inScope, err)
+			}
+		})
+	}
+
+	absolute := &Scope{TimeWindows: []TimeWindow{{
+		Start: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC),
+	}}}
+	if err := absolute.Check(context.Background(), u, time.Date(2025, 3, 20, 0, 0, 0, 0, time.UTC)); !IsOutOfScope(err) {
+		t.Errorf("expected request after engagement end to be out of scope, got %v", err)
+	}
+}
+
+func TestLoadScope(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "scope.json")
+	content := `{
+		"name": "acme-q3",
+		"allowed_hosts": ["api.acme.test"],
+		"allowed_cidrs": ["203.0.113.0/24"],
+		"excluded_paths": ["/billing"],
+		"time_windows": [{"from": "08:00", "to": "18:00", "timezone": "UTC"}]
+	}`
+	if err := os.WriteFile(valid, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write scope: %v", err)
+	}
+
+	scope, err := LoadScope(valid)
+	if err != nil {
+		t.Fatalf("LoadScope failed: %v", err)
+	}
+	if scope.Name != "acme-q3" || len(scope.networks) != 1 {
+		t.Errorf("unexpected scope: %+v", scope)
+	}
+
+	invalid := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(invalid, []byte(`{"allowed_cidrs": ["not-a-cidr"]}`), 0600); err != nil {
+		t.Fatalf("failed to write scope: %v", err)
+	}
+	if _, err := LoadScope(invalid); err == nil {
+		t.Error("expected invalid CIDR to be rejected")
+	}
+}
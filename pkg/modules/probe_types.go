@@ -10,4 +10,5 @@ type EndpointInfo struct {
 	ContentType string            `json:"content_type"`
 	Headers     map[string]string `json:"headers"`
 	Timestamp   time.Time         `json:"timestamp"`
+	HAREntryID  string            `json:"har_entry_id,omitempty"`
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/macawi-ai/strigoi/modules/probe"
 	"github.com/macawi-ai/strigoi/pkg/modules"
+	"github.com/macawi-ai/strigoi/pkg/security"
 )
 
 // ConvertModuleResult converts a module.ModuleResult to StandardOutput.
@@ -54,7 +55,7 @@ func ConvertModuleResult(result modules.ModuleResult) StandardOutput {
 				if output.Results == nil {
 					output.Results = make(map[string]interface{})
 				}
-				output.Results["ai_services"] = aiServices
+				output.Results["ai_services"] = ConvertAIServices(aiServices)
 			}
 
 			// Add ethical discovery data if present
@@ -64,6 +65,41 @@ func ConvertModuleResult(result modules.ModuleResult) StandardOutput {
 				}
 				output.Results["ethical_discovery"] = ethical
 			}
+
+			// Add engagement scheduler statistics if present
+			if stats, ok := result.Data["engagement"]; ok {
+				if output.Results == nil {
+					output.Results = make(map[string]interface{})
+				}
+				output.Results["engagement"] = stats
+			}
+
+			// Reference the HAR evidence archive if one was written
+			if harFile, ok := result.Data["har_file"]; ok {
+				if output.Results == nil {
+					output.Results = make(map[string]interface{})
+				}
+				output.Results["har_file"] = harFile
+			}
+
+			// Add the TLS posture of HTTPS targets
+			if assessment, ok := result.Data["tls"].(*probe.TLSAssessment); ok && assessment != nil {
+				if output.Results == nil {
+					output.Results = make(map[string]interface{})
+				}
+				output.Results["tls_assessment"] = tlsAssessmentToMap(assessment, true)
+			}
+		} else if _, ok := result.Data["auth_endpoints"]; ok {
+			// Handle probe west authentication results
+			output.Results = ConvertWestResults(westDataToMaps(result.Data))
+			if target, ok := result.Data["target"].(string); ok {
+				output.Target = target
+			}
+			for _, key := range []string{"engagement", "har_file"} {
+				if v, ok := result.Data[key]; ok {
+					output.Results[key] = v
+				}
+			}
 		} else {
 			// Direct assignment for other modules
 			output.Results = result.Data
@@ -153,6 +189,10 @@ func ConvertProbeResults(data interface{}) map[string]interface{} {
 			}
 		}
 
+		if len(scResult.RulePacks) > 0 {
+			results["rule_packs"] = convertRulePacks(scResult.RulePacks)
+		}
+
 		// MCP tools
 		if len(scResult.MCPTools) > 0 {
 			mcpTools := make([]interface{}, len(scResult.MCPTools))
@@ -179,6 +219,16 @@ func ConvertProbeResults(data interface{}) map[string]interface{} {
 					"command_line":     tool.CommandLine,
 					"build_info":       tool.BuildInfo,
 					"network_exposure": tool.NetworkExposure,
+					"client":           tool.Client,
+					"transport":        tool.Transport,
+					"url":              tool.URL,
+					"command":          tool.Command,
+					"args":             tool.Args,
+					"env":              tool.Env,
+				}
+				if tool.CapabilityProfile != nil {
+					toolMap["capability_profile"] = convertCapabilityProfile(tool.CapabilityProfile, tool.RiskScore)
+					toolMap["risk_score"] = tool.RiskScore
 				}
 
 				// Convert security risks
@@ -194,6 +244,7 @@ func ConvertProbeResults(data interface{}) map[string]interface{} {
 							"description": risk.Description,
 							"evidence":    risk.Evidence,
 							"file_path":   risk.FilePath,
+							"server":      risk.Server,
 							"line_number": risk.LineNumber,
 							"remediation": risk.Remediation,
 							"references":  risk.References,
@@ -302,6 +353,7 @@ func ConvertProbeResults(data interface{}) map[string]interface{} {
 					"remediation": finding.Remediation,
 					"data_flow":   finding.DataFlow,
 					"references":  finding.References,
+					"regulations": finding.Regulations,
 				}
 
 				// Count severities
@@ -340,11 +392,17 @@ func ConvertProbeResults(data interface{}) map[string]interface{} {
 					"destination":     flow.Destination,
 					"sensitive_data":  flow.SensitiveData,
 					"protection":      flow.Protection,
+					"hops":            convertFlowHops(flow.Hops),
 				}
 			}
 			results["data_flows"] = flows
 		}
 
+		// PII in prompt templates, by destination provider
+		if len(dfResult.PIIByProvider) > 0 {
+			results["pii_by_provider"] = dfResult.PIIByProvider
+		}
+
 		// External services
 		if len(dfResult.ExternalServices) > 0 {
 			services := make([]interface{}, len(dfResult.ExternalServices))
@@ -471,6 +529,11 @@ func ConvertEndpointResults(endpoints []modules.EndpointInfo) map[string]interfa
 			severityCounts[SeverityHigh]++
 		}
 
+		// Reference the archived exchange
+		if ep.HAREntryID != "" {
+			epMap["har_entry"] = ep.HAREntryID
+		}
+
 		byStatus[ep.StatusCode] = append(byStatus[ep.StatusCode], epMap)
 	}
 
@@ -502,6 +565,39 @@ func ConvertEndpointResults(endpoints []modules.EndpointInfo) map[string]interfa
 	return results
 }
 
+// ConvertAIServices converts probe/north AI service data, turning security
+// findings into maps that carry their HAR entry reference.
+func ConvertAIServices(data interface{}) interface{} {
+	services, ok := data.(map[string]interface{})
+	if !ok {
+		return data
+	}
+
+	converted := make(map[string]interface{}, len(services))
+	for k, v := range services {
+		converted[k] = v
+	}
+
+	if findings, ok := services["security_findings"].([]probe.SecurityFinding); ok {
+		list := make([]interface{}, len(findings))
+		for i, finding := range findings {
+			findingMap := map[string]interface{}{
+				"severity":    finding.Severity,
+				"name":        finding.Name,
+				"description": finding.Description,
+				"evidence":    finding.Evidence,
+			}
+			if finding.HAREntryID != "" {
+				findingMap["har_entry"] = finding.HAREntryID
+			}
+			list[i] = findingMap
+		}
+		converted["security_findings"] = list
+	}
+
+	return converted
+}
+
 // Helper to count endpoints in a status range.
 func countByStatusRange(byStatus map[int][]interface{}, min, max int) int {
 	count := 0
@@ -689,9 +785,231 @@ func ConvertWestResults(data map[string]interface{}) map[string]interface{} {
 		results["security_recommendations"] = recs
 	}
 
+	// Process decoded tokens and OpenID Provider configuration
+	if tokens, ok := data["tokens"].([]interface{}); ok && len(tokens) > 0 {
+		results["tokens"] = tokens
+	}
+	if provider, ok := data["oidc"].(map[string]interface{}); ok {
+		results["oidc_provider"] = provider
+	}
+
+	// Process TLS posture; its findings are already part of vulnerabilities
+	if assessment, ok := data["tls"].(map[string]interface{}); ok {
+		results["tls_assessment"] = assessment
+	}
+
 	return results
 }
 
+// westDataToMaps converts the typed probe/west result data into the generic
+// maps expected by ConvertWestResults.
+func westDataToMaps(data map[string]interface{}) map[string]interface{} {
+	converted := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		converted[k] = v
+	}
+
+	if vulns, ok := data["vulnerabilities"].([]probe.AuthVulnerability); ok {
+		list := make([]interface{}, len(vulns))
+		for i, vuln := range vulns {
+			list[i] = authVulnerabilityToMap(vuln)
+		}
+		converted["vulnerabilities"] = list
+	}
+
+	if endpoints, ok := data["auth_endpoints"].([]probe.AuthEndpoint); ok {
+		list := make([]interface{}, len(endpoints))
+		for i, ep := range endpoints {
+			epMap := map[string]interface{}{
+				"url":           ep.URL,
+				"method":        ep.Method,
+				"auth_type":     ep.AuthType,
+				"requires_auth": ep.RequiresAuth,
+				"mfa_enabled":   ep.MFAEnabled,
+				"headers":       ep.Headers,
+			}
+			if len(ep.Vulnerabilities) > 0 {
+				epVulns := make([]interface{}, len(ep.Vulnerabilities))
+				for j, vuln := range ep.Vulnerabilities {
+					epVulns[j] = authVulnerabilityToMap(vuln)
+				}
+				epMap["vulnerabilities"] = epVulns
+			}
+			if ep.HAREntryID != "" {
+				epMap["har_entry"] = ep.HAREntryID
+			}
+			list[i] = epMap
+		}
+		converted["auth_endpoints"] = list
+	}
+
+	if patterns, ok := data["session_info"].([]probe.SessionPattern); ok {
+		list := make([]interface{}, len(patterns))
+		for i, pattern := range patterns {
+			weaknesses := make([]interface{}, len(pattern.Weaknesses))
+			for j, w := range pattern.Weaknesses {
+				weaknesses[j] = w
+			}
+			list[i] = map[string]interface{}{
+				"type":       pattern.Type,
+				"pattern":    pattern.Pattern,
+				"secure":     pattern.Secure,
+				"httponly":   pattern.HTTPOnly,
+				"samesite":   pattern.SameSite,
+				"weaknesses": weaknesses,
+			}
+		}
+		converted["session_info"] = list
+	}
+
+	if matrix, ok := data["access_control"].(map[string]probe.AccessRequirement); ok {
+		access := make(map[string]interface{}, len(matrix))
+		for resource, req := range matrix {
+			access[resource] = map[string]interface{}{
+				"resource":   req.Resource,
+				"methods":    req.Methods,
+				"roles":      req.Roles,
+				"conditions": req.Conditions,
+			}
+		}
+		converted["access_control"] = access
+	}
+
+	if tokens, ok := data["tokens"].([]probe.TokenInfo); ok {
+		list := make([]interface{}, len(tokens))
+		for i, token := range tokens {
+			tokenMap := map[string]interface{}{
+				"source": token.Source,
+				"alg":    token.Algorithm,
+				"token":  token.Token,
+			}
+			for key, value := range map[string]string{"kid": token.KeyID, "iss": token.Issuer, "sub": token.Subject, "har_entry": token.HAREntryID} {
+				if value != "" {
+					tokenMap[key] = value
+				}
+			}
+			if len(token.Audience) > 0 {
+				tokenMap["aud"] = token.Audience
+			}
+			if token.IssuedAt != nil {
+				tokenMap["iat"] = token.IssuedAt
+			}
+			if token.ExpiresAt != nil {
+				tokenMap["exp"] = token.ExpiresAt
+			}
+			list[i] = tokenMap
+		}
+		converted["tokens"] = list
+	}
+
+	if provider, ok := data["oidc"].(*probe.OIDCConfiguration); ok && provider != nil {
+		converted["oidc"] = map[string]interface{}{
+			"issuer":                                provider.Issuer,
+			"authorization_endpoint":                provider.AuthorizationEndpoint,
+			"token_endpoint":                        provider.TokenEndpoint,
+			"userinfo_endpoint":                     provider.UserinfoEndpoint,
+			"jwks_uri":                              provider.JWKSURI,
+			"id_token_signing_alg_values_supported": provider.IDTokenSigningAlgValuesSupported,
+			"code_challenge_methods_supported":      provider.CodeChallengeMethodsSupported,
+			"response_types_supported":              provider.ResponseTypesSupported,
+		}
+	}
+
+	if assessment, ok := data["tls"].(*probe.TLSAssessment); ok && assessment != nil {
+		converted["tls"] = tlsAssessmentToMap(assessment, false)
+	}
+
+	if recs, ok := data["recommendations"].(map[string][]string); ok {
+		recommendations := make(map[string]interface{}, len(recs))
+		for priority, list := range recs {
+			items := make([]interface{}, len(list))
+			for i, rec := range list {
+				items[i] = rec
+			}
+			recommendations[priority] = items
+		}
+		converted["recommendations"] = recommendations
+	}
+
+	return converted
+}
+
+// authVulnerabilityToMap converts a probe/west vulnerability for the formatters.
+func authVulnerabilityToMap(vuln probe.AuthVulnerability) map[string]interface{} {
+	vulnMap := map[string]interface{}{
+		"id":          vuln.ID,
+		"name":        vuln.Name,
+		"severity":    vuln.Severity,
+		"category":    vuln.Category,
+		"description": vuln.Description,
+		"evidence": map[string]interface{}{
+			"request":  vuln.Evidence.Request,
+			"response": vuln.Evidence.Response,
+			"details":  vuln.Evidence.Details,
+		},
+		"remediation": vuln.Remediation,
+		"references":  vuln.References,
+		"confidence":  vuln.Confidence,
+		"timestamp":   vuln.Timestamp,
+	}
+	if vuln.Evidence.HAREntryID != "" {
+		vulnMap["har_entry"] = vuln.Evidence.HAREntryID
+	}
+	return vulnMap
+}
+
+// tlsAssessmentToMap converts a TLS assessment for the formatters. Findings
+// are only included when they are not reported elsewhere in the results.
+func tlsAssessmentToMap(assessment *probe.TLSAssessment, includeFindings bool) map[string]interface{} {
+	suites := make(map[string]interface{}, len(assessment.CipherSuites))
+	for version, names := range assessment.CipherSuites {
+		suites[version] = names
+	}
+
+	tlsMap := map[string]interface{}{
+		"host":               assessment.Host,
+		"port":               assessment.Port,
+		"protocols":          assessment.Protocols,
+		"cipher_suites":      suites,
+		"negotiated_version": assessment.NegotiatedVersion,
+		"negotiated_cipher":  assessment.NegotiatedCipher,
+		"ocsp_stapled":       assessment.OCSPStapled,
+		"hsts":               assessment.HSTS,
+		"hsts_preloadable":   assessment.HSTSPreloadable,
+	}
+
+	if cert := assessment.Certificate; cert != nil {
+		certMap := map[string]interface{}{
+			"subject":             cert.Subject,
+			"issuer":              cert.Issuer,
+			"dns_names":           cert.DNSNames,
+			"not_before":          cert.NotBefore,
+			"not_after":           cert.NotAfter,
+			"key":                 fmt.Sprintf("%s %d", cert.KeyType, cert.KeyBits),
+			"signature_algorithm": cert.SignatureAlgorithm,
+			"self_signed":         cert.SelfSigned,
+			"chain_valid":         cert.ChainValid,
+		}
+		if cert.ChainError != "" {
+			certMap["chain_error"] = cert.ChainError
+		}
+		if cert.NameMismatch != "" {
+			certMap["name_mismatch"] = cert.NameMismatch
+		}
+		tlsMap["certificate"] = certMap
+	}
+
+	if includeFindings && len(assessment.Findings) > 0 {
+		findings := make([]interface{}, len(assessment.Findings))
+		for i, vuln := range assessment.Findings {
+			findings[i] = authVulnerabilityToMap(vuln)
+		}
+		tlsMap["findings"] = findings
+	}
+
+	return tlsMap
+}
+
 // ConvertCenterResults converts center module stream monitoring results to standard format.
 func ConvertCenterResults(data map[string]interface{}) map[string]interface{} {
 	results := make(map[string]interface{})
@@ -759,6 +1077,11 @@ func ConvertCenterResults(data map[string]interface{}) map[string]interface{} {
 		results["captured_flows"] = flows
 	}
 
+	// PII observed in LLM request bodies, by destination provider
+	if piiByProvider, ok := data["pii_by_provider"].(map[string]map[string]int); ok {
+		results["pii_by_provider"] = piiByProvider
+	}
+
 	// Add log file location
 	if logFile, ok := data["log_file"].(string); ok {
 		results["output_log"] = logFile
@@ -766,3 +1089,151 @@ func ConvertCenterResults(data map[string]interface{}) map[string]interface{} {
 
 	return results
 }
+
+// convertFlowHops converts the file/line steps of a traced data flow.
+func convertFlowHops(hops []probe.FlowHop) []interface{} {
+	converted := make([]interface{}, len(hops))
+	for i, hop := range hops {
+		converted[i] = map[string]interface{}{
+			"file":   hop.File,
+			"line":   hop.Line,
+			"kind":   hop.Kind,
+			"detail": hop.Detail,
+		}
+	}
+	return converted
+}
+
+// convertRulePacks converts the rule packs a scan used.
+func convertRulePacks(packs []security.RulePackInfo) []interface{} {
+	converted := make([]interface{}, len(packs))
+	for i, pack := range packs {
+		converted[i] = map[string]interface{}{
+			"name":     pack.Name,
+			"version":  pack.Version,
+			"checksum": pack.Checksum,
+			"source":   pack.Source,
+			"rules":    pack.Rules,
+		}
+	}
+	return converted
+}
+
+// convertCapabilityProfile converts an MCP server's blast-radius profile.
+func convertCapabilityProfile(profile *security.MCPCapabilityProfile, score int) map[string]interface{} {
+	return map[string]interface{}{
+		"risk_score":        score,
+		"lethal_trifecta":   profile.LethalTrifecta(),
+		"private_data":      profile.PrivateData,
+		"untrusted_content": profile.UntrustedContent,
+		"network_egress":    profile.NetworkEgress,
+		"privileged":        profile.Privileged,
+		"runs_as":           profile.RunsAs,
+		"filesystem_roots":  profile.FilesystemRoots,
+		"databases":         profile.Databases,
+		"exec_tools":        profile.ExecTools,
+		"egress_targets":    profile.EgressTargets,
+		"factors":           profile.Factors,
+	}
+}
+
+// ConvertMCPInterrogation converts a live MCP server interrogation to standard format.
+func ConvertMCPInterrogation(result *security.MCPInterrogation) map[string]interface{} {
+	results := make(map[string]interface{})
+
+	server := map[string]interface{}{
+		"transport":        result.Transport,
+		"name":             result.ServerName,
+		"version":          result.ServerVersion,
+		"protocol_version": result.ProtocolVersion,
+		"capabilities":     result.Capabilities,
+	}
+	if result.Target.Source != "" {
+		server["config"] = result.Target.Source
+	}
+	if result.Instructions != "" {
+		server["instructions"] = result.Instructions
+	}
+	results["server"] = server
+
+	if len(result.RulePacks) > 0 {
+		results["rule_packs"] = convertRulePacks(result.RulePacks)
+	}
+
+	if result.Profile != nil {
+		results["blast_radius"] = convertCapabilityProfile(result.Profile, result.RiskScore)
+	}
+
+	if result.Auth != nil {
+		auth := map[string]interface{}{
+			"required":         result.Auth.Required,
+			"credentials_sent": result.Auth.CredentialsSent,
+		}
+		if result.Auth.Required {
+			auth["status_code"] = result.Auth.StatusCode
+			auth["challenge"] = result.Auth.Challenge
+			auth["resource_metadata"] = result.Auth.ResourceMetadata
+		}
+		results["auth"] = auth
+	}
+
+	tools := make([]interface{}, len(result.Tools))
+	for i, tool := range result.Tools {
+		tools[i] = map[string]interface{}{
+			"name":        tool.Name,
+			"description": tool.Description,
+		}
+	}
+	results["tools"] = tools
+
+	if len(result.Resources) > 0 {
+		resources := make([]interface{}, len(result.Resources))
+		for i, resource := range result.Resources {
+			resources[i] = map[string]interface{}{
+				"uri":  resource.URI,
+				"name": resource.Name,
+			}
+		}
+		results["resources"] = resources
+	}
+
+	if len(result.Prompts) > 0 {
+		prompts := make([]interface{}, len(result.Prompts))
+		for i, prompt := range result.Prompts {
+			prompts[i] = map[string]interface{}{
+				"name":        prompt.Name,
+				"description": prompt.Description,
+			}
+		}
+		results["prompts"] = prompts
+	}
+
+	if len(result.Errors) > 0 {
+		results["errors"] = result.Errors
+	}
+
+	severityCounts := make(map[Severity]int)
+	findings := make([]interface{}, len(result.Findings))
+	for i, finding := range result.Findings {
+		findings[i] = map[string]interface{}{
+			"rule_id":     finding.RuleID,
+			"name":        finding.Name,
+			"category":    finding.Category,
+			"severity":    finding.Severity,
+			"description": finding.Description,
+			"evidence":    finding.Evidence,
+			"remediation": finding.Remediation,
+		}
+		severity, _ := ParseSeverity(finding.Severity)
+		severityCounts[severity]++
+	}
+	if len(findings) > 0 {
+		results["security_findings"] = findings
+	}
+	results["_summary"] = map[string]interface{}{
+		"total_findings":  len(result.Findings),
+		"severity_counts": severityCounts,
+	}
+
+	return results
+}
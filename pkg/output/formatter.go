@@ -12,6 +12,8 @@ func NewFormatter(format Format) (Formatter, error) {
 		return NewPrettyFormatter(), nil
 	case FormatJSON:
 		return NewJSONFormatter(true), nil
+	case FormatHTML:
+		return NewHTMLFormatter(), nil
 	case FormatYAML:
 		// TODO: Implement YAML formatter
 		return nil, fmt.Errorf("YAML formatter not yet implemented")
@@ -30,6 +32,8 @@ func ParseFormat(s string) (Format, error) {
 		return FormatPretty, nil
 	case "json":
 		return FormatJSON, nil
+	case "html":
+		return FormatHTML, nil
 	case "yaml":
 		return FormatYAML, nil
 	case "markdown", "md":
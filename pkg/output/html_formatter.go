@@ -0,0 +1,222 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"sort"
+)
+
+// HTMLFormatter formats output as a standalone HTML report.
+type HTMLFormatter struct {
+	tmpl *template.Template
+}
+
+// NewHTMLFormatter creates a new HTML formatter.
+func NewHTMLFormatter() *HTMLFormatter {
+	return &HTMLFormatter{
+		tmpl: template.Must(template.New("report").Parse(htmlReportTemplate)),
+	}
+}
+
+// htmlFinding is a single row of the findings table.
+type htmlFinding struct {
+	Severity    string
+	Title       string
+	Location    string
+	Description string
+	HAREntry    string
+}
+
+// htmlReport is the data rendered by the report template.
+type htmlReport struct {
+	Module     string
+	Target     string
+	Timestamp  string
+	Duration   string
+	Summary    *Summary
+	Severities []Severity
+	Findings   []htmlFinding
+	HARFile    string
+	Errors     []Error
+	Results    string
+}
+
+// Format formats the output as HTML.
+func (f *HTMLFormatter) Format(output StandardOutput, options FormatterOptions) (string, error) {
+	report := htmlReport{
+		Module:     output.Module,
+		Target:     output.Target,
+		Timestamp:  output.Timestamp.Format("2006-01-02 15:04:05 MST"),
+		Duration:   output.Duration.String(),
+		Summary:    output.Summary,
+		Severities: []Severity{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow, SeverityInfo},
+		Errors:     output.Errors,
+	}
+
+	if harFile, ok := output.Results["har_file"].(string); ok {
+		report.HARFile = harFile
+	}
+
+	report.Findings = collectHTMLFindings(output.Results, nil)
+	if len(options.Filters) > 0 {
+		filtered := report.Findings[:0]
+		for _, finding := range report.Findings {
+			sev, _ := ParseSeverity(finding.Severity)
+			item := AnalysisItem{Name: finding.Title, Severity: sev}
+			include := true
+			for _, filter := range options.Filters {
+				if !filter(item) {
+					include = false
+					break
+				}
+			}
+			if include {
+				filtered = append(filtered, finding)
+			}
+		}
+		report.Findings = filtered
+	}
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		a, _ := ParseSeverity(report.Findings[i].Severity)
+		b, _ := ParseSeverity(report.Findings[j].Severity)
+		return CompareSeverity(a, b) > 0
+	})
+
+	results, err := json.MarshalIndent(output.Results, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode results: %w", err)
+	}
+	report.Results = string(results)
+
+	var buf bytes.Buffer
+	if err := f.tmpl.Execute(&buf, report); err != nil {
+		return "", fmt.Errorf("failed to render HTML report: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// collectHTMLFindings walks the results and gathers every item that carries
+// a severity. Nested items of a finding are not collected separately.
+func collectHTMLFindings(data interface{}, findings []htmlFinding) []htmlFinding {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		if finding, ok := htmlFindingFromMap(v); ok {
+			return append(findings, finding)
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			findings = collectHTMLFindings(v[k], findings)
+		}
+	case []interface{}:
+		for _, item := range v {
+			findings = collectHTMLFindings(item, findings)
+		}
+	case map[int][]interface{}:
+		statuses := make([]int, 0, len(v))
+		for status := range v {
+			statuses = append(statuses, status)
+		}
+		sort.Ints(statuses)
+		for _, status := range statuses {
+			findings = collectHTMLFindings(v[status], findings)
+		}
+	}
+	return findings
+}
+
+// htmlFindingFromMap builds a table row from a result item with a severity.
+func htmlFindingFromMap(m map[string]interface{}) (htmlFinding, bool) {
+	severity, ok := m["severity"].(string)
+	if !ok || severity == "" {
+		return htmlFinding{}, false
+	}
+
+	finding := htmlFinding{Severity: severity}
+	if sev, err := ParseSeverity(severity); err == nil {
+		finding.Severity = string(sev)
+	}
+	for _, key := range []string{"name", "security_risk", "type"} {
+		if title, ok := m[key].(string); ok && title != "" {
+			finding.Title = title
+			break
+		}
+	}
+	if finding.Title == "" {
+		return htmlFinding{}, false
+	}
+
+	for _, key := range []string{"location", "url", "path", "file_path"} {
+		if location, ok := m[key].(string); ok && location != "" {
+			finding.Location = location
+			break
+		}
+	}
+	finding.Description, _ = m["description"].(string)
+	finding.HAREntry, _ = m["har_entry"].(string)
+
+	return finding, true
+}
+
+const htmlReportTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Strigoi report - {{.Module}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #f0f0f0; }
+.critical { color: #fff; background: #8b0000; }
+.high { color: #fff; background: #d9534f; }
+.medium { background: #f0ad4e; }
+.low { background: #5bc0de; }
+.info { background: #e8e8e8; }
+pre { background: #f7f7f7; padding: 1em; overflow-x: auto; }
+</style>
+</head>
+<body>
+<h1>{{.Module}}</h1>
+<p>Target: {{.Target}}<br>Started: {{.Timestamp}}<br>Duration: {{.Duration}}</p>
+{{- with .Summary}}
+<h2>Summary</h2>
+<p>Status: {{.Status}}<br>Total findings: {{.TotalFindings}}</p>
+{{- end}}
+{{- if .Summary}}{{if .Summary.SeverityCounts}}
+<table>
+<tr><th>Severity</th><th>Count</th></tr>
+{{- range $sev := .Severities}}{{with index $.Summary.SeverityCounts $sev}}
+<tr><td class="{{$sev}}">{{$sev}}</td><td>{{.}}</td></tr>
+{{- end}}{{end}}
+</table>
+{{- end}}{{end}}
+<h2>Findings</h2>
+{{- if .Findings}}
+<table>
+<tr><th>Severity</th><th>Finding</th><th>Location</th><th>Description</th><th>Evidence</th></tr>
+{{- range .Findings}}
+<tr><td class="{{.Severity}}">{{.Severity}}</td><td>{{.Title}}</td><td>{{.Location}}</td><td>{{.Description}}</td><td>{{if .HAREntry}}{{if $.HARFile}}{{$.HARFile}}#{{end}}{{.HAREntry}}{{end}}</td></tr>
+{{- end}}
+</table>
+{{- else}}
+<p>No findings.</p>
+{{- end}}
+{{- if .Errors}}
+<h2>Errors</h2>
+<ul>
+{{- range .Errors}}
+<li>{{.Phase}}: {{.Message}}{{if .Details}} ({{.Details}}){{end}}</li>
+{{- end}}
+</ul>
+{{- end}}
+<h2>Results</h2>
+<pre>{{.Results}}</pre>
+</body>
+</html>
+`
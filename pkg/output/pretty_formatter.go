@@ -2,6 +2,7 @@ package output
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -158,6 +159,14 @@ func (f *PrettyFormatter) formatResultCategory(sb *strings.Builder, category str
 		}
 	}
 
+	// Rule packs show version and checksum so results can be reproduced
+	if category == "rule_packs" {
+		if packs, ok := data.([]interface{}); ok {
+			f.formatRulePacks(sb, packs, cs, indent+1)
+			return
+		}
+	}
+
 	// Special handling for summary stats
 	if category == "summary_stats" {
 		if stats, ok := data.(map[string]interface{}); ok {
@@ -198,6 +207,22 @@ func (f *PrettyFormatter) formatResultCategory(sb *strings.Builder, category str
 		}
 	}
 
+	// Traced source-to-sink flows (probe/east)
+	if category == "data_flows" {
+		if flows, ok := data.([]interface{}); ok {
+			f.formatDataFlows(sb, flows, cs, verbosity, indent+1)
+			return
+		}
+	}
+
+	// Personal data sent to each LLM provider (probe/east, probe/center)
+	if category == "pii_by_provider" {
+		if counts, ok := data.(map[string]map[string]int); ok {
+			f.formatPIIByProvider(sb, counts, cs, indent+1)
+			return
+		}
+	}
+
 	// Special handling for external services
 	if category == "external_services" {
 		if services, ok := data.([]interface{}); ok {
@@ -788,6 +813,9 @@ func (f *PrettyFormatter) formatEndpointsByStatus(sb *strings.Builder, endpoints
 					sb.WriteString(cs.Warning.Sprint("⚠ "))
 				}
 				sb.WriteString(cs.Warning.Sprint(risk))
+				if harEntry, ok := epMap["har_entry"].(string); ok && harEntry != "" {
+					sb.WriteString(cs.Dim.Sprintf(" [HAR %s]", harEntry))
+				}
 			}
 
 			sb.WriteString("\n")
@@ -846,6 +874,9 @@ func (f *PrettyFormatter) formatMCPTools(sb *strings.Builder, tools []interface{
 		status, _ := toolMap["status"].(string)
 		configPath, _ := toolMap["config_path"].(string)
 		execPath, _ := toolMap["executable_path"].(string)
+		client, _ := toolMap["client"].(string)
+		commandLine, _ := toolMap["command_line"].(string)
+		serverURL, _ := toolMap["url"].(string)
 
 		// Format the tool entry
 		sb.WriteString(Indent(indent))
@@ -882,7 +913,23 @@ func (f *PrettyFormatter) formatMCPTools(sb *strings.Builder, tools []interface{
 				sb.WriteString(Indent(indent + 1))
 				sb.WriteString(cs.Dim.Sprint("Config: "))
 				sb.WriteString(cs.Dim.Sprint(configPath))
+				if client != "" {
+					sb.WriteString(cs.Dim.Sprintf(" (%s)", client))
+				}
 				sb.WriteString("\n")
+
+				// Launch details of a client configuration entry
+				if serverURL != "" {
+					sb.WriteString(Indent(indent + 1))
+					sb.WriteString(cs.Dim.Sprint("URL: "))
+					sb.WriteString(serverURL)
+					sb.WriteString("\n")
+				} else if commandLine != "" {
+					sb.WriteString(Indent(indent + 1))
+					sb.WriteString(cs.Dim.Sprint("Command: "))
+					sb.WriteString(commandLine)
+					sb.WriteString("\n")
+				}
 			} else if execPath != "" {
 				sb.WriteString(Indent(indent + 1))
 				sb.WriteString(cs.Dim.Sprint("Path: "))
@@ -890,6 +937,31 @@ func (f *PrettyFormatter) formatMCPTools(sb *strings.Builder, tools []interface{
 				sb.WriteString("\n")
 			}
 
+			// Blast radius
+			if profile, ok := toolMap["capability_profile"].(map[string]interface{}); ok {
+				score, _ := profile["risk_score"].(int)
+				scoreColor := cs.Success
+				if score >= 70 {
+					scoreColor = cs.Error
+				} else if score >= 40 {
+					scoreColor = cs.Warning
+				}
+				sb.WriteString(Indent(indent + 1))
+				sb.WriteString(cs.Dim.Sprint("Blast radius: "))
+				sb.WriteString(scoreColor.Sprintf("%d/100", score))
+				if trifecta, _ := profile["lethal_trifecta"].(bool); trifecta {
+					sb.WriteString(cs.Error.Sprint(" (lethal trifecta)"))
+				}
+				sb.WriteString("\n")
+
+				if factors, ok := profile["factors"].([]string); ok && len(factors) > 0 && verbosity >= VerbosityVerbose {
+					for _, factor := range factors {
+						sb.WriteString(Indent(indent + 2))
+						sb.WriteString(cs.Dim.Sprint("- " + factor))
+						sb.WriteString("\n")
+					}
+				}
+			}
 			// Security risks
 			if risks, ok := toolMap["security_risks"].([]interface{}); ok && len(risks) > 0 {
 				sb.WriteString(Indent(indent + 1))
@@ -920,6 +992,28 @@ func (f *PrettyFormatter) formatMCPTools(sb *strings.Builder, tools []interface{
 	}
 }
 
+// formatRulePacks lists the rule packs a scan used.
+func (f *PrettyFormatter) formatRulePacks(sb *strings.Builder, packs []interface{}, cs *ColorScheme, indent int) {
+	for _, p := range packs {
+		pack, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := pack["name"].(string)
+		version, _ := pack["version"].(string)
+		checksum, _ := pack["checksum"].(string)
+		rules, _ := pack["rules"].(int)
+
+		sb.WriteString(Indent(indent))
+		sb.WriteString("• ")
+		sb.WriteString(cs.Label.Sprint(name))
+		sb.WriteString(" ")
+		sb.WriteString(version)
+		sb.WriteString(cs.Dim.Sprintf(" (%d rules, %s)", rules, checksum))
+		sb.WriteString("\n")
+	}
+}
+
 // formatEthicalDiscovery formats the ethical discovery probe results.
 func (f *PrettyFormatter) formatEthicalDiscovery(sb *strings.Builder, data interface{}, cs *ColorScheme, indent int) {
 	discovery, ok := data.(map[string]interface{})
@@ -1211,6 +1305,9 @@ func (f *PrettyFormatter) formatAIServices(sb *strings.Builder, services map[str
 					if name, ok := finding["name"].(string); ok {
 						sb.WriteString(name)
 					}
+					if harEntry, ok := finding["har_entry"].(string); ok && harEntry != "" {
+						sb.WriteString(cs.Dim.Sprintf(" [HAR %s]", harEntry))
+					}
 
 					if verbosity >= VerbosityVerbose {
 						if desc, ok := finding["description"].(string); ok {
@@ -1315,6 +1412,14 @@ func (f *PrettyFormatter) formatDataFlowFindings(sb *strings.Builder, findings [
 						sb.WriteString("\n")
 					}
 
+					// Regulations
+					if regulations, ok := fMap["regulations"].([]string); ok && len(regulations) > 0 {
+						sb.WriteString(Indent(indent + 1))
+						sb.WriteString(cs.Label.Sprint("Regulations: "))
+						sb.WriteString(cs.Warning.Sprint(strings.Join(regulations, ", ")))
+						sb.WriteString("\n")
+					}
+
 					// Impact
 					if impact, ok := fMap["impact"].(string); ok && impact != "" {
 						sb.WriteString(Indent(indent + 1))
@@ -1338,6 +1443,79 @@ func (f *PrettyFormatter) formatDataFlowFindings(sb *strings.Builder, findings [
 	}
 }
 
+// formatDataFlows formats traced data flows as source → destination, with
+// their file:line hops in verbose mode.
+func (f *PrettyFormatter) formatDataFlows(sb *strings.Builder, flows []interface{}, cs *ColorScheme, verbosity VerbosityLevel, indent int) {
+	for _, fl := range flows {
+		flow, ok := fl.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		id, _ := flow["id"].(string)
+		source, _ := flow["source"].(string)
+		destination, _ := flow["destination"].(string)
+
+		sb.WriteString(Indent(indent))
+		sb.WriteString("• ")
+		sb.WriteString(cs.Dim.Sprintf("%s ", id))
+		sb.WriteString(cs.Label.Sprint(source))
+		sb.WriteString(" → ")
+		sb.WriteString(cs.Warning.Sprint(destination))
+		if protection, ok := flow["protection"].([]string); ok && len(protection) > 0 && protection[0] != "none" {
+			sb.WriteString(cs.Success.Sprintf(" (%s)", strings.Join(protection, ", ")))
+		}
+		sb.WriteString("\n")
+
+		hops, _ := flow["hops"].([]interface{})
+		if verbosity < VerbosityVerbose {
+			// Just the sink location
+			if len(hops) > 0 {
+				hops = hops[len(hops)-1:]
+			}
+		}
+		for _, h := range hops {
+			hop, ok := h.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			file, _ := hop["file"].(string)
+			line, _ := hop["line"].(int)
+			kind, _ := hop["kind"].(string)
+			detail, _ := hop["detail"].(string)
+			sb.WriteString(Indent(indent + 1))
+			sb.WriteString(cs.Dim.Sprintf("%-6s %s:%d ", kind, file, line))
+			sb.WriteString(detail)
+			sb.WriteString("\n")
+		}
+	}
+}
+
+// formatPIIByProvider lists PII counts per destination provider.
+func (f *PrettyFormatter) formatPIIByProvider(sb *strings.Builder, counts map[string]map[string]int, cs *ColorScheme, indent int) {
+	providers := make([]string, 0, len(counts))
+	for provider := range counts {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+
+	for _, provider := range providers {
+		types := make([]string, 0, len(counts[provider]))
+		for piiType := range counts[provider] {
+			types = append(types, piiType)
+		}
+		sort.Strings(types)
+
+		parts := make([]string, len(types))
+		for i, piiType := range types {
+			parts[i] = fmt.Sprintf("%s %d", piiType, counts[provider][piiType])
+		}
+		sb.WriteString(Indent(indent))
+		sb.WriteString(cs.Label.Sprintf("%s: ", provider))
+		sb.WriteString(cs.Warning.Sprint(strings.Join(parts, ", ")))
+		sb.WriteString("\n")
+	}
+}
+
 // formatExternalServices formats external service inventory.
 func (f *PrettyFormatter) formatExternalServices(sb *strings.Builder, services []interface{}, cs *ColorScheme, _ VerbosityLevel, indent int) {
 	if len(services) == 0 {
@@ -1430,6 +1608,27 @@ func formatFindingType(findingType, category string) string {
 		"api_endpoint": {
 			"default": "External API Endpoint",
 		},
+		"data_flow": {
+			"credential_to_llm":     "Credential Sent to LLM",
+			"credential_to_http":    "Credential Sent in HTTP Request",
+			"credential_to_log":     "Credential Logged",
+			"user_input_to_llm":     "Request Input Sent to LLM",
+			"user_input_to_http":    "Request Input Forwarded over HTTP",
+			"user_input_to_log":     "Request Input Logged",
+			"file_contents_to_llm":  "File Contents Sent to LLM",
+			"file_contents_to_http": "File Contents Sent over HTTP",
+			"default":               "Data Flow",
+		},
+		"pii_exposure": {
+			"email":       "Email Address in Prompt",
+			"phone":       "Phone Number in Prompt",
+			"national_id": "National ID in Prompt",
+			"iban":        "IBAN in Prompt",
+			"credit_card": "Card Number in Prompt",
+			"health":      "Health Information in Prompt",
+			"address":     "Postal Address in Prompt",
+			"default":     "Personal Data in Prompt",
+		},
 	}
 
 	if categoryMap, ok := typeMap[findingType]; ok {
@@ -1514,6 +1713,11 @@ func (f *PrettyFormatter) formatAuthVulnerabilities(sb *strings.Builder, vulns [
 						sb.WriteString("\n")
 					}
 
+					if harEntry, ok := vuln["har_entry"].(string); ok && harEntry != "" {
+						sb.WriteString(Indent(indent + 2))
+						sb.WriteString(cs.Dim.Sprintf("HAR: %s\n", harEntry))
+					}
+
 					if verbosity >= VerbosityVerbose {
 						if remediation, ok := vuln["remediation"].(string); ok {
 							sb.WriteString(Indent(indent + 2))
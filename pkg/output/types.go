@@ -10,6 +10,7 @@ type Format string
 const (
 	FormatPretty   Format = "pretty"
 	FormatJSON     Format = "json"
+	FormatHTML     Format = "html"
 	FormatYAML     Format = "yaml"
 	FormatMarkdown Format = "markdown"
 )
@@ -3,9 +3,12 @@ package security
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -23,7 +26,9 @@ type CommandSpec struct {
 	RequiresPrivs bool                 `json:"requires_privs"`
 	Timeout       time.Duration        `json:"timeout"`
 	MaxOutputSize int64                `json:"max_output_size"`
-	Validator     func([]string) error `json:"-"` // Custom validation function
+	Env           []string             `json:"-"`             // Extra KEY=value pairs for the child environment
+	Dir           string               `json:"dir,omitempty"` // Working directory (default: fresh temp dir)
+	Validator     func([]string) error `json:"-"`             // Custom validation function
 }
 
 // ArgumentSpec defines validation for command arguments.
@@ -206,6 +211,146 @@ func (se *SecureExecutor) Execute(ctx context.Context, command string, args ...s
 	return result, nil
 }
 
+// InteractiveProcess is a long-lived child started by StartInteractive.
+// Stdout is capped at the command's MaxOutputSize.
+type InteractiveProcess struct {
+	Stdin  io.WriteCloser
+	Stdout io.Reader
+
+	cmd     *exec.Cmd
+	cancel  context.CancelFunc
+	stderr  *limitedBuffer
+	tempDir string
+}
+
+// sandboxEnvKeys are the only parent environment variables passed to
+// interactive children; everything else must be granted through the spec.
+var sandboxEnvKeys = []string{"PATH", "HOME", "LANG", "TMPDIR"}
+
+// StartInteractive validates a command like Execute but starts it with
+// piped stdin and stdout for protocols that need a conversation, such as
+// MCP over stdio. The child gets a minimal environment and runs in an
+// empty temporary directory unless the spec names one; it is killed when
+// ctx is done or the spec timeout elapses.
+func (se *SecureExecutor) StartInteractive(ctx context.Context, command string, args ...string) (*InteractiveProcess, error) {
+	spec, exists := se.allowedCommands[command]
+	if !exists {
+		return nil, fmt.Errorf("command not allowed: %s", command)
+	}
+
+	if err := se.validateArguments(spec, args); err != nil {
+		return nil, fmt.Errorf("argument validation failed: %w", err)
+	}
+
+	execCtx, cancel := context.WithTimeout(ctx, spec.Timeout)
+
+	// gosec G204: the path and arguments are validated exactly as in Execute
+	cmd := exec.CommandContext(execCtx, spec.Path, args...) // #nosec G204
+	cmd.WaitDelay = time.Second
+
+	for _, key := range sandboxEnvKeys {
+		if value, ok := os.LookupEnv(key); ok {
+			cmd.Env = append(cmd.Env, key+"="+value)
+		}
+	}
+	cmd.Env = append(cmd.Env, spec.Env...)
+
+	process := &InteractiveProcess{
+		cmd:    cmd,
+		cancel: cancel,
+		stderr: &limitedBuffer{limit: 64 * 1024},
+	}
+
+	cmd.Dir = spec.Dir
+	if cmd.Dir == "" {
+		dir, err := os.MkdirTemp("", "strigoi-sandbox-")
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to create sandbox directory: %w", err)
+		}
+		cmd.Dir = dir
+		process.tempDir = dir
+	}
+	cmd.Stderr = process.stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		process.cleanup()
+		return nil, fmt.Errorf("failed to open stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		process.cleanup()
+		return nil, fmt.Errorf("failed to open stdout: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		process.cleanup()
+		return nil, fmt.Errorf("failed to start %s: %w", command, err)
+	}
+
+	process.Stdin = stdin
+	process.Stdout = io.LimitReader(stdout, spec.MaxOutputSize)
+	return process, nil
+}
+
+// Stderr returns what the child has written to stderr so far (truncated).
+func (p *InteractiveProcess) Stderr() string {
+	return p.stderr.String()
+}
+
+// Close closes stdin, gives the child a moment to exit on its own and then
+// kills it. The returned error is the child's exit status, if any.
+func (p *InteractiveProcess) Close() error {
+	_ = p.Stdin.Close()
+
+	done := make(chan error, 1)
+	go func() { done <- p.cmd.Wait() }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-time.After(2 * time.Second):
+		p.cancel()
+		err = <-done
+	}
+
+	p.cleanup()
+	return err
+}
+
+func (p *InteractiveProcess) cleanup() {
+	p.cancel()
+	if p.tempDir != "" {
+		_ = os.RemoveAll(p.tempDir)
+	}
+}
+
+// limitedBuffer keeps the first limit bytes written and discards the rest.
+type limitedBuffer struct {
+	mu    sync.Mutex
+	buf   []byte
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if room := b.limit - len(b.buf); room > 0 {
+		if len(p) < room {
+			room = len(p)
+		}
+		b.buf = append(b.buf, p[:room]...)
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.buf)
+}
+
 // validateArguments validates command arguments against the spec.
 func (se *SecureExecutor) validateArguments(spec *CommandSpec, args []string) error {
 	// Check for shell metacharacters in all arguments
@@ -0,0 +1,378 @@
+package security
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MCPCapabilityProfile estimates what an MCP server can touch: the blast
+// radius of a compromised or manipulated server.
+type MCPCapabilityProfile struct {
+	FilesystemRoots []string `json:"filesystem_roots,omitempty"`
+	Databases       []string `json:"databases,omitempty"` // scheme://host or file, credentials removed
+	ExecTools       []string `json:"exec_tools,omitempty"`
+	EgressTargets   []string `json:"egress_targets,omitempty"`
+	RunsAs          string   `json:"runs_as,omitempty"`
+	Privileged      bool     `json:"privileged"`
+
+	// The three legs of the "lethal trifecta"
+	PrivateData      bool `json:"private_data"`
+	UntrustedContent bool `json:"untrusted_content"`
+	NetworkEgress    bool `json:"network_egress"`
+
+	// Factors explains each conclusion, e.g. "fetch tool reads web pages"
+	Factors []string `json:"factors,omitempty"`
+}
+
+// LethalTrifecta reports whether the server alone combines private data,
+// untrusted content and a way to send data out.
+func (p *MCPCapabilityProfile) LethalTrifecta() bool {
+	return p.PrivateData && p.UntrustedContent && p.NetworkEgress
+}
+
+// mcpProfileInput is what is known about a server when profiling it.
+type mcpProfileInput struct {
+	Command   string
+	Args      []string
+	Env       map[string]string // raw values; never copied into the profile
+	URL       string
+	Transport string
+	User      string
+	Tools     []MCPToolDefinition
+}
+
+// mcpServerTrait maps well-known server packages and tool names to the
+// capabilities they imply.
+type mcpServerTrait struct {
+	pattern   *regexp.Regexp
+	reason    string
+	private   bool
+	untrusted bool
+	egress    bool
+	exec      bool
+}
+
+var mcpPackageTraits = []mcpServerTrait{
+	{pattern: regexp.MustCompile(`(?i)(fetch|puppeteer|playwright|browser|brave-search|tavily|exa-mcp|firecrawl|web-?search)`), reason: "browses or fetches web content", untrusted: true, egress: true},
+	{pattern: regexp.MustCompile(`(?i)(github|gitlab|jira|linear|atlassian)`), reason: "reads and writes repositories or issues", private: true, untrusted: true, egress: true},
+	{pattern: regexp.MustCompile(`(?i)(slack|gmail|email|outlook|discord|teams|imap)`), reason: "reads and sends messages", private: true, untrusted: true, egress: true},
+	{pattern: regexp.MustCompile(`(?i)(gdrive|google-drive|notion|confluence|dropbox|onedrive)`), reason: "accesses cloud documents", private: true, egress: true},
+	{pattern: regexp.MustCompile(`(?i)(server-filesystem|mcp-filesystem|server-memory)`), reason: "accesses local files or memory", private: true},
+	{pattern: regexp.MustCompile(`(?i)(postgres|mysql|sqlite|mongo|redis|neo4j|duckdb|supabase)`), reason: "queries a database", private: true},
+	{pattern: regexp.MustCompile(`(?i)(desktop-commander|server-shell|mcp-shell|terminal|ssh-mcp|mcp-ssh)`), reason: "runs commands", exec: true},
+}
+
+var mcpToolTraits = []mcpServerTrait{
+	{pattern: regexp.MustCompile(`(?i)(exec|shell|command|terminal|bash|powershell|eval|run_code|run_script)`), reason: "runs commands", exec: true},
+	{pattern: regexp.MustCompile(`(?i)(fetch|browse|navigate|scrape|web_?search|crawl|read_(email|message|issue)|get_(issue|pull_request|message))`), reason: "ingests external content", untrusted: true},
+	{pattern: regexp.MustCompile(`(?i)(fetch|http|request|upload|webhook|send_|post_|create_(issue|comment|pull_request)|navigate)`), reason: "can send data out", egress: true},
+	{pattern: regexp.MustCompile(`(?i)(read_(file|multiple_files)|list_directory|search_files|query|sql|get_secret|read_resource)`), reason: "reads private data", private: true},
+}
+
+var (
+	databaseURLPattern  = regexp.MustCompile(`(?i)^(postgres(ql)?|mysql|mariadb|mongodb(\+srv)?|rediss?|sqlserver|mssql|clickhouse|neo4j|bolt|snowflake)://`)
+	databaseFilePattern = regexp.MustCompile(`(?i)\.(db|sqlite3?|duckdb)$`)
+	scriptArgPattern    = regexp.MustCompile(`(?i)\.(js|mjs|cjs|ts|py|rb|jar|sh)$`)
+)
+
+// profileMCPServer builds a capability profile from a server's launch
+// configuration, the user it runs as and, when interrogated, its tools.
+func profileMCPServer(in mcpProfileInput) *MCPCapabilityProfile {
+	p := &MCPCapabilityProfile{RunsAs: in.User}
+	apply := func(trait mcpServerTrait, subject string) {
+		factor := fmt.Sprintf("%s %s", subject, trait.reason)
+		if trait.private && !p.PrivateData {
+			p.PrivateData = true
+			p.Factors = append(p.Factors, factor)
+		}
+		if trait.untrusted && !p.UntrustedContent {
+			p.UntrustedContent = true
+			p.Factors = append(p.Factors, factor)
+		}
+		if trait.egress && !p.NetworkEgress {
+			p.NetworkEgress = true
+			p.Factors = append(p.Factors, factor)
+		}
+	}
+
+	command, args := unwrapShellLauncher(in.Command, in.Args)
+	if name := filepath.Base(command); name == "sudo" || name == "doas" {
+		p.RunsAs = "root"
+		command, args = skipSudoOptions(args)
+	}
+	if p.RunsAs == "root" || p.RunsAs == "0" || strings.EqualFold(p.RunsAs, "SYSTEM") || strings.EqualFold(p.RunsAs, "Administrator") {
+		p.Privileged = true
+		p.Factors = append(p.Factors, fmt.Sprintf("runs as %s", p.RunsAs))
+	}
+
+	// Package names and scripts reveal well-known servers
+	launch := strings.Join(append([]string{command}, args...), " ")
+	for _, trait := range mcpPackageTraits {
+		if match := trait.pattern.FindString(launch); match != "" {
+			apply(trait, match+" server")
+			if trait.exec {
+				p.ExecTools = appendUnique(p.ExecTools, match)
+			}
+		}
+	}
+	switch filepath.Base(command) {
+	case "bash", "sh", "zsh", "pwsh", "powershell":
+		p.ExecTools = appendUnique(p.ExecTools, filepath.Base(command))
+	}
+
+	for _, arg := range args {
+		p.classifyValue(arg, true)
+	}
+	keys := make([]string, 0, len(in.Env))
+	for key := range in.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := in.Env[key]
+		if p.classifyValue(value, false) {
+			continue
+		}
+		if sensitiveEnvPattern.MatchString(key) && value != "" {
+			apply(mcpServerTrait{reason: "is an account credential", private: true}, key)
+		}
+	}
+
+	if len(p.FilesystemRoots) > 0 {
+		apply(mcpServerTrait{reason: "exposes files", private: true}, "filesystem root "+p.FilesystemRoots[0])
+	}
+	if len(p.Databases) > 0 {
+		apply(mcpServerTrait{reason: "is reachable", private: true}, "database "+p.Databases[0])
+	}
+	if len(p.EgressTargets) > 0 {
+		apply(mcpServerTrait{reason: "is contacted", egress: true}, p.EgressTargets[0])
+	}
+	if in.URL != "" && (in.Transport == "http" || in.Transport == "sse") {
+		if u, err := url.Parse(in.URL); err == nil && !isLoopbackHost(u.Hostname()) {
+			p.EgressTargets = appendUnique(p.EgressTargets, u.Host)
+			apply(mcpServerTrait{reason: "receives every tool call", egress: true}, "remote server "+u.Host)
+		}
+	}
+
+	for _, tool := range in.Tools {
+		for _, trait := range mcpToolTraits {
+			if trait.pattern.MatchString(tool.Name) {
+				apply(trait, tool.Name+" tool")
+				if trait.exec {
+					p.ExecTools = appendUnique(p.ExecTools, tool.Name)
+				}
+			}
+		}
+	}
+	if len(p.ExecTools) > 0 {
+		p.Factors = append(p.Factors, "can execute commands via "+strings.Join(p.ExecTools, ", "))
+	}
+
+	return p
+}
+
+// classifyValue records filesystem roots, databases and egress targets
+// found in an argument or env value. It reports whether the value was
+// recognized.
+func (p *MCPCapabilityProfile) classifyValue(value string, isArg bool) bool {
+	value = strings.TrimSpace(value)
+	switch {
+	case value == "":
+		return false
+	case databaseURLPattern.MatchString(value):
+		p.Databases = appendUnique(p.Databases, redactConnectionString(value))
+		return true
+	case strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://"):
+		if u, err := url.Parse(value); err == nil && !isLoopbackHost(u.Hostname()) {
+			p.EgressTargets = appendUnique(p.EgressTargets, u.Host)
+		}
+		return true
+	case strings.HasPrefix(value, "file://"):
+		p.FilesystemRoots = appendUnique(p.FilesystemRoots, strings.TrimPrefix(value, "file://"))
+		return true
+	case databaseFilePattern.MatchString(value):
+		p.Databases = appendUnique(p.Databases, "file:"+filepath.Base(value))
+		return true
+	case !isArg && strings.ContainsAny(value, ":,") && strings.HasPrefix(value, "/"):
+		// ALLOWED_DIRECTORIES=/a:/b style lists
+		for _, dir := range strings.FieldsFunc(value, func(r rune) bool { return r == ':' || r == ',' }) {
+			p.FilesystemRoots = appendUnique(p.FilesystemRoots, dir)
+		}
+		return true
+	case isFilesystemPath(value) && !scriptArgPattern.MatchString(value):
+		p.FilesystemRoots = appendUnique(p.FilesystemRoots, value)
+		return true
+	}
+	return false
+}
+
+func isFilesystemPath(value string) bool {
+	return strings.HasPrefix(value, "/") || value == "~" || strings.HasPrefix(value, "~/") ||
+		strings.HasPrefix(value, "$HOME") || (len(value) >= 3 && value[1] == ':' && (value[2] == '\\' || value[2] == '/'))
+}
+
+var broadRootPattern = regexp.MustCompile(`^(/|~|\$HOME|/root|/etc|/(home|Users)(/[^/]+)?|[A-Za-z]:[\\/]?|[A-Za-z]:[\\/]Users[\\/][^\\/]+)/?$`)
+
+// isBroadRoot reports whether a filesystem root exposes a whole system or
+// home directory rather than a project.
+func isBroadRoot(root string) bool {
+	return broadRootPattern.MatchString(root)
+}
+
+// redactConnectionString keeps only the scheme, host and database name.
+func redactConnectionString(value string) string {
+	u, err := url.Parse(value)
+	if err != nil {
+		return strings.SplitN(value, "://", 2)[0] + "://[REDACTED]"
+	}
+	return u.Scheme + "://" + u.Host + u.Path
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, existing := range list {
+		if existing == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
+// RiskScore condenses a profile into 0-100: what the server can do and
+// whether its capabilities combine into an exfiltration path.
+func (p *MCPCapabilityProfile) RiskScore() int {
+	score := 0
+	if len(p.ExecTools) > 0 {
+		score += 30
+	}
+	for _, root := range p.FilesystemRoots {
+		if isBroadRoot(root) {
+			score += 25
+			break
+		}
+	}
+	if len(p.FilesystemRoots) > 0 {
+		score += 10
+	}
+	if len(p.Databases) > 0 {
+		score += 15
+	}
+	if p.Privileged {
+		score += 25
+	}
+	if p.PrivateData {
+		score += 10
+	}
+	if p.UntrustedContent {
+		score += 10
+	}
+	if p.NetworkEgress {
+		score += 10
+	}
+	if p.LethalTrifecta() {
+		score += 25
+	}
+	if score > 100 {
+		score = 100
+	}
+	return score
+}
+
+// blastRadiusFindings turns a server's profile into findings.
+func blastRadiusFindings(server, path string, p *MCPCapabilityProfile) []Finding {
+	var findings []Finding
+	add := func(ruleID, name, severity, description, evidence, remediation string) {
+		findings = append(findings, Finding{
+			ID:          uuid.New().String(),
+			RuleID:      ruleID,
+			Name:        name,
+			Category:    "excessive_capability",
+			Severity:    severity,
+			Description: description,
+			Evidence:    evidence,
+			FilePath:    path,
+			Server:      server,
+			Remediation: remediation,
+			References:  []string{"OWASP-LLM06"},
+			Timestamp:   time.Now(),
+		})
+	}
+
+	if p.LethalTrifecta() {
+		add("MCP-BLAST-001", "Lethal Trifecta", "critical",
+			fmt.Sprintf("Server %q combines access to private data, exposure to untrusted content and a way to send data out; a prompt injection in the content can exfiltrate the data", server),
+			strings.Join(p.Factors, "; "),
+			"Split untrusted-content tools and private-data tools into separate servers and require approval for outbound actions")
+	}
+
+	if p.Privileged {
+		add("MCP-BLAST-002", "MCP Server Runs With Elevated Privileges", "high",
+			fmt.Sprintf("Server %q runs as %s, so any tool it exposes acts with full system access", server, p.RunsAs),
+			"runs_as: "+p.RunsAs,
+			"Run MCP servers as an unprivileged user")
+	}
+
+	var broad []string
+	for _, root := range p.FilesystemRoots {
+		if isBroadRoot(root) {
+			broad = append(broad, root)
+		}
+	}
+	if len(broad) > 0 {
+		add("MCP-BLAST-003", "Broad Filesystem Root", "medium",
+			fmt.Sprintf("Server %q is given access to %s instead of a project directory", server, strings.Join(broad, ", ")),
+			strings.Join(broad, ", "),
+			"Restrict the server to the directories it needs")
+	}
+
+	return findings
+}
+
+// combinedTrifectaFinding flags a client configuration whose servers
+// together form the lethal trifecta although no single server does: the
+// agent can carry data between them.
+func combinedTrifectaFinding(path string, servers map[string]*MCPCapabilityProfile) *Finding {
+	var private, untrusted, egress []string
+	for name, p := range servers {
+		if p.LethalTrifecta() {
+			return nil // already reported for that server
+		}
+		if p.PrivateData {
+			private = append(private, name)
+		}
+		if p.UntrustedContent {
+			untrusted = append(untrusted, name)
+		}
+		if p.NetworkEgress {
+			egress = append(egress, name)
+		}
+	}
+	if len(private) == 0 || len(untrusted) == 0 || len(egress) == 0 {
+		return nil
+	}
+	sort.Strings(private)
+	sort.Strings(untrusted)
+	sort.Strings(egress)
+
+	return &Finding{
+		ID:       uuid.New().String(),
+		RuleID:   "MCP-BLAST-004",
+		Name:     "Lethal Trifecta Across Servers",
+		Category: "excessive_capability",
+		Severity: "high",
+		Description: "The servers configured together give the agent private data, untrusted content and egress, " +
+			"so injected instructions in one server's content can move data out through another",
+		Evidence: fmt.Sprintf("private data: %s; untrusted content: %s; egress: %s",
+			strings.Join(private, ", "), strings.Join(untrusted, ", "), strings.Join(egress, ", ")),
+		FilePath:    path,
+		Remediation: "Do not enable untrusted-content servers in the same client profile as private-data servers, or require approval for every outbound tool call",
+		References:  []string{"OWASP-LLM06"},
+		Timestamp:   time.Now(),
+	}
+}
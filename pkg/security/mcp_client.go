@@ -0,0 +1,398 @@
+package security
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// MCPProtocolVersion is the protocol revision offered during initialize.
+const MCPProtocolVersion = "2025-06-18"
+
+// mcpMaxPages bounds cursor pagination of list results.
+const mcpMaxPages = 20
+
+// mcpMessage is a JSON-RPC 2.0 message exchanged with an MCP server.
+type mcpMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  interface{}     `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *MCPError       `json:"error,omitempty"`
+}
+
+// MCPError is a JSON-RPC error returned by an MCP server.
+type MCPError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *MCPError) Error() string {
+	return fmt.Sprintf("MCP error %d: %s", e.Code, e.Message)
+}
+
+// MCPAuthError reports that an HTTP MCP server refused an unauthenticated
+// request.
+type MCPAuthError struct {
+	StatusCode       int
+	WWWAuthenticate  string
+	ResourceMetadata string
+}
+
+func (e *MCPAuthError) Error() string {
+	return fmt.Sprintf("MCP server requires authentication (HTTP %d)", e.StatusCode)
+}
+
+// mcpTransport carries JSON-RPC messages to a server and returns the
+// response whose id matches the request.
+type mcpTransport interface {
+	RoundTrip(ctx context.Context, msg *mcpMessage) (*mcpMessage, error)
+	Send(ctx context.Context, msg *mcpMessage) error
+	Close() error
+}
+
+// MCPClient speaks the client side of the Model Context Protocol.
+type MCPClient struct {
+	transport mcpTransport
+	nextID    int
+	mu        sync.Mutex
+}
+
+// NewStdioMCPClient talks to a server over a child's stdin and stdout
+// using newline-delimited JSON.
+func NewStdioMCPClient(process *InteractiveProcess) *MCPClient {
+	transport := &stdioMCPTransport{
+		process:  process,
+		messages: make(chan *mcpMessage, 16),
+		done:     make(chan struct{}),
+		closed:   make(chan struct{}),
+	}
+	go transport.readLoop()
+	return &MCPClient{transport: transport}
+}
+
+// NewHTTPMCPClient talks to a server using the Streamable HTTP transport.
+// Headers are added to every request, e.g. an Authorization header.
+func NewHTTPMCPClient(client *http.Client, url string, headers map[string]string) *MCPClient {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &MCPClient{transport: &httpMCPTransport{client: client, url: url, headers: headers}}
+}
+
+// Call sends a request and decodes the result into out when it is non-nil.
+func (c *MCPClient) Call(ctx context.Context, method string, params, out interface{}) error {
+	c.mu.Lock()
+	c.nextID++
+	id := c.nextID
+	c.mu.Unlock()
+
+	response, err := c.transport.RoundTrip(ctx, &mcpMessage{
+		JSONRPC: "2.0",
+		ID:      json.RawMessage(fmt.Sprintf("%d", id)),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return err
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+	if out == nil || len(response.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(response.Result, out); err != nil {
+		return fmt.Errorf("invalid %s result: %w", method, err)
+	}
+	return nil
+}
+
+// Notify sends a notification, which has no response.
+func (c *MCPClient) Notify(ctx context.Context, method string, params interface{}) error {
+	return c.transport.Send(ctx, &mcpMessage{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// Close shuts the transport down, stopping a stdio server.
+func (c *MCPClient) Close() error {
+	return c.transport.Close()
+}
+
+// stdioMCPTransport exchanges newline-delimited messages with a child.
+type stdioMCPTransport struct {
+	process  *InteractiveProcess
+	messages chan *mcpMessage
+	done     chan struct{}
+	closed   chan struct{}
+	writeMu  sync.Mutex
+	readErr  error
+}
+
+func (t *stdioMCPTransport) readLoop() {
+	defer close(t.done)
+
+	scanner := bufio.NewScanner(t.process.Stdout)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] != '{' {
+			continue // servers sometimes log to stdout
+		}
+		var msg mcpMessage
+		if err := json.Unmarshal(line, &msg); err != nil {
+			continue
+		}
+		select {
+		case t.messages <- &msg:
+		case <-t.closed:
+			return
+		}
+	}
+	t.readErr = scanner.Err()
+	if t.readErr == nil {
+		t.readErr = io.EOF
+	}
+}
+
+func (t *stdioMCPTransport) Send(_ context.Context, msg *mcpMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if _, err := t.process.Stdin.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write to MCP server: %w", err)
+	}
+	return nil
+}
+
+func (t *stdioMCPTransport) RoundTrip(ctx context.Context, msg *mcpMessage) (*mcpMessage, error) {
+	if err := t.Send(ctx, msg); err != nil {
+		return nil, err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-t.done:
+			// Drain anything read before the server exited
+			select {
+			case response := <-t.messages:
+				if bytes.Equal(response.ID, msg.ID) && response.Method == "" {
+					return response, nil
+				}
+				continue
+			default:
+			}
+			return nil, fmt.Errorf("MCP server exited: %v: %s", t.readErr, strings.TrimSpace(t.process.Stderr()))
+		case response := <-t.messages:
+			if response.Method != "" {
+				t.answerServerRequest(ctx, response)
+				continue
+			}
+			if bytes.Equal(response.ID, msg.ID) {
+				return response, nil
+			}
+		}
+	}
+}
+
+// answerServerRequest declines requests the server makes of the client
+// (sampling, roots, elicitation) so it does not wait on them forever.
+func (t *stdioMCPTransport) answerServerRequest(ctx context.Context, request *mcpMessage) {
+	if len(request.ID) == 0 {
+		return // notification
+	}
+	reply := &mcpMessage{JSONRPC: "2.0", ID: request.ID}
+	if request.Method == "ping" {
+		reply.Result = json.RawMessage("{}")
+	} else {
+		reply.Error = &MCPError{Code: -32601, Message: "method not supported by client"}
+	}
+	_ = t.Send(ctx, reply)
+}
+
+func (t *stdioMCPTransport) Close() error {
+	close(t.closed)
+	return t.process.Close()
+}
+
+// httpMCPTransport implements the Streamable HTTP transport: each message
+// is POSTed and the response is either JSON or an SSE stream.
+type httpMCPTransport struct {
+	client          *http.Client
+	url             string
+	headers         map[string]string
+	sessionID       string
+	protocolVersion string
+}
+
+func (t *httpMCPTransport) post(ctx context.Context, msg *mcpMessage) (*http.Response, error) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	if t.sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", t.sessionID)
+	}
+	if t.protocolVersion != "" {
+		req.Header.Set("MCP-Protocol-Version", t.protocolVersion)
+	}
+	for name, value := range t.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		_ = resp.Body.Close()
+		challenge := resp.Header.Get("WWW-Authenticate")
+		return nil, &MCPAuthError{
+			StatusCode:       resp.StatusCode,
+			WWWAuthenticate:  challenge,
+			ResourceMetadata: authParam(challenge, "resource_metadata"),
+		}
+	}
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("MCP server returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	if id := resp.Header.Get("Mcp-Session-Id"); id != "" {
+		t.sessionID = id
+	}
+	return resp, nil
+}
+
+func (t *httpMCPTransport) Send(ctx context.Context, msg *mcpMessage) error {
+	resp, err := t.post(ctx, msg)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	return resp.Body.Close()
+}
+
+func (t *httpMCPTransport) RoundTrip(ctx context.Context, msg *mcpMessage) (*mcpMessage, error) {
+	resp, err := t.post(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body := io.LimitReader(resp.Body, 16*1024*1024)
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+
+	var response *mcpMessage
+	if mediaType == "text/event-stream" {
+		response, err = readSSEResponse(body, msg.ID)
+	} else {
+		response = &mcpMessage{}
+		err = json.NewDecoder(body).Decode(response)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid MCP response: %w", err)
+	}
+
+	// Later requests must carry the negotiated revision
+	if msg.Method == "initialize" && response.Error == nil {
+		var result struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		if json.Unmarshal(response.Result, &result) == nil {
+			t.protocolVersion = result.ProtocolVersion
+		}
+	}
+	return response, nil
+}
+
+func (t *httpMCPTransport) Close() error {
+	if t.sessionID == "" {
+		return nil
+	}
+
+	// Politely end the session; servers may not support it
+	req, err := http.NewRequest(http.MethodDelete, t.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Mcp-Session-Id", t.sessionID)
+	for name, value := range t.headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil
+	}
+	return resp.Body.Close()
+}
+
+// readSSEResponse reads server-sent events until the response with the
+// given id arrives.
+func readSSEResponse(body io.Reader, id json.RawMessage) (*mcpMessage, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line != "" {
+			if value, ok := strings.CutPrefix(line, "data:"); ok {
+				data.WriteString(strings.TrimPrefix(value, " "))
+			}
+			continue
+		}
+
+		// A blank line dispatches the event
+		if data.Len() > 0 {
+			var msg mcpMessage
+			if err := json.Unmarshal([]byte(data.String()), &msg); err == nil &&
+				msg.Method == "" && bytes.Equal(msg.ID, id) {
+				return &msg, nil
+			}
+			data.Reset()
+		}
+	}
+	if data.Len() > 0 {
+		var msg mcpMessage
+		if err := json.Unmarshal([]byte(data.String()), &msg); err == nil && bytes.Equal(msg.ID, id) {
+			return &msg, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("event stream ended without a response")
+}
+
+// authParam extracts a parameter from a WWW-Authenticate challenge.
+func authParam(challenge, name string) string {
+	for _, part := range strings.FieldsFunc(challenge, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if value, ok := strings.CutPrefix(part, name+"="); ok {
+			return strings.Trim(value, `"`)
+		}
+	}
+	return ""
+}
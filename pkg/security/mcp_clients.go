@@ -0,0 +1,481 @@
+package security
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MCPClientConfig is a place where an MCP client keeps its server entries.
+type MCPClientConfig struct {
+	Client string `json:"client"`
+	Path   string `json:"path"`
+	// Shared marks general editor settings files, of which only the MCP
+	// entries are examined.
+	Shared bool `json:"shared,omitempty"`
+}
+
+// mcpServerEntry is a server entry normalized across client formats.
+type mcpServerEntry struct {
+	Name      string
+	Transport string // "stdio", "http", "sse"
+	Command   string
+	Args      []string
+	Env       map[string]string
+	URL       string
+	Headers   map[string]string
+}
+
+// mcpClientConfigs lists the configuration files of the MCP clients we
+// know about, for a user home directory and a project working directory.
+func mcpClientConfigs(home, workDir, goos string) []MCPClientConfig {
+	appConfig := filepath.Join(home, ".config")
+	switch goos {
+	case "darwin":
+		appConfig = filepath.Join(home, "Library", "Application Support")
+	case "windows":
+		if appData := os.Getenv("APPDATA"); appData != "" {
+			appConfig = appData
+		} else {
+			appConfig = filepath.Join(home, "AppData", "Roaming")
+		}
+	}
+
+	return []MCPClientConfig{
+		{Client: "Claude Desktop", Path: filepath.Join(appConfig, "Claude", "claude_desktop_config.json")},
+		{Client: "Claude Code", Path: filepath.Join(home, ".claude.json")},
+		{Client: "Claude Code", Path: filepath.Join(home, ".config", "claude-code", "mcp_servers.json")},
+		{Client: "Project", Path: filepath.Join(workDir, ".mcp.json")},
+		{Client: "Cursor", Path: filepath.Join(home, ".cursor", "mcp.json")},
+		{Client: "Cursor", Path: filepath.Join(workDir, ".cursor", "mcp.json")},
+		{Client: "VS Code", Path: filepath.Join(appConfig, "Code", "User", "mcp.json")},
+		{Client: "VS Code", Path: filepath.Join(appConfig, "Code", "User", "settings.json"), Shared: true},
+		{Client: "VS Code", Path: filepath.Join(workDir, ".vscode", "mcp.json")},
+		{Client: "Continue", Path: filepath.Join(home, ".continue", "config.yaml")},
+		{Client: "Continue", Path: filepath.Join(home, ".continue", "config.json")},
+		{Client: "Continue", Path: filepath.Join(workDir, ".continue", "mcpServers")},
+		{Client: "Windsurf", Path: filepath.Join(home, ".codeium", "windsurf", "mcp_config.json")},
+		{Client: "Zed", Path: filepath.Join(home, ".config", "zed", "settings.json"), Shared: true},
+		{Client: "Zed", Path: filepath.Join(workDir, ".zed", "settings.json"), Shared: true},
+	}
+}
+
+// parseMCPClientConfig extracts the server entries of any supported client
+// format: "mcpServers" maps (Claude, Cursor, Windsurf, .mcp.json, Continue
+// YAML), VS Code "servers", Zed "context_servers" and Continue's
+// "experimental.modelContextProtocolServers".
+func parseMCPClientConfig(path string, content []byte) ([]mcpServerEntry, error) {
+	var doc interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		parsed, err := parseYAMLSubset(content)
+		if err != nil {
+			return nil, err
+		}
+		doc = parsed
+	default:
+		if err := json.Unmarshal(stripJSONC(content), &doc); err != nil {
+			return nil, err
+		}
+	}
+
+	top, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("configuration is not an object")
+	}
+
+	var entries []mcpServerEntry
+	entries = append(entries, mcpServerCollection(top["mcpServers"])...)
+	entries = append(entries, mcpServerCollection(top["servers"])...)
+	entries = append(entries, mcpServerCollection(top["context_servers"])...)
+
+	if mcp, ok := top["mcp"].(map[string]interface{}); ok {
+		entries = append(entries, mcpServerCollection(mcp["servers"])...)
+	}
+
+	// Claude Code keeps per-project servers in ~/.claude.json
+	if projects, ok := top["projects"].(map[string]interface{}); ok {
+		for _, project := range projects {
+			if p, ok := project.(map[string]interface{}); ok {
+				entries = append(entries, mcpServerCollection(p["mcpServers"])...)
+			}
+		}
+	}
+
+	if experimental, ok := top["experimental"].(map[string]interface{}); ok {
+		entries = append(entries, mcpServerCollection(experimental["modelContextProtocolServers"])...)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+// mcpServerCollection normalizes a map of named entries or a list of
+// entries carrying a "name".
+func mcpServerCollection(value interface{}) []mcpServerEntry {
+	var entries []mcpServerEntry
+	switch servers := value.(type) {
+	case map[string]interface{}:
+		for name, raw := range servers {
+			if m, ok := raw.(map[string]interface{}); ok {
+				if entry, ok := normalizeMCPServer(name, m); ok {
+					entries = append(entries, entry)
+				}
+			}
+		}
+	case []interface{}:
+		for i, raw := range servers {
+			m, ok := raw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := m["name"].(string)
+			if name == "" {
+				name = fmt.Sprintf("server-%d", i+1)
+			}
+			if entry, ok := normalizeMCPServer(name, m); ok {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries
+}
+
+func normalizeMCPServer(name string, m map[string]interface{}) (mcpServerEntry, bool) {
+	// Continue nests the launch details under "transport"
+	if transport, ok := m["transport"].(map[string]interface{}); ok {
+		m = transport
+	}
+
+	entry := mcpServerEntry{
+		Name:    name,
+		Args:    stringList(m["args"]),
+		Env:     stringMap(m["env"]),
+		Headers: stringMap(m["headers"]),
+	}
+
+	switch command := m["command"].(type) {
+	case string:
+		entry.Command = command
+	case map[string]interface{}:
+		// Older Zed settings: {"command": {"path", "args", "env"}}
+		entry.Command, _ = command["path"].(string)
+		entry.Args = stringList(command["args"])
+		entry.Env = stringMap(command["env"])
+	}
+
+	for _, key := range []string{"url", "serverUrl", "httpUrl"} {
+		if u, ok := m[key].(string); ok && u != "" {
+			entry.URL = u
+			break
+		}
+	}
+
+	if entry.Command == "" && entry.URL == "" {
+		return entry, false
+	}
+
+	kind, _ := m["type"].(string)
+	switch {
+	case entry.URL == "":
+		entry.Transport = "stdio"
+	case strings.EqualFold(kind, "sse") || strings.HasSuffix(strings.TrimRight(entry.URL, "/"), "/sse"):
+		entry.Transport = "sse"
+	default:
+		entry.Transport = "http"
+	}
+	return entry, true
+}
+
+func stringList(value interface{}) []string {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+	list := make([]string, 0, len(items))
+	for _, item := range items {
+		if item != nil {
+			list = append(list, fmt.Sprint(item))
+		}
+	}
+	return list
+}
+
+func stringMap(value interface{}) map[string]string {
+	items, ok := value.(map[string]interface{})
+	if !ok || len(items) == 0 {
+		return nil
+	}
+	m := make(map[string]string, len(items))
+	for key, item := range items {
+		if item != nil {
+			m[key] = fmt.Sprint(item)
+		}
+	}
+	return m
+}
+
+// mcpConfigFiles returns the files config stands for: itself, or the
+// configuration files in it if it is a directory such as
+// .continue/mcpServers.
+func mcpConfigFiles(config MCPClientConfig) []MCPClientConfig {
+	entries, err := os.ReadDir(config.Path)
+	if err != nil {
+		return []MCPClientConfig{config}
+	}
+	var files []MCPClientConfig
+	for _, entry := range entries {
+		if !entry.IsDir() && isConfigFileName(entry.Name()) {
+			file := config
+			file.Path = filepath.Join(config.Path, entry.Name())
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// stripJSONC removes // and /* */ comments and trailing commas, which
+// VS Code and Zed allow in their settings files. String contents are left
+// as they are.
+func stripJSONC(content []byte) []byte {
+	out := make([]byte, 0, len(content))
+	inString := false
+	comma := -1 // position in out of a comma that may be trailing
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case inString:
+			out = append(out, c)
+			if c == '\\' && i+1 < len(content) {
+				i++
+				out = append(out, content[i])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			comma = -1
+			out = append(out, c)
+		case c == '/' && i+1 < len(content) && content[i+1] == '/':
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+			i--
+		case c == '/' && i+1 < len(content) && content[i+1] == '*':
+			end := strings.Index(string(content[i+2:]), "*/")
+			if end < 0 {
+				i = len(content)
+			} else {
+				i += end + 3
+			}
+		default:
+			switch c {
+			case '}', ']':
+				if comma >= 0 {
+					out = append(out[:comma], out[comma+1:]...)
+				}
+				comma = -1
+			case ',':
+				comma = len(out)
+			case ' ', '\t', '\r', '\n':
+			default:
+				comma = -1
+			}
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// toMCPTool turns a normalized entry into a discovered server. Env values
+// are redacted; only the variable names are kept.
+func (entry mcpServerEntry) toMCPTool(ms *MCPScanner, config MCPClientConfig) MCPTool {
+	tool := MCPTool{
+		ID:         uuid.New().String(),
+		Name:       entry.Name,
+		Type:       "server",
+		Status:     "configured",
+		ConfigPath: config.Path,
+		Client:     config.Client,
+		Transport:  entry.Transport,
+		URL:        ms.redactSensitive(entry.URL),
+		Command:    entry.Command,
+		Timestamp:  time.Now(),
+	}
+
+	for _, arg := range entry.Args {
+		tool.Args = append(tool.Args, ms.redactSensitive(arg))
+	}
+	if entry.Command != "" {
+		tool.CommandLine = strings.TrimSpace(entry.Command + " " + strings.Join(tool.Args, " "))
+	}
+	if len(entry.Env) > 0 {
+		tool.Env = make(map[string]string, len(entry.Env))
+		for key := range entry.Env {
+			tool.Env[key] = "[REDACTED]"
+		}
+	}
+
+	// Profile from the raw entry; only redacted values reach the profile
+	tool.CapabilityProfile = profileMCPServer(mcpProfileInput{
+		Command:   entry.Command,
+		Args:      entry.Args,
+		Env:       entry.Env,
+		URL:       entry.URL,
+		Transport: entry.Transport,
+	})
+	tool.RiskScore = tool.CapabilityProfile.RiskScore()
+	return tool
+}
+
+func hasRule(findings []Finding, ruleID string) bool {
+	for _, finding := range findings {
+		if finding.RuleID == ruleID {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	sensitiveEnvPattern = regexp.MustCompile(`(?i)(api[_-]?key|access[_-]?key|secret|token|password|passwd|credential|private[_-]?key|auth)`)
+	envReferencePattern = regexp.MustCompile(`^(\$\{[^}]+\}|\$[A-Za-z_][A-Za-z0-9_]*|\{\{.*\}\}|env:.*)$`)
+)
+
+// launchRisks flags risky ways of starting a configured server.
+func (entry mcpServerEntry) launchRisks(path string) []Finding {
+	var findings []Finding
+	add := func(ruleID, name, severity, description, evidence, remediation string, references ...string) {
+		findings = append(findings, Finding{
+			ID:          uuid.New().String(),
+			RuleID:      ruleID,
+			Name:        name,
+			Category:    "insecure_config",
+			Severity:    severity,
+			Description: description,
+			Evidence:    evidence,
+			FilePath:    path,
+			Server:      entry.Name,
+			Remediation: remediation,
+			References:  references,
+			Timestamp:   time.Now(),
+		})
+	}
+
+	command, args := unwrapShellLauncher(entry.Command, entry.Args)
+
+	if command == "sudo" || command == "doas" {
+		add("MCP-LAUNCH-002", "MCP Server Runs With sudo", "high",
+			fmt.Sprintf("Server %q is launched with root privileges", entry.Name),
+			strings.TrimSpace(entry.Command+" "+strings.Join(entry.Args, " ")),
+			"Run MCP servers as an unprivileged user", "CWE-250")
+		command, args = skipSudoOptions(args)
+	}
+
+	if command == "npx" {
+		if pkg, unpinned := unpinnedNPXPackage(args); unpinned {
+			add("MCP-LAUNCH-001", "Unpinned npx Package", "medium",
+				fmt.Sprintf("Server %q installs and runs %q from npm without a pinned version, so a malicious release runs automatically", entry.Name, pkg),
+				"npx "+strings.Join(args, " "),
+				"Pin the package to an exact version (name@1.2.3) or install it ahead of time", "CWE-829", "CWE-494")
+		}
+	}
+
+	keys := make([]string, 0, len(entry.Env))
+	for key := range entry.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := strings.TrimSpace(entry.Env[key])
+		if !sensitiveEnvPattern.MatchString(key) || len(value) < 8 || envReferencePattern.MatchString(value) {
+			continue
+		}
+		add("MCP-LAUNCH-003", "Secret Inlined in MCP Server Environment", "high",
+			fmt.Sprintf("Server %q has a literal value for %s in its client configuration", entry.Name, key),
+			key+"="+maskSensitiveData(value),
+			"Reference the secret from the environment or a secret manager instead of storing it in the config file", "CWE-798")
+	}
+
+	return findings
+}
+
+// unwrapShellLauncher looks through the "cmd /c" wrapper Windows configs use
+// to start servers, returning the effective command name.
+func unwrapShellLauncher(command string, args []string) (string, []string) {
+	name := strings.ToLower(filepath.Base(command))
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".exe"), ".cmd")
+	if name == "cmd" && len(args) > 1 && strings.EqualFold(args[0], "/c") {
+		return unwrapShellLauncher(args[1], args[2:])
+	}
+	return name, args
+}
+
+// skipSudoOptions returns the command sudo runs and its arguments.
+func skipSudoOptions(args []string) (string, []string) {
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "-u" || args[i] == "-g" || args[i] == "-C":
+			i++ // option with a value
+		case strings.HasPrefix(args[i], "-"):
+		default:
+			return unwrapShellLauncher(args[i], args[i+1:])
+		}
+	}
+	return "", nil
+}
+
+// unpinnedNPXPackage reports the package "npx -y" would install when it is
+// not pinned to an exact version.
+func unpinnedNPXPackage(args []string) (string, bool) {
+	autoInstall := false
+	pkg := ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-y" || arg == "--yes":
+			autoInstall = true
+		case (arg == "-p" || arg == "--package") && i+1 < len(args):
+			i++
+			pkg = args[i]
+		case strings.HasPrefix(arg, "--package="):
+			pkg = strings.TrimPrefix(arg, "--package=")
+		case strings.HasPrefix(arg, "-"):
+		default:
+			if pkg == "" {
+				pkg = arg
+			}
+			i = len(args) // the rest are the package's own arguments
+		}
+	}
+	if !autoInstall || pkg == "" {
+		return pkg, false
+	}
+	return pkg, !isPinnedNPMSpec(pkg)
+}
+
+var exactVersionPattern = regexp.MustCompile(`^v?\d+\.\d+\.\d+([-+][0-9A-Za-z.-]+)?$`)
+
+// isPinnedNPMSpec reports whether a package spec names an exact version.
+// Local paths and tarballs are treated as pinned.
+func isPinnedNPMSpec(spec string) bool {
+	if strings.HasPrefix(spec, ".") || strings.HasPrefix(spec, "/") || strings.HasSuffix(spec, ".tgz") {
+		return true
+	}
+	name := spec
+	if strings.HasPrefix(name, "@") {
+		name = name[1:] // scope marker, not a version separator
+	}
+	at := strings.LastIndex(name, "@")
+	if at < 0 {
+		return false
+	}
+	return exactVersionPattern.MatchString(name[at+1:])
+}
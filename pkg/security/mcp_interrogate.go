@@ -0,0 +1,461 @@
+package security
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/macawi-ai/strigoi/pkg/engagement"
+)
+
+// MCPTarget is a server to interrogate: either a command launched over
+// stdio or a Streamable HTTP endpoint.
+type MCPTarget struct {
+	Name    string            `json:"name"`
+	Command string            `json:"command,omitempty"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"-"`
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"-"`
+	Source  string            `json:"source,omitempty"` // Config file the entry came from
+}
+
+// MCPToolDefinition is a tool advertised by a server in tools/list.
+type MCPToolDefinition struct {
+	Name        string                 `json:"name"`
+	Title       string                 `json:"title,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"inputSchema,omitempty"`
+	Annotations map[string]interface{} `json:"annotations,omitempty"`
+}
+
+// MCPResourceDefinition is a resource advertised in resources/list.
+type MCPResourceDefinition struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// MCPPromptDefinition is a prompt advertised in prompts/list.
+type MCPPromptDefinition struct {
+	Name        string                   `json:"name"`
+	Description string                   `json:"description,omitempty"`
+	Arguments   []map[string]interface{} `json:"arguments,omitempty"`
+}
+
+// MCPAuthInfo records how an HTTP server responded to unauthenticated
+// access.
+type MCPAuthInfo struct {
+	Required         bool   `json:"required"`
+	StatusCode       int    `json:"status_code,omitempty"`
+	Challenge        string `json:"challenge,omitempty"`
+	ResourceMetadata string `json:"resource_metadata,omitempty"`
+	CredentialsSent  bool   `json:"credentials_sent"`
+}
+
+// MCPInterrogation is everything learned by talking to a live server.
+type MCPInterrogation struct {
+	Target          MCPTarget               `json:"target"`
+	Transport       string                  `json:"transport"` // "stdio", "http"
+	ProtocolVersion string                  `json:"protocol_version,omitempty"`
+	ServerName      string                  `json:"server_name,omitempty"`
+	ServerVersion   string                  `json:"server_version,omitempty"`
+	Instructions    string                  `json:"instructions,omitempty"`
+	Capabilities    map[string]interface{}  `json:"capabilities,omitempty"`
+	Auth            *MCPAuthInfo            `json:"auth,omitempty"`
+	Tools           []MCPToolDefinition     `json:"tools,omitempty"`
+	Resources       []MCPResourceDefinition `json:"resources,omitempty"`
+	Prompts         []MCPPromptDefinition   `json:"prompts,omitempty"`
+	Errors          []string                `json:"errors,omitempty"`
+	Findings        []Finding               `json:"findings,omitempty"`
+	Profile         *MCPCapabilityProfile   `json:"capability_profile,omitempty"`
+	RiskScore       int                     `json:"risk_score"`
+	RulePacks       []RulePackInfo          `json:"rule_packs,omitempty"`
+	Timestamp       time.Time               `json:"timestamp"`
+	Duration        time.Duration           `json:"duration"`
+	Stderr          string                  `json:"stderr,omitempty"`
+}
+
+// Location identifies the interrogated server in findings.
+func (mi *MCPInterrogation) Location() string {
+	if mi.Target.URL != "" {
+		return mi.Target.URL
+	}
+	return "mcp://" + mi.Target.Name
+}
+
+// ResolveMCPTarget turns a URL or the name of a server entry in an MCP
+// client configuration into a target. When configPath is empty the known
+// configuration locations are searched.
+func ResolveMCPTarget(ref, configPath string) (*MCPTarget, error) {
+	if strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://") {
+		if _, err := url.Parse(ref); err != nil {
+			return nil, fmt.Errorf("invalid MCP server URL: %w", err)
+		}
+		return &MCPTarget{Name: ref, URL: ref}, nil
+	}
+
+	// The same sources the scanner reads, directories included
+	configs := []MCPClientConfig{{Path: configPath}}
+	if configPath == "" {
+		workDir, _ := os.Getwd()
+		configs = mcpClientConfigs(os.Getenv("HOME"), workDir, runtime.GOOS)
+	}
+	var files []MCPClientConfig
+	for _, config := range configs {
+		files = append(files, mcpConfigFiles(config)...)
+	}
+
+	var searched []string
+	for _, config := range files {
+		searched = append(searched, config.Path)
+		content, err := os.ReadFile(config.Path) // #nosec G304 - user-selected or well-known config path
+		if err != nil {
+			if configPath != "" {
+				return nil, fmt.Errorf("failed to read MCP config: %w", err)
+			}
+			continue
+		}
+
+		servers, err := parseMCPClientConfig(config.Path, content)
+		if err != nil {
+			if configPath != "" {
+				return nil, fmt.Errorf("invalid MCP config %s: %w", config.Path, err)
+			}
+			continue
+		}
+		for _, server := range servers {
+			if server.Name != ref {
+				continue
+			}
+			if server.Transport == "sse" {
+				return nil, fmt.Errorf("MCP server %q uses the legacy SSE transport, which is not supported", ref)
+			}
+			return &MCPTarget{
+				Name:    server.Name,
+				Command: server.Command,
+				Args:    server.Args,
+				Env:     server.Env,
+				URL:     server.URL,
+				Headers: server.Headers,
+				Source:  config.Path,
+			}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no MCP server named %q in %s", ref, strings.Join(searched, ", "))
+}
+
+// Interrogate connects to a live MCP server, performs the initialize
+// handshake and enumerates its tools, resources and prompts. Stdio servers
+// are launched through the scanner's SecureExecutor with their configured
+// arguments pinned. What was learned is scanned by the rule engine and the
+// findings are recorded with the scanner's other risks.
+func (ms *MCPScanner) Interrogate(ctx context.Context, target MCPTarget, timeout time.Duration) (*MCPInterrogation, error) {
+	result := &MCPInterrogation{
+		Target:    target,
+		Timestamp: time.Now(),
+	}
+	defer func() { result.Duration = time.Since(result.Timestamp) }()
+
+	var client *MCPClient
+	var process *InteractiveProcess
+	if target.URL != "" {
+		result.Transport = "http"
+		result.Auth = &MCPAuthInfo{CredentialsSent: hasCredentialHeader(target.Headers)}
+		// Remote servers are held to the engagement scope and audit log
+		httpClient := &http.Client{Timeout: timeout}
+		if scheduler := engagement.Default(); scheduler != nil {
+			httpClient.Transport = scheduler.Transport("probe/mcp", nil)
+		}
+		client = NewHTTPMCPClient(httpClient, target.URL, target.Headers)
+	} else {
+		result.Transport = "stdio"
+		var err error
+		process, err = ms.launchMCPServer(ctx, target, timeout)
+		if err != nil {
+			return nil, err
+		}
+		client = NewStdioMCPClient(process)
+	}
+	defer func() {
+		_ = client.Close()
+		if process != nil {
+			result.Stderr = ms.redactSensitive(strings.TrimSpace(process.Stderr()))
+		}
+	}()
+
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := ms.initializeMCP(callCtx, client, result); err != nil {
+		var authErr *MCPAuthError
+		if !errors.As(err, &authErr) {
+			return nil, fmt.Errorf("initialize failed: %w", err)
+		}
+		result.Auth.Required = true
+		result.Auth.StatusCode = authErr.StatusCode
+		result.Auth.Challenge = authErr.WWWAuthenticate
+		result.Auth.ResourceMetadata = authErr.ResourceMetadata
+	} else {
+		ms.enumerateMCP(callCtx, client, result)
+	}
+
+	result.Profile = profileMCPServer(mcpProfileInput{
+		Command:   target.Command,
+		Args:      target.Args,
+		Env:       target.Env,
+		URL:       target.URL,
+		Transport: result.Transport,
+		Tools:     result.Tools,
+	})
+	result.RiskScore = result.Profile.RiskScore()
+
+	result.RulePacks = ms.rules.Packs()
+	result.Findings = ms.rules.ScanInterrogation(result)
+	result.Findings = append(result.Findings, blastRadiusFindings(target.Name, result.Location(), result.Profile)...)
+	sort.SliceStable(result.Findings, func(i, j int) bool { return result.Findings[i].RuleID < result.Findings[j].RuleID })
+	ms.addFindings(result.Findings)
+
+	ms.logger.WithField("server", result.ServerName).
+		WithField("tools", len(result.Tools)).
+		WithField("findings", len(result.Findings)).
+		Debug("MCP interrogation completed")
+	return result, nil
+}
+
+// launchMCPServer registers the target's exact command line with the
+// executor and starts it.
+func (ms *MCPScanner) launchMCPServer(ctx context.Context, target MCPTarget, timeout time.Duration) (*InteractiveProcess, error) {
+	path, err := exec.LookPath(target.Command)
+	if err != nil {
+		return nil, fmt.Errorf("MCP server command not found: %w", err)
+	}
+
+	spec := &CommandSpec{
+		Path:          path,
+		Timeout:       timeout + 5*time.Second,
+		MaxOutputSize: 16 * 1024 * 1024,
+	}
+	for _, arg := range target.Args {
+		spec.AllowedArgs = append(spec.AllowedArgs, ArgumentSpec{
+			Pattern:     "^" + regexp.QuoteMeta(arg) + "$",
+			Required:    true,
+			Description: "configured server argument",
+		})
+	}
+	for key, value := range target.Env {
+		spec.Env = append(spec.Env, key+"="+value)
+	}
+	sort.Strings(spec.Env)
+
+	name := "mcp:" + target.Name
+	if err := ms.executor.RegisterCommand(name, spec); err != nil {
+		return nil, err
+	}
+	return ms.executor.StartInteractive(ctx, name, target.Args...)
+}
+
+func (ms *MCPScanner) initializeMCP(ctx context.Context, client *MCPClient, result *MCPInterrogation) error {
+	var init struct {
+		ProtocolVersion string                 `json:"protocolVersion"`
+		Capabilities    map[string]interface{} `json:"capabilities"`
+		ServerInfo      struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"serverInfo"`
+		Instructions string `json:"instructions"`
+	}
+
+	err := client.Call(ctx, "initialize", map[string]interface{}{
+		"protocolVersion": MCPProtocolVersion,
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      map[string]string{"name": "strigoi", "version": "1.0"},
+	}, &init)
+	if err != nil {
+		return err
+	}
+
+	result.ProtocolVersion = init.ProtocolVersion
+	result.Capabilities = init.Capabilities
+	result.ServerName = init.ServerInfo.Name
+	result.ServerVersion = init.ServerInfo.Version
+	result.Instructions = init.Instructions
+
+	return client.Notify(ctx, "notifications/initialized", nil)
+}
+
+// enumerateMCP lists whatever the server declared a capability for.
+// Failures are recorded rather than aborting the interrogation.
+func (ms *MCPScanner) enumerateMCP(ctx context.Context, client *MCPClient, result *MCPInterrogation) {
+	if _, ok := result.Capabilities["tools"]; ok {
+		err := listMCP(ctx, client, "tools/list", func(page json.RawMessage) error {
+			var list struct {
+				Tools []MCPToolDefinition `json:"tools"`
+			}
+			err := json.Unmarshal(page, &list)
+			result.Tools = append(result.Tools, list.Tools...)
+			return err
+		})
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("tools/list: %v", err))
+		}
+	}
+
+	if _, ok := result.Capabilities["resources"]; ok {
+		err := listMCP(ctx, client, "resources/list", func(page json.RawMessage) error {
+			var list struct {
+				Resources []MCPResourceDefinition `json:"resources"`
+			}
+			err := json.Unmarshal(page, &list)
+			result.Resources = append(result.Resources, list.Resources...)
+			return err
+		})
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("resources/list: %v", err))
+		}
+	}
+
+	if _, ok := result.Capabilities["prompts"]; ok {
+		err := listMCP(ctx, client, "prompts/list", func(page json.RawMessage) error {
+			var list struct {
+				Prompts []MCPPromptDefinition `json:"prompts"`
+			}
+			err := json.Unmarshal(page, &list)
+			result.Prompts = append(result.Prompts, list.Prompts...)
+			return err
+		})
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("prompts/list: %v", err))
+		}
+	}
+}
+
+// listMCP follows nextCursor through a paginated list method.
+func listMCP(ctx context.Context, client *MCPClient, method string, page func(json.RawMessage) error) error {
+	cursor := ""
+	for i := 0; i < mcpMaxPages; i++ {
+		params := map[string]interface{}{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+
+		var raw json.RawMessage
+		if err := client.Call(ctx, method, params, &raw); err != nil {
+			return err
+		}
+		if err := page(raw); err != nil {
+			return err
+		}
+
+		var next struct {
+			NextCursor string `json:"nextCursor"`
+		}
+		_ = json.Unmarshal(raw, &next)
+		if next.NextCursor == "" || next.NextCursor == cursor {
+			return nil
+		}
+		cursor = next.NextCursor
+	}
+	return fmt.Errorf("more than %d pages", mcpMaxPages)
+}
+
+// hasCredentialHeader reports whether configured headers carry credentials.
+func hasCredentialHeader(headers map[string]string) bool {
+	for name := range headers {
+		lower := strings.ToLower(name)
+		if lower == "authorization" || lower == "cookie" || strings.Contains(lower, "api-key") || strings.Contains(lower, "token") {
+			return true
+		}
+	}
+	return false
+}
+
+// manifest renders the interrogation as line-oriented text for the rule
+// engine: one "key: value" fact per line.
+func (mi *MCPInterrogation) manifest() string {
+	var sb strings.Builder
+	line := func(format string, args ...interface{}) {
+		fmt.Fprintf(&sb, format+"\n", args...)
+	}
+
+	line("transport: %s", mi.Transport)
+	if mi.ProtocolVersion != "" {
+		line("protocol_version: %s", mi.ProtocolVersion)
+	}
+	if mi.ServerName != "" {
+		line("server: %s %s", mi.ServerName, mi.ServerVersion)
+	}
+
+	if mi.Transport == "http" && mi.Auth != nil {
+		switch {
+		case mi.Auth.Required:
+			line("http_auth: required")
+		case mi.Auth.CredentialsSent:
+			line("http_auth: credentials")
+		default:
+			line("http_auth: none")
+		}
+		if u, err := url.Parse(mi.Target.URL); err == nil && u.Scheme == "http" && !isLoopbackHost(u.Hostname()) {
+			line("http_tls: false")
+		}
+	}
+
+	capabilities := make([]string, 0, len(mi.Capabilities))
+	for name, value := range mi.Capabilities {
+		capabilities = append(capabilities, name)
+		if options, ok := value.(map[string]interface{}); ok {
+			for option, enabled := range options {
+				if enabled == true {
+					capabilities = append(capabilities, name+"."+option)
+				}
+			}
+		}
+	}
+	sort.Strings(capabilities)
+	for _, capability := range capabilities {
+		line("capability: %s", capability)
+	}
+
+	for _, tool := range mi.Tools {
+		line("tool: %s - %s", tool.Name, singleLine(tool.Description))
+		if schema, err := json.Marshal(tool.InputSchema); err == nil && tool.InputSchema != nil {
+			line("tool_schema: %s %s", tool.Name, schema)
+		}
+	}
+	for _, resource := range mi.Resources {
+		line("resource: %s - %s", resource.URI, singleLine(resource.Description))
+	}
+	for _, prompt := range mi.Prompts {
+		line("prompt: %s - %s", prompt.Name, singleLine(prompt.Description))
+	}
+	if mi.Instructions != "" {
+		line("instructions: %s", singleLine(mi.Instructions))
+	}
+
+	return sb.String()
+}
+
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
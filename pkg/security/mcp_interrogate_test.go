@@ -0,0 +1,226 @@
+package security
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/macawi-ai/strigoi/pkg/engagement"
+)
+
+// buildFakeMCPServer compiles the bundled fake server in testdata.
+func buildFakeMCPServer(t *testing.T) string {
+	t.Helper()
+
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not available to build the fake MCP server")
+	}
+
+	binary := filepath.Join(t.TempDir(), "fakemcp")
+	build := exec.Command(goTool, "build", "-o", binary, "./testdata/fakemcp")
+	if output, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("failed to build fake MCP server: %v\n%s", err, output)
+	}
+	return binary
+}
+
+func findingRules(findings []Finding) map[string]Finding {
+	rules := make(map[string]Finding)
+	for _, f := range findings {
+		rules[f.RuleID] = f
+	}
+	return rules
+}
+
+func TestInterrogateStdioServer(t *testing.T) {
+	binary := buildFakeMCPServer(t)
+
+	config := filepath.Join(t.TempDir(), "mcp.json")
+	content := `{"mcpServers": {"fake": {"command": "` + binary + `", "args": [], "env": {"FAKE_MCP_VERSION": "9.9.9"}}}}`
+	if err := os.WriteFile(config, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	target, err := ResolveMCPTarget("fake", config)
+	if err != nil {
+		t.Fatalf("ResolveMCPTarget failed: %v", err)
+	}
+
+	// Nothing outside the sandbox allowlist reaches the child
+	t.Setenv("STRIGOI_FAKE_PARENT_SECRET", "hunter2")
+
+	scanner := NewMCPScanner(NewSecureExecutor())
+	result, err := scanner.Interrogate(context.Background(), *target, 10*time.Second)
+	if err != nil {
+		t.Fatalf("Interrogate failed: %v", err)
+	}
+
+	if result.Transport != "stdio" || result.ServerName != "fake-mcp" || result.ProtocolVersion != "2024-11-05" {
+		t.Errorf("unexpected server identity: %+v", result)
+	}
+	if result.ServerVersion != "9.9.9" {
+		t.Errorf("configured env not passed to server, version %q", result.ServerVersion)
+	}
+	if strings.Contains(result.Instructions, "hunter2") {
+		t.Error("parent environment leaked into the sandboxed server")
+	}
+	if len(result.Tools) != 2 || result.Tools[1].Name != "read_file" {
+		t.Errorf("expected both pages of tools, got %+v", result.Tools)
+	}
+	if len(result.Resources) != 2 || len(result.Prompts) != 1 {
+		t.Errorf("unexpected resources/prompts: %+v %+v", result.Resources, result.Prompts)
+	}
+	if result.Stderr != "fakemcp: started" {
+		t.Errorf("unexpected stderr: %q", result.Stderr)
+	}
+
+	rules := findingRules(result.Findings)
+	for _, expected := range []string{"MCP-LIVE-003", "MCP-LIVE-004", "MCP-LIVE-006", "MCP-LIVE-007", "CRED-CONFIG_API"} {
+		if _, ok := rules[expected]; !ok {
+			t.Errorf("expected %s finding, got %v", expected, rules)
+		}
+	}
+	if f := rules["MCP-LIVE-004"]; f.FilePath != "mcp://fake" || !strings.Contains(f.Evidence, "run_shell_command") {
+		t.Errorf("unexpected command tool finding: %+v", f)
+	}
+	if _, ok := rules["MCP-LIVE-001"]; ok {
+		t.Error("HTTP auth rule must not fire for stdio servers")
+	}
+	if len(scanner.risks) != len(result.Findings) {
+		t.Errorf("findings not recorded with the scanner: %d vs %d", len(scanner.risks), len(result.Findings))
+	}
+}
+
+func TestInterrogateHTTPServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg mcpMessage
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+
+		switch msg.Method {
+		case "initialize":
+			w.Header().Set("Mcp-Session-Id", "session-1")
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(msg.ID) + `,"result":{"protocolVersion":"2025-06-18","capabilities":{"tools":{}},"serverInfo":{"name":"remote","version":"1.0"}}}`))
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusAccepted)
+		case "tools/list":
+			if r.Header.Get("Mcp-Session-Id") != "session-1" || r.Header.Get("MCP-Protocol-Version") != "2025-06-18" {
+				http.Error(w, "missing session", http.StatusBadRequest)
+				return
+			}
+			// Answer as an event stream, after an unrelated notification
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("event: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\"}\n\n" +
+				"event: message\ndata: {\"jsonrpc\":\"2.0\",\"id\":" + string(msg.ID) + ",\"result\":{\"tools\":[{\"name\":\"delete_file\",\"description\":\"Delete a file\"}]}}\n\n"))
+		}
+	}))
+	defer server.Close()
+
+	target, err := ResolveMCPTarget(server.URL+"/mcp", "")
+	if err != nil {
+		t.Fatalf("ResolveMCPTarget failed: %v", err)
+	}
+
+	scanner := NewMCPScanner(NewSecureExecutor())
+	result, err := scanner.Interrogate(context.Background(), *target, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Interrogate failed: %v", err)
+	}
+
+	if result.Transport != "http" || result.Auth == nil || result.Auth.Required {
+		t.Errorf("unexpected transport/auth: %s %+v", result.Transport, result.Auth)
+	}
+	if len(result.Tools) != 1 || result.Tools[0].Name != "delete_file" {
+		t.Errorf("unexpected tools: %+v (errors %v)", result.Tools, result.Errors)
+	}
+
+	rules := findingRules(result.Findings)
+	for _, expected := range []string{"MCP-LIVE-001", "MCP-LIVE-005"} {
+		if _, ok := rules[expected]; !ok {
+			t.Errorf("expected %s finding, got %v", expected, rules)
+		}
+	}
+	if _, ok := rules["MCP-LIVE-002"]; ok {
+		t.Error("plaintext rule must not fire for loopback servers")
+	}
+}
+
+func TestInterrogateHTTPAuthRequired(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Bearer resource_metadata="https://mcp.example.com/.well-known/oauth-protected-resource"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	scanner := NewMCPScanner(NewSecureExecutor())
+	result, err := scanner.Interrogate(context.Background(), MCPTarget{Name: "remote", URL: server.URL}, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Interrogate failed: %v", err)
+	}
+
+	if result.Auth == nil || !result.Auth.Required || result.Auth.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected auth requirement, got %+v", result.Auth)
+	}
+	if result.Auth.ResourceMetadata != "https://mcp.example.com/.well-known/oauth-protected-resource" {
+		t.Errorf("unexpected resource metadata: %q", result.Auth.ResourceMetadata)
+	}
+	if len(result.Findings) != 0 {
+		t.Errorf("protected server should have no findings, got %+v", result.Findings)
+	}
+}
+
+func TestInterrogateHTTPHonorsEngagementScope(t *testing.T) {
+	var hits int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	scope := &engagement.Scope{Name: "test", ExcludedPaths: []string{"/mcp"}}
+	if err := scope.Compile(); err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	var audit bytes.Buffer
+	engagement.SetDefault(engagement.NewScheduler(engagement.SchedulerConfig{Scope: scope, AuditLog: engagement.NewAuditLog(&audit)}))
+	defer engagement.SetDefault(nil)
+
+	scanner := NewMCPScanner(NewSecureExecutor())
+	_, err := scanner.Interrogate(context.Background(), MCPTarget{Name: "remote", URL: server.URL + "/mcp"}, 5*time.Second)
+	if !engagement.IsOutOfScope(err) {
+		t.Fatalf("expected an out of scope error, got %v", err)
+	}
+	if hits != 0 {
+		t.Error("out of scope request reached the server")
+	}
+	if !strings.Contains(audit.String(), `"module":"probe/mcp"`) || !strings.Contains(audit.String(), `"decision":"blocked"`) {
+		t.Errorf("blocked request not audited: %s", audit.String())
+	}
+}
+
+func TestStartInteractiveRejectsUnpinnedArguments(t *testing.T) {
+	executor := NewSecureExecutor()
+	err := executor.RegisterCommand("cat", &CommandSpec{
+		Path:        "/bin/cat",
+		AllowedArgs: []ArgumentSpec{{Pattern: "^-u$", Required: true}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := executor.StartInteractive(context.Background(), "cat", "/etc/passwd"); err == nil {
+		t.Error("expected argument validation to reject an unpinned argument")
+	}
+	if _, err := executor.StartInteractive(context.Background(), "nc"); err == nil {
+		t.Error("expected unregistered command to be rejected")
+	}
+}
@@ -0,0 +1,420 @@
+package security
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MCP policy actions.
+const (
+	MCPPolicyAllow   = "allow"
+	MCPPolicyDeny    = "deny"
+	MCPPolicyApprove = "approve" // hold for interactive approval
+)
+
+// MCPPolicy is a declarative policy enforced on MCP traffic by the stdio
+// proxy: which tools may be called and with what arguments, which paths
+// they may touch, how large a message may be and what is redacted from
+// results.
+type MCPPolicy struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+
+	// DefaultAction applies to tools no rule names; "deny" if empty.
+	DefaultAction string `json:"default_action,omitempty"`
+	// MaxPayload is the largest message in bytes; 0 means no limit.
+	MaxPayload int `json:"max_payload,omitempty"`
+	// Paths are the roots path-like arguments must resolve under; empty
+	// allows any path.
+	Paths []string `json:"paths,omitempty"`
+	// RedactSecrets removes credentials from results.
+	RedactSecrets bool `json:"redact_secrets,omitempty"`
+	// Redact are extra regexes removed from results.
+	Redact []string        `json:"redact,omitempty"`
+	Tools  []MCPPolicyTool `json:"tools,omitempty"`
+
+	redact []*regexp.Regexp
+	roots  []string
+}
+
+// MCPPolicyTool is the rule for tools matching Name, which may contain
+// * and ? wildcards. The first matching rule applies.
+type MCPPolicyTool struct {
+	Name   string `json:"name"`
+	Action string `json:"action,omitempty"` // allow if empty
+	// Arguments maps an argument name to a regex its value must match.
+	Arguments map[string]string `json:"arguments,omitempty"`
+	// Paths replaces the policy-wide path allowlist for this tool.
+	Paths []string `json:"paths,omitempty"`
+
+	arguments map[string]*regexp.Regexp
+	roots     []string
+}
+
+// MCPPolicyVerdict is the outcome of checking a message against a policy.
+type MCPPolicyVerdict struct {
+	Action string `json:"action"`
+	Rule   string `json:"rule"` // tool rule name, "default" or the check that failed
+	Reason string `json:"reason,omitempty"`
+}
+
+// pathArguments are argument names whose values are treated as paths, in
+// addition to names ending in _path, _file or _dir.
+var pathArguments = map[string]bool{
+	"path": true, "paths": true, "file": true, "files": true, "filename": true,
+	"filepath": true, "dir": true, "directory": true, "source": true,
+	"destination": true, "target": true, "root": true, "cwd": true, "uri": true,
+}
+
+// ReadMCPPolicy reads and compiles a policy from a JSON or YAML file.
+func ReadMCPPolicy(file string) (*MCPPolicy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	content := bytes.TrimSpace(data)
+	if ext := strings.ToLower(filepath.Ext(file)); ext == ".yaml" || ext == ".yml" {
+		parsed, err := parseYAMLSubset(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse policy file: %w", err)
+		}
+		// The YAML subset only yields strings
+		if err := typeYAMLPolicy(parsed); err != nil {
+			return nil, fmt.Errorf("failed to parse policy file: %w", err)
+		}
+		if content, err = json.Marshal(parsed); err != nil {
+			return nil, fmt.Errorf("failed to parse policy file: %w", err)
+		}
+	}
+
+	policy := &MCPPolicy{}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(policy); err != nil {
+		return nil, fmt.Errorf("failed to parse policy file: %w", err)
+	}
+	if policy.Name == "" {
+		policy.Name = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	}
+	if policy.Version == "" {
+		policy.Version = "unversioned"
+	}
+	if err := policy.Compile(); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// typeYAMLPolicy converts the non-string policy fields of a parsed YAML
+// document.
+func typeYAMLPolicy(parsed interface{}) error {
+	doc, ok := parsed.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	if value, ok := doc["max_payload"].(string); ok {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("max_payload: %w", err)
+		}
+		doc["max_payload"] = n
+	}
+	if value, ok := doc["redact_secrets"].(string); ok {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("redact_secrets: %w", err)
+		}
+		doc["redact_secrets"] = b
+	}
+	return nil
+}
+
+// Compile validates the policy and prepares its patterns. ReadMCPPolicy
+// calls it; policies built in code must call it before use.
+func (p *MCPPolicy) Compile() error {
+	if p.DefaultAction == "" {
+		p.DefaultAction = MCPPolicyDeny
+	}
+	if !validMCPPolicyAction(p.DefaultAction) {
+		return fmt.Errorf("policy %s: invalid default_action %q", p.Name, p.DefaultAction)
+	}
+	if p.MaxPayload < 0 {
+		return fmt.Errorf("policy %s: max_payload must not be negative", p.Name)
+	}
+
+	p.redact = nil
+	for _, expr := range p.Redact {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("policy %s: redact pattern %q: %w", p.Name, expr, err)
+		}
+		p.redact = append(p.redact, re)
+	}
+	p.roots = policyRoots(p.Paths)
+
+	for i := range p.Tools {
+		tool := &p.Tools[i]
+		if tool.Name == "" {
+			return fmt.Errorf("policy %s: tool rule %d has no name", p.Name, i+1)
+		}
+		if _, err := path.Match(tool.Name, ""); err != nil {
+			return fmt.Errorf("policy %s: tool %s: invalid name pattern", p.Name, tool.Name)
+		}
+		if tool.Action == "" {
+			tool.Action = MCPPolicyAllow
+		}
+		if !validMCPPolicyAction(tool.Action) {
+			return fmt.Errorf("policy %s: tool %s: invalid action %q", p.Name, tool.Name, tool.Action)
+		}
+		tool.arguments = make(map[string]*regexp.Regexp, len(tool.Arguments))
+		for name, expr := range tool.Arguments {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return fmt.Errorf("policy %s: tool %s: argument %s: %w", p.Name, tool.Name, name, err)
+			}
+			tool.arguments[name] = re
+		}
+		tool.roots = policyRoots(tool.Paths)
+	}
+	return nil
+}
+
+func validMCPPolicyAction(action string) bool {
+	return action == MCPPolicyAllow || action == MCPPolicyDeny || action == MCPPolicyApprove
+}
+
+// CheckToolCall decides a tools/call request. Argument and path checks run
+// before the rule's action, so a call needing approval is only held if it
+// would otherwise be allowed.
+func (p *MCPPolicy) CheckToolCall(tool string, args map[string]interface{}) MCPPolicyVerdict {
+	var rule *MCPPolicyTool
+	for i := range p.Tools {
+		if ok, _ := path.Match(p.Tools[i].Name, tool); ok {
+			rule = &p.Tools[i]
+			break
+		}
+	}
+
+	verdict := MCPPolicyVerdict{Action: p.DefaultAction, Rule: "default"}
+	roots := p.roots
+	if rule != nil {
+		verdict = MCPPolicyVerdict{Action: rule.Action, Rule: rule.Name}
+		if len(rule.roots) > 0 {
+			roots = rule.roots
+		}
+	}
+	if verdict.Action == MCPPolicyDeny {
+		verdict.Reason = fmt.Sprintf("tool %s is not allowed", tool)
+		return verdict
+	}
+
+	if rule != nil {
+		for _, name := range sortedKeys(rule.arguments) {
+			re := rule.arguments[name]
+			value, ok := args[name]
+			if !ok {
+				continue
+			}
+			if text := argumentText(value); !re.MatchString(text) {
+				verdict.Action = MCPPolicyDeny
+				verdict.Reason = fmt.Sprintf("argument %s does not match %s", name, re)
+				return verdict
+			}
+		}
+	}
+
+	if len(roots) > 0 {
+		for _, arg := range pathArgumentValues("", args) {
+			for _, candidate := range argumentPaths(arg.value) {
+				if !pathWithin(candidate, roots) {
+					verdict.Action = MCPPolicyDeny
+					verdict.Reason = fmt.Sprintf("argument %s: %s is outside the allowed paths", arg.name, candidate)
+					return verdict
+				}
+			}
+		}
+	}
+	return verdict
+}
+
+// CheckResource decides a resources/read request. Only file URIs are
+// subject to the path allowlist.
+func (p *MCPPolicy) CheckResource(uri string) MCPPolicyVerdict {
+	verdict := MCPPolicyVerdict{Action: MCPPolicyAllow, Rule: "paths"}
+	if len(p.roots) == 0 || !strings.HasPrefix(strings.ToLower(uri), "file:") {
+		return verdict
+	}
+	for _, candidate := range argumentPaths(uri) {
+		if !pathWithin(candidate, p.roots) {
+			verdict.Action = MCPPolicyDeny
+			verdict.Reason = fmt.Sprintf("resource %s is outside the allowed paths", uri)
+		}
+	}
+	return verdict
+}
+
+// CheckPayload decides whether a message of size bytes may pass.
+func (p *MCPPolicy) CheckPayload(size int) MCPPolicyVerdict {
+	if p.MaxPayload > 0 && size > p.MaxPayload {
+		return MCPPolicyVerdict{
+			Action: MCPPolicyDeny,
+			Rule:   "max_payload",
+			Reason: fmt.Sprintf("message of %d bytes exceeds max_payload %d", size, p.MaxPayload),
+		}
+	}
+	return MCPPolicyVerdict{Action: MCPPolicyAllow, Rule: "max_payload"}
+}
+
+// RedactText removes matches of the policy's redact patterns from text and
+// returns the result with the number of matches removed.
+func (p *MCPPolicy) RedactText(text string) (string, int) {
+	count := 0
+	for _, re := range p.redact {
+		text = re.ReplaceAllStringFunc(text, func(string) string {
+			count++
+			return "[REDACTED]"
+		})
+	}
+	return text, count
+}
+
+// sortedKeys returns the keys of m in order, for deterministic verdicts.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// argumentText is the text an argument regex is matched against: strings
+// as is, anything else as JSON.
+func argumentText(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+func isPathArgument(name string) bool {
+	name = strings.ToLower(name)
+	return pathArguments[name] || strings.HasSuffix(name, "_path") ||
+		strings.HasSuffix(name, "_file") || strings.HasSuffix(name, "_dir")
+}
+
+// pathArgument is a path-like argument found at any depth; name is dotted
+// from the top-level argument down.
+type pathArgument struct {
+	name  string
+	value interface{}
+}
+
+// pathArgumentValues returns the path-like arguments in value, including
+// those nested in objects and lists, in a deterministic order.
+func pathArgumentValues(prefix string, value interface{}) []pathArgument {
+	var found []pathArgument
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(v) {
+			name := key
+			if prefix != "" {
+				name = prefix + "." + key
+			}
+			if isPathArgument(key) {
+				found = append(found, pathArgument{name: name, value: v[key]})
+			}
+			found = append(found, pathArgumentValues(name, v[key])...)
+		}
+	case []interface{}:
+		for _, item := range v {
+			found = append(found, pathArgumentValues(prefix, item)...)
+		}
+	}
+	return found
+}
+
+// argumentPaths returns the filesystem paths named by a string or list
+// argument. Non-file URIs are not paths.
+func argumentPaths(value interface{}) []string {
+	var paths []string
+	switch v := value.(type) {
+	case string:
+		// A one-letter scheme is a Windows drive
+		if u, err := url.Parse(v); err == nil && len(u.Scheme) > 1 {
+			if !strings.EqualFold(u.Scheme, "file") {
+				return nil
+			}
+			v = u.Path
+			if v == "" {
+				v = u.Opaque
+			}
+		}
+		if v != "" {
+			paths = append(paths, v)
+		}
+	case []interface{}:
+		for _, item := range v {
+			paths = append(paths, argumentPaths(item)...)
+		}
+	}
+	return paths
+}
+
+// policyRoots resolves allowlist entries the same way arguments are.
+func policyRoots(paths []string) []string {
+	roots := make([]string, 0, len(paths))
+	for _, p := range paths {
+		roots = append(roots, resolvePolicyPath(p))
+	}
+	return roots
+}
+
+// resolvePolicyPath makes p absolute and resolves symlinks in as much of it
+// as exists, so links and .. cannot step outside an allowed root.
+func resolvePolicyPath(p string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			p = filepath.Join(home, p[1:])
+		}
+	}
+	if abs, err := filepath.Abs(p); err == nil {
+		p = abs
+	}
+
+	// Resolve the longest existing prefix; the rest cannot be a link yet
+	rest := ""
+	for dir := p; ; dir = filepath.Dir(dir) {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(resolved, rest)
+		}
+		if filepath.Dir(dir) == dir {
+			return p
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+	}
+}
+
+// pathWithin reports whether p resolves to one of roots or below it.
+func pathWithin(p string, roots []string) bool {
+	resolved := resolvePolicyPath(p)
+	for _, root := range roots {
+		rel, err := filepath.Rel(root, resolved)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package security
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadMCPPolicyYAML(t *testing.T) {
+	policy, err := ReadMCPPolicy(filepath.Join("..", "..", "configs", "mcp_policy.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.Name != "filesystem-guard" || policy.DefaultAction != MCPPolicyDeny {
+		t.Errorf("unexpected policy %s / %s", policy.Name, policy.DefaultAction)
+	}
+	if policy.MaxPayload != 1048576 || !policy.RedactSecrets {
+		t.Errorf("typed fields not converted: max_payload %d, redact_secrets %v", policy.MaxPayload, policy.RedactSecrets)
+	}
+	if len(policy.Tools) != 5 || policy.Tools[0].Action != MCPPolicyAllow || policy.Tools[3].Action != MCPPolicyApprove {
+		t.Errorf("unexpected tools %+v", policy.Tools)
+	}
+}
+
+func TestReadMCPPolicyRejectsInvalid(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"action.json":  `{"tools": [{"name": "x", "action": "maybe"}]}`,
+		"regex.json":   `{"tools": [{"name": "x", "arguments": {"path": "("}}]}`,
+		"unknown.json": `{"tool": []}`,
+		"size.yaml":    "max_payload: lots\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ReadMCPPolicy(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestMCPPolicyCheckToolCall(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	policy := &MCPPolicy{
+		Name:  "test",
+		Paths: []string{root},
+		Tools: []MCPPolicyTool{
+			{Name: "read_*"},
+			{Name: "write_file", Action: MCPPolicyApprove, Arguments: map[string]string{"content": `^[^<]*$`}},
+			{Name: "run_*", Action: MCPPolicyDeny},
+		},
+	}
+	if err := policy.Compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		tool   string
+		args   map[string]interface{}
+		action string
+		rule   string
+	}{
+		{"allowed path", "read_file", map[string]interface{}{"path": filepath.Join(root, "a.txt")}, MCPPolicyAllow, "read_*"},
+		{"file URI", "read_file", map[string]interface{}{"uri": "file://" + filepath.Join(root, "a.txt")}, MCPPolicyAllow, "read_*"},
+		{"non-path argument", "read_file", map[string]interface{}{"query": "/etc/passwd"}, MCPPolicyAllow, "read_*"},
+		{"dot-dot escape", "read_file", map[string]interface{}{"path": root + "/../etc/passwd"}, MCPPolicyDeny, "read_*"},
+		{"symlink escape", "read_file", map[string]interface{}{"path": filepath.Join(root, "escape", "secret")}, MCPPolicyDeny, "read_*"},
+		{"path list", "read_multiple_files", map[string]interface{}{"paths": []interface{}{filepath.Join(root, "a"), "/etc/shadow"}}, MCPPolicyDeny, "read_*"},
+		{"nested path", "read_file", map[string]interface{}{"options": map[string]interface{}{"source_file": "/etc/shadow"}}, MCPPolicyDeny, "read_*"},
+		{"path in list of objects", "read_file", map[string]interface{}{"edits": []interface{}{map[string]interface{}{"path": filepath.Join(root, "a")}, map[string]interface{}{"path": "/etc/shadow"}}}, MCPPolicyDeny, "read_*"},
+		{"nested allowed path", "read_file", map[string]interface{}{"options": map[string]interface{}{"dir": root}}, MCPPolicyAllow, "read_*"},
+		{"needs approval", "write_file", map[string]interface{}{"path": filepath.Join(root, "b"), "content": "ok"}, MCPPolicyApprove, "write_file"},
+		{"argument regex", "write_file", map[string]interface{}{"path": filepath.Join(root, "b"), "content": "<script>"}, MCPPolicyDeny, "write_file"},
+		{"denied tool", "run_command", nil, MCPPolicyDeny, "run_*"},
+		{"default", "unknown", nil, MCPPolicyDeny, "default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict := policy.CheckToolCall(tt.tool, tt.args)
+			if verdict.Action != tt.action || verdict.Rule != tt.rule {
+				t.Errorf("CheckToolCall() = %+v, want %s by %s", verdict, tt.action, tt.rule)
+			}
+			if verdict.Action == MCPPolicyDeny && verdict.Reason == "" {
+				t.Error("denial without a reason")
+			}
+		})
+	}
+}
+
+func TestMCPPolicyPayloadResourcesAndRedaction(t *testing.T) {
+	policy := &MCPPolicy{Name: "test", MaxPayload: 10, Paths: []string{"/srv/data"}, Redact: []string{`ACME-\d+`}}
+	if err := policy.Compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := policy.CheckPayload(10); v.Action != MCPPolicyAllow {
+		t.Errorf("payload at the limit rejected: %+v", v)
+	}
+	if v := policy.CheckPayload(11); v.Action != MCPPolicyDeny || v.Rule != "max_payload" {
+		t.Errorf("oversized payload allowed: %+v", v)
+	}
+
+	if v := policy.CheckResource("file:///etc/passwd"); v.Action != MCPPolicyDeny {
+		t.Errorf("resource outside paths allowed: %+v", v)
+	}
+	if v := policy.CheckResource("https://example.com/doc"); v.Action != MCPPolicyAllow {
+		t.Errorf("non-file resource denied: %+v", v)
+	}
+
+	text, n := policy.RedactText("ids ACME-123 and ACME-456")
+	if n != 2 || strings.Contains(text, "ACME") {
+		t.Errorf("RedactText() = %q, %d", text, n)
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"strconv"
 	"strings"
 	"sync"
@@ -25,6 +27,10 @@ type MCPScanner struct {
 	risks       []Finding
 	includeSelf bool
 	mu          sync.Mutex
+
+	socketsOnce sync.Once
+	sockets     *SocketInventory
+	socketsErr  error
 }
 
 // MCPTool represents a discovered MCP server/client/bridge.
@@ -48,6 +54,16 @@ type MCPTool struct {
 	CommandLine     string              `json:"command_line,omitempty"`
 	BuildInfo       map[string]string   `json:"build_info,omitempty"`
 	NetworkExposure NetworkExposureInfo `json:"network_exposure,omitempty"`
+	// Normalized client configuration entry
+	Client    string            `json:"client,omitempty"`    // "Claude Desktop", "Cursor", ...
+	Transport string            `json:"transport,omitempty"` // "stdio", "http", "sse"
+	URL       string            `json:"url,omitempty"`
+	Command   string            `json:"command,omitempty"`
+	Args      []string          `json:"args,omitempty"`
+	Env       map[string]string `json:"env,omitempty"` // Values redacted
+	// Blast radius: what the server can touch and a 0-100 score
+	CapabilityProfile *MCPCapabilityProfile `json:"capability_profile,omitempty"`
+	RiskScore         int                   `json:"risk_score,omitempty"`
 }
 
 // MCPDependency represents a dependency of an MCP tool.
@@ -74,21 +90,13 @@ type ProcessInfo struct {
 	Environment map[string]string `json:"-"` // Not exported for security
 }
 
-// NetworkConnection represents a network connection.
-type NetworkConnection struct {
-	Protocol string
-	Port     int
-	Address  string
-	State    string
-	PID      int
-}
-
 // NetworkExposureInfo contains network security assessment data.
 type NetworkExposureInfo struct {
 	ListeningPorts []int    `json:"listening_ports,omitempty"`
 	BindAddress    string   `json:"bind_address,omitempty"`
 	ExposureLevel  string   `json:"exposure_level,omitempty"` // "local", "network", "internet"
 	TLSEnabled     bool     `json:"tls_enabled,omitempty"`
+	UnixSockets    []string `json:"unix_sockets,omitempty"`
 	RiskFactors    []string `json:"risk_factors,omitempty"`
 }
 
@@ -107,6 +115,21 @@ func NewMCPScanner(executor *SecureExecutor) *MCPScanner {
 	}
 }
 
+// LoadRules adds rule packs from JSON or YAML files to the scanner's rules.
+func (ms *MCPScanner) LoadRules(paths []string) error {
+	for _, path := range paths {
+		if err := ms.rules.LoadRulesFromFile(path); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// RulePacks returns the versions and checksums of the rules in use.
+func (ms *MCPScanner) RulePacks() []RulePackInfo {
+	return ms.rules.Packs()
+}
+
 // SetIncludeSelf configures whether to include Strigoi's own files and processes in scans.
 func (ms *MCPScanner) SetIncludeSelf(include bool) {
 	ms.includeSelf = include
@@ -116,6 +139,10 @@ func (ms *MCPScanner) SetIncludeSelf(include bool) {
 func (ms *MCPScanner) DiscoverMCPTools(ctx context.Context) ([]MCPTool, error) {
 	ms.logger.Info("Starting MCP tool discovery")
 
+	// Sockets are read afresh for every discovery
+	ms.socketsOnce = sync.Once{}
+	ms.sockets, ms.socketsErr = nil, nil
+
 	var wg sync.WaitGroup
 	errChan := make(chan error, 3)
 
@@ -177,11 +204,19 @@ func (ms *MCPScanner) DiscoverMCPTools(ctx context.Context) ([]MCPTool, error) {
 func (ms *MCPScanner) scanConfigFiles(ctx context.Context) error {
 	ms.logger.Debug("Scanning MCP configuration files")
 
-	// Known MCP configuration locations
-	configPaths := []string{
-		// Claude Code MCP configurations
-		filepath.Join(os.Getenv("HOME"), ".config", "claude-code", "mcp_servers.json"),
+	// MCP client configurations
+	workDir, _ := os.Getwd()
+	for _, config := range mcpClientConfigs(os.Getenv("HOME"), workDir, runtime.GOOS) {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			ms.scanClientConfig(config)
+		}
+	}
 
+	// Other known MCP configuration locations
+	configPaths := []string{
 		// System-wide configurations
 		"/etc/mcp/",
 		"/opt/mcp/",
@@ -231,6 +266,73 @@ func (ms *MCPScanner) scanConfigPath(configPath string) {
 	}
 }
 
+// scanClientConfig normalizes each server entry of an MCP client
+// configuration into a tool and flags risky launch settings. A directory
+// is treated as a set of configuration files.
+func (ms *MCPScanner) scanClientConfig(config MCPClientConfig) {
+	if ms.shouldExcludePath(config.Path) {
+		return
+	}
+
+	info, err := os.Stat(config.Path)
+	if err != nil {
+		return
+	}
+	if info.IsDir() {
+		for _, file := range mcpConfigFiles(config) {
+			ms.scanClientConfig(file)
+		}
+		return
+	}
+
+	if info.Size() > 10*1024*1024 {
+		ms.logger.WithField("file", config.Path).Warn("File too large, skipping")
+		return
+	}
+	content, err := os.ReadFile(config.Path)
+	if err != nil {
+		ms.logger.WithError(err).WithField("file", config.Path).Debug("Failed to read file")
+		return
+	}
+
+	servers, err := parseMCPClientConfig(config.Path, content)
+	if err != nil {
+		ms.logger.WithError(err).WithField("file", config.Path).Debug("Failed to parse MCP client config")
+		return
+	}
+	if len(servers) == 0 {
+		return
+	}
+
+	ms.logger.WithField("file", config.Path).WithField("client", config.Client).WithField("servers", len(servers)).Debug("Parsed MCP client config")
+
+	var findings []Finding
+	if !config.Shared {
+		findings = ms.rules.ScanContent(string(content), config.Path)
+	}
+	profiles := make(map[string]*MCPCapabilityProfile, len(servers))
+	for _, server := range servers {
+		tool := server.toMCPTool(ms, config)
+		ms.addFinding(tool)
+		profiles[server.Name] = tool.CapabilityProfile
+
+		launchRisks := server.launchRisks(config.Path)
+		findings = append(findings, launchRisks...)
+		for _, finding := range blastRadiusFindings(server.Name, config.Path, tool.CapabilityProfile) {
+			// sudo launches are already reported as MCP-LAUNCH-002
+			if finding.RuleID == "MCP-BLAST-002" && hasRule(launchRisks, "MCP-LAUNCH-002") {
+				continue
+			}
+			findings = append(findings, finding)
+		}
+	}
+	if combined := combinedTrifectaFinding(config.Path, profiles); combined != nil {
+		findings = append(findings, *combined)
+	}
+	ms.addFindings(findings)
+	ms.analyzeSecurityRisks()
+}
+
 // scanDirectory scans a directory for MCP configuration files.
 func (ms *MCPScanner) scanDirectory(dirPath string) {
 	entries, err := os.ReadDir(dirPath)
@@ -534,66 +636,58 @@ func (ms *MCPScanner) processToMCPTool(process ProcessInfo) MCPTool {
 
 	// Analyze network exposure
 	tool.NetworkExposure = ms.analyzeNetworkExposure(process.PID)
+	tool.Port = ms.primaryPort(tool.NetworkExposure.ListeningPorts)
 
 	// Extract build information if available
 	tool.BuildInfo = ms.extractBuildInfo(process.ExePath)
 
+	// Estimate the blast radius from the command line and the process owner
+	if fields := strings.Fields(process.CmdLine); len(fields) > 0 {
+		tool.CapabilityProfile = profileMCPServer(mcpProfileInput{
+			Command: fields[0],
+			Args:    fields[1:],
+			User:    process.User,
+		})
+		tool.RiskScore = tool.CapabilityProfile.RiskScore()
+		ms.addFindings(blastRadiusFindings(tool.Name, "", tool.CapabilityProfile))
+	}
+
 	return tool
 }
 
-// scanNetworkConnections discovers network connections related to MCP tools.
+// scanNetworkConnections reads the socket inventory from /proc/net. Ports
+// and exposure are attached to running tools from the same snapshot.
 func (ms *MCPScanner) scanNetworkConnections(ctx context.Context) error {
 	ms.logger.Debug("Scanning network connections")
 
-	// Execute netstat command safely
-	result, err := ms.executor.Execute(ctx, "netstat", "-tulpn")
+	inventory, err := ms.socketInventory()
 	if err != nil {
-		// netstat might not be available, continue without error
-		ms.logger.Debug("netstat not available, skipping network scanning")
+		// Not Linux, or /proc is not mounted; continue without network data
+		ms.logger.WithError(err).Debug("Socket inventory not available, skipping network scanning")
 		return nil
 	}
 
-	lines := strings.Split(result.Stdout, "\n")
-	for _, line := range lines {
-		conn := ms.parseNetworkLine(line)
-		if conn != nil && ms.isMCPPort(conn.Port) {
-			ms.updateToolWithPort(conn.PID, conn.Port)
-		}
-	}
-
+	ms.logger.WithField("sockets", len(inventory.Sockets)).
+		WithField("listeners", len(inventory.Listeners(0))).
+		Debug("Socket inventory loaded")
 	return nil
 }
 
-// parseNetworkLine parses a network connection line from netstat output.
-func (ms *MCPScanner) parseNetworkLine(line string) *NetworkConnection {
-	fields := strings.Fields(line)
-	if len(fields) < 6 {
-		return nil
-	}
-
-	// Parse address and port
-	addressPort := fields[3]
-	parts := strings.Split(addressPort, ":")
-	if len(parts) < 2 {
-		return nil
-	}
-
-	port, err := strconv.Atoi(parts[len(parts)-1])
-	if err != nil {
-		return nil
-	}
-
-	return &NetworkConnection{
-		Protocol: fields[0],
-		Port:     port,
-		Address:  addressPort,
-		State:    fields[5],
-	}
+// socketInventory reads the socket tables once per discovery.
+func (ms *MCPScanner) socketInventory() (*SocketInventory, error) {
+	ms.socketsOnce.Do(func() {
+		ms.sockets, ms.socketsErr = ReadSocketInventory()
+	})
+	return ms.sockets, ms.socketsErr
 }
 
 // Helper methods
 
 func (ms *MCPScanner) isConfigFile(fileName string) bool {
+	return isConfigFileName(fileName)
+}
+
+func isConfigFileName(fileName string) bool {
 	configExtensions := []string{".json", ".yaml", ".yml", ".conf", ".config", ".env"}
 	for _, ext := range configExtensions {
 		if strings.HasSuffix(fileName, ext) {
@@ -642,18 +736,6 @@ func (ms *MCPScanner) addFindings(findings []Finding) {
 	ms.logger.WithField("total_risks", len(ms.risks)).Debug("Security findings added to risks collection")
 }
 
-func (ms *MCPScanner) updateToolWithPort(pid, port int) {
-	ms.mu.Lock()
-	defer ms.mu.Unlock()
-
-	for i, tool := range ms.findings {
-		if tool.ProcessID == pid {
-			ms.findings[i].Port = port
-			break
-		}
-	}
-}
-
 func (ms *MCPScanner) correlateFindings() {
 	// Correlate process and configuration findings
 	ms.mu.Lock()
@@ -688,7 +770,7 @@ func (ms *MCPScanner) analyzeSecurityRisks() {
 
 		for _, risk := range ms.risks {
 			ms.logger.WithField("tool_config_path", tool.ConfigPath).WithField("risk_file_path", risk.FilePath).Debug("Comparing paths for risk correlation")
-			if risk.FilePath == tool.ConfigPath {
+			if risk.FilePath == tool.ConfigPath && (risk.Server == "" || risk.Server == tool.Name) {
 				toolRisks = append(toolRisks, risk)
 				ms.logger.WithField("risk_category", risk.Category).Debug("Risk correlated with tool")
 			}
@@ -927,57 +1009,52 @@ func (ms *MCPScanner) extractBuildInfo(exePath string) map[string]string {
 	return buildInfo
 }
 
-// analyzeNetworkExposure analyzes the network exposure of a process.
+// analyzeNetworkExposure analyzes the network exposure of a process from
+// the sockets it holds.
 func (ms *MCPScanner) analyzeNetworkExposure(pid int) NetworkExposureInfo {
 	exposure := NetworkExposureInfo{
 		ListeningPorts: []int{},
 		RiskFactors:    []string{},
 	}
 
-	// Use ss or netstat to find listening ports for this PID
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	// Try ss first (more modern)
-	result, err := ms.executor.Execute(ctx, "ss", "-tlnp")
+	inventory, err := ms.socketInventory()
 	if err != nil {
-		// Fall back to netstat
-		result, err = ms.executor.Execute(ctx, "netstat", "-tlnp")
-		if err != nil {
-			ms.logger.Debug("Could not analyze network connections")
-			return exposure
-		}
+		ms.logger.Debug("Could not analyze network connections")
+		return exposure
 	}
 
-	// Parse output looking for our PID
-	lines := strings.Split(result.Stdout, "\n")
-	for _, line := range lines {
-		if strings.Contains(line, fmt.Sprintf("pid=%d", pid)) {
-			// Extract port and bind address
-			fields := strings.Fields(line)
-			for _, field := range fields {
-				if strings.Contains(field, ":") && !strings.Contains(field, "::") {
-					parts := strings.Split(field, ":")
-					if len(parts) >= 2 {
-						port, err := strconv.Atoi(parts[len(parts)-1])
-						if err == nil {
-							exposure.ListeningPorts = append(exposure.ListeningPorts, port)
-
-							// Analyze bind address
-							if strings.HasPrefix(field, "0.0.0.0:") || strings.HasPrefix(field, "*:") {
-								exposure.BindAddress = "0.0.0.0"
-								exposure.ExposureLevel = "network"
-								exposure.RiskFactors = append(exposure.RiskFactors, "Listening on all interfaces")
-							} else if strings.HasPrefix(field, "127.0.0.1:") || strings.HasPrefix(field, "localhost:") {
-								exposure.BindAddress = "127.0.0.1"
-								exposure.ExposureLevel = "local"
-							} else {
-								exposure.BindAddress = field
-								exposure.ExposureLevel = "network"
-							}
-						}
-					}
-				}
+	for _, socket := range inventory.ForPID(pid) {
+		if !socket.Listening() {
+			continue
+		}
+		if socket.Protocol == "unix" {
+			if socket.Path != "" && socket.State == "LISTEN" {
+				exposure.UnixSockets = append(exposure.UnixSockets, socket.Path)
+			}
+			continue
+		}
+
+		if !containsInt(exposure.ListeningPorts, socket.LocalPort) {
+			exposure.ListeningPorts = append(exposure.ListeningPorts, socket.LocalPort)
+		}
+
+		// The widest bind address decides the exposure level
+		ip := net.ParseIP(socket.LocalAddress)
+		switch {
+		case ip != nil && ip.IsUnspecified():
+			exposure.RiskFactors = append(exposure.RiskFactors,
+				fmt.Sprintf("Listening on all interfaces (%s/%s)", socket.Endpoint(), socket.Protocol))
+			exposure.BindAddress = socket.LocalAddress
+			exposure.ExposureLevel = "network"
+		case ip != nil && ip.IsLoopback():
+			if exposure.ExposureLevel == "" {
+				exposure.BindAddress = socket.LocalAddress
+				exposure.ExposureLevel = "local"
+			}
+		default:
+			if exposure.ExposureLevel != "network" {
+				exposure.BindAddress = socket.LocalAddress
+				exposure.ExposureLevel = "network"
 			}
 		}
 	}
@@ -993,6 +1070,29 @@ func (ms *MCPScanner) analyzeNetworkExposure(pid int) NetworkExposureInfo {
 	return exposure
 }
 
+// primaryPort picks the port a tool is reached on, preferring well-known
+// MCP ports.
+func (ms *MCPScanner) primaryPort(ports []int) int {
+	for _, port := range ports {
+		if ms.isMCPPort(port) {
+			return port
+		}
+	}
+	if len(ports) > 0 {
+		return ports[0]
+	}
+	return 0
+}
+
+func containsInt(list []int, value int) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 // redactSensitive removes sensitive information from strings.
 func (ms *MCPScanner) redactSensitive(input string) string {
 	if input == "" {
@@ -318,55 +318,6 @@ func TestProcessToMCPTool(t *testing.T) {
 	}
 }
 
-func TestParseNetworkLine(t *testing.T) {
-	scanner := NewMCPScanner(NewSecureExecutor())
-
-	testCases := []struct {
-		name         string
-		networkLine  string
-		expectNil    bool
-		expectedPort int
-	}{
-		{
-			name:         "valid tcp connection",
-			networkLine:  "tcp        0      0 127.0.0.1:7687          0.0.0.0:*               LISTEN      12345/neo4j",
-			expectNil:    false,
-			expectedPort: 7687,
-		},
-		{
-			name:        "invalid line",
-			networkLine: "invalid line",
-			expectNil:   true,
-		},
-		{
-			name:        "no port",
-			networkLine: "tcp 0 0 localhost 0.0.0.0:* LISTEN",
-			expectNil:   true,
-		},
-	}
-
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			conn := scanner.parseNetworkLine(tc.networkLine)
-
-			if tc.expectNil {
-				if conn != nil {
-					t.Error("Expected nil connection")
-				}
-				return
-			}
-
-			if conn == nil {
-				t.Fatal("Expected non-nil connection")
-			}
-
-			if conn.Port != tc.expectedPort {
-				t.Errorf("Expected port %d, got %d", tc.expectedPort, conn.Port)
-			}
-		})
-	}
-}
-
 func TestDiscoverMCPToolsTimeout(t *testing.T) {
 	scanner := NewMCPScanner(NewSecureExecutor())
 
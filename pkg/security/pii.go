@@ -0,0 +1,432 @@
+package security
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Personal and regulated data types found by the PII classifier.
+const (
+	PIIEmail        = "email"
+	PIIPhone        = "phone"
+	PIINationalID   = "national_id"
+	PIIIBAN         = "iban"
+	PIICreditCard   = "credit_card"
+	PIIHealth       = "health"
+	PIIAddress      = "address"
+	RegulationGDPR  = "GDPR"
+	RegulationHIPAA = "HIPAA"
+	RegulationPCI   = "PCI-DSS"
+)
+
+// PIIMatch is one occurrence of personal or regulated data in a text.
+type PIIMatch struct {
+	Type        string   `json:"type"`
+	Subtype     string   `json:"subtype,omitempty"` // country-specific ID scheme, card brand
+	Value       string   `json:"-"`
+	Redacted    string   `json:"redacted"`
+	Offset      int      `json:"offset"`
+	Validated   bool     `json:"validated"` // passed a checksum, not just a pattern
+	Regulations []string `json:"regulations"`
+}
+
+// piiDetector finds one kind of data. validate, when set, rejects pattern
+// matches that fail a checksum or structural check and may name a subtype.
+// isolated rejects matches that are a fragment of a longer number.
+type piiDetector struct {
+	piiType  string
+	subtype  string
+	pattern  *regexp.Regexp
+	validate func(match string) (subtype string, ok bool)
+	isolated bool
+}
+
+// PIIClassifier finds personal and regulated data in free text such as
+// prompts and request bodies.
+type PIIClassifier struct {
+	detectors []piiDetector
+}
+
+// healthTerms are clinical terms; words also common outside medicine, such
+// as "patient" or "depression", are left out.
+var healthTerms = []string{
+	"diagnosis", "diagnosed", "prescription", "prescribed", "medication", "dosage",
+	"medical record", "medical history", "chemotherapy", "oncology", "diabetes",
+	"diabetic", "hiv", "hepatitis", "anxiety disorder", "depressive disorder",
+	"bipolar disorder", "schizophrenia", "psychiatric", "blood pressure", "insulin",
+	"icd-10",
+}
+
+// healthWindow is how close, in bytes, a health term must be to an
+// identifier to make it protected health information.
+const healthWindow = 200
+
+// NewPIIClassifier creates a classifier with the built-in detectors.
+func NewPIIClassifier() *PIIClassifier {
+	health := make([]string, len(healthTerms))
+	for i, term := range healthTerms {
+		health[i] = regexp.QuoteMeta(term)
+	}
+
+	return &PIIClassifier{detectors: []piiDetector{
+		{
+			piiType: PIIEmail,
+			pattern: regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`),
+			validate: func(m string) (string, bool) {
+				// Skip documentation and placeholder domains
+				domain := strings.ToLower(m[strings.LastIndex(m, "@")+1:])
+				return "", domain != "example.com" && domain != "example.org" && !strings.HasSuffix(domain, ".example")
+			},
+		},
+		{
+			piiType:  PIIIBAN,
+			pattern:  regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,3})?\b`),
+			validate: func(m string) (string, bool) { return m[:2], ibanValid(m) },
+		},
+		{
+			piiType:  PIICreditCard,
+			pattern:  regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+			validate: cardBrand,
+		},
+		{
+			piiType: PIINationalID,
+			subtype: "us_ssn",
+			pattern: regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`),
+			validate: func(m string) (string, bool) {
+				area, group, serial := m[0:3], m[4:6], m[7:11]
+				return "", area != "000" && area != "666" && area[0] != '9' && group != "00" && serial != "0000"
+			},
+		},
+		{
+			piiType: PIINationalID,
+			subtype: "uk_nino",
+			pattern: regexp.MustCompile(`\b[A-CEGHJ-PR-TW-Z][A-CEGHJ-NPR-TW-Z] ?\d{2} ?\d{2} ?\d{2} ?[A-D]\b`),
+			validate: func(m string) (string, bool) {
+				switch m[:2] {
+				case "BG", "GB", "NK", "KN", "TN", "NT", "ZZ":
+					return "", false
+				}
+				return "", true
+			},
+		},
+		{
+			piiType:  PIINationalID,
+			subtype:  "ca_sin",
+			pattern:  regexp.MustCompile(`\b\d{3}[ -]\d{3}[ -]\d{3}\b`),
+			validate: func(m string) (string, bool) { return "", luhnValid(digitsOnly(m)) },
+			isolated: true,
+		},
+		{
+			piiType:  PIINationalID,
+			subtype:  "es_dni",
+			pattern:  regexp.MustCompile(`\b\d{8}-?[A-Z]\b`),
+			validate: func(m string) (string, bool) { return "", dniValid(m) },
+		},
+		{
+			piiType:  PIINationalID,
+			subtype:  "in_aadhaar",
+			pattern:  regexp.MustCompile(`\b[2-9]\d{3} \d{4} \d{4}\b`),
+			validate: func(m string) (string, bool) { return "", verhoeffValid(digitsOnly(m)) },
+			isolated: true,
+		},
+		{
+			piiType: PIIPhone,
+			pattern: regexp.MustCompile(`(?:\+\d{1,3}[ -]?)?\(?\b\d{2,4}\)?(?:[ -]\d{2,4}){2,4}\b`),
+			validate: func(m string) (string, bool) {
+				n := len(digitsOnly(m))
+				return "", n >= 10 && n <= 15
+			},
+			isolated: true,
+		},
+		{
+			piiType: PIIAddress,
+			subtype: "street",
+			pattern: regexp.MustCompile(`\b\d{1,5} (?:[A-Z][a-z]+ ){1,3}(?:Street|St|Avenue|Ave|Road|Rd|Boulevard|Blvd|Lane|Ln|Drive|Dr|Court|Ct|Way|Place|Pl|Terrace|Strasse|Straße)\b`),
+		},
+		{
+			piiType: PIIAddress,
+			subtype: "uk_postcode",
+			pattern: regexp.MustCompile(`\b[A-Z]{1,2}\d[A-Z\d]? \d[A-Z]{2}\b`),
+		},
+		{
+			piiType: PIIHealth,
+			pattern: regexp.MustCompile(`(?i)\b(?:` + strings.Join(health, "|") + `)\b`),
+		},
+	}}
+}
+
+// Classify returns the personal and regulated data in text, ordered by
+// offset. Overlapping matches keep the earlier detector's (more specific)
+// result, so a validated SSN is not also reported as a phone number.
+// Identifiers within healthWindow of a health term are tagged HIPAA as well.
+func (c *PIIClassifier) Classify(text string) []PIIMatch {
+	var matches []PIIMatch
+	var taken [][2]int
+
+	overlaps := func(start, end int) bool {
+		for _, span := range taken {
+			if start < span[1] && end > span[0] {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, d := range c.detectors {
+		for _, loc := range d.pattern.FindAllStringIndex(text, -1) {
+			if overlaps(loc[0], loc[1]) || (d.isolated && !standalone(text, loc[0], loc[1])) {
+				continue
+			}
+			value := text[loc[0]:loc[1]]
+			subtype, validated := d.subtype, false
+			if d.validate != nil {
+				s, ok := d.validate(value)
+				if !ok {
+					continue
+				}
+				if s != "" {
+					subtype = s
+				}
+				validated = d.piiType != PIIEmail && d.piiType != PIIPhone
+			}
+			taken = append(taken, [2]int{loc[0], loc[1]})
+			matches = append(matches, PIIMatch{
+				Type:      d.piiType,
+				Subtype:   subtype,
+				Value:     value,
+				Redacted:  redactPII(d.piiType, value),
+				Offset:    loc[0],
+				Validated: validated,
+			})
+		}
+	}
+
+	var health []PIIMatch
+	for _, m := range matches {
+		if m.Type == PIIHealth {
+			health = append(health, m)
+		}
+	}
+	for i := range matches {
+		m := &matches[i]
+		near := false
+		for _, h := range health {
+			if m.Offset < h.Offset+len(h.Value)+healthWindow && h.Offset < m.Offset+len(m.Value)+healthWindow {
+				near = true
+				break
+			}
+		}
+		m.Regulations = piiRegulations(m.Type, near)
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Offset < matches[j].Offset })
+	return matches
+}
+
+// piiRegulations maps a data type to the regimes that govern it. Health
+// data near an identifier makes it protected health information.
+func piiRegulations(piiType string, withHealth bool) []string {
+	switch piiType {
+	case PIICreditCard:
+		return []string{RegulationGDPR, RegulationPCI}
+	case PIIHealth:
+		return []string{RegulationGDPR, RegulationHIPAA}
+	}
+	if withHealth {
+		return []string{RegulationGDPR, RegulationHIPAA}
+	}
+	return []string{RegulationGDPR}
+}
+
+// PIISeverity rates a data type: financial and government identifiers and
+// health data are high, contact details medium.
+func PIISeverity(piiType string) string {
+	switch piiType {
+	case PIICreditCard, PIIIBAN, PIINationalID, PIIHealth:
+		return "high"
+	}
+	return "medium"
+}
+
+// PIICounts counts matches by type.
+func PIICounts(matches []PIIMatch) map[string]int {
+	counts := make(map[string]int)
+	for _, m := range matches {
+		counts[m.Type]++
+	}
+	return counts
+}
+
+// PIIRegulations returns the regulations touched by any match, sorted.
+func PIIRegulations(matches []PIIMatch) []string {
+	var regulations []string
+	for _, m := range matches {
+		for _, r := range m.Regulations {
+			if !contains(regulations, r) {
+				regulations = append(regulations, r)
+			}
+		}
+	}
+	sort.Strings(regulations)
+	return regulations
+}
+
+// redactPII hides a value, keeping enough to recognize it: the domain of an
+// email, the last four characters of account numbers and IDs.
+func redactPII(piiType, value string) string {
+	switch piiType {
+	case PIIEmail:
+		at := strings.LastIndex(value, "@")
+		return value[:1] + "***" + value[at:]
+	case PIIHealth:
+		return value
+	case PIIAddress:
+		return "[address]"
+	}
+	compact := strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "").Replace(value)
+	if len(compact) <= 4 {
+		return "****"
+	}
+	return strings.Repeat("*", len(compact)-4) + compact[len(compact)-4:]
+}
+
+// standalone reports whether text[start:end] is not continued by more
+// digits across a single space or dash.
+func standalone(text string, start, end int) bool {
+	adjacent := func(c byte) bool { return c >= '0' && c <= '9' }
+	if start >= 2 && (text[start-1] == ' ' || text[start-1] == '-') && adjacent(text[start-2]) {
+		return false
+	}
+	if end+1 < len(text) && (text[end] == ' ' || text[end] == '-') && adjacent(text[end+1]) {
+		return false
+	}
+	return true
+}
+
+func digitsOnly(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// luhnValid checks the Luhn (mod 10) checksum used by cards and Canadian SINs.
+func luhnValid(digits string) bool {
+	if len(digits) < 2 {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// cardBrand accepts Luhn-valid numbers with a known issuer prefix.
+func cardBrand(match string) (string, bool) {
+	digits := digitsOnly(match)
+	if len(digits) < 13 || len(digits) > 19 || !luhnValid(digits) {
+		return "", false
+	}
+	prefix2, _ := strconv.Atoi(digits[:2])
+	prefix4, _ := strconv.Atoi(digits[:4])
+	switch {
+	case digits[0] == '4':
+		return "visa", true
+	case prefix2 >= 51 && prefix2 <= 55, prefix4 >= 2221 && prefix4 <= 2720:
+		return "mastercard", true
+	case prefix2 == 34 || prefix2 == 37:
+		return "amex", true
+	case prefix4 == 6011 || prefix2 == 65:
+		return "discover", true
+	case prefix2 == 35:
+		return "jcb", true
+	}
+	return "", false
+}
+
+// ibanValid checks the ISO 13616 mod-97 checksum.
+func ibanValid(match string) bool {
+	iban := strings.ReplaceAll(match, " ", "")
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+	rearranged := iban[4:] + iban[:4]
+	remainder := 0
+	for _, r := range rearranged {
+		var value int
+		switch {
+		case r >= '0' && r <= '9':
+			value = int(r - '0')
+			remainder = (remainder*10 + value) % 97
+			continue
+		case r >= 'A' && r <= 'Z':
+			value = int(r-'A') + 10
+		default:
+			return false
+		}
+		remainder = (remainder*100 + value) % 97
+	}
+	return remainder == 1
+}
+
+// dniValid checks the control letter of a Spanish DNI (number mod 23).
+func dniValid(match string) bool {
+	digits := digitsOnly(match)
+	number, err := strconv.Atoi(digits)
+	if err != nil {
+		return false
+	}
+	const letters = "TRWAGMYFPDXBNJZSQVHLCKE"
+	return match[len(match)-1] == letters[number%23]
+}
+
+// Verhoeff tables for Aadhaar checksums.
+var (
+	verhoeffD = [10][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, {1, 2, 3, 4, 0, 6, 7, 8, 9, 5},
+		{2, 3, 4, 0, 1, 7, 8, 9, 5, 6}, {3, 4, 0, 1, 2, 8, 9, 5, 6, 7},
+		{4, 0, 1, 2, 3, 9, 5, 6, 7, 8}, {5, 9, 8, 7, 6, 0, 4, 3, 2, 1},
+		{6, 5, 9, 8, 7, 1, 0, 4, 3, 2}, {7, 6, 5, 9, 8, 2, 1, 0, 4, 3},
+		{8, 7, 6, 5, 9, 3, 2, 1, 0, 4}, {9, 8, 7, 6, 5, 4, 3, 2, 1, 0},
+	}
+	verhoeffP = [8][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, {1, 5, 7, 6, 2, 8, 3, 0, 9, 4},
+		{5, 8, 0, 3, 7, 9, 6, 1, 4, 2}, {8, 9, 1, 6, 0, 4, 3, 5, 2, 7},
+		{9, 4, 5, 3, 1, 2, 6, 8, 7, 0}, {4, 2, 8, 6, 5, 7, 3, 9, 0, 1},
+		{2, 7, 9, 3, 8, 0, 6, 4, 1, 5}, {7, 0, 4, 6, 9, 1, 3, 2, 5, 8},
+	}
+)
+
+// verhoeffValid checks a Verhoeff checksum.
+func verhoeffValid(digits string) bool {
+	c := 0
+	for i := 0; i < len(digits); i++ {
+		d := int(digits[len(digits)-1-i] - '0')
+		c = verhoeffD[c][verhoeffP[i%8][d]]
+	}
+	return c == 0
+}
+
+// String renders a match for evidence fields, e.g. "credit_card/visa ************1111".
+func (m PIIMatch) String() string {
+	label := m.Type
+	if m.Subtype != "" {
+		label += "/" + m.Subtype
+	}
+	return fmt.Sprintf("%s %s", label, m.Redacted)
+}
@@ -0,0 +1,110 @@
+package security
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClassifyPII(t *testing.T) {
+	classifier := NewPIIClassifier()
+
+	tests := []struct {
+		text    string
+		piiType string
+		subtype string
+	}{
+		{"contact jane.doe@acme.io today", PIIEmail, ""},
+		{"call +1 415 555 0132", PIIPhone, ""},
+		{"card 4111 1111 1111 1111 exp 12/29", PIICreditCard, "visa"},
+		{"card 5500-0000-0000-0004", PIICreditCard, "mastercard"},
+		{"pay to GB82 WEST 1234 5698 7654 32", PIIIBAN, "GB"},
+		{"iban DE89370400440532013000", PIIIBAN, "DE"},
+		{"ssn 123-45-6789", PIINationalID, "us_ssn"},
+		{"NI number AB 12 34 56 C", PIINationalID, "uk_nino"},
+		{"SIN 046 454 286", PIINationalID, "ca_sin"},
+		{"DNI 12345678Z", PIINationalID, "es_dni"},
+		{"aadhaar 2341 2341 2346", PIINationalID, "in_aadhaar"},
+		{"ship to 221 Baker Street", PIIAddress, "street"},
+		{"postcode SW1A 1AA", PIIAddress, "uk_postcode"},
+		{"she was diagnosed with diabetes", PIIHealth, ""},
+	}
+	for _, tt := range tests {
+		matches := classifier.Classify(tt.text)
+		found := false
+		for _, m := range matches {
+			if m.Type == tt.piiType && m.Subtype == tt.subtype {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("%q: expected %s/%s, got %+v", tt.text, tt.piiType, tt.subtype, matches)
+		}
+	}
+}
+
+func TestClassifyPIIRejectsInvalid(t *testing.T) {
+	classifier := NewPIIClassifier()
+	tests := []struct {
+		text    string
+		piiType string // "" means nothing may match
+	}{
+		{"card 4111 1111 1111 1112", ""},              // fails Luhn
+		{"iban GB82 WEST 1234 5698 7654 33", PIIIBAN}, // fails mod 97
+		{"ssn 666-12-3456", ""},                       // never issued
+		{"DNI 12345678A", ""},                         // wrong control letter
+		{"aadhaar 2341 2341 2345", PIINationalID},     // fails Verhoeff
+		{"mail admin@example.com for access", ""},     // documentation domain
+		{"server 192.168.100.200 version 1.2", ""},    // not a phone number
+		{"order 12345 shipped on 2024-01-15", ""},
+	}
+	for _, tt := range tests {
+		for _, m := range classifier.Classify(tt.text) {
+			if tt.piiType == "" || m.Type == tt.piiType {
+				t.Errorf("%q: unexpected match %+v", tt.text, m)
+			}
+		}
+	}
+}
+
+func TestPIIRegulationsAndRedaction(t *testing.T) {
+	classifier := NewPIIClassifier()
+
+	matches := classifier.Classify("Patient jane@clinic.org, SSN 123-45-6789, prescribed insulin")
+	if got := PIICounts(matches); got[PIIEmail] != 1 || got[PIINationalID] != 1 || got[PIIHealth] != 2 {
+		t.Fatalf("unexpected counts %v", got)
+	}
+	// Identifiers alongside health terms are protected health information
+	for _, m := range matches {
+		if !contains(m.Regulations, RegulationHIPAA) {
+			t.Errorf("%s not tagged HIPAA: %v", m.Type, m.Regulations)
+		}
+		if m.Type != PIIHealth && strings.Contains(m.Redacted, m.Value) {
+			t.Errorf("%s not redacted: %q", m.Type, m.Redacted)
+		}
+	}
+
+	// Everyday words are not health data, and a health term only tags
+	// identifiers near it
+	for _, m := range classifier.Classify("Be patient with the depression in the market; contact bob@acme.io") {
+		if m.Type == PIIHealth || contains(m.Regulations, RegulationHIPAA) {
+			t.Errorf("unexpected health match %+v", m)
+		}
+	}
+	far := classifier.Classify("Diagnosed with diabetes." + strings.Repeat(" Unrelated text.", 20) + " Contact bob@acme.io")
+	for _, m := range far {
+		if m.Type == PIIEmail && contains(m.Regulations, RegulationHIPAA) {
+			t.Errorf("distant identifier tagged HIPAA: %v", m.Regulations)
+		}
+	}
+
+	card := classifier.Classify("4111111111111111")
+	if len(card) != 1 || card[0].Redacted != "************1111" || !card[0].Validated {
+		t.Errorf("unexpected card match %+v", card)
+	}
+	if got := PIIRegulations(card); strings.Join(got, ",") != "GDPR,PCI-DSS" {
+		t.Errorf("unexpected regulations %v", got)
+	}
+	if PIISeverity(PIICreditCard) != "high" || PIISeverity(PIIEmail) != "medium" {
+		t.Error("unexpected severities")
+	}
+}
@@ -0,0 +1,240 @@
+package security
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// BuiltinRulePackVersion identifies the rules compiled into strigoi. Bump it
+// whenever a default rule is added, removed or changed.
+const BuiltinRulePackVersion = "1.1.0"
+
+// BuiltinRulePackName is the pack name of the default rules.
+const BuiltinRulePackName = "builtin"
+
+// RuleExamples are embedded test cases: every positive example must match
+// the rule's pattern and no negative example may.
+type RuleExamples struct {
+	Positive []string `json:"positive,omitempty"`
+	Negative []string `json:"negative,omitempty"`
+}
+
+// RulePack is a versioned set of rules distributed as one file.
+type RulePack struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+	Rules       []Rule `json:"rules"`
+}
+
+// RulePackInfo identifies a loaded rule pack. It is recorded in scan
+// output so results can be traced to the exact rules that produced them.
+type RulePackInfo struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Checksum string `json:"checksum"` // sha256 of the pack file
+	Source   string `json:"source"`   // file path, or "builtin"
+	Rules    int    `json:"rules"`
+}
+
+// ReadRulePack reads a rule pack from a JSON or YAML file. A bare JSON
+// array of rules is accepted as an unversioned pack named after the file.
+func ReadRulePack(path string) (*RulePack, *RulePackInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read rules file: %w", err)
+	}
+
+	content := bytes.TrimSpace(data)
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		// The YAML subset yields strings, lists and maps, which map onto
+		// the rule fields once re-encoded as JSON
+		parsed, err := parseYAMLSubset(data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse rules file: %w", err)
+		}
+		if content, err = json.Marshal(parsed); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse rules file: %w", err)
+		}
+	}
+
+	pack := &RulePack{}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if bytes.HasPrefix(content, []byte("[")) {
+		err = decoder.Decode(&pack.Rules)
+	} else {
+		err = decoder.Decode(pack)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse rules file: %w", err)
+	}
+
+	if pack.Name == "" {
+		pack.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if pack.Version == "" {
+		pack.Version = "unversioned"
+	}
+
+	sum := sha256.Sum256(data)
+	info := &RulePackInfo{
+		Name:     pack.Name,
+		Version:  pack.Version,
+		Checksum: "sha256:" + hex.EncodeToString(sum[:]),
+		Source:   path,
+		Rules:    len(pack.Rules),
+	}
+	return pack, info, nil
+}
+
+// builtinPackInfo describes the default rules; the checksum covers their
+// canonical JSON encoding.
+func builtinPackInfo(rules []Rule) RulePackInfo {
+	data, _ := json.Marshal(rules)
+	sum := sha256.Sum256(data)
+	return RulePackInfo{
+		Name:     BuiltinRulePackName,
+		Version:  BuiltinRulePackVersion,
+		Checksum: "sha256:" + hex.EncodeToString(sum[:]),
+		Source:   BuiltinRulePackName,
+		Rules:    len(rules),
+	}
+}
+
+// RuleIssue is a problem found by LintRules.
+type RuleIssue struct {
+	RuleID  string `json:"rule_id"`
+	Level   string `json:"level"` // "error" or "warning"
+	Message string `json:"message"`
+}
+
+var (
+	validRuleSeverities = []string{"critical", "high", "medium", "low", "info"}
+	fileTypePattern     = regexp.MustCompile(`^\.[A-Za-z0-9_.-]+$`)
+)
+
+// LintRules checks rules for problems that would make them fail to load or
+// silently never match. Errors prevent loading; warnings do not.
+func LintRules(rules []Rule) []RuleIssue {
+	var issues []RuleIssue
+	add := func(id, level, format string, args ...interface{}) {
+		issues = append(issues, RuleIssue{RuleID: id, Level: level, Message: fmt.Sprintf(format, args...)})
+	}
+
+	seen := make(map[string]bool)
+	for i, rule := range rules {
+		id := rule.ID
+		if id == "" {
+			id = fmt.Sprintf("#%d", i+1)
+			add(id, "error", "rule ID is empty")
+		} else if seen[id] {
+			add(id, "error", "duplicate rule ID")
+		}
+		seen[id] = true
+
+		if rule.Pattern == "" {
+			add(id, "error", "pattern is empty")
+		} else if _, err := regexp.Compile(rule.Pattern); err != nil {
+			add(id, "error", "pattern does not compile: %v", err)
+		}
+		if rule.PathPattern != "" {
+			if _, err := regexp.Compile(rule.PathPattern); err != nil {
+				add(id, "error", "path_pattern does not compile: %v", err)
+			}
+		}
+		if !contains(validRuleSeverities, rule.Severity) {
+			add(id, "error", "severity %q is not one of %s", rule.Severity, strings.Join(validRuleSeverities, ", "))
+		}
+		for _, fileType := range rule.FileTypes {
+			if !fileTypePattern.MatchString(fileType) {
+				add(id, "error", "file type %q must be an extension such as \".json\"", fileType)
+			}
+		}
+
+		if len(rule.FileTypes) == 0 && rule.PathPattern == "" {
+			add(id, "warning", "no file_types or path_pattern; the rule applies to every file")
+		}
+		if rule.Name == "" || rule.Description == "" || rule.Remediation == "" {
+			add(id, "warning", "name, description and remediation should all be set")
+		}
+		if len(rule.Examples.Positive) == 0 {
+			add(id, "warning", "no positive examples; the rule cannot be tested")
+		}
+	}
+	return issues
+}
+
+// RuleTestResult is the outcome of running a rule's embedded examples.
+type RuleTestResult struct {
+	RuleID   string   `json:"rule_id"`
+	Examples int      `json:"examples"`
+	Failures []string `json:"failures,omitempty"`
+}
+
+// Passed reports whether every example behaved as declared.
+func (r RuleTestResult) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// RunRuleExamples runs each rule's positive and negative examples against its
+// pattern.
+func RunRuleExamples(rules []Rule) []RuleTestResult {
+	results := make([]RuleTestResult, 0, len(rules))
+	for _, rule := range rules {
+		result := RuleTestResult{
+			RuleID:   rule.ID,
+			Examples: len(rule.Examples.Positive) + len(rule.Examples.Negative),
+		}
+
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			result.Failures = append(result.Failures, fmt.Sprintf("pattern does not compile: %v", err))
+			results = append(results, result)
+			continue
+		}
+		for _, example := range rule.Examples.Positive {
+			if !pattern.MatchString(example) {
+				result.Failures = append(result.Failures, fmt.Sprintf("positive example did not match: %q", example))
+			}
+		}
+		for _, example := range rule.Examples.Negative {
+			if loc := pattern.FindStringIndex(example); loc != nil {
+				result.Failures = append(result.Failures, fmt.Sprintf("negative example matched %q: %q", example[loc[0]:loc[1]], example))
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// Rules returns the loaded rules ordered by ID.
+func (sre *RuleEngine) Rules() []Rule {
+	rules := make([]Rule, len(sre.rules))
+	copy(rules, sre.rules)
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return rules
+}
+
+// Rule returns the rule with the given ID.
+func (sre *RuleEngine) Rule(id string) (Rule, bool) {
+	if compiled, ok := sre.compiledRules[id]; ok {
+		return compiled.Rule, true
+	}
+	return Rule{}, false
+}
+
+// Packs returns the rule packs loaded into the engine, built-in first.
+func (sre *RuleEngine) Packs() []RulePackInfo {
+	packs := make([]RulePackInfo, len(sre.packs))
+	copy(packs, sre.packs)
+	return packs
+}
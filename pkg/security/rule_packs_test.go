@@ -0,0 +1,135 @@
+package security
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuiltinRuleExamples(t *testing.T) {
+	engine := NewRuleEngine()
+	for _, result := range RunRuleExamples(engine.Rules()) {
+		if result.Examples == 0 {
+			t.Errorf("%s has no examples", result.RuleID)
+		}
+		for _, failure := range result.Failures {
+			t.Errorf("%s: %s", result.RuleID, failure)
+		}
+	}
+	for _, issue := range LintRules(engine.Rules()) {
+		t.Errorf("%s: %s: %s", issue.RuleID, issue.Level, issue.Message)
+	}
+
+	packs := engine.Packs()
+	if len(packs) != 1 || packs[0].Name != BuiltinRulePackName || packs[0].Version != BuiltinRulePackVersion ||
+		!strings.HasPrefix(packs[0].Checksum, "sha256:") || packs[0].Rules != len(engine.Rules()) {
+		t.Errorf("unexpected built-in pack: %+v", packs)
+	}
+}
+
+func TestLoadRulePackFormats(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"legacy.json": `[{"id": "ORG-001", "name": "Internal Host", "severity": "low", "pattern": "corp\\.internal", "file_types": [".json"]}]`,
+		"org.json": `{"name": "org", "version": "2.3.0", "rules": [
+			{"id": "MCP-NETWORK-002", "name": "Privileged Port", "severity": "info", "pattern": "port: 80\\b", "file_types": [".yaml"]}
+		]}`,
+		"org.yaml": `name: org-yaml
+version: 1.0.0
+rules:
+  - id: ORG-002
+    name: Staging Endpoint
+    category: insecure_config
+    severity: medium
+    pattern: '(?i)staging\.example\.com'
+    file_types: [.json, .yaml]
+    description: Staging endpoint configured
+    remediation: Point production clients at production
+    examples:
+      positive:
+        - "url: https://staging.example.com/mcp"
+      negative:
+        - "url: https://example.com/mcp"
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	engine := NewRuleEngine()
+	builtin := len(engine.Rules())
+	for _, name := range []string{"legacy.json", "org.json", "org.yaml"} {
+		if err := engine.LoadRulesFromFile(filepath.Join(dir, name)); err != nil {
+			t.Fatalf("loading %s: %v", name, err)
+		}
+	}
+
+	// MCP-NETWORK-002 is overridden, not duplicated
+	if got := len(engine.Rules()); got != builtin+2 {
+		t.Errorf("expected %d rules, got %d", builtin+2, got)
+	}
+	if rule, _ := engine.Rule("MCP-NETWORK-002"); rule.Severity != "info" || rule.Pack != "org" {
+		t.Errorf("override not applied: %+v", rule)
+	}
+
+	packs := engine.Packs()
+	if len(packs) != 4 {
+		t.Fatalf("expected 4 packs, got %+v", packs)
+	}
+	if packs[1].Name != "legacy" || packs[1].Version != "unversioned" ||
+		packs[2].Version != "2.3.0" || packs[3].Name != "org-yaml" {
+		t.Errorf("unexpected packs: %+v", packs)
+	}
+
+	// Reloading a pack, as every south run does, replaces it
+	if err := engine.LoadRulesFromFile(filepath.Join(dir, "org.json")); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(engine.Packs()); got != 4 {
+		t.Errorf("expected 4 packs after reload, got %d", got)
+	}
+
+	rule, ok := engine.Rule("ORG-002")
+	if !ok || len(rule.FileTypes) != 2 || rule.Pattern != `(?i)staging\.example\.com` {
+		t.Fatalf("unexpected YAML rule: %+v", rule)
+	}
+	if results := RunRuleExamples([]Rule{rule}); !results[0].Passed() {
+		t.Errorf("YAML rule examples failed: %v", results[0].Failures)
+	}
+	if findings := engine.ScanContent(`{"url": "https://staging.example.com/mcp"}`, "/cfg.json"); !hasRule(findings, "ORG-002") {
+		t.Errorf("loaded rule did not fire: %+v", findings)
+	}
+}
+
+func TestLintAndTestRules(t *testing.T) {
+	rules := []Rule{
+		{ID: "BAD-001", Name: "Broken", Severity: "urgent", Pattern: `(unclosed`, FileTypes: []string{"json"}},
+		{ID: "BAD-001", Name: "Dup", Severity: "low", Pattern: `x`, FileTypes: []string{".json"},
+			Description: "d", Remediation: "r", Examples: RuleExamples{Positive: []string{"y"}, Negative: []string{"x"}}},
+	}
+
+	levels := make(map[string]int)
+	for _, issue := range LintRules(rules) {
+		levels[issue.Level]++
+	}
+	// bad regex, bad severity, bad file type, duplicate ID
+	if levels["error"] != 4 {
+		t.Errorf("expected 4 errors, got %+v", LintRules(rules))
+	}
+
+	results := RunRuleExamples(rules)
+	if results[0].Passed() || results[1].Passed() || len(results[1].Failures) != 2 {
+		t.Errorf("unexpected test results: %+v", results)
+	}
+
+	path := filepath.Join(t.TempDir(), "typo.json")
+	if err := os.WriteFile(path, []byte(`[{"id": "X-1", "patern": "x"}]`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ReadRulePack(path); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+}
@@ -0,0 +1,381 @@
+package security
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Socket is an open socket read from /proc/net, attributed to the process
+// holding it when that process is visible to us.
+type Socket struct {
+	Protocol      string `json:"protocol"` // "tcp", "tcp6", "udp", "udp6", "unix"
+	LocalAddress  string `json:"local_address,omitempty"`
+	LocalPort     int    `json:"local_port,omitempty"`
+	RemoteAddress string `json:"remote_address,omitempty"`
+	RemotePort    int    `json:"remote_port,omitempty"`
+	State         string `json:"state"`          // "LISTEN", "ESTABLISHED", ...; "UNCONN" for unbound UDP
+	Path          string `json:"path,omitempty"` // unix sockets only
+	Inode         uint64 `json:"inode"`
+	UID           int    `json:"uid"`
+	PID           int    `json:"pid,omitempty"`
+	ProcessName   string `json:"process_name,omitempty"`
+	// Holders lists every process holding a socket shared after fork or
+	// fd passing; PID and ProcessName are the first of them.
+	Holders []SocketHolder `json:"holders,omitempty"`
+}
+
+// SocketHolder is a process holding a socket.
+type SocketHolder struct {
+	PID         int    `json:"pid"`
+	ProcessName string `json:"process_name,omitempty"`
+}
+
+// PIDs returns the processes holding the socket.
+func (s Socket) PIDs() []int {
+	if len(s.Holders) == 0 {
+		if s.PID == 0 {
+			return nil
+		}
+		return []int{s.PID}
+	}
+	pids := make([]int, 0, len(s.Holders))
+	for _, holder := range s.Holders {
+		pids = append(pids, holder.PID)
+	}
+	return pids
+}
+
+// HeldBy reports whether pid holds the socket.
+func (s Socket) HeldBy(pid int) bool {
+	for _, holder := range s.PIDs() {
+		if holder == pid {
+			return true
+		}
+	}
+	return false
+}
+
+// Listening reports whether the socket accepts connections or datagrams.
+func (s Socket) Listening() bool {
+	return s.State == "LISTEN" || s.State == "UNCONN"
+}
+
+// Endpoint renders the local side as address:port, or the path of a unix
+// socket.
+func (s Socket) Endpoint() string {
+	if s.Protocol == "unix" {
+		return s.Path
+	}
+	return net.JoinHostPort(s.LocalAddress, strconv.Itoa(s.LocalPort))
+}
+
+// SocketInventory is a snapshot of the sockets in the current network
+// namespace.
+type SocketInventory struct {
+	Sockets []Socket `json:"sockets"`
+}
+
+// ReadSocketInventory enumerates TCP, UDP and unix sockets from /proc/net
+// and maps their inodes to PIDs through /proc/<pid>/fd. Sockets owned by
+// processes we cannot inspect are kept with PID 0.
+func ReadSocketInventory() (*SocketInventory, error) {
+	return readSocketInventory("/proc")
+}
+
+func readSocketInventory(procRoot string) (*SocketInventory, error) {
+	inventory := &SocketInventory{}
+	read := 0
+	for _, protocol := range []string{"tcp", "tcp6", "udp", "udp6"} {
+		sockets, err := readInetSockets(filepath.Join(procRoot, "net", protocol), protocol)
+		if err != nil {
+			continue // tcp6/udp6 are absent without IPv6
+		}
+		read++
+		inventory.Sockets = append(inventory.Sockets, sockets...)
+	}
+	if sockets, err := readUnixSockets(filepath.Join(procRoot, "net", "unix")); err == nil {
+		read++
+		inventory.Sockets = append(inventory.Sockets, sockets...)
+	}
+	if read == 0 {
+		return nil, fmt.Errorf("cannot read socket tables from %s/net", procRoot)
+	}
+
+	owners := socketOwners(procRoot)
+	for i := range inventory.Sockets {
+		holders := owners[inventory.Sockets[i].Inode]
+		if len(holders) == 0 {
+			continue
+		}
+		inventory.Sockets[i].PID = holders[0].PID
+		inventory.Sockets[i].ProcessName = holders[0].ProcessName
+		if len(holders) > 1 {
+			inventory.Sockets[i].Holders = holders
+		}
+	}
+	return inventory, nil
+}
+
+// ForPID returns the sockets held by a process.
+func (inv *SocketInventory) ForPID(pid int) []Socket {
+	var sockets []Socket
+	for _, s := range inv.Sockets {
+		if s.HeldBy(pid) {
+			sockets = append(sockets, s)
+		}
+	}
+	return sockets
+}
+
+// ByInode returns the socket with the given inode.
+func (inv *SocketInventory) ByInode(inode uint64) (Socket, bool) {
+	for _, s := range inv.Sockets {
+		if s.Inode == inode {
+			return s, true
+		}
+	}
+	return Socket{}, false
+}
+
+// Listeners returns the listening sockets, optionally restricted to a port.
+func (inv *SocketInventory) Listeners(port int) []Socket {
+	var sockets []Socket
+	for _, s := range inv.Sockets {
+		if s.Listening() && s.Protocol != "unix" && (port == 0 || s.LocalPort == port) {
+			sockets = append(sockets, s)
+		}
+	}
+	return sockets
+}
+
+// PIDsForPort returns the processes listening on a TCP or UDP port.
+func (inv *SocketInventory) PIDsForPort(port int) []int {
+	var pids []int
+	for _, s := range inv.Listeners(port) {
+		for _, pid := range s.PIDs() {
+			pids = appendPID(pids, pid)
+		}
+	}
+	return pids
+}
+
+// PIDsForUnixPath returns the processes holding a unix socket bound to path.
+func (inv *SocketInventory) PIDsForUnixPath(path string) []int {
+	var pids []int
+	for _, s := range inv.Sockets {
+		if s.Protocol == "unix" && s.Path == path {
+			for _, pid := range s.PIDs() {
+				pids = appendPID(pids, pid)
+			}
+		}
+	}
+	return pids
+}
+
+func appendPID(pids []int, pid int) []int {
+	if pid == 0 {
+		return pids
+	}
+	for _, existing := range pids {
+		if existing == pid {
+			return pids
+		}
+	}
+	pids = append(pids, pid)
+	sort.Ints(pids)
+	return pids
+}
+
+// tcpStates maps the kernel's TCP state numbers (include/net/tcp_states.h).
+var tcpStates = map[string]string{
+	"01": "ESTABLISHED",
+	"02": "SYN_SENT",
+	"03": "SYN_RECV",
+	"04": "FIN_WAIT1",
+	"05": "FIN_WAIT2",
+	"06": "TIME_WAIT",
+	"07": "CLOSE",
+	"08": "CLOSE_WAIT",
+	"09": "LAST_ACK",
+	"0A": "LISTEN",
+	"0B": "CLOSING",
+}
+
+// readInetSockets parses /proc/net/{tcp,tcp6,udp,udp6}:
+//
+//	sl  local_address rem_address   st tx_queue:rx_queue tr:tm->when retrnsmt   uid  timeout inode
+//	0: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 41893
+func readInetSockets(path, protocol string) ([]Socket, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var sockets []Socket
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		localAddr, localPort, err := parseProcNetAddress(fields[1])
+		if err != nil {
+			continue
+		}
+		remoteAddr, remotePort, err := parseProcNetAddress(fields[2])
+		if err != nil {
+			continue
+		}
+		inode, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil {
+			continue
+		}
+		uid, _ := strconv.Atoi(fields[7])
+
+		state := tcpStates[strings.ToUpper(fields[3])]
+		if strings.HasPrefix(protocol, "udp") {
+			// UDP has no LISTEN; an unconnected bound socket receives from anyone
+			if state == "CLOSE" && remotePort == 0 {
+				state = "UNCONN"
+			}
+		}
+
+		sockets = append(sockets, Socket{
+			Protocol:      protocol,
+			LocalAddress:  localAddr,
+			LocalPort:     localPort,
+			RemoteAddress: remoteAddr,
+			RemotePort:    remotePort,
+			State:         state,
+			Inode:         inode,
+			UID:           uid,
+		})
+	}
+	return sockets, scanner.Err()
+}
+
+// parseProcNetAddress decodes "0100007F:1F90". Addresses are written as
+// 32-bit words in host byte order, which is little-endian on every
+// platform strigoi supports.
+func parseProcNetAddress(field string) (string, int, error) {
+	hostHex, portHex, ok := strings.Cut(field, ":")
+	if !ok {
+		return "", 0, fmt.Errorf("malformed address %q", field)
+	}
+	port, err := strconv.ParseUint(portHex, 16, 16)
+	if err != nil {
+		return "", 0, err
+	}
+	raw, err := hex.DecodeString(hostHex)
+	if err != nil || (len(raw) != net.IPv4len && len(raw) != net.IPv6len) {
+		return "", 0, fmt.Errorf("malformed address %q", field)
+	}
+	ip := make(net.IP, len(raw))
+	for i := 0; i < len(raw); i += 4 {
+		binary.BigEndian.PutUint32(ip[i:], binary.LittleEndian.Uint32(raw[i:]))
+	}
+	return ip.String(), int(port), nil
+}
+
+// readUnixSockets parses /proc/net/unix:
+//
+//	Num       RefCount Protocol Flags    Type St Inode Path
+//	0000000000000000: 00000002 00000000 00010000 0001 01 23456 /run/app.sock
+func readUnixSockets(path string) ([]Socket, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	const acceptConnections = 0x10000 // __SO_ACCEPTCON
+
+	var sockets []Socket
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 7 {
+			continue
+		}
+		flags, _ := strconv.ParseUint(fields[3], 16, 32)
+		inode, err := strconv.ParseUint(fields[6], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		state := "UNCONN"
+		switch {
+		case flags&acceptConnections != 0:
+			state = "LISTEN"
+		case fields[5] == "03":
+			state = "ESTABLISHED"
+		}
+
+		socket := Socket{Protocol: "unix", State: state, Inode: inode}
+		if len(fields) >= 8 {
+			socket.Path = strings.Join(fields[7:], " ")
+		}
+		sockets = append(sockets, socket)
+	}
+	return sockets, scanner.Err()
+}
+
+// socketOwners maps socket inodes to every process holding them, by PID,
+// by reading the "socket:[inode]" links under /proc/<pid>/fd.
+func socketOwners(procRoot string) map[uint64][]SocketHolder {
+	owners := make(map[uint64][]SocketHolder)
+
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return owners
+	}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		fdDir := filepath.Join(procRoot, entry.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue // exited, or owned by another user
+		}
+
+		var name string
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			inode, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]"), 10, 64)
+			if err != nil {
+				continue
+			}
+			holders := owners[inode]
+			if n := len(holders); n > 0 && holders[n-1].PID == pid {
+				continue // another fd of the same process
+			}
+			if name == "" {
+				comm, _ := os.ReadFile(filepath.Join(procRoot, entry.Name(), "comm"))
+				name = strings.TrimSpace(string(comm))
+			}
+			owners[inode] = append(holders, SocketHolder{PID: pid, ProcessName: name})
+		}
+	}
+
+	// /proc lists PIDs in name order, not numeric order
+	for _, holders := range owners {
+		sort.Slice(holders, func(i, j int) bool { return holders[i].PID < holders[j].PID })
+	}
+	return owners
+}
@@ -0,0 +1,169 @@
+package security
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// fakeProc builds a minimal /proc tree with socket tables and fd links.
+func fakeProc(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link := func(pid, fd, inode string) {
+		t.Helper()
+		dir := filepath.Join(root, pid, "fd")
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink("socket:["+inode+"]", filepath.Join(dir, fd)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(filepath.Join(root, "net", "tcp"),
+		"  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"+
+			"   0: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 1001 1 0000000000000000 100 0 0 10 0\n"+
+			"   1: 00000000:1E61 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1002 1 0000000000000000 100 0 0 10 0\n"+
+			"   2: 0100007F:1F90 0100007F:D431 01 00000000:00000000 00:00000000 00000000  1000        0 1003 1 0000000000000000 20 4 30 10 -1\n")
+	write(filepath.Join(root, "net", "tcp6"),
+		"  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"+
+			"   0: 00000000000000000000000001000000:0BB8 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 1004 1 0000000000000000 100 0 0 10 0\n")
+	write(filepath.Join(root, "net", "udp"),
+		"   sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops\n"+
+			"  10: 00000000:14E9 00000000:0000 07 00000000:00000000 00:00000000 00000000   101        0 1005 2 0000000000000000 0\n")
+	write(filepath.Join(root, "net", "unix"),
+		"Num       RefCount Protocol Flags    Type St Inode Path\n"+
+			"0000000000000000: 00000002 00000000 00010000 0001 01 1006 /run/mcp/server.sock\n"+
+			"0000000000000000: 00000003 00000000 00000000 0001 03 1007\n")
+
+	write(filepath.Join(root, "200", "comm"), "mcp-server\n")
+	link("200", "3", "1001")
+	link("200", "4", "1003")
+	link("200", "5", "1006")
+	write(filepath.Join(root, "300", "comm"), "neo4j\n")
+	link("300", "7", "1002")
+	link("300", "8", "1004")
+
+	return root
+}
+
+func TestReadSocketInventory(t *testing.T) {
+	inventory, err := readSocketInventory(fakeProc(t))
+	if err != nil {
+		t.Fatalf("readSocketInventory failed: %v", err)
+	}
+	if len(inventory.Sockets) != 7 {
+		t.Fatalf("expected 7 sockets, got %+v", inventory.Sockets)
+	}
+
+	expected := []Socket{
+		{Protocol: "tcp", LocalAddress: "127.0.0.1", LocalPort: 8080, RemoteAddress: "0.0.0.0", State: "LISTEN", Inode: 1001, UID: 1000, PID: 200, ProcessName: "mcp-server"},
+		{Protocol: "tcp", LocalAddress: "0.0.0.0", LocalPort: 7777, RemoteAddress: "0.0.0.0", State: "LISTEN", Inode: 1002, PID: 300, ProcessName: "neo4j"},
+		{Protocol: "tcp", LocalAddress: "127.0.0.1", LocalPort: 8080, RemoteAddress: "127.0.0.1", RemotePort: 54321, State: "ESTABLISHED", Inode: 1003, UID: 1000, PID: 200, ProcessName: "mcp-server"},
+		{Protocol: "tcp6", LocalAddress: "::1", LocalPort: 3000, RemoteAddress: "::", State: "LISTEN", Inode: 1004, UID: 1000, PID: 300, ProcessName: "neo4j"},
+		{Protocol: "udp", LocalAddress: "0.0.0.0", LocalPort: 5353, RemoteAddress: "0.0.0.0", State: "UNCONN", Inode: 1005, UID: 101},
+		{Protocol: "unix", State: "LISTEN", Path: "/run/mcp/server.sock", Inode: 1006, PID: 200, ProcessName: "mcp-server"},
+		{Protocol: "unix", State: "ESTABLISHED", Inode: 1007},
+	}
+	for i, want := range expected {
+		if !reflect.DeepEqual(inventory.Sockets[i], want) {
+			t.Errorf("socket %d = %+v, want %+v", i, inventory.Sockets[i], want)
+		}
+	}
+
+	if pids := inventory.PIDsForPort(8080); !reflect.DeepEqual(pids, []int{200}) {
+		t.Errorf("PIDsForPort(8080) = %v", pids)
+	}
+	if pids := inventory.PIDsForUnixPath("/run/mcp/server.sock"); !reflect.DeepEqual(pids, []int{200}) {
+		t.Errorf("PIDsForUnixPath = %v", pids)
+	}
+	if s, ok := inventory.ByInode(1003); !ok || s.RemotePort != 54321 {
+		t.Errorf("ByInode(1003) = %+v, %v", s, ok)
+	}
+	if _, ok := inventory.ByInode(9999); ok {
+		t.Error("ByInode found an unknown inode")
+	}
+	if listeners := inventory.Listeners(0); len(listeners) != 4 {
+		t.Errorf("expected 4 TCP/UDP listeners, got %+v", listeners)
+	}
+	if _, err := readSocketInventory(t.TempDir()); err == nil {
+		t.Error("expected an error without socket tables")
+	}
+}
+
+func TestReadSocketInventorySharedSockets(t *testing.T) {
+	root := fakeProc(t)
+
+	// A forked worker (PID 1000 sorts before 200 by name) shares the
+	// listener and the unix socket of its parent
+	if err := os.MkdirAll(filepath.Join(root, "1000", "fd"), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "1000", "comm"), []byte("worker\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for fd, inode := range map[string]string{"3": "1001", "4": "1006", "5": "1006"} {
+		if err := os.Symlink("socket:["+inode+"]", filepath.Join(root, "1000", "fd", fd)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	inventory, err := readSocketInventory(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener, _ := inventory.ByInode(1001)
+	want := []SocketHolder{{PID: 200, ProcessName: "mcp-server"}, {PID: 1000, ProcessName: "worker"}}
+	if listener.PID != 200 || !reflect.DeepEqual(listener.Holders, want) {
+		t.Errorf("listener holders = %d %+v, want %+v", listener.PID, listener.Holders, want)
+	}
+	if pids := inventory.PIDsForPort(8080); !reflect.DeepEqual(pids, []int{200, 1000}) {
+		t.Errorf("PIDsForPort(8080) = %v", pids)
+	}
+	if pids := inventory.PIDsForUnixPath("/run/mcp/server.sock"); !reflect.DeepEqual(pids, []int{200, 1000}) {
+		t.Errorf("PIDsForUnixPath = %v", pids)
+	}
+	if sockets := inventory.ForPID(1000); len(sockets) != 2 {
+		t.Errorf("ForPID(1000) = %+v", sockets)
+	}
+	if s, _ := inventory.ByInode(1004); s.Holders != nil {
+		t.Errorf("unshared socket has holders: %+v", s.Holders)
+	}
+}
+
+func TestAnalyzeNetworkExposureFromInventory(t *testing.T) {
+	inventory, err := readSocketInventory(fakeProc(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scanner := NewMCPScanner(NewSecureExecutor())
+	scanner.socketsOnce.Do(func() { scanner.sockets = inventory })
+
+	local := scanner.analyzeNetworkExposure(200)
+	if !reflect.DeepEqual(local.ListeningPorts, []int{8080}) || local.ExposureLevel != "local" ||
+		!reflect.DeepEqual(local.UnixSockets, []string{"/run/mcp/server.sock"}) {
+		t.Errorf("unexpected exposure for pid 200: %+v", local)
+	}
+
+	wide := scanner.analyzeNetworkExposure(300)
+	if !reflect.DeepEqual(wide.ListeningPorts, []int{7777, 3000}) || wide.ExposureLevel != "network" ||
+		wide.BindAddress != "0.0.0.0" || len(wide.RiskFactors) != 1 {
+		t.Errorf("unexpected exposure for pid 300: %+v", wide)
+	}
+	if port := scanner.primaryPort(wide.ListeningPorts); port != 3000 {
+		t.Errorf("expected the MCP port 3000 to be preferred, got %d", port)
+	}
+}
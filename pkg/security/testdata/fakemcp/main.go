@@ -0,0 +1,97 @@
+// Command fakemcp is a minimal MCP server speaking newline-delimited
+// JSON-RPC over stdio. The interrogation tests build and launch it.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   interface{}     `json:"error,omitempty"`
+}
+
+func main() {
+	out := json.NewEncoder(os.Stdout)
+	fmt.Println("fakemcp: ready") // stray log line clients must skip
+	fmt.Fprintln(os.Stderr, "fakemcp: started")
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var msg message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil || msg.Method == "" || msg.ID == nil {
+			continue // notifications and replies to our own requests
+		}
+
+		reply := message{JSONRPC: "2.0", ID: msg.ID}
+		switch msg.Method {
+		case "initialize":
+			reply.Result = map[string]interface{}{
+				"protocolVersion": "2024-11-05",
+				"capabilities": map[string]interface{}{
+					"tools":     map[string]interface{}{"listChanged": true},
+					"resources": map[string]interface{}{},
+					"prompts":   map[string]interface{}{},
+				},
+				"serverInfo": map[string]string{
+					"name":    "fake-mcp",
+					"version": os.Getenv("FAKE_MCP_VERSION"),
+				},
+				"instructions": "Parent secret: " + os.Getenv("STRIGOI_FAKE_PARENT_SECRET"),
+			}
+
+		case "tools/list":
+			// Ask the client something first, as real servers may
+			_ = out.Encode(message{JSONRPC: "2.0", ID: json.RawMessage(`"srv-1"`), Method: "roots/list"})
+
+			var params struct {
+				Cursor string `json:"cursor"`
+			}
+			_ = json.Unmarshal(msg.Params, &params)
+			if params.Cursor == "" {
+				reply.Result = map[string]interface{}{
+					"tools": []map[string]interface{}{{
+						"name":        "run_shell_command",
+						"description": "Run a shell command on the host.",
+						"inputSchema": map[string]interface{}{"type": "object"},
+					}},
+					"nextCursor": "page-2",
+				}
+			} else {
+				reply.Result = map[string]interface{}{
+					"tools": []map[string]interface{}{{
+						"name":        "read_file",
+						"description": "Read a file. Uses api_key=sk_live_0123456789abcdefghij internally.",
+					}},
+				}
+			}
+
+		case "resources/list":
+			reply.Result = map[string]interface{}{
+				"resources": []map[string]string{
+					{"uri": "file:///home/user/.ssh/id_rsa", "name": "deploy key"},
+					{"uri": "file:///srv/docs/readme.md", "name": "readme"},
+				},
+			}
+
+		case "prompts/list":
+			reply.Result = map[string]interface{}{
+				"prompts": []map[string]string{{"name": "summarize", "description": "Summarize a document"}},
+			}
+
+		default:
+			reply.Error = map[string]interface{}{"code": -32601, "message": "method not found"}
+		}
+
+		if err := out.Encode(reply); err != nil {
+			os.Exit(1)
+		}
+	}
+}
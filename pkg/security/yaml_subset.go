@@ -0,0 +1,313 @@
+package security
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseYAMLSubset parses the block-style YAML used by MCP client
+// configurations: nested maps and lists, quoted or plain scalars, flow
+// lists such as [a, "b"], block scalars and comments. Anchors, tags and
+// multi-document streams are not supported. Scalars are returned as
+// strings (null as nil) since callers only need text.
+func parseYAMLSubset(content []byte) (interface{}, error) {
+	p := &yamlParser{source: strings.Split(string(content), "\n")}
+	for number, raw := range p.source {
+		raw = strings.TrimRight(raw, " \t\r")
+		p.source[number] = raw
+		text := strings.TrimLeft(raw, " ")
+		if text == "" || strings.HasPrefix(text, "#") || text == "---" {
+			continue
+		}
+		if strings.HasPrefix(text, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not valid YAML indentation", number+1)
+		}
+		p.lines = append(p.lines, yamlLine{
+			number: number + 1,
+			indent: len(raw) - len(text),
+			text:   stripYAMLComment(text),
+		})
+	}
+
+	if len(p.lines) == 0 {
+		return map[string]interface{}{}, nil
+	}
+
+	value, err := p.parseBlock(p.lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", p.lines[p.pos].number)
+	}
+	return value, nil
+}
+
+// ParseYAML parses the YAML subset described at parseYAMLSubset, for
+// configuration files read outside this package.
+func ParseYAML(content []byte) (interface{}, error) {
+	return parseYAMLSubset(content)
+}
+
+type yamlLine struct {
+	number int
+	indent int
+	text   string
+}
+
+type yamlParser struct {
+	source []string // every line, for block scalars
+	lines  []yamlLine
+	pos    int
+}
+
+func (p *yamlParser) parseBlock(indent int) (interface{}, error) {
+	if isYAMLListItem(p.lines[p.pos].text) {
+		return p.parseList(indent)
+	}
+	return p.parseMap(indent)
+}
+
+func (p *yamlParser) parseList(indent int) ([]interface{}, error) {
+	list := []interface{}{}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent || (line.indent == indent && !isYAMLListItem(line.text)) {
+			break
+		}
+		if line.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", line.number)
+		}
+
+		item := strings.TrimSpace(strings.TrimPrefix(line.text, "-"))
+		switch {
+		case item == "":
+			p.pos++
+			if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+				value, err := p.parseBlock(p.lines[p.pos].indent)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, value)
+			} else {
+				list = append(list, nil)
+			}
+
+		case isYAMLMapEntry(item):
+			// "- key: value" opens a map whose keys align with "key"
+			itemIndent := line.indent + len(line.text) - len(item)
+			p.lines[p.pos] = yamlLine{number: line.number, indent: itemIndent, text: item}
+			value, err := p.parseMap(itemIndent)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+
+		default:
+			list = append(list, parseYAMLScalar(item))
+			p.pos++
+		}
+	}
+	return list, nil
+}
+
+func (p *yamlParser) parseMap(indent int) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent || isYAMLListItem(line.text) {
+			break
+		}
+		if line.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", line.number)
+		}
+
+		key, value, ok := splitYAMLKey(line.text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", line.number)
+		}
+		p.pos++
+
+		switch {
+		case value == "|" || value == "|-" || value == ">" || value == ">-":
+			m[key] = p.blockScalar(indent, strings.HasPrefix(value, ">"))
+		case value != "":
+			m[key] = parseYAMLScalar(value)
+		case p.pos < len(p.lines) && (p.lines[p.pos].indent > indent ||
+			(p.lines[p.pos].indent == indent && isYAMLListItem(p.lines[p.pos].text))):
+			nested, err := p.parseBlock(p.lines[p.pos].indent)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = nested
+		default:
+			m[key] = nil
+		}
+	}
+	return m, nil
+}
+
+// blockScalar joins the lines indented under the "|" or ">" key just
+// parsed. They are read from the source, so blank lines, "#" and extra
+// indentation are kept. A literal block keeps line breaks; a folded one
+// joins lines with spaces and turns blank lines into breaks. Trailing line
+// breaks are dropped.
+func (p *yamlParser) blockScalar(indent int, folded bool) string {
+	var parts []string
+	blockIndent := -1
+	next := p.lines[p.pos-1].number // index of the line after the key
+	for ; next < len(p.source); next++ {
+		raw := p.source[next]
+		text := strings.TrimLeft(raw, " ")
+		if text == "" {
+			parts = append(parts, "")
+			continue
+		}
+		lineIndent := len(raw) - len(text)
+		if lineIndent <= indent {
+			break
+		}
+		if blockIndent < 0 {
+			blockIndent = lineIndent
+		}
+		parts = append(parts, raw[min(lineIndent, blockIndent):])
+	}
+	for p.pos < len(p.lines) && p.lines[p.pos].number <= next {
+		p.pos++
+	}
+	for len(parts) > 0 && parts[len(parts)-1] == "" {
+		parts = parts[:len(parts)-1]
+	}
+
+	if !folded {
+		return strings.Join(parts, "\n")
+	}
+	var b strings.Builder
+	joined := false
+	for _, part := range parts {
+		switch {
+		case part == "":
+			b.WriteByte('\n')
+			joined = false
+		case joined:
+			b.WriteByte(' ')
+			fallthrough
+		default:
+			b.WriteString(part)
+			joined = true
+		}
+	}
+	return b.String()
+}
+
+func isYAMLListItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func isYAMLMapEntry(text string) bool {
+	if strings.HasPrefix(text, "[") || strings.HasPrefix(text, "{") {
+		return false
+	}
+	_, _, ok := splitYAMLKey(text)
+	return ok
+}
+
+// splitYAMLKey splits "key: value" (or "key:") outside of quotes.
+func splitYAMLKey(text string) (string, string, bool) {
+	if text[0] == '"' || text[0] == '\'' {
+		end := strings.IndexByte(text[1:], text[0])
+		if end < 0 || !strings.HasPrefix(text[end+2:], ":") {
+			return "", "", false
+		}
+		rest := text[end+3:]
+		if rest != "" && rest[0] != ' ' {
+			return "", "", false
+		}
+		return text[1 : end+1], strings.TrimSpace(rest), true
+	}
+
+	idx := strings.Index(text, ": ")
+	if idx < 0 {
+		if !strings.HasSuffix(text, ":") {
+			return "", "", false
+		}
+		idx = len(text) - 1
+	}
+	return strings.TrimSpace(text[:idx]), strings.TrimSpace(text[idx+1:]), true
+}
+
+// stripYAMLComment removes a trailing " # comment" outside of quotes.
+func stripYAMLComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || text[i-1] == ' '):
+			return strings.TrimRight(text[:i], " ")
+		}
+	}
+	return text
+}
+
+func parseYAMLScalar(value string) interface{} {
+	switch {
+	case value == "null" || value == "~":
+		return nil
+	case strings.HasPrefix(value, `"`):
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			return unquoted
+		}
+		return strings.Trim(value, `"`)
+	case strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") && len(value) >= 2:
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+	case strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]"):
+		list := []interface{}{}
+		for _, item := range splitYAMLFlow(value[1 : len(value)-1]) {
+			list = append(list, parseYAMLScalar(item))
+		}
+		return list
+	case strings.HasPrefix(value, "{") && strings.HasSuffix(value, "}"):
+		m := make(map[string]interface{})
+		for _, item := range splitYAMLFlow(value[1 : len(value)-1]) {
+			if key, v, ok := splitYAMLKey(item); ok {
+				m[key] = parseYAMLScalar(v)
+			}
+		}
+		return m
+	}
+	return value
+}
+
+// splitYAMLFlow splits the inside of a flow collection on top-level commas.
+func splitYAMLFlow(s string) []string {
+	var items []string
+	var quote byte
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		case c == ',' && depth == 0:
+			items = append(items, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" {
+		items = append(items, last)
+	}
+	return items
+}
@@ -0,0 +1,81 @@
+package security
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseYAMLSubset(t *testing.T) {
+	content := `# assistant config
+name: "My Assistant"
+models:
+- provider: openai   # list at the parent's indent
+  roles: [chat, "edit"]
+rules:
+  - plain rule
+  - description: |
+      Always cite sources.
+      Be brief.
+empty:
+flow: {a: 1, b: 'it''s'}
+url: http://localhost:8080/path#anchor
+`
+
+	parsed, err := parseYAMLSubset([]byte(content))
+	if err != nil {
+		t.Fatalf("parseYAMLSubset failed: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"name": "My Assistant",
+		"models": []interface{}{
+			map[string]interface{}{
+				"provider": "openai",
+				"roles":    []interface{}{"chat", "edit"},
+			},
+		},
+		"rules": []interface{}{
+			"plain rule",
+			map[string]interface{}{"description": "Always cite sources.\nBe brief."},
+		},
+		"empty": nil,
+		"flow":  map[string]interface{}{"a": "1", "b": "it's"},
+		"url":   "http://localhost:8080/path#anchor",
+	}
+
+	got := parsed.(map[string]interface{})
+	for key, want := range expected {
+		if !reflect.DeepEqual(got[key], want) {
+			t.Errorf("%s = %#v, want %#v", key, got[key], want)
+		}
+	}
+	block := `literal: |
+  line1
+
+  # not a comment
+    indented # kept
+  pattern: 'x'
+folded: >
+  one
+  two
+
+  three
+after: yes
+`
+	parsed, err = parseYAMLSubset([]byte(block))
+	if err != nil {
+		t.Fatalf("parseYAMLSubset failed: %v", err)
+	}
+	want := map[string]interface{}{
+		"literal": "line1\n\n# not a comment\n  indented # kept\npattern: 'x'",
+		"folded":  "one two\nthree",
+		"after":   "yes",
+	}
+	if !reflect.DeepEqual(parsed, want) {
+		t.Errorf("block scalars = %#v, want %#v", parsed, want)
+	}
+
+	if _, err := parseYAMLSubset([]byte("a: 1\n   b: 2\n")); err == nil {
+		t.Error("expected an indentation error")
+	}
+}